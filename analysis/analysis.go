@@ -0,0 +1,182 @@
+// analysis/analysis.go
+// Package analysis summarizes a point cloud: its size and extent, how
+// closely and evenly its points are spaced, and the range of each
+// attribute, for judging whether a scan is dense enough for a task.
+package analysis
+
+import (
+	"math"
+
+	"github.com/sbecker11/webgl-point-cloud/pointcloud"
+	"github.com/sbecker11/webgl-point-cloud/spatial"
+)
+
+// Options controls which points the spacing statistics are measured at.
+type Options struct {
+	// Samples is the number of points, spread evenly through the cloud,
+	// whose neighbours are measured; 10000 when zero. All points are
+	// measured in smaller clouds.
+	Samples int
+	// Bins is the number of density histogram bins; 20 when zero.
+	Bins int
+}
+
+// Histogram counts values in Len(Counts) equal bins from Min to Max.
+type Histogram struct {
+	Min, Max float64
+	Counts   []int
+}
+
+// AttributeStats is the range and mean of one component of an attribute.
+type AttributeStats struct {
+	Name      string
+	Component int
+	Min, Max  float64
+	Mean      float64
+}
+
+// Stats summarizes a cloud.
+type Stats struct {
+	Points int
+	Min    [3]float64
+	Max    [3]float64
+	// MeanSpacing is the mean distance from a point to its nearest
+	// neighbour, and MinSpacing and MaxSpacing its extremes, over the
+	// sampled points.
+	MeanSpacing float64
+	MinSpacing  float64
+	MaxSpacing  float64
+	// DensityRadius is the radius local density is measured in: four times
+	// MeanSpacing.
+	DensityRadius float64
+	// MeanDensity is the mean number of points per unit area around the
+	// sampled points, counting the neighbours within DensityRadius over
+	// the area of a disc of that radius, as suits points sampled from a
+	// surface. Density is its distribution.
+	MeanDensity float64
+	Density     Histogram
+	// Attributes holds every component of every attribute except
+	// position, whose range is Min and Max.
+	Attributes []AttributeStats
+}
+
+// Compute returns the statistics of c.
+func Compute(c *pointcloud.Cloud, opts Options) Stats {
+	if opts.Samples <= 0 {
+		opts.Samples = 10000
+	}
+	if opts.Bins <= 0 {
+		opts.Bins = 20
+	}
+	s := Stats{Points: c.Len()}
+	if s.Points == 0 {
+		return s
+	}
+	min, max := c.Bounds()
+	for a := 0; a < 3; a++ {
+		s.Min[a], s.Max[a] = float64(min[a]), float64(max[a])
+	}
+	s.Attributes = attributeStats(c)
+	if s.Points < 2 {
+		return s
+	}
+
+	g := spatial.NewGrid(c.Coords, 0)
+	step := math.Max(1, float64(s.Points)/float64(opts.Samples))
+	var samples []int
+	for f := 0.0; int(f) < s.Points; f += step {
+		samples = append(samples, int(f))
+	}
+
+	s.MinSpacing = math.Inf(1)
+	for _, i := range samples {
+		_, d := g.Nearest(point(c, i), i)
+		s.MeanSpacing += d
+		s.MinSpacing = math.Min(s.MinSpacing, d)
+		s.MaxSpacing = math.Max(s.MaxSpacing, d)
+	}
+	s.MeanSpacing /= float64(len(samples))
+	if s.MeanSpacing == 0 {
+		return s // every sampled point has a duplicate
+	}
+
+	s.DensityRadius = 4 * s.MeanSpacing
+	area := math.Pi * s.DensityRadius * s.DensityRadius
+	densities := make([]float64, len(samples))
+	for n, i := range samples {
+		count := 0
+		g.Within(point(c, i), s.DensityRadius, func(int, float64) { count++ })
+		densities[n] = float64(count) / area
+		s.MeanDensity += densities[n]
+	}
+	s.MeanDensity /= float64(len(samples))
+	s.Density = histogram(densities, opts.Bins)
+	return s
+}
+
+func point(c *pointcloud.Cloud, i int) [3]float64 {
+	return [3]float64{float64(c.Coords[i*3]), float64(c.Coords[i*3+1]), float64(c.Coords[i*3+2])}
+}
+
+// histogram bins values between their extremes.
+func histogram(values []float64, bins int) Histogram {
+	lo, hi := math.Inf(1), math.Inf(-1)
+	for _, v := range values {
+		lo, hi = math.Min(lo, v), math.Max(hi, v)
+	}
+	return Bin(values, bins, lo, hi)
+}
+
+// Bin counts values in bins equal bins from lo to hi. Values outside the
+// range are left out; when lo equals hi every value in range goes in the
+// first bin.
+func Bin(values []float64, bins int, lo, hi float64) Histogram {
+	h := Histogram{Min: lo, Max: hi, Counts: make([]int, bins)}
+	for _, v := range values {
+		if v < lo || v > hi || v != v {
+			continue
+		}
+		b := 0
+		if hi > lo {
+			b = int((v - lo) / (hi - lo) * float64(bins))
+		}
+		h.Counts[min(b, bins-1)]++
+	}
+	return h
+}
+
+// attributeStats returns the range and mean of every component of every
+// attribute except position.
+func attributeStats(c *pointcloud.Cloud) []AttributeStats {
+	var stats []AttributeStats
+	n := c.Len()
+	for _, a := range c.Schema() {
+		var values []float32
+		switch a.Name {
+		case pointcloud.AttrPosition:
+			continue
+		case pointcloud.AttrColor:
+			values = c.Colors
+		case pointcloud.AttrNormal:
+			values = c.Normals
+		case pointcloud.AttrClass:
+			values = make([]float32, n)
+			for i, class := range c.Classes {
+				values[i] = float32(class)
+			}
+		default:
+			_, values, _ = c.Attribute(a.Name)
+		}
+		for k := 0; k < a.Components; k++ {
+			st := AttributeStats{Name: a.Name, Component: k, Min: math.Inf(1), Max: math.Inf(-1)}
+			for i := 0; i < n; i++ {
+				v := float64(values[i*a.Components+k])
+				st.Min, st.Max = math.Min(st.Min, v), math.Max(st.Max, v)
+				st.Mean += v
+			}
+			st.Mean /= float64(n)
+			stats = append(stats, st)
+		}
+	}
+	return stats
+}
@@ -0,0 +1,91 @@
+// analysis/analysis_test.go
+// usage: go test
+
+package analysis
+
+import (
+	"math"
+	"testing"
+
+	"github.com/sbecker11/webgl-point-cloud/pointcloud"
+)
+
+// gridCloud returns an n x n grid of points spaced 0.1 apart in the XZ
+// plane, with an intensity attribute running from 0 to n*n-1.
+func gridCloud(n int) *pointcloud.Cloud {
+	var coords, colors, intensity []float32
+	for i := 0; i < n; i++ {
+		for j := 0; j < n; j++ {
+			coords = append(coords, float32(i)*0.1, 2, float32(j)*0.1)
+			colors = append(colors, 1, 0.5, 0, 1)
+			intensity = append(intensity, float32(len(intensity)))
+		}
+	}
+	c := pointcloud.New("grid", coords, colors)
+	c.SetAttribute(pointcloud.Attribute{Name: "intensity", Components: 1, Type: pointcloud.Uint16}, intensity)
+	return c
+}
+
+func TestComputeGrid(t *testing.T) {
+	s := Compute(gridCloud(50), Options{})
+	if s.Points != 2500 {
+		t.Errorf("expected 2500 points, got %d", s.Points)
+	}
+	if s.Min != [3]float64{0, 2, 0} || math.Abs(s.Max[0]-4.9) > 1e-5 || s.Max[1] != 2 {
+		t.Errorf("unexpected bounds %v to %v", s.Min, s.Max)
+	}
+	if math.Abs(s.MeanSpacing-0.1) > 1e-5 || math.Abs(s.MinSpacing-0.1) > 1e-5 || math.Abs(s.MaxSpacing-0.1) > 1e-5 {
+		t.Errorf("expected spacing 0.1, got mean %f, range %f to %f", s.MeanSpacing, s.MinSpacing, s.MaxSpacing)
+	}
+	// 100 points per unit area away from the edges, fewer near them.
+	if s.Density.Max < 90 || s.Density.Max > 110 || s.MeanDensity > s.Density.Max {
+		t.Errorf("expected a peak density near 100, got %f (mean %f)", s.Density.Max, s.MeanDensity)
+	}
+	total := 0
+	for _, n := range s.Density.Counts {
+		total += n
+	}
+	if len(s.Density.Counts) != 20 || total != 2500 {
+		t.Errorf("expected 2500 samples in 20 bins, got %d in %d", total, len(s.Density.Counts))
+	}
+
+	var found bool
+	for _, a := range s.Attributes {
+		if a.Name == "intensity" {
+			found = true
+			if a.Min != 0 || a.Max != 2499 || math.Abs(a.Mean-1249.5) > 1e-9 {
+				t.Errorf("intensity: expected 0 to 2499 with mean 1249.5, got %v", a)
+			}
+		}
+		if a.Name == pointcloud.AttrColor && a.Component == 1 && math.Abs(a.Mean-0.5) > 1e-9 {
+			t.Errorf("green: expected mean 0.5, got %f", a.Mean)
+		}
+	}
+	if !found || len(s.Attributes) != 5 {
+		t.Errorf("expected 4 color components and intensity, got %v", s.Attributes)
+	}
+}
+
+func TestComputeSamples(t *testing.T) {
+	s := Compute(gridCloud(100), Options{Samples: 500, Bins: 5})
+	total := 0
+	for _, n := range s.Density.Counts {
+		total += n
+	}
+	if total != 500 || len(s.Density.Counts) != 5 {
+		t.Errorf("expected 500 samples in 5 bins, got %d in %d", total, len(s.Density.Counts))
+	}
+	if s := Compute(pointcloud.New("empty", nil, nil), Options{}); s.Points != 0 || s.MeanSpacing != 0 {
+		t.Errorf("expected empty stats, got %+v", s)
+	}
+}
+
+func TestBin(t *testing.T) {
+	h := Bin([]float64{-1, 0, 0.5, 1, 2.5, 3, 4, math.NaN()}, 3, 0, 3)
+	want := []int{2, 1, 2}
+	for i := range want {
+		if h.Counts[i] != want[i] {
+			t.Fatalf("expected %v, got %v", want, h.Counts)
+		}
+	}
+}
@@ -0,0 +1,57 @@
+// anim/anim.go
+// Package anim makes animations independent of the frame rate: a clock
+// measures the time between frames, and motion tuned per frame at a
+// reference rate is integrated over it, so it looks the same at 30 Hz
+// and at 144 Hz.
+package anim
+
+import "math"
+
+// RefStep is the frame time per-frame rates are tuned for, in seconds:
+// 60 Hz.
+const RefStep = 1.0 / 60
+
+// MaxDelta bounds the time one frame advances animations, in seconds, so
+// a stall or a long gap between frames does not make them jump.
+const MaxDelta = 0.25
+
+// Clock measures the time between animation frames.
+type Clock struct {
+	last    float64
+	started bool
+}
+
+// Tick returns the seconds since the last tick, given the frame's time
+// now in ms (a requestAnimationFrame timestamp), at most MaxDelta. The
+// first tick, and the first after Reset, returns 0.
+func (c *Clock) Tick(now float64) float64 {
+	last, started := c.last, c.started
+	c.last, c.started = now, true
+	if !started || now <= last {
+		return 0
+	}
+	return math.Min((now-last)/1000, MaxDelta)
+}
+
+// Reset makes the next tick start afresh, e.g. after the page was hidden.
+func (c *Clock) Reset() {
+	c.started = false
+}
+
+// Decay returns the fraction of a value left after dt seconds when keep
+// of it is left after each reference step.
+func Decay(keep, dt float64) float64 {
+	return math.Pow(keep, dt/RefStep)
+}
+
+// Travel returns how far something moving at 1 unit per reference step
+// goes in dt seconds while its speed decays by keep each step. For whole
+// steps it is the distance stepping frame by frame covers, 1 + keep +
+// keep² + ..., and it is continuous in between.
+func Travel(keep, dt float64) float64 {
+	steps := dt / RefStep
+	if keep == 1 {
+		return steps
+	}
+	return (1 - math.Pow(keep, steps)) / (1 - keep)
+}
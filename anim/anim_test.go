@@ -0,0 +1,74 @@
+// anim/anim_test.go
+// usage: go test
+
+package anim
+
+import (
+	"math"
+	"testing"
+)
+
+func TestClockTick(t *testing.T) {
+	var c Clock
+	if dt := c.Tick(1000); dt != 0 {
+		t.Errorf("first Tick = %g, want 0", dt)
+	}
+	if dt := c.Tick(1016); math.Abs(dt-0.016) > 1e-12 {
+		t.Errorf("Tick after 16 ms = %g, want 0.016", dt)
+	}
+	if dt := c.Tick(5000); dt != MaxDelta {
+		t.Errorf("Tick after a stall = %g, want %g", dt, MaxDelta)
+	}
+	if dt := c.Tick(4000); dt != 0 {
+		t.Errorf("Tick going back = %g, want 0", dt)
+	}
+	c.Reset()
+	if dt := c.Tick(9000); dt != 0 {
+		t.Errorf("Tick after Reset = %g, want 0", dt)
+	}
+}
+
+// step moves a unit velocity frame by frame for n frames, as inertia did
+// before it was integrated over time.
+func step(keep float64, n int) (distance, v float64) {
+	v = 1
+	for i := 0; i < n; i++ {
+		distance += v
+		v *= keep
+	}
+	return distance, v
+}
+
+func TestTravelMatchesSteppingAtTheReferenceRate(t *testing.T) {
+	for _, n := range []int{1, 2, 10, 60} {
+		want, wantV := step(0.9, n)
+		dt := float64(n) * RefStep
+		if got := Travel(0.9, dt); math.Abs(got-want) > 1e-9 {
+			t.Errorf("Travel over %d steps = %g, want %g", n, got, want)
+		}
+		if got := Decay(0.9, dt); math.Abs(got-wantV) > 1e-9 {
+			t.Errorf("Decay over %d steps = %g, want %g", n, got, wantV)
+		}
+	}
+	if got := Travel(1, 0.5); math.Abs(got-30) > 1e-9 {
+		t.Errorf("Travel without damping over 0.5 s = %g, want 30", got)
+	}
+}
+
+func TestTravelIsIndependentOfTheFrameRate(t *testing.T) {
+	const keep, total = 0.9, 1.0
+	var want float64
+	for i, rate := range []float64{30, 60, 144} {
+		dt := 1 / rate
+		distance, v := 0.0, 1.0
+		for time := 0.0; time < total-1e-9; time += dt {
+			distance += v * Travel(keep, dt)
+			v *= Decay(keep, dt)
+		}
+		if i == 0 {
+			want = distance
+		} else if math.Abs(distance-want) > 1e-6 {
+			t.Errorf("distance at %g Hz = %g, want %g as at 30 Hz", rate, distance, want)
+		}
+	}
+}
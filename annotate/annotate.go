@@ -0,0 +1,89 @@
+// annotate/annotate.go
+// Package annotate writes hand-labelled points as training data for
+// point cloud segmentation models: each point's position and class label.
+package annotate
+
+import (
+	"encoding/binary"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+)
+
+// Dataset is a set of labelled points: packed xyz coordinates and one
+// label per point.
+type Dataset struct {
+	Coords []float32
+	Labels []uint8
+}
+
+// Add appends the points of coords with their labels.
+// Panics if labels does not hold one label per point.
+func (d *Dataset) Add(coords []float32, labels []uint8) {
+	if len(labels)*3 != len(coords) {
+		panic("annotate.Dataset.Add: expected one label per point")
+	}
+	d.Coords = append(d.Coords, coords...)
+	d.Labels = append(d.Labels, labels...)
+}
+
+// Len returns the number of points.
+func (d *Dataset) Len() int {
+	return len(d.Labels)
+}
+
+// Counts returns the number of points with each label.
+func (d *Dataset) Counts() map[uint8]int {
+	counts := map[uint8]int{}
+	for _, l := range d.Labels {
+		counts[l]++
+	}
+	return counts
+}
+
+// WriteCSV writes the points as CSV with an x,y,z,label header row, one
+// row per point.
+func (d *Dataset) WriteCSV(w io.Writer) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write([]string{"x", "y", "z", "label"}); err != nil {
+		return err
+	}
+	f := func(v float32) string { return strconv.FormatFloat(float64(v), 'g', -1, 32) }
+	for i, l := range d.Labels {
+		p := d.Coords[i*3 : i*3+3]
+		if err := cw.Write([]string{f(p[0]), f(p[1]), f(p[2]), strconv.Itoa(int(l))}); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// WriteBinary writes the points as little-endian float32 x, y, z, label
+// records of 16 bytes, which load with NumPy as
+// np.fromfile(path, "<f4").reshape(-1, 4).
+func (d *Dataset) WriteBinary(w io.Writer) error {
+	record := make([]byte, 16)
+	for i, l := range d.Labels {
+		for k, v := range []float32{d.Coords[i*3], d.Coords[i*3+1], d.Coords[i*3+2], float32(l)} {
+			binary.LittleEndian.PutUint32(record[k*4:], math.Float32bits(v))
+		}
+		if _, err := w.Write(record); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Write writes the points in the named format, "csv" or "bin".
+func (d *Dataset) Write(w io.Writer, format string) error {
+	switch format {
+	case "csv":
+		return d.WriteCSV(w)
+	case "bin":
+		return d.WriteBinary(w)
+	}
+	return fmt.Errorf("unknown format %q; expected csv or bin", format)
+}
@@ -0,0 +1,47 @@
+// annotate/annotate_test.go
+// usage: go test
+
+package annotate
+
+import (
+	"bytes"
+	"encoding/binary"
+	"math"
+	"strings"
+	"testing"
+)
+
+func TestWrite(t *testing.T) {
+	var d Dataset
+	d.Add([]float32{1, 2, 3, 0.5, -1, 2.25}, []uint8{2, 6})
+	d.Add([]float32{0, 0, 1}, []uint8{2})
+	if d.Len() != 3 || d.Counts()[2] != 2 || d.Counts()[6] != 1 {
+		t.Fatalf("expected labels 2, 6 and 2, got %v", d.Labels)
+	}
+
+	var csv strings.Builder
+	if err := d.Write(&csv, "csv"); err != nil {
+		t.Fatal(err)
+	}
+	if want := "x,y,z,label\n1,2,3,2\n0.5,-1,2.25,6\n0,0,1,2\n"; csv.String() != want {
+		t.Errorf("csv: expected %q, got %q", want, csv.String())
+	}
+
+	var bin bytes.Buffer
+	if err := d.Write(&bin, "bin"); err != nil {
+		t.Fatal(err)
+	}
+	if bin.Len() != 48 {
+		t.Fatalf("bin: expected 48 bytes, got %d", bin.Len())
+	}
+	record := bin.Bytes()[16:32]
+	for k, want := range []float32{0.5, -1, 2.25, 6} {
+		if got := math.Float32frombits(binary.LittleEndian.Uint32(record[k*4:])); got != want {
+			t.Errorf("bin: field %d of point 1: expected %g, got %g", k, want, got)
+		}
+	}
+
+	if err := d.Write(&bin, "las"); err == nil {
+		t.Error("expected an error for an unknown format")
+	}
+}
@@ -0,0 +1,69 @@
+// basemap/basemap.go
+// Package basemap does the arithmetic of slippy-map tiles, the 256-pixel
+// Web Mercator map images served by OpenStreetMap and most web map
+// services, for laying map imagery under georeferenced point clouds.
+package basemap
+
+import (
+	"fmt"
+	"math"
+)
+
+const (
+	// MaxZoom is the deepest zoom level tile servers commonly provide.
+	MaxZoom = 19
+	// MaxLatitude is the latitude, in degrees, where Web Mercator maps end.
+	MaxLatitude = 85.0511287798
+)
+
+// Tile is a map tile: zoom level Z, which splits the world into 2^Z by
+// 2^Z tiles, and column X and row Y counted from the north-west corner.
+type Tile struct {
+	Z, X, Y int
+}
+
+// TileAt returns the tile at zoom z holding a longitude and latitude in
+// degrees. Latitudes beyond the map are clamped to its edge.
+func TileAt(lon, lat float64, z int) Tile {
+	n := float64(int(1) << z)
+	lat = max(-MaxLatitude, min(MaxLatitude, lat)) * math.Pi / 180
+	x := (lon + 180) / 360 * n
+	y := (1 - math.Log(math.Tan(lat)+1/math.Cos(lat))/math.Pi) / 2 * n
+	last := int(n) - 1
+	return Tile{Z: z, X: max(0, min(last, int(math.Floor(x)))), Y: max(0, min(last, int(math.Floor(y))))}
+}
+
+// Bounds returns the longitudes and latitudes in degrees of the tile's
+// edges.
+func (t Tile) Bounds() (west, south, east, north float64) {
+	n := float64(int(1) << t.Z)
+	lon := func(x int) float64 { return float64(x)/n*360 - 180 }
+	lat := func(y int) float64 { return math.Atan(math.Sinh(math.Pi*(1-2*float64(y)/n))) * 180 / math.Pi }
+	return lon(t.X), lat(t.Y + 1), lon(t.X + 1), lat(t.Y)
+}
+
+// Path returns the tile's path on a tile server, "z/x/y".
+func (t Tile) Path() string {
+	return fmt.Sprintf("%d/%d/%d", t.Z, t.X, t.Y)
+}
+
+// Cover returns the tiles covering a longitude and latitude range at the
+// deepest zoom level up to MaxZoom that needs at most maxTiles of them,
+// in rows from north to south. Zoom 0, a single tile, is the shallowest.
+func Cover(west, south, east, north float64, maxTiles int) []Tile {
+	z := MaxZoom
+	for ; z > 0; z-- {
+		nw, se := TileAt(west, north, z), TileAt(east, south, z)
+		if (se.X-nw.X+1)*(se.Y-nw.Y+1) <= maxTiles {
+			break
+		}
+	}
+	nw, se := TileAt(west, north, z), TileAt(east, south, z)
+	tiles := make([]Tile, 0, (se.X-nw.X+1)*(se.Y-nw.Y+1))
+	for y := nw.Y; y <= se.Y; y++ {
+		for x := nw.X; x <= se.X; x++ {
+			tiles = append(tiles, Tile{Z: z, X: x, Y: y})
+		}
+	}
+	return tiles
+}
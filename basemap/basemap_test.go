@@ -0,0 +1,63 @@
+// basemap/basemap_test.go
+// usage: go test
+
+package basemap
+
+import (
+	"math"
+	"testing"
+)
+
+func TestTileAt(t *testing.T) {
+	if tile := TileAt(0, 0, 0); tile != (Tile{0, 0, 0}) {
+		t.Errorf("zoom 0: expected the single tile, got %v", tile)
+	}
+	// Just north-east of 0°, 0° is the tile right of and above the centre.
+	if tile := TileAt(0.1, 0.1, 1); tile != (Tile{1, 1, 0}) {
+		t.Errorf("expected {1 1 0}, got %v", tile)
+	}
+	// The Colosseum at zoom 16.
+	if tile := TileAt(12.4924, 41.8902, 16); tile != (Tile{16, 35042, 24354}) {
+		t.Errorf("expected {16 35042 24354}, got %v", tile)
+	}
+	if tile := TileAt(180, -90, 2); tile != (Tile{2, 3, 3}) {
+		t.Errorf("expected the south-east corner tile, got %v", tile)
+	}
+}
+
+func TestBounds(t *testing.T) {
+	west, south, east, north := Tile{1, 0, 0}.Bounds()
+	if west != -180 || east != 0 || math.Abs(south) > 1e-9 || math.Abs(north-MaxLatitude) > 1e-6 {
+		t.Errorf("unexpected bounds %g %g %g %g", west, south, east, north)
+	}
+	tile := TileAt(12.4924, 41.8902, 16)
+	w, s, e, n := tile.Bounds()
+	if 12.4924 < w || 12.4924 > e || 41.8902 < s || 41.8902 > n {
+		t.Errorf("%v bounds %g %g %g %g exclude its point", tile, w, s, e, n)
+	}
+	if tile.Path() != "16/35042/24354" {
+		t.Errorf("unexpected path %s", tile.Path())
+	}
+}
+
+func TestCover(t *testing.T) {
+	tiles := Cover(12.48, 41.88, 12.50, 41.90, 16)
+	if len(tiles) == 0 || len(tiles) > 16 {
+		t.Fatalf("expected 1 to 16 tiles, got %d", len(tiles))
+	}
+	// One zoom deeper would need more than 16.
+	z := tiles[0].Z + 1
+	nw, se := TileAt(12.48, 41.90, z), TileAt(12.50, 41.88, z)
+	if (se.X-nw.X+1)*(se.Y-nw.Y+1) <= 16 {
+		t.Errorf("zoom %d is not the deepest with at most 16 tiles", tiles[0].Z)
+	}
+	if tiles[0].Y > tiles[len(tiles)-1].Y {
+		t.Error("expected rows from north to south")
+	}
+	if tiles := Cover(-180, -80, 180, 80, 1); len(tiles) != 1 || tiles[0].Z != 0 {
+		t.Errorf("expected the zoom 0 tile for the world, got %v", tiles)
+	}
+	if tiles := Cover(1, 1, 1, 1, 4); tiles[0].Z != MaxZoom {
+		t.Errorf("expected zoom %d for a point, got %v", MaxZoom, tiles)
+	}
+}
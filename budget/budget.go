@@ -0,0 +1,237 @@
+// budget/budget.go
+// Package budget shares a point budget among the parts of huge clouds by
+// how large they look, as Potree does to keep such clouds interactive: a
+// cloud is split into an octree whose leaves hold runs of a draw order,
+// each run shuffled so that its first points are a random sample of the
+// leaf, and every frame each leaf in view gets a share of the budget in
+// proportion to its projected area. Near parts are drawn densely, far
+// parts sparsely and parts out of view not at all.
+package budget
+
+import (
+	"math"
+	"math/rand"
+	"sort"
+
+	"github.com/sbecker11/webgl-point-cloud/glf32"
+)
+
+// LeafSize is the most points a leaf holds unless its points cannot be
+// split further.
+const LeafSize = 8192
+
+// maxDepth bounds the octree's depth, so that many points at the same
+// place end up in one leaf.
+const maxDepth = 20
+
+// Node is a leaf of a Tree: the bounds of its points and where they are in
+// the tree's order.
+type Node struct {
+	Min, Max     [3]float32
+	First, Count int
+}
+
+// Tree is a cloud's draw order and the leaves it is grouped in.
+type Tree struct {
+	Order []uint32
+	Nodes []Node
+}
+
+// Build splits the points of packed xyz coordinates into leaves of at most
+// leafSize points, shuffling each leaf's points the same way for the same
+// seed.
+func Build(coords []float32, leafSize int, seed int64) *Tree {
+	n := len(coords) / 3
+	t := &Tree{Order: make([]uint32, n)}
+	if n == 0 {
+		return t
+	}
+	for i := range t.Order {
+		t.Order[i] = uint32(i)
+	}
+	lo, hi := bounds(coords, t.Order)
+	scratch := make([]uint32, n)
+	r := rand.New(rand.NewSource(seed))
+	var split func(first int, idx []uint32, lo, hi [3]float32, depth int)
+	split = func(first int, idx []uint32, lo, hi [3]float32, depth int) {
+		if len(idx) <= leafSize || depth >= maxDepth {
+			r.Shuffle(len(idx), func(i, j int) { idx[i], idx[j] = idx[j], idx[i] })
+			nlo, nhi := bounds(coords, idx)
+			t.Nodes = append(t.Nodes, Node{Min: nlo, Max: nhi, First: first, Count: len(idx)})
+			return
+		}
+		var mid [3]float32
+		for k := range mid {
+			mid[k] = (lo[k] + hi[k]) / 2
+		}
+		var counts [9]int
+		octant := func(i uint32) int {
+			o := 0
+			for k := 0; k < 3; k++ {
+				if coords[3*int(i)+k] >= mid[k] {
+					o |= 1 << k
+				}
+			}
+			return o
+		}
+		for _, i := range idx {
+			counts[octant(i)+1]++
+		}
+		for o := 1; o < 9; o++ {
+			counts[o] += counts[o-1]
+		}
+		tmp, next := scratch[:len(idx)], counts
+		for _, i := range idx {
+			o := octant(i)
+			tmp[next[o]] = i
+			next[o]++
+		}
+		copy(idx, tmp)
+		for o := 0; o < 8; o++ {
+			part := idx[counts[o]:counts[o+1]]
+			if len(part) == 0 {
+				continue
+			}
+			clo, chi := lo, hi
+			for k := 0; k < 3; k++ {
+				if o&(1<<k) != 0 {
+					clo[k] = mid[k]
+				} else {
+					chi[k] = mid[k]
+				}
+			}
+			split(first+counts[o], part, clo, chi, depth+1)
+		}
+	}
+	split(0, t.Order, lo, hi, 0)
+	return t
+}
+
+// bounds returns the bounding box of the points at the indices.
+func bounds(coords []float32, idx []uint32) (lo, hi [3]float32) {
+	for k := 0; k < 3; k++ {
+		lo[k], hi[k] = float32(math.Inf(1)), float32(math.Inf(-1))
+	}
+	for _, i := range idx {
+		for k := 0; k < 3; k++ {
+			v := coords[3*int(i)+k]
+			lo[k], hi[k] = min(lo[k], v), max(hi[k], v)
+		}
+	}
+	return lo, hi
+}
+
+// Weigh appends to weights the area in pixels each node's bounding box
+// covers in a width by height viewport with the model-view-projection mvp:
+// 0 for nodes out of view, the whole viewport for nodes the camera is in or
+// behind which it partly is, and at least one pixel for the rest.
+func Weigh(weights []float64, nodes []Node, mvp glf32.Mat4, width, height float32) []float64 {
+	for _, nd := range nodes {
+		weights = append(weights, weigh(nd, mvp, float64(width), float64(height)))
+	}
+	return weights
+}
+
+func weigh(nd Node, mvp glf32.Mat4, width, height float64) float64 {
+	var outside [6]int
+	behind := false
+	x0, y0, x1, y1 := math.Inf(1), math.Inf(1), math.Inf(-1), math.Inf(-1)
+	for c := 0; c < 8; c++ {
+		var p [3]float32
+		for k := 0; k < 3; k++ {
+			if c&(1<<k) != 0 {
+				p[k] = nd.Max[k]
+			} else {
+				p[k] = nd.Min[k]
+			}
+		}
+		var clip [4]float64
+		for r := 0; r < 4; r++ {
+			clip[r] = float64(mvp[r]*p[0] + mvp[4+r]*p[1] + mvp[8+r]*p[2] + mvp[12+r])
+		}
+		x, y, z, w := clip[0], clip[1], clip[2], clip[3]
+		for i, out := range []bool{x < -w, x > w, y < -w, y > w, z < -w, z > w} {
+			if out {
+				outside[i]++
+			}
+		}
+		if w <= 0 {
+			behind = true
+			continue
+		}
+		x0, x1 = min(x0, x/w), max(x1, x/w)
+		y0, y1 = min(y0, y/w), max(y1, y/w)
+	}
+	for _, n := range outside {
+		if n == 8 {
+			return 0
+		}
+	}
+	if behind {
+		return width * height
+	}
+	x0, x1 = max(x0, -1), min(x1, 1)
+	y0, y1 = max(y0, -1), min(y1, 1)
+	return max((x1-x0)*width/2*(y1-y0)*height/2, 1)
+}
+
+// Allocate shares budget points among nodes with the given point counts
+// in proportion to their weights, giving no node more points than it has;
+// what a node cannot use goes to the others. Nodes of weight 0 get none.
+// If the nodes have budget points or fewer in all, each gets all of its
+// points.
+func Allocate(counts []int, weights []float64, budget int) []int {
+	alloc := make([]int, len(counts))
+	var order []int
+	total, points := 0.0, 0
+	for i, w := range weights {
+		if w > 0 && counts[i] > 0 {
+			order = append(order, i)
+			total += w
+			points += counts[i]
+		}
+	}
+	if points <= budget {
+		for _, i := range order {
+			alloc[i] = counts[i]
+		}
+		return alloc
+	}
+	// Nodes that fill up soonest go first, so that what they leave is
+	// shared among the rest.
+	sort.Slice(order, func(a, b int) bool {
+		i, j := order[a], order[b]
+		return float64(counts[i])*weights[j] < float64(counts[j])*weights[i]
+	})
+	left := float64(budget)
+	for _, i := range order {
+		share := left * weights[i] / total
+		alloc[i] = min(counts[i], int(share))
+		left -= float64(alloc[i])
+		total -= weights[i]
+	}
+	return alloc
+}
+
+// Range is a run of the tree's order to draw.
+type Range struct {
+	First, Count int
+}
+
+// Ranges returns the runs of the order to draw the allocated points of
+// each node, the first of each node's run, merging the runs of adjacent
+// nodes drawn in full to save draw calls.
+func (t *Tree) Ranges(alloc []int) []Range {
+	var ranges []Range
+	for i, nd := range t.Nodes {
+		if alloc[i] <= 0 {
+			continue
+		}
+		if last := len(ranges) - 1; last >= 0 && ranges[last].First+ranges[last].Count == nd.First {
+			ranges[last].Count += alloc[i]
+			continue
+		}
+		ranges = append(ranges, Range{nd.First, alloc[i]})
+	}
+	return ranges
+}
@@ -0,0 +1,116 @@
+// budget/budget_test.go
+// usage: go test
+
+package budget
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+
+	"github.com/sbecker11/webgl-point-cloud/glf32"
+)
+
+func randomCoords(n int) []float32 {
+	r := rand.New(rand.NewSource(1))
+	coords := make([]float32, 3*n)
+	for i := range coords {
+		coords[i] = r.Float32()*2 - 1
+	}
+	return coords
+}
+
+func TestBuildGroupsPointsInLeaves(t *testing.T) {
+	n := 10000
+	coords := randomCoords(n)
+	tree := Build(coords, 500, 1)
+	if len(tree.Order) != n {
+		t.Fatalf("expected %d indices, got %d", n, len(tree.Order))
+	}
+	seen := make([]bool, n)
+	next := 0
+	for _, nd := range tree.Nodes {
+		if nd.First != next {
+			t.Fatalf("node starts at %d, expected %d", nd.First, next)
+		}
+		if nd.Count > 500 {
+			t.Errorf("node has %d points, more than the leaf size", nd.Count)
+		}
+		for _, i := range tree.Order[nd.First : nd.First+nd.Count] {
+			if seen[i] {
+				t.Fatalf("index %d appears twice", i)
+			}
+			seen[i] = true
+			for k := 0; k < 3; k++ {
+				if v := coords[3*int(i)+k]; v < nd.Min[k] || v > nd.Max[k] {
+					t.Fatalf("point %d is outside its node's bounds", i)
+				}
+			}
+		}
+		next += nd.Count
+	}
+	if next != n {
+		t.Errorf("nodes hold %d points, expected %d", next, n)
+	}
+}
+
+func TestBuildStopsOnCoincidentPoints(t *testing.T) {
+	tree := Build(make([]float32, 3*100), 10, 1)
+	if len(tree.Nodes) != 1 || tree.Nodes[0].Count != 100 {
+		t.Errorf("expected one leaf of 100 points, got %v", tree.Nodes)
+	}
+}
+
+func TestWeigh(t *testing.T) {
+	mvp := glf32.MultiplyMatrices(glf32.Perspective(math.Pi/3, 1, 0.1, 100), glf32.Translate(0, 0, -5))
+	nodes := []Node{
+		{Min: [3]float32{-0.5, -0.5, -0.5}, Max: [3]float32{0.5, 0.5, 0.5}},
+		{Min: [3]float32{-0.5, -0.5, -20.5}, Max: [3]float32{0.5, 0.5, -19.5}},
+		{Min: [3]float32{50, -0.5, -0.5}, Max: [3]float32{51, 0.5, 0.5}},
+		{Min: [3]float32{-0.5, -0.5, 4}, Max: [3]float32{0.5, 0.5, 6}},
+	}
+	w := Weigh(nil, nodes, mvp, 800, 800)
+	if !(w[0] > w[1] && w[1] > 0) {
+		t.Errorf("expected the near node to weigh more than the far one, got %v", w[:2])
+	}
+	if w[2] != 0 {
+		t.Errorf("expected a node out of view to weigh 0, got %g", w[2])
+	}
+	if w[3] != 800*800 {
+		t.Errorf("expected a node around the camera to weigh the viewport, got %g", w[3])
+	}
+}
+
+func TestAllocate(t *testing.T) {
+	counts := []int{1000, 1000, 50, 1000}
+	alloc := Allocate(counts, []float64{3, 1, 1, 0}, 1000)
+	if alloc[3] != 0 {
+		t.Errorf("expected no points for a node out of view, got %d", alloc[3])
+	}
+	if alloc[2] != 50 {
+		t.Errorf("expected the small node to get all its points, got %d", alloc[2])
+	}
+	if alloc[0] != 712 || alloc[1] != 238 {
+		t.Errorf("expected the rest shared 3:1, got %d and %d", alloc[0], alloc[1])
+	}
+	alloc = Allocate(counts, []float64{1, 1, 1, 1}, 5000)
+	for i, a := range alloc {
+		if a != counts[i] {
+			t.Errorf("node %d: expected all %d points within the budget, got %d", i, counts[i], a)
+		}
+	}
+}
+
+func TestRangesMergesFullNodes(t *testing.T) {
+	tree := &Tree{Nodes: []Node{{First: 0, Count: 10}, {First: 10, Count: 10}, {First: 20, Count: 10}, {First: 30, Count: 10}}}
+	got := tree.Ranges([]int{10, 4, 10, 10})
+	want := []Range{{0, 14}, {20, 20}}
+	if len(got) != len(want) {
+		t.Fatalf("expected %v, got %v", want, got)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("expected %v, got %v", want, got)
+		}
+	}
+}
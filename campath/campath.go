@@ -0,0 +1,109 @@
+// campath/campath.go
+// Package campath holds keyframed camera paths for flythroughs: camera
+// positions and look-at targets at given times, interpolated with
+// Catmull-Rom splines so the camera passes through every keyframe on a
+// smooth curve.
+package campath
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	"github.com/sbecker11/webgl-point-cloud/glf32"
+)
+
+// Keyframe is a camera pose at a time in seconds from the start of the
+// path.
+type Keyframe struct {
+	Time     float64    `json:"time"`
+	Position [3]float64 `json:"position"`
+	Target   [3]float64 `json:"target"`
+}
+
+// Path is a sequence of keyframes in increasing time order.
+type Path struct {
+	Keyframes []Keyframe `json:"keyframes"`
+}
+
+// Add inserts k in time order, replacing a keyframe at the same time.
+func (p *Path) Add(k Keyframe) {
+	i := sort.Search(len(p.Keyframes), func(i int) bool { return p.Keyframes[i].Time >= k.Time })
+	if i < len(p.Keyframes) && p.Keyframes[i].Time == k.Time {
+		p.Keyframes[i] = k
+		return
+	}
+	p.Keyframes = append(p.Keyframes, Keyframe{})
+	copy(p.Keyframes[i+1:], p.Keyframes[i:])
+	p.Keyframes[i] = k
+}
+
+// Duration returns the time of the last keyframe, or 0 for an empty path.
+func (p *Path) Duration() float64 {
+	if len(p.Keyframes) == 0 {
+		return 0
+	}
+	return p.Keyframes[len(p.Keyframes)-1].Time
+}
+
+// Validate checks that the path has keyframes with strictly increasing,
+// non-negative times.
+func (p *Path) Validate() error {
+	if len(p.Keyframes) == 0 {
+		return fmt.Errorf("campath: no keyframes")
+	}
+	for i, k := range p.Keyframes {
+		if k.Time < 0 {
+			return fmt.Errorf("campath: keyframe %d has negative time %g", i, k.Time)
+		}
+		if i > 0 && k.Time <= p.Keyframes[i-1].Time {
+			return fmt.Errorf("campath: keyframe %d at %gs is not after keyframe %d at %gs", i, k.Time, i-1, p.Keyframes[i-1].Time)
+		}
+	}
+	return nil
+}
+
+// At returns the camera position and target at time t, clamped to the
+// path's first and last keyframes. The path must not be empty.
+func (p *Path) At(t float64) (position, target [3]float64) {
+	ks := p.Keyframes
+	if t <= ks[0].Time || len(ks) == 1 {
+		return ks[0].Position, ks[0].Target
+	}
+	if t >= ks[len(ks)-1].Time {
+		return ks[len(ks)-1].Position, ks[len(ks)-1].Target
+	}
+	// ks[i] is the last keyframe at or before t.
+	i := sort.Search(len(ks), func(i int) bool { return ks[i].Time > t }) - 1
+	u := (t - ks[i].Time) / (ks[i+1].Time - ks[i].Time)
+	at := func(j int) Keyframe { return ks[max(0, min(len(ks)-1, j))] }
+	k0, k1, k2, k3 := at(i-1), at(i), at(i+1), at(i+2)
+	spline := func(p0, p1, p2, p3 [3]float64) [3]float64 {
+		p := glf32.CatmullRomSegment(vec3(p0), vec3(p1), vec3(p2), vec3(p3), float32(u))
+		return [3]float64{float64(p[0]), float64(p[1]), float64(p[2])}
+	}
+	return spline(k0.Position, k1.Position, k2.Position, k3.Position),
+		spline(k0.Target, k1.Target, k2.Target, k3.Target)
+}
+
+// vec3 converts a point to a glf32.Vec3.
+func vec3(p [3]float64) glf32.Vec3 {
+	return glf32.Vec3{float32(p[0]), float32(p[1]), float32(p[2])}
+}
+
+// Marshal returns the path as indented JSON.
+func Marshal(p *Path) ([]byte, error) {
+	return json.MarshalIndent(p, "", "  ")
+}
+
+// Unmarshal parses and validates a path written by Marshal.
+func Unmarshal(data []byte) (*Path, error) {
+	var p Path
+	if err := json.Unmarshal(data, &p); err != nil {
+		return nil, fmt.Errorf("campath: %v", err)
+	}
+	if err := p.Validate(); err != nil {
+		return nil, err
+	}
+	return &p, nil
+}
@@ -0,0 +1,69 @@
+// campath/campath_test.go
+// usage: go test
+
+package campath
+
+import (
+	"math"
+	"testing"
+)
+
+func key(t, x float64) Keyframe {
+	return Keyframe{Time: t, Position: [3]float64{x, 1, 0}, Target: [3]float64{x, 0, 0}}
+}
+
+func TestAdd(t *testing.T) {
+	var p Path
+	p.Add(key(2, 2))
+	p.Add(key(0, 0))
+	p.Add(key(1, 1))
+	p.Add(key(1, 5))
+	if len(p.Keyframes) != 3 || p.Keyframes[1].Position[0] != 5 || p.Duration() != 2 {
+		t.Fatalf("unexpected keyframes %+v", p.Keyframes)
+	}
+	if err := p.Validate(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestAt(t *testing.T) {
+	p := Path{Keyframes: []Keyframe{key(0, 0), key(1, 1), key(3, 2), key(4, 3)}}
+	// The curve passes through every keyframe.
+	for _, k := range p.Keyframes {
+		pos, target := p.At(k.Time)
+		if math.Abs(pos[0]-k.Position[0]) > 1e-6 || math.Abs(target[0]-k.Target[0]) > 1e-6 {
+			t.Errorf("at %gs: expected %v, got %v", k.Time, k.Position, pos)
+		}
+	}
+	// Evenly spaced collinear points interpolate linearly.
+	if pos, _ := p.At(2); math.Abs(pos[0]-1.5) > 1e-6 || pos[1] != 1 {
+		t.Errorf("at 2s: expected x 1.5, got %v", pos)
+	}
+	if pos, _ := p.At(-1); pos[0] != 0 {
+		t.Errorf("before the start: expected the first keyframe, got %v", pos)
+	}
+	if pos, _ := p.At(10); pos[0] != 3 {
+		t.Errorf("after the end: expected the last keyframe, got %v", pos)
+	}
+}
+
+func TestMarshal(t *testing.T) {
+	p := &Path{Keyframes: []Keyframe{key(0, 0), key(2, 1)}}
+	data, err := Marshal(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	q, err := Unmarshal(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(q.Keyframes) != 2 || q.Keyframes[1] != p.Keyframes[1] {
+		t.Errorf("expected %+v, got %+v", p, q)
+	}
+	if _, err := Unmarshal([]byte(`{"keyframes":[{"time":1},{"time":1}]}`)); err == nil {
+		t.Error("expected an error for repeated times")
+	}
+	if _, err := Unmarshal([]byte(`{}`)); err == nil {
+		t.Error("expected an error for an empty path")
+	}
+}
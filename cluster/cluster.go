@@ -0,0 +1,169 @@
+// cluster/cluster.go
+// Package cluster groups the points of a cloud into separate objects and
+// summarizes each group, for counting and measuring things such as trees,
+// vehicles or parts in a scan.
+package cluster
+
+import (
+	"encoding/csv"
+	"errors"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+
+	"github.com/sbecker11/webgl-point-cloud/spatial"
+)
+
+// Noise is the label of points in no cluster.
+const Noise = -1
+
+// Euclidean labels packed xyz coordinates by Euclidean clustering: points
+// closer than radius, directly or through a chain of such points, share a
+// cluster. Clusters of fewer than minPoints points are labelled Noise. The
+// rest are numbered from 0 in order of decreasing size. It returns the
+// label of each point and the number of clusters.
+func Euclidean(coords []float32, radius float64, minPoints int) ([]int32, int, error) {
+	if radius <= 0 {
+		return nil, 0, errors.New("cluster: radius must be positive")
+	}
+	n := len(coords) / 3
+	grid := spatial.NewGrid(coords, radius)
+	labels := make([]int32, n)
+	for i := range labels {
+		labels[i] = Noise
+	}
+	var sizes []int
+	var queue []int
+	for seed := 0; seed < n; seed++ {
+		if labels[seed] != Noise {
+			continue
+		}
+		id := int32(len(sizes))
+		labels[seed] = id
+		size := 0
+		queue = append(queue[:0], seed)
+		for len(queue) > 0 {
+			i := queue[len(queue)-1]
+			queue = queue[:len(queue)-1]
+			size++
+			p := [3]float64{float64(coords[i*3]), float64(coords[i*3+1]), float64(coords[i*3+2])}
+			grid.Within(p, radius, func(j int, d2 float64) {
+				if labels[j] == Noise {
+					labels[j] = id
+					queue = append(queue, j)
+				}
+			})
+		}
+		sizes = append(sizes, size)
+	}
+
+	// Renumber by decreasing size, dropping small clusters.
+	order := make([]int, len(sizes))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(a, b int) bool { return sizes[order[a]] > sizes[order[b]] })
+	relabel := make([]int32, len(sizes))
+	count := 0
+	for _, id := range order {
+		relabel[id] = Noise
+		if sizes[id] >= minPoints {
+			relabel[id] = int32(count)
+			count++
+		}
+	}
+	for i, l := range labels {
+		labels[i] = relabel[l]
+	}
+	return labels, count, nil
+}
+
+// Stats summarizes one cluster.
+type Stats struct {
+	Label     int        `json:"label"`
+	Points    int        `json:"points"`
+	Centroid  [3]float64 `json:"centroid"`
+	Min       [3]float64 `json:"min"`
+	Max       [3]float64 `json:"max"`
+	MeanColor [4]float64 `json:"meanColor"` // RGBA in [0, 1]
+}
+
+// Size returns the extent of the cluster's axis-aligned bounding box.
+func (s Stats) Size() [3]float64 {
+	return [3]float64{s.Max[0] - s.Min[0], s.Max[1] - s.Min[1], s.Max[2] - s.Min[2]}
+}
+
+// Summarize returns the stats of each cluster labelled in labels, ordered
+// by label, from packed xyz coordinates and packed RGBA colors, which may
+// be nil. Noise points are left out.
+func Summarize(coords, colors []float32, labels []int32) []Stats {
+	var stats []Stats
+	for i, l := range labels {
+		if l < 0 {
+			continue
+		}
+		for int(l) >= len(stats) {
+			s := Stats{Label: len(stats)}
+			for k := 0; k < 3; k++ {
+				s.Min[k], s.Max[k] = math.Inf(1), math.Inf(-1)
+			}
+			stats = append(stats, s)
+		}
+		s := &stats[l]
+		s.Points++
+		for k := 0; k < 3; k++ {
+			v := float64(coords[i*3+k])
+			s.Centroid[k] += v
+			s.Min[k], s.Max[k] = math.Min(s.Min[k], v), math.Max(s.Max[k], v)
+		}
+		if len(colors) >= i*4+4 {
+			for k := 0; k < 4; k++ {
+				s.MeanColor[k] += float64(colors[i*4+k])
+			}
+		}
+	}
+	// Labels no point has are left empty; drop them.
+	out := stats[:0]
+	for _, s := range stats {
+		if s.Points == 0 {
+			continue
+		}
+		for k := 0; k < 3; k++ {
+			s.Centroid[k] /= float64(s.Points)
+		}
+		for k := 0; k < 4; k++ {
+			s.MeanColor[k] /= float64(s.Points)
+		}
+		out = append(out, s)
+	}
+	return out
+}
+
+// WriteCSV writes stats as CSV with a header row, one row per cluster.
+func WriteCSV(w io.Writer, stats []Stats) error {
+	cw := csv.NewWriter(w)
+	header := []string{"label", "points",
+		"centroid_x", "centroid_y", "centroid_z",
+		"min_x", "min_y", "min_z", "max_x", "max_y", "max_z",
+		"size_x", "size_y", "size_z",
+		"color_r", "color_g", "color_b", "color_a"}
+	if err := cw.Write(header); err != nil {
+		return err
+	}
+	f := func(v float64) string { return strconv.FormatFloat(v, 'g', 7, 64) }
+	for _, s := range stats {
+		row := []string{strconv.Itoa(s.Label), strconv.Itoa(s.Points)}
+		size := s.Size()
+		for _, v := range [][]float64{s.Centroid[:], s.Min[:], s.Max[:], size[:], s.MeanColor[:]} {
+			for _, x := range v {
+				row = append(row, f(x))
+			}
+		}
+		if err := cw.Write(row); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
@@ -0,0 +1,82 @@
+// cluster/cluster_test.go
+// usage: go test
+
+package cluster
+
+import (
+	"math"
+	"strings"
+	"testing"
+)
+
+// blob returns an n by n by n lattice with spacing 0.1 and minimum corner
+// (x, y, z).
+func blob(n int, x, y, z float32) []float32 {
+	var coords []float32
+	for i := 0; i < n; i++ {
+		for j := 0; j < n; j++ {
+			for k := 0; k < n; k++ {
+				coords = append(coords, x+float32(i)*0.1, y+float32(j)*0.1, z+float32(k)*0.1)
+			}
+		}
+	}
+	return coords
+}
+
+func TestEuclidean(t *testing.T) {
+	coords := append(blob(3, 0, 0, 0), blob(4, 5, 0, 0)...)
+	coords = append(coords, 10, 10, 10) // a lone point
+	labels, n, err := Euclidean(coords, 0.15, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 2 {
+		t.Fatalf("expected 2 clusters, got %d", n)
+	}
+	// The larger blob is cluster 0.
+	if labels[0] != 1 || labels[27] != 0 || labels[len(labels)-1] != Noise {
+		t.Errorf("expected labels 1, 0 and noise, got %d, %d and %d", labels[0], labels[27], labels[len(labels)-1])
+	}
+	for i := 0; i < 27; i++ {
+		if labels[i] != 1 {
+			t.Fatalf("point %d: expected cluster 1, got %d", i, labels[i])
+		}
+	}
+	if _, _, err := Euclidean(coords, 0, 1); err == nil {
+		t.Error("expected an error for a zero radius")
+	}
+}
+
+func TestSummarize(t *testing.T) {
+	coords := []float32{0, 0, 0, 2, 0, 0, 5, 5, 5, 9, 9, 9}
+	colors := []float32{1, 0, 0, 1, 0, 0, 1, 1, 0, 1, 0, 1, 1, 1, 1, 1}
+	stats := Summarize(coords, colors, []int32{0, 0, 1, Noise})
+	if len(stats) != 2 {
+		t.Fatalf("expected 2 clusters, got %d", len(stats))
+	}
+	s := stats[0]
+	if s.Points != 2 || s.Centroid != [3]float64{1, 0, 0} || s.Size() != [3]float64{2, 0, 0} {
+		t.Errorf("unexpected stats for cluster 0: %+v", s)
+	}
+	if math.Abs(s.MeanColor[0]-0.5) > 1e-9 || math.Abs(s.MeanColor[2]-0.5) > 1e-9 {
+		t.Errorf("expected mean color (0.5, 0, 0.5, 1), got %v", s.MeanColor)
+	}
+	if stats[1].Label != 1 || stats[1].Min != [3]float64{5, 5, 5} {
+		t.Errorf("unexpected stats for cluster 1: %+v", stats[1])
+	}
+}
+
+func TestWriteCSV(t *testing.T) {
+	var b strings.Builder
+	stats := Summarize([]float32{0, 0, 0, 1, 2, 3}, nil, []int32{0, 0})
+	if err := WriteCSV(&b, stats); err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(b.String()), "\n")
+	if len(lines) != 2 || !strings.HasPrefix(lines[0], "label,points,centroid_x") {
+		t.Fatalf("unexpected CSV:\n%s", b.String())
+	}
+	if want := "0,2,0.5,1,1.5,0,0,0,1,2,3,1,2,3,0,0,0,0"; lines[1] != want {
+		t.Errorf("expected row %q, got %q", want, lines[1])
+	}
+}
@@ -0,0 +1,44 @@
+// cmd/internal/cliutil/cliutil.go
+// Package cliutil holds what the point cloud commands share: their
+// argument parsing and the loading of their inputs.
+package cliutil
+
+import (
+	"flag"
+	"strings"
+
+	"github.com/sbecker11/webgl-point-cloud/importer"
+	"github.com/sbecker11/webgl-point-cloud/pointcloud"
+	"github.com/sbecker11/webgl-point-cloud/procgen"
+)
+
+// ParseArgs parses flags with fs wherever they appear among args and
+// returns the other arguments, so that flags may follow the files.
+// Everything after a "--" is an argument, e.g. a file named "-x".
+func ParseArgs(fs *flag.FlagSet, args []string) []string {
+	var rest []string
+	for {
+		fs.Parse(args)
+		if n := len(args) - fs.NArg(); n > 0 && args[n-1] == "--" {
+			return append(rest, fs.Args()...)
+		}
+		args = fs.Args()
+		if len(args) == 0 {
+			return rest
+		}
+		rest = append(rest, args[0])
+		args = args[1:]
+	}
+}
+
+// Load reads input, a file importer.ReadFile reads or dataset:<name>,
+// sampling meshes and generating datasets with numPoints points. It
+// reports whether the cloud's z axis is up, as in LAS files.
+func Load(input string, numPoints int, seed int64) (*pointcloud.Cloud, bool, error) {
+	if name, ok := strings.CutPrefix(input, "dataset:"); ok {
+		cloud, err := procgen.New(seed).Dataset(name, numPoints)
+		return cloud, false, err
+	}
+	cloud, err := importer.ReadFile(input, numPoints, seed)
+	return cloud, importer.PointFormat(input, "") == "las", err
+}
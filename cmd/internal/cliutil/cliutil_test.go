@@ -0,0 +1,35 @@
+// cmd/internal/cliutil/cliutil_test.go
+// usage: go test
+
+package cliutil
+
+import (
+	"flag"
+	"reflect"
+	"testing"
+)
+
+func TestParseArgs(t *testing.T) {
+	for _, tc := range []struct {
+		args, want []string
+		view       string
+	}{
+		{[]string{"a.las", "--view", "top", "b.las"}, []string{"a.las", "b.las"}, "top"},
+		{[]string{"-view=side", "a.las"}, []string{"a.las"}, "side"},
+		{[]string{"a.las", "--", "-b.las", "-view=top"}, []string{"a.las", "-b.las", "-view=top"}, "iso"},
+		{[]string{"--", "--"}, []string{"--"}, "iso"},
+	} {
+		fs := flag.NewFlagSet("test", flag.ContinueOnError)
+		view := fs.String("view", "iso", "")
+		if got := ParseArgs(fs, tc.args); !reflect.DeepEqual(got, tc.want) || *view != tc.view {
+			t.Errorf("ParseArgs(%q): expected %q with view %s, got %q with view %s", tc.args, tc.want, tc.view, got, *view)
+		}
+	}
+}
+
+func TestLoadDataset(t *testing.T) {
+	cloud, zUp, err := Load("dataset:town", 500, 1)
+	if err != nil || zUp || cloud.Len() == 0 {
+		t.Errorf("Load(dataset:town): got %v points, z up %v, %v", cloud, zUp, err)
+	}
+}
@@ -0,0 +1,103 @@
+// cmd/pcconvert/main.go
+
+// Command pcconvert converts point clouds between file formats, optionally
+// rendering a preview image of the result without a browser or GPU:
+//
+//	pcconvert input.las output.ply --preview out.png
+//	pcconvert --view top scan.xyz scan.csv
+//
+// Inputs are LAS, PLY and CSV files, OBJ and STL meshes, sampled into
+// points, and procedural datasets named dataset:<name>. The output's
+// extension picks its format: binary PLY or CSV, with positions in the
+// input's full coordinates and, where the input has them, colors,
+// normals, intensities and classes. --preview draws the cloud fitted to a
+// PNG image with the software rasterizer, as pcshot does.
+//
+// build: go build -o pcconvert ./cmd/pcconvert
+package main
+
+import (
+	"flag"
+	"fmt"
+	"image/color"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/sbecker11/webgl-point-cloud/cmd/internal/cliutil"
+	"github.com/sbecker11/webgl-point-cloud/softrender"
+)
+
+func main() {
+	fs := flag.NewFlagSet("pcconvert", flag.ExitOnError)
+	preview := fs.String("preview", "", "also render the cloud to this PNG")
+	view := fs.String("view", "iso", "preview view: "+strings.Join(softrender.ViewNames(), ", "))
+	up := fs.String("up", "auto", "preview up axis: y, z, or auto (z for LAS files, y otherwise)")
+	points := fs.Int("points", 100000, "points sampled from meshes and generated for datasets")
+	seed := fs.Int64("seed", 1, "seed for sampling meshes and generating datasets")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "usage: pcconvert [flags] input output (LAS, PLY, CSV, OBJ or STL file, or dataset:<name>, to .ply or .csv)")
+		fs.PrintDefaults()
+	}
+	args := cliutil.ParseArgs(fs, os.Args[1:])
+	if len(args) != 2 {
+		fs.Usage()
+		os.Exit(2)
+	}
+	input, output := args[0], args[1]
+	write, ok := writers[strings.ToLower(strings.TrimPrefix(filepath.Ext(output), "."))]
+	if !ok {
+		fatal(fmt.Errorf("%s: output must be .ply or .csv", output))
+	}
+	if !slices.Contains(softrender.ViewNames(), *view) {
+		fatal(fmt.Errorf("unknown view %q", *view))
+	}
+	if *up != "y" && *up != "z" && *up != "auto" {
+		fatal(fmt.Errorf("unknown up axis %q", *up))
+	}
+
+	cloud, zUp, err := cliutil.Load(input, *points, *seed)
+	if err != nil {
+		fatal(fmt.Errorf("%s: %v", input, err))
+	}
+	f, err := os.Create(output)
+	if err != nil {
+		fatal(err)
+	}
+	if err := write(f, cloud); err != nil {
+		f.Close()
+		fatal(fmt.Errorf("%s: %v", output, err))
+	}
+	if err := f.Close(); err != nil {
+		fatal(err)
+	}
+	fmt.Printf("%s: %d points -> %s\n", input, cloud.Len(), output)
+
+	if *preview == "" {
+		return
+	}
+	if *up != "auto" {
+		zUp = *up == "z"
+	}
+	shot, err := softrender.Shoot(cloud, softrender.ShotOptions{
+		View:       *view,
+		Width:      1280,
+		Height:     720,
+		PointSize:  2,
+		Background: color.RGBA{0, 0x1a, 0x40, 255},
+		ZUp:        zUp,
+	})
+	if err != nil {
+		fatal(err)
+	}
+	if err := shot.SavePNG(*preview); err != nil {
+		fatal(err)
+	}
+	fmt.Printf("%s: preview -> %s\n", input, *preview)
+}
+
+func fatal(err error) {
+	fmt.Fprintln(os.Stderr, "pcconvert:", err)
+	os.Exit(1)
+}
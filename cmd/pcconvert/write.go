@@ -0,0 +1,135 @@
+// cmd/pcconvert/write.go
+package main
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+
+	"github.com/sbecker11/webgl-point-cloud/pointcloud"
+)
+
+// writers write clouds in the formats named by output extensions.
+var writers = map[string]func(io.Writer, *pointcloud.Cloud) error{
+	"ply": WritePLY,
+	"csv": WriteCSV,
+}
+
+// byteColor returns a color component in [0, 1] as a byte.
+func byteColor(c float32) uint8 {
+	return uint8(math.Round(float64(max(0, min(1, c))) * 255))
+}
+
+// WritePLY writes cloud as a binary little-endian PLY file of vertices:
+// positions plus the cloud's Offset as doubles, colors as bytes, and
+// normals, intensities and classes where the cloud has them, as
+// importer.ReadPLY reads them. A georeferenced cloud's CRS is kept in a
+// comment.
+func WritePLY(w io.Writer, cloud *pointcloud.Cloud) error {
+	_, intensity, hasIntensity := cloud.Attribute("intensity")
+	bw := bufio.NewWriter(w)
+	fmt.Fprintf(bw, "ply\nformat binary_little_endian 1.0\n")
+	if cloud.CRS != "" {
+		fmt.Fprintf(bw, "comment crs %s\n", cloud.CRS)
+	}
+	fmt.Fprintf(bw, "element vertex %d\n", cloud.Len())
+	fmt.Fprintf(bw, "property double x\nproperty double y\nproperty double z\n")
+	if cloud.Normals != nil {
+		fmt.Fprintf(bw, "property float nx\nproperty float ny\nproperty float nz\n")
+	}
+	fmt.Fprintf(bw, "property uchar red\nproperty uchar green\nproperty uchar blue\nproperty uchar alpha\n")
+	if hasIntensity {
+		fmt.Fprintf(bw, "property float intensity\n")
+	}
+	if cloud.Classes != nil {
+		fmt.Fprintf(bw, "property uchar classification\n")
+	}
+	fmt.Fprintf(bw, "end_header\n")
+
+	le := binary.LittleEndian
+	var record []byte
+	for i := 0; i < cloud.Len(); i++ {
+		record = record[:0]
+		for k := 0; k < 3; k++ {
+			record = le.AppendUint64(record, math.Float64bits(coord(cloud.Coords[i*3+k], cloud.Offset[k])))
+		}
+		if cloud.Normals != nil {
+			for k := 0; k < 3; k++ {
+				record = le.AppendUint32(record, math.Float32bits(cloud.Normals[i*3+k]))
+			}
+		}
+		for k := 0; k < 4; k++ {
+			record = append(record, byteColor(cloud.Colors[i*4+k]))
+		}
+		if hasIntensity {
+			record = le.AppendUint32(record, math.Float32bits(intensity[i]))
+		}
+		if cloud.Classes != nil {
+			record = append(record, cloud.Classes[i])
+		}
+		bw.Write(record)
+	}
+	return bw.Flush()
+}
+
+// WriteCSV writes cloud as CSV with a header row, one point per line:
+// positions plus the cloud's Offset, colors from 0 to 255, and normals,
+// intensities and classes where the cloud has them, as importer.ReadCSV
+// reads them.
+func WriteCSV(w io.Writer, cloud *pointcloud.Cloud) error {
+	_, intensity, hasIntensity := cloud.Attribute("intensity")
+	bw := bufio.NewWriter(w)
+	bw.WriteString("x,y,z,red,green,blue,alpha")
+	if cloud.Normals != nil {
+		bw.WriteString(",nx,ny,nz")
+	}
+	if hasIntensity {
+		bw.WriteString(",intensity")
+	}
+	if cloud.Classes != nil {
+		bw.WriteString(",classification")
+	}
+	bw.WriteString("\n")
+
+	var line []byte
+	for i := 0; i < cloud.Len(); i++ {
+		line = line[:0]
+		for k := 0; k < 3; k++ {
+			if k > 0 {
+				line = append(line, ',')
+			}
+			line = strconv.AppendFloat(line, coord(cloud.Coords[i*3+k], cloud.Offset[k]), 'f', -1, 64)
+		}
+		for k := 0; k < 4; k++ {
+			line = append(line, ',')
+			line = strconv.AppendUint(line, uint64(byteColor(cloud.Colors[i*4+k])), 10)
+		}
+		if cloud.Normals != nil {
+			for k := 0; k < 3; k++ {
+				line = append(line, ',')
+				line = strconv.AppendFloat(line, float64(cloud.Normals[i*3+k]), 'g', -1, 32)
+			}
+		}
+		if hasIntensity {
+			line = append(line, ',')
+			line = strconv.AppendFloat(line, float64(intensity[i]), 'g', -1, 32)
+		}
+		if cloud.Classes != nil {
+			line = append(line, ',')
+			line = strconv.AppendUint(line, uint64(cloud.Classes[i]), 10)
+		}
+		line = append(line, '\n')
+		bw.Write(line)
+	}
+	return bw.Flush()
+}
+
+// coord returns the full coordinate v+offset, taking v to be only as
+// precise as a float32, so 0.1 stays 0.1 rather than 0.10000000149.
+func coord(v float32, offset float64) float64 {
+	exact, _ := strconv.ParseFloat(strconv.FormatFloat(float64(v), 'g', -1, 32), 64)
+	return exact + offset
+}
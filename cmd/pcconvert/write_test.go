@@ -0,0 +1,90 @@
+// cmd/pcconvert/write_test.go
+// usage: go test
+
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/sbecker11/webgl-point-cloud/importer"
+	"github.com/sbecker11/webgl-point-cloud/pointcloud"
+)
+
+// testCloud returns a georeferenced cloud of two points with every
+// attribute the writers keep.
+func testCloud() *pointcloud.Cloud {
+	cloud := pointcloud.New("scan", []float32{0.1, 0, 0.5, 1.5, 1.25, 2.5}, []float32{1, 0, 0, 1, 0, 0.5, 1, 1})
+	cloud.Offset = [3]float64{500100, 4000200, 12}
+	cloud.Normals = []float32{0, 0, 1, 0, 1, 0}
+	cloud.Classes = []uint8{2, 6}
+	cloud.SetAttribute(pointcloud.Attribute{Name: "intensity", Components: 1, Type: pointcloud.Float32}, []float32{1, 0.25})
+	return cloud
+}
+
+func TestWritersRoundTrip(t *testing.T) {
+	for format, read := range map[string]func(string, *bytes.Reader) (*pointcloud.Cloud, error){
+		"ply": func(name string, r *bytes.Reader) (*pointcloud.Cloud, error) { return importer.ReadPLY(name, r) },
+		"csv": func(name string, r *bytes.Reader) (*pointcloud.Cloud, error) { return importer.ReadCSV(name, r) },
+	} {
+		var b bytes.Buffer
+		if err := writers[format](&b, testCloud()); err != nil {
+			t.Fatalf("%s: write failed: %v", format, err)
+		}
+		cloud, err := read("scan", bytes.NewReader(b.Bytes()))
+		if err != nil {
+			t.Fatalf("%s: read back failed: %v", format, err)
+		}
+		if cloud.Len() != 2 || cloud.Offset != [3]float64{500100, 4000200, 12} {
+			t.Fatalf("%s: expected 2 points from offset (500100, 4000200, 12), got %d from %v", format, cloud.Len(), cloud.Offset)
+		}
+		for i, want := range []float32{0.1, 0, 0.5, 1.5, 1.25, 2.5} {
+			if d := cloud.Coords[i] - want; d > 1e-6 || d < -1e-6 {
+				t.Errorf("%s: expected coords %v, got %v", format, []float32{0.1, 0, 0.5, 1.5, 1.25, 2.5}, cloud.Coords)
+				break
+			}
+		}
+		if c := cloud.Colors; c[0] != 1 || c[1] != 0 || c[5] != 128.0/255 || c[7] != 1 {
+			t.Errorf("%s: unexpected colors %v", format, c)
+		}
+		if n := cloud.Normals; len(n) != 6 || n[2] != 1 || n[4] != 1 {
+			t.Errorf("%s: unexpected normals %v", format, n)
+		}
+		if c := cloud.Classes; len(c) != 2 || c[0] != 2 || c[1] != 6 {
+			t.Errorf("%s: expected classes [2 6], got %v", format, c)
+		}
+		if _, v, ok := cloud.Attribute("intensity"); !ok || v[0] != 1 || v[1] != 0.25 {
+			t.Errorf("%s: expected intensities [1 0.25], got %v", format, v)
+		}
+	}
+}
+
+func TestWriteCSV(t *testing.T) {
+	var b bytes.Buffer
+	if err := WriteCSV(&b, pointcloud.New("p", []float32{0.1, 2, 3}, []float32{1, 0.5, 0, 1})); err != nil {
+		t.Fatal(err)
+	}
+	if want := "x,y,z,red,green,blue,alpha\n0.1,2,3,255,128,0,255\n"; b.String() != want {
+		t.Errorf("expected %q, got %q", want, b.String())
+	}
+}
+
+func TestWritePLYHeader(t *testing.T) {
+	cloud := testCloud()
+	cloud.CRS = "EPSG:32633"
+	var b bytes.Buffer
+	if err := WritePLY(&b, cloud); err != nil {
+		t.Fatal(err)
+	}
+	header, _, _ := strings.Cut(b.String(), "end_header\n")
+	for _, line := range []string{"format binary_little_endian 1.0", "comment crs EPSG:32633", "element vertex 2", "property float nx", "property float intensity", "property uchar classification"} {
+		if !strings.Contains(header, line+"\n") {
+			t.Errorf("expected %q in the header, got %q", line, header)
+		}
+	}
+	// Each vertex is 3 doubles, 3 floats, 4 bytes, a float and a byte.
+	if body := b.Len() - len(header) - len("end_header\n"); body != 2*(24+12+4+4+1) {
+		t.Errorf("expected 2 records of 45 bytes, got %d bytes", body)
+	}
+}
@@ -0,0 +1,133 @@
+// cmd/pcshot/main.go
+
+// Command pcshot renders point clouds to PNG images without a browser or
+// GPU, for batch-generating previews of many datasets in pipelines:
+//
+//	pcshot input.las --view top --size 1920x1080 -o out.png
+//	pcshot -o previews/ scans/*.las dataset:town
+//
+// Inputs are LAS, PLY and CSV files, OBJ and STL meshes, sampled into
+// points, and procedural datasets named dataset:<name>. Each is fitted to
+// the image and drawn with the software rasterizer (softrender.Shoot).
+// With several inputs, -o names a directory the images are written to,
+// each named after its input, as with a single input and an existing
+// directory; by default each image is written beside its input.
+//
+// build: go build -o pcshot ./cmd/pcshot
+package main
+
+import (
+	"flag"
+	"fmt"
+	"image/color"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/sbecker11/webgl-point-cloud/cmd/internal/cliutil"
+	"github.com/sbecker11/webgl-point-cloud/softrender"
+)
+
+func main() {
+	fs := flag.NewFlagSet("pcshot", flag.ExitOnError)
+	view := fs.String("view", "iso", "view: "+strings.Join(softrender.ViewNames(), ", "))
+	size := fs.String("size", "1280x720", "image size, WIDTHxHEIGHT")
+	out := fs.String("o", "", "output PNG, or directory with several inputs")
+	pointSize := fs.Float64("point-size", 2, "point diameter in pixels")
+	background := fs.String("background", "#001a40", "background color, #rrggbb")
+	colorMode := fs.String("color", "rgb", "point colors: rgb, classification, height or intensity")
+	up := fs.String("up", "auto", "up axis: y, z, or auto (z for LAS files, y otherwise)")
+	points := fs.Int("points", 100000, "points sampled from meshes and generated for datasets")
+	seed := fs.Int64("seed", 1, "seed for sampling meshes and generating datasets")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "usage: pcshot [flags] input... (LAS, PLY, CSV, OBJ or STL files, or dataset:<name>)")
+		fs.PrintDefaults()
+	}
+	inputs := cliutil.ParseArgs(fs, os.Args[1:])
+	if len(inputs) == 0 {
+		fs.Usage()
+		os.Exit(2)
+	}
+
+	opts := softrender.ShotOptions{View: *view, PointSize: float32(*pointSize), Color: *colorMode}
+	var err error
+	if opts.Width, opts.Height, err = parseSize(*size); err != nil {
+		fatal(err)
+	}
+	if opts.Background, err = parseColor(*background); err != nil {
+		fatal(err)
+	}
+	toDir := false
+	if info, err := os.Stat(*out); *out != "" && (len(inputs) > 1 || err == nil && info.IsDir()) {
+		if err := os.MkdirAll(*out, 0o755); err != nil {
+			fatal(err)
+		}
+		toDir = true
+	}
+	for _, input := range inputs {
+		cloud, zUp, err := cliutil.Load(input, *points, *seed)
+		if err != nil {
+			fatal(fmt.Errorf("%s: %v", input, err))
+		}
+		switch *up {
+		case "y", "z":
+			zUp = *up == "z"
+		case "auto":
+		default:
+			fatal(fmt.Errorf("unknown up axis %q", *up))
+		}
+		opts.ZUp = zUp
+		shot, err := softrender.Shoot(cloud, opts)
+		if err != nil {
+			fatal(fmt.Errorf("%s: %v", input, err))
+		}
+		path := outputPath(input, *out, toDir)
+		if err := shot.SavePNG(path); err != nil {
+			fatal(err)
+		}
+		fmt.Printf("%s: %d points -> %s\n", input, cloud.Len(), path)
+	}
+}
+
+// parseSize parses "WIDTHxHEIGHT".
+func parseSize(s string) (width, height int, err error) {
+	w, h, ok := strings.Cut(s, "x")
+	width, errW := strconv.Atoi(w)
+	height, errH := strconv.Atoi(h)
+	if !ok || errW != nil || errH != nil || width <= 0 || height <= 0 {
+		return 0, 0, fmt.Errorf("size %q is not WIDTHxHEIGHT", s)
+	}
+	return width, height, nil
+}
+
+// parseColor parses "#rrggbb" as an opaque color.
+func parseColor(s string) (color.RGBA, error) {
+	v, err := strconv.ParseUint(strings.TrimPrefix(s, "#"), 16, 32)
+	if err != nil || len(strings.TrimPrefix(s, "#")) != 6 {
+		return color.RGBA{}, fmt.Errorf("color %q is not #rrggbb", s)
+	}
+	return color.RGBA{uint8(v >> 16), uint8(v >> 8), uint8(v), 255}, nil
+}
+
+// outputPath returns where the image of input goes: out itself, a file
+// named after input in out if toDir, or beside input when out is empty.
+func outputPath(input, out string, toDir bool) string {
+	name := strings.TrimPrefix(input, "dataset:")
+	name = strings.TrimSuffix(filepath.Base(name), filepath.Ext(name)) + ".png"
+	switch {
+	case out == "":
+		if strings.HasPrefix(input, "dataset:") {
+			return name
+		}
+		return filepath.Join(filepath.Dir(input), name)
+	case toDir:
+		return filepath.Join(out, name)
+	}
+	return out
+}
+
+func fatal(err error) {
+	fmt.Fprintln(os.Stderr, "pcshot:", err)
+	os.Exit(1)
+}
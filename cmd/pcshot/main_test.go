@@ -0,0 +1,38 @@
+// cmd/pcshot/main_test.go
+// usage: go test
+
+package main
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestParseSize(t *testing.T) {
+	if w, h, err := parseSize("1920x1080"); err != nil || w != 1920 || h != 1080 {
+		t.Errorf("parseSize(1920x1080): got %d, %d, %v", w, h, err)
+	}
+	for _, s := range []string{"1920", "0x10", "axb", "10x-1"} {
+		if _, _, err := parseSize(s); err == nil {
+			t.Errorf("parseSize(%q): expected an error", s)
+		}
+	}
+}
+
+func TestOutputPath(t *testing.T) {
+	for _, tc := range []struct {
+		input, out string
+		toDir      bool
+		want       string
+	}{
+		{"scans/a.las", "", false, filepath.Join("scans", "a.png")},
+		{"scans/a.las", "x.png", false, "x.png"},
+		{"scans/a.las", "shots", true, filepath.Join("shots", "a.png")},
+		{"dataset:town", "", false, "town.png"},
+		{"dataset:town", "shots", true, filepath.Join("shots", "town.png")},
+	} {
+		if got := outputPath(tc.input, tc.out, tc.toDir); got != tc.want {
+			t.Errorf("outputPath(%q, %q, %v): expected %q, got %q", tc.input, tc.out, tc.toDir, tc.want, got)
+		}
+	}
+}
@@ -0,0 +1,111 @@
+// colormap/colormap.go
+package colormap
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Stop is a color at position T in [0, 1] along a colormap.
+type Stop struct {
+	T       float32
+	R, G, B float32
+}
+
+// Map is a piecewise-linear colormap defined by stops in increasing T order.
+type Map struct {
+	Name  string
+	Stops []Stop
+}
+
+// Rainbow runs blue, cyan, green, yellow, red: the classic height ramp.
+var Rainbow = Map{Name: "rainbow", Stops: []Stop{
+	{0, 0, 0, 1},
+	{0.25, 0, 1, 1},
+	{0.5, 0, 1, 0},
+	{0.75, 1, 1, 0},
+	{1, 1, 0, 0},
+}}
+
+// Terrain runs from deep water through sand, grass and rock to snow.
+var Terrain = Map{Name: "terrain", Stops: []Stop{
+	{0, 0.1, 0.2, 0.5},
+	{0.2, 0.2, 0.5, 0.8},
+	{0.25, 0.85, 0.8, 0.55},
+	{0.35, 0.3, 0.6, 0.25},
+	{0.65, 0.4, 0.35, 0.25},
+	{0.85, 0.55, 0.5, 0.45},
+	{1, 1, 1, 1},
+}}
+
+// Grayscale runs from black to white.
+var Grayscale = Map{Name: "grayscale", Stops: []Stop{
+	{0, 0, 0, 0},
+	{1, 1, 1, 1},
+}}
+
+// CoolWarm is a diverging map from blue through light gray to red, for
+// signed values such as distances either side of a reference.
+var CoolWarm = Map{Name: "coolwarm", Stops: []Stop{
+	{0, 0.23, 0.3, 0.75},
+	{0.5, 0.87, 0.87, 0.87},
+	{1, 0.71, 0.02, 0.15},
+}}
+
+var maps = map[string]Map{}
+
+func init() {
+	for _, m := range []Map{Rainbow, Terrain, Grayscale, CoolWarm} {
+		Register(m)
+	}
+}
+
+// Register makes m available through Lookup under m.Name, replacing any
+// colormap with the same name.
+func Register(m Map) {
+	maps[m.Name] = m
+}
+
+// Lookup returns the registered colormap with the given name.
+func Lookup(name string) (Map, error) {
+	m, ok := maps[name]
+	if !ok {
+		return Map{}, fmt.Errorf("unknown colormap %q (expected one of %s)", name, strings.Join(Names(), ", "))
+	}
+	return m, nil
+}
+
+// Names returns the names of the registered colormaps, sorted.
+func Names() []string {
+	names := make([]string, 0, len(maps))
+	for name := range maps {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// At returns the color at t, clamping t to [0, 1].
+func (m Map) At(t float32) (r, g, b float32) {
+	if len(m.Stops) == 0 {
+		return 0, 0, 0
+	}
+	first, last := m.Stops[0], m.Stops[len(m.Stops)-1]
+	if t <= first.T {
+		return first.R, first.G, first.B
+	}
+	if t >= last.T {
+		return last.R, last.G, last.B
+	}
+	i := sort.Search(len(m.Stops), func(i int) bool { return m.Stops[i].T >= t })
+	a, b2 := m.Stops[i-1], m.Stops[i]
+	f := (t - a.T) / (b2.T - a.T)
+	return a.R + (b2.R-a.R)*f, a.G + (b2.G-a.G)*f, a.B + (b2.B-a.B)*f
+}
+
+// AppendRGBA appends the color at t, with alpha 1, to colors.
+func (m Map) AppendRGBA(colors []float32, t float32) []float32 {
+	r, g, b := m.At(t)
+	return append(colors, r, g, b, 1)
+}
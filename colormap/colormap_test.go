@@ -0,0 +1,45 @@
+// colormap/colormap_test.go
+// usage: go test
+
+package colormap
+
+import (
+	"math"
+	"testing"
+)
+
+func almostEqual(a, b float32) bool {
+	return math.Abs(float64(a-b)) <= 1e-6
+}
+
+func TestAt(t *testing.T) {
+	tests := []struct {
+		t       float32
+		r, g, b float32
+	}{
+		{-1, 0, 0, 1},
+		{0, 0, 0, 1},
+		{0.125, 0, 0.5, 1},
+		{0.5, 0, 1, 0},
+		{0.875, 1, 0.5, 0},
+		{2, 1, 0, 0},
+	}
+	for _, tc := range tests {
+		r, g, b := Rainbow.At(tc.t)
+		if !almostEqual(r, tc.r) || !almostEqual(g, tc.g) || !almostEqual(b, tc.b) {
+			t.Errorf("Rainbow.At(%f): expected (%f, %f, %f), got (%f, %f, %f)", tc.t, tc.r, tc.g, tc.b, r, g, b)
+		}
+	}
+}
+
+func TestLookup(t *testing.T) {
+	for _, name := range Names() {
+		m, err := Lookup(name)
+		if err != nil || m.Name != name {
+			t.Errorf("Lookup(%q): got %q, %v", name, m.Name, err)
+		}
+	}
+	if _, err := Lookup("no-such-map"); err == nil {
+		t.Error("Lookup: expected an error for an unknown colormap")
+	}
+}
@@ -0,0 +1,82 @@
+// compare/compare.go
+// Package compare measures how point clouds differ, for deformation
+// monitoring and change detection between scans of the same scene.
+package compare
+
+import (
+	"errors"
+	"math"
+
+	"github.com/sbecker11/webgl-point-cloud/spatial"
+)
+
+// ErrNoNormals is returned for signed distances to a reference without one
+// normal per point.
+var ErrNoNormals = errors.New("compare: signed distances need a normal per reference point")
+
+// Distances returns, for each point of packed xyz coordinates, the distance
+// to the nearest point of the reference coordinates, or NaN when the
+// reference is empty. When signed, a distance is negative if the point lies
+// behind the surface of the reference, judged by the normal of its nearest
+// reference point, so a surface that moved outwards shows positive values.
+func Distances(coords, ref, refNormals []float32, signed bool) ([]float32, error) {
+	if signed && len(refNormals) != len(ref) {
+		return nil, ErrNoNormals
+	}
+	tree := spatial.NewKDTree(ref)
+	out := make([]float32, len(coords)/3)
+	for i := range out {
+		p := [3]float64{float64(coords[i*3]), float64(coords[i*3+1]), float64(coords[i*3+2])}
+		j, d := tree.Nearest(p, -1)
+		if j < 0 {
+			out[i] = float32(math.NaN())
+			continue
+		}
+		if signed {
+			side := 0.0
+			for k := 0; k < 3; k++ {
+				side += (p[k] - float64(ref[j*3+k])) * float64(refNormals[j*3+k])
+			}
+			if side < 0 {
+				d = -d
+			}
+		}
+		out[i] = float32(d)
+	}
+	return out, nil
+}
+
+// Summary describes a set of distances, ignoring NaNs.
+type Summary struct {
+	Count    int
+	Min, Max float64
+	Mean     float64
+	RMS      float64
+}
+
+// Summarize returns the summary of distances, with zero ranges when there
+// are none.
+func Summarize(distances []float32) Summary {
+	var s Summary
+	sum, sum2 := 0.0, 0.0
+	for _, v := range distances {
+		d := float64(v)
+		if math.IsNaN(d) {
+			continue
+		}
+		if s.Count == 0 || d < s.Min {
+			s.Min = d
+		}
+		if s.Count == 0 || d > s.Max {
+			s.Max = d
+		}
+		s.Count++
+		sum += d
+		sum2 += d * d
+	}
+	if s.Count > 0 {
+		s.Mean = sum / float64(s.Count)
+		s.RMS = math.Sqrt(sum2 / float64(s.Count))
+	}
+	return s
+}
@@ -0,0 +1,63 @@
+// compare/compare_test.go
+// usage: go test
+
+package compare
+
+import (
+	"math"
+	"testing"
+)
+
+// plane returns an n by n grid of points on y = height with spacing 0.1,
+// and normals pointing up.
+func plane(n int, height float32) (coords, normals []float32) {
+	for i := 0; i < n; i++ {
+		for j := 0; j < n; j++ {
+			coords = append(coords, float32(i)*0.1, height, float32(j)*0.1)
+			normals = append(normals, 0, 1, 0)
+		}
+	}
+	return coords, normals
+}
+
+func TestDistances(t *testing.T) {
+	ref, normals := plane(20, 0)
+	above, _ := plane(20, 0.3)
+	below, _ := plane(20, -0.2)
+
+	d, err := Distances(above, ref, nil, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, v := range d {
+		if math.Abs(float64(v)-0.3) > 1e-6 {
+			t.Fatalf("point %d: expected distance 0.3, got %f", i, v)
+		}
+	}
+
+	d, err = Distances(append(above, below...), ref, normals, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := Summarize(d)
+	if math.Abs(s.Max-0.3) > 1e-6 || math.Abs(s.Min+0.2) > 1e-6 || s.Count != 800 {
+		t.Errorf("expected signed distances from -0.2 to 0.3 over 800 points, got %+v", s)
+	}
+	if math.Abs(s.Mean-0.05) > 1e-6 {
+		t.Errorf("expected mean 0.05, got %f", s.Mean)
+	}
+}
+
+func TestDistancesErrors(t *testing.T) {
+	ref, _ := plane(2, 0)
+	if _, err := Distances(ref, ref, nil, true); err != ErrNoNormals {
+		t.Errorf("expected ErrNoNormals, got %v", err)
+	}
+	d, err := Distances([]float32{0, 0, 0}, nil, nil, false)
+	if err != nil || !math.IsNaN(float64(d[0])) {
+		t.Errorf("expected NaN against an empty reference, got %v, %v", d, err)
+	}
+	if s := Summarize(d); s.Count != 0 || s.Mean != 0 {
+		t.Errorf("expected an empty summary, got %+v", s)
+	}
+}
@@ -0,0 +1,178 @@
+// compare/octree.go
+package compare
+
+import (
+	"errors"
+	"math"
+)
+
+// maxDepth is the deepest octree whose leaf coordinates fit a 63-bit
+// Morton code.
+const maxDepth = 21
+
+// Octree records which leaf cells of an octree over a cubic region hold
+// points. It is a linear octree: only the occupied leaves are stored,
+// keyed by the Morton code of their cell coordinates, so two clouds are
+// compared by comparing sets of keys.
+type Octree struct {
+	Origin [3]float64 // minimum corner of the region
+	Size   float64    // side of the region
+	Depth  int        // levels below the root; leaves have side Size / 2^Depth
+	leaves map[uint64]bool
+}
+
+// NewOctree returns an empty octree over the cube with minimum corner
+// origin and side size, with leaves no larger than cellSize.
+func NewOctree(origin [3]float64, size, cellSize float64) (*Octree, error) {
+	if size <= 0 || cellSize <= 0 {
+		return nil, errors.New("compare: octree size and cell size must be positive")
+	}
+	depth := int(math.Ceil(math.Log2(size / cellSize)))
+	depth = max(0, min(depth, maxDepth))
+	return &Octree{Origin: origin, Size: size, Depth: depth, leaves: map[uint64]bool{}}, nil
+}
+
+// CellSize returns the side of the leaf cells.
+func (t *Octree) CellSize() float64 {
+	return t.Size / float64(uint64(1)<<t.Depth)
+}
+
+// cell returns the leaf coordinates of p, clamped to the region.
+func (t *Octree) cell(p [3]float64) [3]int64 {
+	n := int64(1) << t.Depth
+	var c [3]int64
+	for k := 0; k < 3; k++ {
+		c[k] = min(max(int64(math.Floor((p[k]-t.Origin[k])/t.CellSize())), 0), n-1)
+	}
+	return c
+}
+
+// key returns the Morton code of leaf coordinates c, or false if they are
+// outside the region.
+func (t *Octree) key(c [3]int64) (uint64, bool) {
+	n := int64(1) << t.Depth
+	var code uint64
+	for k := 0; k < 3; k++ {
+		if c[k] < 0 || c[k] >= n {
+			return 0, false
+		}
+		code |= spread(uint64(c[k])) << k
+	}
+	return code, true
+}
+
+// spread spaces the low 21 bits of v three bits apart.
+func spread(v uint64) uint64 {
+	v &= 0x1fffff
+	v = (v | v<<32) & 0x1f00000000ffff
+	v = (v | v<<16) & 0x1f0000ff0000ff
+	v = (v | v<<8) & 0x100f00f00f00f00f
+	v = (v | v<<4) & 0x10c30c30c30c30c3
+	v = (v | v<<2) & 0x1249249249249249
+	return v
+}
+
+// Insert marks the leaves holding the points of packed xyz coordinates as
+// occupied. Points outside the region count in the nearest leaf.
+func (t *Octree) Insert(coords []float32) {
+	for i := 0; i+2 < len(coords); i += 3 {
+		k, _ := t.key(t.cell([3]float64{float64(coords[i]), float64(coords[i+1]), float64(coords[i+2])}))
+		t.leaves[k] = true
+	}
+}
+
+// Leaves returns the number of occupied leaves.
+func (t *Octree) Leaves() int {
+	return len(t.leaves)
+}
+
+// Occupied reports whether the leaf holding p is occupied.
+func (t *Octree) Occupied(p [3]float64) bool {
+	k, _ := t.key(t.cell(p))
+	return t.leaves[k]
+}
+
+// Change is how a point differs between two frames.
+type Change uint8
+
+const (
+	Unchanged Change = iota
+	Added            // in a leaf only the later frame occupies
+	Removed          // in a leaf only the earlier frame occupies
+	Moved            // added or removed, with the opposite change nearby
+)
+
+// Changes compares two frames of packed xyz coordinates by the occupancy
+// of octree leaves no larger than cellSize over both. Points of after in
+// leaves before does not occupy are Added, and points of before in leaves
+// after does not occupy are Removed. Either becomes Moved when a leaf with
+// the opposite change lies within moveCells leaves, as when an object
+// shifts a little between frames; a moveCells of 0 reports no moves.
+// It returns the change of each point of before and of after.
+func Changes(before, after []float32, cellSize float64, moveCells int) (beforeChanges, afterChanges []Change, err error) {
+	lo, hi := [3]float64{math.Inf(1), math.Inf(1), math.Inf(1)}, [3]float64{math.Inf(-1), math.Inf(-1), math.Inf(-1)}
+	for _, coords := range [][]float32{before, after} {
+		for i := 0; i+2 < len(coords); i += 3 {
+			for k := 0; k < 3; k++ {
+				lo[k], hi[k] = math.Min(lo[k], float64(coords[i+k])), math.Max(hi[k], float64(coords[i+k]))
+			}
+		}
+	}
+	if lo[0] > hi[0] {
+		return []Change{}, []Change{}, nil
+	}
+	size := math.Max(math.Max(hi[0]-lo[0], hi[1]-lo[1]), hi[2]-lo[2])
+	// Pad the region so points on its far faces fall inside it.
+	size = math.Max(size, cellSize) * (1 + 1e-6)
+	a, err := NewOctree(lo, size, cellSize)
+	if err != nil {
+		return nil, nil, err
+	}
+	b, _ := NewOctree(lo, size, cellSize)
+	a.Insert(before)
+	b.Insert(after)
+	return a.changes(before, b, Removed, moveCells), b.changes(after, a, Added, moveCells), nil
+}
+
+// changes returns the change of each of the points of coords, which t
+// holds, against the other frame's octree: unchanged if other occupies the
+// point's leaf, otherwise change, or Moved if a leaf within moveCells is
+// occupied by other but not by t.
+func (t *Octree) changes(coords []float32, other *Octree, change Change, moveCells int) []Change {
+	out := make([]Change, len(coords)/3)
+	moved := map[uint64]bool{}
+	for i := range out {
+		c := t.cell([3]float64{float64(coords[i*3]), float64(coords[i*3+1]), float64(coords[i*3+2])})
+		k, _ := t.key(c)
+		if other.leaves[k] {
+			continue
+		}
+		m, ok := moved[k]
+		if !ok {
+			m = t.vacatedNear(c, other, moveCells)
+			moved[k] = m
+		}
+		out[i] = change
+		if m {
+			out[i] = Moved
+		}
+	}
+	return out
+}
+
+// vacatedNear reports whether a leaf within r of leaf c is occupied by
+// other but not by t.
+func (t *Octree) vacatedNear(c [3]int64, other *Octree, r int) bool {
+	d := int64(r)
+	for dx := -d; dx <= d; dx++ {
+		for dy := -d; dy <= d; dy++ {
+			for dz := -d; dz <= d; dz++ {
+				k, ok := t.key([3]int64{c[0] + dx, c[1] + dy, c[2] + dz})
+				if ok && other.leaves[k] && !t.leaves[k] {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
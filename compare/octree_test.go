@@ -0,0 +1,98 @@
+// compare/octree_test.go
+// usage: go test
+
+package compare
+
+import "testing"
+
+// block returns the points of an n by n by n lattice with spacing 0.1 and
+// minimum corner (x, y, z).
+func block(n int, x, y, z float32) []float32 {
+	var coords []float32
+	for i := 0; i < n; i++ {
+		for j := 0; j < n; j++ {
+			for k := 0; k < n; k++ {
+				coords = append(coords, x+float32(i)*0.1, y+float32(j)*0.1, z+float32(k)*0.1)
+			}
+		}
+	}
+	return coords
+}
+
+func count(changes []Change) map[Change]int {
+	counts := map[Change]int{}
+	for _, c := range changes {
+		counts[c]++
+	}
+	return counts
+}
+
+func TestSpread(t *testing.T) {
+	if got := spread(0x1fffff); got != 0x1249249249249249 {
+		t.Errorf("expected every third bit set, got %#x", got)
+	}
+	if got := spread(5); got != 0x41 {
+		t.Errorf("expected 0x41, got %#x", got)
+	}
+}
+
+func TestChanges(t *testing.T) {
+	static := block(5, 0, 0, 0)
+	removed := block(3, 5, 0, 0)
+	added := block(3, 0, 0, 5)
+	// A small object nudged one cell along x.
+	shiftedFrom, shiftedTo := block(2, 5, 5, 5), block(2, 5.5, 5, 5)
+
+	before := append(append(append([]float32{}, static...), removed...), shiftedFrom...)
+	after := append(append(append([]float32{}, static...), added...), shiftedTo...)
+	b, a, err := Changes(before, after, 0.5, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := count(b); got[Unchanged] != 125 || got[Removed] != 27 || got[Moved] != 8 {
+		t.Errorf("before: expected 125 unchanged, 27 removed and 8 moved, got %v", got)
+	}
+	if got := count(a); got[Unchanged] != 125 || got[Added] != 27 || got[Moved] != 8 {
+		t.Errorf("after: expected 125 unchanged, 27 added and 8 moved, got %v", got)
+	}
+
+	// Without a move radius the shifted object is removed and added.
+	b, a, _ = Changes(before, after, 0.5, 0)
+	if got := count(b); got[Removed] != 35 || got[Moved] != 0 {
+		t.Errorf("expected 35 removed without moves, got %v", got)
+	}
+	if got := count(a); got[Added] != 35 {
+		t.Errorf("expected 35 added without moves, got %v", got)
+	}
+}
+
+func TestChangesEdgeCases(t *testing.T) {
+	b, a, err := Changes(nil, nil, 0.5, 1)
+	if err != nil || len(b) != 0 || len(a) != 0 {
+		t.Errorf("expected no changes for empty frames, got %v, %v, %v", b, a, err)
+	}
+	if _, _, err := Changes(block(2, 0, 0, 0), nil, 0, 1); err == nil {
+		t.Error("expected an error for a zero cell size")
+	}
+	_, a, _ = Changes(nil, block(2, 0, 0, 0), 0.5, 1)
+	if got := count(a); got[Added] != 8 {
+		t.Errorf("expected every point added to an empty frame, got %v", got)
+	}
+}
+
+func TestOctree(t *testing.T) {
+	tree, err := NewOctree([3]float64{0, 0, 0}, 8, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tree.Depth != 3 || tree.CellSize() != 1 {
+		t.Errorf("expected depth 3 and unit cells, got %d and %f", tree.Depth, tree.CellSize())
+	}
+	tree.Insert([]float32{0.5, 0.5, 0.5, 0.6, 0.6, 0.6, 7.5, 0.5, 3.5})
+	if tree.Leaves() != 2 {
+		t.Errorf("expected 2 occupied leaves, got %d", tree.Leaves())
+	}
+	if !tree.Occupied([3]float64{0.9, 0.1, 0.2}) || tree.Occupied([3]float64{1.1, 0.1, 0.2}) {
+		t.Error("expected only the first leaf occupied around the origin")
+	}
+}
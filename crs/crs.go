@@ -0,0 +1,146 @@
+// crs/crs.go
+// Package crs identifies coordinate reference systems by EPSG code, reads
+// them from the WKT and GeoKeys of LAS headers and reprojects coordinates
+// between them, so that datasets in different systems can be shown
+// together. E57 files are not read: the viewer imports none, though their
+// coordinateMetadata WKT parses as any other.
+package crs
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// CRS is a coordinate reference system identified by its EPSG code. The
+// zero CRS is unknown, e.g. the local coordinates of a scan or a model.
+type CRS struct {
+	EPSG int
+}
+
+// WGS84 is geographic WGS 84, with x the longitude and y the latitude in
+// degrees (the traditional GIS axis order) and z the ellipsoidal height.
+var WGS84 = CRS{EPSG: 4326}
+
+// UTM returns the WGS 84 / UTM CRS of a zone, from 1 to 60, in the
+// northern or southern hemisphere.
+func UTM(zone int, north bool) CRS {
+	if north {
+		return CRS{EPSG: 32600 + zone}
+	}
+	return CRS{EPSG: 32700 + zone}
+}
+
+// UTMZone returns the zone of a WGS 84 / UTM CRS and whether it is in the
+// northern hemisphere. It reports false for other systems.
+func (c CRS) UTMZone() (zone int, north, ok bool) {
+	switch {
+	case c.EPSG > 32600 && c.EPSG <= 32660:
+		return c.EPSG - 32600, true, true
+	case c.EPSG > 32700 && c.EPSG <= 32760:
+		return c.EPSG - 32700, false, true
+	}
+	return 0, false, false
+}
+
+// IsZero reports whether the CRS is unknown.
+func (c CRS) IsZero() bool {
+	return c.EPSG == 0
+}
+
+// String returns the CRS as "EPSG:<code>", or "" if it is unknown.
+func (c CRS) String() string {
+	if c.IsZero() {
+		return ""
+	}
+	return "EPSG:" + strconv.Itoa(c.EPSG)
+}
+
+var epsgCode = regexp.MustCompile(`(?i)^(?:EPSG:|urn:ogc:def:crs:EPSG:[^:]*:|https?://www\.opengis\.net/def/crs/EPSG/[^/]*/)(\d+)$`)
+
+// Parse reads a CRS written as "EPSG:32633", an OGC URN or URL such as
+// "urn:ogc:def:crs:EPSG::32633", or WKT 1 or 2, as stored in LAS 1.4
+// files and E57 coordinateMetadata. The EPSG code of WKT is that of its
+// horizontal CRS, the projected, geographic or geocentric one, so that a
+// compound CRS has the code of its horizontal part rather than its
+// vertical datum's; other WKT has the code of its outermost element. An
+// empty string is the unknown CRS.
+func Parse(s string) (CRS, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return CRS{}, nil
+	}
+	if m := epsgCode.FindStringSubmatch(s); m != nil {
+		return code(m[1])
+	}
+	if strings.ContainsAny(s, "[(") {
+		n, err := parseWKT(s)
+		if err != nil {
+			return CRS{}, err
+		}
+		c := n.horizontalEPSG()
+		if c == "" {
+			c = n.epsg()
+		}
+		if c == "" {
+			return CRS{}, fmt.Errorf("crs: WKT has no EPSG code")
+		}
+		return code(c)
+	}
+	return CRS{}, fmt.Errorf("crs: unrecognized CRS %q", s)
+}
+
+// code returns the CRS of a decimal EPSG code.
+func code(s string) (CRS, error) {
+	n, err := strconv.Atoi(s)
+	if err != nil || n <= 0 {
+		return CRS{}, fmt.Errorf("crs: invalid EPSG code %q", s)
+	}
+	return CRS{EPSG: n}, nil
+}
+
+// GeoTIFF keys giving a CRS's EPSG code.
+const (
+	geoKeyGeographicType  = 2048
+	geoKeyProjectedCSType = 3072
+	geoKeyUserDefined     = 32767
+)
+
+// ParseGeoKeys reads the CRS from a GeoTIFF GeoKeyDirectoryTag, the
+// payload of the "LASF_Projection" variable length record 34735 of LAS
+// files before 1.4: a header of four values, the last the number of keys,
+// followed by four values per key. The projected CRS is preferred to the
+// geographic one.
+func ParseGeoKeys(directory []uint16) (CRS, error) {
+	if len(directory) < 4 {
+		return CRS{}, fmt.Errorf("crs: GeoKey directory too short")
+	}
+	n := int(directory[3])
+	if len(directory) < 4+4*n {
+		return CRS{}, fmt.Errorf("crs: GeoKey directory has %d keys but room for %d", n, (len(directory)-4)/4)
+	}
+	var projected, geographic int
+	for k := 0; k < n; k++ {
+		entry := directory[4+4*k : 8+4*k]
+		// Codes are stored inline, with a tag location of 0.
+		if entry[1] != 0 {
+			continue
+		}
+		switch entry[0] {
+		case geoKeyProjectedCSType:
+			projected = int(entry[3])
+		case geoKeyGeographicType:
+			geographic = int(entry[3])
+		}
+	}
+	switch {
+	case projected != 0 && projected != geoKeyUserDefined:
+		return CRS{EPSG: projected}, nil
+	case geographic != 0 && geographic != geoKeyUserDefined:
+		return CRS{EPSG: geographic}, nil
+	case projected != 0 || geographic != 0:
+		return CRS{}, fmt.Errorf("crs: user-defined CRS has no EPSG code")
+	}
+	return CRS{}, fmt.Errorf("crs: GeoKey directory names no CRS")
+}
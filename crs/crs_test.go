@@ -0,0 +1,148 @@
+// crs/crs_test.go
+// usage: go test
+
+package crs
+
+import (
+	"math"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	wkt := `PROJCS["WGS 84 / UTM zone 33N",GEOGCS["WGS 84",DATUM["WGS_1984",SPHEROID["WGS 84",6378137,298.257223563,AUTHORITY["EPSG","7030"]],AUTHORITY["EPSG","6326"]],AUTHORITY["EPSG","4326"]],PROJECTION["Transverse_Mercator"],AUTHORITY["EPSG","32633"]]`
+	for s, want := range map[string]int{
+		"EPSG:32633":                  32633,
+		"epsg:4326":                   4326,
+		"urn:ogc:def:crs:EPSG::32633": 32633,
+		"http://www.opengis.net/def/crs/EPSG/0/32718": 32718,
+		wkt: 32633,
+		`PROJCRS["x",BASEGEOGCRS["WGS 84",ID["EPSG",4326]],ID["EPSG",32610]]`: 32610,
+		"": 0,
+	} {
+		c, err := Parse(s)
+		if err != nil {
+			t.Errorf("%q: %v", s, err)
+		} else if c.EPSG != want {
+			t.Errorf("%q: expected EPSG %d, got %d", s, want, c.EPSG)
+		}
+	}
+	for _, s := range []string{"UTM 33", "EPSG:x", `LOCAL_CS["scan"]`, `PROJCS["x",AUTHORITY["EPSG","32633"]`} {
+		if _, err := Parse(s); err == nil {
+			t.Errorf("%q: expected an error", s)
+		}
+	}
+}
+
+func TestParseCompound(t *testing.T) {
+	for _, s := range []string{
+		`COMPD_CS["WGS 84 / UTM zone 33N + EGM96 height",` +
+			`PROJCS["WGS 84 / UTM zone 33N",GEOGCS["WGS 84",DATUM["WGS_1984",SPHEROID["WGS 84",6378137,298.257223563]],AUTHORITY["EPSG","4326"]],` +
+			`PROJECTION["Transverse_Mercator"],UNIT["metre",1],AXIS["Easting",EAST],AXIS["Northing",NORTH],AUTHORITY["EPSG","32633"]],` +
+			`VERT_CS["EGM96 height",VERT_DATUM["EGM96 geoid",2005,AUTHORITY["EPSG","5171"]],UNIT["metre",1],AUTHORITY["EPSG","5773"]]]`,
+		`COMPOUNDCRS["UTM 33N + EGM96",PROJCRS["WGS 84 / UTM zone 33N",BASEGEOGCRS["WGS 84",ID["EPSG",4326]],ID["EPSG",32633]],` +
+			`VERTCRS["EGM96 height",VDATUM["EGM96 geoid"],ID["EPSG",5773]]]`,
+		`COMPD_CS("x",PROJCS("y",AUTHORITY("EPSG","32633")),VERT_CS("z",AUTHORITY("EPSG","5773")))`,
+	} {
+		if c, err := Parse(s); err != nil || c.EPSG != 32633 {
+			t.Errorf("%.40q...: expected EPSG:32633, got %v, %v", s, c, err)
+		}
+	}
+}
+
+func TestParseGeoKeys(t *testing.T) {
+	keys := []uint16{1, 1, 0, 3,
+		1024, 0, 1, 1, // model type projected
+		2048, 0, 1, 4326,
+		3072, 0, 1, 32633,
+	}
+	if c, err := ParseGeoKeys(keys); err != nil || c.EPSG != 32633 {
+		t.Errorf("expected EPSG:32633, got %v, %v", c, err)
+	}
+	if c, err := ParseGeoKeys(keys[:12]); err == nil {
+		t.Errorf("expected an error for a truncated directory, got %v", c)
+	}
+	if c, err := ParseGeoKeys([]uint16{1, 1, 0, 1, 2048, 0, 1, 4326}); err != nil || c != WGS84 {
+		t.Errorf("expected WGS 84, got %v, %v", c, err)
+	}
+	if _, err := ParseGeoKeys([]uint16{1, 1, 0, 1, 3072, 0, 1, 32767}); err == nil {
+		t.Error("expected an error for a user-defined CRS")
+	}
+}
+
+func TestUTM(t *testing.T) {
+	c := UTM(33, true)
+	if zone, north, ok := c.UTMZone(); !ok || zone != 33 || !north || c.String() != "EPSG:32633" {
+		t.Errorf("unexpected zone %d %v %v for %s", zone, north, ok, c)
+	}
+	if _, _, ok := WGS84.UTMZone(); ok {
+		t.Error("WGS 84 is not a UTM zone")
+	}
+	// On the central meridian, the easting is 500 km and the northing the
+	// meridian arc length, here to 45°N, times the scale factor.
+	e, n := toUTM(15, 45, 33, true)
+	if math.Abs(e-500000) > 1e-6 || math.Abs(n-0.9996*4984944.378) > 0.01 {
+		t.Errorf("expected (500000, %.2f), got (%.3f, %.3f)", 0.9996*4984944.378, e, n)
+	}
+	if e, n := toUTM(15, -10, 33, false); math.Abs(e-500000) > 1e-6 || math.Abs(n-(10000000-0.9996*1105854.833)) > 0.01 {
+		t.Errorf("southern hemisphere: got (%.3f, %.3f)", e, n)
+	}
+}
+
+func TestTransformer(t *testing.T) {
+	forward, err := NewTransformer(WGS84, UTM(33, true))
+	if err != nil {
+		t.Fatal(err)
+	}
+	inverse, err := NewTransformer(UTM(33, true), WGS84)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, p := range [][3]float64{{12.4924, 41.8902, 20}, {17.9, 70.1, 0}, {9.2, -33.5, -5}} {
+		q, _ := forward.Transform(p)
+		r, _ := inverse.Transform(q)
+		if math.Abs(r[0]-p[0]) > 1e-9 || math.Abs(r[1]-p[1]) > 1e-9 || r[2] != p[2] {
+			t.Errorf("round trip of %v gave %v", p, r)
+		}
+	}
+	// Zone to zone goes through WGS 84: a point on the edge between zones
+	// 32 and 33 is the same distance either side of their central meridians.
+	z32to33, err := NewTransformer(UTM(32, true), UTM(33, true))
+	if err != nil {
+		t.Fatal(err)
+	}
+	e, n := toUTM(12, 50, 32, true)
+	q, _ := z32to33.Transform([3]float64{e, n, 0})
+	if math.Abs((q[0]-500000)+(e-500000)) > 1e-6 || math.Abs(q[1]-n) > 1e-6 {
+		t.Errorf("expected the mirror image of (%.3f, %.3f), got %v", e, n, q)
+	}
+
+	if _, err := NewTransformer(CRS{}, WGS84); err == nil {
+		t.Error("expected an error for an unknown CRS")
+	}
+	custom := CRS{EPSG: 3857}
+	Register(WGS84, custom, TransformerFunc(func(p [3]float64) ([3]float64, error) { return [3]float64{p[0] * 2, p[1], p[2]}, nil }))
+	if tr, err := NewTransformer(WGS84, custom); err != nil {
+		t.Error(err)
+	} else if q, _ := tr.Transform([3]float64{1, 2, 3}); q != [3]float64{2, 2, 3} {
+		t.Errorf("expected the registered transformer, got %v", q)
+	}
+}
+
+func TestReprojector(t *testing.T) {
+	from := Frame{CRS: UTM(33, true), Origin: [3]float64{400000, 5000000, 100}}
+	to := Frame{CRS: UTM(33, true), Origin: [3]float64{400100, 5000050, 0}}
+	r, err := NewReprojector(from, to)
+	if err != nil {
+		t.Fatal(err)
+	}
+	out, err := r.Coords([]float32{0, 0, 0, 100, 50, 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []float32{-100, -50, 100, 0, 0, 101}
+	for i := range want {
+		if out[i] != want[i] {
+			t.Fatalf("expected %v, got %v", want, out)
+		}
+	}
+}
@@ -0,0 +1,152 @@
+// crs/transform.go
+package crs
+
+import (
+	"fmt"
+	"math"
+	"sync"
+)
+
+// Transformer converts coordinates from one CRS to another.
+type Transformer interface {
+	Transform(p [3]float64) ([3]float64, error)
+}
+
+// TransformerFunc adapts a function to a Transformer.
+type TransformerFunc func(p [3]float64) ([3]float64, error)
+
+// Transform calls f(p).
+func (f TransformerFunc) Transform(p [3]float64) ([3]float64, error) {
+	return f(p)
+}
+
+// pair is a source and destination CRS.
+type pair struct {
+	from, to CRS
+}
+
+var (
+	registryMu sync.RWMutex
+	registry   = map[pair]Transformer{}
+)
+
+// Register adds a transformer from one CRS to another, to support systems
+// beyond WGS 84 and UTM or to use a more accurate implementation. It takes
+// precedence over the built-in transformers.
+func Register(from, to CRS, t Transformer) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	registry[pair{from, to}] = t
+}
+
+// NewTransformer returns a transformer from one CRS to another: the one
+// registered for the pair if there is one, otherwise a built-in one. The
+// built-in transformers convert between WGS 84 and the WGS 84 / UTM zones,
+// and from any system to itself; heights are unchanged.
+func NewTransformer(from, to CRS) (Transformer, error) {
+	registryMu.RLock()
+	t, ok := registry[pair{from, to}]
+	registryMu.RUnlock()
+	if ok {
+		return t, nil
+	}
+	if from == to {
+		return TransformerFunc(func(p [3]float64) ([3]float64, error) { return p, nil }), nil
+	}
+	toGeo, err := geographic(from)
+	if err != nil {
+		return nil, err
+	}
+	fromGeo, err := projected(to)
+	if err != nil {
+		return nil, err
+	}
+	return TransformerFunc(func(p [3]float64) ([3]float64, error) {
+		return fromGeo(toGeo(p)), nil
+	}), nil
+}
+
+// geographic returns the conversion from c to WGS 84.
+func geographic(c CRS) (func(p [3]float64) [3]float64, error) {
+	if c == WGS84 {
+		return func(p [3]float64) [3]float64 { return p }, nil
+	}
+	if zone, north, ok := c.UTMZone(); ok {
+		return func(p [3]float64) [3]float64 {
+			lon, lat := fromUTM(p[0], p[1], zone, north)
+			return [3]float64{lon, lat, p[2]}
+		}, nil
+	}
+	return nil, unsupported(c)
+}
+
+// projected returns the conversion from WGS 84 to c.
+func projected(c CRS) (func(p [3]float64) [3]float64, error) {
+	if c == WGS84 {
+		return func(p [3]float64) [3]float64 { return p }, nil
+	}
+	if zone, north, ok := c.UTMZone(); ok {
+		return func(p [3]float64) [3]float64 {
+			e, n := toUTM(p[0], p[1], zone, north)
+			return [3]float64{e, n, p[2]}
+		}, nil
+	}
+	return nil, unsupported(c)
+}
+
+func unsupported(c CRS) error {
+	if c.IsZero() {
+		return fmt.Errorf("crs: cannot reproject coordinates in an unknown CRS")
+	}
+	return fmt.Errorf("crs: no transformer for %s; use Register to add one", c)
+}
+
+// Frame is a local frame in a CRS: coordinates relative to Origin, small
+// enough to keep their precision as float32 for rendering, e.g. a UTM
+// scene centred on its data.
+type Frame struct {
+	CRS    CRS
+	Origin [3]float64
+}
+
+// Reprojector converts local coordinates from one frame to another.
+type Reprojector struct {
+	from, to Frame
+	t        Transformer
+}
+
+// NewReprojector returns a reprojector from one frame to another.
+func NewReprojector(from, to Frame) (*Reprojector, error) {
+	t, err := NewTransformer(from.CRS, to.CRS)
+	if err != nil {
+		return nil, err
+	}
+	return &Reprojector{from: from, to: to, t: t}, nil
+}
+
+// Point converts a local point.
+func (r *Reprojector) Point(p [3]float64) ([3]float64, error) {
+	q, err := r.t.Transform([3]float64{p[0] + r.from.Origin[0], p[1] + r.from.Origin[1], p[2] + r.from.Origin[2]})
+	if err != nil {
+		return q, err
+	}
+	for k := range q {
+		if math.IsNaN(q[k]) || math.IsInf(q[k], 0) {
+			return q, fmt.Errorf("crs: (%g, %g, %g) has no position in %s", p[0], p[1], p[2], r.to.CRS)
+		}
+	}
+	return [3]float64{q[0] - r.to.Origin[0], q[1] - r.to.Origin[1], q[2] - r.to.Origin[2]}, nil
+}
+
+// Coords converts packed local xyz coordinates, returning new ones.
+func (r *Reprojector) Coords(coords []float32) ([]float32, error) {
+	out := make([]float32, len(coords))
+	for i := 0; i+2 < len(coords); i += 3 {
+		q, err := r.Point([3]float64{float64(coords[i]), float64(coords[i+1]), float64(coords[i+2])})
+		if err != nil {
+			return nil, err
+		}
+		out[i], out[i+1], out[i+2] = float32(q[0]), float32(q[1]), float32(q[2])
+	}
+	return out, nil
+}
@@ -0,0 +1,89 @@
+// crs/utm.go
+package crs
+
+import "math"
+
+// WGS 84 ellipsoid and UTM projection constants.
+const (
+	wgs84A       = 6378137.0
+	wgs84F       = 1 / 298.257223563
+	utmScale     = 0.9996
+	utmEasting   = 500000.0
+	utmSouthBase = 10000000.0
+)
+
+// Coefficients of Krüger's series for the transverse Mercator projection,
+// accurate to well under a millimetre within a UTM zone.
+var (
+	tmN     = wgs84F / (2 - wgs84F)
+	tmA     = wgs84A / (1 + tmN) * (1 + tmN*tmN/4 + tmN*tmN*tmN*tmN/64)
+	tmAlpha = [4]float64{
+		tmN/2 - 2*tmN*tmN/3 + 5*tmN*tmN*tmN/16 + 41*tmN*tmN*tmN*tmN/180,
+		13*tmN*tmN/48 - 3*tmN*tmN*tmN/5 + 557*tmN*tmN*tmN*tmN/1440,
+		61*tmN*tmN*tmN/240 - 103*tmN*tmN*tmN*tmN/140,
+		49561 * tmN * tmN * tmN * tmN / 161280,
+	}
+	tmBeta = [4]float64{
+		tmN/2 - 2*tmN*tmN/3 + 37*tmN*tmN*tmN/96 - tmN*tmN*tmN*tmN/360,
+		tmN*tmN/48 + tmN*tmN*tmN/15 - 437*tmN*tmN*tmN*tmN/1440,
+		17*tmN*tmN*tmN/480 - 37*tmN*tmN*tmN*tmN/840,
+		4397 * tmN * tmN * tmN * tmN / 161280,
+	}
+	tmDelta = [4]float64{
+		2*tmN - 2*tmN*tmN/3 - 2*tmN*tmN*tmN + 116*tmN*tmN*tmN*tmN/45,
+		7*tmN*tmN/3 - 8*tmN*tmN*tmN/5 - 227*tmN*tmN*tmN*tmN/45,
+		56*tmN*tmN*tmN/15 - 136*tmN*tmN*tmN*tmN/35,
+		4279 * tmN * tmN * tmN * tmN / 630,
+	}
+)
+
+// centralMeridian returns the central meridian of a UTM zone in radians.
+func centralMeridian(zone int) float64 {
+	return float64(zone*6-183) * math.Pi / 180
+}
+
+// toUTM projects a WGS 84 longitude and latitude in degrees to easting and
+// northing in metres in a UTM zone.
+func toUTM(lon, lat float64, zone int, north bool) (easting, northing float64) {
+	phi := lat * math.Pi / 180
+	lambda := lon*math.Pi/180 - centralMeridian(zone)
+	e := 2 * math.Sqrt(tmN) / (1 + tmN)
+	t := math.Sinh(math.Atanh(math.Sin(phi)) - e*math.Atanh(e*math.Sin(phi)))
+	xi := math.Atan2(t, math.Cos(lambda))
+	eta := math.Atanh(math.Sin(lambda) / math.Sqrt(1+t*t))
+	x, y := eta, xi
+	for j, a := range tmAlpha {
+		k := float64(2 * (j + 1))
+		x += a * math.Cos(k*xi) * math.Sinh(k*eta)
+		y += a * math.Sin(k*xi) * math.Cosh(k*eta)
+	}
+	easting = utmEasting + utmScale*tmA*x
+	northing = utmScale * tmA * y
+	if !north {
+		northing += utmSouthBase
+	}
+	return easting, northing
+}
+
+// fromUTM returns the WGS 84 longitude and latitude in degrees of an
+// easting and northing in metres in a UTM zone.
+func fromUTM(easting, northing float64, zone int, north bool) (lon, lat float64) {
+	if !north {
+		northing -= utmSouthBase
+	}
+	xi := northing / (utmScale * tmA)
+	eta := (easting - utmEasting) / (utmScale * tmA)
+	xiP, etaP := xi, eta
+	for j, b := range tmBeta {
+		k := float64(2 * (j + 1))
+		xiP -= b * math.Sin(k*xi) * math.Cosh(k*eta)
+		etaP -= b * math.Cos(k*xi) * math.Sinh(k*eta)
+	}
+	chi := math.Asin(math.Sin(xiP) / math.Cosh(etaP))
+	phi := chi
+	for j, d := range tmDelta {
+		phi += d * math.Sin(float64(2*(j+1))*chi)
+	}
+	lambda := centralMeridian(zone) + math.Atan2(math.Sinh(etaP), math.Cos(xiP))
+	return lambda * 180 / math.Pi, phi * 180 / math.Pi
+}
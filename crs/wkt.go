@@ -0,0 +1,163 @@
+// crs/wkt.go
+package crs
+
+import (
+	"fmt"
+	"strings"
+)
+
+// wktNode is an element of WKT, KEYWORD[value, ...], with the values that
+// are elements themselves kept apart from the rest.
+type wktNode struct {
+	keyword  string
+	values   []string // the quoted strings, numbers and enumerations
+	children []*wktNode
+}
+
+// horizontalCRS holds the WKT 1 and WKT 2 keywords of the CRS elements
+// placing points on or around the earth, as opposed to vertical, temporal
+// and engineering ones.
+var horizontalCRS = map[string]bool{
+	"PROJCS": true, "PROJCRS": true, "PROJECTEDCRS": true,
+	"GEOGCS": true, "GEOGCRS": true, "GEOGRAPHICCRS": true,
+	"GEOCCS": true, "GEODCRS": true, "GEODETICCRS": true,
+}
+
+// parseWKT parses well-known text, in WKT 1 or WKT 2 and with square or
+// round brackets.
+func parseWKT(s string) (*wktNode, error) {
+	p := &wktParser{s: s}
+	n, err := p.node()
+	if err != nil {
+		return nil, err
+	}
+	if p.skipSpace(); p.i < len(p.s) {
+		return nil, fmt.Errorf("crs: WKT has text after its end at %d", p.i)
+	}
+	return n, nil
+}
+
+// wktParser reads WKT from s, at byte i.
+type wktParser struct {
+	s string
+	i int
+}
+
+func (p *wktParser) skipSpace() {
+	for p.i < len(p.s) && strings.ContainsRune(" \t\r\n", rune(p.s[p.i])) {
+		p.i++
+	}
+}
+
+// node reads KEYWORD[value, ...].
+func (p *wktParser) node() (*wktNode, error) {
+	p.skipSpace()
+	start := p.i
+	for p.i < len(p.s) && isKeywordByte(p.s[p.i]) {
+		p.i++
+	}
+	n := &wktNode{keyword: strings.ToUpper(p.s[start:p.i])}
+	p.skipSpace()
+	if n.keyword == "" || p.i == len(p.s) || p.s[p.i] != '[' && p.s[p.i] != '(' {
+		return nil, fmt.Errorf("crs: WKT expected an element at %d", start)
+	}
+	p.i++
+	for {
+		p.skipSpace()
+		if p.i == len(p.s) {
+			return nil, fmt.Errorf("crs: WKT element %s is not closed", n.keyword)
+		}
+		switch c := p.s[p.i]; {
+		case c == '"':
+			value, err := p.quoted()
+			if err != nil {
+				return nil, err
+			}
+			n.values = append(n.values, value)
+		case c == '-' || c == '+' || c == '.' || '0' <= c && c <= '9':
+			start := p.i
+			for p.i < len(p.s) && !strings.ContainsRune(",])", rune(p.s[p.i])) {
+				p.i++
+			}
+			n.values = append(n.values, strings.TrimSpace(p.s[start:p.i]))
+		default:
+			// An element, or an enumeration such as NORTH.
+			start := p.i
+			child, err := p.node()
+			if err != nil {
+				p.i = start
+				for p.i < len(p.s) && !strings.ContainsRune(",])", rune(p.s[p.i])) {
+					p.i++
+				}
+				if p.i == start {
+					return nil, fmt.Errorf("crs: WKT expected a value at %d", start)
+				}
+				n.values = append(n.values, strings.TrimSpace(p.s[start:p.i]))
+				break
+			}
+			n.children = append(n.children, child)
+		}
+		p.skipSpace()
+		if p.i == len(p.s) {
+			return nil, fmt.Errorf("crs: WKT element %s is not closed", n.keyword)
+		}
+		switch p.s[p.i] {
+		case ',':
+			p.i++
+		case ']', ')':
+			p.i++
+			return n, nil
+		default:
+			return nil, fmt.Errorf("crs: WKT expected , or ] at %d", p.i)
+		}
+	}
+}
+
+// isKeywordByte reports whether c may be part of a keyword.
+func isKeywordByte(c byte) bool {
+	return c == '_' || 'A' <= c && c <= 'Z' || 'a' <= c && c <= 'z' || '0' <= c && c <= '9'
+}
+
+// quoted reads a double-quoted string, in which "" stands for ".
+func (p *wktParser) quoted() (string, error) {
+	var b strings.Builder
+	for p.i++; p.i < len(p.s); p.i++ {
+		if p.s[p.i] == '"' {
+			if p.i+1 < len(p.s) && p.s[p.i+1] == '"' {
+				b.WriteByte('"')
+				p.i++
+				continue
+			}
+			p.i++
+			return b.String(), nil
+		}
+		b.WriteByte(p.s[p.i])
+	}
+	return "", fmt.Errorf("crs: WKT string is not closed")
+}
+
+// epsg returns the EPSG code of the element's own AUTHORITY or ID, or "".
+func (n *wktNode) epsg() string {
+	for _, c := range n.children {
+		if (c.keyword == "AUTHORITY" || c.keyword == "ID") && len(c.values) >= 2 && strings.EqualFold(c.values[0], "EPSG") {
+			return c.values[1]
+		}
+	}
+	return ""
+}
+
+// horizontalEPSG returns the EPSG code of the horizontal CRS of n: its own
+// if it is one, or otherwise that of the first horizontal CRS among its
+// elements, such as the horizontal part of a compound CRS. The geographic
+// CRS a projected one is based on is not looked at.
+func (n *wktNode) horizontalEPSG() string {
+	if horizontalCRS[n.keyword] {
+		return n.epsg()
+	}
+	for _, c := range n.children {
+		if code := c.horizontalEPSG(); code != "" {
+			return code
+		}
+	}
+	return ""
+}
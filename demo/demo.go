@@ -0,0 +1,96 @@
+// demo/demo.go
+// Package demo defines the curated demo scenes: a procedural dataset with a
+// camera, colors and effects chosen to show it off. The viewer opens one
+// with ?demo=<name> and the server lists them on its start screen.
+package demo
+
+// Camera is where a scene's camera starts, in world coordinates.
+type Camera struct {
+	Position [3]float32
+	Target   [3]float32
+}
+
+// Scene is a demo scene.
+type Scene struct {
+	Name        string // the ?demo= value
+	Title       string
+	Description string
+
+	Dataset string // a procgen dataset name
+	Points  int    // points generated, per cluster for "clusters"
+	Seed    int64
+
+	Camera       Camera
+	Color        string     // color mode, as ?color= takes; "" keeps the points' colors
+	PointSize    float32    // in pixels
+	Background   [4]float32 // rgba in [0, 1]
+	Exaggeration float32    // vertical exaggeration; 0 for none
+	Grid         bool       // whether the grid and axes show
+	SSAO         bool       // screen-space ambient occlusion
+	Shadow       bool       // contact shadow under the points
+}
+
+// Scenes are the demo scenes, in the order the start screen lists them.
+var Scenes = []Scene{
+	{
+		Name:        "galaxy",
+		Title:       "Spiral galaxy",
+		Description: "Three arms of stars winding out from a bright core, seen from above the disc.",
+		Dataset:     "galaxy", Points: 80000, Seed: 7,
+		Camera:     Camera{Position: [3]float32{0, 1.9, 2.2}},
+		PointSize:  1.5,
+		Background: [4]float32{0, 0, 0.02, 1},
+	},
+	{
+		Name:        "terrain",
+		Title:       "Terrain",
+		Description: "Fractal hills colored by height, with their relief exaggerated and shaded by ambient occlusion.",
+		Dataset:     "terrain", Points: 120000, Seed: 3,
+		Camera:       Camera{Position: [3]float32{2.2, 1.4, 2.2}},
+		Color:        "height",
+		PointSize:    2.5,
+		Background:   [4]float32{0.55, 0.7, 0.85, 1},
+		Exaggeration: 2,
+		SSAO:         true,
+	},
+	{
+		Name:        "lorenz",
+		Title:       "Lorenz attractor",
+		Description: "The butterfly-shaped orbit of a chaotic system, traced point by point.",
+		Dataset:     "lorenz", Points: 60000, Seed: 1,
+		Camera:     Camera{Position: [3]float32{2.6, 0.6, 2}},
+		PointSize:  1.5,
+		Background: [4]float32{0.02, 0.02, 0.05, 1},
+	},
+	{
+		Name:        "clusters",
+		Title:       "Clusters",
+		Description: "Gaussian blobs of points floating over a contact shadow, as in the default scene.",
+		Dataset:     "clusters", Points: 5000, Seed: 42,
+		Camera:     Camera{Position: [3]float32{1.8, 1.5, 2.6}},
+		PointSize:  2,
+		Background: [4]float32{0, 0.1, 0.25, 1},
+		Grid:       true,
+		SSAO:       true,
+		Shadow:     true,
+	},
+}
+
+// Lookup returns the scene called name.
+func Lookup(name string) (Scene, bool) {
+	for _, s := range Scenes {
+		if s.Name == name {
+			return s, true
+		}
+	}
+	return Scene{}, false
+}
+
+// Names returns the names of the scenes, in order.
+func Names() []string {
+	names := make([]string, len(Scenes))
+	for i, s := range Scenes {
+		names[i] = s.Name
+	}
+	return names
+}
@@ -0,0 +1,48 @@
+// demo/demo_test.go
+// usage: go test
+
+package demo
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/sbecker11/webgl-point-cloud/procgen"
+)
+
+func TestScenesAreValid(t *testing.T) {
+	datasets := procgen.DatasetNames()
+	seen := map[string]bool{}
+	for _, s := range Scenes {
+		if s.Name == "" || seen[s.Name] {
+			t.Errorf("scene %q: names must be unique and not empty", s.Name)
+		}
+		seen[s.Name] = true
+		if !slices.Contains(datasets, s.Dataset) {
+			t.Errorf("scene %q: unknown dataset %q", s.Name, s.Dataset)
+		}
+		if s.Points <= 0 || s.PointSize <= 0 {
+			t.Errorf("scene %q: points and point size must be positive", s.Name)
+		}
+		if s.Camera.Position == s.Camera.Target {
+			t.Errorf("scene %q: the camera must not sit on its target", s.Name)
+		}
+		if !slices.Contains([]string{"", "rgb", "classification", "intensity", "height"}, s.Color) {
+			t.Errorf("scene %q: unknown color mode %q", s.Name, s.Color)
+		}
+	}
+}
+
+func TestLookup(t *testing.T) {
+	for _, name := range []string{"galaxy", "terrain", "lorenz", "clusters"} {
+		if s, ok := Lookup(name); !ok || s.Name != name {
+			t.Errorf("Lookup(%q): expected the scene, got %v", name, ok)
+		}
+	}
+	if _, ok := Lookup("nebula"); ok {
+		t.Errorf("Lookup(nebula): expected no scene")
+	}
+	if got := Names(); len(got) != len(Scenes) || got[0] != Scenes[0].Name {
+		t.Errorf("Names: expected the scenes' names in order, got %v", got)
+	}
+}
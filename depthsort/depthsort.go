@@ -0,0 +1,55 @@
+// depthsort/depthsort.go
+// Package depthsort orders points back to front by their depth, for
+// drawing translucent points so that each blends over the ones behind it.
+// The sort is a radix sort on the depths' bits, linear in the number of
+// points, as a comparison sort of a million points every time the view
+// changes would take too long.
+package depthsort
+
+import "math"
+
+// key maps a float to a uint32 that orders the same way: the sign bit of a
+// positive float is set, and every bit of a negative one is flipped.
+func key(f float32) uint32 {
+	b := math.Float32bits(f)
+	if b&0x80000000 != 0 {
+		return ^b
+	}
+	return b | 0x80000000
+}
+
+// BackToFront returns indices reordered by descending depth, the deepest
+// first, where depths[i] is the depth of indices[i]. Points of equal depth
+// keep their order. Neither argument is modified.
+func BackToFront(indices []uint32, depths []float32) []uint32 {
+	n := len(indices)
+	src := append([]uint32(nil), indices...)
+	if n == 0 {
+		return src
+	}
+	keys := make([]uint32, n)
+	for i, d := range depths[:n] {
+		keys[i] = ^key(d)
+	}
+	dstKeys, dst := make([]uint32, n), make([]uint32, n)
+	for shift := uint(0); shift < 32; shift += 8 {
+		var counts [257]int
+		for _, k := range keys {
+			counts[(k>>shift)&0xff+1]++
+		}
+		if counts[(keys[0]>>shift)&0xff+1] == n {
+			continue // every key has the same byte here
+		}
+		for b := 1; b < len(counts); b++ {
+			counts[b] += counts[b-1]
+		}
+		for i, k := range keys {
+			b := (k >> shift) & 0xff
+			dstKeys[counts[b]], dst[counts[b]] = k, src[i]
+			counts[b]++
+		}
+		keys, dstKeys = dstKeys, keys
+		src, dst = dst, src
+	}
+	return src
+}
@@ -0,0 +1,51 @@
+// depthsort/depthsort_test.go
+// usage: go test
+
+package depthsort
+
+import (
+	"math/rand"
+	"sort"
+	"testing"
+)
+
+func TestBackToFrontSortsByDescendingDepth(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	n := 10000
+	indices := make([]uint32, n)
+	depths := make([]float32, n)
+	for i := range indices {
+		indices[i] = uint32(n - i)
+		depths[i] = (r.Float32()*2 - 1) * 1000
+	}
+	depthOf := map[uint32]float32{}
+	for i, idx := range indices {
+		depthOf[idx] = depths[i]
+	}
+	got := BackToFront(indices, depths)
+	if len(got) != n {
+		t.Fatalf("expected %d indices, got %d", n, len(got))
+	}
+	if !sort.SliceIsSorted(got, func(i, j int) bool { return depthOf[got[i]] > depthOf[got[j]] }) {
+		t.Error("expected the indices in descending depth")
+	}
+	if indices[0] != uint32(n) {
+		t.Error("expected the indices left as they were")
+	}
+}
+
+func TestBackToFrontIsStable(t *testing.T) {
+	got := BackToFront([]uint32{0, 1, 2, 3, 4}, []float32{1, -2, 1, 0.5, -2})
+	want := []uint32{0, 2, 3, 1, 4}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("expected %v, got %v", want, got)
+		}
+	}
+}
+
+func TestBackToFrontEmpty(t *testing.T) {
+	if got := BackToFront(nil, nil); len(got) != 0 {
+		t.Errorf("expected no indices, got %v", got)
+	}
+}
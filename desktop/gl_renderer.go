@@ -0,0 +1,166 @@
+// desktop/gl_renderer.go
+//go:build glfw && !js
+
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/go-gl/gl/v2.1/gl"
+	"github.com/sbecker11/webgl-point-cloud/render"
+)
+
+// overlayVertexShader and overlayFragmentShader are the desktop program for
+// points and lines, with a position and color per vertex, like the
+// browser's line program.
+const (
+	overlayVertexShader = `#version 120
+attribute vec3 aPosition;
+attribute vec4 aColor;
+uniform mat4 uMvpMatrix;
+uniform float uPointSize;
+varying vec4 vColor;
+void main() {
+	gl_Position = uMvpMatrix * vec4(aPosition, 1.0);
+	gl_PointSize = uPointSize;
+	vColor = aColor;
+}`
+	overlayFragmentShader = `#version 120
+varying vec4 vColor;
+void main() {
+	gl_FragColor = vColor;
+}`
+)
+
+// GLRenderer is the OpenGL backend of render.Renderer. It draws with one
+// program, caching its uniform and attribute locations by name.
+type GLRenderer struct {
+	program  uint32
+	uniforms map[string]int32
+	attribs  map[string]int32
+}
+
+// newGLRenderer compiles the program. The GL context must be current.
+func newGLRenderer() (*GLRenderer, error) {
+	program, err := createProgram(overlayVertexShader, overlayFragmentShader)
+	if err != nil {
+		return nil, err
+	}
+	gl.UseProgram(program)
+	gl.Enable(gl.VERTEX_PROGRAM_POINT_SIZE)
+	return &GLRenderer{program: program, uniforms: map[string]int32{}, attribs: map[string]int32{}}, nil
+}
+
+func (r *GLRenderer) CreateBuffer(data []float32) render.Buffer {
+	var b uint32
+	gl.GenBuffers(1, &b)
+	r.UpdateBuffer(render.Buffer(b), data)
+	return render.Buffer(b)
+}
+
+func (r *GLRenderer) UpdateBuffer(b render.Buffer, data []float32) {
+	gl.BindBuffer(gl.ARRAY_BUFFER, uint32(b))
+	if len(data) == 0 {
+		gl.BufferData(gl.ARRAY_BUFFER, 0, nil, gl.DYNAMIC_DRAW)
+		return
+	}
+	gl.BufferData(gl.ARRAY_BUFFER, len(data)*4, gl.Ptr(data), gl.DYNAMIC_DRAW)
+}
+
+func (r *GLRenderer) DeleteBuffer(b render.Buffer) {
+	buf := uint32(b)
+	gl.DeleteBuffers(1, &buf)
+}
+
+func (r *GLRenderer) SetUniform(name string, value []float32) {
+	loc, ok := r.uniforms[name]
+	if !ok {
+		loc = gl.GetUniformLocation(r.program, gl.Str(name+"\x00"))
+		r.uniforms[name] = loc
+	}
+	if loc < 0 || len(value) == 0 {
+		return
+	}
+	switch len(value) {
+	case 1:
+		gl.Uniform1f(loc, value[0])
+	case 2:
+		gl.Uniform2fv(loc, 1, &value[0])
+	case 3:
+		gl.Uniform3fv(loc, 1, &value[0])
+	case 4:
+		gl.Uniform4fv(loc, 1, &value[0])
+	case 16:
+		gl.UniformMatrix4fv(loc, 1, false, &value[0])
+	default:
+		gl.Uniform1fv(loc, int32(len(value)), &value[0])
+	}
+}
+
+func (r *GLRenderer) Draw(p render.Primitive, attribs []render.VertexAttrib, count int) {
+	for _, a := range attribs {
+		loc, ok := r.attribs[a.Name]
+		if !ok {
+			loc = gl.GetAttribLocation(r.program, gl.Str(a.Name+"\x00"))
+			r.attribs[a.Name] = loc
+		}
+		if loc < 0 {
+			continue
+		}
+		gl.EnableVertexAttribArray(uint32(loc))
+		gl.BindBuffer(gl.ARRAY_BUFFER, uint32(a.Buffer))
+		gl.VertexAttribPointer(uint32(loc), int32(a.Components), gl.FLOAT, false, 0, gl.PtrOffset(0))
+	}
+	mode := map[render.Primitive]uint32{render.Points: gl.POINTS, render.Lines: gl.LINES, render.Triangles: gl.TRIANGLES}[p]
+	gl.DrawArrays(mode, 0, int32(count))
+}
+
+// createProgram compiles and links a program from vertex and fragment
+// shader sources.
+func createProgram(vertexSource, fragmentSource string) (uint32, error) {
+	vertex, err := compileShader(vertexSource, gl.VERTEX_SHADER)
+	if err != nil {
+		return 0, err
+	}
+	fragment, err := compileShader(fragmentSource, gl.FRAGMENT_SHADER)
+	if err != nil {
+		return 0, err
+	}
+	program := gl.CreateProgram()
+	gl.AttachShader(program, vertex)
+	gl.AttachShader(program, fragment)
+	gl.LinkProgram(program)
+	gl.DeleteShader(vertex)
+	gl.DeleteShader(fragment)
+	var status int32
+	gl.GetProgramiv(program, gl.LINK_STATUS, &status)
+	if status == gl.FALSE {
+		var length int32
+		gl.GetProgramiv(program, gl.INFO_LOG_LENGTH, &length)
+		log := strings.Repeat("\x00", int(length+1))
+		gl.GetProgramInfoLog(program, length, nil, gl.Str(log))
+		return 0, fmt.Errorf("linking program: %s", strings.TrimRight(log, "\x00"))
+	}
+	return program, nil
+}
+
+// compileShader compiles source as a shader of kind.
+func compileShader(source string, kind uint32) (uint32, error) {
+	shader := gl.CreateShader(kind)
+	sources, free := gl.Strs(source + "\x00")
+	gl.ShaderSource(shader, 1, sources, nil)
+	free()
+	gl.CompileShader(shader)
+	var status int32
+	gl.GetShaderiv(shader, gl.COMPILE_STATUS, &status)
+	if status == gl.FALSE {
+		var length int32
+		gl.GetShaderiv(shader, gl.INFO_LOG_LENGTH, &length)
+		log := strings.Repeat("\x00", int(length+1))
+		gl.GetShaderInfoLog(shader, length, nil, gl.Str(log))
+		gl.DeleteShader(shader)
+		return 0, fmt.Errorf("compiling shader: %s", strings.TrimRight(log, "\x00"))
+	}
+	return shader, nil
+}
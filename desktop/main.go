@@ -0,0 +1,172 @@
+// desktop/main.go
+//go:build glfw && !js
+
+// Command desktop is a native viewer for the procedural datasets. It opens a
+// GLFW window and draws with OpenGL through the same render.Renderer
+// interface as the browser viewer, so the shared drawing code can be run
+// and profiled with native tools.
+//
+// build: go build -tags glfw -o point-cloud-desktop ./desktop
+// run: ./point-cloud-desktop -dataset clusters -points 100000
+//
+// It needs cgo and the OpenGL and X11 development headers (or their macOS
+// and Windows counterparts).
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"math"
+	"os"
+	"runtime"
+	"runtime/pprof"
+	"strings"
+
+	"github.com/go-gl/gl/v2.1/gl"
+	"github.com/go-gl/glfw/v3.3/glfw"
+	"github.com/sbecker11/webgl-point-cloud/glf32"
+	"github.com/sbecker11/webgl-point-cloud/procgen"
+	"github.com/sbecker11/webgl-point-cloud/render"
+)
+
+func init() {
+	// GLFW and OpenGL calls must come from the main thread.
+	runtime.LockOSThread()
+}
+
+// orbitCamera turns around a target point as the mouse drags, like the
+// browser viewer's camera.
+type orbitCamera struct {
+	target             glf32.Vec3
+	yaw, pitch         float32
+	distance           float32
+	dragging           bool
+	lastX, lastY       float64
+	rotateSpeed        float32
+	zoomStep           float32
+	minPitch, maxPitch float32
+}
+
+func newOrbitCamera(distance float32) *orbitCamera {
+	return &orbitCamera{
+		yaw: -0.5, pitch: 0.3, distance: distance,
+		rotateSpeed: 0.01, zoomStep: 1.1,
+		minPitch: -math.Pi / 2 * 0.999, maxPitch: math.Pi / 2 * 0.999,
+	}
+}
+
+func (c *orbitCamera) view() glf32.Mat4 {
+	return glf32.Orbit(c.target, c.yaw, c.pitch, c.distance)
+}
+
+// attach steers the camera with window's mouse: dragging with the left
+// button orbits and the wheel zooms.
+func (c *orbitCamera) attach(window *glfw.Window) {
+	window.SetMouseButtonCallback(func(w *glfw.Window, button glfw.MouseButton, action glfw.Action, mods glfw.ModifierKey) {
+		if button == glfw.MouseButtonLeft {
+			c.dragging = action == glfw.Press
+			c.lastX, c.lastY = w.GetCursorPos()
+		}
+	})
+	window.SetCursorPosCallback(func(w *glfw.Window, x, y float64) {
+		if c.dragging {
+			c.yaw -= float32(x-c.lastX) * c.rotateSpeed
+			c.pitch = max(c.minPitch, min(c.maxPitch, c.pitch+float32(y-c.lastY)*c.rotateSpeed))
+		}
+		c.lastX, c.lastY = x, y
+	})
+	window.SetScrollCallback(func(w *glfw.Window, xoff, yoff float64) {
+		c.distance *= float32(math.Pow(float64(c.zoomStep), -yoff))
+	})
+}
+
+func main() {
+	dataset := flag.String("dataset", "clusters", "procedural dataset: "+strings.Join(procgen.DatasetNames(), ", "))
+	points := flag.Int("points", 5000, "number of points generated")
+	seed := flag.Int64("seed", 1, "seed of the procedural generators")
+	pointSize := flag.Float64("size", 2, "point size in pixels")
+	width := flag.Int("width", 1280, "window width")
+	height := flag.Int("height", 800, "window height")
+	cpuProfile := flag.String("cpuprofile", "", "write a CPU profile to this file")
+	flag.Parse()
+
+	if *cpuProfile != "" {
+		f, err := os.Create(*cpuProfile)
+		if err != nil {
+			log.Fatal(err)
+		}
+		if err := pprof.StartCPUProfile(f); err != nil {
+			log.Fatal(err)
+		}
+		defer pprof.StopCPUProfile()
+	}
+
+	cloud, err := procgen.New(*seed).Dataset(*dataset, *points)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	if err := glfw.Init(); err != nil {
+		log.Fatalf("initializing GLFW: %v", err)
+	}
+	defer glfw.Terminate()
+	glfw.WindowHint(glfw.ContextVersionMajor, 2)
+	glfw.WindowHint(glfw.ContextVersionMinor, 1)
+	window, err := glfw.CreateWindow(*width, *height, "Point cloud - "+*dataset, nil, nil)
+	if err != nil {
+		log.Fatalf("opening a window: %v", err)
+	}
+	window.MakeContextCurrent()
+	glfw.SwapInterval(1)
+	if err := gl.Init(); err != nil {
+		log.Fatalf("initializing OpenGL: %v", err)
+	}
+	window.SetKeyCallback(func(w *glfw.Window, key glfw.Key, scancode int, action glfw.Action, mods glfw.ModifierKey) {
+		if key == glfw.KeyEscape && action == glfw.Press {
+			w.SetShouldClose(true)
+		}
+	})
+
+	r, err := newGLRenderer()
+	if err != nil {
+		log.Fatal(err)
+	}
+	axes, grid := &render.Geometry{Primitive: render.Lines}, &render.Geometry{Primitive: render.Lines}
+	axisPositions, axisColors := render.Axes(1.5)
+	gridPositions, gridColors := render.Grid(1.5, 10)
+	axes.Set(r, axisPositions, axisColors)
+	grid.Set(r, gridPositions, gridColors)
+	cloudPoints := &render.Geometry{Primitive: render.Points}
+	cloudPoints.Set(r, cloud.Coords, cloud.Colors)
+
+	camera := newOrbitCamera(3)
+	camera.attach(window)
+	gl.Enable(gl.DEPTH_TEST)
+	gl.Enable(gl.BLEND)
+	gl.BlendFunc(gl.SRC_ALPHA, gl.ONE_MINUS_SRC_ALPHA)
+
+	frames, since := 0, glfw.GetTime()
+	for !window.ShouldClose() {
+		w, h := window.GetFramebufferSize()
+		if w > 0 && h > 0 {
+			gl.Viewport(0, 0, int32(w), int32(h))
+			gl.ClearColor(0, 0.1, 0.25, 1)
+			gl.Clear(gl.COLOR_BUFFER_BIT | gl.DEPTH_BUFFER_BIT)
+			proj := glf32.Perspective(0.8, float32(w)/float32(h), 0.1, 100)
+			viewProj := glf32.MultiplyMatrices(proj, camera.view())
+			grid.Draw(r, viewProj)
+			axes.Draw(r, viewProj)
+			r.SetUniform(render.UniformPointSize, []float32{float32(*pointSize)})
+			cloudPoints.Draw(r, viewProj)
+		}
+		window.SwapBuffers()
+		glfw.PollEvents()
+
+		frames++
+		if now := glfw.GetTime(); now-since >= 1 {
+			window.SetTitle(fmt.Sprintf("Point cloud - %s - %d points - %.0f fps", *dataset, cloud.Len(), float64(frames)/(now-since)))
+			frames, since = 0, now
+		}
+	}
+}
@@ -0,0 +1,23 @@
+// edit/crop.go
+package edit
+
+import (
+	"github.com/sbecker11/webgl-point-cloud/glf32"
+	"github.com/sbecker11/webgl-point-cloud/pointcloud"
+)
+
+// Keep returns a compacted copy of c holding only the selected points,
+// keeping every attribute. It is the inverse of Delete.
+// Panics if len(sel) differs from the number of points.
+func Keep(c *pointcloud.Cloud, sel []bool) *pointcloud.Cloud {
+	if len(sel) != c.Len() {
+		panic("edit.Keep: selection length must match the number of points")
+	}
+	return c.Subset(Indices(sel, true))
+}
+
+// Crop returns a compacted copy of c holding only the points inside box
+// once transformed by model.
+func Crop(c *pointcloud.Cloud, model glf32.Mat4, box Box) *pointcloud.Cloud {
+	return Keep(c, SelectBox(c, model, box))
+}
@@ -0,0 +1,15 @@
+// edit/delete.go
+package edit
+
+import "github.com/sbecker11/webgl-point-cloud/pointcloud"
+
+// Delete returns a compacted copy of c without the selected points, keeping
+// every attribute. c itself is unchanged, so callers can undo the deletion
+// by restoring it.
+// Panics if len(sel) differs from the number of points.
+func Delete(c *pointcloud.Cloud, sel []bool) *pointcloud.Cloud {
+	if len(sel) != c.Len() {
+		panic("edit.Delete: selection length must match the number of points")
+	}
+	return c.Subset(Indices(sel, false))
+}
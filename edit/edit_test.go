@@ -0,0 +1,111 @@
+// edit/edit_test.go
+// usage: go test
+
+package edit
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/sbecker11/webgl-point-cloud/glf32"
+	"github.com/sbecker11/webgl-point-cloud/pointcloud"
+)
+
+// lineCloud returns n points at x = 0, 1, ..., n-1.
+func lineCloud(n int) *pointcloud.Cloud {
+	coords := make([]float32, 0, n*3)
+	for i := 0; i < n; i++ {
+		coords = append(coords, float32(i), 0, 0)
+	}
+	return pointcloud.New("line", coords, make([]float32, n*4))
+}
+
+func TestCombine(t *testing.T) {
+	current := []bool{true, true, false, false}
+	sel := []bool{true, false, true, false}
+	tests := []struct {
+		mode Mode
+		want []bool
+	}{
+		{Replace, []bool{true, false, true, false}},
+		{Add, []bool{true, true, true, false}},
+		{Subtract, []bool{false, true, false, false}},
+		{Intersect, []bool{true, false, false, false}},
+	}
+	for _, tt := range tests {
+		if got := Combine(current, sel, tt.mode); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("Combine(mode %d): expected %v, got %v", tt.mode, tt.want, got)
+		}
+	}
+	if got := Combine(nil, sel, Add); !reflect.DeepEqual(got, sel) {
+		t.Errorf("Combine(nil, Add): expected %v, got %v", sel, got)
+	}
+	if _, err := ParseMode("toggle"); err == nil {
+		t.Error("ParseMode: expected an error for an unknown mode")
+	}
+}
+
+func TestSelectBox(t *testing.T) {
+	c := lineCloud(5)
+	box := Box{glf32.Vec3{0.5, -1, -1}, glf32.Vec3{2, 1, 1}}
+	if got := Indices(SelectBox(c, glf32.Identity(), box), true); !reflect.DeepEqual(got, []int{1, 2}) {
+		t.Errorf("SelectBox: expected [1 2], got %v", got)
+	}
+	// The box is in world coordinates: shifting the cloud by 2 selects the
+	// points that land in [0.5, 2].
+	got := Indices(SelectBox(c, glf32.Translate(-2, 0, 0), box), true)
+	if !reflect.DeepEqual(got, []int{3, 4}) {
+		t.Errorf("SelectBox with model: expected [3 4], got %v", got)
+	}
+}
+
+func TestDelete(t *testing.T) {
+	c := lineCloud(4)
+	c.Classes = []uint8{1, 2, 3, 4}
+	out := Delete(c, []bool{false, true, false, true})
+	if out.Len() != 2 || out.Coords[3] != 2 || !reflect.DeepEqual(out.Classes, []uint8{1, 3}) {
+		t.Errorf("Delete: expected points 0 and 2, got %v %v", out.Coords, out.Classes)
+	}
+	if c.Len() != 4 {
+		t.Errorf("Delete: source cloud changed to %d points", c.Len())
+	}
+	if Count([]bool{true, false, true}) != 2 {
+		t.Error("Count: expected 2")
+	}
+}
+
+func TestCrop(t *testing.T) {
+	c := lineCloud(6)
+	c.SetAttribute(pointcloud.Attribute{Name: "intensity", Components: 1}, []float32{0, 1, 2, 3, 4, 5})
+	out := Crop(c, glf32.Identity(), Box{glf32.Vec3{1.5, -1, -1}, glf32.Vec3{3.5, 1, 1}})
+	if out.Len() != 2 || out.Coords[0] != 2 || out.Coords[3] != 3 {
+		t.Fatalf("Crop: expected points 2 and 3, got %v", out.Coords)
+	}
+	if _, values, _ := out.Attribute("intensity"); !reflect.DeepEqual(values, []float32{2, 3}) {
+		t.Errorf("Crop: expected intensity [2 3], got %v", values)
+	}
+	if kept := Keep(c, []bool{true, false, false, false, false, true}); kept.Len() != 2 || kept.Coords[3] != 5 {
+		t.Errorf("Keep: expected points 0 and 5, got %v", kept.Coords)
+	}
+}
+
+func TestGrowShrink(t *testing.T) {
+	coords := lineCloud(10).Coords
+	sel := []bool{false, false, false, false, true, true, false, false, false, false}
+	grown := Grow(coords, sel, 2)
+	if got := Indices(grown, true); !reflect.DeepEqual(got, []int{2, 3, 4, 5, 6, 7}) {
+		t.Errorf("Grow: expected [2 3 4 5 6 7], got %v", got)
+	}
+	if got := Indices(Shrink(coords, grown, 2), true); !reflect.DeepEqual(got, []int{4, 5}) {
+		t.Errorf("Shrink: expected [4 5], got %v", got)
+	}
+	if got := Indices(Shrink(coords, sel, 1), true); got != nil {
+		t.Errorf("Shrink: expected nothing left of two points, got %v", got)
+	}
+	if got := Indices(Invert(sel, 10), true); !reflect.DeepEqual(got, []int{0, 1, 2, 3, 6, 7, 8, 9}) {
+		t.Errorf("Invert: expected every other point, got %v", got)
+	}
+	if Count(Invert(nil, 3)) != 3 {
+		t.Error("Invert: expected an empty selection to invert to every point")
+	}
+}
@@ -0,0 +1,36 @@
+// edit/grow.go
+package edit
+
+import "github.com/sbecker11/webgl-point-cloud/spatial"
+
+// Invert returns the selection of the n points not in sel. sel may be nil.
+func Invert(sel []bool, n int) []bool {
+	out := make([]bool, n)
+	for i := range out {
+		out[i] = sel == nil || !sel[i]
+	}
+	return out
+}
+
+// Grow returns sel with every point within radius of a selected point
+// added, for taking in the rest of a surface partly selected. coords holds
+// the points' packed xyz coordinates.
+func Grow(coords []float32, sel []bool, radius float64) []bool {
+	out := append([]bool(nil), sel...)
+	if Count(sel) == 0 {
+		return out
+	}
+	tree := spatial.NewKDTree(coords)
+	for _, i := range Indices(sel, true) {
+		p := [3]float64{float64(coords[i*3]), float64(coords[i*3+1]), float64(coords[i*3+2])}
+		tree.Within(p, radius, func(j int, _ float64) { out[j] = true })
+	}
+	return out
+}
+
+// Shrink returns sel without the points within radius of an unselected
+// point, peeling the selection back from its edges. It undoes Grow where
+// the selection is solid.
+func Shrink(coords []float32, sel []bool, radius float64) []bool {
+	return Invert(Grow(coords, Invert(sel, len(sel)), radius), len(sel))
+}
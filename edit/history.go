@@ -0,0 +1,87 @@
+// edit/history.go
+package edit
+
+// Command is a reversible mutating operation. Do applies it and Undo
+// reverts it; History calls them alternately, starting with Do.
+type Command interface {
+	Do()
+	Undo()
+	// String describes the command, e.g. "delete 120 points".
+	String() string
+}
+
+// History is a bounded undo/redo stack of commands.
+type History struct {
+	limit  int
+	done   []Command // oldest first
+	undone []Command // most recently undone last
+}
+
+// NewHistory returns a history keeping at most limit commands for undo.
+// Panics if limit is less than 1.
+func NewHistory(limit int) *History {
+	if limit < 1 {
+		panic("edit.NewHistory: limit must be at least 1")
+	}
+	return &History{limit: limit}
+}
+
+// Execute runs c and records it for undo, discarding the redo history and
+// the oldest command beyond the limit.
+func (h *History) Execute(c Command) {
+	c.Do()
+	h.done = append(h.done, c)
+	if len(h.done) > h.limit {
+		h.done = h.done[len(h.done)-h.limit:]
+	}
+	h.undone = nil
+}
+
+// Undo reverts the most recent command and returns it, or returns false if
+// there is nothing to undo.
+func (h *History) Undo() (Command, bool) {
+	if len(h.done) == 0 {
+		return nil, false
+	}
+	c := h.done[len(h.done)-1]
+	h.done = h.done[:len(h.done)-1]
+	c.Undo()
+	h.undone = append(h.undone, c)
+	return c, true
+}
+
+// Redo re-applies the most recently undone command and returns it, or
+// returns false if there is nothing to redo.
+func (h *History) Redo() (Command, bool) {
+	if len(h.undone) == 0 {
+		return nil, false
+	}
+	c := h.undone[len(h.undone)-1]
+	h.undone = h.undone[:len(h.undone)-1]
+	c.Do()
+	h.done = append(h.done, c)
+	return c, true
+}
+
+// UndoNames describes the commands that can be undone, most recent first.
+func (h *History) UndoNames() []string {
+	return names(h.done)
+}
+
+// RedoNames describes the commands that can be redone, next first.
+func (h *History) RedoNames() []string {
+	return names(h.undone)
+}
+
+func names(stack []Command) []string {
+	out := make([]string, len(stack))
+	for i, c := range stack {
+		out[len(stack)-1-i] = c.String()
+	}
+	return out
+}
+
+// Clear discards the whole history.
+func (h *History) Clear() {
+	h.done, h.undone = nil, nil
+}
@@ -0,0 +1,71 @@
+// edit/history_test.go
+// usage: go test
+
+package edit
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+// addCommand adds n to a counter.
+type addCommand struct {
+	counter *int
+	n       int
+}
+
+func (c addCommand) Do()            { *c.counter += c.n }
+func (c addCommand) Undo()          { *c.counter -= c.n }
+func (c addCommand) String() string { return fmt.Sprintf("add %d", c.n) }
+
+func TestHistoryUndoRedo(t *testing.T) {
+	counter := 0
+	h := NewHistory(10)
+	h.Execute(addCommand{&counter, 1})
+	h.Execute(addCommand{&counter, 10})
+	if counter != 11 {
+		t.Fatalf("Execute: expected 11, got %d", counter)
+	}
+	if c, ok := h.Undo(); !ok || c.String() != "add 10" || counter != 1 {
+		t.Errorf("Undo: expected add 10 reverted to 1, got %v %v %d", c, ok, counter)
+	}
+	if got := h.RedoNames(); !reflect.DeepEqual(got, []string{"add 10"}) {
+		t.Errorf("RedoNames: expected [add 10], got %v", got)
+	}
+	if _, ok := h.Redo(); !ok || counter != 11 {
+		t.Errorf("Redo: expected 11, got %d", counter)
+	}
+	h.Undo()
+	h.Undo()
+	if _, ok := h.Undo(); ok || counter != 0 {
+		t.Errorf("Undo past the start: expected false and 0, got %v %d", ok, counter)
+	}
+
+	// A new command discards the redo history.
+	h.Redo()
+	h.Execute(addCommand{&counter, 100})
+	if _, ok := h.Redo(); ok {
+		t.Error("Redo after Execute: expected nothing to redo")
+	}
+	if got := h.UndoNames(); !reflect.DeepEqual(got, []string{"add 100", "add 1"}) {
+		t.Errorf("UndoNames: expected [add 100 add 1], got %v", got)
+	}
+}
+
+func TestHistoryLimit(t *testing.T) {
+	counter := 0
+	h := NewHistory(2)
+	for n := 1; n <= 3; n++ {
+		h.Execute(addCommand{&counter, n})
+	}
+	for {
+		if _, ok := h.Undo(); !ok {
+			break
+		}
+	}
+	// Only the last two commands could be undone.
+	if counter != 1 {
+		t.Errorf("Undo with limit 2: expected 1, got %d", counter)
+	}
+}
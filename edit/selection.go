@@ -0,0 +1,120 @@
+// edit/selection.go
+package edit
+
+import (
+	"fmt"
+
+	"github.com/sbecker11/webgl-point-cloud/glf32"
+	"github.com/sbecker11/webgl-point-cloud/pointcloud"
+)
+
+// A selection is a []bool with one entry per point of a cloud; a nil
+// selection selects nothing.
+
+// Mode says how a new selection combines with the current one.
+type Mode int
+
+const (
+	// Replace discards the current selection.
+	Replace Mode = iota
+	// Add selects the new points as well.
+	Add
+	// Subtract deselects the new points.
+	Subtract
+	// Intersect keeps only points in both selections.
+	Intersect
+)
+
+var modeNames = map[string]Mode{
+	"replace":   Replace,
+	"add":       Add,
+	"subtract":  Subtract,
+	"intersect": Intersect,
+}
+
+// ParseMode returns the Mode with the given name.
+func ParseMode(name string) (Mode, error) {
+	mode, ok := modeNames[name]
+	if !ok {
+		return Replace, fmt.Errorf("unknown selection mode %q", name)
+	}
+	return mode, nil
+}
+
+// Combine returns the result of applying sel to current with mode, as a
+// new selection. current may be nil.
+// Panics if current is non-nil and its length differs from sel's.
+func Combine(current, sel []bool, mode Mode) []bool {
+	if current != nil && len(current) != len(sel) {
+		panic("edit.Combine: selections must have the same length")
+	}
+	out := make([]bool, len(sel))
+	for i, s := range sel {
+		c := current != nil && current[i]
+		switch mode {
+		case Replace:
+			out[i] = s
+		case Add:
+			out[i] = c || s
+		case Subtract:
+			out[i] = c && !s
+		case Intersect:
+			out[i] = c && s
+		}
+	}
+	return out
+}
+
+// Box is an axis-aligned box.
+type Box struct {
+	Min, Max glf32.Vec3
+}
+
+// Contains reports whether p lies inside the box, boundary included.
+func (b Box) Contains(p glf32.Vec3) bool {
+	return p[0] >= b.Min[0] && p[0] <= b.Max[0] &&
+		p[1] >= b.Min[1] && p[1] <= b.Max[1] &&
+		p[2] >= b.Min[2] && p[2] <= b.Max[2]
+}
+
+// SelectBox selects the points of c that lie inside box once transformed
+// by model, so the box can be given in world coordinates.
+func SelectBox(c *pointcloud.Cloud, model glf32.Mat4, box Box) []bool {
+	sel := make([]bool, c.Len())
+	for i := range sel {
+		sel[i] = box.Contains(transformPoint(model, c.Point(i)))
+	}
+	return sel
+}
+
+// transformPoint applies the column-major affine matrix m to p.
+func transformPoint(m glf32.Mat4, p glf32.Vec3) glf32.Vec3 {
+	return glf32.Vec3{
+		m[0]*p[0] + m[4]*p[1] + m[8]*p[2] + m[12],
+		m[1]*p[0] + m[5]*p[1] + m[9]*p[2] + m[13],
+		m[2]*p[0] + m[6]*p[1] + m[10]*p[2] + m[14],
+	}
+}
+
+// Count returns the number of selected points.
+func Count(sel []bool) int {
+	n := 0
+	for _, s := range sel {
+		if s {
+			n++
+		}
+	}
+	return n
+}
+
+// Indices returns, in ascending order, the indices of the points whose
+// selection state equals want.
+func Indices(sel []bool, want bool) []int {
+	var indices []int
+	for i, s := range sel {
+		if s == want {
+			indices = append(indices, i)
+		}
+	}
+	return indices
+}
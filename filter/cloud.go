@@ -0,0 +1,64 @@
+// filter/cloud.go
+package filter
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/sbecker11/webgl-point-cloud/pointcloud"
+)
+
+// cloudSource exposes a point cloud's attributes to filter expressions:
+// x, y, z; r, g, b, a (colors in [0, 1]); nx, ny, nz when the cloud has
+// normals; class (ClassUnclassified for clouds without classifications);
+// and the cloud's extra attributes, by name for single-component ones and
+// as name_0, name_1, ... for each component of the others.
+type cloudSource struct {
+	c *pointcloud.Cloud
+}
+
+// CloudSource returns a Source reading attributes from c.
+func CloudSource(c *pointcloud.Cloud) Source {
+	return cloudSource{c}
+}
+
+func (s cloudSource) Len() int {
+	return s.c.Len()
+}
+
+// component returns an accessor for element k of a packed attribute with
+// the given stride.
+func component(data []float32, stride, k int) func(i int) float64 {
+	return func(i int) float64 { return float64(data[i*stride+k]) }
+}
+
+func (s cloudSource) Attribute(name string) (func(i int) float64, bool) {
+	c := s.c
+	switch name {
+	case "x", "y", "z":
+		return component(c.Coords, 3, int(name[0]-'x')), true
+	case "r", "g", "b", "a":
+		return component(c.Colors, 4, map[string]int{"r": 0, "g": 1, "b": 2, "a": 3}[name]), true
+	case "nx", "ny", "nz":
+		if c.Normals == nil {
+			return nil, false
+		}
+		return component(c.Normals, 3, int(name[1]-'x')), true
+	case "class":
+		if c.Classes == nil {
+			return func(int) float64 { return float64(pointcloud.ClassUnclassified) }, true
+		}
+		return func(i int) float64 { return float64(c.Classes[i]) }, true
+	}
+	if a, values, ok := c.Attribute(name); ok && a.Components == 1 {
+		return component(values, 1, 0), true
+	}
+	if k := strings.LastIndexByte(name, '_'); k > 0 {
+		if a, values, ok := c.Attribute(name[:k]); ok && a.Components > 1 {
+			if n, err := strconv.Atoi(name[k+1:]); err == nil && n >= 0 && n < a.Components {
+				return component(values, a.Components, n), true
+			}
+		}
+	}
+	return nil, false
+}
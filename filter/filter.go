@@ -0,0 +1,386 @@
+// filter/filter.go
+package filter
+
+import (
+	"fmt"
+	"math"
+	"sort"
+)
+
+// Expr is a parsed filter expression such as "intensity > 0.4 && z < 10".
+//
+// Expressions combine numbers and per-point attributes with arithmetic
+// (+ - * / %), comparisons (< <= > >= == !=), logic (&& || !), parentheses
+// and the functions abs, min, max, sqrt, floor and ceil. Comparisons and
+// logic yield 1 for true and 0 for false; a point passes when the whole
+// expression is non-zero.
+type Expr struct {
+	src  string
+	root node
+}
+
+// Source supplies per-point attribute values to an expression.
+type Source interface {
+	// Len returns the number of points.
+	Len() int
+	// Attribute returns an accessor for the named attribute, or false if
+	// the source has no such attribute.
+	Attribute(name string) (func(i int) float64, bool)
+}
+
+// node is an expression tree node.
+type node interface {
+	// bind resolves attribute names against src and returns an evaluator.
+	bind(src Source) (func(i int) float64, error)
+	// vars adds the attribute names used by the node to set.
+	vars(set map[string]bool)
+}
+
+// Parse parses a filter expression.
+func Parse(src string) (*Expr, error) {
+	tokens, err := lex(src)
+	if err != nil {
+		return nil, err
+	}
+	p := &parser{tokens: tokens}
+	root, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if t := p.peek(); t.kind != tokEOF {
+		return nil, fmt.Errorf("unexpected %q at %d", t.text, t.pos)
+	}
+	return &Expr{src: src, root: root}, nil
+}
+
+// String returns the source text of the expression.
+func (e *Expr) String() string {
+	return e.src
+}
+
+// Variables returns the attribute names the expression refers to, sorted.
+func (e *Expr) Variables() []string {
+	set := map[string]bool{}
+	e.root.vars(set)
+	names := make([]string, 0, len(set))
+	for name := range set {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Mask evaluates the expression for every point of src and returns true for
+// the points that pass. Returns an error if the expression refers to an
+// attribute src does not have.
+func (e *Expr) Mask(src Source) ([]bool, error) {
+	eval, err := e.root.bind(src)
+	if err != nil {
+		return nil, err
+	}
+	mask := make([]bool, src.Len())
+	for i := range mask {
+		mask[i] = eval(i) != 0
+	}
+	return mask, nil
+}
+
+// Indices evaluates the expression like Mask and returns the indices of the
+// points that pass, in ascending order.
+func (e *Expr) Indices(src Source) ([]int, error) {
+	mask, err := e.Mask(src)
+	if err != nil {
+		return nil, err
+	}
+	var indices []int
+	for i, ok := range mask {
+		if ok {
+			indices = append(indices, i)
+		}
+	}
+	return indices, nil
+}
+
+// --- Expression tree ---
+
+type numberNode float64
+
+func (n numberNode) bind(Source) (func(int) float64, error) {
+	v := float64(n)
+	return func(int) float64 { return v }, nil
+}
+
+func (n numberNode) vars(map[string]bool) {}
+
+type attrNode string
+
+func (n attrNode) bind(src Source) (func(int) float64, error) {
+	get, ok := src.Attribute(string(n))
+	if !ok {
+		return nil, fmt.Errorf("unknown attribute %q", string(n))
+	}
+	return get, nil
+}
+
+func (n attrNode) vars(set map[string]bool) { set[string(n)] = true }
+
+type unaryNode struct {
+	op string
+	x  node
+}
+
+func (n *unaryNode) bind(src Source) (func(int) float64, error) {
+	x, err := n.x.bind(src)
+	if err != nil {
+		return nil, err
+	}
+	if n.op == "-" {
+		return func(i int) float64 { return -x(i) }, nil
+	}
+	return func(i int) float64 { return boolValue(x(i) == 0) }, nil
+}
+
+func (n *unaryNode) vars(set map[string]bool) { n.x.vars(set) }
+
+type binaryNode struct {
+	op   string
+	x, y node
+}
+
+func boolValue(b bool) float64 {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+func (n *binaryNode) bind(src Source) (func(int) float64, error) {
+	x, err := n.x.bind(src)
+	if err != nil {
+		return nil, err
+	}
+	y, err := n.y.bind(src)
+	if err != nil {
+		return nil, err
+	}
+	switch n.op {
+	case "+":
+		return func(i int) float64 { return x(i) + y(i) }, nil
+	case "-":
+		return func(i int) float64 { return x(i) - y(i) }, nil
+	case "*":
+		return func(i int) float64 { return x(i) * y(i) }, nil
+	case "/":
+		return func(i int) float64 { return x(i) / y(i) }, nil
+	case "%":
+		return func(i int) float64 { return math.Mod(x(i), y(i)) }, nil
+	case "<":
+		return func(i int) float64 { return boolValue(x(i) < y(i)) }, nil
+	case "<=":
+		return func(i int) float64 { return boolValue(x(i) <= y(i)) }, nil
+	case ">":
+		return func(i int) float64 { return boolValue(x(i) > y(i)) }, nil
+	case ">=":
+		return func(i int) float64 { return boolValue(x(i) >= y(i)) }, nil
+	case "==":
+		return func(i int) float64 { return boolValue(x(i) == y(i)) }, nil
+	case "!=":
+		return func(i int) float64 { return boolValue(x(i) != y(i)) }, nil
+	case "&&":
+		return func(i int) float64 { return boolValue(x(i) != 0 && y(i) != 0) }, nil
+	case "||":
+		return func(i int) float64 { return boolValue(x(i) != 0 || y(i) != 0) }, nil
+	}
+	return nil, fmt.Errorf("unknown operator %q", n.op)
+}
+
+func (n *binaryNode) vars(set map[string]bool) {
+	n.x.vars(set)
+	n.y.vars(set)
+}
+
+// functions maps function names to their implementations and arity.
+var functions = map[string]struct {
+	arity int
+	fn    func(args []float64) float64
+}{
+	"abs":   {1, func(a []float64) float64 { return math.Abs(a[0]) }},
+	"sqrt":  {1, func(a []float64) float64 { return math.Sqrt(a[0]) }},
+	"floor": {1, func(a []float64) float64 { return math.Floor(a[0]) }},
+	"ceil":  {1, func(a []float64) float64 { return math.Ceil(a[0]) }},
+	"min":   {2, func(a []float64) float64 { return math.Min(a[0], a[1]) }},
+	"max":   {2, func(a []float64) float64 { return math.Max(a[0], a[1]) }},
+}
+
+type callNode struct {
+	name string
+	args []node
+}
+
+func (n *callNode) bind(src Source) (func(int) float64, error) {
+	fn := functions[n.name].fn
+	evals := make([]func(int) float64, len(n.args))
+	for k, a := range n.args {
+		e, err := a.bind(src)
+		if err != nil {
+			return nil, err
+		}
+		evals[k] = e
+	}
+	buf := make([]float64, len(evals))
+	return func(i int) float64 {
+		for k, e := range evals {
+			buf[k] = e(i)
+		}
+		return fn(buf)
+	}, nil
+}
+
+func (n *callNode) vars(set map[string]bool) {
+	for _, a := range n.args {
+		a.vars(set)
+	}
+}
+
+// --- Parser ---
+
+// parser is a recursive-descent parser over the token list. Precedence from
+// lowest to highest: ||, &&, comparisons, + -, * / %, unary - and !.
+type parser struct {
+	tokens []token
+	pos    int
+}
+
+func (p *parser) peek() token {
+	return p.tokens[p.pos]
+}
+
+func (p *parser) next() token {
+	t := p.tokens[p.pos]
+	if t.kind != tokEOF {
+		p.pos++
+	}
+	return t
+}
+
+// accept consumes the next token if it is one of the given operators.
+func (p *parser) accept(ops ...string) (string, bool) {
+	t := p.peek()
+	if t.kind != tokOp {
+		return "", false
+	}
+	for _, op := range ops {
+		if t.text == op {
+			p.pos++
+			return op, true
+		}
+	}
+	return "", false
+}
+
+// binaryLevel parses operands separated by any of ops, left-associatively.
+func (p *parser) binaryLevel(operand func() (node, error), ops ...string) (node, error) {
+	x, err := operand()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		op, ok := p.accept(ops...)
+		if !ok {
+			return x, nil
+		}
+		y, err := operand()
+		if err != nil {
+			return nil, err
+		}
+		x = &binaryNode{op: op, x: x, y: y}
+	}
+}
+
+func (p *parser) parseOr() (node, error) {
+	return p.binaryLevel(p.parseAnd, "||")
+}
+
+func (p *parser) parseAnd() (node, error) {
+	return p.binaryLevel(p.parseComparison, "&&")
+}
+
+func (p *parser) parseComparison() (node, error) {
+	x, err := p.parseSum()
+	if err != nil {
+		return nil, err
+	}
+	if op, ok := p.accept("<", "<=", ">", ">=", "==", "!="); ok {
+		y, err := p.parseSum()
+		if err != nil {
+			return nil, err
+		}
+		return &binaryNode{op: op, x: x, y: y}, nil
+	}
+	return x, nil
+}
+
+func (p *parser) parseSum() (node, error) {
+	return p.binaryLevel(p.parseProduct, "+", "-")
+}
+
+func (p *parser) parseProduct() (node, error) {
+	return p.binaryLevel(p.parseUnary, "*", "/", "%")
+}
+
+func (p *parser) parseUnary() (node, error) {
+	if op, ok := p.accept("-", "!"); ok {
+		x, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return &unaryNode{op: op, x: x}, nil
+	}
+	return p.parsePrimary()
+}
+
+func (p *parser) parsePrimary() (node, error) {
+	t := p.next()
+	switch {
+	case t.kind == tokNumber:
+		return numberNode(t.value), nil
+	case t.kind == tokIdent:
+		if _, ok := p.accept("("); !ok {
+			return attrNode(t.text), nil
+		}
+		f, ok := functions[t.text]
+		if !ok {
+			return nil, fmt.Errorf("unknown function %q at %d", t.text, t.pos)
+		}
+		var args []node
+		for {
+			a, err := p.parseOr()
+			if err != nil {
+				return nil, err
+			}
+			args = append(args, a)
+			if _, ok := p.accept(","); !ok {
+				break
+			}
+		}
+		if _, ok := p.accept(")"); !ok {
+			return nil, fmt.Errorf("expected ')' at %d", p.peek().pos)
+		}
+		if len(args) != f.arity {
+			return nil, fmt.Errorf("%s expects %d argument(s), got %d", t.text, f.arity, len(args))
+		}
+		return &callNode{name: t.text, args: args}, nil
+	case t.kind == tokOp && t.text == "(":
+		x, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if _, ok := p.accept(")"); !ok {
+			return nil, fmt.Errorf("expected ')' at %d", p.peek().pos)
+		}
+		return x, nil
+	case t.kind == tokEOF:
+		return nil, fmt.Errorf("unexpected end of expression")
+	}
+	return nil, fmt.Errorf("unexpected %q at %d", t.text, t.pos)
+}
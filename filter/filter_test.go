@@ -0,0 +1,97 @@
+// filter/filter_test.go
+// usage: go test
+
+package filter
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/sbecker11/webgl-point-cloud/pointcloud"
+)
+
+// testCloud returns four points along x with increasing red, classes
+// ground, ground, building, water, and extra intensity and uv attributes.
+func testCloud() *pointcloud.Cloud {
+	c := pointcloud.New("test",
+		[]float32{0, 0, 5, 1, 0, 15, 2, 0, 5, 3, 0, -5},
+		[]float32{0, 0, 0, 1, 0.3, 0, 0, 1, 0.6, 0, 0, 1, 0.9, 0, 0, 1})
+	c.Classes = []uint8{pointcloud.ClassGround, pointcloud.ClassGround, pointcloud.ClassBuilding, pointcloud.ClassWater}
+	c.SetAttribute(pointcloud.Attribute{Name: "intensity", Components: 1}, []float32{0.1, 0.5, 0.7, 0.2})
+	c.SetAttribute(pointcloud.Attribute{Name: "uv", Components: 2}, []float32{0, 1, 0, 2, 1, 1, 1, 2})
+	return c
+}
+
+func TestIndices(t *testing.T) {
+	src := CloudSource(testCloud())
+	tests := []struct {
+		expr string
+		want []int
+	}{
+		{"x > 1", []int{2, 3}},
+		{"r > 0.4 && z < 10", []int{2, 3}},
+		{"class == 2 || x == 3", []int{0, 1, 3}},
+		{"!(x < 2)", []int{2, 3}},
+		{"x * 2 + 1 >= 5", []int{2, 3}},
+		{"-x > -1.5", []int{0, 1}},
+		{"abs(z) == 5", []int{0, 2, 3}},
+		{"max(x, z) > 10", []int{1}},
+		{"x % 2 == 0", []int{0, 2}},
+		{"1 + 2 * 3 == 7", []int{0, 1, 2, 3}},
+		{"0", nil},
+		{"1e1 == 10 && x < .5", []int{0}},
+		{"intensity > 0.4 && z < 10", []int{2}},
+		{"uv_0 == 1 && uv_1 == 2", []int{3}},
+	}
+	for _, tt := range tests {
+		e, err := Parse(tt.expr)
+		if err != nil {
+			t.Errorf("Parse(%q): unexpected error %v", tt.expr, err)
+			continue
+		}
+		got, err := e.Indices(src)
+		if err != nil {
+			t.Errorf("Indices(%q): unexpected error %v", tt.expr, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("Indices(%q): expected %v, got %v", tt.expr, tt.want, got)
+		}
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	for _, expr := range []string{"", "x >", "(x > 1", "x > 1)", "x $ 1", "foo(1)", "min(1)", "x y"} {
+		if _, err := Parse(expr); err == nil {
+			t.Errorf("Parse(%q): expected an error", expr)
+		}
+	}
+}
+
+func TestUnknownAttribute(t *testing.T) {
+	e, err := Parse("reflectance > 0.4")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := e.Mask(CloudSource(testCloud())); err == nil {
+		t.Error("Mask: expected an error for an unknown attribute")
+	}
+	// Normals are only available when the cloud has them, and
+	// multi-component attributes only by component.
+	for _, expr := range []string{"nz > 0", "uv > 0", "uv_2 > 0", "intensity_0 > 0"} {
+		e, _ = Parse(expr)
+		if _, err := e.Mask(CloudSource(testCloud())); err == nil {
+			t.Errorf("Mask(%q): expected an unknown attribute error", expr)
+		}
+	}
+}
+
+func TestVariables(t *testing.T) {
+	e, err := Parse("z < 10 && (r > 0.4 || abs(x) > z)")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := e.Variables(), []string{"r", "x", "z"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Variables: expected %v, got %v", want, got)
+	}
+}
@@ -0,0 +1,138 @@
+// glf32/checked.go
+package glf32
+
+import (
+	"fmt"
+	"math"
+)
+
+// The Checked variants of functions that panic on bad input return an
+// error instead, and also reject non-finite input and degenerate cases
+// that would silently produce NaNs, so that a bad value arriving from
+// outside (a host page call, a file, a sensor) can be handled without
+// stopping the render loop.
+
+// IsFinite reports whether every element of a Mat4, Vec3, Vec4 or Quat is
+// neither NaN nor infinite.
+func IsFinite[T ~[]float32](v T) bool {
+	for _, x := range v {
+		if math.IsNaN(float64(x)) || math.IsInf(float64(x), 0) {
+			return false
+		}
+	}
+	return true
+}
+
+// checkVec3 returns an error unless v is a finite Vec3.
+func checkVec3(fn, name string, v Vec3) error {
+	if len(v) != 3 {
+		return fmt.Errorf("%s: %s must be Vec3 (length 3), got length %d", fn, name, len(v))
+	}
+	if !IsFinite(v) {
+		return fmt.Errorf("%s: %s is not finite: %v", fn, name, v)
+	}
+	return nil
+}
+
+// checkMat4 returns an error unless m is a finite Mat4.
+func checkMat4(fn, name string, m Mat4) error {
+	if len(m) != 16 {
+		return fmt.Errorf("%s: %s must be Mat4 (length 16), got length %d", fn, name, len(m))
+	}
+	if !IsFinite(m) {
+		return fmt.Errorf("%s: %s is not finite", fn, name)
+	}
+	return nil
+}
+
+// LookAtChecked is LookAt returning an error for vectors that are not
+// finite Vec3s, an eye at the center, or a zero up vector.
+func LookAtChecked(eye, center, up Vec3) (Mat4, error) {
+	for _, v := range []struct {
+		name string
+		v    Vec3
+	}{{"eye", eye}, {"center", center}, {"up", up}} {
+		if err := checkVec3("LookAt", v.name, v.v); err != nil {
+			return nil, err
+		}
+	}
+	if eye[0] == center[0] && eye[1] == center[1] && eye[2] == center[2] {
+		return nil, fmt.Errorf("LookAt: eye and center are the same point")
+	}
+	if Dot(up, up) == 0 {
+		return nil, fmt.Errorf("LookAt: up is zero")
+	}
+	return LookAt(eye, center, up), nil
+}
+
+// PerspectiveChecked is Perspective returning an error for a
+// non-positive or non-finite aspect ratio, clipping planes that are not
+// 0 < near < far, or a field of view giving a non-finite matrix.
+func PerspectiveChecked(fov, aspect, near, far float32) (Mat4, error) {
+	if !IsFinite([]float32{fov, aspect, near, far}) {
+		return nil, fmt.Errorf("Perspective: parameters are not finite")
+	}
+	if aspect <= 0 {
+		return nil, fmt.Errorf("Perspective: aspect ratio %g is not positive", aspect)
+	}
+	if near <= 0 || far <= near {
+		return nil, fmt.Errorf("Perspective: expected 0 < near < far, got near %g, far %g", near, far)
+	}
+	m := Perspective(fov, aspect, near, far)
+	if !IsFinite(m) {
+		return nil, fmt.Errorf("Perspective: field of view %g is degenerate", fov)
+	}
+	return m, nil
+}
+
+// MultiplyMatricesChecked is MultiplyMatrices returning an error for
+// operands that are not finite Mat4s.
+func MultiplyMatricesChecked(a, b Mat4) (Mat4, error) {
+	if err := checkMat4("MultiplyMatrices", "a", a); err != nil {
+		return nil, err
+	}
+	if err := checkMat4("MultiplyMatrices", "b", b); err != nil {
+		return nil, err
+	}
+	return MultiplyMatrices(a, b), nil
+}
+
+// TransformVerticesChecked is TransformVertices returning an error, and
+// leaving coords unchanged, for a matrix that is not a finite Mat4 or
+// coordinates that are not whole xyz triples.
+func TransformVerticesChecked(coords []float32, m Mat4) ([]float32, error) {
+	if err := checkMat4("TransformVertices", "matrix", m); err != nil {
+		return coords, err
+	}
+	if len(coords)%3 != 0 {
+		return coords, fmt.Errorf("TransformVertices: %d coordinates are not whole xyz triples", len(coords))
+	}
+	return TransformVertices(coords, m), nil
+}
+
+// InvertChecked is Invert returning an error for a matrix that is not a
+// finite Mat4, or is singular.
+func InvertChecked(m Mat4) (Mat4, error) {
+	if err := checkMat4("Invert", "matrix", m); err != nil {
+		return nil, err
+	}
+	inv, ok := Invert(m)
+	if !ok || !IsFinite(inv) {
+		return nil, fmt.Errorf("Invert: matrix is singular")
+	}
+	return inv, nil
+}
+
+// ComposeChecked is Compose returning an error for parts that are not
+// finite Vec3s.
+func ComposeChecked(position, rotationEuler, scale Vec3) (Mat4, error) {
+	for _, v := range []struct {
+		name string
+		v    Vec3
+	}{{"position", position}, {"rotationEuler", rotationEuler}, {"scale", scale}} {
+		if err := checkVec3("Compose", v.name, v.v); err != nil {
+			return nil, err
+		}
+	}
+	return Compose(position, rotationEuler, scale), nil
+}
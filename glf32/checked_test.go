@@ -0,0 +1,71 @@
+// glf32/checked_test.go
+// usage: go test
+
+package glf32
+
+import (
+	"math"
+	"strings"
+	"testing"
+)
+
+func TestIsFinite(t *testing.T) {
+	nan := float32(math.NaN())
+	if !IsFinite(Identity()) || !IsFinite(Vec3{1, 2, 3}) || !IsFinite(Quat(nil)) {
+		t.Error("expected finite values to be finite")
+	}
+	if IsFinite(Vec3{1, nan, 3}) || IsFinite(Vec4{float32(math.Inf(-1)), 0, 0, 0}) {
+		t.Error("expected NaN and infinity not to be finite")
+	}
+}
+
+func TestLookAtParallelUp(t *testing.T) {
+	for _, eye := range []Vec3{{0, 5, 0}, {0, -5, 0}} {
+		m := LookAt(eye, Vec3{0, 0, 0}, Vec3{0, 1, 0})
+		if !IsFinite(m) {
+			t.Fatalf("expected a finite view looking along up from %v, got %v", eye, m)
+		}
+		// The center is straight ahead, 5 away.
+		p := TransformVertices([]float32{0, 0, 0}, m)
+		if math.Abs(float64(p[0])) > 1e-5 || math.Abs(float64(p[1])) > 1e-5 || math.Abs(float64(p[2]+5)) > 1e-5 {
+			t.Errorf("expected the center at (0, 0, -5) in view space, got %v", p)
+		}
+	}
+}
+
+func TestChecked(t *testing.T) {
+	nan := float32(math.NaN())
+	for name, err := range map[string]error{
+		"LookAt eye":         second(LookAtChecked(Vec3{nan, 0, 0}, Vec3{0, 0, 0}, Vec3{0, 1, 0})),
+		"LookAt length":      second(LookAtChecked(Vec3{1, 0}, Vec3{0, 0, 0}, Vec3{0, 1, 0})),
+		"LookAt same point":  second(LookAtChecked(Vec3{1, 1, 1}, Vec3{1, 1, 1}, Vec3{0, 1, 0})),
+		"LookAt zero up":     second(LookAtChecked(Vec3{1, 1, 1}, Vec3{0, 0, 0}, Vec3{0, 0, 0})),
+		"Perspective aspect": second(PerspectiveChecked(1, 0, 0.1, 100)),
+		"Perspective planes": second(PerspectiveChecked(1, 1, 10, 1)),
+		"Perspective fov":    second(PerspectiveChecked(0, 1, 0.1, 100)),
+		"Multiply length":    second(MultiplyMatricesChecked(Identity(), Mat4{1})),
+		"Multiply NaN":       second(MultiplyMatricesChecked(Identity(), Translate(nan, 0, 0))),
+		"Transform triples":  second(TransformVerticesChecked([]float32{1, 2}, Identity())),
+		"Invert singular":    second(InvertChecked(Scale(1, 0, 1))),
+		"Compose scale":      second(ComposeChecked(Vec3{0, 0, 0}, Vec3{0, 0, 0}, Vec3{1, nan, 1})),
+	} {
+		if err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+	if _, err := LookAtChecked(Vec3{1, 0, 0}, Vec3{0, 0, 0}, Vec3{0, 1, 0}); err != nil {
+		t.Errorf("LookAtChecked: %v", err)
+	}
+	if m, err := PerspectiveChecked(1, 1.5, 0.1, 100); err != nil || !mat4AlmostEqual(m, Perspective(1, 1.5, 0.1, 100)) {
+		t.Errorf("PerspectiveChecked: %v", err)
+	}
+	coords := []float32{1, 2, 3}
+	if _, err := TransformVerticesChecked(coords, Translate(nan, 0, 0)); err == nil || !strings.Contains(err.Error(), "not finite") || coords[0] != 1 {
+		t.Errorf("expected a non-finite matrix error leaving coords unchanged, got %v, %v", err, coords)
+	}
+}
+
+// second returns the error of a (Mat4, error) or ([]float32, error) pair.
+func second[T any](_ T, err error) error {
+	return err
+}
@@ -0,0 +1,169 @@
+// glf32/encoding.go
+package glf32
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"math"
+)
+
+// Mat4, Vec3, Vec4 and Quat have one wire representation each, shared by
+// the viewer, the server and saved files. In JSON they are arrays of
+// exactly 16, 3, 4 and 4 numbers, Mat4 in column-major order and Quat as
+// x, y, z, w; nil is null. In binary they are the same values as
+// little-endian IEEE 754 float32s, 64, 12, 16 and 16 bytes long.
+
+// marshalFixed encodes v as a JSON array of n numbers.
+func marshalFixed(v []float32, n int, typ string) ([]byte, error) {
+	if v == nil {
+		return []byte("null"), nil
+	}
+	if len(v) != n {
+		return nil, fmt.Errorf("glf32: %s must have %d elements, got %d", typ, n, len(v))
+	}
+	return json.Marshal([]float32(v))
+}
+
+// unmarshalFixed decodes a JSON array of n numbers, or null as nil.
+func unmarshalFixed(data []byte, n int, typ string) ([]float32, error) {
+	var v []float32
+	if err := json.Unmarshal(data, &v); err != nil {
+		return nil, fmt.Errorf("glf32: %s: %v", typ, err)
+	}
+	if v != nil && len(v) != n {
+		return nil, fmt.Errorf("glf32: %s must have %d elements, got %d", typ, n, len(v))
+	}
+	return v, nil
+}
+
+// appendFixed appends v as n little-endian float32s.
+func appendFixed(b []byte, v []float32, n int, typ string) ([]byte, error) {
+	if len(v) != n {
+		return b, fmt.Errorf("glf32: %s must have %d elements, got %d", typ, n, len(v))
+	}
+	for _, x := range v {
+		b = binary.LittleEndian.AppendUint32(b, math.Float32bits(x))
+	}
+	return b, nil
+}
+
+// decodeFixed decodes n little-endian float32s from exactly n*4 bytes.
+func decodeFixed(data []byte, n int, typ string) ([]float32, error) {
+	if len(data) != n*4 {
+		return nil, fmt.Errorf("glf32: %s of %d bytes, expected %d", typ, len(data), n*4)
+	}
+	v := make([]float32, n)
+	for i := range v {
+		v[i] = math.Float32frombits(binary.LittleEndian.Uint32(data[i*4:]))
+	}
+	return v, nil
+}
+
+// MarshalJSON implements json.Marshaler.
+func (m Mat4) MarshalJSON() ([]byte, error) { return marshalFixed(m, 16, "Mat4") }
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (m *Mat4) UnmarshalJSON(data []byte) error {
+	v, err := unmarshalFixed(data, 16, "Mat4")
+	if err == nil {
+		*m = v
+	}
+	return err
+}
+
+// AppendBinary implements encoding.BinaryAppender.
+func (m Mat4) AppendBinary(b []byte) ([]byte, error) { return appendFixed(b, m, 16, "Mat4") }
+
+// MarshalBinary implements encoding.BinaryMarshaler.
+func (m Mat4) MarshalBinary() ([]byte, error) { return m.AppendBinary(nil) }
+
+// UnmarshalBinary implements encoding.BinaryUnmarshaler.
+func (m *Mat4) UnmarshalBinary(data []byte) error {
+	v, err := decodeFixed(data, 16, "Mat4")
+	if err == nil {
+		*m = v
+	}
+	return err
+}
+
+// MarshalJSON implements json.Marshaler.
+func (v Vec3) MarshalJSON() ([]byte, error) { return marshalFixed(v, 3, "Vec3") }
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (v *Vec3) UnmarshalJSON(data []byte) error {
+	d, err := unmarshalFixed(data, 3, "Vec3")
+	if err == nil {
+		*v = d
+	}
+	return err
+}
+
+// AppendBinary implements encoding.BinaryAppender.
+func (v Vec3) AppendBinary(b []byte) ([]byte, error) { return appendFixed(b, v, 3, "Vec3") }
+
+// MarshalBinary implements encoding.BinaryMarshaler.
+func (v Vec3) MarshalBinary() ([]byte, error) { return v.AppendBinary(nil) }
+
+// UnmarshalBinary implements encoding.BinaryUnmarshaler.
+func (v *Vec3) UnmarshalBinary(data []byte) error {
+	d, err := decodeFixed(data, 3, "Vec3")
+	if err == nil {
+		*v = d
+	}
+	return err
+}
+
+// MarshalJSON implements json.Marshaler.
+func (v Vec4) MarshalJSON() ([]byte, error) { return marshalFixed(v, 4, "Vec4") }
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (v *Vec4) UnmarshalJSON(data []byte) error {
+	d, err := unmarshalFixed(data, 4, "Vec4")
+	if err == nil {
+		*v = d
+	}
+	return err
+}
+
+// AppendBinary implements encoding.BinaryAppender.
+func (v Vec4) AppendBinary(b []byte) ([]byte, error) { return appendFixed(b, v, 4, "Vec4") }
+
+// MarshalBinary implements encoding.BinaryMarshaler.
+func (v Vec4) MarshalBinary() ([]byte, error) { return v.AppendBinary(nil) }
+
+// UnmarshalBinary implements encoding.BinaryUnmarshaler.
+func (v *Vec4) UnmarshalBinary(data []byte) error {
+	d, err := decodeFixed(data, 4, "Vec4")
+	if err == nil {
+		*v = d
+	}
+	return err
+}
+
+// MarshalJSON implements json.Marshaler.
+func (q Quat) MarshalJSON() ([]byte, error) { return marshalFixed(q, 4, "Quat") }
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (q *Quat) UnmarshalJSON(data []byte) error {
+	d, err := unmarshalFixed(data, 4, "Quat")
+	if err == nil {
+		*q = d
+	}
+	return err
+}
+
+// AppendBinary implements encoding.BinaryAppender.
+func (q Quat) AppendBinary(b []byte) ([]byte, error) { return appendFixed(b, q, 4, "Quat") }
+
+// MarshalBinary implements encoding.BinaryMarshaler.
+func (q Quat) MarshalBinary() ([]byte, error) { return q.AppendBinary(nil) }
+
+// UnmarshalBinary implements encoding.BinaryUnmarshaler.
+func (q *Quat) UnmarshalBinary(data []byte) error {
+	d, err := decodeFixed(data, 4, "Quat")
+	if err == nil {
+		*q = d
+	}
+	return err
+}
@@ -0,0 +1,73 @@
+// glf32/encoding_test.go
+// usage: go test
+
+package glf32
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestJSON(t *testing.T) {
+	var s struct {
+		Pose     Mat4 `json:"pose"`
+		Position Vec3 `json:"position"`
+		Color    Vec4 `json:"color"`
+		Rotation Quat `json:"rotation"`
+		Unset    Mat4 `json:"unset"`
+	}
+	s.Pose, s.Position, s.Color, s.Rotation = Translate(1, 2, 3), Vec3{0.1, -2, 3e7}, Vec4{1, 0.5, 0, 1}, Quat{0, 0, 0, 1}
+	data, err := json.Marshal(s)
+	if err != nil {
+		t.Fatalf("Marshal: %v", err)
+	}
+	want := `{"pose":[1,0,0,0,0,1,0,0,0,0,1,0,1,2,3,1],"position":[0.1,-2,30000000],"color":[1,0.5,0,1],"rotation":[0,0,0,1],"unset":null}`
+	if string(data) != want {
+		t.Errorf("expected %s, got %s", want, data)
+	}
+	s.Pose = nil
+	if err := json.Unmarshal(data, &s); err != nil || !mat4AlmostEqual(s.Pose, Translate(1, 2, 3)) || s.Position[0] != 0.1 || s.Unset != nil {
+		t.Errorf("round trip failed: %+v, %v", s, err)
+	}
+
+	if _, err := json.Marshal(Vec3{1, 2}); err == nil {
+		t.Error("expected an error marshaling a Vec3 of 2 elements")
+	}
+	var m Mat4
+	if err := json.Unmarshal([]byte(`[1, 2, 3]`), &m); err == nil {
+		t.Error("expected an error unmarshaling a Mat4 of 3 elements")
+	}
+	var q Quat
+	if err := json.Unmarshal([]byte(`{"x": 0}`), &q); err == nil {
+		t.Error("expected an error unmarshaling an object as a Quat")
+	}
+}
+
+func TestBinary(t *testing.T) {
+	m := Compose(Vec3{1, 2, 3}, Vec3{0.1, 0.2, 0.3}, Vec3{1, 2, 1})
+	data, err := m.MarshalBinary()
+	if err != nil || len(data) != 64 {
+		t.Fatalf("expected 64 bytes, got %d, %v", len(data), err)
+	}
+	// Little-endian float32 2 is 00 00 00 40.
+	if data[52] != 0 || data[55] != 0x40 {
+		t.Errorf("expected m[13] = 2 little-endian, got % x", data[52:56])
+	}
+	var got Mat4
+	if err := got.UnmarshalBinary(data); err != nil || !mat4AlmostEqual(got, m) {
+		t.Errorf("round trip failed: %v, %v", got, err)
+	}
+
+	data, _ = Vec3{1, 2, 3}.AppendBinary([]byte("v3"))
+	var v Vec3
+	if err := v.UnmarshalBinary(data[2:]); err != nil || len(data) != 14 || v[2] != 3 {
+		t.Errorf("expected Vec3 {1, 2, 3} after a prefix, got %v, %v", v, err)
+	}
+	var q Quat
+	if err := q.UnmarshalBinary(data[2:]); err == nil {
+		t.Error("expected an error decoding 12 bytes as a Quat")
+	}
+	if _, err := (Vec4{1}).MarshalBinary(); err == nil {
+		t.Error("expected an error encoding a Vec4 of 1 element")
+	}
+}
@@ -20,6 +20,10 @@ type Vec3 []float32
 // Vec4 represents a 4D vector as a slice of 4 float32 values.
 type Vec4 []float32
 
+// Quat represents a rotation as a unit quaternion, a slice of 4 float32
+// values x, y, z, w.
+type Quat []float32
+
 // PrintMat4 prints a 4x4 matrix in column major order with an optional label.
 func PrintMat4(label string, m Mat4) {
 	if label != "" {
@@ -130,6 +134,21 @@ func Translate(x, y, z float32) Mat4 {
 	}
 }
 
+// Scale creates a 4x4 column-major matrix scaling along the axes.
+//
+// Parameters:
+//   x, y, z: The scale factors along the respective axes.
+//
+// Returns a column-major Mat4 representing the scaling matrix.
+func Scale(x, y, z float32) Mat4 {
+	return Mat4{
+		x, 0, 0, 0, // Column 0
+		0, y, 0, 0, // Column 1
+		0, 0, z, 0, // Column 2
+		0, 0, 0, 1, // Column 3
+	}
+}
+
 // RotateX creates a 4x4 column-major matrix for rotation around the X-axis.
 //
 // Parameters:
@@ -178,6 +197,183 @@ func RotateZ(angle float32) Mat4 {
 	}
 }
 
+// FromQuaternion creates a 4x4 column-major matrix for the rotation by the
+// quaternion x, y, z, w, as ROS and glTF give orientations. The quaternion
+// need not be normalized.
+//
+// Returns a column-major Mat4 representing the rotation matrix, or the
+// identity for a zero quaternion.
+func FromQuaternion(x, y, z, w float32) Mat4 {
+	n := float32(math.Sqrt(float64(x*x + y*y + z*z + w*w)))
+	if n == 0 {
+		return Identity()
+	}
+	x, y, z, w = x/n, y/n, z/n, w/n
+	return Mat4{
+		1 - 2*(y*y+z*z), 2 * (x*y + z*w), 2 * (x*z - y*w), 0, // Column 0
+		2 * (x*y - z*w), 1 - 2*(x*x+z*z), 2 * (y*z + x*w), 0, // Column 1
+		2 * (x*z + y*w), 2 * (y*z - x*w), 1 - 2*(x*x+y*y), 0, // Column 2
+		0, 0, 0, 1, // Column 3
+	}
+}
+
+// Compose creates a 4x4 column-major model matrix that scales, then
+// rotates, then translates: Translate * RotateZ * RotateY * RotateX * Scale.
+//
+// Parameters:
+//   position: The translation (e.g., Vec3{x, y, z}).
+//   rotationEuler: The rotation angles in radians about the X, Y and Z
+//     axes, applied in that order.
+//   scale: The scale factors along the X, Y and Z axes.
+//
+// Returns a column-major Mat4. Decompose recovers the three parts.
+// Panics if input vectors are not of length 3.
+func Compose(position, rotationEuler, scale Vec3) Mat4 {
+	if len(position) != 3 || len(rotationEuler) != 3 || len(scale) != 3 {
+		panic("Compose: input vectors must be Vec3 (length 3)")
+	}
+	r := MultiplyMatrices(RotateZ(rotationEuler[2]), MultiplyMatrices(RotateY(rotationEuler[1]), RotateX(rotationEuler[0])))
+	for col := 0; col < 3; col++ {
+		for row := 0; row < 3; row++ {
+			r[col*4+row] *= scale[col]
+		}
+	}
+	r[12], r[13], r[14] = position[0], position[1], position[2]
+	return r
+}
+
+// Decompose splits a model matrix made of a translation, rotation and
+// scale into the arguments Compose takes for it. Scales are positive,
+// except that a matrix that mirrors gets a negative X scale. When the Y
+// rotation is a quarter turn, X and Z rotate about the same axis and the
+// Z angle is returned as 0.
+//
+// Parameters:
+//   m: The 4x4 column-major matrix to decompose.
+//
+// Returns the position, the rotation angles in radians and the scale.
+// Panics if m is not of length 16.
+func Decompose(m Mat4) (position, rotationEuler, scale Vec3) {
+	if len(m) != 16 {
+		panic("Decompose: matrix must be Mat4 (length 16)")
+	}
+	r, scale := rotationScale(m)
+	var rx, ry, rz float64
+	sy := math.Max(-1, math.Min(1, -r[2][0]))
+	ry = math.Asin(sy)
+	if math.Abs(sy) < 1-1e-6 {
+		rx = math.Atan2(r[2][1], r[2][2])
+		rz = math.Atan2(r[1][0], r[0][0])
+	} else {
+		rx = math.Atan2(-r[1][2], r[1][1])
+	}
+	return Vec3{m[12], m[13], m[14]}, Vec3{float32(rx), float32(ry), float32(rz)}, scale
+}
+
+// rotationScale splits the upper 3x3 of m into a rotation, r[row][col],
+// and the scale along each axis, with a mirroring matrix's X scale
+// negative.
+func rotationScale(m Mat4) (r [3][3]float64, scale Vec3) {
+	scale = make(Vec3, 3)
+	for col := 0; col < 3; col++ {
+		x, y, z := float64(m[col*4]), float64(m[col*4+1]), float64(m[col*4+2])
+		scale[col] = float32(math.Sqrt(x*x + y*y + z*z))
+	}
+	det := float64(m[0])*(float64(m[5])*float64(m[10])-float64(m[9])*float64(m[6])) -
+		float64(m[4])*(float64(m[1])*float64(m[10])-float64(m[9])*float64(m[2])) +
+		float64(m[8])*(float64(m[1])*float64(m[6])-float64(m[5])*float64(m[2]))
+	if det < 0 {
+		scale[0] = -scale[0]
+	}
+	for col := 0; col < 3; col++ {
+		for row := 0; row < 3; row++ {
+			if scale[col] != 0 {
+				r[row][col] = float64(m[col*4+row]) / float64(scale[col])
+			}
+		}
+	}
+	return r, scale
+}
+
+// ToQuaternion returns the rotation of a matrix as a unit quaternion x, y,
+// z, w, ignoring its translation and scale. It is the inverse of
+// FromQuaternion.
+//
+// Panics if m is not of length 16.
+func ToQuaternion(m Mat4) (x, y, z, w float32) {
+	if len(m) != 16 {
+		panic("ToQuaternion: matrix must be Mat4 (length 16)")
+	}
+	r, _ := rotationScale(m)
+	var qx, qy, qz, qw float64
+	switch trace := r[0][0] + r[1][1] + r[2][2]; {
+	case trace > 0:
+		s := 0.5 / math.Sqrt(trace+1)
+		qw, qx, qy, qz = 0.25/s, (r[2][1]-r[1][2])*s, (r[0][2]-r[2][0])*s, (r[1][0]-r[0][1])*s
+	case r[0][0] > r[1][1] && r[0][0] > r[2][2]:
+		s := 2 * math.Sqrt(1+r[0][0]-r[1][1]-r[2][2])
+		qw, qx, qy, qz = (r[2][1]-r[1][2])/s, 0.25*s, (r[0][1]+r[1][0])/s, (r[0][2]+r[2][0])/s
+	case r[1][1] > r[2][2]:
+		s := 2 * math.Sqrt(1+r[1][1]-r[0][0]-r[2][2])
+		qw, qx, qy, qz = (r[0][2]-r[2][0])/s, (r[0][1]+r[1][0])/s, 0.25*s, (r[1][2]+r[2][1])/s
+	default:
+		s := 2 * math.Sqrt(1+r[2][2]-r[0][0]-r[1][1])
+		qw, qx, qy, qz = (r[1][0]-r[0][1])/s, (r[0][2]+r[2][0])/s, (r[1][2]+r[2][1])/s, 0.25*s
+	}
+	return float32(qx), float32(qy), float32(qz), float32(qw)
+}
+
+// Interpolate blends two transforms, such as successive poses of a tracked
+// object: positions and scales linearly, rotations along the shortest arc
+// (spherical linear interpolation), so the result stays a rigid motion.
+//
+// Parameters:
+//   a, b: The 4x4 column-major matrices to blend.
+//   t: 0 for a, 1 for b.
+//
+// Returns the blended column-major Mat4.
+// Panics if a or b is not of length 16.
+func Interpolate(a, b Mat4, t float32) Mat4 {
+	if len(a) != 16 || len(b) != 16 {
+		panic("Interpolate: matrices must be Mat4 (length 16)")
+	}
+	ax, ay, az, aw := ToQuaternion(a)
+	bx, by, bz, bw := ToQuaternion(b)
+	qa := [4]float64{float64(ax), float64(ay), float64(az), float64(aw)}
+	qb := [4]float64{float64(bx), float64(by), float64(bz), float64(bw)}
+	dot := qa[0]*qb[0] + qa[1]*qb[1] + qa[2]*qb[2] + qa[3]*qb[3]
+	if dot < 0 {
+		// q and -q are the same rotation; take the nearer.
+		dot = -dot
+		for i := range qb {
+			qb[i] = -qb[i]
+		}
+	}
+	wa, wb := 1-float64(t), float64(t)
+	if dot < 0.9995 {
+		theta := math.Acos(dot)
+		wa = math.Sin((1-float64(t))*theta) / math.Sin(theta)
+		wb = math.Sin(float64(t)*theta) / math.Sin(theta)
+	}
+	var q [4]float32
+	for i := range q {
+		q[i] = float32(wa*qa[i] + wb*qb[i])
+	}
+	m := FromQuaternion(q[0], q[1], q[2], q[3])
+	_, sa := rotationScale(a)
+	_, sb := rotationScale(b)
+	for col := 0; col < 3; col++ {
+		scale := sa[col] + (sb[col]-sa[col])*t
+		for row := 0; row < 3; row++ {
+			m[col*4+row] *= scale
+		}
+	}
+	for i := 12; i < 15; i++ {
+		m[i] = a[i] + (b[i]-a[i])*t
+	}
+	return m
+}
+
 // LookAt creates a 4x4 column-major view matrix that transforms world
 // coordinates into camera (view) coordinates. This is used to position and
 // orient the camera in the scene.
@@ -185,7 +381,9 @@ func RotateZ(angle float32) Mat4 {
 // Parameters:
 //   eye: The position of the camera in world space (e.g., Vec3{x, y, z}).
 //   center: The point in world space that the camera is looking at.
-//   up: The world's "up" direction (typically Vec3{0, 1, 0}).
+//   up: The world's "up" direction (typically Vec3{0, 1, 0}). If the view
+//       is parallel to it, the world axis least aligned with the view is
+//       used instead.
 //
 // Returns a Mat4 representing the 4x4 column-major view matrix.
 // Panics if input vectors are not of length 3.
@@ -196,8 +394,20 @@ func LookAt(eye, center, up Vec3) Mat4 {
 
 	f := Normalize(Subtract(center, eye))
 	s := Normalize(Cross(f, up))
+	if Dot(s, s) == 0 {
+		// Looking straight along up (or with no up at all) leaves the roll
+		// undefined; take up from the world axis least aligned with the
+		// view instead of returning a matrix of NaNs.
+		s = Normalize(Cross(f, leastAlignedAxis(f)))
+	}
 	u := Cross(s, f)
 
+	return viewMatrix(s, u, f, eye)
+}
+
+// viewMatrix returns the view matrix of a camera at eye whose right, up
+// and forward directions are the orthonormal s, u and f.
+func viewMatrix(s, u, f, eye Vec3) Mat4 {
 	tx := -Dot(s, eye)
 	ty := -Dot(u, eye)
 	tz := Dot(f, eye) // This is equivalent to -Dot(-f, eye)
@@ -216,6 +426,18 @@ func LookAt(eye, center, up Vec3) Mat4 {
 	}
 }
 
+// leastAlignedAxis returns the world axis most nearly perpendicular to v.
+func leastAlignedAxis(v Vec3) Vec3 {
+	ax, ay, az := math.Abs(float64(v[0])), math.Abs(float64(v[1])), math.Abs(float64(v[2]))
+	switch {
+	case ax <= ay && ax <= az:
+		return Vec3{1, 0, 0}
+	case ay <= az:
+		return Vec3{0, 1, 0}
+	}
+	return Vec3{0, 0, 1}
+}
+
 // Perspective creates a 4x4 column-major perspective projection matrix.
 // This matrix transforms 3D camera-space coordinates into 2D clip-space coordinates,
 // accounting for perspective (objects further away appear smaller).
@@ -249,6 +471,28 @@ func Perspective(fov, aspect, near, far float32) Mat4 {
 	}
 }
 
+// Orthographic creates an orthographic projection matrix, mapping the box
+// from (left, bottom, -near) to (right, top, -far) in view space onto the
+// clip volume without perspective, for plan views and overview maps.
+//
+// Parameters:
+//   left, right: The x extent of the view volume.
+//   bottom, top: The y extent of the view volume.
+//   near, far: The distances to the near and far clipping planes. They must differ.
+//
+// Returns a Mat4 representing the 4x4 column-major orthographic matrix.
+func Orthographic(left, right, bottom, top, near, far float32) Mat4 {
+	rl := 1 / (right - left)
+	tb := 1 / (top - bottom)
+	fn := 1 / (far - near)
+	return Mat4{
+		2 * rl, 0, 0, 0,
+		0, 2 * tb, 0, 0,
+		0, 0, -2 * fn, 0,
+		-(right + left) * rl, -(top + bottom) * tb, -(far + near) * fn, 1,
+	}
+}
+
 // MultiplyMatrices performs the multiplication of two 4x4 column-major matrices (A * B).
 // The result is also a 4x4 column-major matrix.
 //
@@ -342,3 +586,156 @@ func TransformVertices(coords []float32, m Mat4) []float32 {
 	}
 	return coords
 }
+
+// Invert returns the inverse of a 4x4 column-major matrix, computed by
+// cofactor expansion in float64 for accuracy. Inverting a view-projection
+// matrix maps clip space back to the world, e.g. to turn a screen position
+// into a ray for picking.
+//
+// Parameters:
+//   m: The 4x4 column-major matrix to invert.
+//
+// Returns the inverse and true, or nil and false if m is singular.
+// Panics if m is not of length 16.
+func Invert(m Mat4) (Mat4, bool) {
+	if len(m) != 16 {
+		panic("Invert: matrix must be Mat4 (length 16)")
+	}
+	var a [16]float64
+	for i, v := range m {
+		a[i] = float64(v)
+	}
+	var inv [16]float64
+	inv[0] = a[5]*a[10]*a[15] - a[5]*a[11]*a[14] - a[9]*a[6]*a[15] + a[9]*a[7]*a[14] + a[13]*a[6]*a[11] - a[13]*a[7]*a[10]
+	inv[4] = -a[4]*a[10]*a[15] + a[4]*a[11]*a[14] + a[8]*a[6]*a[15] - a[8]*a[7]*a[14] - a[12]*a[6]*a[11] + a[12]*a[7]*a[10]
+	inv[8] = a[4]*a[9]*a[15] - a[4]*a[11]*a[13] - a[8]*a[5]*a[15] + a[8]*a[7]*a[13] + a[12]*a[5]*a[11] - a[12]*a[7]*a[9]
+	inv[12] = -a[4]*a[9]*a[14] + a[4]*a[10]*a[13] + a[8]*a[5]*a[14] - a[8]*a[6]*a[13] - a[12]*a[5]*a[10] + a[12]*a[6]*a[9]
+	inv[1] = -a[1]*a[10]*a[15] + a[1]*a[11]*a[14] + a[9]*a[2]*a[15] - a[9]*a[3]*a[14] - a[13]*a[2]*a[11] + a[13]*a[3]*a[10]
+	inv[5] = a[0]*a[10]*a[15] - a[0]*a[11]*a[14] - a[8]*a[2]*a[15] + a[8]*a[3]*a[14] + a[12]*a[2]*a[11] - a[12]*a[3]*a[10]
+	inv[9] = -a[0]*a[9]*a[15] + a[0]*a[11]*a[13] + a[8]*a[1]*a[15] - a[8]*a[3]*a[13] - a[12]*a[1]*a[11] + a[12]*a[3]*a[9]
+	inv[13] = a[0]*a[9]*a[14] - a[0]*a[10]*a[13] - a[8]*a[1]*a[14] + a[8]*a[2]*a[13] + a[12]*a[1]*a[10] - a[12]*a[2]*a[9]
+	inv[2] = a[1]*a[6]*a[15] - a[1]*a[7]*a[14] - a[5]*a[2]*a[15] + a[5]*a[3]*a[14] + a[13]*a[2]*a[7] - a[13]*a[3]*a[6]
+	inv[6] = -a[0]*a[6]*a[15] + a[0]*a[7]*a[14] + a[4]*a[2]*a[15] - a[4]*a[3]*a[14] - a[12]*a[2]*a[7] + a[12]*a[3]*a[6]
+	inv[10] = a[0]*a[5]*a[15] - a[0]*a[7]*a[13] - a[4]*a[1]*a[15] + a[4]*a[3]*a[13] + a[12]*a[1]*a[7] - a[12]*a[3]*a[5]
+	inv[14] = -a[0]*a[5]*a[14] + a[0]*a[6]*a[13] + a[4]*a[1]*a[14] - a[4]*a[2]*a[13] - a[12]*a[1]*a[6] + a[12]*a[2]*a[5]
+	inv[3] = -a[1]*a[6]*a[11] + a[1]*a[7]*a[10] + a[5]*a[2]*a[11] - a[5]*a[3]*a[10] - a[9]*a[2]*a[7] + a[9]*a[3]*a[6]
+	inv[7] = a[0]*a[6]*a[11] - a[0]*a[7]*a[10] - a[4]*a[2]*a[11] + a[4]*a[3]*a[10] + a[8]*a[2]*a[7] - a[8]*a[3]*a[6]
+	inv[11] = -a[0]*a[5]*a[11] + a[0]*a[7]*a[9] + a[4]*a[1]*a[11] - a[4]*a[3]*a[9] - a[8]*a[1]*a[7] + a[8]*a[3]*a[5]
+	inv[15] = a[0]*a[5]*a[10] - a[0]*a[6]*a[9] - a[4]*a[1]*a[10] + a[4]*a[2]*a[9] + a[8]*a[1]*a[6] - a[8]*a[2]*a[5]
+
+	det := a[0]*inv[0] + a[1]*inv[4] + a[2]*inv[8] + a[3]*inv[12]
+	if det == 0 || math.IsNaN(det) {
+		return nil, false
+	}
+	result := make(Mat4, 16)
+	for i := range inv {
+		result[i] = float32(inv[i] / det)
+	}
+	return result, true
+}
+
+// CatmullRomSegment evaluates the uniform Catmull-Rom spline segment from
+// p1 to p2, whose tangents are set by the neighbouring points p0 and p3.
+//
+// Parameters:
+//   p0, p1, p2, p3: Consecutive control points.
+//   t: The position along the segment, 0 at p1 and 1 at p2.
+//
+// Returns the point on the curve. Panics if input vectors are not of
+// length 3.
+func CatmullRomSegment(p0, p1, p2, p3 Vec3, t float32) Vec3 {
+	if len(p0) != 3 || len(p1) != 3 || len(p2) != 3 || len(p3) != 3 {
+		panic("CatmullRomSegment: input vectors must be Vec3 (length 3)")
+	}
+	t2, t3 := t*t, t*t*t
+	out := make(Vec3, 3)
+	for k := range out {
+		out[k] = 0.5 * (2*p1[k] + (p2[k]-p0[k])*t +
+			(2*p0[k]-5*p1[k]+4*p2[k]-p3[k])*t2 +
+			(3*p1[k]-p0[k]-3*p2[k]+p3[k])*t3)
+	}
+	return out
+}
+
+// CatmullRom evaluates the uniform Catmull-Rom spline through points,
+// which passes through every point. The end points are repeated to shape
+// the first and last segments.
+//
+// Parameters:
+//   points: The points to pass through, at least one.
+//   t: The position along the curve, 0 at the first point and 1 at the
+//     last, with each segment taking an equal share. It is clamped to
+//     [0, 1].
+//
+// Returns the point on the curve. Panics if points is empty.
+func CatmullRom(points []Vec3, t float32) Vec3 {
+	if len(points) == 0 {
+		panic("CatmullRom: no points")
+	}
+	segments := len(points) - 1
+	if segments == 0 || t <= 0 {
+		return append(Vec3(nil), points[0]...)
+	}
+	if t >= 1 {
+		return append(Vec3(nil), points[segments]...)
+	}
+	f := t * float32(segments)
+	i := int(f)
+	at := func(j int) Vec3 {
+		if j < 0 {
+			j = 0
+		} else if j > segments {
+			j = segments
+		}
+		return points[j]
+	}
+	return CatmullRomSegment(at(i-1), at(i), at(i+1), at(i+2), f-float32(i))
+}
+
+// Bezier evaluates the cubic Bézier curve from p0 to p3 with control
+// points p1 and p2.
+//
+// Parameters:
+//   p0, p1, p2, p3: The start point, two control points and end point.
+//   t: The position along the curve, 0 at p0 and 1 at p3.
+//
+// Returns the point on the curve. Panics if input vectors are not of
+// length 3.
+func Bezier(p0, p1, p2, p3 Vec3, t float32) Vec3 {
+	if len(p0) != 3 || len(p1) != 3 || len(p2) != 3 || len(p3) != 3 {
+		panic("Bezier: input vectors must be Vec3 (length 3)")
+	}
+	u := 1 - t
+	b0, b1, b2, b3 := u*u*u, 3*u*u*t, 3*u*t*t, t*t*t
+	out := make(Vec3, 3)
+	for k := range out {
+		out[k] = b0*p0[k] + b1*p1[k] + b2*p2[k] + b3*p3[k]
+	}
+	return out
+}
+
+// Unproject maps normalized device coordinates back to world space, the
+// inverse of projecting a point with a view-projection matrix. Unprojecting
+// a screen position at depths -1 and 1 gives the ends of the view ray
+// through it between the near and far planes.
+//
+// Parameters:
+//   invViewProj: The inverse of the view-projection matrix (see Invert).
+//   ndc: The normalized device coordinates, each in [-1, 1].
+//
+// Returns the world position, or nil and false if it is at infinity.
+// Panics if invViewProj is not of length 16 or ndc not of length 3.
+func Unproject(invViewProj Mat4, ndc Vec3) (Vec3, bool) {
+	if len(invViewProj) != 16 || len(ndc) != 3 {
+		panic("Unproject: expected a Mat4 (length 16) and a Vec3 (length 3)")
+	}
+	var p [4]float64
+	for r := 0; r < 4; r++ {
+		p[r] = float64(invViewProj[r])*float64(ndc[0]) + float64(invViewProj[4+r])*float64(ndc[1]) +
+			float64(invViewProj[8+r])*float64(ndc[2]) + float64(invViewProj[12+r])
+	}
+	if p[3] == 0 {
+		return nil, false
+	}
+	return Vec3{float32(p[0] / p[3]), float32(p[1] / p[3]), float32(p[2] / p[3])}, true
+}
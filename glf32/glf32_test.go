@@ -127,6 +127,19 @@ func TestTranslate(t *testing.T) {
 	}
 }
 
+func TestScale(t *testing.T) {
+	expected := Mat4{
+		2, 0, 0, 0,
+		0, 3, 0, 0,
+		0, 0, 4, 0,
+		0, 0, 0, 1,
+	}
+	result := Scale(2, 3, 4)
+	if !mat4AlmostEqual(result, expected) {
+		t.Errorf("Scale matrix failed: expected %v, got %v", expected, result)
+	}
+}
+
 func TestRotateX(t *testing.T) {
 	angle := float32(math.Pi / 2)
 	s, c := float32(math.Sin(float64(angle))), float32(math.Cos(float64(angle)))
@@ -172,6 +185,57 @@ func TestRotateZ(t *testing.T) {
 	}
 }
 
+func TestFromQuaternion(t *testing.T) {
+	// A quarter turn about Z, unnormalized.
+	s := float32(math.Sqrt(0.5))
+	result := FromQuaternion(0, 0, 2*s, 2*s)
+	if expected := RotateZ(math.Pi / 2); !mat4AlmostEqual(result, expected) {
+		t.Errorf("FromQuaternion matrix failed: expected %v, got %v", expected, result)
+	}
+	if result := FromQuaternion(0, 0, 0, 0); !mat4AlmostEqual(result, Identity()) {
+		t.Errorf("expected the identity for a zero quaternion, got %v", result)
+	}
+}
+
+func TestToQuaternion(t *testing.T) {
+	for _, m := range []Mat4{
+		Identity(),
+		RotateX(2.5),
+		RotateY(-3),
+		MultiplyMatrices(RotateZ(3.1), Scale(2, 3, 4)),
+		Compose(Vec3{1, 2, 3}, Vec3{0.3, -1.2, 2.9}, Vec3{1, 1, 1}),
+	} {
+		x, y, z, w := ToQuaternion(m)
+		r, _ := rotationScale(m)
+		got := FromQuaternion(x, y, z, w)
+		for col := 0; col < 3; col++ {
+			for row := 0; row < 3; row++ {
+				if d := float64(got[col*4+row]) - r[row][col]; d > 1e-5 || d < -1e-5 {
+					t.Errorf("ToQuaternion(%v) = %v, %v, %v, %v does not round trip: got %v", m, x, y, z, w, got)
+					return
+				}
+			}
+		}
+	}
+}
+
+func TestInterpolate(t *testing.T) {
+	a := Compose(Vec3{0, 0, 0}, Vec3{0, 0, 0}, Vec3{1, 1, 1})
+	b := Compose(Vec3{2, 4, 6}, Vec3{0, 0, math.Pi / 2}, Vec3{3, 3, 3})
+	expected := Compose(Vec3{1, 2, 3}, Vec3{0, 0, math.Pi / 4}, Vec3{2, 2, 2})
+	if result := Interpolate(a, b, 0.5); !mat4AlmostEqual(result, expected) {
+		t.Errorf("Interpolate halfway failed: expected %v, got %v", expected, result)
+	}
+	if result := Interpolate(a, b, 1); !mat4AlmostEqual(result, b) {
+		t.Errorf("Interpolate at 1 failed: expected %v, got %v", b, result)
+	}
+	// From just short of a half turn to just past it, the short way round.
+	c, d := RotateZ(math.Pi-0.1), RotateZ(-math.Pi+0.1)
+	if result := Interpolate(c, d, 0.5); !mat4AlmostEqual(result, RotateZ(math.Pi)) {
+		t.Errorf("Interpolate took the long way: expected %v, got %v", RotateZ(math.Pi), result)
+	}
+}
+
 //
 // --- Matrix Operation Tests ---
 //
@@ -346,4 +410,152 @@ func TestMVPExampleFromREADME(t *testing.T) {
 	if mat4AlmostEqual(mvpMatrix, make(Mat4, 16)) {
 		t.Error("MVP matrix should not be a zero matrix")
 	}
-} 
\ No newline at end of file
+} 
+//
+// Benchmarks (go test -bench .)
+//
+
+var benchSink Mat4
+
+func BenchmarkMultiplyMatrices(b *testing.B) {
+	m1 := MultiplyMatrices(RotateY(0.3), Translate(1, 2, 3))
+	m2 := Perspective(math.Pi/4, 16.0/9.0, 0.1, 100)
+	for i := 0; i < b.N; i++ {
+		benchSink = MultiplyMatrices(m1, m2)
+	}
+}
+
+func BenchmarkTransformVertices(b *testing.B) {
+	const numVertices = 100000
+	coords := make([]float32, numVertices*3)
+	for i := range coords {
+		coords[i] = float32(i%1000) / 1000
+	}
+	m := MultiplyMatrices(RotateY(0.3), Translate(1, 2, 3))
+	b.SetBytes(int64(len(coords) * 4))
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		TransformVertices(coords, m)
+	}
+}
+
+func TestInvert(t *testing.T) {
+	m := MultiplyMatrices(Perspective(45, 1.5, 0.1, 100), MultiplyMatrices(RotateY(0.7), Translate(1, -2, 3)))
+	inv, ok := Invert(m)
+	if !ok {
+		t.Fatalf("Invert: expected an invertible matrix")
+	}
+	if product := MultiplyMatrices(m, inv); !mat4AlmostEqual(product, Identity()) {
+		// Perspective matrices are poorly conditioned; allow for rounding.
+		for i := range product {
+			if math.Abs(float64(product[i]-Identity()[i])) > 1e-4 {
+				t.Fatalf("m * Invert(m) should be Identity. Got %v", product)
+			}
+		}
+	}
+	if _, ok := Invert(make(Mat4, 16)); ok {
+		t.Errorf("Invert of the zero matrix should fail")
+	}
+}
+
+func TestOrthographic(t *testing.T) {
+	m := Orthographic(-2, 4, -1, 1, 1, 11)
+	for _, tt := range []struct{ in, want Vec3 }{
+		{Vec3{-2, -1, -1}, Vec3{-1, -1, -1}},
+		{Vec3{4, 1, -11}, Vec3{1, 1, 1}},
+		{Vec3{1, 0, -6}, Vec3{0, 0, 0}},
+	} {
+		got := TransformVertices([]float32{tt.in[0], tt.in[1], tt.in[2]}, m)
+		if !vec3AlmostEqual(Vec3{got[0], got[1], got[2]}, tt.want) {
+			t.Errorf("Orthographic maps %v to %v, expected %v", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestComposeDecompose(t *testing.T) {
+	position, rotation, scale := Vec3{1, -2, 3}, Vec3{0.3, -0.7, 1.2}, Vec3{2, 0.5, 1}
+	m := Compose(position, rotation, scale)
+	want := MultiplyMatrices(Translate(1, -2, 3), MultiplyMatrices(RotateZ(1.2), MultiplyMatrices(RotateY(-0.7), RotateX(0.3))))
+	want = MultiplyMatrices(want, Mat4{2, 0, 0, 0, 0, 0.5, 0, 0, 0, 0, 1, 0, 0, 0, 0, 1})
+	if !mat4AlmostEqual(m, want) {
+		t.Errorf("Compose: expected %v, got %v", want, m)
+	}
+	p, r, s := Decompose(m)
+	const tol = 1e-5
+	for i := 0; i < 3; i++ {
+		if math.Abs(float64(p[i]-position[i])) > tol || math.Abs(float64(r[i]-rotation[i])) > tol || math.Abs(float64(s[i]-scale[i])) > tol {
+			t.Fatalf("Decompose: expected %v %v %v, got %v %v %v", position, rotation, scale, p, r, s)
+		}
+	}
+	// A mirrored matrix gets a negative X scale and still recomposes.
+	mirrored := Compose(Vec3{0, 0, 0}, Vec3{0, 0, 0.5}, Vec3{-1, 1, 1})
+	p, r, s = Decompose(mirrored)
+	if s[0] >= 0 {
+		t.Errorf("Decompose: expected a negative X scale, got %v", s)
+	}
+	if back := Compose(p, r, s); !mat4AlmostEqual(back, mirrored) {
+		t.Errorf("Decompose of a mirror doesn't recompose: %v vs %v", back, mirrored)
+	}
+	// At a quarter turn about Y the matrix still recomposes.
+	gimbal := Compose(Vec3{0, 0, 0}, Vec3{0.4, math.Pi / 2, 0.3}, Vec3{1, 1, 1})
+	p, r, s = Decompose(gimbal)
+	if back := Compose(p, r, s); !mat4AlmostEqual(back, gimbal) {
+		t.Errorf("Decompose at gimbal lock doesn't recompose: %v vs %v", back, gimbal)
+	}
+}
+
+func TestCatmullRom(t *testing.T) {
+	points := []Vec3{{0, 0, 0}, {1, 2, 0}, {3, 2, 1}, {4, 0, 1}}
+	// The curve passes through every point.
+	for i, p := range points {
+		if got := CatmullRom(points, float32(i)/3); !vec3AlmostEqual(got, p) {
+			t.Errorf("CatmullRom at point %d: expected %v, got %v", i, p, got)
+		}
+	}
+	// Evenly spaced collinear points interpolate linearly.
+	line := []Vec3{{0, 0, 0}, {1, 0, 0}, {2, 0, 0}, {3, 0, 0}}
+	if got := CatmullRom(line, 0.5); !vec3AlmostEqual(got, Vec3{1.5, 0, 0}) {
+		t.Errorf("CatmullRom along a line: expected [1.5 0 0], got %v", got)
+	}
+	if got := CatmullRom(points[:1], 0.7); !vec3AlmostEqual(got, points[0]) {
+		t.Errorf("CatmullRom of one point: expected %v, got %v", points[0], got)
+	}
+	if got := CatmullRom(points, 2); !vec3AlmostEqual(got, points[3]) {
+		t.Errorf("CatmullRom past the end: expected %v, got %v", points[3], got)
+	}
+}
+
+func TestBezier(t *testing.T) {
+	p0, p1, p2, p3 := Vec3{0, 0, 0}, Vec3{0, 1, 0}, Vec3{1, 1, 0}, Vec3{1, 0, 0}
+	for _, tt := range []struct {
+		t    float32
+		want Vec3
+	}{
+		{0, p0},
+		{1, p3},
+		{0.5, Vec3{0.5, 0.75, 0}},
+	} {
+		if got := Bezier(p0, p1, p2, p3, tt.t); !vec3AlmostEqual(got, tt.want) {
+			t.Errorf("Bezier at %v: expected %v, got %v", tt.t, tt.want, got)
+		}
+	}
+}
+
+func TestUnproject(t *testing.T) {
+	viewProj := MultiplyMatrices(Perspective(45, 1.5, 0.1, 100), LookAt(Vec3{1, 2, 5}, Vec3{0, 0, 0}, Vec3{0, 1, 0}))
+	inv, ok := Invert(viewProj)
+	if !ok {
+		t.Fatalf("Unproject: expected an invertible matrix")
+	}
+	p := Vec3{0.5, -0.25, 0.75}
+	clip := TransformVertices([]float32{p[0], p[1], p[2]}, viewProj)
+	got, ok := Unproject(inv, Vec3{clip[0], clip[1], clip[2]})
+	for i := range p {
+		if !ok || math.Abs(float64(got[i]-p[i])) > 1e-4 {
+			t.Fatalf("Unproject: expected %v, got %v", p, got)
+		}
+	}
+	if _, ok := Unproject(make(Mat4, 16), Vec3{0, 0, 0}); ok {
+		t.Errorf("Unproject with w = 0 should fail")
+	}
+}
@@ -5,55 +5,96 @@ package glf32
 
 import (
 	"fmt"
-	"reflect"
 	"syscall/js"
 	"unsafe"
 )
 
+// bytesOf returns the memory of s as a byte slice, without copying.
+func bytesOf[T float32 | uint32 | uint16 | uint8](s []T) []byte {
+	if len(s) == 0 {
+		return nil
+	}
+	return unsafe.Slice((*byte)(unsafe.Pointer(&s[0])), len(s)*int(unsafe.Sizeof(s[0])))
+}
+
+// toTypedArray copies s into a new JS typed array of the given type with a
+// single CopyBytesToJS. Copying rather than viewing WASM memory keeps the
+// array valid when the memory grows.
+func toTypedArray[T float32 | uint32 | uint16 | uint8](s []T, typedArrayType string) js.Value {
+	array := js.Global().Get(typedArrayType).New(len(s))
+	if len(s) > 0 {
+		js.CopyBytesToJS(js.Global().Get("Uint8Array").New(array.Get("buffer")), bytesOf(s))
+	}
+	return array
+}
+
+// ToFloat32Array copies s into a new JS Float32Array.
+func ToFloat32Array(s []float32) js.Value {
+	return toTypedArray(s, "Float32Array")
+}
+
+// ToUint32Array copies s into a new JS Uint32Array.
+func ToUint32Array(s []uint32) js.Value {
+	return toTypedArray(s, "Uint32Array")
+}
+
+// ToUint16Array copies s into a new JS Uint16Array.
+func ToUint16Array(s []uint16) js.Value {
+	return toTypedArray(s, "Uint16Array")
+}
+
+// ToUint8Array copies s into a new JS Uint8Array.
+func ToUint8Array(s []uint8) js.Value {
+	return toTypedArray(s, "Uint8Array")
+}
+
+// fromTypedArray copies the JS typed array v, whose elements must have the
+// size of T, into s with a single CopyBytesToGo.
+func fromTypedArray[T float32 | uint32 | uint16 | uint8](v js.Value, s []T) []T {
+	if len(s) > 0 {
+		view := js.Global().Get("Uint8Array").New(v.Get("buffer"), v.Get("byteOffset"), v.Get("byteLength"))
+		js.CopyBytesToGo(bytesOf(s), view)
+	}
+	return s
+}
+
+// FromFloat32Array copies a JS Float32Array into a new Go slice. The array
+// may live in a SharedArrayBuffer.
+func FromFloat32Array(v js.Value) []float32 {
+	return fromTypedArray(v, make([]float32, v.Get("length").Int()))
+}
+
+// FromUint32Array copies a JS Uint32Array into a new Go slice.
+func FromUint32Array(v js.Value) []uint32 {
+	return fromTypedArray(v, make([]uint32, v.Get("length").Int()))
+}
+
 // UploadSliceToGL uploads a numeric Go slice to a WebGL buffer.
 // Accepts []float32, []uint16, or []uint32.
 // `target` is either "ARRAY_BUFFER" or "ELEMENT_ARRAY_BUFFER".
 // `usage` is usually gl.Get("STATIC_DRAW").
 func UploadSliceToGL(gl js.Value, data interface{}, target string, usage js.Value) js.Value {
-	v := reflect.ValueOf(data)
-	if v.Kind() != reflect.Slice || v.Len() == 0 {
-		panic("UploadSliceToGL: data must be a non-empty slice")
-	}
-
-	var byteSlice []byte
-	var byteLen int
-	var jsTypedArrayType string
-
-	switch v.Type().Elem().Kind() {
-	case reflect.Float32:
-		byteLen = v.Len() * 4
-		byteSlice = unsafe.Slice((*byte)(unsafe.Pointer((*float32)(unsafe.Pointer(v.Index(0).UnsafeAddr())))), byteLen)
-		jsTypedArrayType = "Float32Array"
-	case reflect.Uint16:
-		byteLen = v.Len() * 2
-		byteSlice = unsafe.Slice((*byte)(unsafe.Pointer((*uint16)(unsafe.Pointer(v.Index(0).UnsafeAddr())))), byteLen)
-		jsTypedArrayType = "Uint16Array"
-	case reflect.Uint32:
-		byteLen = v.Len() * 4
-		byteSlice = unsafe.Slice((*byte)(unsafe.Pointer((*uint32)(unsafe.Pointer(v.Index(0).UnsafeAddr())))), byteLen)
-		jsTypedArrayType = "Uint32Array"
+	var jsTypedArray js.Value
+	switch d := data.(type) {
+	case []float32:
+		jsTypedArray = ToFloat32Array(d)
+	case []uint16:
+		jsTypedArray = ToUint16Array(d)
+	case []uint32:
+		jsTypedArray = ToUint32Array(d)
 	default:
-		panic(fmt.Sprintf("UploadSliceToGL: unsupported slice type %s", v.Type().Elem()))
+		panic(fmt.Sprintf("UploadSliceToGL: unsupported slice type %T", data))
+	}
+	if jsTypedArray.Get("length").Int() == 0 {
+		panic("UploadSliceToGL: data must be a non-empty slice")
 	}
 
 	// Create buffer and bind
 	buffer := gl.Call("createBuffer")
 	gl.Call("bindBuffer", gl.Get(target), buffer)
 
-	// Transfer bytes to JS
-	jsBytes := js.Global().Get("Uint8Array").New(len(byteSlice))
-	js.CopyBytesToJS(jsBytes, byteSlice)
-
-	// Create correct JS typed array from the same buffer
-	jsTypedArray := js.Global().Get(jsTypedArrayType).New(jsBytes.Get("buffer"))
-
 	// Upload to GPU
 	gl.Call("bufferData", gl.Get(target), jsTypedArray, usage)
 
 	return buffer
-} 
\ No newline at end of file
+}
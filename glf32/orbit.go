@@ -0,0 +1,91 @@
+// glf32/orbit.go
+package glf32
+
+import "math"
+
+// parallelSin is the sine of the angle between a view and its up vector
+// below which SafeLookAt takes the roll from another axis: about 0.06°,
+// where the float32 cross product of the two is mostly rounding error.
+const parallelSin = 1e-3
+
+// SafeLookAt is LookAt for views that may pass close to their up vector,
+// such as a camera tilting over the top of a model. It works in float64,
+// and when the view is within about 0.06° of up (or up is zero) it takes
+// up from the world axis least aligned with the view, so the basis stays
+// orthonormal. An eye at the center gives a view that only translates.
+// It never returns NaNs for finite input.
+//
+// Panics if input vectors are not of length 3.
+func SafeLookAt(eye, center, up Vec3) Mat4 {
+	if len(eye) != 3 || len(center) != 3 || len(up) != 3 {
+		panic("SafeLookAt: input vectors must be Vec3 (length 3)")
+	}
+	f := unit64([3]float64{float64(center[0] - eye[0]), float64(center[1] - eye[1]), float64(center[2] - eye[2])})
+	if f == ([3]float64{}) {
+		return Translate(-eye[0], -eye[1], -eye[2])
+	}
+	u := unit64([3]float64{float64(up[0]), float64(up[1]), float64(up[2])})
+	s := cross64(f, u)
+	if math.Sqrt(s[0]*s[0]+s[1]*s[1]+s[2]*s[2]) < parallelSin {
+		a := leastAlignedAxis(Vec3{float32(f[0]), float32(f[1]), float32(f[2])})
+		s = cross64(f, [3]float64{float64(a[0]), float64(a[1]), float64(a[2])})
+	}
+	s = unit64(s)
+	u = cross64(s, f)
+	vec := func(v [3]float64) Vec3 { return Vec3{float32(v[0]), float32(v[1]), float32(v[2])} }
+	return viewMatrix(vec(s), vec(u), vec(f), eye)
+}
+
+// unit64 returns v scaled to unit length, or zero if it is zero.
+func unit64(v [3]float64) [3]float64 {
+	l := math.Sqrt(v[0]*v[0] + v[1]*v[1] + v[2]*v[2])
+	if l == 0 {
+		return [3]float64{}
+	}
+	return [3]float64{v[0] / l, v[1] / l, v[2] / l}
+}
+
+// cross64 returns the cross product a × b.
+func cross64(a, b [3]float64) [3]float64 {
+	return [3]float64{a[1]*b[2] - a[2]*b[1], a[2]*b[0] - a[0]*b[2], a[0]*b[1] - a[1]*b[0]}
+}
+
+// OrbitPosition returns where an orbit camera is: distance from target in
+// the direction given by yaw, the angle about the Y axis from +Z towards
+// +X, and pitch, the angle up from the XZ plane, both in radians.
+func OrbitPosition(target Vec3, yaw, pitch, distance float32) Vec3 {
+	d := orbitDirection(float64(yaw), float64(pitch))
+	return Vec3{
+		target[0] + distance*float32(d[0]),
+		target[1] + distance*float32(d[1]),
+		target[2] + distance*float32(d[2]),
+	}
+}
+
+// orbitDirection returns the unit vector from an orbit camera's target to
+// the camera.
+func orbitDirection(yaw, pitch float64) [3]float64 {
+	sy, cy := math.Sincos(yaw)
+	sp, cp := math.Sincos(pitch)
+	return [3]float64{sy * cp, sp, cy * cp}
+}
+
+// Orbit returns the view matrix of a camera at OrbitPosition(target, yaw,
+// pitch, distance) looking at target, with +Y up. Its up direction is the
+// way the camera moves as pitch increases, which is perpendicular to the
+// view at every pitch, so unlike LookAt with a fixed up vector it is
+// stable at and beyond the poles: at pitch π/2 the camera looks straight
+// down with yaw setting which way is up on screen.
+func Orbit(target Vec3, yaw, pitch, distance float32) Mat4 {
+	if len(target) != 3 {
+		panic("Orbit: target must be Vec3 (length 3)")
+	}
+	d := orbitDirection(float64(yaw), float64(pitch))
+	sy, cy := math.Sincos(float64(yaw))
+	sp, cp := math.Sincos(float64(pitch))
+	f := [3]float64{-d[0], -d[1], -d[2]}
+	u := [3]float64{-sy * sp, cp, -cy * sp}
+	s := cross64(f, u)
+	vec := func(v [3]float64) Vec3 { return Vec3{float32(v[0]), float32(v[1]), float32(v[2])} }
+	return viewMatrix(vec(s), vec(u), vec(f), OrbitPosition(target, yaw, pitch, distance))
+}
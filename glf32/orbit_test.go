@@ -0,0 +1,63 @@
+// glf32/orbit_test.go
+// usage: go test
+
+package glf32
+
+import (
+	"math"
+	"testing"
+)
+
+func TestSafeLookAt(t *testing.T) {
+	// Away from up it agrees with LookAt.
+	eye, center, up := Vec3{3, 2, 5}, Vec3{0, 1, 0}, Vec3{0, 1, 0}
+	if m := SafeLookAt(eye, center, up); !mat4AlmostEqual(m, LookAt(eye, center, up)) {
+		t.Errorf("expected LookAt's view, got %v", m)
+	}
+	// Within a hair of up it stays orthonormal and keeps the center ahead.
+	for _, eye := range []Vec3{{0, 5, 0}, {1e-6, 5, 0}, {0, -5, 1e-5}} {
+		m := SafeLookAt(eye, Vec3{0, 0, 0}, Vec3{0, 1, 0})
+		if !IsFinite(m) {
+			t.Fatalf("expected a finite view from %v, got %v", eye, m)
+		}
+		for col := 0; col < 3; col++ {
+			row := Vec3{m[col], m[4+col], m[8+col]}
+			if l := Dot(row, row); math.Abs(float64(l-1)) > 1e-5 {
+				t.Errorf("from %v: column %d of the rotation has length² %g", eye, col, l)
+			}
+		}
+		p := TransformVertices([]float32{0, 0, 0}, m)
+		if math.Abs(float64(p[2]+5)) > 1e-4 {
+			t.Errorf("from %v: expected the center 5 ahead, got %v", eye, p)
+		}
+	}
+	if m := SafeLookAt(Vec3{1, 2, 3}, Vec3{1, 2, 3}, Vec3{0, 1, 0}); !mat4AlmostEqual(m, Translate(-1, -2, -3)) {
+		t.Errorf("expected a translation for an eye at the center, got %v", m)
+	}
+}
+
+func TestOrbit(t *testing.T) {
+	target := Vec3{1, 2, 3}
+	yaw, pitch, distance := float32(0.7), float32(0.4), float32(10)
+	eye := OrbitPosition(target, yaw, pitch, distance)
+	if d := Subtract(eye, target); math.Abs(float64(Dot(d, d)-100)) > 1e-3 || d[1] <= 0 {
+		t.Errorf("expected the camera 10 from the target and above it, got %v", eye)
+	}
+	// It works in float64, so allow for LookAt's float32 rounding.
+	m, expected := Orbit(target, yaw, pitch, distance), LookAt(eye, target, Vec3{0, 1, 0})
+	for i := range m {
+		if math.Abs(float64(m[i]-expected[i])) > 1e-5 {
+			t.Errorf("expected LookAt's view %v off the poles, got %v", expected, m)
+			break
+		}
+	}
+	// Over the top, looking straight down with -Z up on screen at yaw 0.
+	m = Orbit(target, 0, math.Pi/2, distance)
+	if !IsFinite(m) {
+		t.Fatalf("expected a finite view at the pole, got %v", m)
+	}
+	p := TransformVertices([]float32{1, 2, 3, 1, 2, 2}, m)
+	if math.Abs(float64(p[2]+10)) > 1e-4 || p[4] < 0.999 || p[4] > 1.001 {
+		t.Errorf("expected the target 10 ahead and -Z up, got %v", p)
+	}
+}
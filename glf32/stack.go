@@ -0,0 +1,78 @@
+// glf32/stack.go
+package glf32
+
+// MatrixStack is a stack of transforms for walking a hierarchy, such as
+// layers and the objects in them or a gizmo attached to an object, in the
+// manner of OpenGL's fixed-function matrix stack: Push on the way down
+// into a child, MultMatrix its own transform, draw with Top, and Pop on
+// the way back up. The zero value holds the identity.
+type MatrixStack struct {
+	stack []Mat4
+}
+
+// NewMatrixStack returns a stack holding m, or the identity if m is nil.
+func NewMatrixStack(m Mat4) *MatrixStack {
+	s := &MatrixStack{}
+	if m != nil {
+		s.LoadMatrix(m)
+	}
+	return s
+}
+
+// top returns the index of the top, making sure there is one.
+func (s *MatrixStack) top() int {
+	if len(s.stack) == 0 {
+		s.stack = append(s.stack, Identity())
+	}
+	return len(s.stack) - 1
+}
+
+// Top returns the transform on top of the stack. Later calls never modify
+// it, so it may be kept.
+func (s *MatrixStack) Top() Mat4 {
+	return s.stack[s.top()]
+}
+
+// Depth returns the number of transforms on the stack, at least 1.
+func (s *MatrixStack) Depth() int {
+	return s.top() + 1
+}
+
+// Push saves the top, so that the next Pop returns to it.
+func (s *MatrixStack) Push() {
+	s.stack = append(s.stack, s.stack[s.top()])
+}
+
+// Pop discards the top, returning to the transform saved by the matching
+// Push.
+//
+// Panics if there is no matching Push.
+func (s *MatrixStack) Pop() {
+	if s.top() == 0 {
+		panic("MatrixStack.Pop: no matching Push")
+	}
+	s.stack = s.stack[:len(s.stack)-1]
+}
+
+// LoadIdentity replaces the top with the identity.
+func (s *MatrixStack) LoadIdentity() {
+	s.stack[s.top()] = Identity()
+}
+
+// LoadMatrix replaces the top with a copy of m.
+//
+// Panics if m is not of length 16.
+func (s *MatrixStack) LoadMatrix(m Mat4) {
+	if len(m) != 16 {
+		panic("MatrixStack.LoadMatrix: matrix must be Mat4 (length 16)")
+	}
+	s.stack[s.top()] = append(Mat4(nil), m...)
+}
+
+// MultMatrix replaces the top T with T * m, so that m applies first: a
+// child's transform multiplied in after its parent's places the child
+// within the parent.
+func (s *MatrixStack) MultMatrix(m Mat4) {
+	i := s.top()
+	s.stack[i] = MultiplyMatrices(s.stack[i], m)
+}
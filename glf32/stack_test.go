@@ -0,0 +1,48 @@
+// glf32/stack_test.go
+// usage: go test
+
+package glf32
+
+import (
+	"testing"
+)
+
+func TestMatrixStack(t *testing.T) {
+	var s MatrixStack
+	if !mat4AlmostEqual(s.Top(), Identity()) || s.Depth() != 1 {
+		t.Fatalf("expected the zero stack to hold the identity, got %v at depth %d", s.Top(), s.Depth())
+	}
+	// A parent moved along x holding a child turned a quarter about z.
+	parent, child := Translate(5, 0, 0), RotateZ(1.5707964)
+	s.MultMatrix(parent)
+	s.Push()
+	s.MultMatrix(child)
+	inChild := s.Top()
+	if expected := MultiplyMatrices(parent, child); !mat4AlmostEqual(inChild, expected) || s.Depth() != 2 {
+		t.Errorf("expected parent * child at depth 2, got %v at depth %d", inChild, s.Depth())
+	}
+	p := TransformVertices([]float32{1, 0, 0}, inChild)
+	if p[0] < 4.999 || p[0] > 5.001 || p[1] < 0.999 || p[1] > 1.001 {
+		t.Errorf("expected the child's x axis at (5, 1, 0), got %v", p)
+	}
+	s.Pop()
+	if !mat4AlmostEqual(s.Top(), parent) {
+		t.Errorf("expected Pop to return to the parent, got %v", s.Top())
+	}
+	if !mat4AlmostEqual(inChild, MultiplyMatrices(parent, child)) {
+		t.Errorf("expected a kept Top to stay unchanged, got %v", inChild)
+	}
+	s.Push()
+	s.LoadIdentity()
+	s.Pop()
+	if !mat4AlmostEqual(s.Top(), parent) {
+		t.Errorf("expected LoadIdentity to change only the top, got %v", s.Top())
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Error("expected Pop without Push to panic")
+		}
+	}()
+	NewMatrixStack(Scale(2, 2, 2)).Pop()
+}
@@ -0,0 +1,159 @@
+// glsl/glsl.go
+// Package glsl assembles GLSL shader sources from named snippets, so that
+// shaders can live in files of their own rather than in Go strings: a
+// snippet pulls in others with #include "name" lines, constants and
+// feature switches are injected as #define lines, and the compiler's
+// error log, which numbers the lines of the assembled source, is mapped
+// back to the snippets and lines they came from.
+package glsl
+
+import (
+	"fmt"
+	"io/fs"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Library is a set of named snippets.
+type Library struct {
+	snippets map[string]string
+}
+
+// NewLibrary returns an empty library.
+func NewLibrary() *Library {
+	return &Library{snippets: map[string]string{}}
+}
+
+// Add adds the snippet source under name, replacing any of that name.
+func (l *Library) Add(name, source string) {
+	l.snippets[name] = source
+}
+
+// AddFS adds the files of fsys matching pattern, each under its base name,
+// e.g. "point.vert" for "shaders/point.vert".
+func (l *Library) AddFS(fsys fs.FS, pattern string) error {
+	names, err := fs.Glob(fsys, pattern)
+	if err != nil {
+		return err
+	}
+	for _, name := range names {
+		b, err := fs.ReadFile(fsys, name)
+		if err != nil {
+			return err
+		}
+		l.Add(path.Base(name), string(b))
+	}
+	return nil
+}
+
+// Define is a macro injected into an assembled source; Value may be empty.
+type Define struct {
+	Name, Value string
+}
+
+// Origin is where a line of an assembled source came from: a line of a
+// snippet, counting from 1, or, with Line 0, the injected defines.
+type Origin struct {
+	Snippet string
+	Line    int
+}
+
+func (o Origin) String() string {
+	if o.Line == 0 {
+		return "<defines>"
+	}
+	return fmt.Sprintf("%s:%d", o.Snippet, o.Line)
+}
+
+// Source is an assembled shader source and where each of its lines came
+// from.
+type Source struct {
+	Text    string
+	origins []Origin
+}
+
+// includeLine matches an #include line, naming the snippet in quotes or
+// angle brackets.
+var includeLine = regexp.MustCompile(`^\s*#\s*include\s+["<]([^">]+)[">]\s*$`)
+
+// Build assembles the named snippet with the defines ahead of it, after
+// its #version line if it starts with one. Each #include line is replaced
+// by the snippet it names, assembled the same way; a snippet already
+// included is left out, so shared snippets need no include guards.
+func (l *Library) Build(name string, defines []Define) (*Source, error) {
+	src, ok := l.snippets[name]
+	if !ok {
+		return nil, fmt.Errorf("glsl: unknown snippet %q", name)
+	}
+	s := &Source{}
+	var b strings.Builder
+	emit := func(line string, o Origin) {
+		b.WriteString(line)
+		b.WriteByte('\n')
+		s.origins = append(s.origins, o)
+	}
+	lines := strings.Split(strings.TrimSuffix(src, "\n"), "\n")
+	first := 0
+	if strings.HasPrefix(strings.TrimSpace(lines[0]), "#version") {
+		emit(lines[0], Origin{name, 1})
+		first = 1
+	}
+	for _, d := range defines {
+		emit(strings.TrimSpace("#define "+d.Name+" "+d.Value), Origin{})
+	}
+	included := map[string]bool{name: true}
+	var expand func(name string, lines []string, offset int) error
+	expand = func(name string, lines []string, offset int) error {
+		for i, line := range lines {
+			m := includeLine.FindStringSubmatch(line)
+			if m == nil {
+				emit(line, Origin{name, offset + i + 1})
+				continue
+			}
+			if included[m[1]] {
+				continue
+			}
+			sub, ok := l.snippets[m[1]]
+			if !ok {
+				return fmt.Errorf("glsl: %s:%d: unknown snippet %q", name, offset+i+1, m[1])
+			}
+			included[m[1]] = true
+			if err := expand(m[1], strings.Split(strings.TrimSuffix(sub, "\n"), "\n"), 0); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	if err := expand(name, lines[first:], first); err != nil {
+		return nil, err
+	}
+	s.Text = b.String()
+	return s, nil
+}
+
+// Origin returns where line n of the source, counting from 1, came from.
+func (s *Source) Origin(n int) (Origin, bool) {
+	if n < 1 || n > len(s.origins) {
+		return Origin{}, false
+	}
+	return s.origins[n-1], true
+}
+
+// logLine matches the source and line numbers WebGL compilers put in
+// their logs, as in "ERROR: 0:12: 'x' : undeclared identifier".
+var logLine = regexp.MustCompile(`\b(ERROR|WARNING): \d+:(\d+):`)
+
+// Remap rewrites the line numbers of a compiler log for the source to the
+// snippets and lines they came from, as in "ERROR: point.vert:10:".
+func (s *Source) Remap(log string) string {
+	return logLine.ReplaceAllStringFunc(log, func(m string) string {
+		sub := logLine.FindStringSubmatch(m)
+		n, _ := strconv.Atoi(sub[2])
+		if o, ok := s.Origin(n); ok {
+			return sub[1] + ": " + o.String() + ":"
+		}
+		return m
+	})
+}
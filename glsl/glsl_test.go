@@ -0,0 +1,88 @@
+// glsl/glsl_test.go
+// usage: go test
+
+package glsl
+
+import (
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+func TestBuildIncludesSnippetsOnce(t *testing.T) {
+	l := NewLibrary()
+	l.Add("main.vert", "#include \"a.glsl\"\n#include <b.glsl>\nvoid main() {}\n")
+	l.Add("a.glsl", "#include \"b.glsl\"\nfloat a;")
+	l.Add("b.glsl", "float b;\n")
+	s, err := l.Build("main.vert", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "float b;\nfloat a;\nvoid main() {}\n"
+	if s.Text != want {
+		t.Errorf("expected %q, got %q", want, s.Text)
+	}
+}
+
+func TestBuildInjectsDefinesAfterVersion(t *testing.T) {
+	l := NewLibrary()
+	l.Add("main.vert", "#version 300 es\nvoid main() {}")
+	s, err := l.Build("main.vert", []Define{{"MAX_CLASSES", "32"}, {"LIT_POINTS", ""}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "#version 300 es\n#define MAX_CLASSES 32\n#define LIT_POINTS\nvoid main() {}\n"
+	if s.Text != want {
+		t.Errorf("expected %q, got %q", want, s.Text)
+	}
+	if o, _ := s.Origin(4); o != (Origin{"main.vert", 2}) {
+		t.Errorf("expected line 4 from main.vert:2, got %v", o)
+	}
+	if o, _ := s.Origin(2); o.Line != 0 {
+		t.Errorf("expected line 2 from the defines, got %v", o)
+	}
+}
+
+func TestBuildReportsUnknownSnippets(t *testing.T) {
+	l := NewLibrary()
+	l.Add("main.vert", "void f();\n#include \"missing.glsl\"\n")
+	if _, err := l.Build("main.vert", nil); err == nil || !strings.Contains(err.Error(), "main.vert:2") {
+		t.Errorf("expected an error at main.vert:2, got %v", err)
+	}
+	if _, err := l.Build("nope.vert", nil); err == nil {
+		t.Error("expected an error for an unknown snippet")
+	}
+}
+
+func TestRemap(t *testing.T) {
+	l := NewLibrary()
+	l.Add("main.vert", "#include \"util.glsl\"\nvoid main() {\n\tx = 1.0;\n}")
+	l.Add("util.glsl", "float f() {\n\treturn y;\n}")
+	s, err := l.Build("main.vert", []Define{{"A", "1"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	log := "ERROR: 0:3: 'y' : undeclared identifier\nERROR: 0:6: 'x' : undeclared identifier\nERROR: 0:99: end"
+	want := "ERROR: util.glsl:2: 'y' : undeclared identifier\nERROR: main.vert:3: 'x' : undeclared identifier\nERROR: 0:99: end"
+	if got := s.Remap(log); got != want {
+		t.Errorf("expected %q, got %q", want, got)
+	}
+}
+
+func TestAddFS(t *testing.T) {
+	fsys := fstest.MapFS{
+		"shaders/point.vert": {Data: []byte("#include \"ramp.glsl\"\n")},
+		"shaders/ramp.glsl":  {Data: []byte("vec3 ramp(float t);\n")},
+	}
+	l := NewLibrary()
+	if err := l.AddFS(fsys, "shaders/*"); err != nil {
+		t.Fatal(err)
+	}
+	s, err := l.Build("point.vert", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.Text != "vec3 ramp(float t);\n" {
+		t.Errorf("unexpected source %q", s.Text)
+	}
+}
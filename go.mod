@@ -1,3 +1,8 @@
 module github.com/sbecker11/webgl-point-cloud
 
 go 1.24
+
+require (
+	github.com/go-gl/gl v0.0.0-20231021071112-07e5d0ea2e71
+	github.com/go-gl/glfw/v3.3/glfw v0.0.0-20260823155953-d41da22a9587
+)
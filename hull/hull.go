@@ -0,0 +1,266 @@
+// hull/hull.go
+// Package hull computes the convex hull of a set of points with quickhull,
+// and the oriented bounding box of a set of points from its hull, for
+// sizing objects and estimating stockpile volumes.
+package hull
+
+import (
+	"errors"
+	"math"
+
+	"github.com/sbecker11/webgl-point-cloud/mesh"
+)
+
+// ErrDegenerate is returned when the points do not span three dimensions,
+// so they have no solid hull: fewer than four points, or all of them on one
+// plane.
+var ErrDegenerate = errors.New("hull: points are coplanar")
+
+type vec [3]float64
+
+func (a vec) add(b vec) vec       { return vec{a[0] + b[0], a[1] + b[1], a[2] + b[2]} }
+func (a vec) sub(b vec) vec       { return vec{a[0] - b[0], a[1] - b[1], a[2] - b[2]} }
+func (a vec) dot(b vec) float64   { return a[0]*b[0] + a[1]*b[1] + a[2]*b[2] }
+func (a vec) scale(s float64) vec { return vec{a[0] * s, a[1] * s, a[2] * s} }
+func (a vec) cross(b vec) vec {
+	return vec{a[1]*b[2] - a[2]*b[1], a[2]*b[0] - a[0]*b[2], a[0]*b[1] - a[1]*b[0]}
+}
+func (a vec) length() float64 { return math.Sqrt(a.dot(a)) }
+
+// face is a hull triangle, wound counter-clockwise seen from outside, with
+// the points still outside it.
+type face struct {
+	v       [3]int
+	normal  vec
+	offset  float64 // dot(normal, p) for p on the plane
+	outside []int
+	dead    bool
+}
+
+func (f *face) distance(p vec) float64 {
+	return f.normal.dot(p) - f.offset
+}
+
+// quickhull holds the hull being built.
+type quickhull struct {
+	points []vec
+	faces  []*face
+	edges  map[uint64]*face // face by each of its directed edges
+	eps    float64
+}
+
+func edgeKey(a, b int) uint64 {
+	return uint64(a)<<32 | uint64(uint32(b))
+}
+
+func (q *quickhull) addFace(a, b, c int) *face {
+	pa, pb, pc := q.points[a], q.points[b], q.points[c]
+	n := pb.sub(pa).cross(pc.sub(pa))
+	if l := n.length(); l > 0 {
+		n = n.scale(1 / l)
+	}
+	f := &face{v: [3]int{a, b, c}, normal: n, offset: n.dot(pa)}
+	q.faces = append(q.faces, f)
+	for i := 0; i < 3; i++ {
+		q.edges[edgeKey(f.v[i], f.v[(i+1)%3])] = f
+	}
+	return f
+}
+
+// assign adds point i to the first face it is outside of, if any.
+func (q *quickhull) assign(i int, faces []*face) {
+	for _, f := range faces {
+		if f.distance(q.points[i]) > q.eps {
+			f.outside = append(f.outside, i)
+			return
+		}
+	}
+}
+
+// Hull returns the convex hull of packed xyz coordinates as a closed
+// triangle mesh of the hull's vertices, wound counter-clockwise seen from
+// outside, with vertex normals. Flat facets with more than three corners
+// are split into triangles.
+func Hull(coords []float32) (*mesh.Mesh, error) {
+	if len(coords)%3 != 0 {
+		return nil, errors.New("hull: coords length must be a multiple of 3")
+	}
+	n := len(coords) / 3
+	if n < 4 {
+		return nil, ErrDegenerate
+	}
+	q := &quickhull{points: make([]vec, n), edges: map[uint64]*face{}}
+	scale := 0.0
+	for i := range q.points {
+		q.points[i] = vec{float64(coords[i*3]), float64(coords[i*3+1]), float64(coords[i*3+2])}
+		for _, c := range q.points[i] {
+			scale = math.Max(scale, math.Abs(c))
+		}
+	}
+	q.eps = 1e-9 * math.Max(scale, 1)
+
+	simplex, err := q.initialSimplex()
+	if err != nil {
+		return nil, err
+	}
+	for i := range q.points {
+		if i != simplex[0] && i != simplex[1] && i != simplex[2] && i != simplex[3] {
+			q.assign(i, q.faces)
+		}
+	}
+
+	for pending := 0; pending < len(q.faces); {
+		f := q.faces[pending]
+		if f.dead || len(f.outside) == 0 {
+			pending++
+			continue
+		}
+		q.addPoint(f)
+	}
+	return q.mesh(), nil
+}
+
+// initialSimplex adds the four faces of a large tetrahedron: the extreme
+// points along the axis of greatest spread, the point farthest from the
+// line through them and the point farthest from the plane through all
+// three.
+func (q *quickhull) initialSimplex() ([4]int, error) {
+	var lo, hi [3]int
+	for i, p := range q.points {
+		for a := 0; a < 3; a++ {
+			if p[a] < q.points[lo[a]][a] {
+				lo[a] = i
+			}
+			if p[a] > q.points[hi[a]][a] {
+				hi[a] = i
+			}
+		}
+	}
+	axis := 0
+	for a := 1; a < 3; a++ {
+		if q.points[hi[a]][a]-q.points[lo[a]][a] > q.points[hi[axis]][axis]-q.points[lo[axis]][axis] {
+			axis = a
+		}
+	}
+	p0, p1 := lo[axis], hi[axis]
+	line := q.points[p1].sub(q.points[p0])
+	if line.length() <= q.eps {
+		return [4]int{}, ErrDegenerate
+	}
+
+	p2, best := -1, q.eps
+	for i, p := range q.points {
+		if d := line.cross(p.sub(q.points[p0])).length() / line.length(); d > best {
+			p2, best = i, d
+		}
+	}
+	if p2 < 0 {
+		return [4]int{}, ErrDegenerate
+	}
+	normal := line.cross(q.points[p2].sub(q.points[p0]))
+	normal = normal.scale(1 / normal.length())
+
+	p3, best := -1, q.eps
+	for i, p := range q.points {
+		if d := math.Abs(normal.dot(p.sub(q.points[p0]))); d > best {
+			p3, best = i, d
+		}
+	}
+	if p3 < 0 {
+		return [4]int{}, ErrDegenerate
+	}
+
+	// Wind the base away from the apex, and the sides to match.
+	if normal.dot(q.points[p3].sub(q.points[p0])) > 0 {
+		p1, p2 = p2, p1
+	}
+	q.addFace(p0, p1, p2)
+	q.addFace(p0, p3, p1)
+	q.addFace(p1, p3, p2)
+	q.addFace(p2, p3, p0)
+	return [4]int{p0, p1, p2, p3}, nil
+}
+
+// addPoint grows the hull to the point of f's outside set farthest from
+// it: the faces it can see are removed and the hole is closed with a fan of
+// new faces from the point to the edges of the hole.
+func (q *quickhull) addPoint(f *face) {
+	apex, best := -1, -1.0
+	for _, i := range f.outside {
+		if d := f.distance(q.points[i]); d > best {
+			apex, best = i, d
+		}
+	}
+	p := q.points[apex]
+
+	// Collect the faces visible from the point, flood-filling from f
+	// across shared edges; edges of visible faces whose neighbour is not
+	// visible form the horizon.
+	visible := map[*face]bool{f: true}
+	stack := []*face{f}
+	var horizon [][2]int
+	for len(stack) > 0 {
+		g := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		for i := 0; i < 3; i++ {
+			a, b := g.v[i], g.v[(i+1)%3]
+			neighbour := q.edges[edgeKey(b, a)]
+			if visible[neighbour] {
+				continue
+			}
+			if neighbour.distance(p) > q.eps {
+				visible[neighbour] = true
+				stack = append(stack, neighbour)
+			} else {
+				horizon = append(horizon, [2]int{a, b})
+			}
+		}
+	}
+
+	var orphans []int
+	for g := range visible {
+		g.dead = true
+		for i := 0; i < 3; i++ {
+			delete(q.edges, edgeKey(g.v[i], g.v[(i+1)%3]))
+		}
+		for _, i := range g.outside {
+			if i != apex {
+				orphans = append(orphans, i)
+			}
+		}
+		g.outside = nil
+	}
+
+	// Each horizon edge keeps the winding it had in its visible face, so
+	// the new faces face outward.
+	added := make([]*face, len(horizon))
+	for i, e := range horizon {
+		added[i] = q.addFace(e[0], e[1], apex)
+	}
+	for _, i := range orphans {
+		q.assign(i, added)
+	}
+}
+
+// mesh returns the live faces as a mesh over the hull's vertices only.
+func (q *quickhull) mesh() *mesh.Mesh {
+	m := &mesh.Mesh{}
+	index := map[int]uint32{}
+	for _, f := range q.faces {
+		if f.dead {
+			continue
+		}
+		for _, v := range f.v {
+			i, ok := index[v]
+			if !ok {
+				i = uint32(len(index))
+				index[v] = i
+				p := q.points[v]
+				m.Positions = append(m.Positions, float32(p[0]), float32(p[1]), float32(p[2]))
+			}
+			m.Indices = append(m.Indices, i)
+		}
+	}
+	m.ComputeVertexNormals()
+	return m
+}
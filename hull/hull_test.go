@@ -0,0 +1,112 @@
+// hull/hull_test.go
+// usage: go test
+
+package hull
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+)
+
+// cubePoints returns n random points inside an axis-aligned box with the
+// given sides, plus its corners, rotated about y by angle and moved to
+// (10, 20, 30).
+func cubePoints(n int, size [3]float64, angle float64) []float32 {
+	r := rand.New(rand.NewSource(1))
+	var coords []float32
+	add := func(x, y, z float64) {
+		c, s := math.Cos(angle), math.Sin(angle)
+		coords = append(coords, float32(c*x+s*z+10), float32(y+20), float32(-s*x+c*z+30))
+	}
+	for c := 0; c < 8; c++ {
+		add(float64(c&1)*size[0], float64(c>>1&1)*size[1], float64(c>>2&1)*size[2])
+	}
+	for i := 0; i < n; i++ {
+		add(r.Float64()*size[0], r.Float64()*size[1], r.Float64()*size[2])
+	}
+	return coords
+}
+
+func TestHullCube(t *testing.T) {
+	m, err := Hull(cubePoints(2000, [3]float64{4, 2, 1}, 0))
+	if err != nil {
+		t.Fatalf("Hull: %v", err)
+	}
+	if m.VertexCount() != 8 || m.TriangleCount() != 12 {
+		t.Errorf("expected 8 vertices and 12 triangles, got %d and %d", m.VertexCount(), m.TriangleCount())
+	}
+	if v := m.Volume(); math.Abs(v-8) > 1e-3 {
+		t.Errorf("expected volume 8, got %f", v)
+	}
+}
+
+func TestHullSphere(t *testing.T) {
+	// Every point of a sphere is on its hull, and the hull is closed and
+	// wound outward.
+	r := rand.New(rand.NewSource(2))
+	var coords []float32
+	for len(coords) < 3000 {
+		x, y, z := r.NormFloat64(), r.NormFloat64(), r.NormFloat64()
+		l := math.Sqrt(x*x + y*y + z*z)
+		coords = append(coords, float32(x/l), float32(y/l), float32(z/l))
+	}
+	m, err := Hull(coords)
+	if err != nil {
+		t.Fatalf("Hull: %v", err)
+	}
+	if m.VertexCount() != 1000 {
+		t.Errorf("expected 1000 hull vertices, got %d", m.VertexCount())
+	}
+	if v := m.Volume(); v < 4.0 || v > 4*math.Pi/3 {
+		t.Errorf("expected volume just under %f, got %f", 4*math.Pi/3, v)
+	}
+	edges := map[[2]uint32]int{}
+	for i := 0; i < len(m.Indices); i += 3 {
+		for e := 0; e < 3; e++ {
+			edges[[2]uint32{m.Indices[i+e], m.Indices[i+(e+1)%3]}]++
+		}
+	}
+	for e, n := range edges {
+		if n != 1 || edges[[2]uint32{e[1], e[0]}] != 1 {
+			t.Fatalf("edge %v is not shared by exactly two consistently wound triangles", e)
+		}
+	}
+}
+
+func TestHullDegenerate(t *testing.T) {
+	if _, err := Hull([]float32{0, 0, 0, 1, 0, 0, 0, 1, 0}); err != ErrDegenerate {
+		t.Errorf("three points: expected ErrDegenerate, got %v", err)
+	}
+	flat := []float32{0, 0, 0, 1, 0, 0, 0, 1, 0, 1, 1, 0, 0.5, 0.5, 0}
+	if _, err := Hull(flat); err != ErrDegenerate {
+		t.Errorf("coplanar points: expected ErrDegenerate, got %v", err)
+	}
+}
+
+func TestOrientedBox(t *testing.T) {
+	box, err := OrientedBox(cubePoints(2000, [3]float64{4, 2, 1}, 0.5))
+	if err != nil {
+		t.Fatalf("OrientedBox: %v", err)
+	}
+	size := box.Size()
+	want := [3]float64{4, 2, 1}
+	for a := 0; a < 3; a++ {
+		if math.Abs(size[a]-want[a]) > 1e-3 {
+			t.Fatalf("expected size %v, got %v", want, size)
+		}
+	}
+	// The longest axis is the box's rotated x axis, up to sign.
+	if d := math.Abs(box.Axes[0][0]*math.Cos(0.5) - box.Axes[0][2]*math.Sin(0.5)); math.Abs(d-1) > 1e-3 {
+		t.Errorf("expected the first axis along the rotated x axis, got %v", box.Axes[0])
+	}
+	center := [3]float64{10 + 2*math.Cos(0.5) + 0.5*math.Sin(0.5), 21, 30 - 2*math.Sin(0.5) + 0.5*math.Cos(0.5)}
+	for k := 0; k < 3; k++ {
+		if math.Abs(box.Center[k]-center[k]) > 1e-3 {
+			t.Fatalf("expected center %v, got %v", center, box.Center)
+		}
+	}
+	if v := box.Mesh().Volume(); math.Abs(v-8) > 1e-3 {
+		t.Errorf("expected the box mesh to enclose 8 wound outward, got %f", v)
+	}
+}
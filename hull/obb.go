@@ -0,0 +1,224 @@
+// hull/obb.go
+package hull
+
+import (
+	"math"
+	"sort"
+
+	"github.com/sbecker11/webgl-point-cloud/mesh"
+)
+
+// Box is an oriented bounding box: a center, three orthonormal axes and
+// the half size of the box along each.
+type Box struct {
+	Center   [3]float64
+	Axes     [3][3]float64
+	HalfSize [3]float64
+}
+
+// Size returns the box's dimensions along its axes.
+func (b Box) Size() [3]float64 {
+	return [3]float64{2 * b.HalfSize[0], 2 * b.HalfSize[1], 2 * b.HalfSize[2]}
+}
+
+// Volume returns the box's volume.
+func (b Box) Volume() float64 {
+	s := b.Size()
+	return s[0] * s[1] * s[2]
+}
+
+// Corners returns the box's eight corners; corner c is on the positive
+// side of axis a when bit a of c is set.
+func (b Box) Corners() [8][3]float64 {
+	var corners [8][3]float64
+	for c := range corners {
+		corners[c] = b.Center
+		for a := 0; a < 3; a++ {
+			s := -b.HalfSize[a]
+			if c>>a&1 == 1 {
+				s = b.HalfSize[a]
+			}
+			for k := 0; k < 3; k++ {
+				corners[c][k] += s * b.Axes[a][k]
+			}
+		}
+	}
+	return corners
+}
+
+// boxFaces lists the corners of each face of a box, counter-clockwise seen
+// from outside when the axes are right-handed.
+var boxFaces = [6][4]int{
+	{0, 4, 6, 2}, {1, 3, 7, 5}, // -x, +x
+	{0, 1, 5, 4}, {2, 6, 7, 3}, // -y, +y
+	{0, 2, 3, 1}, {4, 5, 7, 6}, // -z, +z
+}
+
+// BoxEdges lists the corner pairs of a box's twelve edges, for drawing it
+// as a wireframe over Corners.
+var BoxEdges = []uint32{
+	0, 1, 2, 3, 4, 5, 6, 7,
+	0, 2, 1, 3, 4, 6, 5, 7,
+	0, 4, 1, 5, 2, 6, 3, 7,
+}
+
+// Mesh returns the box as a closed triangle mesh over its corners.
+func (b Box) Mesh() *mesh.Mesh {
+	m := &mesh.Mesh{}
+	for _, c := range b.Corners() {
+		m.Positions = append(m.Positions, float32(c[0]), float32(c[1]), float32(c[2]))
+	}
+	for _, f := range boxFaces {
+		m.Indices = append(m.Indices, uint32(f[0]), uint32(f[1]), uint32(f[2]), uint32(f[0]), uint32(f[2]), uint32(f[3]))
+	}
+	m.ComputeVertexNormals()
+	return m
+}
+
+// OrientedBox returns a tight oriented bounding box of packed xyz
+// coordinates. It tries a box flush with each facet of the points' convex
+// hull, rotated in the facet's plane to the smallest rectangle around the
+// hull's outline, and returns the one of least volume. That is the
+// minimum-volume box in the common case where the minimum box has a face
+// on a hull facet, and close to it otherwise. The axes are right-handed,
+// ordered from the longest side to the shortest.
+func OrientedBox(coords []float32) (Box, error) {
+	m, err := Hull(coords)
+	if err != nil {
+		return Box{}, err
+	}
+	points := make([]vec, m.VertexCount())
+	for i := range points {
+		x, y, z := m.Vertex(uint32(i))
+		points[i] = vec{float64(x), float64(y), float64(z)}
+	}
+
+	var best Box
+	bestVolume := math.Inf(1)
+	tried := map[[3]int64]bool{}
+	for t := 0; t < m.TriangleCount(); t++ {
+		a, b, c := m.Triangle(t)
+		n := points[b].sub(points[a]).cross(points[c].sub(points[a]))
+		l := n.length()
+		if l == 0 {
+			continue
+		}
+		n = n.scale(1 / l)
+		// Facets split into several triangles share a normal; try it once.
+		key := [3]int64{int64(math.Round(n[0] * 1e6)), int64(math.Round(n[1] * 1e6)), int64(math.Round(n[2] * 1e6))}
+		if tried[key] {
+			continue
+		}
+		tried[key] = true
+		if box := facetBox(points, n); box.Volume() < bestVolume {
+			best, bestVolume = box, box.Volume()
+		}
+	}
+	return best.sorted(), nil
+}
+
+// facetBox returns the smallest box around points with one axis along n.
+func facetBox(points []vec, n vec) Box {
+	// Any unit vector perpendicular to n completes a basis of the plane.
+	u := vec{1, 0, 0}
+	if math.Abs(n[0]) > 0.9 {
+		u = vec{0, 1, 0}
+	}
+	u = u.sub(n.scale(u.dot(n)))
+	u = u.scale(1 / u.length())
+	v := n.cross(u)
+
+	flat := make([][2]float64, len(points))
+	lo, hi := math.Inf(1), math.Inf(-1)
+	for i, p := range points {
+		flat[i] = [2]float64{p.dot(u), p.dot(v)}
+		h := p.dot(n)
+		lo, hi = math.Min(lo, h), math.Max(hi, h)
+	}
+	outline := hull2D(flat)
+
+	// The smallest rectangle around a convex polygon has a side along one
+	// of the polygon's edges.
+	bestArea := math.Inf(1)
+	var rect [2][2]float64 // rectangle axes in the plane
+	var rectLo, rectHi [2]float64
+	for i := range outline {
+		p, q := outline[i], outline[(i+1)%len(outline)]
+		dx, dy := q[0]-p[0], q[1]-p[1]
+		l := math.Hypot(dx, dy)
+		if l == 0 {
+			continue
+		}
+		e := [2]float64{dx / l, dy / l}
+		f := [2]float64{-e[1], e[0]}
+		min := [2]float64{math.Inf(1), math.Inf(1)}
+		max := [2]float64{math.Inf(-1), math.Inf(-1)}
+		for _, r := range outline {
+			s, t := r[0]*e[0]+r[1]*e[1], r[0]*f[0]+r[1]*f[1]
+			min[0], max[0] = math.Min(min[0], s), math.Max(max[0], s)
+			min[1], max[1] = math.Min(min[1], t), math.Max(max[1], t)
+		}
+		if area := (max[0] - min[0]) * (max[1] - min[1]); area < bestArea {
+			bestArea, rect, rectLo, rectHi = area, [2][2]float64{e, f}, min, max
+		}
+	}
+
+	var box Box
+	inPlane := func(d [2]float64) vec { return u.scale(d[0]).add(v.scale(d[1])) }
+	box.Axes = [3][3]float64{inPlane(rect[0]), inPlane(rect[1]), n}
+	box.HalfSize = [3]float64{(rectHi[0] - rectLo[0]) / 2, (rectHi[1] - rectLo[1]) / 2, (hi - lo) / 2}
+	mid := [3]float64{(rectHi[0] + rectLo[0]) / 2, (rectHi[1] + rectLo[1]) / 2, (hi + lo) / 2}
+	for a := 0; a < 3; a++ {
+		for k := 0; k < 3; k++ {
+			box.Center[k] += mid[a] * box.Axes[a][k]
+		}
+	}
+	return box
+}
+
+// sorted returns the box with its axes ordered from the longest side to
+// the shortest, the third axis flipped if needed to keep them right-handed.
+func (b Box) sorted() Box {
+	for i := 0; i < 2; i++ {
+		for j := 0; j < 2-i; j++ {
+			if b.HalfSize[j] < b.HalfSize[j+1] {
+				b.HalfSize[j], b.HalfSize[j+1] = b.HalfSize[j+1], b.HalfSize[j]
+				b.Axes[j], b.Axes[j+1] = b.Axes[j+1], b.Axes[j]
+			}
+		}
+	}
+	if vec(b.Axes[0]).cross(b.Axes[1]).dot(b.Axes[2]) < 0 {
+		b.Axes[2] = vec(b.Axes[2]).scale(-1)
+	}
+	return b
+}
+
+// hull2D returns the convex hull of points in the plane, counter-clockwise,
+// by Andrew's monotone chain.
+func hull2D(points [][2]float64) [][2]float64 {
+	sorted := append([][2]float64(nil), points...)
+	sort.Slice(sorted, func(i, j int) bool {
+		if sorted[i][0] != sorted[j][0] {
+			return sorted[i][0] < sorted[j][0]
+		}
+		return sorted[i][1] < sorted[j][1]
+	})
+	cross := func(o, a, b [2]float64) float64 {
+		return (a[0]-o[0])*(b[1]-o[1]) - (a[1]-o[1])*(b[0]-o[0])
+	}
+	var h [][2]float64
+	for pass := 0; pass < 2; pass++ {
+		start := len(h)
+		for _, p := range sorted {
+			for len(h) >= start+2 && cross(h[len(h)-2], h[len(h)-1], p) <= 0 {
+				h = h[:len(h)-1]
+			}
+			h = append(h, p)
+		}
+		h = h[:len(h)-1] // the last point starts the other chain
+		for i, j := 0, len(sorted)-1; i < j; i, j = i+1, j-1 {
+			sorted[i], sorted[j] = sorted[j], sorted[i]
+		}
+	}
+	return h
+}
@@ -0,0 +1,98 @@
+// i18n/english.go
+package i18n
+
+// English is the built-in catalog: every key the viewer uses, with its
+// English message. Other catalogs fall back to it.
+var English = New("en", map[string]string{
+	// Loading overlay and error panel.
+	"loading.starting":  "Starting viewer",
+	"loading.dataset":   "Generating the %s dataset",
+	"loading.file":      "Loading %s",
+	"error.start":       "The viewer failed to start",
+	"error.webgl":       "WebGL is not available",
+	"error.pointShader": "The point shader failed to compile",
+	"error.meshShader":  "The mesh shader failed to compile",
+	"error.lineShader":  "The line shader failed to compile",
+	"error.dataset":     "The dataset could not be generated",
+	"error.file":        "Could not load %s",
+
+	// Notices.
+	"notice.pointSize":       "Points are drawn at most %g pixels wide in this browser.",
+	"notice.effectOff":       "The %q effect is off: %v.",
+	"notice.postOff":         "Post-processing is off: %v.",
+	"notice.shadowOff":       "The contact shadow is unavailable: %v",
+	"notice.ssaoOff":         "Ambient occlusion is unavailable: %v",
+	"notice.edlOff":          "Eye-dome lighting is unavailable: %v",
+	"notice.messagesBad":     "Ignoring the message catalog: %v",
+	"notice.positionTexture": "The positions of %s are kept in a vertex buffer: %v.",
+	"notice.shaderVariant":   "Points are drawn without some effects: %v",
+	"notice.feedbackOff":     "Points are processed on the CPU: %v",
+	"notice.oitOff":          "Translucent points blend in drawing order: %v",
+
+	// Buttons.
+	"button.addKeyframe": "Add keyframe",
+	"button.cancel":      "Cancel",
+	"button.close":       "Close",
+	"button.draw":        "Draw",
+	"button.hide":        "Hide",
+	"button.invert":      "Invert",
+	"button.play":        "Play",
+	"button.reset":       "Reset",
+	"button.select":      "Select",
+	"button.show":        "Show",
+
+	// Control panel.
+	"panel.title":        "Controls",
+	"panel.pointSize":    "Point size",
+	"panel.displayed":    "Points shown (%)",
+	"panel.budget":       "Point budget",
+	"panel.exaggeration": "Exaggeration",
+	"panel.background":   "Background",
+	"panel.shading":      "Shading",
+	"panel.axes":         "Axes",
+	"panel.grid":         "Grid",
+	"panel.adaptive":     "Adaptive quality",
+	"panel.onDemand":     "Render on demand",
+	"panel.histogram":    "Histogram",
+	"panel.minimap":      "Minimap",
+	"panel.coordinates":  "Coordinates",
+	"panel.basemap":      "Basemap",
+	"panel.ssao":         "Ambient occlusion",
+	"panel.edl":          "Eye-dome lighting",
+	"panel.shadow":       "Contact shadow",
+	"panel.gizmo":        "Orientation gizmo",
+	"panel.trajectories": "Trajectories",
+	"panel.profile":      "Profile",
+	"panel.measure":      "Measure",
+	"panel.lasso":        "Lasso",
+	"panel.selection":    "Selection",
+	"panel.labeling":     "Labeling",
+	"panel.snap":         "Snap to points",
+	"panel.cameraPath":   "Camera path",
+	"panel.flythrough":   "Flythrough",
+	"panel.record":       "Record video",
+	"panel.filter":       "Filter",
+	"panel.classes":      "Classes",
+	"panel.noClasses":    "No classified points",
+	"panel.palette":      "Palette",
+	"panel.objects":      "Objects",
+	"panel.opacity":      "opacity",
+	"panel.statistics":   "statistics",
+
+	// Context menu.
+	"menu.setPivot":    "Set pivot here",
+	"menu.measure":     "Measure from here",
+	"menu.hideCluster": "Hide cluster %.0f",
+	"menu.copy":        "Copy coordinates",
+	"menu.copyIn":      "Copy coordinates (%s)",
+
+	// Coordinate HUD, histogram and profile.
+	"hud.noPoint":       "No point under the cursor",
+	"histogram.filter":  "Filter",
+	"histogram.noValue": "No scalar to show in %s mode",
+	"histogram.range":   "%s: %.4g to %.4g",
+	"profile.title":     "Profile: %.4g long, %d points, %.2fx vertical",
+
+	// Swipe comparison.
+	"swipe.divider": "Drag to compare",
+}, nil)
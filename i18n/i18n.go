@@ -0,0 +1,79 @@
+// i18n/i18n.go
+// Package i18n holds the text of the viewer's user interface as catalogs
+// of messages by key, so deployments can localize it. A catalog loaded
+// from JSON falls back to the built-in English one for keys it lacks.
+package i18n
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+)
+
+// Catalog is a set of messages by key. Messages taking arguments are
+// fmt formats; translations can reorder them with explicit indexes such
+// as %[2]s.
+type Catalog struct {
+	Lang     string
+	messages map[string]string
+	fallback *Catalog
+}
+
+// New returns a catalog of messages in lang that looks keys it lacks up
+// in fallback, which may be nil.
+func New(lang string, messages map[string]string, fallback *Catalog) *Catalog {
+	return &Catalog{Lang: lang, messages: messages, fallback: fallback}
+}
+
+// Parse reads a catalog from a JSON object mapping keys to messages.
+func Parse(lang string, data []byte, fallback *Catalog) (*Catalog, error) {
+	var messages map[string]string
+	if err := json.Unmarshal(data, &messages); err != nil {
+		return nil, fmt.Errorf("i18n: %v", err)
+	}
+	if messages == nil {
+		return nil, fmt.Errorf("i18n: expected an object of messages")
+	}
+	return New(lang, messages, fallback), nil
+}
+
+// Text returns the message for key, from the fallback if the catalog
+// lacks it, or key itself if neither has it.
+func (c *Catalog) Text(key string) string {
+	for ; c != nil; c = c.fallback {
+		if m, ok := c.messages[key]; ok {
+			return m
+		}
+	}
+	return key
+}
+
+// Format returns the message for key formatted with args.
+func (c *Catalog) Format(key string, args ...interface{}) string {
+	return fmt.Sprintf(c.Text(key), args...)
+}
+
+// Keys returns the catalog's own keys, sorted.
+func (c *Catalog) Keys() []string {
+	keys := make([]string, 0, len(c.messages))
+	for k := range c.messages {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// Unknown returns the catalog's keys its fallback does not have, sorted:
+// usually misspelt keys, whose messages are never shown.
+func (c *Catalog) Unknown() []string {
+	if c.fallback == nil {
+		return nil
+	}
+	var unknown []string
+	for _, k := range c.Keys() {
+		if c.fallback.Text(k) == k {
+			unknown = append(unknown, k)
+		}
+	}
+	return unknown
+}
@@ -0,0 +1,65 @@
+// i18n/i18n_test.go
+// usage: go test
+
+package i18n
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseFallsBackToEnglish(t *testing.T) {
+	c, err := Parse("de", []byte(`{"panel.title": "Steuerung", "panel.grid": "Raster"}`), English)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := c.Text("panel.title"); got != "Steuerung" {
+		t.Errorf("Text(panel.title) = %q, want Steuerung", got)
+	}
+	if got := c.Text("panel.axes"); got != "Axes" {
+		t.Errorf("Text(panel.axes) = %q, want the English Axes", got)
+	}
+	if got := c.Text("no.such.key"); got != "no.such.key" {
+		t.Errorf("Text of a missing key = %q, want the key", got)
+	}
+}
+
+func TestFormatReordersArguments(t *testing.T) {
+	c := New("xx", map[string]string{"histogram.range": "%.4[2]g..%.4[3]g (%[1]s)"}, English)
+	if got := c.Format("histogram.range", "height", 1.5, 2.0); got != "1.5..2 (height)" {
+		t.Errorf("Format = %q", got)
+	}
+	if got := English.Format("histogram.range", "height", 1.5, 2.0); got != "height: 1.5 to 2" {
+		t.Errorf("English Format = %q", got)
+	}
+}
+
+func TestParseRejectsMalformedCatalogs(t *testing.T) {
+	for _, data := range []string{`[]`, `null`, `{"panel.title": 1}`, `{`} {
+		if _, err := Parse("xx", []byte(data), English); err == nil {
+			t.Errorf("Parse(%s) succeeded", data)
+		}
+	}
+}
+
+func TestUnknownKeys(t *testing.T) {
+	c, err := Parse("xx", []byte(`{"panel.title": "a", "panel.titel": "b", "zzz": "c"}`), English)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := c.Unknown(), []string{"panel.titel", "zzz"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Unknown() = %v, want %v", got, want)
+	}
+	if got := English.Unknown(); got != nil {
+		t.Errorf("English.Unknown() = %v, want none", got)
+	}
+}
+
+func TestEnglishMessagesAreNamespaced(t *testing.T) {
+	for _, k := range English.Keys() {
+		if !strings.Contains(k, ".") || English.Text(k) == "" {
+			t.Errorf("English has key %q with message %q", k, English.Text(k))
+		}
+	}
+}
@@ -0,0 +1,185 @@
+// importer/csv.go
+package importer
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/sbecker11/webgl-point-cloud/pointcloud"
+)
+
+// csvColumns maps the header names ReadCSV understands, lowercased, to the
+// fields they fill: x, y, z, r, g, b, a, nx, ny, nz, intensity and class.
+var csvColumns = map[string]string{
+	"x": "x", "y": "y", "z": "z",
+	"r": "r", "red": "r", "g": "g", "green": "g", "b": "b", "blue": "b",
+	"a": "a", "alpha": "a",
+	"nx": "nx", "ny": "ny", "nz": "nz",
+	"i": "intensity", "intensity": "intensity",
+	"class": "class", "classification": "class", "label": "class",
+}
+
+// ReadCSV parses points stored as delimited text, one per line, into a
+// cloud named name: CSV, or the XYZ and TXT files scanners export, split
+// by commas, semicolons, tabs or spaces, whichever the first line uses.
+// A first line that is not all numbers is a header naming the columns
+// (x, y, z, r or red, g, b, a or alpha, nx, ny, nz, intensity, and class,
+// classification or label; others are skipped); without one the columns
+// are x, y and z, then red, green and blue if there are six or more.
+// Colors and intensities with values above 1 are taken to be 0 to 255,
+// or 0 to 65535 if any is above 255, and scaled to [0, 1]; points without
+// colors are white. Blank lines and lines starting with # are skipped.
+// Coordinates are stored relative to the floor of their minimum, kept in
+// the cloud's Offset, as ReadLAS does.
+func ReadCSV(name string, r io.Reader) (*pointcloud.Cloud, error) {
+	lines := bufio.NewScanner(r)
+	lines.Buffer(nil, 1<<20)
+	var split func(string) []string
+	columns := map[string]int{}
+	var coords []float64
+	var rgb, alpha, normals, intensities []float32
+	var classes []uint8
+	values := map[string]float64{}
+	line := 0
+	for lines.Scan() {
+		line++
+		text := strings.TrimSpace(strings.TrimPrefix(lines.Text(), "\ufeff"))
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		if split == nil {
+			split = csvSplitter(text)
+			fields := split(text)
+			if !csvNumbers(fields) {
+				for i, f := range fields {
+					if c, ok := csvColumns[strings.ToLower(strings.TrimSpace(f))]; ok {
+						columns[c] = i
+					}
+				}
+				if !csvHas(columns, "x", "y", "z") {
+					return nil, fmt.Errorf("csv: header %q has no x, y and z columns", text)
+				}
+				continue
+			}
+			for i, c := range []string{"x", "y", "z", "r", "g", "b"} {
+				if i < 3 || len(fields) >= 6 {
+					columns[c] = i
+				}
+			}
+		}
+		fields := split(text)
+		for c, i := range columns {
+			if i >= len(fields) {
+				return nil, fmt.Errorf("csv: line %d has %d fields, no %s", line, len(fields), c)
+			}
+			v, err := strconv.ParseFloat(strings.TrimSpace(fields[i]), 64)
+			if err != nil {
+				return nil, fmt.Errorf("csv: line %d: bad %s %q", line, c, fields[i])
+			}
+			values[c] = v
+		}
+		coords = append(coords, values["x"], values["y"], values["z"])
+		if csvHas(columns, "r", "g", "b") {
+			rgb = append(rgb, float32(values["r"]), float32(values["g"]), float32(values["b"]))
+		}
+		if csvHas(columns, "a") {
+			alpha = append(alpha, float32(values["a"]))
+		}
+		if csvHas(columns, "nx", "ny", "nz") {
+			normals = append(normals, float32(values["nx"]), float32(values["ny"]), float32(values["nz"]))
+		}
+		if csvHas(columns, "intensity") {
+			intensities = append(intensities, float32(values["intensity"]))
+		}
+		if csvHas(columns, "class") {
+			classes = append(classes, uint8(values["class"]))
+		}
+	}
+	if err := lines.Err(); err != nil {
+		return nil, fmt.Errorf("csv: %v", err)
+	}
+	if len(coords) == 0 {
+		return nil, fmt.Errorf("csv: no points")
+	}
+
+	n := len(coords) / 3
+	toUnit(rgb)
+	toUnit(alpha)
+	colors := make([]float32, 0, n*4)
+	for i := 0; i < n; i++ {
+		c := [4]float32{1, 1, 1, 1}
+		if rgb != nil {
+			copy(c[:], rgb[i*3:i*3+3])
+		}
+		if alpha != nil {
+			c[3] = alpha[i]
+		}
+		colors = append(colors, c[:]...)
+	}
+	local, origin := localCoords(coords)
+	cloud := pointcloud.New(name, local, colors)
+	cloud.Offset = origin
+	cloud.Normals = normals
+	cloud.Classes = classes
+	if intensities != nil {
+		toUnit(intensities)
+		cloud.SetAttribute(lasIntensity, intensities)
+	}
+	return cloud, nil
+}
+
+// csvSplitter returns the function splitting the lines of a file whose
+// first line is first into fields.
+func csvSplitter(first string) func(string) []string {
+	for _, sep := range []string{",", ";", "\t"} {
+		if strings.Contains(first, sep) {
+			return func(s string) []string { return strings.Split(s, sep) }
+		}
+	}
+	return strings.Fields
+}
+
+// csvNumbers reports whether all of fields are numbers.
+func csvNumbers(fields []string) bool {
+	for _, f := range fields {
+		if _, err := strconv.ParseFloat(strings.TrimSpace(f), 64); err != nil {
+			return false
+		}
+	}
+	return true
+}
+
+// csvHas reports whether columns holds all of names.
+func csvHas(columns map[string]int, names ...string) bool {
+	for _, n := range names {
+		if _, ok := columns[n]; !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// toUnit scales values given from 0 to 255, or to 65535 if any is above
+// 255, to [0, 1], leaving them be if none is above 1.
+func toUnit(values []float32) {
+	var top float32
+	for _, v := range values {
+		top = max(top, v)
+	}
+	scale := float32(1)
+	switch {
+	case top > 255:
+		scale = 65535
+	case top > 1:
+		scale = 255
+	}
+	if scale == 1 {
+		return
+	}
+	for i := range values {
+		values[i] /= scale
+	}
+}
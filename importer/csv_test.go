@@ -0,0 +1,95 @@
+// importer/csv_test.go
+// usage: go test
+
+package importer
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestReadCSVWithHeader(t *testing.T) {
+	data := "\ufeffX,Y,Z,Red,Green,Blue,Intensity,Classification,Time\n" +
+		"500100.5,4000200.25,12,255,0,0,255,2,0.1\n" +
+		"# a comment\n\n" +
+		"500101.5,4000201.25,14.5,0,128,255,0,6,0.2\n"
+	cloud, err := ReadCSV("scan", strings.NewReader(data))
+	if err != nil {
+		t.Fatalf("ReadCSV failed: %v", err)
+	}
+	if cloud.Len() != 2 {
+		t.Fatalf("expected 2 points, got %d", cloud.Len())
+	}
+	if cloud.Offset != [3]float64{500100, 4000200, 12} {
+		t.Errorf("expected offset at the floor of the minimum, got %v", cloud.Offset)
+	}
+	if p := cloud.Point(1); !almostEqual(p[0], 1.5) || !almostEqual(p[1], 1.25) || !almostEqual(p[2], 2.5) {
+		t.Errorf("expected point 1 at (1.5, 1.25, 2.5), got %v", p)
+	}
+	if c := cloud.Colors; c[0] != 1 || c[1] != 0 || c[3] != 1 || !almostEqual(c[5], 128.0/255) || c[7] != 1 {
+		t.Errorf("expected colors scaled from 0 to 255, got %v", c)
+	}
+	if len(cloud.Classes) != 2 || cloud.Classes[0] != 2 || cloud.Classes[1] != 6 {
+		t.Errorf("expected classes [2 6], got %v", cloud.Classes)
+	}
+	if _, v, ok := cloud.Attribute("intensity"); !ok || v[0] != 1 || v[1] != 0 {
+		t.Errorf("expected intensities [1 0], got %v", v)
+	}
+	if cloud.Normals != nil {
+		t.Errorf("expected no normals, got %v", cloud.Normals)
+	}
+}
+
+func TestReadCSVWithoutHeader(t *testing.T) {
+	for name, data := range map[string]string{
+		"spaces":     "1 2 3 0.5 0.25 1\n4  5\t6 1 1 1\n",
+		"tabs":       "1\t2\t3\t0.5\t0.25\t1\n4\t5\t6\t1\t1\t1\n",
+		"semicolons": "1;2;3;0.5;0.25;1\n4;5;6;1;1;1\n",
+	} {
+		cloud, err := ReadCSV("xyz", strings.NewReader(data))
+		if err != nil {
+			t.Fatalf("%s: ReadCSV failed: %v", name, err)
+		}
+		if cloud.Len() != 2 || cloud.Coords[3] != 3 || cloud.Coords[4] != 3 || cloud.Coords[5] != 3 {
+			t.Errorf("%s: expected 2 points, the second at (3, 3, 3), got %v", name, cloud.Coords)
+		}
+		if c := cloud.Colors; c[0] != 0.5 || c[1] != 0.25 || c[2] != 1 || c[3] != 1 {
+			t.Errorf("%s: expected colors in [0, 1] kept, got %v", name, c)
+		}
+	}
+
+	cloud, err := ReadCSV("xyz", strings.NewReader("1,2,3\n4,5,6,7\n"))
+	if err != nil {
+		t.Fatalf("xyz only: ReadCSV failed: %v", err)
+	}
+	if c := cloud.Colors; cloud.Len() != 2 || c[0] != 1 || c[4] != 1 {
+		t.Errorf("xyz only: expected 2 white points, got %v", c)
+	}
+}
+
+func TestReadCSVNormalsAndAlpha(t *testing.T) {
+	cloud, err := ReadCSV("n", strings.NewReader("x y z nx ny nz r g b a\n0 0 0 0 0 1 255 255 255 128\n"))
+	if err != nil {
+		t.Fatalf("ReadCSV failed: %v", err)
+	}
+	if len(cloud.Normals) != 3 || cloud.Normals[2] != 1 {
+		t.Errorf("expected normal (0, 0, 1), got %v", cloud.Normals)
+	}
+	if c := cloud.Colors; c[0] != 1 || !almostEqual(c[3], 128.0/255) {
+		t.Errorf("expected white at half opacity, got %v", c)
+	}
+}
+
+func TestReadCSVErrors(t *testing.T) {
+	for name, data := range map[string]string{
+		"empty":       "# nothing\n\n",
+		"header only": "x,y,z\n",
+		"no z column": "x,y,value\n1,2,3\n",
+		"bad number":  "1,2,3\n4,five,6\n",
+		"short line":  "x,y,z,intensity\n1,2,3,4\n5,6,7\n",
+	} {
+		if _, err := ReadCSV("p", strings.NewReader(data)); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
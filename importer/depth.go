@@ -0,0 +1,123 @@
+// importer/depth.go
+package importer
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+
+	"github.com/sbecker11/webgl-point-cloud/pointcloud"
+)
+
+// Intrinsics are pinhole camera parameters in pixels: focal lengths fx, fy
+// and principal point cx, cy, as reported by RGB-D cameras.
+type Intrinsics struct {
+	Fx, Fy, Cx, Cy float32
+}
+
+// DepthImage is a row-major grid of depths in meters. A depth of 0 marks a
+// pixel with no measurement.
+type DepthImage struct {
+	Width, Height int
+	Values        []float32
+}
+
+// At returns the depth at pixel (x, y).
+func (d *DepthImage) At(x, y int) float32 {
+	return d.Values[y*d.Width+x]
+}
+
+// DecodeDepth converts an image into a DepthImage, multiplying each raw
+// sample by scale. 16-bit grayscale images (the usual RealSense/Kinect PNG
+// export, in millimeters with scale 0.001) use the full 16-bit value; other
+// images use their 8-bit gray level.
+func DecodeDepth(img image.Image, scale float32) *DepthImage {
+	b := img.Bounds()
+	d := &DepthImage{Width: b.Dx(), Height: b.Dy(), Values: make([]float32, b.Dx()*b.Dy())}
+	for y := 0; y < d.Height; y++ {
+		for x := 0; x < d.Width; x++ {
+			var raw float32
+			switch im := img.(type) {
+			case *image.Gray16:
+				raw = float32(im.Gray16At(b.Min.X+x, b.Min.Y+y).Y)
+			default:
+				raw = float32(color.GrayModel.Convert(img.At(b.Min.X+x, b.Min.Y+y)).(color.Gray).Y)
+			}
+			d.Values[y*d.Width+x] = raw * scale
+		}
+	}
+	return d
+}
+
+// DepthOptions control which pixels of a depth image become points.
+type DepthOptions struct {
+	// MinDepth and MaxDepth discard points outside [MinDepth, MaxDepth]
+	// meters. A MaxDepth of 0 means no upper limit.
+	MinDepth, MaxDepth float32
+	// Stride keeps every Stride'th pixel in each direction; 0 or 1 keeps all.
+	Stride int
+}
+
+// DepthToCloud back-projects every valid pixel of depth through the pinhole
+// model into a point cloud. The camera looks down -Z with +Y up, matching
+// the viewer's conventions, so a depth d becomes z = -d.
+//
+// If colorImg is non-nil it must have the same dimensions as the depth image
+// and supplies per-point colors (the registered color frame); otherwise
+// points are shaded by depth, near points brighter.
+func DepthToCloud(name string, depth *DepthImage, colorImg image.Image, in Intrinsics, opts DepthOptions) (*pointcloud.Cloud, error) {
+	if in.Fx == 0 || in.Fy == 0 {
+		return nil, fmt.Errorf("depth intrinsics: fx and fy must be non-zero")
+	}
+	if len(depth.Values) != depth.Width*depth.Height {
+		return nil, fmt.Errorf("depth image: expected %d values, got %d", depth.Width*depth.Height, len(depth.Values))
+	}
+	var cb image.Rectangle
+	if colorImg != nil {
+		cb = colorImg.Bounds()
+		if cb.Dx() != depth.Width || cb.Dy() != depth.Height {
+			return nil, fmt.Errorf("color image is %dx%d but depth image is %dx%d", cb.Dx(), cb.Dy(), depth.Width, depth.Height)
+		}
+	}
+	stride := opts.Stride
+	if stride < 1 {
+		stride = 1
+	}
+
+	var coords, colors []float32
+	var nearest, farthest float32
+	for y := 0; y < depth.Height; y += stride {
+		for x := 0; x < depth.Width; x += stride {
+			z := depth.At(x, y)
+			if z <= 0 || z < opts.MinDepth || (opts.MaxDepth > 0 && z > opts.MaxDepth) {
+				continue
+			}
+			px := (float32(x) - in.Cx) * z / in.Fx
+			py := (float32(y) - in.Cy) * z / in.Fy
+			// Image rows grow downward; flip to the viewer's +Y up.
+			coords = append(coords, px, -py, -z)
+			if colorImg != nil {
+				r, g, b, a := colorImg.At(cb.Min.X+x, cb.Min.Y+y).RGBA()
+				colors = append(colors, float32(r)/0xffff, float32(g)/0xffff, float32(b)/0xffff, float32(a)/0xffff)
+			} else {
+				if len(colors) == 0 || z < nearest {
+					nearest = z
+				}
+				if len(colors) == 0 || z > farthest {
+					farthest = z
+				}
+				colors = append(colors, z, 0, 0, 1) // depth stashed for shading below
+			}
+		}
+	}
+	if colorImg == nil {
+		for i := 0; i < len(colors); i += 4 {
+			shade := float32(1)
+			if farthest > nearest {
+				shade = 1 - 0.8*(colors[i]-nearest)/(farthest-nearest)
+			}
+			colors[i], colors[i+1], colors[i+2] = shade, shade, shade
+		}
+	}
+	return pointcloud.New(name, coords, colors), nil
+}
@@ -0,0 +1,80 @@
+// importer/depth_test.go
+// usage: go test
+
+package importer
+
+import (
+	"image"
+	"image/color"
+	"math"
+	"testing"
+)
+
+func almostEqual(a, b float32) bool {
+	return math.Abs(float64(a-b)) <= 1e-5
+}
+
+func TestDecodeDepth16(t *testing.T) {
+	img := image.NewGray16(image.Rect(0, 0, 2, 1))
+	img.SetGray16(0, 0, color.Gray16{Y: 1500})
+	img.SetGray16(1, 0, color.Gray16{Y: 0})
+	d := DecodeDepth(img, 0.001)
+	if d.Width != 2 || d.Height != 1 {
+		t.Fatalf("DecodeDepth: expected 2x1, got %dx%d", d.Width, d.Height)
+	}
+	if !almostEqual(d.At(0, 0), 1.5) || d.At(1, 0) != 0 {
+		t.Errorf("DecodeDepth: expected [1.5 0], got %v", d.Values)
+	}
+}
+
+func TestDepthToCloud(t *testing.T) {
+	// A 4x3 image of a flat wall 2m away; the principal point is pixel (2, 1).
+	d := &DepthImage{Width: 4, Height: 3, Values: make([]float32, 12)}
+	for i := range d.Values {
+		d.Values[i] = 2
+	}
+	d.Values[0] = 0 // missing measurement
+	in := Intrinsics{Fx: 100, Fy: 100, Cx: 2, Cy: 1}
+
+	c, err := DepthToCloud("wall", d, nil, in, DepthOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.Len() != 11 {
+		t.Fatalf("DepthToCloud: expected 11 points, got %d", c.Len())
+	}
+	// Pixel (3, 2) is one pixel right of and one below the principal point.
+	p := c.Point(10)
+	if !almostEqual(p[0], 0.02) || !almostEqual(p[1], -0.02) || !almostEqual(p[2], -2) {
+		t.Errorf("DepthToCloud: expected (0.02, -0.02, -2), got %v", p)
+	}
+
+	c, _ = DepthToCloud("wall", d, nil, in, DepthOptions{Stride: 2})
+	if c.Len() != 3 {
+		t.Errorf("DepthToCloud with stride 2: expected 3 points, got %d", c.Len())
+	}
+	c, _ = DepthToCloud("wall", d, nil, in, DepthOptions{MaxDepth: 1})
+	if c.Len() != 0 {
+		t.Errorf("DepthToCloud with MaxDepth 1: expected no points, got %d", c.Len())
+	}
+}
+
+func TestDepthToCloudColor(t *testing.T) {
+	d := &DepthImage{Width: 1, Height: 1, Values: []float32{1}}
+	rgb := image.NewRGBA(image.Rect(0, 0, 1, 1))
+	rgb.Set(0, 0, color.RGBA{255, 0, 0, 255})
+	c, err := DepthToCloud("px", d, rgb, Intrinsics{Fx: 1, Fy: 1}, DepthOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.Colors[0] != 1 || c.Colors[1] != 0 || c.Colors[3] != 1 {
+		t.Errorf("DepthToCloud: expected red, got %v", c.Colors)
+	}
+
+	if _, err := DepthToCloud("px", d, image.NewRGBA(image.Rect(0, 0, 2, 2)), Intrinsics{Fx: 1, Fy: 1}, DepthOptions{}); err == nil {
+		t.Error("DepthToCloud: expected an error for mismatched color dimensions")
+	}
+	if _, err := DepthToCloud("px", d, nil, Intrinsics{}, DepthOptions{}); err == nil {
+		t.Error("DepthToCloud: expected an error for zero focal length")
+	}
+}
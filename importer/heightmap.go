@@ -0,0 +1,107 @@
+// importer/heightmap.go
+package importer
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+
+	"github.com/sbecker11/webgl-point-cloud/colormap"
+	"github.com/sbecker11/webgl-point-cloud/pointcloud"
+)
+
+// HeightmapOptions control how a heightmap image becomes a terrain cloud.
+type HeightmapOptions struct {
+	// CellSize is the spacing between neighboring pixels on the XZ grid.
+	// Defaults to 1.
+	CellSize float32
+	// HeightScale is the Y value of a white pixel; black is at Y = 0.
+	// Defaults to 1.
+	HeightScale float32
+	// Stride keeps every Stride'th pixel in each direction; 0 or 1 keeps all.
+	Stride int
+	// Texture, if non-nil, colors each point from the texel at the same
+	// relative position (the texture may have a different resolution).
+	Texture image.Image
+	// Ramp colors points by relative height when there is no Texture.
+	// Defaults to colormap.Terrain.
+	Ramp *colormap.Map
+}
+
+// heightAt returns the gray level of pixel (x, y) normalized to [0, 1],
+// using the full range of 16-bit images.
+func heightAt(img image.Image, x, y int) float32 {
+	if g16, ok := img.(*image.Gray16); ok {
+		return float32(g16.Gray16At(x, y).Y) / 0xffff
+	}
+	return float32(color.Gray16Model.Convert(img.At(x, y)).(color.Gray16).Y) / 0xffff
+}
+
+// HeightmapToCloud converts a grayscale heightmap into a gridded point cloud:
+// one point per pixel on an XZ grid centered at the origin, with image rows
+// running toward +Z, and Y taken from the pixel's gray level.
+func HeightmapToCloud(name string, img image.Image, opts HeightmapOptions) (*pointcloud.Cloud, error) {
+	b := img.Bounds()
+	if b.Empty() {
+		return nil, fmt.Errorf("heightmap image is empty")
+	}
+	cell := opts.CellSize
+	if cell == 0 {
+		cell = 1
+	}
+	heightScale := opts.HeightScale
+	if heightScale == 0 {
+		heightScale = 1
+	}
+	stride := opts.Stride
+	if stride < 1 {
+		stride = 1
+	}
+	ramp := opts.Ramp
+	if ramp == nil {
+		ramp = &colormap.Terrain
+	}
+
+	w, h := b.Dx(), b.Dy()
+	var coords, heights []float32
+	lo, hi := float32(1), float32(0)
+	for y := 0; y < h; y += stride {
+		for x := 0; x < w; x += stride {
+			v := heightAt(img, b.Min.X+x, b.Min.Y+y)
+			if v < lo {
+				lo = v
+			}
+			if v > hi {
+				hi = v
+			}
+			coords = append(coords,
+				(float32(x)-float32(w-1)/2)*cell,
+				v*heightScale,
+				(float32(y)-float32(h-1)/2)*cell,
+			)
+			heights = append(heights, v)
+		}
+	}
+
+	colors := make([]float32, 0, len(heights)*4)
+	i := 0
+	for y := 0; y < h; y += stride {
+		for x := 0; x < w; x += stride {
+			if opts.Texture != nil {
+				tb := opts.Texture.Bounds()
+				tx := tb.Min.X + x*tb.Dx()/w
+				ty := tb.Min.Y + y*tb.Dy()/h
+				r, g, bl, a := opts.Texture.At(tx, ty).RGBA()
+				colors = append(colors, float32(r)/0xffff, float32(g)/0xffff, float32(bl)/0xffff, float32(a)/0xffff)
+			} else {
+				t := float32(0)
+				if hi > lo {
+					t = (heights[i] - lo) / (hi - lo)
+				}
+				colors = ramp.AppendRGBA(colors, t)
+			}
+			i++
+		}
+	}
+	return pointcloud.New(name, coords, colors), nil
+}
@@ -0,0 +1,57 @@
+// importer/heightmap_test.go
+// usage: go test
+
+package importer
+
+import (
+	"image"
+	"image/color"
+	"testing"
+
+	"github.com/sbecker11/webgl-point-cloud/colormap"
+)
+
+func TestHeightmapToCloud(t *testing.T) {
+	img := image.NewGray(image.Rect(0, 0, 3, 2))
+	img.SetGray(2, 1, color.Gray{Y: 255})
+
+	c, err := HeightmapToCloud("terrain", img, HeightmapOptions{CellSize: 2, HeightScale: 10, Ramp: &colormap.Grayscale})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.Len() != 6 {
+		t.Fatalf("HeightmapToCloud: expected 6 points, got %d", c.Len())
+	}
+	// The first pixel is the top-left corner of a grid centered on the origin.
+	if p := c.Point(0); !almostEqual(p[0], -2) || p[1] != 0 || !almostEqual(p[2], -1) {
+		t.Errorf("HeightmapToCloud: expected (-2, 0, -1), got %v", p)
+	}
+	if p := c.Point(5); !almostEqual(p[0], 2) || !almostEqual(p[1], 10) || !almostEqual(p[2], 1) {
+		t.Errorf("HeightmapToCloud: expected (2, 10, 1), got %v", p)
+	}
+	// With a grayscale ramp the highest point is white and the rest black.
+	if c.Colors[5*4] != 1 || c.Colors[0] != 0 {
+		t.Errorf("HeightmapToCloud: expected ramp colors black..white, got %v", c.Colors)
+	}
+
+	c, _ = HeightmapToCloud("terrain", img, HeightmapOptions{Stride: 2})
+	if c.Len() != 2 {
+		t.Errorf("HeightmapToCloud with stride 2: expected 2 points, got %d", c.Len())
+	}
+}
+
+func TestHeightmapTexture(t *testing.T) {
+	img := image.NewGray(image.Rect(0, 0, 4, 4))
+	tex := image.NewRGBA(image.Rect(0, 0, 2, 2))
+	tex.Set(1, 1, color.RGBA{0, 0, 255, 255})
+
+	c, err := HeightmapToCloud("terrain", img, HeightmapOptions{Texture: tex})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Pixel (3, 3) of the heightmap maps to texel (1, 1).
+	last := c.Colors[len(c.Colors)-4:]
+	if last[2] != 1 || last[0] != 0 {
+		t.Errorf("HeightmapToCloud: expected the blue texel, got %v", last)
+	}
+}
@@ -0,0 +1,165 @@
+// importer/las.go
+package importer
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+
+	"github.com/sbecker11/webgl-point-cloud/crs"
+	"github.com/sbecker11/webgl-point-cloud/pointcloud"
+)
+
+// lasRGBOffsets holds the byte offset of red, green and blue within a
+// point record of each LAS point data format that has them.
+var lasRGBOffsets = map[uint8]int{2: 20, 3: 28, 5: 28, 7: 30, 8: 30, 10: 30}
+
+// lasIntensity is the attribute holding LAS return intensities, scaled to
+// [0, 1].
+var lasIntensity = pointcloud.Attribute{Name: "intensity", Components: 1, Type: pointcloud.Float32}
+
+// ReadLAS parses an uncompressed ASPRS LAS file, versions 1.0 to 1.4 and
+// point data formats 0 to 10, into a cloud named name with each point's
+// position, class and intensity, and color for formats that have one.
+// Points without colors are white. Coordinates are stored relative to the
+// floor of the header's minimum, kept in the cloud's Offset, so large
+// projected coordinates keep float32 precision; the CRS is read from the
+// GeoKey or WKT projection record when there is one. LAS axes are kept:
+// z is up. Compressed (LAZ) files are rejected.
+func ReadLAS(name string, r io.Reader) (*pointcloud.Cloud, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	if len(data) < 227 || string(data[:4]) != "LASF" {
+		return nil, fmt.Errorf("las: not a LAS file")
+	}
+	le := binary.LittleEndian
+	minor := data[25]
+	headerSize := int(le.Uint16(data[94:]))
+	pointStart := int(le.Uint32(data[96:]))
+	numVLRs := int(le.Uint32(data[100:]))
+	format := data[104]
+	recordLen := int(le.Uint16(data[105:]))
+	count := uint64(le.Uint32(data[107:]))
+	if format&0xc0 != 0 {
+		return nil, fmt.Errorf("las: compressed (LAZ) files are not supported")
+	}
+	if format > 10 {
+		return nil, fmt.Errorf("las: unknown point data format %d", format)
+	}
+	if minor >= 4 && len(data) >= 255 {
+		if n := le.Uint64(data[247:]); n > 0 {
+			count = n
+		}
+	}
+	f64 := func(at int) float64 { return math.Float64frombits(le.Uint64(data[at:])) }
+	scale := [3]float64{f64(131), f64(139), f64(147)}
+	offset := [3]float64{f64(155), f64(163), f64(171)}
+	origin := [3]float64{math.Floor(f64(187)), math.Floor(f64(203)), math.Floor(f64(219))}
+	minLen := 20
+	if format >= 6 {
+		minLen = 30
+	}
+	rgbAt, hasRGB := lasRGBOffsets[format]
+	if hasRGB {
+		minLen = rgbAt + 6
+	}
+	if recordLen < minLen {
+		return nil, fmt.Errorf("las: point records of %d bytes are too short for format %d", recordLen, format)
+	}
+	if pointStart < headerSize || pointStart > len(data) || uint64(len(data)-pointStart)/uint64(recordLen) < count {
+		return nil, fmt.Errorf("las: file is truncated: %d points don't fit", count)
+	}
+
+	n := int(count)
+	coords := make([]float32, 0, n*3)
+	colors := make([]float32, 0, n*4)
+	classes := make([]uint8, n)
+	intensity := make([]float32, n)
+	var rgb []uint16
+	if hasRGB {
+		rgb = make([]uint16, 0, n*3)
+	}
+	for i := 0; i < n; i++ {
+		p := data[pointStart+i*recordLen:]
+		for k := 0; k < 3; k++ {
+			v := float64(int32(le.Uint32(p[k*4:])))*scale[k] + offset[k]
+			coords = append(coords, float32(v-origin[k]))
+		}
+		intensity[i] = float32(le.Uint16(p[12:])) / 0xffff
+		if format >= 6 {
+			classes[i] = p[16]
+		} else {
+			classes[i] = p[15] & 0x1f
+		}
+		if hasRGB {
+			rgb = append(rgb, le.Uint16(p[rgbAt:]), le.Uint16(p[rgbAt+2:]), le.Uint16(p[rgbAt+4:]))
+		}
+	}
+	// Some writers store 8-bit colors unscaled.
+	maxRGB := float32(0xffff)
+	if hasRGB {
+		var top uint16
+		for _, v := range rgb {
+			top = max(top, v)
+		}
+		if top <= 0xff {
+			maxRGB = 0xff
+		}
+	}
+	for i := 0; i < n; i++ {
+		if hasRGB {
+			colors = append(colors, float32(rgb[i*3])/maxRGB, float32(rgb[i*3+1])/maxRGB, float32(rgb[i*3+2])/maxRGB, 1)
+		} else {
+			colors = append(colors, 1, 1, 1, 1)
+		}
+	}
+
+	cloud := pointcloud.New(name, coords, colors)
+	cloud.Classes = classes
+	cloud.Offset = origin
+	cloud.SetAttribute(lasIntensity, intensity)
+	if c, ok := lasCRS(data[headerSize:pointStart], numVLRs); ok {
+		cloud.CRS = c.String()
+	}
+	return cloud, nil
+}
+
+// lasCRS reads the CRS from the projection records among the numVLRs
+// variable length records in vlrs, preferring WKT to GeoKeys.
+func lasCRS(vlrs []byte, numVLRs int) (crs.CRS, bool) {
+	le := binary.LittleEndian
+	var found crs.CRS
+	ok := false
+	for i := 0; i < numVLRs && len(vlrs) >= 54; i++ {
+		user := string(bytes.TrimRight(vlrs[2:18], "\x00"))
+		id := le.Uint16(vlrs[18:])
+		length := int(le.Uint16(vlrs[20:]))
+		if len(vlrs) < 54+length {
+			break
+		}
+		payload := vlrs[54 : 54+length]
+		vlrs = vlrs[54+length:]
+		if user != "LASF_Projection" {
+			continue
+		}
+		switch id {
+		case 2112:
+			if c, err := crs.Parse(string(bytes.TrimRight(payload, "\x00"))); err == nil && !c.IsZero() {
+				return c, true
+			}
+		case 34735:
+			keys := make([]uint16, len(payload)/2)
+			for k := range keys {
+				keys[k] = le.Uint16(payload[k*2:])
+			}
+			if c, err := crs.ParseGeoKeys(keys); err == nil {
+				found, ok = c, true
+			}
+		}
+	}
+	return found, ok
+}
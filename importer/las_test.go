@@ -0,0 +1,140 @@
+// importer/las_test.go
+// usage: go test
+
+package importer
+
+import (
+	"bytes"
+	"encoding/binary"
+	"math"
+	"testing"
+)
+
+// lasPoint is a point written by buildLAS, in file coordinates.
+type lasPoint struct {
+	x, y, z   float64
+	intensity uint16
+	class     uint8
+	rgb       [3]uint16
+}
+
+// buildLAS returns a LAS 1.2 file of points in format, with scale 0.01,
+// offset (500000, 4000000, 0) and a GeoKey projection record naming
+// EPSG:32633.
+func buildLAS(t *testing.T, format uint8, recordLen int, points []lasPoint) []byte {
+	t.Helper()
+	le := binary.LittleEndian
+	geoKeys := []uint16{1, 1, 0, 1, 3072, 0, 1, 32633}
+	vlr := make([]byte, 54+len(geoKeys)*2)
+	copy(vlr[2:], "LASF_Projection")
+	le.PutUint16(vlr[18:], 34735)
+	le.PutUint16(vlr[20:], uint16(len(geoKeys)*2))
+	for i, k := range geoKeys {
+		le.PutUint16(vlr[54+i*2:], k)
+	}
+	header := make([]byte, 227)
+	copy(header, "LASF")
+	header[24], header[25] = 1, 2
+	le.PutUint16(header[94:], 227)
+	le.PutUint32(header[96:], uint32(227+len(vlr)))
+	le.PutUint32(header[100:], 1)
+	header[104] = format
+	le.PutUint16(header[105:], uint16(recordLen))
+	le.PutUint32(header[107:], uint32(len(points)))
+	scale, offset := 0.01, [3]float64{500000, 4000000, 0}
+	for k := 0; k < 3; k++ {
+		le.PutUint64(header[131+k*8:], math.Float64bits(scale))
+		le.PutUint64(header[155+k*8:], math.Float64bits(offset[k]))
+	}
+	lo := [3]float64{math.Inf(1), math.Inf(1), math.Inf(1)}
+	for _, p := range points {
+		lo = [3]float64{min(lo[0], p.x), min(lo[1], p.y), min(lo[2], p.z)}
+	}
+	for k := 0; k < 3; k++ {
+		le.PutUint64(header[187+k*16:], math.Float64bits(lo[k]))
+	}
+	var b bytes.Buffer
+	b.Write(header)
+	b.Write(vlr)
+	for _, p := range points {
+		rec := make([]byte, recordLen)
+		for k, v := range []float64{p.x, p.y, p.z} {
+			le.PutUint32(rec[k*4:], uint32(int32(math.Round((v-offset[k])/scale))))
+		}
+		le.PutUint16(rec[12:], p.intensity)
+		if format >= 6 {
+			rec[16] = p.class
+		} else {
+			rec[15] = p.class
+		}
+		if at, ok := lasRGBOffsets[format]; ok {
+			for k := 0; k < 3; k++ {
+				le.PutUint16(rec[at+k*2:], p.rgb[k])
+			}
+		}
+		b.Write(rec)
+	}
+	return b.Bytes()
+}
+
+func TestReadLAS(t *testing.T) {
+	points := []lasPoint{
+		{x: 500100.5, y: 4000200.25, z: 12, intensity: 0xffff, class: 2, rgb: [3]uint16{0xffff, 0, 0}},
+		{x: 500101.5, y: 4000201.25, z: 14.5, class: 6, rgb: [3]uint16{0, 0x8000, 0xffff}},
+	}
+	for _, tc := range []struct {
+		format    uint8
+		recordLen int
+	}{{2, 26}, {3, 34}, {7, 36}} {
+		cloud, err := ReadLAS("scan", bytes.NewReader(buildLAS(t, tc.format, tc.recordLen, points)))
+		if err != nil {
+			t.Fatalf("format %d: ReadLAS failed: %v", tc.format, err)
+		}
+		if cloud.Len() != 2 {
+			t.Fatalf("format %d: expected 2 points, got %d", tc.format, cloud.Len())
+		}
+		if cloud.Offset != [3]float64{500100, 4000200, 12} {
+			t.Errorf("format %d: expected offset at the floor of the minimum, got %v", tc.format, cloud.Offset)
+		}
+		if p := cloud.Point(1); !almostEqual(p[0], 1.5) || !almostEqual(p[1], 1.25) || !almostEqual(p[2], 2.5) {
+			t.Errorf("format %d: expected point 1 at (1.5, 1.25, 2.5), got %v", tc.format, p)
+		}
+		if cloud.Classes[0] != 2 || cloud.Classes[1] != 6 {
+			t.Errorf("format %d: expected classes [2 6], got %v", tc.format, cloud.Classes)
+		}
+		if !almostEqual(cloud.Colors[0], 1) || !almostEqual(cloud.Colors[6], 1) || cloud.Colors[4] != 0 {
+			t.Errorf("format %d: unexpected colors %v", tc.format, cloud.Colors)
+		}
+		if _, v, ok := cloud.Attribute("intensity"); !ok || v[0] != 1 || v[1] != 0 {
+			t.Errorf("format %d: expected intensities [1 0], got %v", tc.format, v)
+		}
+		if cloud.CRS != "EPSG:32633" {
+			t.Errorf("format %d: expected CRS EPSG:32633, got %q", tc.format, cloud.CRS)
+		}
+	}
+}
+
+func TestReadLASWithoutColor(t *testing.T) {
+	cloud, err := ReadLAS("scan", bytes.NewReader(buildLAS(t, 1, 28, []lasPoint{{x: 500000, y: 4000000, z: 1}})))
+	if err != nil {
+		t.Fatalf("ReadLAS failed: %v", err)
+	}
+	if c := cloud.Colors; c[0] != 1 || c[1] != 1 || c[2] != 1 {
+		t.Errorf("format 1: expected white, got %v", c)
+	}
+}
+
+func TestReadLASErrors(t *testing.T) {
+	good := buildLAS(t, 2, 26, []lasPoint{{x: 500000, y: 4000000}})
+	laz := append([]byte(nil), good...)
+	laz[104] |= 0x80
+	for name, data := range map[string][]byte{
+		"not LAS":   []byte("PLY\nformat ascii 1.0\n"),
+		"LAZ":       laz,
+		"truncated": good[:len(good)-4],
+	} {
+		if _, err := ReadLAS("scan", bytes.NewReader(data)); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
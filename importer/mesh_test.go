@@ -0,0 +1,112 @@
+// importer/mesh_test.go
+// usage: go test
+
+package importer
+
+import (
+	"bytes"
+	"encoding/binary"
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/sbecker11/webgl-point-cloud/mesh"
+	"github.com/sbecker11/webgl-point-cloud/procgen"
+)
+
+const quadOBJ = `# unit quad in the XY plane
+v 0 0 0
+v 1 0 0
+v 1 1 0
+v 0 1 0
+vn 0 0 1
+f 1//1 2//1 3//1 -1//1
+`
+
+func TestReadOBJ(t *testing.T) {
+	m, err := ReadOBJ(strings.NewReader(quadOBJ))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m.TriangleCount() != 2 {
+		t.Fatalf("ReadOBJ: expected the quad to become 2 triangles, got %d", m.TriangleCount())
+	}
+	if m.VertexCount() != 4 || len(m.Normals) != 12 {
+		t.Errorf("ReadOBJ: expected 4 vertices with normals, got %d vertices and %d normal components", m.VertexCount(), len(m.Normals))
+	}
+
+	plain, err := ReadOBJ(strings.NewReader("v 0 0 0\nv 1 0 0\nv 0 1 0\nf 1 2 3\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if plain.Normals != nil || plain.TriangleCount() != 1 {
+		t.Errorf("ReadOBJ without vn: expected 1 triangle and no normals, got %d and %v", plain.TriangleCount(), plain.Normals)
+	}
+
+	if _, err := ReadOBJ(strings.NewReader("v 0 0 0\nf 1 2 3\n")); err == nil {
+		t.Error("ReadOBJ: expected an error for an out-of-range index")
+	}
+}
+
+func TestReadSTL(t *testing.T) {
+	ascii := `solid tri
+facet normal 0 0 0
+  outer loop
+    vertex 0 0 0
+    vertex 1 0 0
+    vertex 0 1 0
+  endloop
+endfacet
+endsolid tri
+`
+	m, err := ReadSTL(strings.NewReader(ascii))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m.TriangleCount() != 1 || m.Normals[2] != 1 {
+		t.Errorf("ReadSTL ascii: expected 1 triangle with a +Z normal derived from winding, got %d, %v", m.TriangleCount(), m.Normals)
+	}
+
+	// The same triangle in binary form, with a header that also starts with "solid".
+	var buf bytes.Buffer
+	header := make([]byte, 80)
+	copy(header, "solid but actually binary")
+	buf.Write(header)
+	binary.Write(&buf, binary.LittleEndian, uint32(1))
+	binary.Write(&buf, binary.LittleEndian, []float32{0, 0, 1, 0, 0, 0, 1, 0, 0, 0, 1, 0})
+	binary.Write(&buf, binary.LittleEndian, uint16(0))
+	m, err = ReadSTL(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m.TriangleCount() != 1 || m.Positions[3] != 1 || m.Normals[2] != 1 {
+		t.Errorf("ReadSTL binary: unexpected mesh %v / %v", m.Positions, m.Normals)
+	}
+}
+
+func TestMeshToCloud(t *testing.T) {
+	m, err := ReadMesh("quad.obj", "", []byte(quadOBJ))
+	if err != nil {
+		t.Fatal(err)
+	}
+	c, err := MeshToCloud("quad", m, 500, procgen.New(1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.Len() != 500 || len(c.Normals) != 1500 {
+		t.Fatalf("MeshToCloud: expected 500 points with normals, got %d and %d", c.Len(), len(c.Normals)/3)
+	}
+	for i := 0; i < c.Len(); i++ {
+		p := c.Point(i)
+		if p[0] < 0 || p[0] > 1 || p[1] < 0 || p[1] > 1 || p[2] != 0 {
+			t.Fatalf("MeshToCloud: point %d outside the quad: %v", i, p)
+		}
+		if math.Abs(float64(c.Normals[i*3+2]-1)) > 1e-6 {
+			t.Fatalf("MeshToCloud: point %d has normal %v, expected +Z", i, c.Normals[i*3:i*3+3])
+		}
+	}
+
+	if _, err := MeshToCloud("empty", &mesh.Mesh{}, 10, procgen.New(1)); err == nil {
+		t.Error("MeshToCloud: expected an error for an empty mesh")
+	}
+}
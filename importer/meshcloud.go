@@ -0,0 +1,72 @@
+// importer/meshcloud.go
+package importer
+
+import (
+	"bytes"
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/sbecker11/webgl-point-cloud/mesh"
+	"github.com/sbecker11/webgl-point-cloud/pointcloud"
+	"github.com/sbecker11/webgl-point-cloud/procgen"
+)
+
+// ReadMesh parses mesh data in the given format, "obj" or "stl". An empty
+// format is inferred from the extension of name, or else sniffed from data.
+func ReadMesh(name, format string, data []byte) (*mesh.Mesh, error) {
+	if format == "" {
+		format = strings.TrimPrefix(strings.ToLower(path.Ext(name)), ".")
+	}
+	if format != "obj" && format != "stl" {
+		format = sniffMeshFormat(data)
+	}
+	switch format {
+	case "obj":
+		return ReadOBJ(bytes.NewReader(data))
+	case "stl":
+		return ReadSTL(bytes.NewReader(data))
+	}
+	return nil, fmt.Errorf("unsupported mesh format %q", format)
+}
+
+// sniffMeshFormat guesses "stl" for files beginning with "solid" or without
+// any printable OBJ statements, and "obj" otherwise.
+func sniffMeshFormat(data []byte) string {
+	head := data
+	if len(head) > 512 {
+		head = head[:512]
+	}
+	if bytes.HasPrefix(bytes.TrimSpace(head), []byte("solid")) {
+		return "stl"
+	}
+	if bytes.Contains(head, []byte("\nv ")) || bytes.HasPrefix(head, []byte("v ")) || bytes.HasPrefix(head, []byte("#")) {
+		return "obj"
+	}
+	return "stl"
+}
+
+// MeshToCloud samples numPoints points uniformly over the surface of m
+// using g, with normals interpolated across each triangle. Points are
+// shaded gray by a fixed key light so the surface reads as solid.
+// Returns an error if the mesh has no area.
+func MeshToCloud(name string, m *mesh.Mesh, numPoints int, g *procgen.Generator) (*pointcloud.Cloud, error) {
+	coords, normals, _ := g.SampleMesh(m, numPoints)
+	if coords == nil {
+		return nil, fmt.Errorf("mesh %q has no triangles with area", name)
+	}
+	// Light from the upper front right.
+	const lx, ly, lz = 0.37, 0.74, 0.56
+	colors := make([]float32, 0, len(coords)/3*4)
+	for i := 0; i < len(normals); i += 3 {
+		lambert := normals[i]*lx + normals[i+1]*ly + normals[i+2]*lz
+		if lambert < 0 {
+			lambert = 0
+		}
+		shade := 0.25 + 0.75*lambert
+		colors = append(colors, shade, shade, shade, 1)
+	}
+	cloud := pointcloud.New(name, coords, colors)
+	cloud.Normals = normals
+	return cloud, nil
+}
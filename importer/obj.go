@@ -0,0 +1,127 @@
+// importer/obj.go
+package importer
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/sbecker11/webgl-point-cloud/mesh"
+)
+
+// ReadOBJ parses the geometry of a Wavefront OBJ file: vertex positions
+// (v), vertex normals (vn) and faces (f). Polygons are fan-triangulated and
+// negative (relative) indices are supported; texture coordinates, groups and
+// materials are ignored. If any face references normals, each distinct
+// position/normal pair becomes a mesh vertex so normals are preserved;
+// otherwise the mesh has no normals.
+func ReadOBJ(r io.Reader) (*mesh.Mesh, error) {
+	var positions, normals []float32
+	type corner struct{ v, vn int } // zero-based; vn is -1 when absent
+	var faces [][3]corner
+	hasNormals := false
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	line := 0
+	for scanner.Scan() {
+		line++
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 || strings.HasPrefix(fields[0], "#") {
+			continue
+		}
+		switch fields[0] {
+		case "v", "vn":
+			if len(fields) < 4 {
+				return nil, fmt.Errorf("obj line %d: %s needs 3 coordinates", line, fields[0])
+			}
+			var xyz [3]float32
+			for k := 0; k < 3; k++ {
+				f, err := strconv.ParseFloat(fields[k+1], 32)
+				if err != nil {
+					return nil, fmt.Errorf("obj line %d: %v", line, err)
+				}
+				xyz[k] = float32(f)
+			}
+			if fields[0] == "v" {
+				positions = append(positions, xyz[:]...)
+			} else {
+				normals = append(normals, xyz[:]...)
+			}
+		case "f":
+			if len(fields) < 4 {
+				return nil, fmt.Errorf("obj line %d: face needs at least 3 vertices", line)
+			}
+			corners := make([]corner, 0, len(fields)-1)
+			for _, ref := range fields[1:] {
+				parts := strings.Split(ref, "/")
+				v, err := objIndex(parts[0], len(positions)/3)
+				if err != nil {
+					return nil, fmt.Errorf("obj line %d: %v", line, err)
+				}
+				c := corner{v: v, vn: -1}
+				if len(parts) == 3 && parts[2] != "" {
+					if c.vn, err = objIndex(parts[2], len(normals)/3); err != nil {
+						return nil, fmt.Errorf("obj line %d: %v", line, err)
+					}
+					hasNormals = true
+				}
+				corners = append(corners, c)
+			}
+			for i := 1; i+1 < len(corners); i++ {
+				faces = append(faces, [3]corner{corners[0], corners[i], corners[i+1]})
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	if !hasNormals {
+		m := &mesh.Mesh{Positions: positions}
+		for _, f := range faces {
+			m.Indices = append(m.Indices, uint32(f[0].v), uint32(f[1].v), uint32(f[2].v))
+		}
+		return m, nil
+	}
+
+	m := &mesh.Mesh{}
+	index := map[corner]uint32{}
+	for _, f := range faces {
+		for _, c := range f {
+			i, ok := index[c]
+			if !ok {
+				i = uint32(len(m.Positions) / 3)
+				index[c] = i
+				m.Positions = append(m.Positions, positions[c.v*3:c.v*3+3]...)
+				if c.vn >= 0 {
+					m.Normals = append(m.Normals, normals[c.vn*3:c.vn*3+3]...)
+				} else {
+					m.Normals = append(m.Normals, 0, 0, 0)
+				}
+			}
+			m.Indices = append(m.Indices, i)
+		}
+	}
+	return m, nil
+}
+
+// objIndex converts a one-based (or negative, relative) OBJ index into a
+// zero-based index into a list of n elements.
+func objIndex(s string, n int) (int, error) {
+	i, err := strconv.Atoi(s)
+	if err != nil {
+		return 0, fmt.Errorf("bad index %q", s)
+	}
+	if i < 0 {
+		i = n + i
+	} else {
+		i--
+	}
+	if i < 0 || i >= n {
+		return 0, fmt.Errorf("index %s out of range (%d defined)", s, n)
+	}
+	return i, nil
+}
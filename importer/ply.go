@@ -0,0 +1,322 @@
+// importer/ply.go
+package importer
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/sbecker11/webgl-point-cloud/pointcloud"
+)
+
+// plyTypes holds the size in bytes and the largest value of each PLY
+// scalar type, by both its old and its sized name; the largest value
+// scales integer colors and intensities to [0, 1].
+var plyTypes = map[string]struct {
+	size int
+	max  float64
+}{
+	"char": {1, math.MaxInt8}, "int8": {1, math.MaxInt8},
+	"uchar": {1, math.MaxUint8}, "uint8": {1, math.MaxUint8},
+	"short": {2, math.MaxInt16}, "int16": {2, math.MaxInt16},
+	"ushort": {2, math.MaxUint16}, "uint16": {2, math.MaxUint16},
+	"int": {4, math.MaxInt32}, "int32": {4, math.MaxInt32},
+	"uint": {4, math.MaxUint32}, "uint32": {4, math.MaxUint32},
+	"float": {4, 1}, "float32": {4, 1},
+	"double": {8, 1}, "float64": {8, 1},
+}
+
+// plyProperty is a property of a PLY element. List properties have the
+// type of their length in countType.
+type plyProperty struct {
+	name, typ, countType string
+}
+
+type plyElement struct {
+	name  string
+	count int
+	props []plyProperty
+}
+
+// ReadPLY parses a PLY file, ASCII or binary of either byte order, into a
+// cloud named name of its vertices: their positions, normals (nx, ny, nz),
+// colors (red, green, blue and alpha; white when absent), classes
+// (classification or class) and intensities (intensity), integer colors
+// and intensities being scaled to [0, 1]. Faces and other elements are
+// skipped, so meshes read as the points of their vertices. Coordinates are
+// stored relative to the floor of their minimum, kept in the cloud's
+// Offset, as ReadLAS does.
+func ReadPLY(name string, r io.Reader) (*pointcloud.Cloud, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	format, elements, body, err := readPLYHeader(data)
+	if err != nil {
+		return nil, err
+	}
+	var next func(typ string) (float64, error)
+	switch format {
+	case "ascii":
+		words := bufio.NewScanner(bytes.NewReader(body))
+		words.Buffer(nil, 1<<20)
+		words.Split(bufio.ScanWords)
+		next = func(string) (float64, error) {
+			if !words.Scan() {
+				return 0, fmt.Errorf("ply: file is truncated")
+			}
+			return strconv.ParseFloat(words.Text(), 64)
+		}
+	case "binary_little_endian", "binary_big_endian":
+		var order binary.ByteOrder = binary.LittleEndian
+		if format == "binary_big_endian" {
+			order = binary.BigEndian
+		}
+		next = func(typ string) (float64, error) {
+			size := plyTypes[typ].size
+			if len(body) < size {
+				return 0, fmt.Errorf("ply: file is truncated")
+			}
+			v := plyBinary(order, typ, body)
+			body = body[size:]
+			return v, nil
+		}
+	default:
+		return nil, fmt.Errorf("ply: unknown format %q", format)
+	}
+
+	for _, e := range elements {
+		if e.name != "vertex" {
+			if err := skipPLYElement(e, next); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		return readPLYVertices(name, e, next)
+	}
+	return nil, fmt.Errorf("ply: no vertex element")
+}
+
+// readPLYHeader parses the header of a PLY file into its format and
+// elements, and returns the data after it.
+func readPLYHeader(data []byte) (format string, elements []plyElement, body []byte, err error) {
+	if !bytes.HasPrefix(data, []byte("ply")) {
+		return "", nil, nil, fmt.Errorf("ply: not a PLY file")
+	}
+	end := bytes.Index(data, []byte("end_header"))
+	if end < 0 {
+		return "", nil, nil, fmt.Errorf("ply: header has no end")
+	}
+	body = data[end+len("end_header"):]
+	// The header ends with a line break, \r\n from some writers.
+	body = bytes.TrimPrefix(body, []byte("\r"))
+	body = bytes.TrimPrefix(body, []byte("\n"))
+	for _, line := range strings.Split(string(data[:end]), "\n") {
+		f := strings.Fields(line)
+		if len(f) == 0 {
+			continue
+		}
+		switch f[0] {
+		case "format":
+			if len(f) < 2 {
+				return "", nil, nil, fmt.Errorf("ply: bad format line %q", line)
+			}
+			format = f[1]
+		case "element":
+			count := -1
+			if len(f) == 3 {
+				count, _ = strconv.Atoi(f[2])
+			}
+			if count < 0 {
+				return "", nil, nil, fmt.Errorf("ply: bad element line %q", line)
+			}
+			elements = append(elements, plyElement{name: f[1], count: count})
+		case "property":
+			var p plyProperty
+			switch {
+			case len(f) == 3:
+				p = plyProperty{name: f[2], typ: f[1]}
+			case len(f) == 5 && f[1] == "list":
+				p = plyProperty{name: f[4], typ: f[3], countType: f[2]}
+			default:
+				return "", nil, nil, fmt.Errorf("ply: bad property line %q", line)
+			}
+			if _, ok := plyTypes[p.typ]; !ok {
+				return "", nil, nil, fmt.Errorf("ply: unknown type %q", p.typ)
+			}
+			if _, ok := plyTypes[p.countType]; p.countType != "" && !ok {
+				return "", nil, nil, fmt.Errorf("ply: unknown type %q", p.countType)
+			}
+			if len(elements) == 0 {
+				return "", nil, nil, fmt.Errorf("ply: property %s outside an element", p.name)
+			}
+			e := &elements[len(elements)-1]
+			e.props = append(e.props, p)
+		}
+	}
+	return format, elements, body, nil
+}
+
+// plyBinary decodes a value of a PLY scalar type from the start of b.
+func plyBinary(order binary.ByteOrder, typ string, b []byte) float64 {
+	switch plyTypes[typ].size {
+	case 1:
+		if typ == "char" || typ == "int8" {
+			return float64(int8(b[0]))
+		}
+		return float64(b[0])
+	case 2:
+		if typ == "short" || typ == "int16" {
+			return float64(int16(order.Uint16(b)))
+		}
+		return float64(order.Uint16(b))
+	case 4:
+		switch typ {
+		case "float", "float32":
+			return float64(math.Float32frombits(order.Uint32(b)))
+		case "int", "int32":
+			return float64(int32(order.Uint32(b)))
+		}
+		return float64(order.Uint32(b))
+	}
+	return math.Float64frombits(order.Uint64(b))
+}
+
+// skipPLYElement reads past the records of e.
+func skipPLYElement(e plyElement, next func(string) (float64, error)) error {
+	for i := 0; i < e.count; i++ {
+		for _, p := range e.props {
+			if _, err := readPLYProperty(p, next); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// readPLYProperty reads a value of p, or the first of a list, which is 0
+// when the list is empty.
+func readPLYProperty(p plyProperty, next func(string) (float64, error)) (float64, error) {
+	if p.countType == "" {
+		return next(p.typ)
+	}
+	n, err := next(p.countType)
+	if err != nil {
+		return 0, err
+	}
+	if n < 0 || n > math.MaxInt32 {
+		return 0, fmt.Errorf("ply: bad list length %v", n)
+	}
+	first := 0.0
+	for k := 0; k < int(n); k++ {
+		v, err := next(p.typ)
+		if err != nil {
+			return 0, err
+		}
+		if k == 0 {
+			first = v
+		}
+	}
+	return first, nil
+}
+
+// readPLYVertices reads the vertex element e into a cloud.
+func readPLYVertices(name string, e plyElement, next func(string) (float64, error)) (*pointcloud.Cloud, error) {
+	index := map[string]int{}
+	for i, p := range e.props {
+		index[p.name] = i
+	}
+	column := func(names ...string) int {
+		for _, n := range names {
+			if i, ok := index[n]; ok {
+				return i
+			}
+		}
+		return -1
+	}
+	xyz := [3]int{column("x"), column("y"), column("z")}
+	if xyz[0] < 0 || xyz[1] < 0 || xyz[2] < 0 {
+		return nil, fmt.Errorf("ply: vertices have no x, y and z")
+	}
+	normal := [3]int{column("nx"), column("ny"), column("nz")}
+	rgba := [4]int{column("red", "diffuse_red"), column("green", "diffuse_green"), column("blue", "diffuse_blue"), column("alpha")}
+	class := column("classification", "class", "scalar_classification")
+	intensity := column("intensity", "scalar_intensity")
+	hasNormals := normal[0] >= 0 && normal[1] >= 0 && normal[2] >= 0
+	hasColors := rgba[0] >= 0 && rgba[1] >= 0 && rgba[2] >= 0
+	// scale divides a value of the property at i into [0, 1].
+	scale := func(i int) float64 {
+		return plyTypes[e.props[i].typ].max
+	}
+
+	// The count is only trusted as far as the data bears it out.
+	coords := make([]float64, 0, min(e.count, 1<<20)*3)
+	colors := make([]float32, 0, min(e.count, 1<<20)*4)
+	var normals, intensities []float32
+	var classes []uint8
+	values := make([]float64, len(e.props))
+	for n := 0; n < e.count; n++ {
+		for i, p := range e.props {
+			v, err := readPLYProperty(p, next)
+			if err != nil {
+				return nil, err
+			}
+			values[i] = v
+		}
+		coords = append(coords, values[xyz[0]], values[xyz[1]], values[xyz[2]])
+		if hasColors {
+			a := float32(1)
+			if rgba[3] >= 0 {
+				a = float32(values[rgba[3]] / scale(rgba[3]))
+			}
+			colors = append(colors, float32(values[rgba[0]]/scale(rgba[0])), float32(values[rgba[1]]/scale(rgba[1])), float32(values[rgba[2]]/scale(rgba[2])), a)
+		} else {
+			colors = append(colors, 1, 1, 1, 1)
+		}
+		if hasNormals {
+			normals = append(normals, float32(values[normal[0]]), float32(values[normal[1]]), float32(values[normal[2]]))
+		}
+		if class >= 0 {
+			classes = append(classes, uint8(values[class]))
+		}
+		if intensity >= 0 {
+			intensities = append(intensities, float32(values[intensity]/scale(intensity)))
+		}
+	}
+
+	local, origin := localCoords(coords)
+	cloud := pointcloud.New(name, local, colors)
+	cloud.Offset = origin
+	cloud.Normals = normals
+	cloud.Classes = classes
+	if intensities != nil {
+		cloud.SetAttribute(lasIntensity, intensities)
+	}
+	return cloud, nil
+}
+
+// localCoords returns coords relative to the floor of their minimum, the
+// origin, in float32, which keeps large projected coordinates precise.
+func localCoords(coords []float64) ([]float32, [3]float64) {
+	var origin [3]float64
+	if len(coords) == 0 {
+		return []float32{}, origin
+	}
+	for k := 0; k < 3; k++ {
+		lo := coords[k]
+		for i := k; i < len(coords); i += 3 {
+			lo = math.Min(lo, coords[i])
+		}
+		origin[k] = math.Floor(lo)
+	}
+	local := make([]float32, len(coords))
+	for i, v := range coords {
+		local[i] = float32(v - origin[i%3])
+	}
+	return local, origin
+}
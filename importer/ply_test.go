@@ -0,0 +1,157 @@
+// importer/ply_test.go
+// usage: go test
+
+package importer
+
+import (
+	"bytes"
+	"encoding/binary"
+	"math"
+	"strings"
+	"testing"
+)
+
+const plyHeader = `ply
+format %s 1.0
+comment two vertices of a triangle, after a stray element
+element camera 1
+property float fov
+property list uchar int ids
+element vertex 2
+property double x
+property double y
+property double z
+property float nx
+property float ny
+property float nz
+property uchar red
+property uchar green
+property uchar blue
+property ushort intensity
+property uchar classification
+element face 1
+property list uchar int vertex_indices
+end_header
+`
+
+func TestReadPLYASCII(t *testing.T) {
+	data := strings.Replace(plyHeader, "%s", "ascii", 1) +
+		"0.8 2 7 9\n" +
+		"500100.5 4000200.25 12 0 0 1 255 0 0 65535 2\n" +
+		"500101.5 4000201.25 14.5 0 1 0 0 128 255 0 6\n" +
+		"3 0 1 1\n"
+	checkPLY(t, "ascii", []byte(data))
+}
+
+func TestReadPLYBinary(t *testing.T) {
+	for _, order := range []binary.ByteOrder{binary.LittleEndian, binary.BigEndian} {
+		format := "binary_little_endian"
+		if order == binary.BigEndian {
+			format = "binary_big_endian"
+		}
+		var b bytes.Buffer
+		b.WriteString(strings.Replace(plyHeader, "%s", format, 1))
+		put := func(v any) { binary.Write(&b, order, v) }
+		put(float32(0.8))
+		put(uint8(2))
+		put([]int32{7, 9})
+		for _, p := range []struct {
+			xyz       [3]float64
+			normal    [3]float32
+			rgb       [3]uint8
+			intensity uint16
+			class     uint8
+		}{
+			{[3]float64{500100.5, 4000200.25, 12}, [3]float32{0, 0, 1}, [3]uint8{255, 0, 0}, 65535, 2},
+			{[3]float64{500101.5, 4000201.25, 14.5}, [3]float32{0, 1, 0}, [3]uint8{0, 128, 255}, 0, 6},
+		} {
+			put(p.xyz)
+			put(p.normal)
+			put(p.rgb)
+			put(p.intensity)
+			put(p.class)
+		}
+		put(uint8(3))
+		put([]int32{0, 1, 1})
+		checkPLY(t, format, b.Bytes())
+	}
+}
+
+// checkPLY checks the cloud read from data, a PLY file in format of
+// plyHeader's two vertices.
+func checkPLY(t *testing.T, format string, data []byte) {
+	t.Helper()
+	cloud, err := ReadPLY("scan", bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("%s: ReadPLY failed: %v", format, err)
+	}
+	if cloud.Len() != 2 {
+		t.Fatalf("%s: expected 2 points, got %d", format, cloud.Len())
+	}
+	if cloud.Offset != [3]float64{500100, 4000200, 12} {
+		t.Errorf("%s: expected offset at the floor of the minimum, got %v", format, cloud.Offset)
+	}
+	if p := cloud.Point(1); !almostEqual(p[0], 1.5) || !almostEqual(p[1], 1.25) || !almostEqual(p[2], 2.5) {
+		t.Errorf("%s: expected point 1 at (1.5, 1.25, 2.5), got %v", format, p)
+	}
+	if len(cloud.Normals) != 6 || cloud.Normals[2] != 1 || cloud.Normals[4] != 1 {
+		t.Errorf("%s: unexpected normals %v", format, cloud.Normals)
+	}
+	if c := cloud.Colors; c[0] != 1 || c[1] != 0 || !almostEqual(c[5], 128.0/255) || c[6] != 1 || c[7] != 1 {
+		t.Errorf("%s: unexpected colors %v", format, c)
+	}
+	if len(cloud.Classes) != 2 || cloud.Classes[0] != 2 || cloud.Classes[1] != 6 {
+		t.Errorf("%s: expected classes [2 6], got %v", format, cloud.Classes)
+	}
+	if _, v, ok := cloud.Attribute("intensity"); !ok || v[0] != 1 || v[1] != 0 {
+		t.Errorf("%s: expected intensities [1 0], got %v", format, v)
+	}
+}
+
+func TestReadPLYWithoutColor(t *testing.T) {
+	data := "ply\r\nformat ascii 1.0\r\nelement vertex 1\r\nproperty float x\r\nproperty float y\r\nproperty float z\r\nend_header\r\n1 2 3\r\n"
+	cloud, err := ReadPLY("p", strings.NewReader(data))
+	if err != nil {
+		t.Fatalf("ReadPLY failed: %v", err)
+	}
+	if c := cloud.Colors; c[0] != 1 || c[1] != 1 || c[2] != 1 || c[3] != 1 {
+		t.Errorf("expected white, got %v", c)
+	}
+	if cloud.Normals != nil || cloud.Classes != nil {
+		t.Errorf("expected no normals or classes, got %v and %v", cloud.Normals, cloud.Classes)
+	}
+}
+
+func TestReadPLYErrors(t *testing.T) {
+	vertex := "element vertex 2\nproperty float x\nproperty float y\nproperty float z\nend_header\n"
+	truncated := append([]byte("ply\nformat binary_little_endian 1.0\n"+vertex), make([]byte, 20)...)
+	for name, data := range map[string]string{
+		"not PLY":      "LASF",
+		"no end":       "ply\nformat ascii 1.0\nelement vertex 1\n",
+		"bad format":   "ply\nformat utf8 1.0\n" + vertex,
+		"bad type":     "ply\nformat ascii 1.0\nelement vertex 1\nproperty float128 x\nend_header\n",
+		"no xyz":       "ply\nformat ascii 1.0\nelement vertex 1\nproperty float x\nend_header\n1\n",
+		"no vertices":  "ply\nformat ascii 1.0\nelement face 0\nproperty list uchar int vertex_indices\nend_header\n",
+		"bad number":   "ply\nformat ascii 1.0\n" + vertex + "1 2 3\n4 five 6\n",
+		"short ascii":  "ply\nformat ascii 1.0\n" + vertex + "1 2 3\n",
+		"short binary": string(truncated),
+	} {
+		if _, err := ReadPLY("p", strings.NewReader(data)); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
+
+func TestLocalCoords(t *testing.T) {
+	local, origin := localCoords([]float64{-0.5, 10, 1e6 + 0.25, 2, 11, 1e6})
+	if origin != [3]float64{-1, 10, 1e6} {
+		t.Errorf("expected origin (-1, 10, 1e6), got %v", origin)
+	}
+	want := []float32{0.5, 0, 0.25, 3, 1, 0}
+	for i := range want {
+		if math.Abs(float64(local[i]-want[i])) > 1e-6 {
+			t.Errorf("expected %v, got %v", want, local)
+			break
+		}
+	}
+}
@@ -0,0 +1,69 @@
+// importer/points.go
+package importer
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/sbecker11/webgl-point-cloud/pointcloud"
+	"github.com/sbecker11/webgl-point-cloud/procgen"
+)
+
+// pointFormats maps the extensions of point cloud files to the formats
+// ReadPoints reads them as.
+var pointFormats = map[string]string{
+	"las": "las",
+	"ply": "ply",
+	"csv": "csv", "txt": "csv", "xyz": "csv", "pts": "csv",
+}
+
+// PointFormat returns the point cloud format, "las", "ply" or "csv", of a
+// file named name in format, inferred from the extension of name when
+// format is empty, or "" for other formats, such as meshes.
+func PointFormat(name, format string) string {
+	if format == "" {
+		format = path.Ext(name)
+	}
+	return pointFormats[strings.TrimPrefix(strings.ToLower(format), ".")]
+}
+
+// ReadPoints parses point cloud data in a format PointFormat returns into
+// a cloud named name.
+func ReadPoints(name, format string, data []byte) (*pointcloud.Cloud, error) {
+	switch PointFormat(name, format) {
+	case "las":
+		return ReadLAS(name, bytes.NewReader(data))
+	case "ply":
+		return ReadPLY(name, bytes.NewReader(data))
+	case "csv":
+		return ReadCSV(name, bytes.NewReader(data))
+	}
+	return nil, fmt.Errorf("unsupported point cloud format %q", format)
+}
+
+// ReadFile reads the file at path into a cloud named after it: LAS, PLY
+// and CSV files as points, and OBJ and STL meshes sampled into numPoints
+// points with a generator seeded with seed.
+func ReadFile(path string, numPoints int, seed int64) (*pointcloud.Cloud, error) {
+	name := strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+	ext := strings.ToLower(strings.TrimPrefix(filepath.Ext(path), "."))
+	if PointFormat(path, "") == "" && ext != "obj" && ext != "stl" {
+		return nil, fmt.Errorf("unsupported format %q", ext)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	if PointFormat(path, "") != "" {
+		return ReadPoints(name, ext, data)
+	}
+	m, err := ReadMesh(name, ext, data)
+	if err != nil {
+		return nil, err
+	}
+	return MeshToCloud(name, m, numPoints, procgen.New(seed))
+}
@@ -0,0 +1,67 @@
+// importer/points_test.go
+// usage: go test
+
+package importer
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestReadPoints(t *testing.T) {
+	for _, tc := range []struct{ name, format, want string }{
+		{"scan.LAS", "", "las"},
+		{"scan.ply", "", "ply"},
+		{"scan.xyz", "", "csv"},
+		{"scan.txt", "", "csv"},
+		{"scan.bin", "csv", "csv"},
+		{"bunny.obj", "", ""},
+		{"scan", "stl", ""},
+	} {
+		if got := PointFormat(tc.name, tc.format); got != tc.want {
+			t.Errorf("PointFormat(%q, %q): expected %q, got %q", tc.name, tc.format, tc.want, got)
+		}
+	}
+
+	las := buildLAS(t, 2, 26, []lasPoint{{x: 500000, y: 4000000, z: 1}})
+	for name, data := range map[string][]byte{
+		"scan.las": las,
+		"scan.ply": []byte("ply\nformat ascii 1.0\nelement vertex 1\nproperty float x\nproperty float y\nproperty float z\nend_header\n1 2 3\n"),
+		"scan.csv": []byte("1,2,3\n"),
+	} {
+		cloud, err := ReadPoints(name, "", data)
+		if err != nil || cloud.Len() != 1 || cloud.Name != name {
+			t.Errorf("ReadPoints(%s): expected 1 point, got %v, %v", name, cloud, err)
+		}
+	}
+	if _, err := ReadPoints("bunny.obj", "", nil); err == nil {
+		t.Error("ReadPoints(bunny.obj): expected an error")
+	}
+}
+
+func TestReadFile(t *testing.T) {
+	dir := t.TempDir()
+	for name, data := range map[string]string{
+		"scan.csv": "x,y,z\n1,2,3\n4,5,6\n",
+		"quad.obj": quadOBJ,
+		"notes.md": "# not points\n",
+	} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(data), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	cloud, err := ReadFile(filepath.Join(dir, "scan.csv"), 0, 1)
+	if err != nil || cloud.Len() != 2 || cloud.Name != "scan" {
+		t.Errorf("ReadFile(scan.csv): expected 2 points named scan, got %v, %v", cloud, err)
+	}
+	cloud, err = ReadFile(filepath.Join(dir, "quad.obj"), 50, 1)
+	if err != nil || cloud.Len() != 50 || cloud.Normals == nil {
+		t.Errorf("ReadFile(quad.obj): expected 50 sampled points with normals, got %v, %v", cloud, err)
+	}
+	for _, name := range []string{"notes.md", "missing.las"} {
+		if _, err := ReadFile(filepath.Join(dir, name), 50, 1); err == nil {
+			t.Errorf("ReadFile(%s): expected an error", name)
+		}
+	}
+}
@@ -0,0 +1,135 @@
+// importer/stl.go
+package importer
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/sbecker11/webgl-point-cloud/mesh"
+)
+
+// ReadSTL parses a binary or ASCII STL file. STL stores each triangle with
+// its own three vertices and a facet normal, so the mesh is not welded and
+// every vertex carries its facet's normal (recomputed from the winding when
+// the file's normal is zero), which keeps hard edges flat-shaded.
+func ReadSTL(r io.Reader) (*mesh.Mesh, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	// Binary files may also begin with "solid", so trust the size check first.
+	if len(data) >= 84 {
+		n := binary.LittleEndian.Uint32(data[80:84])
+		if uint64(len(data)) == 84+50*uint64(n) {
+			return readBinarySTL(data[84:], int(n)), nil
+		}
+	}
+	if bytes.HasPrefix(bytes.TrimSpace(data), []byte("solid")) {
+		return readASCIISTL(data)
+	}
+	return nil, fmt.Errorf("stl: not a valid binary or ASCII STL file")
+}
+
+func readBinarySTL(data []byte, n int) *mesh.Mesh {
+	m := &mesh.Mesh{
+		Positions: make([]float32, 0, n*9),
+		Normals:   make([]float32, 0, n*9),
+		Indices:   make([]uint32, 0, n*3),
+	}
+	f := func(off int) float32 {
+		return math.Float32frombits(binary.LittleEndian.Uint32(data[off:]))
+	}
+	for t := 0; t < n; t++ {
+		rec := t * 50
+		normal := [3]float32{f(rec), f(rec + 4), f(rec + 8)}
+		var verts [9]float32
+		for k := 0; k < 9; k++ {
+			verts[k] = f(rec + 12 + k*4)
+		}
+		appendFacet(m, normal, verts)
+	}
+	return m
+}
+
+func readASCIISTL(data []byte) (*mesh.Mesh, error) {
+	m := &mesh.Mesh{}
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	var normal [3]float32
+	var verts [9]float32
+	nv := 0
+	line := 0
+	parse := func(fields []string) ([3]float32, error) {
+		var v [3]float32
+		if len(fields) < 3 {
+			return v, fmt.Errorf("stl line %d: expected 3 coordinates", line)
+		}
+		for k := 0; k < 3; k++ {
+			x, err := strconv.ParseFloat(fields[k], 32)
+			if err != nil {
+				return v, fmt.Errorf("stl line %d: %v", line, err)
+			}
+			v[k] = float32(x)
+		}
+		return v, nil
+	}
+	for scanner.Scan() {
+		line++
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 {
+			continue
+		}
+		switch fields[0] {
+		case "facet":
+			if len(fields) < 2 || fields[1] != "normal" {
+				return nil, fmt.Errorf("stl line %d: expected 'facet normal'", line)
+			}
+			n, err := parse(fields[2:])
+			if err != nil {
+				return nil, err
+			}
+			normal, nv = n, 0
+		case "vertex":
+			v, err := parse(fields[1:])
+			if err != nil {
+				return nil, err
+			}
+			if nv == 3 {
+				return nil, fmt.Errorf("stl line %d: facet has more than 3 vertices", line)
+			}
+			copy(verts[nv*3:], v[:])
+			nv++
+		case "endfacet":
+			if nv != 3 {
+				return nil, fmt.Errorf("stl line %d: facet has %d vertices, expected 3", line, nv)
+			}
+			appendFacet(m, normal, verts)
+		}
+	}
+	return m, scanner.Err()
+}
+
+// appendFacet adds one unwelded triangle to m with normal on all three
+// vertices, deriving the normal from the winding if it is zero.
+func appendFacet(m *mesh.Mesh, normal [3]float32, verts [9]float32) {
+	if normal == [3]float32{} {
+		ux, uy, uz := verts[3]-verts[0], verts[4]-verts[1], verts[5]-verts[2]
+		vx, vy, vz := verts[6]-verts[0], verts[7]-verts[1], verts[8]-verts[2]
+		normal = [3]float32{uy*vz - uz*vy, uz*vx - ux*vz, ux*vy - uy*vx}
+	}
+	l := float32(math.Sqrt(float64(normal[0]*normal[0] + normal[1]*normal[1] + normal[2]*normal[2])))
+	if l > 0 {
+		normal[0], normal[1], normal[2] = normal[0]/l, normal[1]/l, normal[2]/l
+	}
+	base := uint32(len(m.Positions) / 3)
+	m.Positions = append(m.Positions, verts[:]...)
+	for k := 0; k < 3; k++ {
+		m.Normals = append(m.Normals, normal[:]...)
+	}
+	m.Indices = append(m.Indices, base, base+1, base+2)
+}
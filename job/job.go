@@ -0,0 +1,209 @@
+// job/job.go
+// Package job tracks the progress of long operations and lets callers
+// cancel them. An operation reports its progress through its Job and stops
+// when Report returns ErrCancelled.
+package job
+
+import (
+	"errors"
+	"sync"
+)
+
+// ErrCancelled is returned by Report once a job has been cancelled.
+var ErrCancelled = errors.New("job: cancelled")
+
+// State is the stage of a job's life.
+type State int
+
+const (
+	Running State = iota
+	Done
+	Cancelled
+	Failed
+)
+
+func (s State) String() string {
+	switch s {
+	case Done:
+		return "done"
+	case Cancelled:
+		return "cancelled"
+	case Failed:
+		return "failed"
+	}
+	return "running"
+}
+
+// Job is one long operation. Its methods may be called from any goroutine.
+type Job struct {
+	ID   int
+	Name string
+
+	mu       sync.Mutex
+	progress float64 // in [0, 1]
+	percent  int     // whole percent done at the last change notification
+	cancel   bool
+	state    State
+	err      error
+	manager  *Manager
+}
+
+// Report records the fraction of the work done, clamped to [0, 1], and
+// returns ErrCancelled if the job has been cancelled. The manager's
+// OnChange is called each time progress reaches another whole percent, so
+// reporting from a tight loop is cheap.
+func (j *Job) Report(fraction float64) error {
+	if fraction < 0 {
+		fraction = 0
+	} else if fraction > 1 {
+		fraction = 1
+	}
+	j.mu.Lock()
+	j.progress = fraction
+	percent := int(fraction * 100)
+	notify := percent > j.percent
+	if notify {
+		j.percent = percent
+	}
+	cancelled := j.cancel
+	j.mu.Unlock()
+	if notify {
+		j.manager.changed(j)
+	}
+	if cancelled {
+		return ErrCancelled
+	}
+	return nil
+}
+
+// Step reports done of total units of work finished.
+func (j *Job) Step(done, total int) error {
+	if total <= 0 {
+		return j.Report(1)
+	}
+	return j.Report(float64(done) / float64(total))
+}
+
+// Progress returns the fraction of the work done.
+func (j *Job) Progress() float64 {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	return j.progress
+}
+
+// Cancel asks the job to stop; the operation sees ErrCancelled from its
+// next Report. It has no effect on a finished job.
+func (j *Job) Cancel() {
+	j.mu.Lock()
+	j.cancel = j.state == Running
+	j.mu.Unlock()
+}
+
+// Cancelled reports whether Cancel has been called on the running job.
+func (j *Job) Cancelled() bool {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	return j.cancel
+}
+
+// Finish ends the job with the operation's result: Done for a nil err,
+// Cancelled for ErrCancelled and Failed otherwise. The job is removed from
+// its manager. Later calls have no effect.
+func (j *Job) Finish(err error) {
+	j.mu.Lock()
+	if j.state != Running {
+		j.mu.Unlock()
+		return
+	}
+	switch {
+	case err == nil:
+		j.state, j.progress = Done, 1
+	case errors.Is(err, ErrCancelled):
+		j.state = Cancelled
+	default:
+		j.state = Failed
+	}
+	j.err = err
+	j.mu.Unlock()
+	j.manager.finished(j)
+}
+
+// State returns the job's state.
+func (j *Job) State() State {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	return j.state
+}
+
+// Err returns the error the job finished with.
+func (j *Job) Err() error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	return j.err
+}
+
+// Manager hands out jobs and keeps the running ones.
+type Manager struct {
+	// OnChange, if set, is called when a job starts, advances or finishes.
+	// It is called without any job's lock held.
+	OnChange func(j *Job)
+
+	mu      sync.Mutex
+	running []*Job
+	next    int
+}
+
+// Start begins a job with the given name.
+func (m *Manager) Start(name string) *Job {
+	m.mu.Lock()
+	m.next++
+	j := &Job{ID: m.next, Name: name, manager: m}
+	m.running = append(m.running, j)
+	m.mu.Unlock()
+	m.changed(j)
+	return j
+}
+
+// Running returns the unfinished jobs, oldest first.
+func (m *Manager) Running() []*Job {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]*Job(nil), m.running...)
+}
+
+// Job returns the running job with the given id, or nil.
+func (m *Manager) Job(id int) *Job {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, j := range m.running {
+		if j.ID == id {
+			return j
+		}
+	}
+	return nil
+}
+
+// CancelAll cancels every running job.
+func (m *Manager) CancelAll() {
+	for _, j := range m.Running() {
+		j.Cancel()
+	}
+}
+
+func (m *Manager) changed(j *Job) {
+	if m.OnChange != nil {
+		m.OnChange(j)
+	}
+}
+
+func (m *Manager) finished(j *Job) {
+	m.mu.Lock()
+	for i, r := range m.running {
+		if r == j {
+			m.running = append(m.running[:i:i], m.running[i+1:]...)
+			break
+		}
+	}
+	m.mu.Unlock()
+	m.changed(j)
+}
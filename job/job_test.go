@@ -0,0 +1,83 @@
+// job/job_test.go
+// usage: go test
+
+package job
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestReportThrottlesChanges(t *testing.T) {
+	var m Manager
+	changes := 0
+	m.OnChange = func(*Job) { changes++ }
+	j := m.Start("import")
+	for i := 0; i <= 1000; i++ {
+		if err := j.Step(i, 1000); err != nil {
+			t.Fatalf("Step: unexpected error %v", err)
+		}
+	}
+	// One for Start, then one per percent.
+	if changes != 101 {
+		t.Errorf("expected 101 change notifications, got %d", changes)
+	}
+	if j.Progress() != 1 {
+		t.Errorf("expected progress 1, got %v", j.Progress())
+	}
+}
+
+func TestCancel(t *testing.T) {
+	var m Manager
+	j := m.Start("cluster")
+	other := m.Start("normals")
+	if got := len(m.Running()); got != 2 {
+		t.Fatalf("expected 2 running jobs, got %d", got)
+	}
+	if m.Job(j.ID) != j {
+		t.Errorf("Job(%d) did not return the job", j.ID)
+	}
+	j.Cancel()
+	if !j.Cancelled() || other.Cancelled() {
+		t.Errorf("Cancel affected the wrong jobs")
+	}
+	err := j.Report(0.5)
+	if !errors.Is(err, ErrCancelled) {
+		t.Fatalf("Report after Cancel: expected ErrCancelled, got %v", err)
+	}
+	j.Finish(err)
+	if j.State() != Cancelled {
+		t.Errorf("expected state cancelled, got %v", j.State())
+	}
+	if got := m.Running(); len(got) != 1 || got[0] != other {
+		t.Errorf("expected only the other job running, got %d jobs", len(got))
+	}
+	if m.Job(j.ID) != nil {
+		t.Errorf("finished job still returned by Job")
+	}
+}
+
+func TestFinish(t *testing.T) {
+	var m Manager
+	var last State = -1
+	m.OnChange = func(j *Job) { last = j.State() }
+	j := m.Start("icp")
+	j.Finish(nil)
+	if j.State() != Done || j.Progress() != 1 || last != Done {
+		t.Errorf("Finish(nil): state %v, progress %v, last change %v", j.State(), j.Progress(), last)
+	}
+	j.Finish(errors.New("late"))
+	if j.State() != Done || j.Err() != nil {
+		t.Errorf("second Finish changed the job")
+	}
+	j.Cancel()
+	if j.Cancelled() {
+		t.Errorf("Cancel affected a finished job")
+	}
+
+	f := m.Start("parse")
+	f.Finish(errors.New("bad header"))
+	if f.State() != Failed || f.Err() == nil || len(m.Running()) != 0 {
+		t.Errorf("Finish(err): state %v, err %v, %d running", f.State(), f.Err(), len(m.Running()))
+	}
+}
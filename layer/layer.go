@@ -0,0 +1,192 @@
+// layer/layer.go
+package layer
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/sbecker11/webgl-point-cloud/glf32"
+)
+
+// Layer is a named group of scene objects and child layers. A layer's
+// visibility, opacity and transform apply to everything below it:
+// visibility and opacity combine with those of its ancestors, and its
+// transform is applied after its descendants' ones.
+//
+// Layers are addressed by slash-separated paths such as "scans/2024"; the
+// root layer has the empty path. Each object belongs to exactly one layer,
+// the root unless moved.
+type Layer struct {
+	Name      string
+	Visible   bool
+	Opacity   float32
+	Transform glf32.Mat4
+	Objects   []string
+	Children  []*Layer
+	parent    *Layer
+}
+
+// New returns a visible, opaque layer with an identity transform.
+func New(name string) *Layer {
+	return &Layer{Name: name, Visible: true, Opacity: 1, Transform: glf32.Identity()}
+}
+
+// Parent returns the layer's parent, or nil for a root.
+func (l *Layer) Parent() *Layer {
+	return l.parent
+}
+
+// Path returns the layer's slash-separated path from the root.
+func (l *Layer) Path() string {
+	if l.parent == nil {
+		return ""
+	}
+	if p := l.parent.Path(); p != "" {
+		return p + "/" + l.Name
+	}
+	return l.Name
+}
+
+// splitPath splits a layer path into names, rejecting empty names.
+func splitPath(path string) ([]string, error) {
+	if path == "" {
+		return nil, nil
+	}
+	names := strings.Split(path, "/")
+	for _, n := range names {
+		if n == "" {
+			return nil, fmt.Errorf("invalid layer path %q", path)
+		}
+	}
+	return names, nil
+}
+
+// child returns the direct child with the given name, or nil.
+func (l *Layer) child(name string) *Layer {
+	for _, c := range l.Children {
+		if c.Name == name {
+			return c
+		}
+	}
+	return nil
+}
+
+// Find returns the layer at path below l, or nil if there is none.
+func (l *Layer) Find(path string) *Layer {
+	names, err := splitPath(path)
+	if err != nil {
+		return nil
+	}
+	for _, n := range names {
+		if l = l.child(n); l == nil {
+			return nil
+		}
+	}
+	return l
+}
+
+// Create returns the layer at path below l, creating it and any missing
+// intermediate layers.
+func (l *Layer) Create(path string) (*Layer, error) {
+	names, err := splitPath(path)
+	if err != nil {
+		return nil, err
+	}
+	for _, n := range names {
+		c := l.child(n)
+		if c == nil {
+			c = New(n)
+			c.parent = l
+			l.Children = append(l.Children, c)
+		}
+		l = c
+	}
+	return l, nil
+}
+
+// Remove deletes the layer at path below l. Its objects and child layers
+// move up to its parent. Returns an error if there is no such layer or
+// path names l itself.
+func (l *Layer) Remove(path string) error {
+	target := l.Find(path)
+	if target == nil {
+		return fmt.Errorf("no layer %q", path)
+	}
+	if target == l {
+		return fmt.Errorf("cannot remove the root layer")
+	}
+	parent := target.parent
+	for i, c := range parent.Children {
+		if c == target {
+			parent.Children = append(parent.Children[:i], parent.Children[i+1:]...)
+			break
+		}
+	}
+	for _, c := range target.Children {
+		c.parent = parent
+		parent.Children = append(parent.Children, c)
+	}
+	parent.Objects = append(parent.Objects, target.Objects...)
+	return nil
+}
+
+// Walk calls fn for l and every layer below it, parents before children.
+func (l *Layer) Walk(fn func(*Layer)) {
+	fn(l)
+	for _, c := range l.Children {
+		c.Walk(fn)
+	}
+}
+
+// LayerOf returns the layer below l holding the named object, or nil.
+func (l *Layer) LayerOf(object string) *Layer {
+	var found *Layer
+	l.Walk(func(x *Layer) {
+		for _, o := range x.Objects {
+			if o == object && found == nil {
+				found = x
+			}
+		}
+	})
+	return found
+}
+
+// RemoveObject removes the named object from whichever layer below l
+// holds it. Returns false if none does.
+func (l *Layer) RemoveObject(object string) bool {
+	x := l.LayerOf(object)
+	if x == nil {
+		return false
+	}
+	for i, o := range x.Objects {
+		if o == object {
+			x.Objects = append(x.Objects[:i], x.Objects[i+1:]...)
+			break
+		}
+	}
+	return true
+}
+
+// Move puts the named object in to, removing it from any other layer of
+// the tree rooted at l.
+func (l *Layer) Move(object string, to *Layer) {
+	l.RemoveObject(object)
+	to.Objects = append(to.Objects, object)
+}
+
+// Effective returns the visibility, opacity and transform that apply to
+// the contents of l once its ancestors' are combined with its own.
+func (l *Layer) Effective() (visible bool, opacity float32, transform glf32.Mat4) {
+	var path []*Layer
+	for p := l; p != nil; p = p.parent {
+		path = append(path, p)
+	}
+	visible, opacity = true, 1
+	var stack glf32.MatrixStack
+	for i := len(path) - 1; i >= 0; i-- {
+		visible = visible && path[i].Visible
+		opacity *= path[i].Opacity
+		stack.MultMatrix(path[i].Transform)
+	}
+	return visible, opacity, stack.Top()
+}
@@ -0,0 +1,80 @@
+// layer/layer_test.go
+// usage: go test
+
+package layer
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/sbecker11/webgl-point-cloud/glf32"
+)
+
+func TestCreateFindPath(t *testing.T) {
+	root := New("")
+	l, err := root.Create("scans/2024/north")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if l.Path() != "scans/2024/north" {
+		t.Errorf("Path: expected scans/2024/north, got %q", l.Path())
+	}
+	if root.Find("scans/2024") != l.Parent() {
+		t.Error("Find: expected the intermediate layer created by Create")
+	}
+	if again, _ := root.Create("scans/2024/north"); again != l {
+		t.Error("Create: expected the existing layer")
+	}
+	if root.Find("scans/2023") != nil || root.Find("scans//north") != nil {
+		t.Error("Find: expected nil for missing or malformed paths")
+	}
+	if _, err := root.Create("a//b"); err == nil {
+		t.Error("Create: expected an error for an empty layer name")
+	}
+}
+
+func TestMoveAndRemove(t *testing.T) {
+	root := New("")
+	root.Objects = []string{"a", "b"}
+	scans, _ := root.Create("scans")
+	inner, _ := root.Create("scans/inner")
+	root.Move("a", inner)
+	if root.LayerOf("a") != inner || !reflect.DeepEqual(root.Objects, []string{"b"}) {
+		t.Fatalf("Move: expected a in scans/inner, root objects %v", root.Objects)
+	}
+	if err := root.Remove("scans"); err != nil {
+		t.Fatal(err)
+	}
+	// The removed layer's children move up to the root.
+	if root.Find("inner") != inner || root.Find("scans") != nil || scans.Parent() != root {
+		t.Errorf("Remove: expected inner to move to the root, got %v", root.Children)
+	}
+	if err := root.Remove(""); err == nil {
+		t.Error("Remove: expected an error for the root layer")
+	}
+	if !root.RemoveObject("a") || root.LayerOf("a") != nil {
+		t.Error("RemoveObject: expected a to be removed")
+	}
+}
+
+func TestEffective(t *testing.T) {
+	root := New("")
+	parent, _ := root.Create("p")
+	child, _ := root.Create("p/c")
+	parent.Opacity = 0.5
+	parent.Transform = glf32.Translate(1, 0, 0)
+	child.Opacity = 0.5
+	child.Transform = glf32.Translate(0, 2, 0)
+
+	visible, opacity, m := child.Effective()
+	if !visible || opacity != 0.25 {
+		t.Errorf("Effective: expected visible with opacity 0.25, got %v %f", visible, opacity)
+	}
+	if m[12] != 1 || m[13] != 2 {
+		t.Errorf("Effective: expected translation (1, 2, 0), got (%f, %f, %f)", m[12], m[13], m[14])
+	}
+	parent.Visible = false
+	if visible, _, _ := child.Effective(); visible {
+		t.Error("Effective: a hidden parent should hide its children")
+	}
+}
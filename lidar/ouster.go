@@ -0,0 +1,147 @@
+// lidar/ouster.go
+package lidar
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"math"
+)
+
+// Layout of an Ouster lidar packet in the legacy format: columns of a
+// 16-byte header (timestamp, measurement id, frame id, encoder count),
+// 12 bytes per pixel (range in mm in the low 20 bits, reflectivity,
+// signal, near-infrared and padding) and a 4-byte status, all
+// little-endian.
+const (
+	ousterColumnHeader = 16
+	ousterPixelSize    = 12
+	ousterColumnFooter = 4
+	ousterValidColumn  = 0xffffffff
+	ousterRangeMask    = 0xfffff
+)
+
+// OusterMetadata is the part of an Ouster sensor's metadata, as its HTTP
+// API and the Ouster SDK save it as JSON, needed to decode its packets.
+type OusterMetadata struct {
+	BeamAltitudeAngles        []float64 // degrees, per pixel of a column
+	BeamAzimuthAngles         []float64 // degrees, per pixel of a column
+	LidarOriginToBeamOriginMM float64
+	ColumnsPerFrame           int
+	ColumnsPerPacket          int
+}
+
+// ParseOusterMetadata reads the metadata JSON of an Ouster sensor, in
+// either the flat layout of older firmware or the sectioned one of
+// firmware 2.x and later.
+func ParseOusterMetadata(data []byte) (OusterMetadata, error) {
+	type intrinsics struct {
+		BeamAltitudeAngles        []float64 `json:"beam_altitude_angles"`
+		BeamAzimuthAngles         []float64 `json:"beam_azimuth_angles"`
+		LidarOriginToBeamOriginMM float64   `json:"lidar_origin_to_beam_origin_mm"`
+	}
+	type format struct {
+		ColumnsPerFrame  int `json:"columns_per_frame"`
+		ColumnsPerPacket int `json:"columns_per_packet"`
+	}
+	var raw struct {
+		intrinsics
+		DataFormat      *format     `json:"data_format"`
+		LidarDataFormat *format     `json:"lidar_data_format"`
+		BeamIntrinsics  *intrinsics `json:"beam_intrinsics"`
+		LidarMode       string      `json:"lidar_mode"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return OusterMetadata{}, fmt.Errorf("lidar: Ouster metadata: %v", err)
+	}
+	beams := raw.intrinsics
+	if raw.BeamIntrinsics != nil {
+		beams = *raw.BeamIntrinsics
+	}
+	f := format{ColumnsPerPacket: 16}
+	if raw.LidarDataFormat != nil {
+		f = *raw.LidarDataFormat
+	} else if raw.DataFormat != nil {
+		f = *raw.DataFormat
+	}
+	if f.ColumnsPerFrame == 0 {
+		// lidar_mode is e.g. "1024x10": columns per frame x frames per second.
+		fmt.Sscanf(raw.LidarMode, "%dx", &f.ColumnsPerFrame)
+	}
+	m := OusterMetadata{
+		BeamAltitudeAngles:        beams.BeamAltitudeAngles,
+		BeamAzimuthAngles:         beams.BeamAzimuthAngles,
+		LidarOriginToBeamOriginMM: beams.LidarOriginToBeamOriginMM,
+		ColumnsPerFrame:           f.ColumnsPerFrame,
+		ColumnsPerPacket:          max(1, f.ColumnsPerPacket),
+	}
+	if len(m.BeamAltitudeAngles) == 0 || len(m.BeamAltitudeAngles) != len(m.BeamAzimuthAngles) {
+		return m, fmt.Errorf("lidar: Ouster metadata needs beam_altitude_angles and beam_azimuth_angles for every beam")
+	}
+	if m.ColumnsPerFrame <= 0 {
+		return m, fmt.Errorf("lidar: Ouster metadata has no columns per frame")
+	}
+	return m, nil
+}
+
+// Ouster decodes Ouster lidar packets in the legacy format. A scan is
+// complete when the frame id changes.
+type Ouster struct {
+	meta     OusterMetadata
+	sinAlt   []float64
+	cosAlt   []float64
+	azimuth  []float64 // radians, per beam
+	scan     *Scan
+	frame    int // of the scan being gathered; -1 before the first
+	colBytes int
+}
+
+// NewOuster returns a decoder for a sensor with metadata m.
+func NewOuster(m OusterMetadata) *Ouster {
+	o := &Ouster{meta: m, scan: &Scan{}, frame: -1}
+	for i, alt := range m.BeamAltitudeAngles {
+		o.sinAlt = append(o.sinAlt, math.Sin(alt*math.Pi/180))
+		o.cosAlt = append(o.cosAlt, math.Cos(alt*math.Pi/180))
+		o.azimuth = append(o.azimuth, -m.BeamAzimuthAngles[i]*math.Pi/180)
+	}
+	o.colBytes = ousterColumnHeader + ousterPixelSize*len(m.BeamAltitudeAngles) + ousterColumnFooter
+	return o
+}
+
+// Decode implements Decoder, with the sensor's xyz formula: each beam
+// starts LidarOriginToBeamOriginMM out from the axis at the column's
+// encoder angle and points at its altitude and azimuth angles. Columns
+// marked invalid and pixels with no range are left out.
+func (o *Ouster) Decode(packet []byte) (*Scan, error) {
+	if len(packet) != o.meta.ColumnsPerPacket*o.colBytes {
+		return nil, fmt.Errorf("lidar: Ouster packet of %d bytes, expected %d", len(packet), o.meta.ColumnsPerPacket*o.colBytes)
+	}
+	n := o.meta.LidarOriginToBeamOriginMM / 1000
+	var done *Scan
+	for c := 0; c < o.meta.ColumnsPerPacket; c++ {
+		col := packet[c*o.colBytes : (c+1)*o.colBytes]
+		if binary.LittleEndian.Uint32(col[len(col)-ousterColumnFooter:]) != ousterValidColumn {
+			continue
+		}
+		measurement := int(binary.LittleEndian.Uint16(col[8:]))
+		frame := int(binary.LittleEndian.Uint16(col[10:]))
+		if o.frame >= 0 && frame != o.frame {
+			done, o.scan = o.scan, &Scan{}
+		}
+		o.frame = frame
+		encoder := 2 * math.Pi * (1 - float64(measurement)/float64(o.meta.ColumnsPerFrame))
+		sinEnc, cosEnc := math.Sincos(encoder)
+		for p := range o.sinAlt {
+			px := col[ousterColumnHeader+p*ousterPixelSize:]
+			r := float64(binary.LittleEndian.Uint32(px)&ousterRangeMask) / 1000
+			if r == 0 {
+				continue
+			}
+			sin, cos := math.Sincos(encoder + o.azimuth[p])
+			d := (r - n) * o.cosAlt[p]
+			reflectivity := binary.LittleEndian.Uint16(px[4:])
+			o.scan.add(d*cos+n*cosEnc, d*sin+n*sinEnc, (r-n)*o.sinAlt[p], uint8(min(255, reflectivity)))
+		}
+	}
+	return done, nil
+}
@@ -0,0 +1,93 @@
+// lidar/ouster_test.go
+// usage: go test
+
+package lidar
+
+import (
+	"encoding/binary"
+	"math"
+	"testing"
+)
+
+// ousterMetadata is a two-beam sensor in the sectioned metadata layout.
+const ousterMetadata = `{
+	"beam_intrinsics": {
+		"beam_altitude_angles": [10, -10],
+		"beam_azimuth_angles": [0, 2],
+		"lidar_origin_to_beam_origin_mm": 15
+	},
+	"lidar_data_format": {"columns_per_frame": 1024, "columns_per_packet": 4, "pixels_per_column": 2}
+}`
+
+// ousterColumn sets column c of packet p.
+func ousterColumn(p []byte, colBytes, c, measurement, frame int, ranges []uint32, reflectivity uint16, valid bool) {
+	col := p[c*colBytes:]
+	binary.LittleEndian.PutUint16(col[8:], uint16(measurement))
+	binary.LittleEndian.PutUint16(col[10:], uint16(frame))
+	for i, r := range ranges {
+		px := col[ousterColumnHeader+i*ousterPixelSize:]
+		binary.LittleEndian.PutUint32(px, r)
+		binary.LittleEndian.PutUint16(px[4:], reflectivity)
+	}
+	if valid {
+		binary.LittleEndian.PutUint32(col[colBytes-ousterColumnFooter:], ousterValidColumn)
+	}
+}
+
+func TestOuster(t *testing.T) {
+	m, err := ParseOusterMetadata([]byte(ousterMetadata))
+	if err != nil {
+		t.Fatalf("ParseOusterMetadata: %v", err)
+	}
+	o := NewOuster(m)
+	colBytes := ousterColumnHeader + 2*ousterPixelSize + ousterColumnFooter
+	p := make([]byte, 4*colBytes)
+	ousterColumn(p, colBytes, 0, 0, 5, []uint32{2000, 0}, 300, true)
+	ousterColumn(p, colBytes, 1, 1, 5, []uint32{1000, 1000}, 1, false)
+	ousterColumn(p, colBytes, 2, 256, 5, []uint32{0, 3000 | 0xf00000}, 20, true)
+	if scan, err := o.Decode(p); err != nil || scan != nil {
+		t.Fatalf("expected no scan yet, got %v, %v", scan, err)
+	}
+	next := make([]byte, 4*colBytes)
+	ousterColumn(next, colBytes, 0, 0, 6, []uint32{1, 1}, 0, true)
+	scan, err := o.Decode(next)
+	if err != nil || scan == nil {
+		t.Fatalf("expected the scan to complete on the next frame, got %v", err)
+	}
+	if scan.Len() != 2 {
+		t.Fatalf("expected 2 points, got %d", scan.Len())
+	}
+
+	rad := math.Pi / 180
+	// Beam 0 at encoder angle 0, straight ahead.
+	d := (2 - 0.015) * math.Cos(10*rad)
+	if !near(scan.Coords[0], d+0.015) || !near(scan.Coords[1], 0) || !near(scan.Coords[2], (2-0.015)*math.Sin(10*rad)) {
+		t.Errorf("unexpected first point %v", scan.Coords[:3])
+	}
+	// Beam 1 a quarter turn on, to the right, 2° further clockwise; the
+	// range's upper bits are not part of it.
+	enc, az := 270*rad, -2*rad
+	d = (3 - 0.015) * math.Cos(-10*rad)
+	if !near(scan.Coords[3], d*math.Cos(enc+az)+0.015*math.Cos(enc)) || !near(scan.Coords[4], d*math.Sin(enc+az)+0.015*math.Sin(enc)) {
+		t.Errorf("unexpected second point %v", scan.Coords[3:])
+	}
+	if scan.Reflectivity[0] != 255 || scan.Reflectivity[1] != 20 {
+		t.Errorf("expected reflectivity clamped to 255, then 20, got %v", scan.Reflectivity)
+	}
+	if _, err := o.Decode(p[:10]); err == nil {
+		t.Error("expected an error for a short packet")
+	}
+}
+
+func TestParseOusterMetadataLegacy(t *testing.T) {
+	m, err := ParseOusterMetadata([]byte(`{"beam_altitude_angles": [1], "beam_azimuth_angles": [0], "lidar_mode": "2048x10"}`))
+	if err != nil || m.ColumnsPerFrame != 2048 || m.ColumnsPerPacket != 16 {
+		t.Errorf("expected 2048 columns, 16 a packet, got %+v, %v", m, err)
+	}
+	if _, err := ParseOusterMetadata([]byte(`{"lidar_mode": "1024x10"}`)); err == nil {
+		t.Error("expected an error without beam angles")
+	}
+	if _, err := NewDecoder("hesai", nil); err == nil {
+		t.Error("expected an error for an unknown model")
+	}
+}
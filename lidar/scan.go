@@ -0,0 +1,58 @@
+// lidar/scan.go
+// Package lidar decodes the UDP data packets of spinning LiDAR sensors
+// (Velodyne VLP-16 and similar, Ouster OS series) into Cartesian points,
+// gathering them into a scan per sensor rotation, for live capture
+// without ROS. Coordinates are in metres in the sensor's frame: x forward,
+// y left, z up.
+package lidar
+
+import "fmt"
+
+// Scan is a sensor rotation's points: packed xyz coordinates and a
+// reflectivity per point in 0-255.
+type Scan struct {
+	Coords       []float32
+	Reflectivity []uint8
+}
+
+// Len returns the number of points in the scan.
+func (s *Scan) Len() int {
+	return len(s.Coords) / 3
+}
+
+func (s *Scan) add(x, y, z float64, reflectivity uint8) {
+	s.Coords = append(s.Coords, float32(x), float32(y), float32(z))
+	s.Reflectivity = append(s.Reflectivity, reflectivity)
+}
+
+// Decoder turns a sensor's data packets into scans.
+type Decoder interface {
+	// Decode decodes a packet's points. It returns the scan they
+	// complete, once the sensor has turned a full rotation, or nil.
+	Decode(packet []byte) (*Scan, error)
+}
+
+// NewDecoder returns a decoder for a sensor model: "vlp16" (Velodyne
+// VLP-16 with its published calibration), "velodyne" (another Velodyne
+// sensor with 16 or 32 lasers, with calibration read from a JSON
+// Calibration) or "ouster" (with calibration read from the sensor's JSON
+// metadata). calibration holds the JSON, or nil for "vlp16".
+func NewDecoder(model string, calibration []byte) (Decoder, error) {
+	switch model {
+	case "vlp16":
+		return NewVelodyne(VLP16), nil
+	case "velodyne":
+		c, err := ParseCalibration(calibration)
+		if err != nil {
+			return nil, err
+		}
+		return NewVelodyne(c), nil
+	case "ouster":
+		m, err := ParseOusterMetadata(calibration)
+		if err != nil {
+			return nil, err
+		}
+		return NewOuster(m), nil
+	}
+	return nil, fmt.Errorf("lidar: unknown model %q; expected vlp16, velodyne or ouster", model)
+}
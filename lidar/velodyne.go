@@ -0,0 +1,132 @@
+// lidar/velodyne.go
+package lidar
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"math"
+)
+
+// Layout of a Velodyne data packet's 1206-byte UDP payload: 12 blocks of a
+// flag, an azimuth in hundredths of a degree and 32 returns of a distance
+// in 2 mm units and a reflectivity, then a timestamp and two factory
+// bytes.
+const (
+	velodynePacketSize = 1206
+	velodyneBlocks     = 12
+	velodyneBlockSize  = 100
+	velodyneReturns    = 32
+	velodyneBlockFlag  = 0xeeff
+	velodyneDistance   = 0.002 // metres per distance unit
+)
+
+// Calibration describes a Velodyne sensor's lasers, in the order their
+// returns appear in a block: the elevation of each in degrees and,
+// optionally, the offset of its azimuth in degrees and its vertical offset
+// from the sensor's origin in metres. Sensors with 16 lasers fire them
+// twice per block, those with 32 once.
+type Calibration struct {
+	Elevation      []float64 `json:"elevation"`
+	AzimuthOffset  []float64 `json:"azimuthOffset,omitempty"`
+	VerticalOffset []float64 `json:"verticalOffset,omitempty"`
+}
+
+// VLP16 is the Velodyne VLP-16's calibration from its user manual.
+var VLP16 = Calibration{
+	Elevation: []float64{-15, 1, -13, 3, -11, 5, -9, 7, -7, 9, -5, 11, -3, 13, -1, 15},
+	VerticalOffset: []float64{
+		0.0112, -0.0007, 0.0097, -0.0022, 0.0081, -0.0037, 0.0066, -0.0051,
+		0.0051, -0.0066, 0.0037, -0.0081, 0.0022, -0.0097, 0.0007, -0.0112,
+	},
+}
+
+// ParseCalibration reads a Calibration from JSON, e.g.
+// {"elevation": [-15, 1, ...], "verticalOffset": [0.0112, ...]}.
+func ParseCalibration(data []byte) (Calibration, error) {
+	var c Calibration
+	if err := json.Unmarshal(data, &c); err != nil {
+		return c, fmt.Errorf("lidar: calibration: %v", err)
+	}
+	if n := len(c.Elevation); n != 16 && n != 32 {
+		return c, fmt.Errorf("lidar: calibration has %d lasers, expected 16 or 32", n)
+	}
+	if len(c.AzimuthOffset) > 0 && len(c.AzimuthOffset) != len(c.Elevation) || len(c.VerticalOffset) > 0 && len(c.VerticalOffset) != len(c.Elevation) {
+		return c, fmt.Errorf("lidar: calibration offsets do not match its %d lasers", len(c.Elevation))
+	}
+	return c, nil
+}
+
+// Velodyne decodes Velodyne data packets. A scan is complete when the
+// azimuth wraps past 0°, which is straight ahead.
+type Velodyne struct {
+	cal       Calibration
+	sin, cos  []float64 // of each laser's elevation
+	scan      *Scan
+	azimuth   float64 // of the last block, in degrees; -1 before the first
+	blockStep float64 // degrees between blocks, for the last block's second firing
+}
+
+// NewVelodyne returns a decoder for a sensor with calibration c.
+func NewVelodyne(c Calibration) *Velodyne {
+	v := &Velodyne{cal: c, scan: &Scan{}, azimuth: -1}
+	for _, e := range c.Elevation {
+		v.sin = append(v.sin, math.Sin(e*math.Pi/180))
+		v.cos = append(v.cos, math.Cos(e*math.Pi/180))
+	}
+	return v
+}
+
+// Decode implements Decoder. Points are placed at their block's azimuth,
+// and those of a 16-laser sensor's second firing halfway to the next
+// block's, ignoring the few microseconds between lasers. Returns with no
+// distance are left out.
+func (v *Velodyne) Decode(packet []byte) (*Scan, error) {
+	if len(packet) != velodynePacketSize {
+		return nil, fmt.Errorf("lidar: Velodyne packet of %d bytes, expected %d", len(packet), velodynePacketSize)
+	}
+	lasers := len(v.cal.Elevation)
+	var done *Scan
+	for b := 0; b < velodyneBlocks; b++ {
+		block := packet[b*velodyneBlockSize:]
+		if binary.LittleEndian.Uint16(block) != velodyneBlockFlag {
+			return done, fmt.Errorf("lidar: Velodyne block %d has no flag", b)
+		}
+		azimuth := float64(binary.LittleEndian.Uint16(block[2:])) / 100
+		if b+1 < velodyneBlocks {
+			next := float64(binary.LittleEndian.Uint16(packet[(b+1)*velodyneBlockSize+2:])) / 100
+			// Dual-return packets repeat each azimuth; keep the last step.
+			if step := math.Mod(next-azimuth+360, 360); step > 0 {
+				v.blockStep = step
+			}
+		}
+		if v.azimuth >= 0 && azimuth < v.azimuth {
+			done, v.scan = v.scan, &Scan{}
+		}
+		v.azimuth = azimuth
+		for r := 0; r < velodyneReturns; r++ {
+			ret := block[4+r*3:]
+			distance := float64(binary.LittleEndian.Uint16(ret)) * velodyneDistance
+			if distance == 0 {
+				continue
+			}
+			laser := r % lasers
+			a := azimuth
+			if r >= lasers {
+				a += v.blockStep / 2
+			}
+			if len(v.cal.AzimuthOffset) > 0 {
+				a += v.cal.AzimuthOffset[laser]
+			}
+			// Azimuth runs clockwise seen from above, from straight ahead.
+			sin, cos := math.Sincos(a * math.Pi / 180)
+			horizontal := distance * v.cos[laser]
+			z := distance * v.sin[laser]
+			if len(v.cal.VerticalOffset) > 0 {
+				z += v.cal.VerticalOffset[laser]
+			}
+			v.scan.add(horizontal*cos, -horizontal*sin, z, ret[2])
+		}
+	}
+	return done, nil
+}
@@ -0,0 +1,91 @@
+// lidar/velodyne_test.go
+// usage: go test
+
+package lidar
+
+import (
+	"encoding/binary"
+	"math"
+	"testing"
+)
+
+func near(a float32, b float64) bool {
+	return math.Abs(float64(a)-b) < 1e-5
+}
+
+// velodynePacket returns a packet whose blocks start at azimuth start,
+// step degrees apart, with returns set as ret[block][return] = {distance
+// in metres, reflectivity}.
+func velodynePacket(start, step float64, returns map[[2]int][2]float64) []byte {
+	p := make([]byte, velodynePacketSize)
+	for b := 0; b < velodyneBlocks; b++ {
+		block := p[b*velodyneBlockSize:]
+		binary.LittleEndian.PutUint16(block, velodyneBlockFlag)
+		binary.LittleEndian.PutUint16(block[2:], uint16(math.Round(math.Mod(start+float64(b)*step, 360)*100)))
+	}
+	for at, ret := range returns {
+		r := p[at[0]*velodyneBlockSize+4+at[1]*3:]
+		binary.LittleEndian.PutUint16(r, uint16(math.Round(ret[0]/velodyneDistance)))
+		r[2] = uint8(ret[1])
+	}
+	return p
+}
+
+func TestVelodyne(t *testing.T) {
+	v := NewVelodyne(VLP16)
+	scan, err := v.Decode(velodynePacket(350, 0.4, map[[2]int][2]float64{
+		{0, 1}:  {10, 77}, // laser 1, elevation 1°, at 350°
+		{1, 18}: {5, 9},   // laser 2, elevation -13°, second firing at 350.6°
+	}))
+	if err != nil || scan != nil {
+		t.Fatalf("expected no scan yet, got %v, %v", scan, err)
+	}
+	scan, err = v.Decode(velodynePacket(0, 0.4, map[[2]int][2]float64{{0, 0}: {1, 0}}))
+	if err != nil || scan == nil {
+		t.Fatalf("expected the scan to complete when the azimuth wraps, got %v", err)
+	}
+	if scan.Len() != 2 {
+		t.Fatalf("expected 2 points, got %d", scan.Len())
+	}
+	rad := math.Pi / 180
+	h := 10 * math.Cos(1*rad)
+	if !near(scan.Coords[0], h*math.Cos(350*rad)) || !near(scan.Coords[1], -h*math.Sin(350*rad)) || !near(scan.Coords[2], 10*math.Sin(1*rad)-0.0007) {
+		t.Errorf("unexpected first point %v", scan.Coords[:3])
+	}
+	h = 5 * math.Cos(-13*rad)
+	if !near(scan.Coords[3], h*math.Cos(350.6*rad)) || !near(scan.Coords[4], -h*math.Sin(350.6*rad)) || !near(scan.Coords[5], 5*math.Sin(-13*rad)+0.0097) {
+		t.Errorf("unexpected second point %v", scan.Coords[3:])
+	}
+	if scan.Reflectivity[0] != 77 || scan.Reflectivity[1] != 9 {
+		t.Errorf("unexpected reflectivity %v", scan.Reflectivity)
+	}
+
+	if _, err := v.Decode(make([]byte, 100)); err == nil {
+		t.Error("expected an error for a short packet")
+	}
+	if _, err := v.Decode(make([]byte, velodynePacketSize)); err == nil {
+		t.Error("expected an error for blocks without flags")
+	}
+}
+
+func TestParseCalibration(t *testing.T) {
+	elevations := `[-30.67,-9.33,-29.33,-8,-28,-6.67,-26.67,-5.33,-25.33,-4,-24,-2.67,-22.67,-1.33,-21.33,0,-20,1.33,-18.67,2.67,-17.33,4,-16,5.33,-14.67,6.67,-13.33,8,-12,9.33,-10.67,10.67]`
+	c, err := ParseCalibration([]byte(`{"elevation": ` + elevations + `}`))
+	if err != nil || len(c.Elevation) != 32 {
+		t.Fatalf("expected 32 lasers, got %v, %v", c, err)
+	}
+	if _, err := ParseCalibration([]byte(`{"elevation": [1, 2, 3]}`)); err == nil {
+		t.Error("expected an error for 3 lasers")
+	}
+	if _, err := ParseCalibration([]byte(`{"elevation": ` + elevations + `, "verticalOffset": [0]}`)); err == nil {
+		t.Error("expected an error for mismatched offsets")
+	}
+
+	// A 32-laser sensor fires every laser once per block.
+	v := NewVelodyne(c)
+	v.Decode(velodynePacket(10, 0.2, map[[2]int][2]float64{{0, 31}: {2, 1}}))
+	scan, _ := v.Decode(velodynePacket(0, 0.2, nil))
+	if scan == nil || scan.Len() != 1 || !near(scan.Coords[2], 2*math.Sin(10.67*math.Pi/180)) {
+		t.Errorf("expected laser 31 at 10.67°, got %v", scan)
+	}
+}
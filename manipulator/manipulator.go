@@ -0,0 +1,257 @@
+// manipulator/manipulator.go
+// Package manipulator implements the geometry of an interactive transform
+// gizmo: picking its handles with view rays and turning drags of a handle
+// into translations, rotations and scalings constrained to one axis.
+package manipulator
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/sbecker11/webgl-point-cloud/glf32"
+)
+
+// Mode is what dragging a handle does.
+type Mode int
+
+const (
+	Translate Mode = iota // move along the axis
+	Rotate                // turn about the axis
+	Scale                 // stretch along the axis
+)
+
+var modeNames = []string{"translate", "rotate", "scale"}
+
+func (m Mode) String() string {
+	if m >= 0 && int(m) < len(modeNames) {
+		return modeNames[m]
+	}
+	return fmt.Sprintf("Mode(%d)", int(m))
+}
+
+// ParseMode returns the mode named s.
+func ParseMode(s string) (Mode, error) {
+	for i, name := range modeNames {
+		if s == name {
+			return Mode(i), nil
+		}
+	}
+	return 0, fmt.Errorf("manipulator: unknown mode %q", s)
+}
+
+// Ray is a half-line from Origin in direction Dir, which need not be unit
+// length.
+type Ray struct {
+	Origin, Dir [3]float64
+}
+
+// Manipulator is a gizmo at Center with one handle per axis, each Size
+// long: an arrow when translating, a ring of radius Size when rotating and
+// a bar ending in a square when scaling. Axes must be unit length.
+type Manipulator struct {
+	Mode   Mode
+	Center [3]float64
+	Axes   [3][3]float64
+	Size   float64
+}
+
+// tolerance is how near, as a fraction of Size, a ray must pass to a
+// handle to pick it.
+const tolerance = 0.08
+
+// Pick returns the axis of the handle r passes nearest, within the
+// picking tolerance, or -1 if it misses them all.
+func (m Manipulator) Pick(r Ray) int {
+	best, bestDist := -1, tolerance*m.Size
+	for i, a := range m.Axes {
+		var d float64
+		if m.Mode == Rotate {
+			p, ok := planeHit(r, m.Center, a)
+			if !ok {
+				continue
+			}
+			d = math.Abs(length(sub(p, m.Center)) - m.Size)
+		} else {
+			s, u, ok := closest(r, m.Center, a)
+			if !ok || u < 0 {
+				continue
+			}
+			s = math.Max(0, math.Min(m.Size, s))
+			d = length(sub(add(m.Center, scale(a, s)), add(r.Origin, scale(r.Dir, u))))
+		}
+		if d <= bestDist {
+			best, bestDist = i, d
+		}
+	}
+	return best
+}
+
+// Drag returns the world transform that dragging the handle of axis from
+// where ray from meets it to where ray to does applies, and whether the
+// rays meet it at all.
+func (m Manipulator) Drag(axis int, from, to Ray) (glf32.Mat4, bool) {
+	a := m.Axes[axis]
+	switch m.Mode {
+	case Translate:
+		s0, _, ok0 := closest(from, m.Center, a)
+		s1, _, ok1 := closest(to, m.Center, a)
+		if !ok0 || !ok1 {
+			return nil, false
+		}
+		t := scale(a, s1-s0)
+		return glf32.Translate(float32(t[0]), float32(t[1]), float32(t[2])), true
+	case Rotate:
+		p0, ok0 := planeHit(from, m.Center, a)
+		p1, ok1 := planeHit(to, m.Center, a)
+		if !ok0 || !ok1 {
+			return nil, false
+		}
+		v0, v1 := sub(p0, m.Center), sub(p1, m.Center)
+		angle := math.Atan2(dot(a, cross(v0, v1)), dot(v0, v1))
+		return m.about(rotation(a, angle)), true
+	case Scale:
+		s0, _, ok0 := closest(from, m.Center, a)
+		s1, _, ok1 := closest(to, m.Center, a)
+		if !ok0 || !ok1 || math.Abs(s0) < 1e-9 {
+			return nil, false
+		}
+		k := math.Max(s1/s0, 1e-3)
+		var l [3][3]float64
+		for r := 0; r < 3; r++ {
+			for c := 0; c < 3; c++ {
+				l[r][c] = (k - 1) * a[r] * a[c]
+			}
+			l[r][r]++
+		}
+		return m.about(l), true
+	}
+	return nil, false
+}
+
+// about returns the matrix applying linear map l about the center.
+func (m Manipulator) about(l [3][3]float64) glf32.Mat4 {
+	out := glf32.Identity()
+	for r := 0; r < 3; r++ {
+		t := m.Center[r]
+		for c := 0; c < 3; c++ {
+			out[c*4+r] = float32(l[r][c])
+			t -= l[r][c] * m.Center[c]
+		}
+		out[12+r] = float32(t)
+	}
+	return out
+}
+
+// rotation returns the matrix turning by angle radians about unit axis a,
+// counterclockwise seen from the tip of a.
+func rotation(a [3]float64, angle float64) [3][3]float64 {
+	c, s := math.Cos(angle), math.Sin(angle)
+	var r [3][3]float64
+	for i := 0; i < 3; i++ {
+		for j := 0; j < 3; j++ {
+			r[i][j] = (1 - c) * a[i] * a[j]
+		}
+		r[i][i] += c
+	}
+	r[0][1] -= s * a[2]
+	r[0][2] += s * a[1]
+	r[1][0] += s * a[2]
+	r[1][2] -= s * a[0]
+	r[2][0] -= s * a[1]
+	r[2][1] += s * a[0]
+	return r
+}
+
+// Lines returns the handles as line segments, packed xyz positions in
+// pairs with an RGBA color per position. Axes are colored red, green and
+// blue, except the highlighted one, which is yellow; pass -1 for none.
+func (m Manipulator) Lines(highlight int) (positions, colors []float32) {
+	axisColors := [3][4]float32{{0.95, 0.3, 0.3, 1}, {0.3, 0.9, 0.3, 1}, {0.35, 0.55, 1, 1}}
+	for i, a := range m.Axes {
+		color := axisColors[i]
+		if i == highlight {
+			color = [4]float32{1, 0.9, 0.2, 1}
+		}
+		// u and v span the plane across the axis.
+		u, v := m.Axes[(i+1)%3], m.Axes[(i+2)%3]
+		var segs [][2][3]float64
+		tip := add(m.Center, scale(a, m.Size))
+		switch m.Mode {
+		case Translate:
+			back := add(m.Center, scale(a, m.Size*0.85))
+			segs = append(segs, [2][3]float64{m.Center, tip})
+			for _, w := range [][3]float64{u, v, scale(u, -1), scale(v, -1)} {
+				segs = append(segs, [2][3]float64{tip, add(back, scale(w, m.Size*0.06))})
+			}
+		case Rotate:
+			const n = 48
+			for k := 0; k < n; k++ {
+				p := func(k int) [3]float64 {
+					t := 2 * math.Pi * float64(k) / n
+					return add(m.Center, add(scale(u, m.Size*math.Cos(t)), scale(v, m.Size*math.Sin(t))))
+				}
+				segs = append(segs, [2][3]float64{p(k), p(k + 1)})
+			}
+		case Scale:
+			segs = append(segs, [2][3]float64{m.Center, tip})
+			h := m.Size * 0.06
+			corners := [][3]float64{
+				add(tip, add(scale(u, h), scale(v, h))), add(tip, add(scale(u, -h), scale(v, h))),
+				add(tip, add(scale(u, -h), scale(v, -h))), add(tip, add(scale(u, h), scale(v, -h))),
+			}
+			for k := range corners {
+				segs = append(segs, [2][3]float64{corners[k], corners[(k+1)%4]})
+			}
+		}
+		for _, s := range segs {
+			for _, p := range s {
+				positions = append(positions, float32(p[0]), float32(p[1]), float32(p[2]))
+				colors = append(colors, color[:]...)
+			}
+		}
+	}
+	return positions, colors
+}
+
+// closest returns the parameters s along the line through c in unit
+// direction a and u along r of the points where they pass nearest each
+// other, and false if they are parallel.
+func closest(r Ray, c, a [3]float64) (s, u float64, ok bool) {
+	w := sub(c, r.Origin)
+	b, dd := dot(a, r.Dir), dot(r.Dir, r.Dir)
+	denom := dd - b*b
+	if denom < 1e-12*dd {
+		return 0, 0, false
+	}
+	e, f := dot(a, w), dot(r.Dir, w)
+	s = (b*f - dd*e) / denom
+	u = (f - b*e) / denom
+	return s, u, true
+}
+
+// planeHit returns where r meets the plane through c with normal n, and
+// false if it runs parallel to the plane or points away from it.
+func planeHit(r Ray, c, n [3]float64) ([3]float64, bool) {
+	d := dot(n, r.Dir)
+	if math.Abs(d) < 1e-9 {
+		return [3]float64{}, false
+	}
+	t := dot(n, sub(c, r.Origin)) / d
+	if t < 0 {
+		return [3]float64{}, false
+	}
+	return add(r.Origin, scale(r.Dir, t)), true
+}
+
+func add(a, b [3]float64) [3]float64 { return [3]float64{a[0] + b[0], a[1] + b[1], a[2] + b[2]} }
+func sub(a, b [3]float64) [3]float64 { return [3]float64{a[0] - b[0], a[1] - b[1], a[2] - b[2]} }
+func dot(a, b [3]float64) float64    { return a[0]*b[0] + a[1]*b[1] + a[2]*b[2] }
+func length(a [3]float64) float64    { return math.Sqrt(dot(a, a)) }
+
+func scale(a [3]float64, s float64) [3]float64 {
+	return [3]float64{a[0] * s, a[1] * s, a[2] * s}
+}
+
+func cross(a, b [3]float64) [3]float64 {
+	return [3]float64{a[1]*b[2] - a[2]*b[1], a[2]*b[0] - a[0]*b[2], a[0]*b[1] - a[1]*b[0]}
+}
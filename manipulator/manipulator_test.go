@@ -0,0 +1,102 @@
+// manipulator/manipulator_test.go
+// usage: go test
+
+package manipulator
+
+import (
+	"math"
+	"testing"
+
+	"github.com/sbecker11/webgl-point-cloud/glf32"
+)
+
+func gizmo(mode Mode) Manipulator {
+	return Manipulator{
+		Mode:   mode,
+		Center: [3]float64{1, 0, 0},
+		Axes:   [3][3]float64{{1, 0, 0}, {0, 1, 0}, {0, 0, 1}},
+		Size:   1,
+	}
+}
+
+// down returns a ray pointing straight down at (x, z).
+func down(x, z float64) Ray {
+	return Ray{Origin: [3]float64{x, 10, z}, Dir: [3]float64{0, -1, 0}}
+}
+
+// apply returns p transformed by m.
+func apply(m glf32.Mat4, p [3]float32) [3]float32 {
+	out := glf32.TransformVertices([]float32{p[0], p[1], p[2]}, m)
+	return [3]float32{out[0], out[1], out[2]}
+}
+
+func near(a, b [3]float32) bool {
+	for k := range a {
+		if math.Abs(float64(a[k]-b[k])) > 1e-4 {
+			return false
+		}
+	}
+	return true
+}
+
+func TestPick(t *testing.T) {
+	g := gizmo(Translate)
+	if axis := g.Pick(down(1.5, 0.02)); axis != 0 {
+		t.Errorf("expected the x handle, got %d", axis)
+	}
+	if axis := g.Pick(down(1.01, 0.5)); axis != 2 {
+		t.Errorf("expected the z handle, got %d", axis)
+	}
+	if axis := g.Pick(down(3, 0)); axis != -1 {
+		t.Errorf("expected a miss beyond the handle, got %d", axis)
+	}
+	g.Mode = Rotate
+	// Looking down y, only the y ring faces the ray.
+	if axis := g.Pick(down(1, 1.02)); axis != 1 {
+		t.Errorf("expected the y ring, got %d", axis)
+	}
+	if axis := g.Pick(down(1.5, 0.2)); axis != -1 {
+		t.Errorf("expected a miss inside the ring, got %d", axis)
+	}
+}
+
+func TestDrag(t *testing.T) {
+	g := gizmo(Translate)
+	m, ok := g.Drag(0, down(1.5, 0), down(2.25, 0.4))
+	if !ok || !near(apply(m, [3]float32{0, 0, 0}), [3]float32{0.75, 0, 0}) {
+		t.Errorf("expected a translation by 0.75 along x, got %v", m)
+	}
+
+	g.Mode = Rotate
+	m, ok = g.Drag(1, down(2, 0), down(1, -1))
+	// A quarter turn counterclockwise looking down y takes +x to -z.
+	if !ok || !near(apply(m, [3]float32{2, 0, 0}), [3]float32{1, 0, -1}) || !near(apply(m, [3]float32{1, 5, 0}), [3]float32{1, 5, 0}) {
+		t.Errorf("expected a quarter turn about y through the center, got %v", m)
+	}
+
+	g.Mode = Scale
+	m, ok = g.Drag(2, down(1, 0.5), down(1, 1.5))
+	if !ok || !near(apply(m, [3]float32{1, 2, 1}), [3]float32{1, 2, 3}) || !near(apply(m, [3]float32{3, 0, 0}), [3]float32{3, 0, 0}) {
+		t.Errorf("expected a threefold stretch along z, got %v", m)
+	}
+
+	// A ray along the x axis cannot drag it.
+	if _, ok := gizmo(Translate).Drag(0, Ray{Dir: [3]float64{1, 0, 0}}, down(2, 0)); ok {
+		t.Error("expected a drag along the axis to fail")
+	}
+}
+
+func TestLines(t *testing.T) {
+	for _, mode := range []Mode{Translate, Rotate, Scale} {
+		positions, colors := gizmo(mode).Lines(1)
+		if len(positions) == 0 || len(positions)%6 != 0 || len(colors) != len(positions)/3*4 {
+			t.Errorf("%v: got %d positions and %d colors", mode, len(positions), len(colors))
+		}
+	}
+	if _, err := ParseMode("rotate"); err != nil {
+		t.Error(err)
+	}
+	if _, err := ParseMode("shear"); err == nil {
+		t.Error("expected an error for an unknown mode")
+	}
+}
@@ -0,0 +1,118 @@
+// mesh/mesh.go
+package mesh
+
+import "math"
+
+// Mesh is an indexed triangle mesh. Positions holds packed xyz vertex
+// coordinates and Indices holds three vertex indices per triangle.
+// Normals, when present, holds one packed xyz unit normal per vertex.
+type Mesh struct {
+	Positions []float32
+	Normals   []float32
+	Indices   []uint32
+}
+
+// VertexCount returns the number of vertices in the mesh.
+func (m *Mesh) VertexCount() int {
+	return len(m.Positions) / 3
+}
+
+// TriangleCount returns the number of triangles in the mesh.
+func (m *Mesh) TriangleCount() int {
+	return len(m.Indices) / 3
+}
+
+// Vertex returns the position of vertex i.
+func (m *Mesh) Vertex(i uint32) (x, y, z float32) {
+	return m.Positions[i*3], m.Positions[i*3+1], m.Positions[i*3+2]
+}
+
+// Triangle returns the vertex indices of triangle t.
+func (m *Mesh) Triangle(t int) (a, b, c uint32) {
+	return m.Indices[t*3], m.Indices[t*3+1], m.Indices[t*3+2]
+}
+
+// faceNormal returns the unnormalized normal of triangle t, whose length is
+// twice the triangle's area. Triangles wind counter-clockwise.
+func (m *Mesh) faceNormal(t int) (nx, ny, nz float64) {
+	a, b, c := m.Triangle(t)
+	ax, ay, az := m.Vertex(a)
+	bx, by, bz := m.Vertex(b)
+	cx, cy, cz := m.Vertex(c)
+	ux, uy, uz := float64(bx-ax), float64(by-ay), float64(bz-az)
+	vx, vy, vz := float64(cx-ax), float64(cy-ay), float64(cz-az)
+	return uy*vz - uz*vy, uz*vx - ux*vz, ux*vy - uy*vx
+}
+
+// TriangleArea returns the area of triangle t.
+func (m *Mesh) TriangleArea(t int) float64 {
+	nx, ny, nz := m.faceNormal(t)
+	return 0.5 * math.Sqrt(nx*nx+ny*ny+nz*nz)
+}
+
+// Volume returns the volume enclosed by a closed mesh wound
+// counter-clockwise seen from outside, as the sum of the signed volumes of
+// the tetrahedra from the origin to each triangle. It is negative for
+// inward winding and meaningless for open meshes.
+func (m *Mesh) Volume() float64 {
+	v := 0.0
+	for t := 0; t < m.TriangleCount(); t++ {
+		a, b, c := m.Triangle(t)
+		ax, ay, az := m.Vertex(a)
+		bx, by, bz := m.Vertex(b)
+		cx, cy, cz := m.Vertex(c)
+		v += float64(ax)*(float64(by)*float64(cz)-float64(bz)*float64(cy)) +
+			float64(ay)*(float64(bz)*float64(cx)-float64(bx)*float64(cz)) +
+			float64(az)*(float64(bx)*float64(cy)-float64(by)*float64(cx))
+	}
+	return v / 6
+}
+
+// ComputeVertexNormals replaces Normals with smooth per-vertex normals, the
+// area-weighted average of the normals of the triangles sharing each vertex.
+// Vertices used by no triangle get a zero normal.
+func (m *Mesh) ComputeVertexNormals() {
+	acc := make([]float64, len(m.Positions))
+	for t := 0; t < m.TriangleCount(); t++ {
+		nx, ny, nz := m.faceNormal(t)
+		a, b, c := m.Triangle(t)
+		for _, v := range []uint32{a, b, c} {
+			acc[v*3] += nx
+			acc[v*3+1] += ny
+			acc[v*3+2] += nz
+		}
+	}
+	m.Normals = make([]float32, len(m.Positions))
+	for i := 0; i < len(acc); i += 3 {
+		l := math.Sqrt(acc[i]*acc[i] + acc[i+1]*acc[i+1] + acc[i+2]*acc[i+2])
+		if l > 0 {
+			m.Normals[i] = float32(acc[i] / l)
+			m.Normals[i+1] = float32(acc[i+1] / l)
+			m.Normals[i+2] = float32(acc[i+2] / l)
+		}
+	}
+}
+
+// Edges returns the mesh's unique edges as packed pairs of vertex indices,
+// the smaller index first, in order of first appearance. An edge shared by
+// several triangles is listed once, so drawing the pairs as lines gives a
+// wireframe without overdraw.
+func (m *Mesh) Edges() []uint32 {
+	seen := make(map[uint64]bool, len(m.Indices))
+	var edges []uint32
+	for t := 0; t < m.TriangleCount(); t++ {
+		a, b, c := m.Triangle(t)
+		for _, e := range [3][2]uint32{{a, b}, {b, c}, {c, a}} {
+			lo, hi := e[0], e[1]
+			if lo > hi {
+				lo, hi = hi, lo
+			}
+			key := uint64(lo)<<32 | uint64(hi)
+			if !seen[key] {
+				seen[key] = true
+				edges = append(edges, lo, hi)
+			}
+		}
+	}
+	return edges
+}
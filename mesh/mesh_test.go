@@ -0,0 +1,78 @@
+// mesh/mesh_test.go
+// usage: go test
+
+package mesh
+
+import (
+	"math"
+	"testing"
+)
+
+func TestTriangleArea(t *testing.T) {
+	m := &Mesh{
+		Positions: []float32{0, 0, 0, 2, 0, 0, 0, 3, 0},
+		Indices:   []uint32{0, 1, 2},
+	}
+	if a := m.TriangleArea(0); math.Abs(a-3) > 1e-9 {
+		t.Errorf("TriangleArea: expected 3, got %f", a)
+	}
+	if m.VertexCount() != 3 || m.TriangleCount() != 1 {
+		t.Errorf("expected 3 vertices and 1 triangle, got %d and %d", m.VertexCount(), m.TriangleCount())
+	}
+}
+
+func TestComputeVertexNormals(t *testing.T) {
+	// Two triangles folded along the shared edge (0, 1): one facing +Z and
+	// one facing +Y. The shared vertices average to the diagonal.
+	m := &Mesh{
+		Positions: []float32{
+			0, 0, 0,
+			1, 0, 0,
+			0, 1, 0,
+			0, 0, -1,
+		},
+		Indices: []uint32{0, 1, 2, 0, 1, 3},
+	}
+	m.ComputeVertexNormals()
+	s := float32(1 / math.Sqrt(2))
+	want := []float32{
+		0, s, s,
+		0, s, s,
+		0, 0, 1,
+		0, 1, 0,
+	}
+	for i := range want {
+		if math.Abs(float64(m.Normals[i]-want[i])) > 1e-6 {
+			t.Fatalf("ComputeVertexNormals: expected %v, got %v", want, m.Normals)
+		}
+	}
+}
+
+func TestEdges(t *testing.T) {
+	// A quad split along the diagonal (0, 2) has five unique edges.
+	m := &Mesh{
+		Positions: []float32{0, 0, 0, 1, 0, 0, 1, 1, 0, 0, 1, 0},
+		Indices:   []uint32{0, 1, 2, 2, 3, 0},
+	}
+	got := m.Edges()
+	want := []uint32{0, 1, 1, 2, 0, 2, 2, 3, 0, 3}
+	if len(got) != len(want) {
+		t.Fatalf("Edges: expected %v, got %v", want, got)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("Edges: expected %v, got %v", want, got)
+		}
+	}
+}
+
+func TestVolume(t *testing.T) {
+	// A unit right tetrahedron away from the origin, wound outward.
+	m := &Mesh{
+		Positions: []float32{5, 5, 5, 6, 5, 5, 5, 6, 5, 5, 5, 6},
+		Indices:   []uint32{0, 2, 1, 0, 1, 3, 0, 3, 2, 1, 2, 3},
+	}
+	if v := m.Volume(); math.Abs(v-1.0/6) > 1e-6 {
+		t.Errorf("Volume: expected %f, got %f", 1.0/6, v)
+	}
+}
@@ -0,0 +1,171 @@
+// normals/normals.go
+// Package normals estimates the surface normal and curvature at each point
+// of a cloud from its neighbourhood, for shading and for spotting edges and
+// surface defects in scans that come without normals.
+package normals
+
+import (
+	"errors"
+	"math"
+
+	"github.com/sbecker11/webgl-point-cloud/spatial"
+)
+
+// Options controls the estimation.
+type Options struct {
+	// Radius is the radius of each point's neighbourhood. When zero it is
+	// 1.5 times spatial.AutoCellSize, about three point spacings on a
+	// surface.
+	Radius float64
+	// Viewpoint, if set, is the position normals are turned toward, such
+	// as the scanner's. Otherwise they are turned toward +y.
+	Viewpoint *[3]float64
+	// Progress, if set, is called with the fraction of the work done.
+	// Returning an error stops the estimation with that error.
+	Progress func(fraction float64) error
+}
+
+// progressEvery is how many points are estimated between calls to
+// Options.Progress.
+const progressEvery = 4096
+
+// Estimate returns each point's unit normal, packed xyz, and curvature:
+// the surface variation l0 / (l0 + l1 + l2) of the covariance of its
+// neighbourhood with eigenvalues l0 <= l1 <= l2, from 0 on a plane to 1/3
+// for points scattered evenly in every direction. Points with fewer than
+// three neighbours, counting themselves, get a zero normal and curvature.
+func Estimate(coords []float32, opts Options) (normals, curvature []float32, err error) {
+	if len(coords)%3 != 0 {
+		return nil, nil, errors.New("normals: coords length must be a multiple of 3")
+	}
+	radius := opts.Radius
+	if radius == 0 {
+		radius = 1.5 * spatial.AutoCellSize(coords)
+	}
+	if !(radius > 0) {
+		return nil, nil, errors.New("normals: radius must be positive")
+	}
+	n := len(coords) / 3
+	normals, curvature = make([]float32, n*3), make([]float32, n)
+	grid := spatial.NewGrid(coords, radius)
+	var neighbours []int
+	for i := 0; i < n; i++ {
+		if opts.Progress != nil && i%progressEvery == 0 {
+			if err := opts.Progress(float64(i) / float64(n)); err != nil {
+				return nil, nil, err
+			}
+		}
+		p := point(coords, i)
+		neighbours = neighbours[:0]
+		grid.Within(p, radius, func(j int, _ float64) { neighbours = append(neighbours, j) })
+		if len(neighbours) < 3 {
+			continue
+		}
+		values, vectors := eigen(covariance(coords, neighbours))
+		if sum := values[0] + values[1] + values[2]; sum > 0 {
+			curvature[i] = float32(values[0] / sum)
+		}
+		normal := vectors[0]
+		toward := [3]float64{0, 1, 0}
+		if opts.Viewpoint != nil {
+			v := *opts.Viewpoint
+			toward = [3]float64{v[0] - p[0], v[1] - p[1], v[2] - p[2]}
+		}
+		if normal[0]*toward[0]+normal[1]*toward[1]+normal[2]*toward[2] < 0 {
+			normal = [3]float64{-normal[0], -normal[1], -normal[2]}
+		}
+		for k := 0; k < 3; k++ {
+			normals[i*3+k] = float32(normal[k])
+		}
+	}
+	if opts.Progress != nil {
+		if err := opts.Progress(1); err != nil {
+			return nil, nil, err
+		}
+	}
+	return normals, curvature, nil
+}
+
+// point returns point i of coords.
+func point(coords []float32, i int) [3]float64 {
+	return [3]float64{float64(coords[i*3]), float64(coords[i*3+1]), float64(coords[i*3+2])}
+}
+
+// covariance returns the covariance matrix of the points at indices.
+func covariance(coords []float32, indices []int) [3][3]float64 {
+	var mean [3]float64
+	for _, j := range indices {
+		p := point(coords, j)
+		for k := range mean {
+			mean[k] += p[k]
+		}
+	}
+	for k := range mean {
+		mean[k] /= float64(len(indices))
+	}
+	var c [3][3]float64
+	for _, j := range indices {
+		p := point(coords, j)
+		d := [3]float64{p[0] - mean[0], p[1] - mean[1], p[2] - mean[2]}
+		for r := 0; r < 3; r++ {
+			for s := r; s < 3; s++ {
+				c[r][s] += d[r] * d[s]
+			}
+		}
+	}
+	for r := 0; r < 3; r++ {
+		for s := r; s < 3; s++ {
+			c[r][s] /= float64(len(indices))
+			c[s][r] = c[r][s]
+		}
+	}
+	return c
+}
+
+// eigen returns the eigenvalues of the symmetric matrix a in ascending
+// order and their unit eigenvectors, by Jacobi rotations.
+func eigen(a [3][3]float64) (values [3]float64, vectors [3][3]float64) {
+	v := [3][3]float64{{1, 0, 0}, {0, 1, 0}, {0, 0, 1}} // columns are the eigenvectors
+	for sweep := 0; sweep < 50; sweep++ {
+		off := a[0][1]*a[0][1] + a[0][2]*a[0][2] + a[1][2]*a[1][2]
+		if off < 1e-30 {
+			break
+		}
+		for p := 0; p < 2; p++ {
+			for q := p + 1; q < 3; q++ {
+				if a[p][q] == 0 {
+					continue
+				}
+				theta := (a[q][q] - a[p][p]) / (2 * a[p][q])
+				t := math.Copysign(1, theta) / (math.Abs(theta) + math.Sqrt(theta*theta+1))
+				c := 1 / math.Sqrt(t*t+1)
+				s := t * c
+				for k := 0; k < 3; k++ {
+					akp, akq := a[k][p], a[k][q]
+					a[k][p], a[k][q] = c*akp-s*akq, s*akp+c*akq
+				}
+				for k := 0; k < 3; k++ {
+					apk, aqk := a[p][k], a[q][k]
+					a[p][k], a[q][k] = c*apk-s*aqk, s*apk+c*aqk
+				}
+				for k := 0; k < 3; k++ {
+					vkp, vkq := v[k][p], v[k][q]
+					v[k][p], v[k][q] = c*vkp-s*vkq, s*vkp+c*vkq
+				}
+			}
+		}
+	}
+	order := [3]int{0, 1, 2}
+	for i := 0; i < 3; i++ {
+		for j := i + 1; j < 3; j++ {
+			if a[order[j]][order[j]] < a[order[i]][order[i]] {
+				order[i], order[j] = order[j], order[i]
+			}
+		}
+	}
+	for i, o := range order {
+		values[i] = a[o][o]
+		vectors[i] = [3]float64{v[0][o], v[1][o], v[2][o]}
+	}
+	return values, vectors
+}
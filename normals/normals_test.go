@@ -0,0 +1,75 @@
+// normals/normals_test.go
+// usage: go test
+
+package normals
+
+import (
+	"errors"
+	"math"
+	"testing"
+)
+
+// plane returns an n by n grid of points spaced 0.1 apart on the plane
+// through the origin spanned by u and v.
+func plane(n int, u, v [3]float32) []float32 {
+	var coords []float32
+	for i := 0; i < n; i++ {
+		for j := 0; j < n; j++ {
+			a, b := float32(i)*0.1, float32(j)*0.1
+			coords = append(coords, a*u[0]+b*v[0], a*u[1]+b*v[1], a*u[2]+b*v[2])
+		}
+	}
+	return coords
+}
+
+func TestEstimatePlane(t *testing.T) {
+	// A tilted plane whose normal is (0, 0.6, 0.8) or its opposite.
+	coords := plane(20, [3]float32{1, 0, 0}, [3]float32{0, 0.8, -0.6})
+	normals, curvature, err := Estimate(coords, Options{Radius: 0.25})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < len(curvature); i++ {
+		n := normals[i*3 : i*3+3]
+		if math.Abs(float64(n[0])) > 1e-4 || math.Abs(float64(n[1])-0.6) > 1e-4 || math.Abs(float64(n[2])-0.8) > 1e-4 {
+			t.Fatalf("point %d: expected normal (0, 0.6, 0.8) turned up, got %v", i, n)
+		}
+		if curvature[i] > 1e-6 {
+			t.Fatalf("point %d: expected no curvature on a plane, got %g", i, curvature[i])
+		}
+	}
+
+	// Turned toward a viewpoint below the plane instead.
+	normals, _, _ = Estimate(coords, Options{Radius: 0.25, Viewpoint: &[3]float64{1, -5, 0}})
+	if normals[1] > 0 {
+		t.Errorf("expected normals turned toward the viewpoint, got %v", normals[:3])
+	}
+}
+
+func TestEstimateEdge(t *testing.T) {
+	// Two planes meeting at a right angle along the x axis.
+	coords := append(plane(20, [3]float32{1, 0, 0}, [3]float32{0, 0, 1}), plane(20, [3]float32{1, 0, 0}, [3]float32{0, 1, 0})...)
+	_, curvature, err := Estimate(coords, Options{Radius: 0.25})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Point (1, 0, 0) is on the edge; (1, 0, 1) is well inside a face.
+	edge, face := curvature[10*20], curvature[10*20+10]
+	if edge < 0.05 || face > 1e-6 {
+		t.Errorf("expected curvature high on the edge and none on the face, got %g and %g", edge, face)
+	}
+}
+
+func TestEstimateErrors(t *testing.T) {
+	if _, _, err := Estimate([]float32{1, 2}, Options{}); err == nil {
+		t.Error("expected an error for a partial point")
+	}
+	normals, curvature, err := Estimate([]float32{0, 0, 0, 5, 5, 5}, Options{Radius: 1})
+	if err != nil || normals[0] != 0 || normals[1] != 0 || curvature[0] != 0 {
+		t.Errorf("expected zero normals for isolated points, got %v %v %v", normals, curvature, err)
+	}
+	stop := errors.New("stop")
+	if _, _, err := Estimate(plane(5, [3]float32{1, 0, 0}, [3]float32{0, 0, 1}), Options{Progress: func(float64) error { return stop }}); err != stop {
+		t.Errorf("expected the progress error, got %v", err)
+	}
+}
@@ -0,0 +1,55 @@
+// pick/depth.go
+package pick
+
+import "math"
+
+// PackDepth encodes a depth in [0, 1) into RGBA bytes the way the viewer's
+// depth shader does, for reading depth back from a color texture where
+// depth textures are unavailable. Each byte holds the next 8 bits of the
+// fraction.
+func PackDepth(d float64) [4]byte {
+	var b [4]byte
+	for i := range b {
+		d *= 255
+		v := math.Floor(d)
+		b[i] = byte(v)
+		d -= v
+	}
+	return b
+}
+
+// UnpackDepth decodes a depth packed by PackDepth or the depth shader.
+// All-zero bytes mean nothing was drawn there and report false.
+func UnpackDepth(b [4]byte) (float64, bool) {
+	if b == [4]byte{} {
+		return 0, false
+	}
+	return float64(b[0])/255 + float64(b[1])/(255*255) + float64(b[2])/(255*255*255) + float64(b[3])/(255*255*255*255), true
+}
+
+// NearestDepth finds the nearest depth in a square window of packed depth
+// pixels read back from WebGL: side by side RGBA pixels, rows bottom to
+// top, centered on the queried pixel. Only pixels within radius of the
+// center are considered, so point sprites near the cursor are found even
+// when the cursor falls between them.
+//
+// Returns the chosen pixel's offset from the center, with dy up, and its
+// depth in [0, 1], or false if nothing was drawn there.
+func NearestDepth(pixels []byte, side int, radius float64) (dx, dy int, depth float64, ok bool) {
+	c := side / 2
+	best := math.Inf(1)
+	for y := 0; y < side; y++ {
+		for x := 0; x < side; x++ {
+			ox, oy := x-c, y-c
+			if float64(ox*ox+oy*oy) > radius*radius {
+				continue
+			}
+			i := (y*side + x) * 4
+			d, hit := UnpackDepth([4]byte{pixels[i], pixels[i+1], pixels[i+2], pixels[i+3]})
+			if hit && d < best {
+				best, dx, dy, ok = d, ox, oy, true
+			}
+		}
+	}
+	return dx, dy, best, ok
+}
@@ -0,0 +1,40 @@
+// pick/depth_test.go
+// usage: go test
+
+package pick
+
+import (
+	"math"
+	"testing"
+)
+
+func TestPackDepth(t *testing.T) {
+	for _, d := range []float64{0.001, 0.25, 0.5, 0.987654321, 0.99999} {
+		got, ok := UnpackDepth(PackDepth(d))
+		if !ok || math.Abs(got-d) > 1e-9 {
+			t.Errorf("depth %g: round trip gave %g", d, got)
+		}
+	}
+	if _, ok := UnpackDepth([4]byte{}); ok {
+		t.Error("expected all-zero bytes to be a miss")
+	}
+}
+
+func TestNearestDepth(t *testing.T) {
+	const side = 5
+	pixels := make([]byte, side*side*4)
+	set := func(x, y int, d float64) {
+		b := PackDepth(d)
+		copy(pixels[(y*side+x)*4:], b[:])
+	}
+	set(3, 2, 0.6) // right of center
+	set(2, 4, 0.4) // top edge, nearer
+	set(0, 0, 0.1) // corner, outside the radius
+	dx, dy, d, ok := NearestDepth(pixels, side, 2)
+	if !ok || dx != 0 || dy != 2 || math.Abs(d-0.4) > 1e-9 {
+		t.Errorf("expected offset (0, 2) at depth 0.4, got (%d, %d) at %g, %v", dx, dy, d, ok)
+	}
+	if _, _, _, ok := NearestDepth(make([]byte, side*side*4), side, 2); ok {
+		t.Error("expected no depth in an empty window")
+	}
+}
@@ -0,0 +1,214 @@
+// pick/octree.go
+package pick
+
+import "github.com/sbecker11/webgl-point-cloud/glf32"
+
+const (
+	// octreeLeafSize is the most points a node holds before it splits.
+	octreeLeafSize = 64
+	// octreeMaxDepth stops splitting nodes of coincident points.
+	octreeMaxDepth = 16
+)
+
+// octreeNode is a box of points: order[start:end] are its points' indices.
+// Leaves have no children; a child of -1 is an empty octant.
+type octreeNode struct {
+	min, max   [3]float32
+	start, end int32
+	children   []int32
+}
+
+// Octree indexes a cloud's points for picking, so Nearest only projects
+// the points in boxes that can reach the pick radius on screen instead of
+// every point. The coordinates must not change while it is in use.
+type Octree struct {
+	coords []float32
+	order  []int32
+	nodes  []octreeNode
+}
+
+// NewOctree indexes packed xyz coordinates.
+func NewOctree(coords []float32) *Octree {
+	n := len(coords) / 3
+	t := &Octree{coords: coords, order: make([]int32, n)}
+	for i := range t.order {
+		t.order[i] = int32(i)
+	}
+	if n > 0 {
+		t.build(0, int32(n), 0)
+	}
+	return t
+}
+
+// Len returns the number of points indexed.
+func (t *Octree) Len() int {
+	return len(t.order)
+}
+
+// build adds the node for order[start:end] and its descendants, returning
+// its index.
+func (t *Octree) build(start, end int32, depth int) int32 {
+	nd := octreeNode{start: start, end: end}
+	for k := 0; k < 3; k++ {
+		nd.min[k] = t.coords[int(t.order[start])*3+k]
+		nd.max[k] = nd.min[k]
+	}
+	for _, i := range t.order[start:end] {
+		for k := 0; k < 3; k++ {
+			v := t.coords[int(i)*3+k]
+			nd.min[k] = min(nd.min[k], v)
+			nd.max[k] = max(nd.max[k], v)
+		}
+	}
+	index := int32(len(t.nodes))
+	t.nodes = append(t.nodes, nd)
+	if end-start <= octreeLeafSize || depth >= octreeMaxDepth || nd.min == nd.max {
+		return index
+	}
+
+	// Sort the points by octant around the box's center.
+	var center [3]float32
+	for k := range center {
+		center[k] = (nd.min[k] + nd.max[k]) / 2
+	}
+	octant := func(i int32) int {
+		o := 0
+		for k := 0; k < 3; k++ {
+			if t.coords[int(i)*3+k] > center[k] {
+				o |= 1 << k
+			}
+		}
+		return o
+	}
+	var counts [9]int32
+	for _, i := range t.order[start:end] {
+		counts[octant(i)+1]++
+	}
+	for o := 1; o < 9; o++ {
+		counts[o] += counts[o-1]
+	}
+	sorted := make([]int32, end-start)
+	next := counts
+	for _, i := range t.order[start:end] {
+		o := octant(i)
+		sorted[next[o]] = i
+		next[o]++
+	}
+	copy(t.order[start:end], sorted)
+
+	children := make([]int32, 8)
+	for o := 0; o < 8; o++ {
+		children[o] = -1
+		if counts[o+1] > counts[o] {
+			children[o] = t.build(start+counts[o], start+counts[o+1], depth+1)
+		}
+	}
+	t.nodes[index].children = children
+	return index
+}
+
+// Nearest is Nearest on the indexed coordinates.
+func (t *Octree) Nearest(mvp glf32.Mat4, width, height, x, y, radius float32, visible func(i int) bool) (index int, depth float32, ok bool) {
+	best := float32(2) // NDC depth is in [-1, 1]
+	index = -1
+	if len(t.nodes) == 0 {
+		return index, best, false
+	}
+	r2 := radius * radius
+	stack := []int32{0}
+	for len(stack) > 0 {
+		nd := &t.nodes[stack[len(stack)-1]]
+		stack = stack[:len(stack)-1]
+		if !t.reaches(nd, mvp, width, height, x, y, radius, best) {
+			continue
+		}
+		if nd.children != nil {
+			for _, c := range nd.children {
+				if c >= 0 {
+					stack = append(stack, c)
+				}
+			}
+			continue
+		}
+		for _, i := range t.order[nd.start:nd.end] {
+			if visible != nil && !visible(int(i)) {
+				continue
+			}
+			sx, sy, z, ok := screenPoint(mvp, t.coords[i*3:i*3+3], width, height)
+			if !ok || z < -1 || z > 1 {
+				continue
+			}
+			dx, dy := sx-x, sy-y
+			if dx*dx+dy*dy <= r2 && (z < best || z == best && int(i) < index) {
+				best, index = z, int(i)
+			}
+		}
+	}
+	return index, best, index >= 0
+}
+
+// reaches reports whether a point of the node's box could project within
+// radius of (x, y) nearer than depth best. Boxes reaching behind the
+// camera can't be bounded on screen and always reach.
+func (t *Octree) reaches(nd *octreeNode, mvp glf32.Mat4, width, height, x, y, radius, best float32) bool {
+	minX, minY, maxX, maxY, minZ, ok := t.screenBounds(nd, mvp, width, height)
+	if !ok {
+		return true
+	}
+	return maxX >= x-radius && minX <= x+radius && maxY >= y-radius && minY <= y+radius && minZ <= best
+}
+
+// WithinPolygon is WithinPolygon on the indexed coordinates. Only the
+// points in boxes overlapping the polygon's bounds on screen are
+// projected.
+func (t *Octree) WithinPolygon(mvp glf32.Mat4, width, height float32, polygon []float32, visible func(i int) bool) []bool {
+	inside := make([]bool, len(t.order))
+	if len(t.nodes) == 0 || len(polygon) < 6 {
+		return inside
+	}
+	pMinX, pMinY, pMaxX, pMaxY := polygonBounds(polygon)
+	stack := []int32{0}
+	for len(stack) > 0 {
+		nd := &t.nodes[stack[len(stack)-1]]
+		stack = stack[:len(stack)-1]
+		if minX, minY, maxX, maxY, _, ok := t.screenBounds(nd, mvp, width, height); ok && (maxX < pMinX || minX > pMaxX || maxY < pMinY || minY > pMaxY) {
+			continue
+		}
+		if nd.children != nil {
+			for _, c := range nd.children {
+				if c >= 0 {
+					stack = append(stack, c)
+				}
+			}
+			continue
+		}
+		for _, i := range t.order[nd.start:nd.end] {
+			inside[i] = projectsWithin(t.coords[i*3:i*3+3], mvp, width, height, polygon, visible == nil || visible(int(i)))
+		}
+	}
+	return inside
+}
+
+// screenBounds returns the screen bounds of the node's box and the least
+// depth of its corners, or false if the box reaches behind the camera.
+func (t *Octree) screenBounds(nd *octreeNode, mvp glf32.Mat4, width, height float32) (minX, minY, maxX, maxY, minZ float32, ok bool) {
+	minX, minY, minZ = 1e30, 1e30, 1e30
+	maxX, maxY = -1e30, -1e30
+	corner := make([]float32, 3)
+	for c := 0; c < 8; c++ {
+		for k := 0; k < 3; k++ {
+			corner[k] = nd.min[k]
+			if c&(1<<k) != 0 {
+				corner[k] = nd.max[k]
+			}
+		}
+		sx, sy, z, ok := screenPoint(mvp, corner, width, height)
+		if !ok {
+			return 0, 0, 0, 0, 0, false
+		}
+		minX, maxX = min(minX, sx), max(maxX, sx)
+		minY, maxY = min(minY, sy), max(maxY, sy)
+		minZ = min(minZ, z)
+	}
+	return minX, minY, maxX, maxY, minZ, true
+}
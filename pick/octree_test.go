@@ -0,0 +1,41 @@
+// pick/octree_test.go
+// usage: go test
+
+package pick
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/sbecker11/webgl-point-cloud/glf32"
+)
+
+func TestOctreeNearest(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	coords := make([]float32, 3*5000)
+	for i := range coords {
+		coords[i] = rng.Float32()*4 - 2
+	}
+	// Repeat some points so ties are exercised.
+	copy(coords[300:600], coords[:300])
+	tree := NewOctree(coords)
+	if tree.Len() != 5000 {
+		t.Fatalf("expected 5000 points, got %d", tree.Len())
+	}
+	visible := func(i int) bool { return i%7 != 0 }
+	for _, eye := range []glf32.Vec3{{0, 0, 6}, {3, 2, 1}, {0.5, 0.2, 0.1}} {
+		view := glf32.LookAt(eye, glf32.Vec3{0, 0, 0}, glf32.Vec3{0, 1, 0})
+		mvp := glf32.MultiplyMatrices(glf32.Perspective(0.8, 1.5, 0.1, 100), view)
+		for k := 0; k < 50; k++ {
+			x, y := rng.Float32()*300, rng.Float32()*200
+			want, wantDepth, wantOK := Nearest(coords, mvp, 300, 200, x, y, 6, visible)
+			got, gotDepth, gotOK := tree.Nearest(mvp, 300, 200, x, y, 6, visible)
+			if got != want || gotDepth != wantDepth || gotOK != wantOK {
+				t.Fatalf("eye %v at (%g, %g): expected %d %g %v, got %d %g %v", eye, x, y, want, wantDepth, wantOK, got, gotDepth, gotOK)
+			}
+		}
+	}
+	if _, _, ok := NewOctree(nil).Nearest(glf32.Identity(), 10, 10, 5, 5, 5, nil); ok {
+		t.Error("expected no point in an empty octree")
+	}
+}
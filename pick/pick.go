@@ -0,0 +1,49 @@
+// pick/pick.go
+package pick
+
+import "github.com/sbecker11/webgl-point-cloud/glf32"
+
+// Nearest finds the point under a screen position. coords holds packed xyz
+// points, mvp maps them to clip space and the viewport is width by height
+// pixels with (x, y) measured from its top-left corner. Among the points
+// projecting within radius pixels of (x, y), Nearest returns the one
+// closest to the camera. visible, if non-nil, excludes points for which
+// it returns false.
+//
+// Returns the point's index and normalized device depth in [-1, 1], for
+// comparing picks across clouds drawn with the same projection, or false if
+// no point is within radius.
+func Nearest(coords []float32, mvp glf32.Mat4, width, height, x, y, radius float32, visible func(i int) bool) (index int, depth float32, ok bool) {
+	best := float32(2) // NDC depth is in [-1, 1]
+	index = -1
+	r2 := radius * radius
+	for i := 0; i*3+2 < len(coords); i++ {
+		if visible != nil && !visible(i) {
+			continue
+		}
+		sx, sy, z, ok := screenPoint(mvp, coords[i*3:i*3+3], width, height)
+		if !ok || z < -1 || z > 1 {
+			continue // behind the camera or clipped by the near or far plane
+		}
+		dx, dy := sx-x, sy-y
+		if dx*dx+dy*dy <= r2 && z < best {
+			best, index = z, i
+		}
+	}
+	return index, best, index >= 0
+}
+
+// screenPoint projects point p with mvp to a width by height viewport,
+// returning its pixel position from the top-left corner and normalized
+// device depth, or false if it is behind the camera.
+func screenPoint(mvp glf32.Mat4, p []float32, width, height float32) (sx, sy, z float32, ok bool) {
+	px, py, pz := p[0], p[1], p[2]
+	cw := mvp[3]*px + mvp[7]*py + mvp[11]*pz + mvp[15]
+	if cw <= 0 {
+		return 0, 0, 0, false
+	}
+	cx := (mvp[0]*px + mvp[4]*py + mvp[8]*pz + mvp[12]) / cw
+	cy := (mvp[1]*px + mvp[5]*py + mvp[9]*pz + mvp[13]) / cw
+	z = (mvp[2]*px + mvp[6]*py + mvp[10]*pz + mvp[14]) / cw
+	return (cx + 1) / 2 * width, (1 - cy) / 2 * height, z, true
+}
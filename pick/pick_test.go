@@ -0,0 +1,42 @@
+// pick/pick_test.go
+// usage: go test
+
+package pick
+
+import (
+	"testing"
+
+	"github.com/sbecker11/webgl-point-cloud/glf32"
+)
+
+func TestNearest(t *testing.T) {
+	// Camera at z = 5 looking at the origin.
+	view := glf32.LookAt(glf32.Vec3{0, 0, 5}, glf32.Vec3{0, 0, 0}, glf32.Vec3{0, 1, 0})
+	mvp := glf32.MultiplyMatrices(glf32.Perspective(0.8, 1, 0.1, 100), view)
+	coords := []float32{
+		0, 0, 0, // center, far
+		0, 0, 2, // center, nearer the camera
+		1, 0, 0, // right of center
+		0, 0, 10, // behind the camera
+	}
+
+	i, nearDepth, ok := Nearest(coords, mvp, 200, 200, 100, 100, 5, nil)
+	if !ok || i != 1 {
+		t.Errorf("Nearest at center: expected point 1, got %d %v", i, ok)
+	}
+	i, farDepth, ok := Nearest(coords, mvp, 200, 200, 100, 100, 5, func(i int) bool { return i != 1 })
+	if !ok || i != 0 {
+		t.Errorf("Nearest with point 1 hidden: expected point 0, got %d %v", i, ok)
+	}
+	if nearDepth >= farDepth {
+		t.Errorf("Nearest: expected point 1 (depth %f) in front of point 0 (depth %f)", nearDepth, farDepth)
+	}
+	if _, _, ok := Nearest(coords, mvp, 200, 200, 10, 10, 5, nil); ok {
+		t.Error("Nearest in an empty corner: expected no point")
+	}
+	// Point 2 projects to the right of center.
+	i, _, ok = Nearest(coords, mvp, 200, 200, 100, 100, 200, func(i int) bool { return i == 2 })
+	if !ok || i != 2 {
+		t.Errorf("Nearest with a large radius: expected point 2, got %d %v", i, ok)
+	}
+}
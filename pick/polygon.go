@@ -0,0 +1,56 @@
+// pick/polygon.go
+package pick
+
+import "github.com/sbecker11/webgl-point-cloud/glf32"
+
+// InPolygon reports whether (x, y) is inside polygon, a closed outline
+// given as packed x, y vertices, by the even-odd rule. Outlines of fewer
+// than three vertices contain nothing.
+func InPolygon(polygon []float32, x, y float32) bool {
+	n := len(polygon) / 2
+	if n < 3 {
+		return false
+	}
+	inside := false
+	for i, j := 0, n-1; i < n; j, i = i, i+1 {
+		xi, yi, xj, yj := polygon[i*2], polygon[i*2+1], polygon[j*2], polygon[j*2+1]
+		if (yi > y) != (yj > y) && x < xi+(y-yi)*(xj-xi)/(yj-yi) {
+			inside = !inside
+		}
+	}
+	return inside
+}
+
+// WithinPolygon finds the points projecting inside a screen-space polygon,
+// e.g. a lasso drawn over the viewport. coords, mvp, width, height and
+// visible are as for Nearest, and polygon is as for InPolygon, in pixels
+// from the viewport's top-left corner. Points behind the camera or clipped
+// by the near or far plane are outside.
+//
+// Returns whether each point is inside.
+func WithinPolygon(coords []float32, mvp glf32.Mat4, width, height float32, polygon []float32, visible func(i int) bool) []bool {
+	inside := make([]bool, len(coords)/3)
+	for i := range inside {
+		inside[i] = projectsWithin(coords[i*3:i*3+3], mvp, width, height, polygon, visible == nil || visible(i))
+	}
+	return inside
+}
+
+// projectsWithin reports whether p, if visible, projects inside polygon.
+func projectsWithin(p []float32, mvp glf32.Mat4, width, height float32, polygon []float32, visible bool) bool {
+	if !visible {
+		return false
+	}
+	sx, sy, z, ok := screenPoint(mvp, p, width, height)
+	return ok && z >= -1 && z <= 1 && InPolygon(polygon, sx, sy)
+}
+
+// polygonBounds returns the bounding box of polygon's vertices.
+func polygonBounds(polygon []float32) (minX, minY, maxX, maxY float32) {
+	minX, minY, maxX, maxY = 1e30, 1e30, -1e30, -1e30
+	for i := 0; i+1 < len(polygon); i += 2 {
+		minX, maxX = min(minX, polygon[i]), max(maxX, polygon[i])
+		minY, maxY = min(minY, polygon[i+1]), max(maxY, polygon[i+1])
+	}
+	return minX, minY, maxX, maxY
+}
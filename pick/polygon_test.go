@@ -0,0 +1,51 @@
+// pick/polygon_test.go
+// usage: go test
+
+package pick
+
+import (
+	"math/rand"
+	"slices"
+	"testing"
+
+	"github.com/sbecker11/webgl-point-cloud/glf32"
+)
+
+func TestInPolygon(t *testing.T) {
+	// An L shape: the notch at the top right is outside.
+	l := []float32{0, 0, 10, 0, 10, 4, 4, 4, 4, 10, 0, 10}
+	for _, c := range []struct {
+		x, y float32
+		want bool
+	}{{2, 2, true}, {8, 2, true}, {2, 8, true}, {8, 8, false}, {-1, 2, false}, {5, 11, false}} {
+		if got := InPolygon(l, c.x, c.y); got != c.want {
+			t.Errorf("(%g, %g): expected %v, got %v", c.x, c.y, c.want, got)
+		}
+	}
+	if InPolygon([]float32{0, 0, 10, 10}, 5, 5) {
+		t.Error("expected a two-vertex outline to contain nothing")
+	}
+}
+
+func TestOctreeWithinPolygon(t *testing.T) {
+	rng := rand.New(rand.NewSource(2))
+	coords := make([]float32, 3*5000)
+	for i := range coords {
+		coords[i] = rng.Float32()*4 - 2
+	}
+	tree := NewOctree(coords)
+	visible := func(i int) bool { return i%5 != 0 }
+	lasso := []float32{40, 30, 260, 50, 150, 100, 230, 180, 60, 160}
+	for _, eye := range []glf32.Vec3{{0, 0, 6}, {3, 2, 1}, {0.5, 0.2, 0.1}} {
+		view := glf32.LookAt(eye, glf32.Vec3{0, 0, 0}, glf32.Vec3{0, 1, 0})
+		mvp := glf32.MultiplyMatrices(glf32.Perspective(0.8, 1.5, 0.1, 100), view)
+		want := WithinPolygon(coords, mvp, 300, 200, lasso, visible)
+		got := tree.WithinPolygon(mvp, 300, 200, lasso, visible)
+		if !slices.Equal(got, want) {
+			t.Errorf("eye %v: the octree disagrees with projecting every point", eye)
+		}
+		if !slices.Contains(want, true) {
+			t.Errorf("eye %v: expected some points inside the lasso", eye)
+		}
+	}
+}
@@ -0,0 +1,134 @@
+// pointcloud/attribute.go
+package pointcloud
+
+import "fmt"
+
+// AttributeType is the storage type an attribute is uploaded to the GPU as.
+// Values are always held as float32 on the CPU; Uint8 and Uint16 attributes
+// hold whole numbers in their type's range and take a quarter or half of
+// the GPU memory of Float32 ones. Values are not normalized.
+type AttributeType int
+
+const (
+	Float32 AttributeType = iota
+	Uint8
+	Uint16
+)
+
+// Size returns the size of one component in bytes.
+func (t AttributeType) Size() int {
+	switch t {
+	case Uint8:
+		return 1
+	case Uint16:
+		return 2
+	}
+	return 4
+}
+
+func (t AttributeType) String() string {
+	switch t {
+	case Uint8:
+		return "uint8"
+	case Uint16:
+		return "uint16"
+	}
+	return "float32"
+}
+
+// Attribute describes one per-point attribute of a cloud.
+type Attribute struct {
+	Name       string
+	Components int
+	Type       AttributeType
+}
+
+// Names of the built-in attributes backed by Cloud's fields.
+const (
+	AttrPosition = "position"
+	AttrColor    = "color"
+	AttrNormal   = "normal"
+	AttrClass    = "class"
+)
+
+func isBuiltin(name string) bool {
+	return name == AttrPosition || name == AttrColor || name == AttrNormal || name == AttrClass
+}
+
+// Schema returns the cloud's attributes: position and color, normal and
+// class when present, then any extra attributes in the order they were
+// first set.
+func (c *Cloud) Schema() []Attribute {
+	schema := []Attribute{
+		{AttrPosition, 3, Float32},
+		{AttrColor, 4, Float32},
+	}
+	if c.Normals != nil {
+		schema = append(schema, Attribute{AttrNormal, 3, Float32})
+	}
+	if c.Classes != nil {
+		schema = append(schema, Attribute{AttrClass, 1, Uint8})
+	}
+	return append(schema, c.extra...)
+}
+
+// SetAttribute sets the values of an extra attribute such as "intensity",
+// replacing any attribute of the same name. values holds a.Components
+// packed values per point.
+// Panics if a names a built-in attribute, has fewer than 1 component, or
+// if len(values) does not match the number of points.
+func (c *Cloud) SetAttribute(a Attribute, values []float32) {
+	if isBuiltin(a.Name) || a.Name == "" {
+		panic(fmt.Sprintf("pointcloud.SetAttribute: invalid attribute name %q", a.Name))
+	}
+	if a.Components < 1 {
+		panic("pointcloud.SetAttribute: attribute must have at least 1 component")
+	}
+	if len(values) != c.Len()*a.Components {
+		panic(fmt.Sprintf("pointcloud.SetAttribute: %s needs %d values, got %d", a.Name, c.Len()*a.Components, len(values)))
+	}
+	if c.values == nil {
+		c.values = map[string][]float32{}
+	}
+	if _, ok := c.values[a.Name]; ok {
+		for i := range c.extra {
+			if c.extra[i].Name == a.Name {
+				c.extra[i] = a
+			}
+		}
+	} else {
+		c.extra = append(c.extra, a)
+	}
+	c.values[a.Name] = values
+}
+
+// Attribute returns the description and packed values of an extra
+// attribute. Built-in attributes are read from the cloud's fields instead.
+func (c *Cloud) Attribute(name string) (Attribute, []float32, bool) {
+	values, ok := c.values[name]
+	if !ok {
+		return Attribute{}, nil, false
+	}
+	for _, a := range c.extra {
+		if a.Name == name {
+			return a, values, true
+		}
+	}
+	return Attribute{}, nil, false
+}
+
+// RemoveAttribute deletes an extra attribute. Returns false if there is no
+// such attribute.
+func (c *Cloud) RemoveAttribute(name string) bool {
+	if _, ok := c.values[name]; !ok {
+		return false
+	}
+	delete(c.values, name)
+	for i, a := range c.extra {
+		if a.Name == name {
+			c.extra = append(c.extra[:i], c.extra[i+1:]...)
+			break
+		}
+	}
+	return true
+}
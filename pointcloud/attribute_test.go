@@ -0,0 +1,71 @@
+// pointcloud/attribute_test.go
+// usage: go test
+
+package pointcloud
+
+import "testing"
+
+func TestSchema(t *testing.T) {
+	c := New("test", []float32{0, 0, 0, 1, 1, 1}, make([]float32, 8))
+	c.Classes = []uint8{ClassGround, ClassWater}
+	c.SetAttribute(Attribute{"intensity", 1, Float32}, []float32{0.2, 0.8})
+	c.SetAttribute(Attribute{"returns", 2, Uint8}, []float32{1, 1, 1, 2})
+
+	want := []string{AttrPosition, AttrColor, AttrClass, "intensity", "returns"}
+	schema := c.Schema()
+	if len(schema) != len(want) {
+		t.Fatalf("Schema: expected %v, got %v", want, schema)
+	}
+	for i, name := range want {
+		if schema[i].Name != name {
+			t.Errorf("Schema[%d]: expected %s, got %s", i, name, schema[i].Name)
+		}
+	}
+
+	// Replacing an attribute keeps its position in the schema.
+	c.SetAttribute(Attribute{"intensity", 1, Uint16}, []float32{100, 200})
+	a, values, ok := c.Attribute("intensity")
+	if !ok || a.Type != Uint16 || values[1] != 200 {
+		t.Errorf("Attribute: expected replaced uint16 intensity, got %v %v %v", a, values, ok)
+	}
+	if c.Schema()[3].Name != "intensity" {
+		t.Errorf("Schema: replaced attribute moved, got %v", c.Schema())
+	}
+
+	if !c.RemoveAttribute("returns") || c.RemoveAttribute("returns") {
+		t.Error("RemoveAttribute: expected true then false")
+	}
+	if len(c.Schema()) != 4 {
+		t.Errorf("Schema after removal: expected 4 attributes, got %v", c.Schema())
+	}
+}
+
+func TestSubsetCopiesAttributes(t *testing.T) {
+	c := New("test", []float32{0, 0, 0, 1, 1, 1, 2, 2, 2}, make([]float32, 12))
+	c.SetAttribute(Attribute{"uv", 2, Float32}, []float32{0, 0.5, 1, 1.5, 2, 2.5})
+	_, values, ok := c.Subset([]int{2, 1}).Attribute("uv")
+	if !ok || len(values) != 4 || values[0] != 2 || values[3] != 1.5 {
+		t.Errorf("Subset: expected uv [2 2.5 1 1.5], got %v", values)
+	}
+}
+
+func TestSetAttributePanics(t *testing.T) {
+	c := New("test", []float32{0, 0, 0}, make([]float32, 4))
+	for _, tt := range []struct {
+		a      Attribute
+		values []float32
+	}{
+		{Attribute{AttrColor, 1, Float32}, []float32{1}},
+		{Attribute{"intensity", 0, Float32}, nil},
+		{Attribute{"intensity", 1, Float32}, []float32{1, 2}},
+	} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("SetAttribute(%v): expected a panic", tt.a)
+				}
+			}()
+			c.SetAttribute(tt.a, tt.values)
+		}()
+	}
+}
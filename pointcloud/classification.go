@@ -0,0 +1,107 @@
+// pointcloud/classification.go
+package pointcloud
+
+import "fmt"
+
+// Standard ASPRS LAS point classes (LAS 1.4, table 17).
+const (
+	ClassCreated          uint8 = 0
+	ClassUnclassified     uint8 = 1
+	ClassGround           uint8 = 2
+	ClassLowVegetation    uint8 = 3
+	ClassMediumVegetation uint8 = 4
+	ClassHighVegetation   uint8 = 5
+	ClassBuilding         uint8 = 6
+	ClassLowPoint         uint8 = 7
+	ClassWater            uint8 = 9
+	ClassRail             uint8 = 10
+	ClassRoadSurface      uint8 = 11
+	ClassWireGuard        uint8 = 13
+	ClassWireConductor    uint8 = 14
+	ClassTransmission     uint8 = 15
+	ClassWireConnector    uint8 = 16
+	ClassBridgeDeck       uint8 = 17
+	ClassHighNoise        uint8 = 18
+)
+
+// MaxClasses is the number of distinct classes the viewer can style.
+// LAS formats 0-5 store classes in 5 bits, so 32 covers them all.
+const MaxClasses = 32
+
+var classNames = map[uint8]string{
+	ClassCreated:          "Created, never classified",
+	ClassUnclassified:     "Unclassified",
+	ClassGround:           "Ground",
+	ClassLowVegetation:    "Low vegetation",
+	ClassMediumVegetation: "Medium vegetation",
+	ClassHighVegetation:   "High vegetation",
+	ClassBuilding:         "Building",
+	ClassLowPoint:         "Low point (noise)",
+	ClassWater:            "Water",
+	ClassRail:             "Rail",
+	ClassRoadSurface:      "Road surface",
+	ClassWireGuard:        "Wire - guard (shield)",
+	ClassWireConductor:    "Wire - conductor (phase)",
+	ClassTransmission:     "Transmission tower",
+	ClassWireConnector:    "Wire-structure connector",
+	ClassBridgeDeck:       "Bridge deck",
+	ClassHighNoise:        "High noise",
+}
+
+// classColors is the standard palette of the ASPRS classes, following the
+// conventions common to LiDAR viewers: earth tones for ground, greens for
+// vegetation, red buildings, blue water and magenta noise.
+var classColors = map[uint8][4]float32{
+	ClassCreated:          {0.7, 0.7, 0.7, 1},
+	ClassUnclassified:     {0.7, 0.7, 0.7, 1},
+	ClassGround:           {0.65, 0.5, 0.3, 1},
+	ClassLowVegetation:    {0.55, 0.8, 0.3, 1},
+	ClassMediumVegetation: {0.3, 0.65, 0.2, 1},
+	ClassHighVegetation:   {0.1, 0.45, 0.1, 1},
+	ClassBuilding:         {0.85, 0.25, 0.2, 1},
+	ClassLowPoint:         {1, 0, 1, 1},
+	ClassWater:            {0.2, 0.4, 0.9, 1},
+	ClassRail:             {0.55, 0.35, 0.2, 1},
+	ClassRoadSurface:      {0.4, 0.4, 0.45, 1},
+	ClassWireGuard:        {1, 0.85, 0.3, 1},
+	ClassWireConductor:    {1, 0.65, 0.1, 1},
+	ClassTransmission:     {0.6, 0.6, 0.8, 1},
+	ClassWireConnector:    {0.9, 0.9, 0.5, 1},
+	ClassBridgeDeck:       {0.75, 0.6, 0.45, 1},
+	ClassHighNoise:        {1, 0, 1, 1},
+}
+
+// ClassColor returns the standard RGBA color of class c, or light gray
+// for reserved and user-defined classes.
+func ClassColor(c uint8) [4]float32 {
+	if color, ok := classColors[c]; ok {
+		return color
+	}
+	return [4]float32{0.7, 0.7, 0.7, 1}
+}
+
+// ClassName returns the ASPRS name of class c, or "Class <c>" for
+// reserved and user-defined classes.
+func ClassName(c uint8) string {
+	if name, ok := classNames[c]; ok {
+		return name
+	}
+	return fmt.Sprintf("Class %d", c)
+}
+
+// ClassCounts returns the number of points in each class present in the
+// cloud. A cloud without classifications reports every point as
+// ClassUnclassified.
+func (c *Cloud) ClassCounts() map[uint8]int {
+	counts := map[uint8]int{}
+	if c.Classes == nil {
+		if c.Len() > 0 {
+			counts[ClassUnclassified] = c.Len()
+		}
+		return counts
+	}
+	for _, cls := range c.Classes {
+		counts[cls]++
+	}
+	return counts
+}
@@ -0,0 +1,182 @@
+// pointcloud/pointcloud.go
+package pointcloud
+
+import (
+	"math"
+
+	"github.com/sbecker11/webgl-point-cloud/glf32"
+)
+
+// Cloud is a named set of points with per-point colors.
+// Coords holds packed xyz coordinates and Colors holds packed RGBA values in
+// [0, 1], the same layouts the viewer uploads to WebGL. Normals and Classes
+// are optional; when present Normals holds one packed xyz unit normal per
+// point and Classes holds one ASPRS LAS classification code per point.
+// Further per-point attributes are added with SetAttribute and described by
+// Schema.
+//
+// CRS is the coordinate reference system of georeferenced clouds, e.g.
+// "EPSG:32633" as read from a file header (see package crs), and empty for
+// local coordinates. Coordinates in it are Coords plus Offset, which keeps
+// large projected coordinates within float32 precision.
+type Cloud struct {
+	Name    string
+	Coords  []float32
+	Colors  []float32
+	Normals []float32
+	Classes []uint8
+	CRS     string
+	Offset  [3]float64
+
+	extra  []Attribute
+	values map[string][]float32
+}
+
+// New creates a cloud from packed coordinates and colors.
+// Panics if len(coords) is not a multiple of 3 or if the number of colors
+// does not match the number of points.
+func New(name string, coords, colors []float32) *Cloud {
+	if len(coords)%3 != 0 {
+		panic("pointcloud.New: coords slice length must be a multiple of 3")
+	}
+	if len(colors) != len(coords)/3*4 {
+		panic("pointcloud.New: colors must have 4 components per point")
+	}
+	return &Cloud{Name: name, Coords: coords, Colors: colors}
+}
+
+// Len returns the number of points in the cloud.
+func (c *Cloud) Len() int {
+	return len(c.Coords) / 3
+}
+
+// Point returns the coordinates of point i.
+func (c *Cloud) Point(i int) glf32.Vec3 {
+	return glf32.Vec3{c.Coords[i*3], c.Coords[i*3+1], c.Coords[i*3+2]}
+}
+
+// Bounds returns the axis-aligned bounding box of the cloud.
+// An empty cloud has zero bounds.
+func (c *Cloud) Bounds() (min, max glf32.Vec3) {
+	if c.Len() == 0 {
+		return glf32.Vec3{0, 0, 0}, glf32.Vec3{0, 0, 0}
+	}
+	min = glf32.Vec3{math.MaxFloat32, math.MaxFloat32, math.MaxFloat32}
+	max = glf32.Vec3{-math.MaxFloat32, -math.MaxFloat32, -math.MaxFloat32}
+	for i := 0; i < len(c.Coords); i += 3 {
+		for k := 0; k < 3; k++ {
+			v := c.Coords[i+k]
+			if v < min[k] {
+				min[k] = v
+			}
+			if v > max[k] {
+				max[k] = v
+			}
+		}
+	}
+	return min, max
+}
+
+// FitTransform returns a model matrix that centers the cloud's bounding box
+// on the origin and scales it uniformly so its largest dimension equals size.
+// Clouds with no extent are only centered.
+func (c *Cloud) FitTransform(size float32) glf32.Mat4 {
+	min, max := c.Bounds()
+	center := glf32.Vec3{(min[0] + max[0]) / 2, (min[1] + max[1]) / 2, (min[2] + max[2]) / 2}
+	extent := float32(math.Max(float64(max[0]-min[0]), math.Max(float64(max[1]-min[1]), float64(max[2]-min[2]))))
+	translate := glf32.Translate(-center[0], -center[1], -center[2])
+	if extent == 0 {
+		return translate
+	}
+	s := size / extent
+	scale := glf32.Mat4{
+		s, 0, 0, 0,
+		0, s, 0, 0,
+		0, 0, s, 0,
+		0, 0, 0, 1,
+	}
+	return glf32.MultiplyMatrices(scale, translate)
+}
+
+// Subset returns a new cloud holding the points at the given indices, in
+// order, with every attribute the cloud carries. The result shares no
+// storage with c.
+func (c *Cloud) Subset(indices []int) *Cloud {
+	out := &Cloud{
+		Name:   c.Name,
+		Coords: make([]float32, 0, len(indices)*3),
+		Colors: make([]float32, 0, len(indices)*4),
+		CRS:    c.CRS,
+		Offset: c.Offset,
+	}
+	if c.Normals != nil {
+		out.Normals = make([]float32, 0, len(indices)*3)
+	}
+	if c.Classes != nil {
+		out.Classes = make([]uint8, 0, len(indices))
+	}
+	for _, i := range indices {
+		out.Coords = append(out.Coords, c.Coords[i*3:i*3+3]...)
+		out.Colors = append(out.Colors, c.Colors[i*4:i*4+4]...)
+		if c.Normals != nil {
+			out.Normals = append(out.Normals, c.Normals[i*3:i*3+3]...)
+		}
+		if c.Classes != nil {
+			out.Classes = append(out.Classes, c.Classes[i])
+		}
+	}
+	for _, a := range c.extra {
+		values := c.values[a.Name]
+		subset := make([]float32, 0, len(indices)*a.Components)
+		for _, i := range indices {
+			subset = append(subset, values[i*a.Components:(i+1)*a.Components]...)
+		}
+		out.SetAttribute(a, subset)
+	}
+	return out
+}
+
+// Concat returns a new cloud named name holding the points of clouds in
+// order, for combining frames of a stream. Normals, classes and extra
+// attributes are kept only if every cloud has them, extra attributes with
+// the same number of components. The CRS and offset are those of the last
+// cloud. The result shares no storage with the clouds.
+func Concat(name string, clouds ...*Cloud) *Cloud {
+	out := &Cloud{Name: name, Coords: []float32{}, Colors: []float32{}}
+	if len(clouds) == 0 {
+		return out
+	}
+	last := clouds[len(clouds)-1]
+	out.CRS, out.Offset = last.CRS, last.Offset
+	normals, classes := true, true
+	extras := append([]Attribute(nil), clouds[0].extra...)
+	for _, c := range clouds {
+		normals = normals && c.Normals != nil
+		classes = classes && c.Classes != nil
+		kept := extras[:0]
+		for _, a := range extras {
+			if b, _, ok := c.Attribute(a.Name); ok && b.Components == a.Components {
+				kept = append(kept, a)
+			}
+		}
+		extras = kept
+	}
+	values := make([][]float32, len(extras))
+	for _, c := range clouds {
+		out.Coords = append(out.Coords, c.Coords...)
+		out.Colors = append(out.Colors, c.Colors...)
+		if normals {
+			out.Normals = append(out.Normals, c.Normals...)
+		}
+		if classes {
+			out.Classes = append(out.Classes, c.Classes...)
+		}
+		for i, a := range extras {
+			values[i] = append(values[i], c.values[a.Name]...)
+		}
+	}
+	for i, a := range extras {
+		out.SetAttribute(a, values[i])
+	}
+	return out
+}
@@ -0,0 +1,112 @@
+// pointcloud/pointcloud_test.go
+// usage: go test
+
+package pointcloud
+
+import (
+	"math"
+	"testing"
+
+	"github.com/sbecker11/webgl-point-cloud/glf32"
+)
+
+func almostEqual(a, b float32) bool {
+	return math.Abs(float64(a-b)) <= 1e-5
+}
+
+func TestBounds(t *testing.T) {
+	c := New("test", []float32{1, 2, 3, -1, 5, 0, 0, 0, 7}, make([]float32, 12))
+	min, max := c.Bounds()
+	wantMin, wantMax := glf32.Vec3{-1, 0, 0}, glf32.Vec3{1, 5, 7}
+	for k := 0; k < 3; k++ {
+		if min[k] != wantMin[k] || max[k] != wantMax[k] {
+			t.Fatalf("Bounds: expected %v-%v, got %v-%v", wantMin, wantMax, min, max)
+		}
+	}
+	if c.Len() != 3 {
+		t.Errorf("Len: expected 3, got %d", c.Len())
+	}
+
+	min, max = New("empty", nil, nil).Bounds()
+	if min[0] != 0 || max[0] != 0 {
+		t.Errorf("Bounds of an empty cloud: expected zeros, got %v-%v", min, max)
+	}
+}
+
+func TestFitTransform(t *testing.T) {
+	c := New("test", []float32{10, 10, 10, 14, 12, 10}, make([]float32, 8))
+	m := c.FitTransform(2)
+	coords := glf32.TransformVertices(append([]float32(nil), c.Coords...), m)
+	want := []float32{-1, -0.5, 0, 1, 0.5, 0}
+	for i := range want {
+		if !almostEqual(coords[i], want[i]) {
+			t.Fatalf("FitTransform: expected %v, got %v", want, coords)
+		}
+	}
+}
+
+func TestNewPanicsOnMismatchedColors(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("New: expected a panic for mismatched colors")
+		}
+	}()
+	New("bad", []float32{0, 0, 0}, []float32{1, 1, 1})
+}
+
+func TestSubset(t *testing.T) {
+	c := New("test", []float32{0, 0, 0, 1, 1, 1, 2, 2, 2}, []float32{0, 0, 0, 1, 0.5, 0.5, 0.5, 1, 1, 1, 1, 1})
+	c.Classes = []uint8{ClassGround, ClassBuilding, ClassWater}
+	c.CRS, c.Offset = "EPSG:32633", [3]float64{400000, 5000000, 0}
+	s := c.Subset([]int{2, 0})
+	if s.Len() != 2 || s.Coords[0] != 2 || s.Coords[3] != 0 {
+		t.Fatalf("Subset: expected points 2 and 0, got %v", s.Coords)
+	}
+	if s.Colors[0] != 1 || s.Colors[4] != 0 {
+		t.Errorf("Subset: colors not carried over, got %v", s.Colors)
+	}
+	if len(s.Classes) != 2 || s.Classes[0] != ClassWater || s.Classes[1] != ClassGround {
+		t.Errorf("Subset: expected classes [%d %d], got %v", ClassWater, ClassGround, s.Classes)
+	}
+	if s.Normals != nil {
+		t.Errorf("Subset: expected nil normals, got %v", s.Normals)
+	}
+	if s.CRS != c.CRS || s.Offset != c.Offset {
+		t.Errorf("Subset: expected CRS %s and offset %v, got %s and %v", c.CRS, c.Offset, s.CRS, s.Offset)
+	}
+	s.Coords[0] = 9
+	if c.Coords[6] != 2 {
+		t.Error("Subset: result shares storage with the source cloud")
+	}
+}
+
+func TestConcat(t *testing.T) {
+	a := New("a", []float32{0, 0, 0}, []float32{1, 0, 0, 1})
+	a.Classes = []uint8{ClassGround}
+	a.SetAttribute(Attribute{"intensity", 1, Float32}, []float32{5})
+	a.SetAttribute(Attribute{"ring", 1, Uint8}, []float32{3})
+	b := New("b", []float32{1, 1, 1, 2, 2, 2}, []float32{0, 1, 0, 1, 0, 0, 1, 1})
+	b.SetAttribute(Attribute{"intensity", 1, Float32}, []float32{6, 7})
+	b.CRS = "EPSG:32633"
+
+	c := Concat("both", a, b)
+	if c.Name != "both" || c.Len() != 3 || c.Coords[3] != 1 || c.Colors[5] != 1 || c.CRS != "EPSG:32633" {
+		t.Fatalf("Concat: unexpected cloud %+v", c)
+	}
+	if c.Classes != nil {
+		t.Errorf("Concat: expected no classes, as b has none, got %v", c.Classes)
+	}
+	if _, values, ok := c.Attribute("intensity"); !ok || len(values) != 3 || values[2] != 7 {
+		t.Errorf("Concat: expected intensity [5 6 7], got %v", values)
+	}
+	if _, _, ok := c.Attribute("ring"); ok {
+		t.Error("Concat: expected no ring, as b has none")
+	}
+	a.Coords[0] = 9
+	if c.Coords[0] != 0 {
+		t.Error("Concat: result shares storage with its input")
+	}
+	if Concat("empty").Len() != 0 {
+		t.Error("Concat: expected an empty cloud")
+	}
+}
@@ -0,0 +1,80 @@
+// procgen/datasets.go
+package procgen
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/sbecker11/webgl-point-cloud/glf32"
+	"github.com/sbecker11/webgl-point-cloud/pointcloud"
+)
+
+// datasets maps dataset names to generators with demo-friendly parameters.
+// numPoints is the total number of points, except for "clusters" where it is
+// the number of points per cluster, matching the original demo.
+var datasets = map[string]func(g *Generator, numPoints int) ([]float32, []float32){
+	"clusters": func(g *Generator, n int) ([]float32, []float32) { return g.Clusters(n) },
+	"sphere":   func(g *Generator, n int) ([]float32, []float32) { return g.SphereSurface(n, 1) },
+	"ball":     func(g *Generator, n int) ([]float32, []float32) { return g.SphereVolume(n, 1) },
+	"torus":    func(g *Generator, n int) ([]float32, []float32) { return g.Torus(n, 0.8, 0.3) },
+	"helix": func(g *Generator, n int) ([]float32, []float32) {
+		return g.Helix(n, 5, 0.6, 2, 0.02, false)
+	},
+	"spiral": func(g *Generator, n int) ([]float32, []float32) {
+		return g.Helix(n, 8, 1, 1.5, 0.02, true)
+	},
+	"bluenoise": func(g *Generator, n int) ([]float32, []float32) {
+		coords := g.BlueNoiseSphere(n, 1)
+		return coords, normalColors(coords)
+	},
+	"lorenz":  func(g *Generator, n int) ([]float32, []float32) { return g.Lorenz(n) },
+	"galaxy":  func(g *Generator, n int) ([]float32, []float32) { return g.Galaxy(n, 3, 1.2) },
+	"terrain": func(g *Generator, n int) ([]float32, []float32) { return g.Terrain(n, 2.5, 0.4) },
+	"bunny":   func(g *Generator, n int) ([]float32, []float32) { return g.Bunny(n) },
+}
+
+// classifiedDatasets generate clouds that carry per-point classifications.
+var classifiedDatasets = map[string]func(g *Generator, numPoints int) *pointcloud.Cloud{
+	"town": func(g *Generator, n int) *pointcloud.Cloud { return g.Town(n) },
+}
+
+// DatasetNames returns the names accepted by Dataset, sorted.
+func DatasetNames() []string {
+	names := make([]string, 0, len(datasets)+len(classifiedDatasets))
+	for name := range datasets {
+		names = append(names, name)
+	}
+	for name := range classifiedDatasets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Dataset generates the named procedural dataset with numPoints points as a
+// cloud of the same name. Returns an error if the name is unknown.
+func (g *Generator) Dataset(name string, numPoints int) (*pointcloud.Cloud, error) {
+	if gen, ok := classifiedDatasets[name]; ok {
+		cloud := gen(g, numPoints)
+		cloud.Name = name
+		return cloud, nil
+	}
+	gen, ok := datasets[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown dataset %q (expected one of %s)", name, strings.Join(DatasetNames(), ", "))
+	}
+	coords, colors := gen(g, numPoints)
+	return pointcloud.New(name, coords, colors), nil
+}
+
+// normalColors colors points on a sphere centered at the origin by their
+// direction, like SphereSurface.
+func normalColors(coords []float32) []float32 {
+	colors := make([]float32, 0, len(coords)/3*4)
+	for i := 0; i < len(coords); i += 3 {
+		n := glf32.Normalize(glf32.Vec3{coords[i], coords[i+1], coords[i+2]})
+		colors = append(colors, (n[0]+1)/2, (n[1]+1)/2, (n[2]+1)/2, 1)
+	}
+	return colors
+}
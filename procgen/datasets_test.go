@@ -0,0 +1,169 @@
+// procgen/datasets_test.go
+// usage: go test
+
+package procgen
+
+import (
+	"math"
+	"testing"
+
+	"github.com/sbecker11/webgl-point-cloud/mesh"
+	"github.com/sbecker11/webgl-point-cloud/pointcloud"
+)
+
+func TestDatasets(t *testing.T) {
+	for _, name := range DatasetNames() {
+		n := 2000
+		cloud, err := New(5).Dataset(name, n)
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		coords, colors := cloud.Coords, cloud.Colors
+		if cloud.Name != name {
+			t.Errorf("%s: cloud is named %q", name, cloud.Name)
+		}
+		if cloud.Classes != nil && len(cloud.Classes) != cloud.Len() {
+			t.Errorf("%s: expected %d classes, got %d", name, cloud.Len(), len(cloud.Classes))
+		}
+		want := n
+		if name == "clusters" {
+			want = 3 * n
+		}
+		if len(coords) != want*3 || len(colors) != want*4 {
+			t.Errorf("%s: expected %d points, got %d coords and %d colors", name, want, len(coords)/3, len(colors)/4)
+		}
+		for i, v := range coords {
+			if math.IsNaN(float64(v)) || math.IsInf(float64(v), 0) || v < -2 || v > 2 {
+				t.Errorf("%s: coordinate %d out of range: %f", name, i, v)
+				break
+			}
+		}
+		for i, v := range colors {
+			if v < 0 || v > 1 {
+				t.Errorf("%s: color component %d out of range: %f", name, i, v)
+				break
+			}
+		}
+
+		again, _ := New(5).Dataset(name, n)
+		if !slicesEqual(coords, again.Coords) {
+			t.Errorf("%s: same seed produced different points", name)
+		}
+	}
+
+	if _, err := New(1).Dataset("no-such-dataset", 10); err == nil {
+		t.Error("Dataset: expected an error for an unknown name")
+	}
+}
+
+func TestTownClasses(t *testing.T) {
+	town := New(3).Town(5000)
+	counts := town.ClassCounts()
+	for _, class := range []uint8{
+		pointcloud.ClassGround,
+		pointcloud.ClassBuilding,
+		pointcloud.ClassHighVegetation,
+		pointcloud.ClassLowVegetation,
+	} {
+		if counts[class] == 0 {
+			t.Errorf("Town: no points of class %s", pointcloud.ClassName(class))
+		}
+	}
+	_, intensity, ok := town.Attribute("intensity")
+	if !ok || len(intensity) != town.Len() {
+		t.Fatal("Town: expected an intensity value per point")
+	}
+	for i, v := range intensity {
+		if v < 0 || v > 1 {
+			t.Fatalf("Town: intensity %f of point %d outside [0, 1]", v, i)
+		}
+	}
+}
+
+func TestSphereSurfaceAndVolume(t *testing.T) {
+	g := New(3)
+	coords, _ := g.SphereSurface(1000, 2)
+	for i := 0; i < len(coords); i += 3 {
+		r := math.Sqrt(float64(coords[i]*coords[i] + coords[i+1]*coords[i+1] + coords[i+2]*coords[i+2]))
+		if math.Abs(r-2) > 1e-4 {
+			t.Fatalf("SphereSurface: point %d at radius %f, expected 2", i/3, r)
+		}
+	}
+
+	coords, _ = g.SphereVolume(4000, 1)
+	inner := 0
+	for i := 0; i < len(coords); i += 3 {
+		r := math.Sqrt(float64(coords[i]*coords[i] + coords[i+1]*coords[i+1] + coords[i+2]*coords[i+2]))
+		if r > 1+1e-5 {
+			t.Fatalf("SphereVolume: point %d outside the sphere at radius %f", i/3, r)
+		}
+		if r < 0.5 {
+			inner++
+		}
+	}
+	// A uniform ball has 1/8 of its volume inside half the radius.
+	if frac := float64(inner) / 4000; math.Abs(frac-0.125) > 0.03 {
+		t.Errorf("SphereVolume: expected ~12.5%% of points within r=0.5, got %.1f%%", frac*100)
+	}
+}
+
+func TestTorus(t *testing.T) {
+	coords, _ := New(9).Torus(1000, 0.8, 0.3)
+	for i := 0; i < len(coords); i += 3 {
+		x, y, z := float64(coords[i]), float64(coords[i+1]), float64(coords[i+2])
+		d := math.Hypot(math.Hypot(x, z)-0.8, y)
+		if math.Abs(d-0.3) > 1e-4 {
+			t.Fatalf("Torus: point %d is %f from the tube center, expected 0.3", i/3, d)
+		}
+	}
+}
+
+func TestSampleMeshAreaWeighting(t *testing.T) {
+	// Two triangles in the XY plane; the second has three times the area.
+	m := &mesh.Mesh{
+		Positions: []float32{
+			0, 0, 0, 1, 0, 0, 0, 1, 0,
+			2, 0, 0, 5, 0, 0, 2, 1, 0,
+		},
+		Indices: []uint32{0, 1, 2, 3, 4, 5},
+	}
+	coords, normals, tris := New(11).SampleMesh(m, 8000)
+	counts := [2]int{}
+	for i, tri := range tris {
+		counts[tri]++
+		x, y := coords[i*3], coords[i*3+1]
+		if tri == 0 && (x < 0 || y < 0 || x+y > 1+1e-5) {
+			t.Fatalf("SampleMesh: point (%f, %f) outside triangle 0", x, y)
+		}
+	}
+	if ratio := float64(counts[1]) / float64(counts[0]); math.Abs(ratio-3) > 0.3 {
+		t.Errorf("SampleMesh: expected a 3:1 split by area, got %d:%d", counts[1], counts[0])
+	}
+
+	for i := 0; i < len(normals); i += 3 {
+		if normals[i] != 0 || normals[i+1] != 0 || normals[i+2] != 1 {
+			t.Fatalf("SampleMesh: expected +Z normals for a mesh in the XY plane, got %v", normals[i:i+3])
+		}
+	}
+
+	if c, _, _ := New(1).SampleMesh(&mesh.Mesh{}, 10); c != nil {
+		t.Error("SampleMesh: expected nil for an empty mesh")
+	}
+}
+
+func TestPerlin(t *testing.T) {
+	a, b := New(2).Perlin(), New(2).Perlin()
+	for i := 0; i < 100; i++ {
+		x, y := float64(i)*0.37, float64(i)*0.11
+		v := a.Noise2(x, y)
+		if v != b.Noise2(x, y) {
+			t.Fatal("Perlin: same seed produced different noise")
+		}
+		if v < -1 || v > 1 {
+			t.Fatalf("Perlin: Noise2(%f, %f) = %f, outside [-1, 1]", x, y, v)
+		}
+	}
+	if v := a.Noise2(3, 4); v != 0 {
+		t.Errorf("Perlin: noise at integer lattice points should be 0, got %f", v)
+	}
+}
@@ -0,0 +1,86 @@
+// procgen/mesh.go
+package procgen
+
+import (
+	"math"
+	"sort"
+
+	"github.com/sbecker11/webgl-point-cloud/mesh"
+)
+
+// SampleMesh returns numPoints points distributed uniformly over the surface
+// of m: triangles are chosen with probability proportional to their area and
+// points are placed uniformly within each chosen triangle. Each point gets a
+// unit normal interpolated from the triangle's vertex normals; if m has no
+// normals, smooth ones are computed and stored in m first.
+// It also returns the index of the triangle each point was sampled from.
+// Returns nil slices if the mesh has no area.
+func (g *Generator) SampleMesh(m *mesh.Mesh, numPoints int) (coords, normals []float32, triangles []int) {
+	n := m.TriangleCount()
+	cdf := make([]float64, n)
+	total := 0.0
+	for t := 0; t < n; t++ {
+		total += m.TriangleArea(t)
+		cdf[t] = total
+	}
+	if total == 0 {
+		return nil, nil, nil
+	}
+	if len(m.Normals) != len(m.Positions) {
+		m.ComputeVertexNormals()
+	}
+
+	coords = make([]float32, 0, numPoints*3)
+	normals = make([]float32, 0, numPoints*3)
+	triangles = make([]int, 0, numPoints)
+	for i := 0; i < numPoints; i++ {
+		t := sort.SearchFloat64s(cdf, g.rng.Float64()*total)
+		if t >= n {
+			t = n - 1
+		}
+		// Uniform barycentric coordinates: fold the unit square onto the triangle.
+		u, v := g.rng.Float32(), g.rng.Float32()
+		if u+v > 1 {
+			u, v = 1-u, 1-v
+		}
+		w := 1 - u - v
+		a, b, c := m.Triangle(t)
+		for k := uint32(0); k < 3; k++ {
+			coords = append(coords, w*m.Positions[a*3+k]+u*m.Positions[b*3+k]+v*m.Positions[c*3+k])
+		}
+		nx := w*m.Normals[a*3] + u*m.Normals[b*3] + v*m.Normals[c*3]
+		ny := w*m.Normals[a*3+1] + u*m.Normals[b*3+1] + v*m.Normals[c*3+1]
+		nz := w*m.Normals[a*3+2] + u*m.Normals[b*3+2] + v*m.Normals[c*3+2]
+		if l := float32(math.Sqrt(float64(nx*nx + ny*ny + nz*nz))); l > 0 {
+			nx, ny, nz = nx/l, ny/l, nz/l
+		}
+		normals = append(normals, nx, ny, nz)
+		triangles = append(triangles, t)
+	}
+	return coords, normals, triangles
+}
+
+// appendEllipsoid appends a UV-sphere tessellation of an axis-aligned
+// ellipsoid with the given center and radii to m.
+func appendEllipsoid(m *mesh.Mesh, center, radii [3]float32, rings, segments int) {
+	base := uint32(len(m.Positions) / 3)
+	for r := 0; r <= rings; r++ {
+		theta := math.Pi * float64(r) / float64(rings)
+		for s := 0; s <= segments; s++ {
+			phi := 2 * math.Pi * float64(s) / float64(segments)
+			m.Positions = append(m.Positions,
+				center[0]+radii[0]*float32(math.Sin(theta)*math.Cos(phi)),
+				center[1]+radii[1]*float32(math.Cos(theta)),
+				center[2]+radii[2]*float32(math.Sin(theta)*math.Sin(phi)),
+			)
+		}
+	}
+	stride := uint32(segments + 1)
+	for r := uint32(0); r < uint32(rings); r++ {
+		for s := uint32(0); s < uint32(segments); s++ {
+			a := base + r*stride + s
+			b := a + stride
+			m.Indices = append(m.Indices, a, a+1, b, a+1, b+1, b)
+		}
+	}
+}
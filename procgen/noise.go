@@ -0,0 +1,87 @@
+// procgen/noise.go
+package procgen
+
+import "math"
+
+// Perlin is a 2D gradient noise function with a permutation table drawn
+// from a Generator, so terrain built from it is reproducible from the seed.
+type Perlin struct {
+	perm [512]int
+}
+
+// Perlin returns a new noise function whose permutation is drawn from g.
+func (g *Generator) Perlin() *Perlin {
+	p := &Perlin{}
+	order := g.rng.Perm(256)
+	for i := 0; i < 512; i++ {
+		p.perm[i] = order[i&255]
+	}
+	return p
+}
+
+func fade(t float64) float64 {
+	return t * t * t * (t*(t*6-15) + 10)
+}
+
+func lerp(a, b, t float64) float64 {
+	return a + t*(b-a)
+}
+
+// grad returns the dot product of the offset (x, y) with one of eight
+// gradient directions selected by hash.
+func grad(hash int, x, y float64) float64 {
+	switch hash & 7 {
+	case 0:
+		return x + y
+	case 1:
+		return -x + y
+	case 2:
+		return x - y
+	case 3:
+		return -x - y
+	case 4:
+		return x
+	case 5:
+		return -x
+	case 6:
+		return y
+	default:
+		return -y
+	}
+}
+
+// Noise2 returns the noise value at (x, y), roughly in [-1, 1].
+func (p *Perlin) Noise2(x, y float64) float64 {
+	xf, yf := math.Floor(x), math.Floor(y)
+	xi, yi := int(xf)&255, int(yf)&255
+	x, y = x-xf, y-yf
+	u, v := fade(x), fade(y)
+
+	aa := p.perm[p.perm[xi]+yi]
+	ab := p.perm[p.perm[xi]+yi+1]
+	ba := p.perm[p.perm[xi+1]+yi]
+	bb := p.perm[p.perm[xi+1]+yi+1]
+
+	return lerp(
+		lerp(grad(aa, x, y), grad(ba, x-1, y), u),
+		lerp(grad(ab, x, y-1), grad(bb, x-1, y-1), u),
+		v,
+	)
+}
+
+// Fractal sums octaves of noise (fractional Brownian motion), halving the
+// amplitude and doubling the frequency at each octave. The result is
+// normalized back to roughly [-1, 1].
+func (p *Perlin) Fractal(x, y float64, octaves int) float64 {
+	sum, amp, freq, norm := 0.0, 1.0, 1.0, 0.0
+	for i := 0; i < octaves; i++ {
+		sum += amp * p.Noise2(x*freq, y*freq)
+		norm += amp
+		amp *= 0.5
+		freq *= 2
+	}
+	if norm == 0 {
+		return 0
+	}
+	return sum / norm
+}
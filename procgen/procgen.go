@@ -0,0 +1,68 @@
+// procgen/procgen.go
+package procgen
+
+import (
+	"math"
+	"math/rand"
+
+	"github.com/sbecker11/webgl-point-cloud/glf32"
+)
+
+// Generator produces procedural point data from its own random source, so
+// that a given seed always yields the same scene. It is not safe for
+// concurrent use; give each goroutine its own Generator.
+type Generator struct {
+	rng  *rand.Rand
+	seed int64
+}
+
+// New returns a Generator whose random source is seeded with seed.
+func New(seed int64) *Generator {
+	return &Generator{rng: rand.New(rand.NewSource(seed)), seed: seed}
+}
+
+// NewWithRand returns a Generator that draws from rng. Seed reports 0 for
+// such generators, since the seed of an injected source is unknown.
+// Panics if rng is nil.
+func NewWithRand(rng *rand.Rand) *Generator {
+	if rng == nil {
+		panic("NewWithRand: rng must not be nil")
+	}
+	return &Generator{rng: rng}
+}
+
+// Seed returns the seed the Generator was created with.
+func (g *Generator) Seed() int64 {
+	return g.seed
+}
+
+// Rand returns the Generator's random source.
+func (g *Generator) Rand() *rand.Rand {
+	return g.rng
+}
+
+// Float32 returns a uniform random value in [0, 1).
+func (g *Generator) Float32() float32 {
+	return g.rng.Float32()
+}
+
+// Normal returns a normally distributed value with mean 0 and standard
+// deviation stdDev, using the Box-Muller transform.
+func (g *Generator) Normal(stdDev float32) float32 {
+	// 1 - Float64 lies in (0, 1], which keeps the logarithm finite.
+	u1, u2 := 1-g.rng.Float64(), g.rng.Float64()
+	return stdDev * float32(math.Sqrt(-2.0*math.Log(u1))*math.Cos(2.0*math.Pi*u2))
+}
+
+// NormalCluster creates a cluster of points with a normal (Gaussian) distribution.
+// It returns packed xyz coordinates and packed RGBA colors (alpha 1).
+func (g *Generator) NormalCluster(numPoints int, center glf32.Vec3, stdDev float32, color glf32.Vec3) (coords []float32, colors []float32) {
+	coords = make([]float32, 0, numPoints*3)
+	colors = make([]float32, 0, numPoints*4) // 4 components for RGBA
+
+	for i := 0; i < numPoints; i++ {
+		coords = append(coords, center[0]+g.Normal(stdDev), center[1]+g.Normal(stdDev), center[2]+g.Normal(stdDev))
+		colors = append(colors, color[0], color[1], color[2], 1.0) // Add alpha
+	}
+	return coords, colors
+}
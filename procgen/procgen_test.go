@@ -0,0 +1,71 @@
+// procgen/procgen_test.go
+// usage: go test
+
+package procgen
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+
+	"github.com/sbecker11/webgl-point-cloud/glf32"
+)
+
+func slicesEqual(a, b []float32) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func TestSameSeedIsReproducible(t *testing.T) {
+	a, ac := New(42).NormalCluster(100, glf32.Vec3{1, 2, 3}, 0.5, glf32.Vec3{1, 0, 0})
+	b, bc := New(42).NormalCluster(100, glf32.Vec3{1, 2, 3}, 0.5, glf32.Vec3{1, 0, 0})
+	if !slicesEqual(a, b) || !slicesEqual(ac, bc) {
+		t.Error("NormalCluster: same seed produced different clusters")
+	}
+
+	c, _ := New(43).NormalCluster(100, glf32.Vec3{1, 2, 3}, 0.5, glf32.Vec3{1, 0, 0})
+	if slicesEqual(a, c) {
+		t.Error("NormalCluster: different seeds produced identical clusters")
+	}
+}
+
+func TestNewWithRand(t *testing.T) {
+	g := NewWithRand(rand.New(rand.NewSource(7)))
+	h := New(7)
+	if g.Float32() != h.Float32() {
+		t.Error("NewWithRand: injected source does not match New with the same seed")
+	}
+	if g.Seed() != 0 || h.Seed() != 7 {
+		t.Errorf("Seed: expected 0 and 7, got %d and %d", g.Seed(), h.Seed())
+	}
+}
+
+func TestNormalClusterStatistics(t *testing.T) {
+	center := glf32.Vec3{1, -2, 0.5}
+	n := 20000
+	coords, colors := New(1).NormalCluster(n, center, 0.2, glf32.Vec3{0, 1, 0})
+	if len(coords) != n*3 || len(colors) != n*4 {
+		t.Fatalf("NormalCluster: expected %d coords and %d colors, got %d and %d", n*3, n*4, len(coords), len(colors))
+	}
+
+	for axis := 0; axis < 3; axis++ {
+		var sum, sumSq float64
+		for i := 0; i < n; i++ {
+			v := float64(coords[i*3+axis] - center[axis])
+			sum += v
+			sumSq += v * v
+		}
+		mean := sum / float64(n)
+		stdDev := math.Sqrt(sumSq/float64(n) - mean*mean)
+		if math.Abs(mean) > 0.01 || math.Abs(stdDev-0.2) > 0.01 {
+			t.Errorf("axis %d: expected mean 0 and stdDev 0.2, got %f and %f", axis, mean, stdDev)
+		}
+	}
+}
@@ -0,0 +1,203 @@
+// procgen/sampling.go
+package procgen
+
+import (
+	"math"
+
+	"github.com/sbecker11/webgl-point-cloud/glf32"
+)
+
+// hashGrid buckets points into cubic cells of a fixed size, so that
+// neighbors within one cell size can be found without scanning every point.
+type hashGrid struct {
+	cell   float32
+	cells  map[[3]int32][]int
+	coords []float32
+}
+
+func newHashGrid(cell float32, coords []float32) *hashGrid {
+	return &hashGrid{cell: cell, cells: make(map[[3]int32][]int), coords: coords}
+}
+
+func (h *hashGrid) key(x, y, z float32) [3]int32 {
+	return [3]int32{
+		int32(math.Floor(float64(x / h.cell))),
+		int32(math.Floor(float64(y / h.cell))),
+		int32(math.Floor(float64(z / h.cell))),
+	}
+}
+
+// insert records that point i of h.coords occupies its cell.
+func (h *hashGrid) insert(i int) {
+	k := h.key(h.coords[i*3], h.coords[i*3+1], h.coords[i*3+2])
+	h.cells[k] = append(h.cells[k], i)
+}
+
+// near reports whether any inserted point lies closer than radius to
+// (x, y, z). radius must not exceed the cell size.
+func (h *hashGrid) near(x, y, z, radius float32) bool {
+	k := h.key(x, y, z)
+	r2 := radius * radius
+	for dx := int32(-1); dx <= 1; dx++ {
+		for dy := int32(-1); dy <= 1; dy++ {
+			for dz := int32(-1); dz <= 1; dz++ {
+				for _, i := range h.cells[[3]int32{k[0] + dx, k[1] + dy, k[2] + dz}] {
+					ex, ey, ez := h.coords[i*3]-x, h.coords[i*3+1]-y, h.coords[i*3+2]-z
+					if ex*ex+ey*ey+ez*ez < r2 {
+						return true
+					}
+				}
+			}
+		}
+	}
+	return false
+}
+
+// PoissonDiskBox fills the axis-aligned box [min, max] with points no closer
+// than radius to each other, using Bridson's algorithm with 30 candidates per
+// active point. Generation stops early once maxPoints points exist
+// (maxPoints <= 0 means no limit). Returns packed xyz coordinates.
+// Panics if radius is not positive.
+func (g *Generator) PoissonDiskBox(min, max glf32.Vec3, radius float32, maxPoints int) []float32 {
+	if radius <= 0 {
+		panic("PoissonDiskBox: radius must be positive")
+	}
+	const attempts = 30
+	var coords []float32
+	grid := newHashGrid(radius, nil)
+	add := func(x, y, z float32) int {
+		coords = append(coords, x, y, z)
+		grid.coords = coords
+		i := len(coords)/3 - 1
+		grid.insert(i)
+		return i
+	}
+	inBox := func(x, y, z float32) bool {
+		return x >= min[0] && x <= max[0] && y >= min[1] && y <= max[1] && z >= min[2] && z <= max[2]
+	}
+	full := func() bool { return maxPoints > 0 && len(coords)/3 >= maxPoints }
+
+	active := []int{add(
+		min[0]+g.rng.Float32()*(max[0]-min[0]),
+		min[1]+g.rng.Float32()*(max[1]-min[1]),
+		min[2]+g.rng.Float32()*(max[2]-min[2]),
+	)}
+	for len(active) > 0 && !full() {
+		a := g.rng.Intn(len(active))
+		p := active[a]
+		found := false
+		for k := 0; k < attempts && !full(); k++ {
+			// Candidate in the spherical shell [radius, 2*radius] around p.
+			dx, dy, dz := g.unitVector()
+			d := radius * (1 + g.rng.Float32())
+			x, y, z := coords[p*3]+dx*d, coords[p*3+1]+dy*d, coords[p*3+2]+dz*d
+			if !inBox(x, y, z) || grid.near(x, y, z, radius) {
+				continue
+			}
+			active = append(active, add(x, y, z))
+			found = true
+		}
+		if !found {
+			active[a] = active[len(active)-1]
+			active = active[:len(active)-1]
+		}
+	}
+	return coords
+}
+
+// StratifiedGrid divides the box [min, max] into nx * ny * nz equal cells and
+// places one uniformly jittered point in each, giving even coverage without
+// the regular pattern of a lattice. Returns packed xyz coordinates.
+func (g *Generator) StratifiedGrid(min, max glf32.Vec3, nx, ny, nz int) []float32 {
+	if nx <= 0 || ny <= 0 || nz <= 0 {
+		return nil
+	}
+	sx := (max[0] - min[0]) / float32(nx)
+	sy := (max[1] - min[1]) / float32(ny)
+	sz := (max[2] - min[2]) / float32(nz)
+	coords := make([]float32, 0, nx*ny*nz*3)
+	for i := 0; i < nx; i++ {
+		for j := 0; j < ny; j++ {
+			for k := 0; k < nz; k++ {
+				coords = append(coords,
+					min[0]+(float32(i)+g.rng.Float32())*sx,
+					min[1]+(float32(j)+g.rng.Float32())*sy,
+					min[2]+(float32(k)+g.rng.Float32())*sz,
+				)
+			}
+		}
+	}
+	return coords
+}
+
+// BlueNoiseSphere returns up to numPoints points on the surface of a sphere
+// centered at the origin, spaced by Poisson-disk dart throwing so that no
+// two points are closer than about 70% of the ideal spacing for numPoints.
+// Fewer points are returned if the sphere fills up first.
+func (g *Generator) BlueNoiseSphere(numPoints int, radius float32) []float32 {
+	if numPoints <= 0 {
+		return nil
+	}
+	// Area per point on the unit sphere is 4*pi/n; a hexagonal packing of
+	// disks of that area has center spacing sqrt(2*area/sqrt(3)). Random dart
+	// throwing jams well before hexagonal density, hence the 0.7 factor.
+	spacing := float32(0.7 * math.Sqrt(2*(4*math.Pi/float64(numPoints))/math.Sqrt(3)))
+	coords := make([]float32, 0, numPoints*3)
+	grid := newHashGrid(spacing, nil)
+	maxFailures := 100 + 30*numPoints
+	for failures := 0; len(coords) < numPoints*3 && failures < maxFailures; {
+		x, y, z := g.unitVector()
+		if grid.near(x, y, z, spacing) {
+			failures++
+			continue
+		}
+		coords = append(coords, x, y, z)
+		grid.coords = coords
+		grid.insert(len(coords)/3 - 1)
+	}
+	for i := range coords {
+		coords[i] *= radius
+	}
+	return coords
+}
+
+// Decimate returns the indices of a subset of coords in which no two points
+// are closer than radius, visiting points in a random order so that the
+// retained points are spread evenly rather than biased toward the start of
+// the buffer. The indices are returned in ascending order.
+// Panics if radius is not positive or len(coords) is not a multiple of 3.
+func (g *Generator) Decimate(coords []float32, radius float32) []int {
+	if radius <= 0 {
+		panic("Decimate: radius must be positive")
+	}
+	if len(coords)%3 != 0 {
+		panic("Decimate: coords slice length must be a multiple of 3")
+	}
+	n := len(coords) / 3
+	grid := newHashGrid(radius, coords)
+	keep := make([]bool, n)
+	for _, i := range g.rng.Perm(n) {
+		if grid.near(coords[i*3], coords[i*3+1], coords[i*3+2], radius) {
+			continue
+		}
+		grid.insert(i)
+		keep[i] = true
+	}
+	var indices []int
+	for i, k := range keep {
+		if k {
+			indices = append(indices, i)
+		}
+	}
+	return indices
+}
+
+// Gather returns the elements of data selected by indices, where each
+// element has stride components (3 for coordinates, 4 for RGBA colors).
+func Gather(data []float32, stride int, indices []int) []float32 {
+	out := make([]float32, 0, len(indices)*stride)
+	for _, i := range indices {
+		out = append(out, data[i*stride:(i+1)*stride]...)
+	}
+	return out
+}
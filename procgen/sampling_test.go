@@ -0,0 +1,98 @@
+// procgen/sampling_test.go
+// usage: go test
+
+package procgen
+
+import (
+	"math"
+	"testing"
+
+	"github.com/sbecker11/webgl-point-cloud/glf32"
+)
+
+// minSpacing returns the smallest distance between any two points.
+func minSpacing(coords []float32) float32 {
+	best := float32(math.MaxFloat32)
+	for i := 0; i < len(coords); i += 3 {
+		for j := i + 3; j < len(coords); j += 3 {
+			dx, dy, dz := coords[i]-coords[j], coords[i+1]-coords[j+1], coords[i+2]-coords[j+2]
+			if d := float32(math.Sqrt(float64(dx*dx + dy*dy + dz*dz))); d < best {
+				best = d
+			}
+		}
+	}
+	return best
+}
+
+func TestPoissonDiskBox(t *testing.T) {
+	min, max := glf32.Vec3{-1, -1, -1}, glf32.Vec3{1, 1, 1}
+	coords := New(4).PoissonDiskBox(min, max, 0.2, 0)
+	if n := len(coords) / 3; n < 300 {
+		t.Errorf("PoissonDiskBox: expected the box to fill with several hundred points, got %d", n)
+	}
+	if d := minSpacing(coords); d < 0.2 {
+		t.Errorf("PoissonDiskBox: points closer than the radius: %f", d)
+	}
+	for i, v := range coords {
+		if v < -1 || v > 1 {
+			t.Fatalf("PoissonDiskBox: coordinate %d outside the box: %f", i, v)
+		}
+	}
+
+	if n := len(New(4).PoissonDiskBox(min, max, 0.2, 50)) / 3; n != 50 {
+		t.Errorf("PoissonDiskBox: expected maxPoints to cap the result at 50, got %d", n)
+	}
+}
+
+func TestStratifiedGrid(t *testing.T) {
+	coords := New(8).StratifiedGrid(glf32.Vec3{0, 0, 0}, glf32.Vec3{4, 2, 1}, 4, 2, 1)
+	if len(coords) != 8*3 {
+		t.Fatalf("StratifiedGrid: expected 8 points, got %d", len(coords)/3)
+	}
+	// Each unit cell must contain exactly one point.
+	seen := map[[3]int]bool{}
+	for i := 0; i < len(coords); i += 3 {
+		cell := [3]int{int(coords[i]), int(coords[i+1]), int(coords[i+2])}
+		if seen[cell] {
+			t.Errorf("StratifiedGrid: cell %v contains more than one point", cell)
+		}
+		seen[cell] = true
+	}
+}
+
+func TestBlueNoiseSphere(t *testing.T) {
+	coords := New(6).BlueNoiseSphere(500, 2)
+	if n := len(coords) / 3; n != 500 {
+		t.Errorf("BlueNoiseSphere: expected 500 points, got %d", n)
+	}
+	for i := 0; i < len(coords); i += 3 {
+		r := math.Sqrt(float64(coords[i]*coords[i] + coords[i+1]*coords[i+1] + coords[i+2]*coords[i+2]))
+		if math.Abs(r-2) > 1e-4 {
+			t.Fatalf("BlueNoiseSphere: point %d at radius %f, expected 2", i/3, r)
+		}
+	}
+	// Uniform random points on a sphere would have a far smaller minimum spacing.
+	if d := minSpacing(coords); d < 0.1 {
+		t.Errorf("BlueNoiseSphere: minimum spacing %f is too small for blue noise", d)
+	}
+}
+
+func TestDecimate(t *testing.T) {
+	coords, colors := New(2).SphereVolume(3000, 1)
+	indices := New(2).Decimate(coords, 0.15)
+	if len(indices) == 0 || len(indices) >= 3000 {
+		t.Fatalf("Decimate: expected a strict subset, got %d of 3000 points", len(indices))
+	}
+	for i := 1; i < len(indices); i++ {
+		if indices[i] <= indices[i-1] {
+			t.Fatal("Decimate: indices are not in ascending order")
+		}
+	}
+	kept := Gather(coords, 3, indices)
+	if d := minSpacing(kept); d < 0.15 {
+		t.Errorf("Decimate: kept points closer than the radius: %f", d)
+	}
+	if keptColors := Gather(colors, 4, indices); len(keptColors) != len(indices)*4 {
+		t.Errorf("Gather: expected %d color components, got %d", len(indices)*4, len(keptColors))
+	}
+}
@@ -0,0 +1,267 @@
+// procgen/shapes.go
+package procgen
+
+import (
+	"math"
+
+	"github.com/sbecker11/webgl-point-cloud/colormap"
+	"github.com/sbecker11/webgl-point-cloud/glf32"
+	"github.com/sbecker11/webgl-point-cloud/mesh"
+)
+
+// Every shape generator returns packed xyz coordinates and packed RGBA
+// colors, like NormalCluster, and is sized to fit roughly within [-1, 1].
+
+func clamp01(t float32) float32 {
+	if t < 0 {
+		return 0
+	}
+	if t > 1 {
+		return 1
+	}
+	return t
+}
+
+// appendRamp appends the rainbow ramp color for t, with alpha 1, to colors.
+func appendRamp(colors []float32, t float32) []float32 {
+	return colormap.Rainbow.AppendRGBA(colors, t)
+}
+
+// unitVector returns a uniformly distributed direction on the unit sphere.
+func (g *Generator) unitVector() (x, y, z float32) {
+	cosTheta := 2*g.rng.Float64() - 1
+	sinTheta := math.Sqrt(1 - cosTheta*cosTheta)
+	phi := 2 * math.Pi * g.rng.Float64()
+	return float32(sinTheta * math.Cos(phi)), float32(cosTheta), float32(sinTheta * math.Sin(phi))
+}
+
+// SphereSurface samples numPoints uniformly over the surface of a sphere
+// centered at the origin, colored by surface normal.
+func (g *Generator) SphereSurface(numPoints int, radius float32) (coords []float32, colors []float32) {
+	coords = make([]float32, 0, numPoints*3)
+	colors = make([]float32, 0, numPoints*4)
+	for i := 0; i < numPoints; i++ {
+		x, y, z := g.unitVector()
+		coords = append(coords, x*radius, y*radius, z*radius)
+		colors = append(colors, (x+1)/2, (y+1)/2, (z+1)/2, 1)
+	}
+	return coords, colors
+}
+
+// SphereVolume samples numPoints uniformly inside a solid sphere centered at
+// the origin, colored by distance from the center.
+func (g *Generator) SphereVolume(numPoints int, radius float32) (coords []float32, colors []float32) {
+	coords = make([]float32, 0, numPoints*3)
+	colors = make([]float32, 0, numPoints*4)
+	for i := 0; i < numPoints; i++ {
+		x, y, z := g.unitVector()
+		// The cube root makes the density uniform in volume rather than in radius.
+		r := float32(math.Cbrt(g.rng.Float64()))
+		coords = append(coords, x*r*radius, y*r*radius, z*r*radius)
+		colors = appendRamp(colors, r)
+	}
+	return coords, colors
+}
+
+// Torus samples numPoints uniformly over the surface of a torus lying in the
+// XZ plane, with the given major (ring) and minor (tube) radii, colored by
+// the angle around the tube.
+func (g *Generator) Torus(numPoints int, major, minor float32) (coords []float32, colors []float32) {
+	coords = make([]float32, 0, numPoints*3)
+	colors = make([]float32, 0, numPoints*4)
+	R, r := float64(major), float64(minor)
+	for len(coords) < numPoints*3 {
+		u := 2 * math.Pi * g.rng.Float64() // around the ring
+		v := 2 * math.Pi * g.rng.Float64() // around the tube
+		// The outer side of the tube has more area than the inner side;
+		// rejection sampling against the local area factor keeps density uniform.
+		if g.rng.Float64()*(R+r) > R+r*math.Cos(v) {
+			continue
+		}
+		w := R + r*math.Cos(v)
+		coords = append(coords, float32(w*math.Cos(u)), float32(r*math.Sin(v)), float32(w*math.Sin(u)))
+		colors = appendRamp(colors, float32(v/(2*math.Pi)))
+	}
+	return coords, colors
+}
+
+// Helix samples numPoints along a vertical helix around the Y axis with the
+// given number of turns, radius and height. Points are jittered off the
+// curve by jitter and colored by height. A radius that shrinks linearly to
+// zero at the top can be requested with spiral, turning the helix into a
+// conical spiral.
+func (g *Generator) Helix(numPoints int, turns, radius, height, jitter float32, spiral bool) (coords []float32, colors []float32) {
+	coords = make([]float32, 0, numPoints*3)
+	colors = make([]float32, 0, numPoints*4)
+	for i := 0; i < numPoints; i++ {
+		t := g.rng.Float32()
+		angle := float64(2 * math.Pi * turns * t)
+		r := radius
+		if spiral {
+			r *= 1 - t
+		}
+		coords = append(coords,
+			r*float32(math.Cos(angle))+g.Normal(jitter),
+			(t-0.5)*height+g.Normal(jitter),
+			r*float32(math.Sin(angle))+g.Normal(jitter),
+		)
+		colors = appendRamp(colors, t)
+	}
+	return coords, colors
+}
+
+// Lorenz integrates the Lorenz system (sigma=10, rho=28, beta=8/3) from a
+// randomized starting point and returns numPoints samples of the attractor,
+// scaled and centered to fit the scene, colored by speed.
+func (g *Generator) Lorenz(numPoints int) (coords []float32, colors []float32) {
+	const (
+		sigma = 10.0
+		rho   = 28.0
+		beta  = 8.0 / 3.0
+		dt    = 0.005
+		scale = 1.0 / 25.0
+		skip  = 1000 // settle onto the attractor before recording
+	)
+	coords = make([]float32, 0, numPoints*3)
+	colors = make([]float32, 0, numPoints*4)
+	x, y, z := 0.1+g.rng.Float64(), g.rng.Float64(), g.rng.Float64()
+	for i := 0; i < numPoints+skip; i++ {
+		dx := sigma * (y - x)
+		dy := x*(rho-z) - y
+		dz := x*y - beta*z
+		x, y, z = x+dx*dt, y+dy*dt, z+dz*dt
+		if i < skip {
+			continue
+		}
+		speed := math.Sqrt(dx*dx+dy*dy+dz*dz) / 250
+		// The attractor's lobes lie in XY, centered around z = rho - 1.
+		coords = append(coords, float32(x*scale), float32((z-(rho-1))*scale), float32(y*scale))
+		colors = appendRamp(colors, float32(speed))
+	}
+	return coords, colors
+}
+
+// Galaxy samples a spiral galaxy in the XZ plane: a dense yellow-white bulge
+// plus arms logarithmic spiral arms of bluish stars. The disk is thin in Y.
+func (g *Generator) Galaxy(numPoints int, arms int, radius float32) (coords []float32, colors []float32) {
+	coords = make([]float32, 0, numPoints*3)
+	colors = make([]float32, 0, numPoints*4)
+	if arms < 1 {
+		arms = 1
+	}
+	bulge := numPoints / 5
+	for i := 0; i < numPoints; i++ {
+		if i < bulge {
+			coords = append(coords, g.Normal(radius*0.12), g.Normal(radius*0.06), g.Normal(radius*0.12))
+			colors = append(colors, 1, 0.9, 0.6+0.3*g.rng.Float32(), 1)
+			continue
+		}
+		// Distance along the arm, biased toward the center.
+		t := float32(math.Sqrt(g.rng.Float64()))
+		arm := g.rng.Intn(arms)
+		angle := float64(t)*3*math.Pi + 2*math.Pi*float64(arm)/float64(arms)
+		r := t * radius
+		spread := radius * (0.015 + 0.035*t)
+		coords = append(coords,
+			r*float32(math.Cos(angle))+g.Normal(spread),
+			g.Normal(radius*0.02*(1-t)+radius*0.005),
+			r*float32(math.Sin(angle))+g.Normal(spread),
+		)
+		colors = append(colors, 0.5+0.3*(1-t), 0.6+0.3*(1-t), 1, 1)
+	}
+	return coords, colors
+}
+
+// Terrain samples numPoints at random XZ positions over a size x size square
+// centered at the origin, with heights from fractal Perlin noise of the
+// given amplitude, colored by height.
+func (g *Generator) Terrain(numPoints int, size, amplitude float32) (coords []float32, colors []float32) {
+	coords = make([]float32, 0, numPoints*3)
+	colors = make([]float32, 0, numPoints*4)
+	noise := g.Perlin()
+	const frequency = 3.0
+	for i := 0; i < numPoints; i++ {
+		x := (g.rng.Float32() - 0.5) * size
+		z := (g.rng.Float32() - 0.5) * size
+		h := float32(noise.Fractal(float64(x/size)*frequency, float64(z/size)*frequency, 5))
+		coords = append(coords, x, h*amplitude, z)
+		colors = appendRamp(colors, (h+1)/2)
+	}
+	return coords, colors
+}
+
+// bunnyParts are the ellipsoids (center, radii) making up the bunny-like mesh:
+// body, head, two ears, a tail and two feet.
+var bunnyParts = [][2][3]float32{
+	{{0, -0.15, 0}, {0.55, 0.45, 0.4}},
+	{{0.45, 0.3, 0}, {0.28, 0.25, 0.24}},
+	{{0.4, 0.75, 0.1}, {0.07, 0.3, 0.05}},
+	{{0.5, 0.72, -0.1}, {0.07, 0.28, 0.05}},
+	{{-0.55, 0, 0}, {0.12, 0.12, 0.12}},
+	{{0.3, -0.55, 0.2}, {0.22, 0.07, 0.1}},
+	{{0.3, -0.55, -0.2}, {0.22, 0.07, 0.1}},
+}
+
+// BunnyMesh returns a low-poly bunny-like triangle mesh assembled from
+// ellipsoids. It is a stand-in for the Stanford bunny for demos and tests.
+func BunnyMesh() *mesh.Mesh {
+	m := &mesh.Mesh{}
+	for _, p := range bunnyParts {
+		appendEllipsoid(m, p[0], p[1], 16, 24)
+	}
+	return m
+}
+
+// insideBunnyPart reports whether (x, y, z) lies strictly inside any part
+// other than skip.
+func insideBunnyPart(x, y, z float32, skip int) bool {
+	for i, p := range bunnyParts {
+		if i == skip {
+			continue
+		}
+		dx := (x - p[0][0]) / p[1][0]
+		dy := (y - p[0][1]) / p[1][1]
+		dz := (z - p[0][2]) / p[1][2]
+		if dx*dx+dy*dy+dz*dz < 0.98 {
+			return true
+		}
+	}
+	return false
+}
+
+// Bunny samples numPoints over the outer surface of BunnyMesh, discarding
+// samples buried inside overlapping parts, shaded with a warm ramp by height.
+func (g *Generator) Bunny(numPoints int) (coords []float32, colors []float32) {
+	m := BunnyMesh()
+	// Each ellipsoid contributes the same number of triangles, which maps
+	// triangle indices back to parts.
+	trianglesPerPart := m.TriangleCount() / len(bunnyParts)
+	coords = make([]float32, 0, numPoints*3)
+	colors = make([]float32, 0, numPoints*4)
+	for len(coords) < numPoints*3 {
+		batch, _, tris := g.SampleMesh(m, numPoints-len(coords)/3)
+		for i, t := range tris {
+			x, y, z := batch[i*3], batch[i*3+1], batch[i*3+2]
+			if insideBunnyPart(x, y, z, t/trianglesPerPart) {
+				continue
+			}
+			coords = append(coords, x, y, z)
+			shade := 0.6 + 0.4*clamp01((y+0.7)/1.8)
+			colors = append(colors, shade, shade*0.85, shade*0.7, 1)
+		}
+	}
+	return coords, colors
+}
+
+// Clusters returns three Gaussian clusters of numPoints points each, in red,
+// green and blue: the original demo scene.
+func (g *Generator) Clusters(numPoints int) (coords []float32, colors []float32) {
+	centers := []glf32.Vec3{{0.5, 0.5, 0.5}, {-0.5, -0.5, 0.5}, {0.0, 0.5, -0.5}}
+	tints := []glf32.Vec3{{1, 0, 0}, {0, 1, 0}, {0, 0, 1}}
+	for i := range centers {
+		c, col := g.NormalCluster(numPoints, centers[i], 0.2, tints[i])
+		coords = append(coords, c...)
+		colors = append(colors, col...)
+	}
+	return coords, colors
+}
@@ -0,0 +1,150 @@
+// procgen/town.go
+package procgen
+
+import (
+	"math"
+
+	"github.com/sbecker11/webgl-point-cloud/pointcloud"
+)
+
+// box is an axis-aligned building footprint with a height.
+type box struct {
+	x0, z0, x1, z1, height float32
+}
+
+func (b box) contains(x, z, margin float32) bool {
+	return x >= b.x0-margin && x <= b.x1+margin && z >= b.z0-margin && z <= b.z1+margin
+}
+
+// Town generates a small classified scene resembling an aerial LiDAR tile:
+// rolling ground with a pond, box buildings, trees and bushes. Every point
+// carries an ASPRS class (ground, water, building, low and high vegetation)
+// so classification styling and filtering have something to work on, and
+// an "intensity" attribute in [0, 1] mimicking return strength: bright
+// roofs and roads, darker vegetation and near-black water.
+func (g *Generator) Town(numPoints int) *pointcloud.Cloud {
+	const size = 2.4
+	noise := g.Perlin()
+	ground := func(x, z float32) float32 {
+		return 0.12 * float32(noise.Fractal(float64(x)*0.8, float64(z)*0.8, 3))
+	}
+	const waterLevel = -0.05
+
+	var buildings []box
+	for len(buildings) < 7 {
+		w, d := 0.15+0.2*g.rng.Float32(), 0.15+0.2*g.rng.Float32()
+		x, z := (g.rng.Float32()-0.5)*(size-0.6), (g.rng.Float32()-0.5)*(size-0.6)
+		b := box{x, z, x + w, z + d, 0.1 + 0.25*g.rng.Float32()}
+		ok := ground(x+w/2, z+d/2) > waterLevel+0.02
+		for _, o := range buildings {
+			if o.contains(b.x0, b.z0, w+0.05) || o.contains(b.x1, b.z1, 0.05) {
+				ok = false
+			}
+		}
+		if ok {
+			buildings = append(buildings, b)
+		}
+	}
+	inBuilding := func(x, z, margin float32) bool {
+		for _, b := range buildings {
+			if b.contains(x, z, margin) {
+				return true
+			}
+		}
+		return false
+	}
+
+	// Mean return intensity per class.
+	meanIntensity := map[uint8]float32{
+		pointcloud.ClassGround:         0.45,
+		pointcloud.ClassWater:          0.05,
+		pointcloud.ClassBuilding:       0.7,
+		pointcloud.ClassHighVegetation: 0.25,
+		pointcloud.ClassLowVegetation:  0.3,
+	}
+	cloud := &pointcloud.Cloud{Name: "town"}
+	var intensity []float32
+	add := func(x, y, z, r, gr, b float32, class uint8) {
+		cloud.Coords = append(cloud.Coords, x, y, z)
+		cloud.Colors = append(cloud.Colors, clamp01(r), clamp01(gr), clamp01(b), 1)
+		cloud.Classes = append(cloud.Classes, class)
+		intensity = append(intensity, clamp01(meanIntensity[class]+g.Normal(0.08)))
+	}
+
+	// Ground and water: 55% of the points.
+	for n := 0; n < numPoints*55/100; {
+		x, z := (g.rng.Float32()-0.5)*size, (g.rng.Float32()-0.5)*size
+		if inBuilding(x, z, 0) {
+			continue
+		}
+		n++
+		if y := ground(x, z); y < waterLevel {
+			add(x, waterLevel, z, 0.15, 0.3+0.05*g.rng.Float32(), 0.6, pointcloud.ClassWater)
+		} else {
+			shade := 0.8 + 0.4*g.rng.Float32()
+			add(x, y, z, 0.45*shade, 0.42*shade, 0.3*shade, pointcloud.ClassGround)
+		}
+	}
+
+	// Buildings: 20% of the points, split between roofs and walls by area.
+	for n := 0; n < numPoints*20/100; n++ {
+		b := buildings[g.rng.Intn(len(buildings))]
+		base := ground((b.x0+b.x1)/2, (b.z0+b.z1)/2)
+		w, d := b.x1-b.x0, b.z1-b.z0
+		roofArea, wallArea := w*d, 2*(w+d)*b.height
+		if g.rng.Float32()*(roofArea+wallArea) < roofArea {
+			add(b.x0+g.rng.Float32()*w, base+b.height, b.z0+g.rng.Float32()*d, 0.65, 0.25, 0.2, pointcloud.ClassBuilding)
+			continue
+		}
+		y := base + g.rng.Float32()*b.height
+		t := g.rng.Float32() * 2 * (w + d)
+		var x, z float32
+		switch {
+		case t < w:
+			x, z = b.x0+t, b.z0
+		case t < w+d:
+			x, z = b.x1, b.z0+t-w
+		case t < 2*w+d:
+			x, z = b.x1-(t-w-d), b.z1
+		default:
+			x, z = b.x0, b.z1-(t-2*w-d)
+		}
+		add(x, y, z, 0.7, 0.7, 0.68, pointcloud.ClassBuilding)
+	}
+
+	// Trees: 18% of the points on spherical canopies above the ground.
+	type tree struct{ x, z, y, r float32 }
+	var trees []tree
+	for len(trees) < 30 {
+		x, z := (g.rng.Float32()-0.5)*size, (g.rng.Float32()-0.5)*size
+		if inBuilding(x, z, 0.1) || ground(x, z) < waterLevel {
+			continue
+		}
+		r := 0.05 + 0.05*g.rng.Float32()
+		trees = append(trees, tree{x, z, ground(x, z) + 0.08 + r, r})
+	}
+	for n := 0; n < numPoints*18/100; n++ {
+		t := trees[g.rng.Intn(len(trees))]
+		dx, dy, dz := g.unitVector()
+		// Airborne scanners mostly see the top of the canopy.
+		if dy < -0.3 {
+			dy = -dy
+		}
+		shade := 0.7 + 0.3*dy
+		add(t.x+dx*t.r, t.y+dy*t.r, t.z+dz*t.r, 0.15*shade, 0.5*shade, 0.15*shade, pointcloud.ClassHighVegetation)
+	}
+
+	// Bushes: the remainder, as low Gaussian blobs.
+	for cloud.Len() < numPoints {
+		t := trees[g.rng.Intn(len(trees))]
+		x := t.x + g.Normal(0.12)
+		z := t.z + g.Normal(0.12)
+		if inBuilding(x, z, 0.01) {
+			continue
+		}
+		y := ground(x, z) + float32(math.Abs(float64(g.Normal(0.02))))
+		add(x, y, z, 0.35, 0.55, 0.2, pointcloud.ClassLowVegetation)
+	}
+	cloud.SetAttribute(pointcloud.Attribute{Name: "intensity", Components: 1, Type: pointcloud.Float32}, intensity)
+	return cloud
+}
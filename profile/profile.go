@@ -0,0 +1,95 @@
+// profile/profile.go
+// Package profile extracts cross-sections from point clouds: the points in
+// a thin corridor around the vertical plane through a line, laid out as
+// distance along the line against elevation, as road, rail and terrain
+// surveys are reviewed.
+package profile
+
+import (
+	"errors"
+	"math"
+	"sort"
+)
+
+// Line is a section line from A to B. Up is the vertical direction;
+// elevations are measured along it and the section plane contains it.
+type Line struct {
+	A, B [3]float64
+	Up   [3]float64
+}
+
+// Sample is a point in the section.
+type Sample struct {
+	Index     int     // index of the point in the coordinates given
+	Distance  float64 // along the line from A, across Up
+	Elevation float64 // along Up
+	Offset    float64 // signed distance from the section plane
+}
+
+// Frame returns the unit vertical, the unit direction of the line across
+// it and the unit normal of the section plane, and the line's length
+// across the vertical.
+func (l Line) Frame() (up, along, across [3]float64, length float64, err error) {
+	up, ok := unit(l.Up)
+	if !ok {
+		return up, along, across, 0, errors.New("profile: zero up direction")
+	}
+	d := sub(l.B, l.A)
+	d = sub(d, scale(up, dot(d, up)))
+	along, ok = unit(d)
+	if !ok {
+		return up, along, across, 0, errors.New("profile: line is vertical or has no length")
+	}
+	return up, along, cross(along, up), dot(sub(l.B, l.A), along), nil
+}
+
+// Length returns the length of the line across the vertical.
+func (l Line) Length() float64 {
+	_, _, _, length, _ := l.Frame()
+	return length
+}
+
+// Extract returns the points of packed xyz coordinates within halfWidth of
+// the section plane and between the ends of the line, ordered by distance.
+func Extract(coords []float32, line Line, halfWidth float64) ([]Sample, error) {
+	up, along, across, length, err := line.Frame()
+	if err != nil {
+		return nil, err
+	}
+	var samples []Sample
+	for i := 0; i+2 < len(coords); i += 3 {
+		p := sub([3]float64{float64(coords[i]), float64(coords[i+1]), float64(coords[i+2])}, line.A)
+		offset := dot(p, across)
+		if math.Abs(offset) > halfWidth {
+			continue
+		}
+		distance := dot(p, along)
+		if distance < 0 || distance > length {
+			continue
+		}
+		samples = append(samples, Sample{
+			Index:     i / 3,
+			Distance:  distance,
+			Elevation: dot(p, up) + dot(line.A, up),
+			Offset:    offset,
+		})
+	}
+	sort.Slice(samples, func(i, j int) bool { return samples[i].Distance < samples[j].Distance })
+	return samples, nil
+}
+
+func sub(a, b [3]float64) [3]float64 { return [3]float64{a[0] - b[0], a[1] - b[1], a[2] - b[2]} }
+func dot(a, b [3]float64) float64    { return a[0]*b[0] + a[1]*b[1] + a[2]*b[2] }
+func scale(a [3]float64, s float64) [3]float64 {
+	return [3]float64{a[0] * s, a[1] * s, a[2] * s}
+}
+func cross(a, b [3]float64) [3]float64 {
+	return [3]float64{a[1]*b[2] - a[2]*b[1], a[2]*b[0] - a[0]*b[2], a[0]*b[1] - a[1]*b[0]}
+}
+func unit(a [3]float64) ([3]float64, bool) {
+	l := math.Sqrt(dot(a, a))
+	if l == 0 {
+		return a, false
+	}
+	return scale(a, 1/l), true
+}
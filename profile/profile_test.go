@@ -0,0 +1,56 @@
+// profile/profile_test.go
+// usage: go test
+
+package profile
+
+import (
+	"math"
+	"testing"
+)
+
+func TestExtract(t *testing.T) {
+	// A ramp rising along x, sampled on a grid; the section runs along x
+	// through z = 0.5 from x = 1 to x = 3.
+	var coords []float32
+	for x := 0.0; x <= 4; x += 0.25 {
+		for z := 0.0; z <= 1; z += 0.25 {
+			coords = append(coords, float32(x), float32(0.5*x), float32(z))
+		}
+	}
+	line := Line{A: [3]float64{1, 7, 0.5}, B: [3]float64{3, -2, 0.5}, Up: [3]float64{0, 2, 0}}
+	if l := line.Length(); math.Abs(l-2) > 1e-9 {
+		t.Errorf("expected a length of 2 across the vertical, got %f", l)
+	}
+	samples, err := Extract(coords, line, 0.1)
+	if err != nil {
+		t.Fatalf("Extract: %v", err)
+	}
+	if len(samples) != 9 {
+		t.Fatalf("expected the 9 points at z = 0.5 from x = 1 to 3, got %d", len(samples))
+	}
+	for i, s := range samples {
+		x, y, z := coords[s.Index*3], coords[s.Index*3+1], coords[s.Index*3+2]
+		if z != 0.5 || math.Abs(s.Distance-(float64(x)-1)) > 1e-6 || math.Abs(s.Elevation-float64(y)) > 1e-6 || s.Offset != 0 {
+			t.Errorf("sample %d: unexpected %+v for point (%v, %v, %v)", i, s, x, y, z)
+		}
+		if i > 0 && s.Distance < samples[i-1].Distance {
+			t.Errorf("samples are not ordered by distance")
+		}
+	}
+}
+
+func TestExtractWidth(t *testing.T) {
+	coords := []float32{0.5, 0, 0.05, 0.5, 0, -0.2, 0.5, 0, 0.3}
+	line := Line{A: [3]float64{0, 0, 0}, B: [3]float64{1, 0, 0}, Up: [3]float64{0, 1, 0}}
+	samples, _ := Extract(coords, line, 0.25)
+	if len(samples) != 2 {
+		t.Fatalf("expected 2 points in the corridor, got %d", len(samples))
+	}
+	// The plane normal is the line's direction cross up, +z here.
+	if samples[0].Offset*float64(coords[samples[0].Index*3+2]) < 0 {
+		t.Errorf("expected offsets signed along x cross up, got %+v", samples)
+	}
+	if _, err := Extract(coords, Line{A: [3]float64{0, 0, 0}, B: [3]float64{0, 1, 0}, Up: [3]float64{0, 1, 0}}, 1); err == nil {
+		t.Errorf("expected an error for a vertical line")
+	}
+}
@@ -0,0 +1,171 @@
+// project/project.go
+package project
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/sbecker11/webgl-point-cloud/glf32"
+)
+
+// Version is the project file format version written by Marshal.
+const Version = 1
+
+// Project is a saved viewer session: the scene's objects and layers with
+// their settings, named selections, the view settings and the camera.
+// Objects are stored by reference to their source rather than as point
+// data, so a project file stays small; procedural datasets are regenerated
+// on load and imported clouds must be re-imported under the same name.
+type Project struct {
+	Version    int         `json:"version"`
+	Camera     *Camera     `json:"camera,omitempty"`
+	View       View        `json:"view"`
+	Layers     []Layer     `json:"layers,omitempty"`
+	Objects    []Object    `json:"objects"`
+	Selections []Selection `json:"selections,omitempty"`
+}
+
+// Camera is an orbit camera pose. Target is the point orbited; older
+// files without one orbit the origin.
+type Camera struct {
+	Distance  float32    `json:"distance"`
+	RotationX float32    `json:"rotationX"`
+	RotationY float32    `json:"rotationY"`
+	Zoom      float32    `json:"zoom"`
+	Target    [3]float32 `json:"target"`
+}
+
+// View holds scene-wide display settings. Palette lists only the classes
+// whose color or name differs from the standard palette.
+type View struct {
+	ColorMode     string       `json:"colorMode,omitempty"`
+	Filter        string       `json:"filter,omitempty"`
+	HiddenClasses []int        `json:"hiddenClasses,omitempty"`
+	Palette       []ClassStyle `json:"palette,omitempty"`
+}
+
+// ClassStyle is a class's custom color, RGBA in [0, 1], and name. Either
+// may be omitted to keep the standard one.
+type ClassStyle struct {
+	Class int        `json:"class"`
+	Color glf32.Vec4 `json:"color,omitempty"`
+	Name  string     `json:"name,omitempty"`
+}
+
+// Layer holds the settings of one layer, identified by its path. Layers
+// are listed parents first.
+type Layer struct {
+	Path      string     `json:"path"`
+	Visible   bool       `json:"visible"`
+	Opacity   float32    `json:"opacity"`
+	Transform glf32.Mat4 `json:"transform"`
+}
+
+// Source says where an object's points came from.
+type Source struct {
+	// Type is "dataset" for procedural datasets, which can be regenerated
+	// from Dataset, Seed and Points, or the importer used otherwise
+	// ("depth", "heightmap", "mesh"), "file" for files read by importFile,
+	// "host" for points pushed by the host page, "ros" for frames
+	// streamed from a ROS topic, or "stream" for datasets relayed by the
+	// server's sensor stream.
+	Type    string `json:"type"`
+	Dataset string `json:"dataset,omitempty"`
+	Seed    int64  `json:"seed,omitempty"`
+	Points  int    `json:"points,omitempty"`
+}
+
+// Reproducible reports whether the points can be regenerated from the
+// source alone.
+func (s Source) Reproducible() bool {
+	return s.Type == "dataset"
+}
+
+// Object holds one scene object's source and settings.
+type Object struct {
+	Name       string     `json:"name"`
+	Source     Source     `json:"source"`
+	Layer      string     `json:"layer,omitempty"`
+	Model      glf32.Mat4 `json:"model"`
+	Visible    bool       `json:"visible"`
+	Opacity    float32    `json:"opacity"`
+	DepthWrite bool       `json:"depthWrite"`
+	Tint       glf32.Vec3 `json:"tint,omitempty"`
+	Color      glf32.Vec4 `json:"color,omitempty"`
+	SizeMode   string     `json:"sizeMode,omitempty"`
+	PointSize  float32    `json:"pointSize,omitempty"`
+	Round      bool       `json:"round,omitempty"`
+	Shading    string     `json:"shading,omitempty"`
+}
+
+// Selection is a named set of points, e.g. a hand-labelled segment: the
+// indices of its points in each object, by object name, and the color it
+// is drawn in.
+type Selection struct {
+	Name   string              `json:"name"`
+	Color  glf32.Vec4          `json:"color,omitempty"`
+	Points map[string][]uint32 `json:"points"`
+}
+
+// Marshal encodes p as indented JSON, setting its version to Version.
+func Marshal(p *Project) ([]byte, error) {
+	p.Version = Version
+	return json.MarshalIndent(p, "", "  ")
+}
+
+// Unmarshal decodes and validates a project file.
+func Unmarshal(data []byte) (*Project, error) {
+	var p Project
+	if err := json.Unmarshal(data, &p); err != nil {
+		return nil, fmt.Errorf("project: %v", err)
+	}
+	if p.Version < 1 || p.Version > Version {
+		return nil, fmt.Errorf("project: unsupported version %d", p.Version)
+	}
+	for _, l := range p.Layers {
+		if len(l.Transform) != 16 {
+			return nil, fmt.Errorf("project: layer %q: transform must have 16 elements", l.Path)
+		}
+		if l.Opacity < 0 || l.Opacity > 1 {
+			return nil, fmt.Errorf("project: layer %q: opacity must be in [0, 1]", l.Path)
+		}
+	}
+	for _, c := range p.View.Palette {
+		if c.Color != nil && len(c.Color) != 4 {
+			return nil, fmt.Errorf("project: class %d: color must have 4 elements", c.Class)
+		}
+	}
+	names := map[string]bool{}
+	for _, o := range p.Objects {
+		if o.Name == "" || names[o.Name] {
+			return nil, fmt.Errorf("project: object names must be unique and non-empty, got %q", o.Name)
+		}
+		names[o.Name] = true
+		if len(o.Model) != 16 {
+			return nil, fmt.Errorf("project: object %q: model must have 16 elements", o.Name)
+		}
+		if o.Opacity < 0 || o.Opacity > 1 {
+			return nil, fmt.Errorf("project: object %q: opacity must be in [0, 1]", o.Name)
+		}
+		if o.Tint != nil && len(o.Tint) != 3 {
+			return nil, fmt.Errorf("project: object %q: tint must have 3 elements", o.Name)
+		}
+		if o.Color != nil && len(o.Color) != 4 {
+			return nil, fmt.Errorf("project: object %q: color must have 4 elements", o.Name)
+		}
+		if o.PointSize < 0 {
+			return nil, fmt.Errorf("project: object %q: pointSize must not be negative", o.Name)
+		}
+	}
+	selections := map[string]bool{}
+	for _, sel := range p.Selections {
+		if sel.Name == "" || selections[sel.Name] {
+			return nil, fmt.Errorf("project: selection names must be unique and non-empty, got %q", sel.Name)
+		}
+		selections[sel.Name] = true
+		if sel.Color != nil && len(sel.Color) != 4 {
+			return nil, fmt.Errorf("project: selection %q: color must have 4 elements", sel.Name)
+		}
+	}
+	return &p, nil
+}
@@ -0,0 +1,75 @@
+// project/project_test.go
+// usage: go test
+
+package project
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func identity() []float32 {
+	return []float32{1, 0, 0, 0, 0, 1, 0, 0, 0, 0, 1, 0, 0, 0, 0, 1}
+}
+
+func TestRoundTrip(t *testing.T) {
+	p := &Project{
+		Camera: &Camera{Distance: 3, RotationX: 0.3, RotationY: -0.5, Zoom: 1.5, Target: [3]float32{1, 0, -2}},
+		View: View{ColorMode: "classification", Filter: "z < 1", HiddenClasses: []int{5},
+			Palette: []ClassStyle{{Class: 2, Color: []float32{0.5, 0.4, 0.3, 1}}, {Class: 64, Name: "Pole"}}},
+		Layers: []Layer{{Path: "scans", Visible: true, Opacity: 0.5, Transform: identity()}},
+		Objects: []Object{{
+			Name:       "town",
+			Source:     Source{Type: "dataset", Dataset: "town", Seed: 42, Points: 5000},
+			Layer:      "scans",
+			Model:      identity(),
+			Visible:    true,
+			Opacity:    1,
+			DepthWrite: true,
+			Tint:       []float32{1, 0.5, 0.5},
+			Color:      []float32{1, 0, 0, 0.8},
+			SizeMode:   "pixels",
+			PointSize:  8,
+			Round:      true,
+			Shading:    "flat",
+		}},
+		Selections: []Selection{{Name: "curb", Color: []float32{1, 0, 0, 1}, Points: map[string][]uint32{"town": {3, 1, 4}}}},
+	}
+	data, err := Marshal(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := Unmarshal(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Version != Version || !reflect.DeepEqual(got, p) {
+		t.Errorf("Unmarshal(Marshal(p)): expected %+v, got %+v", p, got)
+	}
+	if !got.Objects[0].Source.Reproducible() {
+		t.Error("Reproducible: expected true for a dataset source")
+	}
+}
+
+func TestUnmarshalErrors(t *testing.T) {
+	for _, tt := range []struct {
+		json, want string
+	}{
+		{`{`, "unexpected end"},
+		{`{"version": 99}`, "unsupported version"},
+		{`{"version": 1, "objects": [{"name": "a", "model": [1]}]}`, "16 elements"},
+		{`{"version": 1, "layers": [{"path": "a", "opacity": 2, "transform": [1,0,0,0,0,1,0,0,0,0,1,0,0,0,0,1]}]}`, "opacity"},
+		{`{"version": 1, "objects": [{"name": ""}]}`, "unique and non-empty"},
+		{`{"version": 1, "objects": [{"name": "a", "model": [1,0,0,0,0,1,0,0,0,0,1,0,0,0,0,1], "tint": [1, 0]}]}`, "3 elements"},
+		{`{"version": 1, "objects": [{"name": "a", "model": [1,0,0,0,0,1,0,0,0,0,1,0,0,0,0,1], "color": [1, 0, 0]}]}`, "4 elements"},
+		{`{"version": 1, "objects": [{"name": "a", "model": [1,0,0,0,0,1,0,0,0,0,1,0,0,0,0,1], "pointSize": -1}]}`, "pointSize"},
+		{`{"version": 1, "view": {"palette": [{"class": 2, "color": [1, 0]}]}}`, "4 elements"},
+		{`{"version": 1, "selections": [{"name": "a"}, {"name": "a"}]}`, "selection names must be unique"},
+		{`{"version": 1, "selections": [{"name": "a", "color": [1]}]}`, "4 elements"},
+	} {
+		if _, err := Unmarshal([]byte(tt.json)); err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("Unmarshal(%s): expected an error containing %q, got %v", tt.json, tt.want, err)
+		}
+	}
+}
@@ -0,0 +1,118 @@
+// quality/quality.go
+// Package quality picks a rendering quality level that keeps frame times
+// near a target, so the same build runs smoothly on slow and fast GPUs.
+package quality
+
+// Tuning of the controller. Frame times are smoothed with an exponential
+// moving average; the level drops after degradeAfter consecutive smoothed
+// frames over the budget by more than degradeMargin, and rises after
+// improveAfter frames under improveMargin of it. The gap between the
+// margins and the longer wait to improve are the hysteresis that stops the
+// level flickering between two settings.
+const (
+	smoothing     = 0.1
+	degradeMargin = 1.2
+	improveMargin = 0.9
+	degradeAfter  = 20
+	improveAfter  = 120
+	// A level that fails within failWindow frames of improving doubles the
+	// wait before the next improvement, up to maxBackoff times improveAfter.
+	failWindow = 3 * degradeAfter
+	maxBackoff = 8
+	// Frame times are capped so that one stall, such as returning to a
+	// hidden tab, does not swamp the average.
+	maxFrameMs = 250
+)
+
+// Controller adjusts a quality level between 0 (best) and Levels()-1
+// (fastest) from observed frame times.
+type Controller struct {
+	targetMs float64
+	levels   int
+	level    int
+	avg      float64 // smoothed frame time in milliseconds
+	slow     int     // consecutive frames over the degrade threshold
+	fast     int     // consecutive frames under the improve threshold
+	since    int     // frames since the last level change
+	improved bool    // whether the last change was an improvement
+	backoff  int     // multiplier of improveAfter
+}
+
+// NewController returns a controller for the given number of levels that
+// aims for targetFPS, starting at level 0. targetFPS should be below the
+// display refresh rate, which caps the frame rate the controller can see.
+// Panics if targetFPS is not positive or levels is less than 1.
+func NewController(targetFPS float64, levels int) *Controller {
+	if targetFPS <= 0 || levels < 1 {
+		panic("quality.NewController: targetFPS must be positive and levels at least 1")
+	}
+	return &Controller{targetMs: 1000 / targetFPS, levels: levels, backoff: 1}
+}
+
+// Frame records the duration of a frame in milliseconds and returns the
+// level to render the next frame at and whether it changed.
+func (c *Controller) Frame(ms float64) (level int, changed bool) {
+	if ms > maxFrameMs {
+		ms = maxFrameMs
+	}
+	if c.avg == 0 {
+		c.avg = ms
+	} else {
+		c.avg += smoothing * (ms - c.avg)
+	}
+	c.since++
+
+	if c.avg > c.targetMs*degradeMargin {
+		c.slow++
+		c.fast = 0
+	} else if c.avg < c.targetMs*improveMargin {
+		c.fast++
+		c.slow = 0
+	} else {
+		c.slow, c.fast = 0, 0
+	}
+
+	switch {
+	case c.slow >= degradeAfter && c.level < c.levels-1:
+		if c.improved && c.since <= failWindow && c.backoff < maxBackoff {
+			c.backoff *= 2
+		}
+		c.setLevel(c.level+1, false)
+		return c.level, true
+	case c.fast >= improveAfter*c.backoff && c.level > 0:
+		c.setLevel(c.level-1, true)
+		return c.level, true
+	}
+	return c.level, false
+}
+
+func (c *Controller) setLevel(level int, improved bool) {
+	c.level = level
+	c.improved = improved
+	c.slow, c.fast, c.since = 0, 0, 0
+}
+
+// Level returns the current level.
+func (c *Controller) Level() int {
+	return c.level
+}
+
+// Levels returns the number of levels.
+func (c *Controller) Levels() int {
+	return c.levels
+}
+
+// TargetFPS returns the frame rate the controller aims for.
+func (c *Controller) TargetFPS() float64 {
+	return 1000 / c.targetMs
+}
+
+// FrameTime returns the smoothed frame time in milliseconds.
+func (c *Controller) FrameTime() float64 {
+	return c.avg
+}
+
+// Reset returns to level 0 and forgets the frame history.
+func (c *Controller) Reset() {
+	*c = Controller{targetMs: c.targetMs, levels: c.levels, backoff: 1}
+}
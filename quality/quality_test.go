@@ -0,0 +1,83 @@
+// quality/quality_test.go
+// usage: go test
+
+package quality
+
+import "testing"
+
+// run feeds n frames of ms milliseconds and returns the final level and
+// the number of level changes.
+func run(c *Controller, n int, ms float64) (level, changes int) {
+	for i := 0; i < n; i++ {
+		var changed bool
+		if level, changed = c.Frame(ms); changed {
+			changes++
+		}
+	}
+	return c.Level(), changes
+}
+
+func TestDegradesWhenSlow(t *testing.T) {
+	c := NewController(50, 4) // 20ms budget
+	if level, changes := run(c, 100, 18); level != 0 || changes != 0 {
+		t.Errorf("within budget: expected level 0 and no changes, got level %d after %d changes", level, changes)
+	}
+	if level, _ := run(c, 10, 40); level != 0 {
+		t.Errorf("brief slowdown: expected level 0, got %d", level)
+	}
+	if level, _ := run(c, 200, 40); level != 3 {
+		t.Errorf("sustained slowdown: expected the fastest level 3, got %d", level)
+	}
+}
+
+func TestImprovesWhenFast(t *testing.T) {
+	c := NewController(50, 4)
+	run(c, 200, 40)
+	if level, _ := run(c, improveAfter-1, 10); level != 3 {
+		t.Errorf("expected no improvement before %d fast frames, got level %d", improveAfter, level)
+	}
+	if level, _ := run(c, 3*improveAfter, 10); level != 0 {
+		t.Errorf("expected level 0 after a long fast stretch, got %d", level)
+	}
+}
+
+func TestHysteresisPreventsFlicker(t *testing.T) {
+	c := NewController(50, 4)
+	// Frame time between the improve and degrade thresholds.
+	if _, changes := run(c, 1000, 21); changes != 0 {
+		t.Errorf("expected no changes inside the hysteresis band, got %d", changes)
+	}
+
+	// A scene that is only fast enough at level 1 should settle there,
+	// retrying level 0 less and less often.
+	c = NewController(50, 4)
+	changes := 0
+	for i := 0; i < 5000; i++ {
+		ms := 10.0
+		if c.Level() == 0 {
+			ms = 40
+		}
+		if _, changed := c.Frame(ms); changed {
+			changes++
+		}
+	}
+	if c.Level() > 1 {
+		t.Errorf("expected to settle at level 0 or 1, got %d", c.Level())
+	}
+	if changes > 20 {
+		t.Errorf("expected backoff to limit level changes, got %d", changes)
+	}
+}
+
+func TestStallsAreCapped(t *testing.T) {
+	c := NewController(50, 4)
+	run(c, 50, 16)
+	c.Frame(5000)
+	if c.FrameTime() > 16+smoothing*maxFrameMs {
+		t.Errorf("expected a capped stall, got smoothed frame time %v", c.FrameTime())
+	}
+	c.Reset()
+	if c.Level() != 0 || c.FrameTime() != 0 || c.TargetFPS() != 50 {
+		t.Errorf("Reset: level %d, frame time %v, target %v", c.Level(), c.FrameTime(), c.TargetFPS())
+	}
+}
@@ -0,0 +1,284 @@
+// reconstruct/reconstruct.go
+// Package reconstruct builds a preview triangle mesh over a point cloud's
+// surface.
+//
+// The cloud is downsampled to one point per voxel, each point is grown into
+// a ball of radius Radius and the boundary of the union of the balls is
+// extracted from a distance field with naive surface nets, which places one
+// vertex in every grid cell the boundary crosses and joins the vertices of
+// the four cells around every crossed grid edge with a quad. The result is
+// a closed surface hugging the points: a thin shell over an open scan,
+// showing at a glance where the sampled surface is continuous and where it
+// has holes. It is a preview, not a watertight reconstruction of the
+// underlying surface.
+package reconstruct
+
+import (
+	"errors"
+	"math"
+
+	"github.com/sbecker11/webgl-point-cloud/mesh"
+)
+
+// Options controls the reconstruction.
+type Options struct {
+	// Resolution is the number of grid cells along the longest side of the
+	// cloud's bounding box; 64 when zero. The cost grows with its cube.
+	Resolution int
+	// Radius is the radius of the ball around each point in cells; 1.2 when
+	// zero. Larger radii bridge sparser sampling but thicken the shell.
+	Radius float64
+	// Progress, if set, is called with the fraction of the work done.
+	// Returning an error stops the reconstruction with that error.
+	Progress func(fraction float64) error
+}
+
+// Surface reconstructs a surface from packed xyz coordinates. It returns an
+// empty mesh for an empty cloud.
+func Surface(coords []float32, opts Options) (*mesh.Mesh, error) {
+	if len(coords)%3 != 0 {
+		return nil, errors.New("reconstruct: coords length must be a multiple of 3")
+	}
+	if opts.Resolution <= 0 {
+		opts.Resolution = 64
+	}
+	if opts.Radius <= 0 {
+		opts.Radius = 1.2
+	}
+	report := func(fraction float64) error {
+		if opts.Progress == nil {
+			return nil
+		}
+		return opts.Progress(fraction)
+	}
+	if len(coords) == 0 {
+		return &mesh.Mesh{}, nil
+	}
+
+	g := newGrid(coords, opts.Resolution, opts.Radius)
+	centroids := g.downsample(coords)
+	if err := report(0.1); err != nil {
+		return nil, err
+	}
+	if err := g.splat(centroids, opts.Radius, func(f float64) error { return report(0.1 + 0.5*f) }); err != nil {
+		return nil, err
+	}
+	m, err := g.surfaceNets(func(f float64) error { return report(0.6 + 0.4*f) })
+	if err != nil {
+		return nil, err
+	}
+	return m, report(1)
+}
+
+// grid is a regular grid of distance samples at its vertices.
+type grid struct {
+	origin     [3]float64
+	cell       float64
+	nx, ny, nz int       // vertices along each axis
+	field      []float32 // distance to the nearest ball surface, negative inside
+}
+
+// newGrid sizes a grid around the cloud with room for the balls and a
+// border of outside vertices, so the surface always closes.
+func newGrid(coords []float32, resolution int, radius float64) *grid {
+	min := [3]float64{math.Inf(1), math.Inf(1), math.Inf(1)}
+	max := [3]float64{math.Inf(-1), math.Inf(-1), math.Inf(-1)}
+	for i := 0; i < len(coords); i += 3 {
+		for a := 0; a < 3; a++ {
+			v := float64(coords[i+a])
+			min[a] = math.Min(min[a], v)
+			max[a] = math.Max(max[a], v)
+		}
+	}
+	extent := math.Max(max[0]-min[0], math.Max(max[1]-min[1], max[2]-min[2]))
+	if extent == 0 {
+		extent = 1
+	}
+	g := &grid{cell: extent / float64(resolution)}
+	pad := math.Ceil(radius) + 1
+	var n [3]int
+	for a := 0; a < 3; a++ {
+		g.origin[a] = min[a] - pad*g.cell
+		n[a] = int(math.Ceil((max[a]-min[a])/g.cell+2*pad)) + 1
+	}
+	g.nx, g.ny, g.nz = n[0], n[1], n[2]
+	g.field = make([]float32, g.nx*g.ny*g.nz)
+	for i := range g.field {
+		g.field[i] = float32(pad)
+	}
+	return g
+}
+
+func (g *grid) index(i, j, k int) int {
+	return (k*g.ny+j)*g.nx + i
+}
+
+// position returns the world position of a fractional grid coordinate.
+func (g *grid) position(i, j, k float64) [3]float64 {
+	return [3]float64{g.origin[0] + i*g.cell, g.origin[1] + j*g.cell, g.origin[2] + k*g.cell}
+}
+
+// downsample returns the centroid of the points in each occupied cell, in
+// grid units.
+func (g *grid) downsample(coords []float32) [][3]float64 {
+	type sum struct {
+		p [3]float64
+		n int
+	}
+	cells := map[int]*sum{}
+	var order []int
+	for i := 0; i < len(coords); i += 3 {
+		var p [3]float64
+		var c [3]int
+		for a := 0; a < 3; a++ {
+			p[a] = (float64(coords[i+a]) - g.origin[a]) / g.cell
+			c[a] = int(p[a])
+		}
+		key := g.index(c[0], c[1], c[2])
+		s, ok := cells[key]
+		if !ok {
+			s = &sum{}
+			cells[key] = s
+			order = append(order, key)
+		}
+		for a := 0; a < 3; a++ {
+			s.p[a] += p[a]
+		}
+		s.n++
+	}
+	centroids := make([][3]float64, len(order))
+	for i, key := range order {
+		s := cells[key]
+		centroids[i] = [3]float64{s.p[0] / float64(s.n), s.p[1] / float64(s.n), s.p[2] / float64(s.n)}
+	}
+	return centroids
+}
+
+// splat lowers the field around each point, in grid units, to the distance
+// from the point's ball of the given radius.
+func (g *grid) splat(points [][3]float64, radius float64, report func(float64) error) error {
+	reach := int(math.Ceil(radius)) + 1
+	for n, p := range points {
+		if n%1024 == 0 {
+			if err := report(float64(n) / float64(len(points))); err != nil {
+				return err
+			}
+		}
+		ci, cj, ck := int(math.Round(p[0])), int(math.Round(p[1])), int(math.Round(p[2]))
+		for k := ck - reach; k <= ck+reach; k++ {
+			for j := cj - reach; j <= cj+reach; j++ {
+				for i := ci - reach; i <= ci+reach; i++ {
+					if i < 0 || j < 0 || k < 0 || i >= g.nx || j >= g.ny || k >= g.nz {
+						continue
+					}
+					dx, dy, dz := float64(i)-p[0], float64(j)-p[1], float64(k)-p[2]
+					d := float32(math.Sqrt(dx*dx+dy*dy+dz*dz) - radius)
+					if idx := g.index(i, j, k); d < g.field[idx] {
+						g.field[idx] = d
+					}
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// cubeEdges lists the corner pairs of a cell's 12 edges; corner c is at
+// offset (c&1, c>>1&1, c>>2&1).
+var cubeEdges = [12][2]int{
+	{0, 1}, {2, 3}, {4, 5}, {6, 7},
+	{0, 2}, {1, 3}, {4, 6}, {5, 7},
+	{0, 4}, {1, 5}, {2, 6}, {3, 7},
+}
+
+// surfaceNets extracts the field's zero level set as a triangle mesh.
+func (g *grid) surfaceNets(report func(float64) error) (*mesh.Mesh, error) {
+	m := &mesh.Mesh{}
+	cx, cy, cz := g.nx-1, g.ny-1, g.nz-1 // cells along each axis
+	vertexOf := make([]int32, cx*cy*cz)  // mesh vertex of each cell, -1 if none
+	cellIndex := func(i, j, k int) int { return (k*cy+j)*cx + i }
+
+	for k := 0; k < cz; k++ {
+		if err := report(0.5 * float64(k) / float64(cz)); err != nil {
+			return nil, err
+		}
+		for j := 0; j < cy; j++ {
+			for i := 0; i < cx; i++ {
+				var f [8]float32
+				inside := 0
+				for c := 0; c < 8; c++ {
+					f[c] = g.field[g.index(i+c&1, j+c>>1&1, k+c>>2&1)]
+					if f[c] < 0 {
+						inside++
+					}
+				}
+				vertexOf[cellIndex(i, j, k)] = -1
+				if inside == 0 || inside == 8 {
+					continue
+				}
+				// Place the vertex at the mean of the edge crossings.
+				var sum [3]float64
+				crossings := 0
+				for _, e := range cubeEdges {
+					fa, fb := f[e[0]], f[e[1]]
+					if (fa < 0) == (fb < 0) {
+						continue
+					}
+					t := float64(fa / (fa - fb))
+					for a := 0; a < 3; a++ {
+						ca, cb := float64(e[0]>>a&1), float64(e[1]>>a&1)
+						sum[a] += ca + t*(cb-ca)
+					}
+					crossings++
+				}
+				p := g.position(float64(i)+sum[0]/float64(crossings), float64(j)+sum[1]/float64(crossings), float64(k)+sum[2]/float64(crossings))
+				vertexOf[cellIndex(i, j, k)] = int32(m.VertexCount())
+				m.Positions = append(m.Positions, float32(p[0]), float32(p[1]), float32(p[2]))
+			}
+		}
+	}
+
+	// For each grid edge crossing the surface, join the four cells around
+	// it, wound so the face normal points out of the balls.
+	for k := 1; k < cz; k++ {
+		if err := report(0.5 + 0.5*float64(k)/float64(cz)); err != nil {
+			return nil, err
+		}
+		for j := 1; j < cy; j++ {
+			for i := 1; i < cx; i++ {
+				f0 := g.field[g.index(i, j, k)]
+				// Edges along x, y and z from vertex (i, j, k), with the
+				// four cells around each in counter-clockwise order seen
+				// from the positive end of the axis.
+				edges := [3]struct {
+					f     float32
+					cells [4][3]int
+				}{
+					{g.field[g.index(i+1, j, k)], [4][3]int{{i, j - 1, k - 1}, {i, j, k - 1}, {i, j, k}, {i, j - 1, k}}},
+					{g.field[g.index(i, j+1, k)], [4][3]int{{i - 1, j, k - 1}, {i - 1, j, k}, {i, j, k}, {i, j, k - 1}}},
+					{g.field[g.index(i, j, k+1)], [4][3]int{{i - 1, j - 1, k}, {i, j - 1, k}, {i, j, k}, {i - 1, j, k}}},
+				}
+				for _, e := range edges {
+					if (f0 < 0) == (e.f < 0) {
+						continue
+					}
+					var q [4]uint32
+					for n, c := range e.cells {
+						q[n] = uint32(vertexOf[cellIndex(c[0], c[1], c[2])])
+					}
+					if f0 < 0 {
+						// Inside at the negative end: the normal points along +axis.
+						m.Indices = append(m.Indices, q[0], q[1], q[2], q[0], q[2], q[3])
+					} else {
+						m.Indices = append(m.Indices, q[0], q[2], q[1], q[0], q[3], q[2])
+					}
+				}
+			}
+		}
+	}
+	// The last layer of vertices is outside the padded cloud, so edges
+	// starting there never cross the surface and the loops above, which
+	// skip them, miss nothing.
+	m.ComputeVertexNormals()
+	return m, nil
+}
@@ -0,0 +1,110 @@
+// reconstruct/reconstruct_test.go
+// usage: go test
+
+package reconstruct
+
+import (
+	"errors"
+	"math"
+	"testing"
+)
+
+// spherePoints returns n points spread evenly over a unit sphere.
+func spherePoints(n int) []float32 {
+	coords := make([]float32, 0, n*3)
+	golden := math.Pi * (3 - math.Sqrt(5))
+	for i := 0; i < n; i++ {
+		y := 1 - 2*(float64(i)+0.5)/float64(n)
+		r := math.Sqrt(1 - y*y)
+		a := golden * float64(i)
+		coords = append(coords, float32(r*math.Cos(a)), float32(y), float32(r*math.Sin(a)))
+	}
+	return coords
+}
+
+func TestSurfaceSphere(t *testing.T) {
+	m, err := Surface(spherePoints(20000), Options{Resolution: 32})
+	if err != nil {
+		t.Fatalf("Surface: %v", err)
+	}
+	if m.TriangleCount() == 0 {
+		t.Fatalf("expected triangles")
+	}
+	for i, v := range m.Indices {
+		if int(v) >= m.VertexCount() {
+			t.Fatalf("index %d: vertex %d out of range", i, v)
+		}
+	}
+
+	// The shell lies within a few cells of the sphere, and on its outer
+	// side the normals point away from the centre.
+	cell := 2.0 / 32
+	outward, inward := 0, 0
+	for i := 0; i < m.VertexCount(); i++ {
+		x, y, z := m.Vertex(uint32(i))
+		r := math.Sqrt(float64(x*x + y*y + z*z))
+		if math.Abs(r-1) > 3*cell {
+			t.Fatalf("vertex %d at radius %f, expected near 1", i, r)
+		}
+		if r > 1 {
+			n := m.Normals[i*3 : i*3+3]
+			if x*n[0]+y*n[1]+z*n[2] > 0 {
+				outward++
+			} else {
+				inward++
+			}
+		}
+	}
+	if outward < 10*inward {
+		t.Errorf("expected outer normals to point outward, got %d outward and %d inward", outward, inward)
+	}
+
+	// Every edge of the closed shell is shared by two triangles.
+	edges := map[[2]uint32]int{}
+	for i := 0; i < len(m.Indices); i += 3 {
+		for e := 0; e < 3; e++ {
+			a, b := m.Indices[i+e], m.Indices[i+(e+1)%3]
+			if a > b {
+				a, b = b, a
+			}
+			edges[[2]uint32{a, b}]++
+		}
+	}
+	open := 0
+	for _, n := range edges {
+		if n == 1 {
+			open++
+		}
+	}
+	if open > 0 {
+		t.Errorf("expected a closed surface, got %d open edges", open)
+	}
+}
+
+func TestSurfaceEmpty(t *testing.T) {
+	m, err := Surface(nil, Options{})
+	if err != nil || m.TriangleCount() != 0 {
+		t.Errorf("expected an empty mesh, got %d triangles and %v", m.TriangleCount(), err)
+	}
+	if _, err := Surface([]float32{1, 2}, Options{}); err == nil {
+		t.Errorf("expected an error for a partial point")
+	}
+}
+
+func TestSurfaceCancel(t *testing.T) {
+	stop := errors.New("stop")
+	last := 0.0
+	_, err := Surface(spherePoints(1000), Options{Resolution: 16, Progress: func(f float64) error {
+		if f < last {
+			t.Errorf("progress went back from %f to %f", last, f)
+		}
+		last = f
+		if f > 0.5 {
+			return stop
+		}
+		return nil
+	}})
+	if err != stop {
+		t.Errorf("expected the progress error, got %v", err)
+	}
+}
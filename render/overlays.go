@@ -0,0 +1,43 @@
+// render/overlays.go
+package render
+
+// Axes returns the x, y and z axes from -size to size as line vertices,
+// colored red, green and blue.
+func Axes(size float32) (positions, colors []float32) {
+	positions = []float32{
+		-size, 0, 0, size, 0, 0,
+		0, -size, 0, 0, size, 0,
+		0, 0, -size, 0, 0, size,
+	}
+	colors = []float32{
+		1, 0, 0, 1, 1, 0, 0, 1,
+		0, 1, 0, 1, 0, 1, 0, 1,
+		0, 0, 1, 1, 0, 0, 1, 1,
+	}
+	return positions, colors
+}
+
+// Grid returns gray grid lines in the xz, xy and yz planes, divisions
+// each side of the origin out to size, as line vertices. The lines through
+// the origin are left to Axes.
+func Grid(size float32, divisions int) (positions, colors []float32) {
+	step := size / float32(divisions)
+	for i := -divisions; i <= divisions; i++ {
+		if i == 0 {
+			continue
+		}
+		pos := float32(i) * step
+		positions = append(positions,
+			-size, 0, pos, size, 0, pos, // xz plane
+			pos, 0, -size, pos, 0, size,
+			-size, pos, 0, size, pos, 0, // xy plane
+			pos, -size, 0, pos, size, 0,
+			0, pos, -size, 0, pos, size, // yz plane
+			0, -size, pos, 0, size, pos,
+		)
+		for j := 0; j < 12; j++ {
+			colors = append(colors, 0.4, 0.4, 0.4, 1)
+		}
+	}
+	return positions, colors
+}
@@ -0,0 +1,122 @@
+// render/render.go
+// Package render describes drawing as calls on a Renderer backend, so code
+// that draws the scene's overlays needn't know whether WebGL in the
+// browser or the software rasterizer carries them out.
+package render
+
+import (
+	"fmt"
+
+	"github.com/sbecker11/webgl-point-cloud/glf32"
+)
+
+// Buffer is a backend's handle to vertex data it holds. The zero Buffer is
+// no buffer.
+type Buffer uint32
+
+// Primitive is how a draw joins its vertices.
+type Primitive int
+
+const (
+	Points    Primitive = iota // one point per vertex
+	Lines                      // a segment per pair of vertices
+	Triangles                  // a triangle per three vertices
+)
+
+func (p Primitive) String() string {
+	switch p {
+	case Points:
+		return "points"
+	case Lines:
+		return "lines"
+	case Triangles:
+		return "triangles"
+	}
+	return fmt.Sprintf("Primitive(%d)", int(p))
+}
+
+// Names of the vertex attributes and uniforms that every backend's line
+// and point programs read.
+const (
+	AttribPosition   = "aPosition"  // xyz
+	AttribColor      = "aColor"     // rgba in [0, 1]
+	UniformMVP       = "uMvpMatrix" // model-view-projection matrix
+	UniformPointSize = "uPointSize" // point diameter in pixels
+)
+
+// VertexAttrib feeds a vertex attribute from a buffer of float32 values,
+// Components per vertex.
+type VertexAttrib struct {
+	Name       string
+	Buffer     Buffer
+	Components int
+}
+
+// Renderer is a drawing backend: WebGL in the browser, the software
+// rasterizer in tests and command line tools. Drawing code written against
+// it runs on any backend.
+type Renderer interface {
+	// CreateBuffer uploads data to a new buffer.
+	CreateBuffer(data []float32) Buffer
+	// UpdateBuffer replaces the data of b.
+	UpdateBuffer(b Buffer, data []float32)
+	// DeleteBuffer frees b.
+	DeleteBuffer(b Buffer)
+	// SetUniform sets a uniform of the program in use: a float, a vector
+	// of 2 to 4 components or a column-major 4x4 matrix, by length.
+	SetUniform(name string, value []float32)
+	// Draw draws count vertices read from attribs as p.
+	Draw(p Primitive, attribs []VertexAttrib, count int)
+}
+
+// Geometry is vertices with a position and a color each, the kind of
+// overlay the viewer draws with its line program: grids, axes, paths and
+// gizmos. It creates its buffers on first use and reuses them after.
+type Geometry struct {
+	Primitive         Primitive
+	positions, colors Buffer
+	count             int
+}
+
+// Set replaces the vertices with packed xyz positions and rgba colors.
+// Panics if len(positions) is not a multiple of 3 or there are not four
+// colors per position.
+func (g *Geometry) Set(r Renderer, positions, colors []float32) {
+	if len(positions)%3 != 0 {
+		panic("Geometry.Set: positions slice length must be a multiple of 3")
+	}
+	g.count = len(positions) / 3
+	if len(colors) != g.count*4 {
+		panic("Geometry.Set: colors slice must have 4 components per position")
+	}
+	if g.positions == 0 {
+		g.positions, g.colors = r.CreateBuffer(positions), r.CreateBuffer(colors)
+		return
+	}
+	r.UpdateBuffer(g.positions, positions)
+	r.UpdateBuffer(g.colors, colors)
+}
+
+// Count returns the number of vertices.
+func (g *Geometry) Count() int { return g.count }
+
+// Draw draws the vertices transformed by mvp, if there are any.
+func (g *Geometry) Draw(r Renderer, mvp glf32.Mat4) {
+	if g.count == 0 {
+		return
+	}
+	r.SetUniform(UniformMVP, mvp)
+	r.Draw(g.Primitive, []VertexAttrib{
+		{Name: AttribPosition, Buffer: g.positions, Components: 3},
+		{Name: AttribColor, Buffer: g.colors, Components: 4},
+	}, g.count)
+}
+
+// Delete frees the buffers; a later Set creates new ones.
+func (g *Geometry) Delete(r Renderer) {
+	if g.positions != 0 {
+		r.DeleteBuffer(g.positions)
+		r.DeleteBuffer(g.colors)
+	}
+	g.positions, g.colors, g.count = 0, 0, 0
+}
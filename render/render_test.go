@@ -0,0 +1,109 @@
+// render/render_test.go
+// usage: go test
+
+package render
+
+import (
+	"testing"
+
+	"github.com/sbecker11/webgl-point-cloud/glf32"
+)
+
+// recorder is a Renderer that keeps buffers in memory and records the
+// calls made to it.
+type recorder struct {
+	buffers  map[Buffer][]float32
+	next     Buffer
+	uniforms map[string][]float32
+	draws    []string
+	created  int
+}
+
+func newRecorder() *recorder {
+	return &recorder{buffers: map[Buffer][]float32{}, uniforms: map[string][]float32{}}
+}
+
+func (r *recorder) CreateBuffer(data []float32) Buffer {
+	r.next++
+	r.created++
+	r.buffers[r.next] = append([]float32(nil), data...)
+	return r.next
+}
+
+func (r *recorder) UpdateBuffer(b Buffer, data []float32) {
+	r.buffers[b] = append([]float32(nil), data...)
+}
+
+func (r *recorder) DeleteBuffer(b Buffer) { delete(r.buffers, b) }
+
+func (r *recorder) SetUniform(name string, value []float32) { r.uniforms[name] = value }
+
+func (r *recorder) Draw(p Primitive, attribs []VertexAttrib, count int) {
+	d := p.String()
+	for _, a := range attribs {
+		d += " " + a.Name
+		if len(r.buffers[a.Buffer]) < count*a.Components {
+			d += "(short)"
+		}
+	}
+	r.draws = append(r.draws, d)
+}
+
+func TestGeometryReusesBuffers(t *testing.T) {
+	r := newRecorder()
+	g := &Geometry{Primitive: Lines}
+	g.Set(r, []float32{0, 0, 0, 1, 0, 0}, []float32{1, 0, 0, 1, 1, 0, 0, 1})
+	g.Set(r, []float32{0, 0, 0, 0, 1, 0, 0, 0, 1, 1, 1, 1}, make([]float32, 16))
+	if r.created != 2 {
+		t.Errorf("Set twice: expected 2 buffers created, got %d", r.created)
+	}
+	if g.Count() != 4 {
+		t.Errorf("Count: expected 4, got %d", g.Count())
+	}
+	g.Draw(r, glf32.Identity())
+	if len(r.draws) != 1 || r.draws[0] != "lines aPosition aColor" {
+		t.Errorf("Draw: expected one full lines draw, got %q", r.draws)
+	}
+	if len(r.uniforms[UniformMVP]) != 16 {
+		t.Errorf("Draw: expected %s to be set", UniformMVP)
+	}
+	g.Delete(r)
+	if len(r.buffers) != 0 || g.Count() != 0 {
+		t.Errorf("Delete: expected no buffers left, got %d", len(r.buffers))
+	}
+}
+
+func TestGeometryEmptyDrawsNothing(t *testing.T) {
+	r := newRecorder()
+	g := &Geometry{Primitive: Points}
+	g.Draw(r, glf32.Identity())
+	g.Set(r, nil, nil)
+	g.Draw(r, glf32.Identity())
+	if len(r.draws) != 0 {
+		t.Errorf("Draw of no vertices: expected no draws, got %q", r.draws)
+	}
+}
+
+func TestGeometrySetPanicsOnMismatch(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Errorf("Set with too few colors: expected a panic")
+		}
+	}()
+	(&Geometry{}).Set(newRecorder(), []float32{0, 0, 0}, []float32{1, 1, 1})
+}
+
+func TestOverlaysPairVertices(t *testing.T) {
+	for name, f := range map[string]func() ([]float32, []float32){
+		"Axes": func() ([]float32, []float32) { return Axes(1.5) },
+		"Grid": func() ([]float32, []float32) { return Grid(1.5, 10) },
+	} {
+		positions, colors := f()
+		if n := len(positions) / 3; len(positions)%6 != 0 || len(colors) != n*4 {
+			t.Errorf("%s: expected line pairs with a color each, got %d positions and %d colors", name, len(positions), len(colors))
+		}
+	}
+	if positions, _ := Grid(1, 2); len(positions) != 4*6*6 {
+		t.Errorf("Grid(1, 2): expected 24 lines, got %d", len(positions)/6)
+	}
+}
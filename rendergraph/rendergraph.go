@@ -0,0 +1,101 @@
+// rendergraph/rendergraph.go
+// Package rendergraph orders the passes of a frame by the textures they
+// read and draw into, so effects such as ambient occlusion and
+// anti-aliasing compose without knowing about each other. The viewer
+// allocates the textures and framebuffers; this package only checks and
+// orders the passes.
+package rendergraph
+
+import "fmt"
+
+// Pass is a step of a frame: it reads the textures named by Inputs and
+// draws into those named by Outputs, or onto the screen when it has none.
+type Pass struct {
+	Name    string
+	Inputs  []string
+	Outputs []string
+}
+
+// Order returns passes ordered so that every texture is drawn before it is
+// read, keeping the given order wherever it already allows that, so
+// passes drawing onto the screen composite in the order given. It reports
+// an error if a pass is unnamed or named twice, a texture is drawn by more
+// than one pass, a pass reads a texture no pass draws, or passes read each
+// other's textures in a cycle.
+func Order(passes []Pass) ([]Pass, error) {
+	names := map[string]bool{}
+	producer := map[string]int{}
+	for i, p := range passes {
+		if p.Name == "" {
+			return nil, fmt.Errorf("rendergraph: pass %d has no name", i)
+		}
+		if names[p.Name] {
+			return nil, fmt.Errorf("rendergraph: two passes are named %q", p.Name)
+		}
+		names[p.Name] = true
+		for _, t := range p.Outputs {
+			if j, ok := producer[t]; ok {
+				return nil, fmt.Errorf("rendergraph: passes %q and %q both draw %q", passes[j].Name, p.Name, t)
+			}
+			producer[t] = i
+		}
+	}
+	// waiting[i] counts the passes pass i reads from that are not yet
+	// ordered.
+	waiting := make([]int, len(passes))
+	readers := make([][]int, len(passes))
+	for i, p := range passes {
+		seen := map[int]bool{}
+		for _, t := range p.Inputs {
+			j, ok := producer[t]
+			if !ok {
+				return nil, fmt.Errorf("rendergraph: pass %q reads %q, which no pass draws", p.Name, t)
+			}
+			if !seen[j] {
+				seen[j] = true
+				waiting[i]++
+				readers[j] = append(readers[j], i)
+			}
+		}
+	}
+	order := make([]Pass, 0, len(passes))
+	done := make([]bool, len(passes))
+	for len(order) < len(passes) {
+		next := -1
+		for i := range passes {
+			if !done[i] && waiting[i] == 0 {
+				next = i
+				break
+			}
+		}
+		if next < 0 {
+			for i, p := range passes {
+				if !done[i] {
+					return nil, fmt.Errorf("rendergraph: pass %q depends on its own output", p.Name)
+				}
+			}
+		}
+		done[next] = true
+		order = append(order, passes[next])
+		for _, r := range readers[next] {
+			waiting[r]--
+		}
+	}
+	return order, nil
+}
+
+// Textures returns the names of the textures the passes draw, in the
+// order they first appear.
+func Textures(passes []Pass) []string {
+	var names []string
+	seen := map[string]bool{}
+	for _, p := range passes {
+		for _, t := range p.Outputs {
+			if !seen[t] {
+				seen[t] = true
+				names = append(names, t)
+			}
+		}
+	}
+	return names
+}
@@ -0,0 +1,64 @@
+// rendergraph/rendergraph_test.go
+// usage: go test
+
+package rendergraph
+
+import (
+	"strings"
+	"testing"
+)
+
+// names returns the names of passes, joined by spaces.
+func names(passes []Pass) string {
+	var s []string
+	for _, p := range passes {
+		s = append(s, p.Name)
+	}
+	return strings.Join(s, " ")
+}
+
+func TestOrder(t *testing.T) {
+	passes := []Pass{
+		{Name: "ssao", Inputs: []string{"depth"}, Outputs: []string{"occlusion"}},
+		{Name: "composite", Inputs: []string{"occlusion", "color"}},
+		{Name: "depth", Outputs: []string{"depth"}},
+		{Name: "overlay"},
+		{Name: "color", Outputs: []string{"color"}},
+	}
+	order, err := Order(passes)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := names(order), "depth ssao overlay color composite"; got != want {
+		t.Errorf("expected order %q, got %q", want, got)
+	}
+	if got := strings.Join(Textures(order), " "); got != "depth occlusion color" {
+		t.Errorf("expected textures depth occlusion color, got %q", got)
+	}
+
+	// Passes already in order stay as they are.
+	order, _ = Order([]Pass{{Name: "a"}, {Name: "b"}, {Name: "c"}})
+	if got := names(order); got != "a b c" {
+		t.Errorf("expected screen passes kept in order, got %q", got)
+	}
+}
+
+func TestOrderErrors(t *testing.T) {
+	for _, c := range []struct {
+		passes []Pass
+		want   string
+	}{
+		{[]Pass{{Name: ""}}, "no name"},
+		{[]Pass{{Name: "a"}, {Name: "a"}}, "two passes"},
+		{[]Pass{{Name: "a", Outputs: []string{"t"}}, {Name: "b", Outputs: []string{"t"}}}, "both draw"},
+		{[]Pass{{Name: "a", Inputs: []string{"t"}}}, "no pass draws"},
+		{[]Pass{
+			{Name: "a", Inputs: []string{"v"}, Outputs: []string{"u"}},
+			{Name: "b", Inputs: []string{"u"}, Outputs: []string{"v"}},
+		}, "own output"},
+	} {
+		if _, err := Order(c.passes); err == nil || !strings.Contains(err.Error(), c.want) {
+			t.Errorf("%v: expected an error containing %q, got %v", c.passes, c.want, err)
+		}
+	}
+}
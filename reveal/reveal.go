@@ -0,0 +1,66 @@
+// reveal/reveal.go
+// Package reveal times the animation revealing points as they arrive in
+// chunks, as the frames of a stream or the bands of a cloud uploaded
+// progressively do: each chunk's points fade in and grow over a duration
+// from the time the chunk arrived. The vertex shader looks up each
+// point's chunk by its index, among a fixed number of chunks that Merge
+// keeps them within.
+package reveal
+
+import (
+	"math"
+	"sort"
+)
+
+// Chunk is a run of consecutive points that arrived at Time, in
+// milliseconds.
+type Chunk struct {
+	First, Count int
+	Time         float64
+}
+
+// Progress returns how far the reveal of points arriving at time is at
+// now, over duration milliseconds; 1 if duration is not positive.
+func Progress(time, now, duration float64) float64 {
+	if duration <= 0 {
+		return 1
+	}
+	return min(max((now-time)/duration, 0), 1)
+}
+
+// Prune returns the chunks still being revealed at now, reusing the
+// slice, or nil if none are.
+func Prune(chunks []Chunk, now, duration float64) []Chunk {
+	kept := chunks[:0]
+	for _, c := range chunks {
+		if Progress(c.Time, now, duration) < 1 {
+			kept = append(kept, c)
+		}
+	}
+	if len(kept) == 0 {
+		return nil
+	}
+	return kept
+}
+
+// Merge returns the chunks in order of their first points, at most n of
+// them: while there are more, the two neighbours that arrived closest in
+// time become one chunk covering both, and whatever lies between, timed
+// by the later, so no point shows before its chunk would have.
+func Merge(chunks []Chunk, n int) []Chunk {
+	merged := append([]Chunk(nil), chunks...)
+	sort.SliceStable(merged, func(i, j int) bool { return merged[i].First < merged[j].First })
+	for len(merged) > max(n, 1) {
+		best, gap := 0, math.Inf(1)
+		for i := 0; i+1 < len(merged); i++ {
+			if d := math.Abs(merged[i+1].Time - merged[i].Time); d < gap {
+				best, gap = i, d
+			}
+		}
+		a, b := merged[best], merged[best+1]
+		end := max(a.First+a.Count, b.First+b.Count)
+		merged[best] = Chunk{a.First, end - a.First, max(a.Time, b.Time)}
+		merged = append(merged[:best+1], merged[best+2:]...)
+	}
+	return merged
+}
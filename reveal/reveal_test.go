@@ -0,0 +1,69 @@
+// reveal/reveal_test.go
+// usage: go test
+
+package reveal
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestProgress(t *testing.T) {
+	for _, tt := range []struct {
+		time, now, duration, want float64
+	}{
+		{100, 100, 500, 0},
+		{100, 350, 500, 0.5},
+		{100, 900, 500, 1},
+		{100, 50, 500, 0},
+		{100, 100, 0, 1},
+	} {
+		if got := Progress(tt.time, tt.now, tt.duration); got != tt.want {
+			t.Errorf("Progress(%g, %g, %g): expected %g, got %g", tt.time, tt.now, tt.duration, tt.want, got)
+		}
+	}
+}
+
+func TestPruneDropsRevealedChunks(t *testing.T) {
+	chunks := []Chunk{{0, 10, 0}, {10, 10, 800}, {20, 10, 900}}
+	want := []Chunk{{10, 10, 800}, {20, 10, 900}}
+	if got := Prune(chunks, 1000, 500); !reflect.DeepEqual(got, want) {
+		t.Errorf("Prune: expected %v, got %v", want, got)
+	}
+	if got := Prune(chunks, 2000, 500); got != nil {
+		t.Errorf("Prune: expected nil once all are revealed, got %v", got)
+	}
+}
+
+func TestMergeSortsWithinLimit(t *testing.T) {
+	chunks := []Chunk{{60, 40, 750}, {20, 20, 500}, {40, 20, 600}}
+	want := []Chunk{{20, 20, 500}, {40, 20, 600}, {60, 40, 750}}
+	if got := Merge(chunks, 3); !reflect.DeepEqual(got, want) {
+		t.Errorf("Merge: expected %v, got %v", want, got)
+	}
+	if chunks[0].First != 60 {
+		t.Errorf("Merge: expected the chunks passed left in place, got %v", chunks)
+	}
+}
+
+func TestMergeJoinsClosestInTime(t *testing.T) {
+	chunks := []Chunk{{0, 10, 100}, {10, 10, 400}, {30, 10, 420}, {40, 10, 900}}
+	// The second and third arrived 20ms apart; they join, with the gap
+	// between them, at the later time.
+	want := []Chunk{{0, 10, 100}, {10, 30, 420}, {40, 10, 900}}
+	if got := Merge(chunks, 3); !reflect.DeepEqual(got, want) {
+		t.Errorf("Merge: expected %v, got %v", want, got)
+	}
+	want = []Chunk{{0, 50, 900}}
+	if got := Merge(chunks, 1); !reflect.DeepEqual(got, want) {
+		t.Errorf("Merge to one: expected %v, got %v", want, got)
+	}
+}
+
+func TestMergeOverlapping(t *testing.T) {
+	chunks := []Chunk{{0, 30, 500}, {20, 5, 510}}
+	want := []Chunk{{0, 30, 510}}
+	if got := Merge(chunks, 1); !reflect.DeepEqual(got, want) {
+		t.Errorf("Merge: expected %v, got %v", want, got)
+	}
+}
@@ -0,0 +1,290 @@
+// ros/pointcloud2.go
+// Package ros decodes ROS sensor messages as rosbridge delivers them, for
+// showing live robot data: sensor_msgs/PointCloud2 frames become point
+// clouds, and pose and odometry messages place them in the world.
+package ros
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"math"
+
+	"github.com/sbecker11/webgl-point-cloud/pointcloud"
+)
+
+// Datatypes of a PointField, as in sensor_msgs/PointField.
+const (
+	Int8    = 1
+	Uint8   = 2
+	Int16   = 3
+	Uint16  = 4
+	Int32   = 5
+	Uint32  = 6
+	Float32 = 7
+	Float64 = 8
+)
+
+// datatypeSize returns the size in bytes of a PointField datatype, or 0
+// for an unknown one.
+func datatypeSize(datatype uint8) int {
+	switch datatype {
+	case Int8, Uint8:
+		return 1
+	case Int16, Uint16:
+		return 2
+	case Int32, Uint32, Float32:
+		return 4
+	case Float64:
+		return 8
+	}
+	return 0
+}
+
+// Time is a message timestamp. ROS 1 names its parts secs and nsecs, ROS 2
+// sec and nanosec; either decodes.
+type Time struct {
+	Secs    int64 `json:"secs"`
+	Nsecs   int64 `json:"nsecs"`
+	Sec     int64 `json:"sec"`
+	Nanosec int64 `json:"nanosec"`
+}
+
+// Seconds returns the time in seconds.
+func (t Time) Seconds() float64 {
+	return float64(t.Secs+t.Sec) + float64(t.Nsecs+t.Nanosec)*1e-9
+}
+
+// Header is a std_msgs/Header.
+type Header struct {
+	Seq     uint32 `json:"seq"`
+	Stamp   Time   `json:"stamp"`
+	FrameID string `json:"frame_id"`
+}
+
+// PointField describes one field of the points in a PointCloud2: Count
+// values of type Datatype at byte Offset within each point.
+type PointField struct {
+	Name     string `json:"name"`
+	Offset   uint32 `json:"offset"`
+	Datatype uint8  `json:"datatype"`
+	Count    uint32 `json:"count"`
+}
+
+// PointCloud2 is a sensor_msgs/PointCloud2: Height rows of Width points,
+// each PointStep bytes laid out as Fields describe, in Data. rosbridge
+// sends Data base64 encoded, which encoding/json decodes into the slice.
+type PointCloud2 struct {
+	Header      Header       `json:"header"`
+	Height      uint32       `json:"height"`
+	Width       uint32       `json:"width"`
+	Fields      []PointField `json:"fields"`
+	IsBigendian bool         `json:"is_bigendian"`
+	PointStep   uint32       `json:"point_step"`
+	RowStep     uint32       `json:"row_step"`
+	Data        []byte       `json:"data"`
+	IsDense     bool         `json:"is_dense"`
+}
+
+// ParsePointCloud2 decodes a PointCloud2 from its JSON form.
+func ParsePointCloud2(data []byte) (*PointCloud2, error) {
+	var m PointCloud2
+	if err := json.Unmarshal(data, &m); err != nil {
+		return nil, fmt.Errorf("PointCloud2: %v", err)
+	}
+	return &m, nil
+}
+
+// Field returns the named field, or false if the points have none.
+func (m *PointCloud2) Field(name string) (PointField, bool) {
+	for _, f := range m.Fields {
+		if f.Name == name {
+			return f, true
+		}
+	}
+	return PointField{}, false
+}
+
+// value reads the first value of field f of the point starting at byte p.
+func (m *PointCloud2) value(f PointField, p int) float64 {
+	var order binary.ByteOrder = binary.LittleEndian
+	if m.IsBigendian {
+		order = binary.BigEndian
+	}
+	b := m.Data[p+int(f.Offset):]
+	switch f.Datatype {
+	case Int8:
+		return float64(int8(b[0]))
+	case Uint8:
+		return float64(b[0])
+	case Int16:
+		return float64(int16(order.Uint16(b)))
+	case Uint16:
+		return float64(order.Uint16(b))
+	case Int32:
+		return float64(int32(order.Uint32(b)))
+	case Uint32:
+		return float64(order.Uint32(b))
+	case Float32:
+		return float64(math.Float32frombits(order.Uint32(b)))
+	}
+	return math.Float64frombits(order.Uint64(b))
+}
+
+// packedColor reads the 4-byte packed color of field f of the point
+// starting at byte p. PCL stores rgb as a float32 whose bits are
+// 0x00RRGGBB and rgba as 0xAARRGGBB; only the bits matter.
+func (m *PointCloud2) packedColor(f PointField, p int) uint32 {
+	b := m.Data[p+int(f.Offset):]
+	if m.IsBigendian {
+		return binary.BigEndian.Uint32(b)
+	}
+	return binary.LittleEndian.Uint32(b)
+}
+
+// check returns an error if the layout does not fit the data.
+func (m *PointCloud2) check() error {
+	if m.Width == 0 || m.Height == 0 {
+		return nil
+	}
+	rowStep := m.RowStep
+	if m.Height == 1 && rowStep == 0 {
+		rowStep = m.Width * m.PointStep
+	}
+	if m.PointStep == 0 || rowStep < m.Width*m.PointStep {
+		return fmt.Errorf("PointCloud2: point_step %d and row_step %d do not fit %d points a row", m.PointStep, m.RowStep, m.Width)
+	}
+	if need := uint64(m.Height-1)*uint64(rowStep) + uint64(m.Width)*uint64(m.PointStep); uint64(len(m.Data)) < need {
+		return fmt.Errorf("PointCloud2: %d bytes of data, expected %d", len(m.Data), need)
+	}
+	for _, f := range m.Fields {
+		size := datatypeSize(f.Datatype)
+		if size == 0 {
+			return fmt.Errorf("PointCloud2: field %s has unknown datatype %d", f.Name, f.Datatype)
+		}
+		if int(f.Offset)+size > int(m.PointStep) {
+			return fmt.Errorf("PointCloud2: field %s extends past point_step", f.Name)
+		}
+	}
+	return nil
+}
+
+// Cloud converts the message into a point cloud named name. Points need x,
+// y and z fields; points with a non-finite coordinate, which organized
+// clouds use for missing returns, are left out. Colors come from an rgb or
+// rgba field, or else from intensity as gray levels scaled by the frame's
+// highest intensity, or else are white. normal_x, normal_y and normal_z
+// become the cloud's normals, and any other single-valued field, such as
+// intensity, ring or time, becomes a float32 attribute of the same name.
+func (m *PointCloud2) Cloud(name string) (*pointcloud.Cloud, error) {
+	if err := m.check(); err != nil {
+		return nil, err
+	}
+	var xyz [3]PointField
+	for k, axis := range []string{"x", "y", "z"} {
+		f, ok := m.Field(axis)
+		if !ok {
+			return nil, fmt.Errorf("PointCloud2: no %s field", axis)
+		}
+		xyz[k] = f
+	}
+	color, hasColor := m.Field("rgb")
+	alpha := false
+	if f, ok := m.Field("rgba"); ok && !hasColor {
+		color, hasColor, alpha = f, true, true
+	}
+	if hasColor && datatypeSize(color.Datatype) != 4 {
+		return nil, fmt.Errorf("PointCloud2: field %s must be 4 bytes", color.Name)
+	}
+	var normals [3]PointField
+	hasNormals := true
+	for k, axis := range []string{"normal_x", "normal_y", "normal_z"} {
+		normals[k], hasNormals = m.Field(axis)
+		if !hasNormals {
+			break
+		}
+	}
+	var extras []PointField
+	for _, f := range m.Fields {
+		switch f.Name {
+		case "x", "y", "z", "rgb", "rgba", "normal_x", "normal_y", "normal_z", pointcloud.AttrPosition, pointcloud.AttrColor, pointcloud.AttrNormal, pointcloud.AttrClass:
+			continue
+		}
+		if f.Count <= 1 && f.Name != "" {
+			extras = append(extras, f)
+		}
+	}
+
+	n := int(m.Width * m.Height)
+	coords := make([]float32, 0, n*3)
+	var colors, norms []float32
+	if hasColor {
+		colors = make([]float32, 0, n*4)
+	}
+	if hasNormals {
+		norms = make([]float32, 0, n*3)
+	}
+	values := make([][]float32, len(extras))
+	for row := 0; row < int(m.Height); row++ {
+		start := row * int(m.RowStep)
+		if m.Height == 1 {
+			start = 0
+		}
+		for col := 0; col < int(m.Width); col++ {
+			p := start + col*int(m.PointStep)
+			x, y, z := m.value(xyz[0], p), m.value(xyz[1], p), m.value(xyz[2], p)
+			if !finite(x) || !finite(y) || !finite(z) {
+				continue
+			}
+			coords = append(coords, float32(x), float32(y), float32(z))
+			if hasColor {
+				c := m.packedColor(color, p)
+				a := float32(1)
+				if alpha {
+					a = float32(c>>24) / 255
+				}
+				colors = append(colors, float32(c>>16&0xff)/255, float32(c>>8&0xff)/255, float32(c&0xff)/255, a)
+			}
+			if hasNormals {
+				norms = append(norms, float32(m.value(normals[0], p)), float32(m.value(normals[1], p)), float32(m.value(normals[2], p)))
+			}
+			for i, f := range extras {
+				values[i] = append(values[i], float32(m.value(f, p)))
+			}
+		}
+	}
+
+	count := len(coords) / 3
+	if !hasColor {
+		colors = make([]float32, count*4)
+		intensity := -1
+		for i, f := range extras {
+			if f.Name == "intensity" {
+				intensity = i
+			}
+		}
+		var peak float32
+		if intensity >= 0 {
+			for _, v := range values[intensity] {
+				peak = max(peak, v)
+			}
+		}
+		for i := 0; i < count; i++ {
+			gray := float32(1)
+			if peak > 0 {
+				gray = max(0, values[intensity][i]/peak)
+			}
+			colors[i*4], colors[i*4+1], colors[i*4+2], colors[i*4+3] = gray, gray, gray, 1
+		}
+	}
+	cloud := pointcloud.New(name, coords, colors)
+	cloud.Normals = norms
+	for i, f := range extras {
+		cloud.SetAttribute(pointcloud.Attribute{Name: f.Name, Components: 1, Type: pointcloud.Float32}, values[i])
+	}
+	return cloud, nil
+}
+
+func finite(v float64) bool {
+	return !math.IsNaN(v) && !math.IsInf(v, 0)
+}
@@ -0,0 +1,63 @@
+// ros/pose.go
+package ros
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/sbecker11/webgl-point-cloud/glf32"
+)
+
+// Point is a geometry_msgs/Point.
+type Point struct {
+	X float64 `json:"x"`
+	Y float64 `json:"y"`
+	Z float64 `json:"z"`
+}
+
+// Quaternion is a geometry_msgs/Quaternion.
+type Quaternion struct {
+	X float64 `json:"x"`
+	Y float64 `json:"y"`
+	Z float64 `json:"z"`
+	W float64 `json:"w"`
+}
+
+// Pose is a geometry_msgs/Pose: where a frame is and how it is turned in
+// its parent frame.
+type Pose struct {
+	Position    Point      `json:"position"`
+	Orientation Quaternion `json:"orientation"`
+}
+
+// Matrix returns the pose as a column-major matrix taking points in the
+// posed frame to its parent frame.
+func (p Pose) Matrix() glf32.Mat4 {
+	q := p.Orientation
+	m := glf32.FromQuaternion(float32(q.X), float32(q.Y), float32(q.Z), float32(q.W))
+	m[12], m[13], m[14] = float32(p.Position.X), float32(p.Position.Y), float32(p.Position.Z)
+	return m
+}
+
+// ParsePose decodes the pose of a geometry_msgs/PoseStamped, or of a
+// nav_msgs/Odometry or geometry_msgs/PoseWithCovarianceStamped, which nest
+// it one level deeper, as SLAM and odometry nodes publish them.
+func ParsePose(data []byte) (Header, Pose, error) {
+	var msg struct {
+		Header Header `json:"header"`
+		Pose   *struct {
+			Pose
+			Nested *Pose `json:"pose"`
+		} `json:"pose"`
+	}
+	if err := json.Unmarshal(data, &msg); err != nil {
+		return msg.Header, Pose{}, fmt.Errorf("ros: pose: %v", err)
+	}
+	if msg.Pose == nil {
+		return msg.Header, Pose{}, fmt.Errorf("ros: message has no pose")
+	}
+	if msg.Pose.Nested != nil {
+		return msg.Header, *msg.Pose.Nested, nil
+	}
+	return msg.Header, msg.Pose.Pose, nil
+}
@@ -0,0 +1,157 @@
+// ros/ros_test.go
+// usage: go test
+
+package ros
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/sbecker11/webgl-point-cloud/glf32"
+)
+
+// testCloud returns a PointCloud2 of points x, y, z (float32), a padding
+// byte, intensity (uint16) and, if withColor, rgb (float32-packed).
+func testCloud(points [][3]float32, intensities []uint16, rgb []uint32, big bool) *PointCloud2 {
+	var order binary.ByteOrder = binary.LittleEndian
+	if big {
+		order = binary.BigEndian
+	}
+	m := &PointCloud2{
+		Height: 1, Width: uint32(len(points)), IsBigendian: big, PointStep: 20,
+		Fields: []PointField{
+			{"x", 0, Float32, 1}, {"y", 4, Float32, 1}, {"z", 8, Float32, 1},
+			{"intensity", 14, Uint16, 1},
+		},
+	}
+	if rgb != nil {
+		m.Fields = append(m.Fields, PointField{"rgb", 16, Float32, 1})
+	}
+	m.RowStep = m.Width * m.PointStep
+	m.Data = make([]byte, m.RowStep)
+	for i, p := range points {
+		b := m.Data[i*20:]
+		for k := 0; k < 3; k++ {
+			order.PutUint32(b[k*4:], math.Float32bits(p[k]))
+		}
+		order.PutUint16(b[14:], intensities[i])
+		if rgb != nil {
+			order.PutUint32(b[16:], rgb[i])
+		}
+	}
+	return m
+}
+
+func TestCloud(t *testing.T) {
+	nan := float32(math.NaN())
+	for _, big := range []bool{false, true} {
+		m := testCloud([][3]float32{{1, 2, 3}, {nan, 0, 0}, {-4, 5.5, 6}}, []uint16{100, 7, 50}, []uint32{0xff8000, 0, 0x0000ff}, big)
+		c, err := m.Cloud("scan")
+		if err != nil {
+			t.Fatalf("Cloud: %v", err)
+		}
+		if c.Len() != 2 || c.Name != "scan" {
+			t.Fatalf("expected 2 points named scan without the NaN one, got %d named %s", c.Len(), c.Name)
+		}
+		if p := c.Point(1); p[0] != -4 || p[1] != 5.5 || p[2] != 6 {
+			t.Errorf("big endian %v: expected point 1 at -4 5.5 6, got %v", big, p)
+		}
+		if r, g, b := c.Colors[0], c.Colors[1], c.Colors[2]; r != 1 || math.Abs(float64(g)-128.0/255) > 1e-6 || b != 0 {
+			t.Errorf("big endian %v: expected orange, got %v", big, c.Colors[:4])
+		}
+		if c.Colors[6] != 1 || c.Colors[7] != 1 {
+			t.Errorf("big endian %v: expected opaque blue, got %v", big, c.Colors[4:])
+		}
+		if _, values, ok := c.Attribute("intensity"); !ok || len(values) != 2 || values[0] != 100 || values[1] != 50 {
+			t.Errorf("big endian %v: expected intensity [100 50], got %v", big, values)
+		}
+	}
+}
+
+func TestCloudIntensityColors(t *testing.T) {
+	m := testCloud([][3]float32{{0, 0, 0}, {1, 1, 1}}, []uint16{200, 50}, nil, false)
+	c, err := m.Cloud("scan")
+	if err != nil {
+		t.Fatalf("Cloud: %v", err)
+	}
+	if c.Colors[0] != 1 || c.Colors[4] != 0.25 || c.Colors[7] != 1 {
+		t.Errorf("expected gray levels 1 and 0.25, got %v", c.Colors)
+	}
+}
+
+func TestCloudErrors(t *testing.T) {
+	m := testCloud([][3]float32{{0, 0, 0}}, []uint16{0}, nil, false)
+	m.Data = m.Data[:10]
+	if _, err := m.Cloud("short"); err == nil {
+		t.Error("expected an error for truncated data")
+	}
+	m = testCloud([][3]float32{{0, 0, 0}}, []uint16{0}, nil, false)
+	m.Fields = m.Fields[1:]
+	if _, err := m.Cloud("no x"); err == nil || !strings.Contains(err.Error(), "no x field") {
+		t.Errorf("expected a missing x error, got %v", err)
+	}
+}
+
+func TestParse(t *testing.T) {
+	m := testCloud([][3]float32{{1, 2, 3}}, []uint16{9}, nil, false)
+	m.Header = Header{FrameID: "velodyne", Stamp: Time{Sec: 12, Nanosec: 500000000}}
+	msg, _ := json.Marshal(m)
+	data, _ := json.Marshal(Message{Op: "publish", Topic: "/points", Msg: msg})
+
+	env, err := ParseMessage(data)
+	if err != nil || env.Op != "publish" || env.Topic != "/points" {
+		t.Fatalf("ParseMessage: got %+v, %v", env, err)
+	}
+	parsed, err := ParsePointCloud2(env.Msg)
+	if err != nil {
+		t.Fatalf("ParsePointCloud2: %v", err)
+	}
+	if parsed.Header.FrameID != "velodyne" || parsed.Header.Stamp.Seconds() != 12.5 || len(parsed.Data) != 20 {
+		t.Errorf("unexpected round trip %+v", parsed.Header)
+	}
+
+	if _, err := ParseMessage([]byte(`{"op":"status","level":"error","msg":"Unknown topic"}`)); err == nil || !strings.Contains(err.Error(), "Unknown topic") {
+		t.Errorf("expected the status error, got %v", err)
+	}
+}
+
+func TestSubscribe(t *testing.T) {
+	var req map[string]interface{}
+	json.Unmarshal(Subscribe("viewer", "/points", "sensor_msgs/PointCloud2", 100), &req)
+	if req["op"] != "subscribe" || req["topic"] != "/points" || req["type"] != "sensor_msgs/PointCloud2" || req["throttle_rate"] != 100.0 || req["queue_length"] != 1.0 {
+		t.Errorf("unexpected subscribe request %v", req)
+	}
+	req = nil
+	json.Unmarshal(Unsubscribe("viewer", "/points"), &req)
+	if req["op"] != "unsubscribe" || req["id"] != "viewer" {
+		t.Errorf("unexpected unsubscribe request %v", req)
+	}
+}
+
+func TestParsePose(t *testing.T) {
+	stamped := `{"header": {"frame_id": "map"}, "pose": {"position": {"x": 1, "y": 2, "z": 3}, "orientation": {"x": 0, "y": 0, "z": 0.7071068, "w": 0.7071068}}}`
+	odometry := `{"header": {"frame_id": "odom"}, "child_frame_id": "base_link", "pose": {"pose": {"position": {"x": 1, "y": 2, "z": 3}, "orientation": {"x": 0, "y": 0, "z": 0.7071068, "w": 0.7071068}}, "covariance": []}}`
+	for _, msg := range []string{stamped, odometry} {
+		header, pose, err := ParsePose([]byte(msg))
+		if err != nil {
+			t.Fatalf("ParsePose: %v", err)
+		}
+		// A quarter turn about z: x goes to y.
+		m := pose.Matrix()
+		p := glf32.TransformVertices([]float32{1, 0, 0}, m)
+		if math.Abs(float64(p[0]-1)) > 1e-5 || math.Abs(float64(p[1]-3)) > 1e-5 || math.Abs(float64(p[2]-3)) > 1e-5 {
+			t.Errorf("%s: expected (1, 3, 3), got %v", header.FrameID, p)
+		}
+	}
+	if _, _, err := ParsePose([]byte(`{"header": {}}`)); err == nil {
+		t.Error("expected an error without a pose")
+	}
+	var req map[string]interface{}
+	json.Unmarshal(Subscribe("viewer/pose", "/odom", "", 0), &req)
+	if _, ok := req["type"]; ok {
+		t.Errorf("expected no type, got %v", req)
+	}
+}
@@ -0,0 +1,61 @@
+// ros/rosbridge.go
+package ros
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// Message is a rosbridge protocol message. Publish messages carry a
+// topic's message in Msg; status messages carry a Level and their text
+// in Msg.
+type Message struct {
+	Op    string          `json:"op"`
+	ID    string          `json:"id,omitempty"`
+	Topic string          `json:"topic,omitempty"`
+	Msg   json.RawMessage `json:"msg,omitempty"`
+	Level string          `json:"level,omitempty"`
+}
+
+// ParseMessage decodes a rosbridge message. A status message at level
+// "error" is returned as an error.
+func ParseMessage(data []byte) (*Message, error) {
+	var m Message
+	if err := json.Unmarshal(data, &m); err != nil {
+		return nil, fmt.Errorf("rosbridge: %v", err)
+	}
+	if m.Op == "status" && m.Level == "error" {
+		var text string
+		json.Unmarshal(m.Msg, &text)
+		return nil, fmt.Errorf("rosbridge: %s", text)
+	}
+	return &m, nil
+}
+
+// Subscribe returns the rosbridge request subscribing to topic, whose
+// messages are of type msgType, e.g. "sensor_msgs/PointCloud2", or of the
+// type the topic is advertised with if msgType is "". With
+// throttleMs above 0 the bridge sends at most one message per throttleMs
+// milliseconds, and keeps only the latest while the client is behind.
+func Subscribe(id, topic, msgType string, throttleMs int) []byte {
+	req := struct {
+		Op           string `json:"op"`
+		ID           string `json:"id,omitempty"`
+		Topic        string `json:"topic"`
+		Type         string `json:"type,omitempty"`
+		ThrottleRate int    `json:"throttle_rate,omitempty"`
+		QueueLength  int    `json:"queue_length,omitempty"`
+	}{"subscribe", id, topic, msgType, max(0, throttleMs), 0}
+	if throttleMs > 0 {
+		req.QueueLength = 1
+	}
+	data, _ := json.Marshal(req)
+	return data
+}
+
+// Unsubscribe returns the rosbridge request ending subscription id to
+// topic.
+func Unsubscribe(id, topic string) []byte {
+	data, _ := json.Marshal(Message{Op: "unsubscribe", ID: id, Topic: topic})
+	return data
+}
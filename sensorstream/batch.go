@@ -0,0 +1,116 @@
+// sensorstream/batch.go
+// Package sensorstream relays point batches that edge devices publish to a
+// message broker (MQTT or NATS) on to viewers over a WebSocket. Each topic
+// becomes a named dataset holding a rolling window of its latest points.
+package sensorstream
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+
+	"github.com/sbecker11/webgl-point-cloud/glf32"
+)
+
+// batchMagic starts every encoded batch; posedBatchMagic starts those with
+// a pose.
+const (
+	batchMagic      = "PTS1"
+	posedBatchMagic = "PTS2"
+)
+
+// Batch is a set of points: packed xyz coordinates and, optionally, packed
+// RGBA bytes and the pose of the sensor that took them, the column-major
+// matrix taking them from the sensor's frame to the world's.
+type Batch struct {
+	Coords []float32
+	Colors []uint8
+	Pose   glf32.Mat4
+}
+
+// Len returns the number of points in the batch.
+func (b *Batch) Len() int {
+	return len(b.Coords) / 3
+}
+
+// Encode returns the batch in the format devices publish and viewers
+// receive, all little-endian:
+//
+//	"PTS1" or "PTS2"  magic; "PTS2" if the batch has a pose
+//	uint32 n          number of points
+//	16 float32        pose as glf32.Mat4 encodes it, for "PTS2" only
+//	n*3 float32       packed xyz coordinates
+//	n*4 uint8         packed RGBA colors, optional
+func (b *Batch) Encode() []byte {
+	n := b.Len()
+	data := make([]byte, 8, 8+64+n*12+len(b.Colors))
+	copy(data, batchMagic)
+	binary.LittleEndian.PutUint32(data[4:], uint32(n))
+	if len(b.Pose) == 16 {
+		copy(data, posedBatchMagic)
+		data, _ = b.Pose.AppendBinary(data)
+	}
+	for _, v := range b.Coords[:n*3] {
+		data = binary.LittleEndian.AppendUint32(data, math.Float32bits(v))
+	}
+	if len(b.Colors) == n*4 {
+		data = append(data, b.Colors...)
+	}
+	return data
+}
+
+// DecodeBatch decodes a batch encoded as Encode does.
+func DecodeBatch(data []byte) (*Batch, error) {
+	if len(data) < 8 || string(data[:4]) != batchMagic && string(data[:4]) != posedBatchMagic {
+		return nil, fmt.Errorf("sensorstream: batch does not start with %q or %q", batchMagic, posedBatchMagic)
+	}
+	n := int(binary.LittleEndian.Uint32(data[4:]))
+	body := data[8:]
+	b := &Batch{}
+	if string(data[:4]) == posedBatchMagic {
+		if len(body) < 64 {
+			return nil, fmt.Errorf("sensorstream: batch too short for its pose")
+		}
+		b.Pose.UnmarshalBinary(body[:64])
+		body = body[64:]
+	}
+	if n > len(body)/12 {
+		return nil, fmt.Errorf("sensorstream: batch of %d points has only %d bytes", n, len(body))
+	}
+	b.Coords = make([]float32, n*3)
+	for i := range b.Coords {
+		b.Coords[i] = math.Float32frombits(binary.LittleEndian.Uint32(body[i*4:]))
+	}
+	switch rest := body[n*12:]; len(rest) {
+	case 0:
+	case n * 4:
+		b.Colors = append([]uint8(nil), rest...)
+	default:
+		return nil, fmt.Errorf("sensorstream: batch of %d points has %d bytes of colors, expected %d", n, len(rest), n*4)
+	}
+	return b, nil
+}
+
+// EncodeFrame returns the WebSocket message relaying a dataset's points to
+// viewers: a uint16 little-endian name length, the name, then the batch.
+// Relayed points are in the world's frame already (see Hub.Add), so a
+// relayed pose only marks where the sensor was.
+func EncodeFrame(dataset string, b *Batch) []byte {
+	name := dataset[:min(len(dataset), math.MaxUint16)]
+	data := binary.LittleEndian.AppendUint16(nil, uint16(len(name)))
+	data = append(data, name...)
+	return append(data, b.Encode()...)
+}
+
+// DecodeFrame decodes a message encoded by EncodeFrame.
+func DecodeFrame(data []byte) (dataset string, b *Batch, err error) {
+	if len(data) < 2 {
+		return "", nil, fmt.Errorf("sensorstream: frame too short")
+	}
+	n := int(binary.LittleEndian.Uint16(data))
+	if len(data) < 2+n {
+		return "", nil, fmt.Errorf("sensorstream: frame too short for its name")
+	}
+	b, err = DecodeBatch(data[2+n:])
+	return string(data[2 : 2+n]), b, err
+}
@@ -0,0 +1,89 @@
+// sensorstream/batch_test.go
+// usage: go test
+
+package sensorstream
+
+import (
+	"testing"
+
+	"github.com/sbecker11/webgl-point-cloud/glf32"
+)
+
+func TestBatchRoundTrip(t *testing.T) {
+	for _, b := range []*Batch{
+		{Coords: []float32{1, 2, 3, -4, 5.5, 6}},
+		{Coords: []float32{1, 2, 3}, Colors: []uint8{255, 128, 0, 255}},
+		{},
+		{Coords: []float32{1, 2, 3}, Pose: glf32.Translate(4, 5, 6)},
+	} {
+		got, err := DecodeBatch(b.Encode())
+		if err != nil {
+			t.Fatalf("DecodeBatch: %v", err)
+		}
+		if got.Len() != b.Len() || len(got.Colors) != len(b.Colors) {
+			t.Fatalf("expected %d points and %d color bytes, got %d and %d", b.Len(), len(b.Colors), got.Len(), len(got.Colors))
+		}
+		for i := range b.Coords {
+			if got.Coords[i] != b.Coords[i] {
+				t.Errorf("coordinate %d: expected %g, got %g", i, b.Coords[i], got.Coords[i])
+			}
+		}
+		if len(got.Pose) != len(b.Pose) || len(b.Pose) > 0 && got.Pose[13] != b.Pose[13] {
+			t.Errorf("expected pose %v, got %v", b.Pose, got.Pose)
+		}
+		for i := range b.Colors {
+			if got.Colors[i] != b.Colors[i] {
+				t.Errorf("color byte %d: expected %d, got %d", i, b.Colors[i], got.Colors[i])
+			}
+		}
+	}
+}
+
+func TestDecodeBatchErrors(t *testing.T) {
+	data := (&Batch{Coords: []float32{1, 2, 3}, Colors: []uint8{1, 2, 3, 4}}).Encode()
+	for name, bad := range map[string][]byte{
+		"magic":  append([]byte("XXXX"), data[4:]...),
+		"short":  data[:15],
+		"colors": data[:len(data)-1],
+		"pose":   append([]byte("PTS2"), data[4:]...),
+	} {
+		if _, err := DecodeBatch(bad); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
+
+func TestFrameRoundTrip(t *testing.T) {
+	dataset, b, err := DecodeFrame(EncodeFrame("lidar-front", &Batch{Coords: []float32{7, 8, 9}}))
+	if err != nil || dataset != "lidar-front" || b.Len() != 1 || b.Coords[2] != 9 {
+		t.Errorf("expected lidar-front with 1 point, got %q %v %v", dataset, b, err)
+	}
+	if _, _, err := DecodeFrame([]byte{9, 0, 'a'}); err == nil {
+		t.Error("expected an error for a truncated name")
+	}
+}
+
+func TestNamer(t *testing.T) {
+	n, err := ParseNamer("site/+/lidar/+=lidar-{2}@{1}, robots.>=robot:{#}, fixed/topic=fixed")
+	if err != nil {
+		t.Fatalf("ParseNamer: %v", err)
+	}
+	for topic, want := range map[string]string{
+		"site/dock/lidar/front": "lidar-front@dock",
+		"site/dock/lidar":       "site/dock/lidar",
+		"site/dock/lidar/a/b":   "site/dock/lidar/a/b",
+		"robots.r2.points":      "robot:r2.points",
+		"robots":                "robots",
+		"fixed/topic":           "fixed",
+		"other/device/points":   "other/device/points",
+	} {
+		if got := n.Name(topic); got != want {
+			t.Errorf("%s: expected %q, got %q", topic, want, got)
+		}
+	}
+	for _, bad := range []string{"no-equals", "a/#/b=x", "=x"} {
+		if _, err := ParseNamer(bad); err == nil {
+			t.Errorf("%q: expected an error", bad)
+		}
+	}
+}
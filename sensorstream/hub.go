@@ -0,0 +1,186 @@
+// sensorstream/hub.go
+package sensorstream
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/sbecker11/webgl-point-cloud/glf32"
+)
+
+// clientQueue is how many frames a viewer may fall behind before frames
+// for it are dropped.
+const clientQueue = 16
+
+// Hub buffers each dataset's latest points and relays them to viewers. It
+// keeps a rolling window of up to MaxPoints points per dataset, fed by
+// batches as they arrive, and sends the windows that changed to every
+// viewer on each Flush, so devices may publish many small batches without
+// flooding viewers. Viewers that join get every window at once.
+type Hub struct {
+	MaxPoints int
+
+	mu      sync.Mutex
+	windows map[string]*window
+	clients map[chan []byte]bool
+	dropped int
+}
+
+// window is a dataset's buffered points.
+type window struct {
+	batch   Batch
+	colored bool
+	dirty   bool
+}
+
+// NewHub returns a hub keeping up to maxPoints points per dataset.
+func NewHub(maxPoints int) *Hub {
+	return &Hub{MaxPoints: max(1, maxPoints), windows: map[string]*window{}, clients: map[chan []byte]bool{}}
+}
+
+// Add appends a batch to a dataset's window, dropping its oldest points
+// beyond MaxPoints. A window stays colored once a colored batch arrives;
+// the points of uncolored batches in it, before or after, are white. The
+// points of a batch with a pose are moved into the world's frame as they
+// arrive, and the window keeps the latest pose.
+func (h *Hub) Add(dataset string, b *Batch) {
+	b = b.placed()
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	w := h.windows[dataset]
+	if w == nil {
+		w = &window{}
+		h.windows[dataset] = w
+	}
+	colored := len(b.Colors) == b.Len()*4
+	if colored && !w.colored {
+		w.batch.Colors = make([]uint8, w.batch.Len()*4)
+		for i := range w.batch.Colors {
+			w.batch.Colors[i] = 255
+		}
+	}
+	w.colored = w.colored || colored
+	n := b.Len()
+	w.batch.Coords = append(w.batch.Coords, b.Coords[:n*3]...)
+	if w.colored {
+		if colored {
+			w.batch.Colors = append(w.batch.Colors, b.Colors...)
+		} else {
+			for i := 0; i < n*4; i++ {
+				w.batch.Colors = append(w.batch.Colors, 255)
+			}
+		}
+	}
+	if excess := w.batch.Len() - h.MaxPoints; excess > 0 {
+		w.batch.Coords = append(w.batch.Coords[:0], w.batch.Coords[excess*3:]...)
+		if w.colored {
+			w.batch.Colors = append(w.batch.Colors[:0], w.batch.Colors[excess*4:]...)
+		}
+	}
+	if b.Pose != nil {
+		w.batch.Pose = b.Pose
+	}
+	w.dirty = true
+}
+
+// placed returns the batch with its points moved into the world's frame
+// by its pose, or the batch itself if it has none.
+func (b *Batch) placed() *Batch {
+	if len(b.Pose) != 16 {
+		return &Batch{Coords: b.Coords, Colors: b.Colors}
+	}
+	coords := append([]float32(nil), b.Coords[:b.Len()*3]...)
+	return &Batch{Coords: glf32.TransformVertices(coords, b.Pose), Colors: b.Colors, Pose: append(glf32.Mat4(nil), b.Pose...)}
+}
+
+// Set replaces a dataset's window with a batch, for sources that produce
+// whole frames, such as LiDAR scans. Beyond MaxPoints, only the batch's
+// last points are kept. Posed batches are placed as by Add.
+func (h *Hub) Set(dataset string, b *Batch) {
+	b = b.placed()
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	n := b.Len()
+	keep := max(0, n-h.MaxPoints)
+	w := &window{colored: len(b.Colors) == n*4, dirty: true}
+	w.batch.Pose = b.Pose
+	w.batch.Coords = append([]float32(nil), b.Coords[keep*3:n*3]...)
+	if w.colored {
+		w.batch.Colors = append([]uint8(nil), b.Colors[keep*4:]...)
+	}
+	h.windows[dataset] = w
+}
+
+// Flush sends the windows changed since the last flush to every viewer.
+func (h *Hub) Flush() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.flush()
+}
+
+// flush is Flush with h.mu held.
+func (h *Hub) flush() {
+	for name, w := range h.windows {
+		if !w.dirty {
+			continue
+		}
+		w.dirty = false
+		frame := EncodeFrame(name, &w.batch)
+		for c := range h.clients {
+			h.send(c, frame)
+		}
+	}
+}
+
+// send queues a frame for a viewer, dropping it if the viewer is too far
+// behind. h.mu must be held.
+func (h *Hub) send(c chan []byte, frame []byte) {
+	select {
+	case c <- frame:
+	default:
+		h.dropped++
+	}
+}
+
+// Join registers a viewer, returning the channel its frames arrive on,
+// starting with every window, and a function that unregisters it.
+func (h *Hub) Join() (<-chan []byte, func()) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	// Bring the other viewers up to date first, so that the new one does
+	// not get the changed windows twice.
+	h.flush()
+	c := make(chan []byte, max(clientQueue, len(h.windows)))
+	for name, w := range h.windows {
+		h.send(c, EncodeFrame(name, &w.batch))
+	}
+	h.clients[c] = true
+	return c, func() {
+		h.mu.Lock()
+		defer h.mu.Unlock()
+		delete(h.clients, c)
+	}
+}
+
+// Stats returns the number of datasets and viewers, and the frames dropped
+// for viewers that fell behind.
+func (h *Hub) Stats() (datasets, viewers, dropped int) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return len(h.windows), len(h.clients), h.dropped
+}
+
+// Run flushes every interval until ctx is done.
+func (h *Hub) Run(ctx context.Context, interval time.Duration) {
+	t := time.NewTicker(interval)
+	defer t.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C:
+			h.Flush()
+		}
+	}
+}
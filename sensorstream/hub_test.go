@@ -0,0 +1,167 @@
+// sensorstream/hub_test.go
+// usage: go test
+
+package sensorstream
+
+import (
+	"bufio"
+	"encoding/binary"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sbecker11/webgl-point-cloud/glf32"
+)
+
+func TestHubWindow(t *testing.T) {
+	h := NewHub(3)
+	h.Add("a", &Batch{Coords: []float32{0, 0, 0, 1, 1, 1}})
+	h.Add("a", &Batch{Coords: []float32{2, 2, 2, 3, 3, 3}, Colors: []uint8{1, 2, 3, 4, 5, 6, 7, 8}})
+	frames, leave := h.Join()
+	defer leave()
+
+	name, b, err := DecodeFrame(<-frames)
+	if err != nil || name != "a" {
+		t.Fatalf("expected a frame for a, got %q %v", name, err)
+	}
+	// The oldest point is dropped; the uncolored one left turns white.
+	if b.Len() != 3 || b.Coords[0] != 1 || b.Coords[8] != 3 {
+		t.Errorf("expected the window 1, 2, 3, got %v", b.Coords)
+	}
+	if len(b.Colors) != 12 || b.Colors[0] != 255 || b.Colors[4] != 1 || b.Colors[11] != 8 {
+		t.Errorf("unexpected colors %v", b.Colors)
+	}
+
+	h.Flush()
+	h.Add("b", &Batch{Coords: []float32{9, 9, 9}})
+	h.Flush()
+	select {
+	case frame := <-frames:
+		if name, _, _ := DecodeFrame(frame); name != "b" {
+			t.Errorf("expected only b to be flushed, got %s", name)
+		}
+	default:
+		t.Fatal("expected a frame for b")
+	}
+	if len(frames) != 0 {
+		t.Errorf("expected no more frames, got %d", len(frames))
+	}
+	if datasets, viewers, _ := h.Stats(); datasets != 2 || viewers != 1 {
+		t.Errorf("expected 2 datasets and 1 viewer, got %d and %d", datasets, viewers)
+	}
+}
+
+func TestHubSet(t *testing.T) {
+	h := NewHub(2)
+	h.Add("scan", &Batch{Coords: []float32{0, 0, 0}, Colors: []uint8{1, 2, 3, 4}})
+	h.Set("scan", &Batch{Coords: []float32{1, 1, 1, 2, 2, 2, 3, 3, 3}})
+	frames, leave := h.Join()
+	defer leave()
+	if _, b, _ := DecodeFrame(<-frames); b.Len() != 2 || b.Coords[0] != 2 || b.Colors != nil {
+		t.Errorf("expected the last 2 points of the set batch, uncolored, got %v %v", b.Coords, b.Colors)
+	}
+}
+
+func TestHubPose(t *testing.T) {
+	h := NewHub(10)
+	pose := glf32.Translate(10, 0, 0)
+	b := &Batch{Coords: []float32{1, 2, 3}, Pose: pose}
+	h.Add("slam", b)
+	h.Add("slam", &Batch{Coords: []float32{4, 5, 6}})
+	if b.Coords[0] != 1 {
+		t.Errorf("expected the added batch to be left as it was, got %v", b.Coords)
+	}
+	frames, leave := h.Join()
+	defer leave()
+	_, got, err := DecodeFrame(<-frames)
+	if err != nil || got.Len() != 2 || got.Coords[0] != 11 || got.Coords[3] != 4 {
+		t.Fatalf("expected the posed point moved into the world, got %v, %v", got, err)
+	}
+	if len(got.Pose) != 16 || got.Pose[12] != 10 {
+		t.Errorf("expected the window to keep the latest pose, got %v", got.Pose)
+	}
+}
+
+func TestHubDropsForSlowViewers(t *testing.T) {
+	h := NewHub(10)
+	_, leave := h.Join()
+	defer leave()
+	for i := 0; i < clientQueue+5; i++ {
+		h.Add("a", &Batch{Coords: []float32{1, 2, 3}})
+		h.Flush()
+	}
+	if _, _, dropped := h.Stats(); dropped != 5 {
+		t.Errorf("expected 5 dropped frames, got %d", dropped)
+	}
+}
+
+// readServerFrame reads an unmasked frame from the server.
+func readServerFrame(t *testing.T, r io.Reader) (byte, []byte) {
+	t.Helper()
+	var head [2]byte
+	if _, err := io.ReadFull(r, head[:]); err != nil {
+		t.Fatalf("reading frame: %v", err)
+	}
+	n := int(head[1] & 0x7f)
+	if n == 126 {
+		var ext [2]byte
+		io.ReadFull(r, ext[:])
+		n = int(binary.BigEndian.Uint16(ext[:]))
+	}
+	payload := make([]byte, n)
+	if _, err := io.ReadFull(r, payload); err != nil {
+		t.Fatalf("reading frame: %v", err)
+	}
+	return head[0] & 0x0f, payload
+}
+
+func TestWebSocket(t *testing.T) {
+	h := NewHub(100)
+	h.Add("scan", &Batch{Coords: []float32{1, 2, 3}})
+	server := httptest.NewServer(h.Handler())
+	defer server.Close()
+
+	if resp, err := http.Get(server.URL); err != nil || resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("expected 400 for a plain GET, got %v %v", resp, err)
+	}
+
+	conn, err := net.Dial("tcp", strings.TrimPrefix(server.URL, "http://"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(5 * time.Second))
+	io.WriteString(conn, "GET / HTTP/1.1\r\nHost: test\r\nUpgrade: websocket\r\nConnection: keep-alive, Upgrade\r\n"+
+		"Sec-WebSocket-Key: dGhlIHNhbXBsZSBub25jZQ==\r\nSec-WebSocket-Version: 13\r\n\r\n")
+	r := bufio.NewReader(conn)
+	resp, err := http.ReadResponse(r, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The accept value from the example in RFC 6455.
+	if resp.StatusCode != http.StatusSwitchingProtocols || resp.Header.Get("Sec-WebSocket-Accept") != "s3pPLMBiTxaQ9kYGzzhZRbK+xOo=" {
+		t.Fatalf("unexpected handshake response %v", resp)
+	}
+
+	opcode, payload := readServerFrame(t, r)
+	if name, b, err := DecodeFrame(payload); opcode != wsBinary || err != nil || name != "scan" || b.Len() != 1 {
+		t.Errorf("expected the scan window, got opcode %d, %q %v", opcode, name, err)
+	}
+
+	h.Add("scan", &Batch{Coords: []float32{4, 5, 6}})
+	h.Flush()
+	if _, payload := readServerFrame(t, r); len(payload) != len(EncodeFrame("scan", &Batch{Coords: make([]float32, 6)})) {
+		t.Errorf("expected the window of 2 points, got %d bytes", len(payload))
+	}
+
+	// A masked close frame with status 1000 is echoed.
+	mask := []byte{1, 2, 3, 4}
+	conn.Write(append([]byte{0x80 | wsClose, 0x80 | 2}, append(mask, 0x03^1, 0xe8^2)...))
+	if opcode, payload := readServerFrame(t, r); opcode != wsClose || len(payload) != 2 || payload[0] != 0x03 || payload[1] != 0xe8 {
+		t.Errorf("expected the close frame echoed, got opcode %d %v", opcode, payload)
+	}
+}
@@ -0,0 +1,196 @@
+// sensorstream/mqtt.go
+package sensorstream
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"os"
+	"sync"
+	"time"
+)
+
+// MQTT 3.1.1 control packet types, in the high nibble of the first byte.
+const (
+	mqttConnect   = 1
+	mqttConnAck   = 2
+	mqttPublish   = 3
+	mqttPubAck    = 4
+	mqttSubscribe = 8
+	mqttSubAck    = 9
+	mqttPingReq   = 12
+	mqttPingResp  = 13
+)
+
+// mqttKeepAlive is the keep-alive interval announced to MQTT brokers. A
+// ping is sent every half interval, and a broker silent for two intervals
+// is taken to be gone.
+const mqttKeepAlive = 30 * time.Second
+
+// subscribeMQTT is the Protocol for MQTT 3.1.1 brokers, at QoS 0. The URL
+// may carry a username and password.
+func subscribeMQTT(ctx context.Context, u *url.URL, topics []string, handle Handler) error {
+	connCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	conn, err := dial(connCtx, u, "1883")
+	if err != nil {
+		return connError(ctx, fmt.Errorf("mqtt: %v", err))
+	}
+	c := &mqttConn{conn: conn, r: bufio.NewReader(conn)}
+
+	var connect []byte
+	connect = appendMQTTString(connect, "MQTT")
+	flags := byte(0x02) // clean session
+	if u.User != nil {
+		flags |= 0x80
+		if _, ok := u.User.Password(); ok {
+			flags |= 0x40
+		}
+	}
+	connect = append(connect, 4, flags)
+	connect = binary.BigEndian.AppendUint16(connect, uint16(mqttKeepAlive/time.Second))
+	connect = appendMQTTString(connect, fmt.Sprintf("webgl-point-cloud-%d", os.Getpid()))
+	if u.User != nil {
+		connect = appendMQTTString(connect, u.User.Username())
+		if password, ok := u.User.Password(); ok {
+			connect = appendMQTTString(connect, password)
+		}
+	}
+	if err := c.write(mqttConnect<<4, connect); err != nil {
+		return connError(ctx, err)
+	}
+	kind, body, err := c.read()
+	if err != nil {
+		return connError(ctx, err)
+	}
+	if kind>>4 != mqttConnAck || len(body) < 2 || body[1] != 0 {
+		return fmt.Errorf("mqtt: broker refused the connection (%v)", body)
+	}
+
+	subscribe := []byte{0, 1} // packet id
+	for _, t := range topics {
+		subscribe = append(appendMQTTString(subscribe, t), 0)
+	}
+	if err := c.write(mqttSubscribe<<4|0x02, subscribe); err != nil {
+		return connError(ctx, err)
+	}
+
+	go func() {
+		t := time.NewTicker(mqttKeepAlive / 2)
+		defer t.Stop()
+		for {
+			select {
+			case <-connCtx.Done():
+				return
+			case <-t.C:
+				c.write(mqttPingReq<<4, nil)
+			}
+		}
+	}()
+
+	for {
+		kind, body, err := c.read()
+		if err != nil {
+			return connError(ctx, err)
+		}
+		switch kind >> 4 {
+		case mqttSubAck:
+			for i, code := range body[min(2, len(body)):] {
+				if code == 0x80 {
+					return fmt.Errorf("mqtt: broker refused the subscription to %s", topics[min(i, len(topics)-1)])
+				}
+			}
+		case mqttPublish:
+			if len(body) < 2 {
+				return fmt.Errorf("mqtt: short PUBLISH packet")
+			}
+			n := int(binary.BigEndian.Uint16(body))
+			if len(body) < 2+n {
+				return fmt.Errorf("mqtt: short PUBLISH packet")
+			}
+			topic, payload := string(body[2:2+n]), body[2+n:]
+			if qos := kind >> 1 & 3; qos > 0 {
+				if len(payload) < 2 {
+					return fmt.Errorf("mqtt: short PUBLISH packet")
+				}
+				if err := c.write(mqttPubAck<<4, payload[:2]); err != nil {
+					return connError(ctx, err)
+				}
+				payload = payload[2:]
+			}
+			handle(topic, payload)
+		case mqttPingResp:
+			// The answer to the keep-alive pings: the broker is there, and
+			// reading it pushed the read deadline back.
+		}
+	}
+}
+
+// mqttConn reads and writes MQTT packets. Writes may come from several
+// goroutines.
+type mqttConn struct {
+	conn net.Conn
+	r    *bufio.Reader
+	mu   sync.Mutex
+}
+
+// write sends a packet with the given first byte and body.
+func (c *mqttConn) write(first byte, body []byte) error {
+	packet := []byte{first}
+	for n := len(body); ; {
+		b := byte(n % 128)
+		n /= 128
+		if n > 0 {
+			b |= 0x80
+		}
+		packet = append(packet, b)
+		if n == 0 {
+			break
+		}
+	}
+	packet = append(packet, body...)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	_, err := c.conn.Write(packet)
+	if err != nil {
+		return fmt.Errorf("mqtt: %v", err)
+	}
+	return nil
+}
+
+// read returns the next packet's first byte and body.
+func (c *mqttConn) read() (byte, []byte, error) {
+	c.conn.SetReadDeadline(time.Now().Add(2 * mqttKeepAlive))
+	first, err := c.r.ReadByte()
+	if err != nil {
+		return 0, nil, fmt.Errorf("mqtt: %v", err)
+	}
+	n, shift := 0, 0
+	for {
+		b, err := c.r.ReadByte()
+		if err != nil {
+			return 0, nil, fmt.Errorf("mqtt: %v", err)
+		}
+		n |= int(b&0x7f) << shift
+		if b&0x80 == 0 {
+			break
+		}
+		if shift += 7; shift > 21 {
+			return 0, nil, fmt.Errorf("mqtt: malformed packet length")
+		}
+	}
+	body := make([]byte, n)
+	if _, err := io.ReadFull(c.r, body); err != nil {
+		return 0, nil, fmt.Errorf("mqtt: %v", err)
+	}
+	return first, body, nil
+}
+
+// appendMQTTString appends a length-prefixed UTF-8 string.
+func appendMQTTString(b []byte, s string) []byte {
+	return append(binary.BigEndian.AppendUint16(b, uint16(len(s))), s...)
+}
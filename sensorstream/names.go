@@ -0,0 +1,100 @@
+// sensorstream/names.go
+package sensorstream
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Namer names the dataset a topic's points go to. Rules are tried in
+// order; topics matching none keep their own name.
+type Namer struct {
+	rules []nameRule
+}
+
+type nameRule struct {
+	pattern []string
+	name    string
+}
+
+// ParseNamer reads rules of the form "pattern=name" separated by commas,
+// e.g. "site/+/lidar/+=lidar-{2}@{1}". Patterns are topics with MQTT
+// wildcards: + matches one level and a final # any number of levels. NATS
+// subjects separated by dots work the same, with * and > as wildcards. In
+// name, {1}, {2}, ... stand for the levels the +/* wildcards matched, and
+// {#} for those # or > matched.
+func ParseNamer(rules string) (*Namer, error) {
+	n := &Namer{}
+	for _, rule := range strings.Split(rules, ",") {
+		rule = strings.TrimSpace(rule)
+		if rule == "" {
+			continue
+		}
+		pattern, name, ok := strings.Cut(rule, "=")
+		if !ok || pattern == "" || name == "" {
+			return nil, fmt.Errorf("sensorstream: naming rule %q is not pattern=name", rule)
+		}
+		levels, _ := splitTopic(pattern)
+		for i, l := range levels {
+			if (l == "#" || l == ">") && i != len(levels)-1 {
+				return nil, fmt.Errorf("sensorstream: %s must end pattern %q", l, pattern)
+			}
+		}
+		n.rules = append(n.rules, nameRule{levels, name})
+	}
+	return n, nil
+}
+
+// Name returns the dataset for topic.
+func (n *Namer) Name(topic string) string {
+	levels, sep := splitTopic(topic)
+	for _, r := range n.rules {
+		if name, ok := r.apply(levels, sep); ok {
+			return name
+		}
+	}
+	return topic
+}
+
+// apply returns the rule's name for a topic's levels, separated by sep,
+// or false if the pattern does not match.
+func (r nameRule) apply(levels []string, sep string) (string, bool) {
+	var wild []string
+	rest, multi := "", false
+	for i, p := range r.pattern {
+		if p == "#" || p == ">" {
+			if i >= len(levels) {
+				return "", false
+			}
+			rest, multi = strings.Join(levels[i:], sep), true
+			break
+		}
+		if i >= len(levels) {
+			return "", false
+		}
+		if p == "+" || p == "*" {
+			wild = append(wild, levels[i])
+		} else if p != levels[i] {
+			return "", false
+		}
+	}
+	if !multi && len(levels) != len(r.pattern) {
+		return "", false
+	}
+	replacements := []string{"{#}", rest}
+	for i, w := range wild {
+		replacements = append(replacements, "{"+strconv.Itoa(i+1)+"}", w)
+	}
+	return strings.NewReplacer(replacements...).Replace(r.name), true
+}
+
+// splitTopic splits an MQTT topic at slashes or a NATS subject at dots,
+// returning the levels and the separator.
+func splitTopic(topic string) ([]string, string) {
+	sep := "."
+	if strings.Contains(topic, "/") {
+		sep = "/"
+	}
+	return strings.Split(topic, sep), sep
+}
@@ -0,0 +1,110 @@
+// sensorstream/nats.go
+package sensorstream
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// natsTimeout is how long a NATS server may stay silent before it is taken
+// to be gone. Servers ping idle clients every two minutes by default.
+const natsTimeout = 5 * time.Minute
+
+// subscribeNATS is the Protocol for NATS servers. The URL may carry a
+// username and password, or a token as the username alone.
+func subscribeNATS(ctx context.Context, u *url.URL, subjects []string, handle Handler) error {
+	connCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	conn, err := dial(connCtx, u, "4222")
+	if err != nil {
+		return connError(ctx, fmt.Errorf("nats: %v", err))
+	}
+	r := bufio.NewReader(conn)
+	var mu sync.Mutex
+	send := func(format string, args ...interface{}) error {
+		mu.Lock()
+		defer mu.Unlock()
+		if _, err := fmt.Fprintf(conn, format+"\r\n", args...); err != nil {
+			return fmt.Errorf("nats: %v", err)
+		}
+		return nil
+	}
+	readLine := func() (string, error) {
+		conn.SetReadDeadline(time.Now().Add(natsTimeout))
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return "", fmt.Errorf("nats: %v", err)
+		}
+		return strings.TrimRight(line, "\r\n"), nil
+	}
+
+	// The server greets with INFO before anything else.
+	line, err := readLine()
+	if err != nil {
+		return connError(ctx, err)
+	}
+	if !strings.HasPrefix(line, "INFO ") {
+		return fmt.Errorf("nats: expected INFO, got %q", line)
+	}
+	options := map[string]interface{}{"verbose": false, "pedantic": false, "name": "webgl-point-cloud", "lang": "go", "version": "1", "protocol": 1, "headers": true}
+	if u.User != nil {
+		if password, ok := u.User.Password(); ok {
+			options["user"], options["pass"] = u.User.Username(), password
+		} else {
+			options["auth_token"] = u.User.Username()
+		}
+	}
+	connect, _ := json.Marshal(options)
+	if err := send("CONNECT %s", connect); err != nil {
+		return connError(ctx, err)
+	}
+	for i, s := range subjects {
+		if err := send("SUB %s %d", s, i+1); err != nil {
+			return connError(ctx, err)
+		}
+	}
+
+	for {
+		line, err := readLine()
+		if err != nil {
+			return connError(ctx, err)
+		}
+		op, args, _ := strings.Cut(line, " ")
+		switch strings.ToUpper(op) {
+		case "PING":
+			if err := send("PONG"); err != nil {
+				return connError(ctx, err)
+			}
+		case "-ERR":
+			return fmt.Errorf("nats: server error %s", args)
+		case "MSG", "HMSG":
+			// MSG <subject> <sid> [reply-to] <#bytes>
+			// HMSG <subject> <sid> [reply-to] <#header bytes> <#total bytes>
+			fields := strings.Fields(args)
+			if len(fields) < 3 {
+				return fmt.Errorf("nats: malformed %s", line)
+			}
+			headerLen := 0
+			if strings.ToUpper(op) == "HMSG" && len(fields) >= 4 {
+				headerLen, _ = strconv.Atoi(fields[len(fields)-2])
+			}
+			n, err := strconv.Atoi(fields[len(fields)-1])
+			if err != nil || n < 0 || headerLen < 0 || headerLen > n {
+				return fmt.Errorf("nats: malformed %s", line)
+			}
+			payload := make([]byte, n+2) // with the trailing CRLF
+			if _, err := io.ReadFull(r, payload); err != nil {
+				return connError(ctx, fmt.Errorf("nats: %v", err))
+			}
+			handle(fields[0], payload[headerLen:n])
+		}
+	}
+}
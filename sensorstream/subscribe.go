@@ -0,0 +1,95 @@
+// sensorstream/subscribe.go
+package sensorstream
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Handler receives a message published on a topic.
+type Handler func(topic string, payload []byte)
+
+// Protocol subscribes to topics on the broker at u, calling handle with
+// each message, until ctx is done or the connection fails. It returns nil
+// only when ctx is done.
+type Protocol func(ctx context.Context, u *url.URL, topics []string, handle Handler) error
+
+var (
+	protocolsMu sync.Mutex
+	protocols   = map[string]Protocol{
+		"mqtt": subscribeMQTT,
+		"tcp":  subscribeMQTT,
+		"nats": subscribeNATS,
+	}
+)
+
+// Register makes a protocol available for broker URLs with the given
+// scheme, replacing any registered before.
+func Register(scheme string, p Protocol) {
+	protocolsMu.Lock()
+	defer protocolsMu.Unlock()
+	protocols[scheme] = p
+}
+
+// Schemes returns the broker URL schemes with a registered protocol.
+func Schemes() []string {
+	protocolsMu.Lock()
+	defer protocolsMu.Unlock()
+	schemes := make([]string, 0, len(protocols))
+	for s := range protocols {
+		schemes = append(schemes, s)
+	}
+	sort.Strings(schemes)
+	return schemes
+}
+
+// Subscribe subscribes to topics on the broker at brokerURL, e.g.
+// "mqtt://localhost:1883" or "nats://localhost:4222", with the protocol
+// registered for its scheme. See Protocol.
+func Subscribe(ctx context.Context, brokerURL string, topics []string, handle Handler) error {
+	u, err := url.Parse(brokerURL)
+	if err != nil {
+		return fmt.Errorf("sensorstream: %v", err)
+	}
+	protocolsMu.Lock()
+	p, ok := protocols[u.Scheme]
+	protocolsMu.Unlock()
+	if !ok {
+		return fmt.Errorf("sensorstream: no protocol for %q URLs; have %s", u.Scheme, strings.Join(Schemes(), ", "))
+	}
+	return p(ctx, u, topics, handle)
+}
+
+// dial connects to the broker at u, using defaultPort if u has no port,
+// and closes the connection when ctx is done.
+func dial(ctx context.Context, u *url.URL, defaultPort string) (net.Conn, error) {
+	host := u.Host
+	if u.Port() == "" {
+		host = net.JoinHostPort(u.Hostname(), defaultPort)
+	}
+	d := net.Dialer{Timeout: 10 * time.Second}
+	conn, err := d.DialContext(ctx, "tcp", host)
+	if err != nil {
+		return nil, err
+	}
+	go func() {
+		<-ctx.Done()
+		conn.Close()
+	}()
+	return conn, nil
+}
+
+// connError returns nil if ctx is done, since the connection failing was
+// then expected, and err otherwise.
+func connError(ctx context.Context, err error) error {
+	if ctx.Err() != nil {
+		return nil
+	}
+	return err
+}
@@ -0,0 +1,161 @@
+// sensorstream/subscribe_test.go
+// usage: go test
+
+package sensorstream
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+type message struct {
+	topic   string
+	payload string
+}
+
+// listen starts a fake broker on a loopback port, running serve on the
+// first connection, and returns its address.
+func listen(t *testing.T, serve func(conn net.Conn)) string {
+	t.Helper()
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { l.Close() })
+	go func() {
+		conn, err := l.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		conn.SetDeadline(time.Now().Add(5 * time.Second))
+		serve(conn)
+	}()
+	return l.Addr().String()
+}
+
+// collect subscribes to the broker at brokerURL until want messages
+// arrive, then cancels and returns them with Subscribe's result.
+func collect(t *testing.T, brokerURL string, topics []string, want int) ([]message, error) {
+	t.Helper()
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	var got []message
+	err := Subscribe(ctx, brokerURL, topics, func(topic string, payload []byte) {
+		got = append(got, message{topic, string(payload)})
+		if len(got) == want {
+			cancel()
+		}
+	})
+	return got, err
+}
+
+func TestNATS(t *testing.T) {
+	subs := make(chan []string, 1)
+	addr := listen(t, func(conn net.Conn) {
+		r := bufio.NewReader(conn)
+		fmt.Fprintf(conn, "INFO {\"server_id\":\"test\",\"headers\":true}\r\n")
+		var lines []string
+		for len(lines) < 3 {
+			line, err := r.ReadString('\n')
+			if err != nil {
+				return
+			}
+			lines = append(lines, strings.TrimSpace(line))
+		}
+		subs <- lines
+		fmt.Fprintf(conn, "PING\r\n")
+		fmt.Fprintf(conn, "MSG dev.a.points 1 5\r\nhello\r\n")
+		fmt.Fprintf(conn, "HMSG dev.b.points 2 _INBOX.x 12 15\r\nNATS/1.0\r\n\r\nabc\r\n")
+		r.ReadString('\n') // PONG
+		r.ReadString('\n') // until the client hangs up
+	})
+
+	got, err := collect(t, "nats://token@"+addr, []string{"dev.*.points", "other"}, 2)
+	if err != nil {
+		t.Fatalf("Subscribe: %v", err)
+	}
+	lines := <-subs
+	if !strings.HasPrefix(lines[0], "CONNECT {") || !strings.Contains(lines[0], `"auth_token":"token"`) || lines[1] != "SUB dev.*.points 1" || lines[2] != "SUB other 2" {
+		t.Errorf("unexpected handshake %q", lines)
+	}
+	if len(got) != 2 || got[0] != (message{"dev.a.points", "hello"}) || got[1] != (message{"dev.b.points", "abc"}) {
+		t.Errorf("unexpected messages %v", got)
+	}
+}
+
+func TestNATSError(t *testing.T) {
+	addr := listen(t, func(conn net.Conn) {
+		fmt.Fprintf(conn, "INFO {}\r\n-ERR 'Authorization Violation'\r\n")
+		bufio.NewReader(conn).ReadString('\n')
+	})
+	if _, err := collect(t, "nats://"+addr, []string{"x"}, 1); err == nil || !strings.Contains(err.Error(), "Authorization Violation") {
+		t.Errorf("expected the server's error, got %v", err)
+	}
+}
+
+func TestMQTT(t *testing.T) {
+	type handshake struct {
+		connect, subscribe []byte
+		puback             []byte
+	}
+	seen := make(chan handshake, 1)
+	addr := listen(t, func(conn net.Conn) {
+		c := &mqttConn{conn: conn, r: bufio.NewReader(conn)}
+		var h handshake
+		_, h.connect, _ = c.read()
+		c.write(mqttConnAck<<4, []byte{0, 0})
+		_, h.subscribe, _ = c.read()
+		c.write(mqttSubAck<<4, []byte{0, 1, 0})
+		publish := appendMQTTString(nil, "dev/a/points")
+		c.write(mqttPublish<<4, append(publish, "hello"...))
+		publish = appendMQTTString(nil, "dev/b/points")
+		c.write(mqttPublish<<4|0x02, append(append(publish, 0, 7), make([]byte, 200)...))
+		_, h.puback, _ = c.read()
+		seen <- h
+		c.read() // until the client hangs up
+	})
+
+	got, err := collect(t, "mqtt://user:secret@"+addr, []string{"dev/+/points"}, 2)
+	if err != nil {
+		t.Fatalf("Subscribe: %v", err)
+	}
+	h := <-seen
+	if len(h.connect) < 10 || string(h.connect[2:6]) != "MQTT" || h.connect[6] != 4 || h.connect[7] != 0xc2 {
+		t.Errorf("unexpected CONNECT %v", h.connect)
+	}
+	if !strings.HasSuffix(string(h.connect), "\x00\x04user\x00\x06secret") {
+		t.Errorf("expected the credentials at the end of CONNECT, got %q", h.connect)
+	}
+	if string(h.subscribe) != "\x00\x01\x00\x0cdev/+/points\x00" {
+		t.Errorf("unexpected SUBSCRIBE %q", h.subscribe)
+	}
+	if len(h.puback) != 2 || binary.BigEndian.Uint16(h.puback) != 7 {
+		t.Errorf("expected PUBACK for packet 7, got %v", h.puback)
+	}
+	if len(got) != 2 || got[0] != (message{"dev/a/points", "hello"}) || got[1].topic != "dev/b/points" || len(got[1].payload) != 200 {
+		t.Errorf("unexpected messages %v", got)
+	}
+}
+
+func TestSubscribeSchemes(t *testing.T) {
+	if err := Subscribe(context.Background(), "kafka://localhost", nil, nil); err == nil || !strings.Contains(err.Error(), "mqtt, nats") {
+		t.Errorf("expected an error listing the schemes, got %v", err)
+	}
+	called := false
+	Register("test", func(ctx context.Context, u *url.URL, topics []string, handle Handler) error {
+		called = u.Host == "broker" && len(topics) == 1
+		return nil
+	})
+	Subscribe(context.Background(), "test://broker", []string{"t"}, nil)
+	if !called {
+		t.Error("expected the registered protocol to be used")
+	}
+}
@@ -5,14 +5,33 @@ package main
 // run: ./server
 
 import (
+    "container/list"
+    "context"
     "fmt"
+    "html/template"
+    "io"
+    "log"
+    "net"
     "net/http"
+    "os"
+    "regexp"
+    "strconv"
+    "strings"
+    "sync"
+    "time"
+
+    "github.com/sbecker11/webgl-point-cloud/demo"
+    "github.com/sbecker11/webgl-point-cloud/lidar"
+    "github.com/sbecker11/webgl-point-cloud/sensorstream"
 )
 
 func main() {
     // configure the server to serve files from the current directory
     fs := http.FileServer(http.Dir("."))
-    http.Handle("/", fs)
+    http.Handle("/", crossOriginIsolated(fs))
+    http.Handle("/tiles/", crossOriginIsolated(newTileProxy()))
+    http.Handle("/stream", startSensorStream())
+    http.Handle("/demos", crossOriginIsolated(http.HandlerFunc(serveDemos)))
 
     // server configured to listen on port 8080
     fmt.Println("Server running at http://localhost:8080")
@@ -21,3 +40,298 @@ func main() {
         fmt.Println("Server error:", err)
     }
 }
+
+// crossOriginIsolated sets the headers that make the page cross-origin
+// isolated, which browsers require before they expose SharedArrayBuffer.
+func crossOriginIsolated(h http.Handler) http.Handler {
+    return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+        w.Header().Set("Cross-Origin-Opener-Policy", "same-origin")
+        w.Header().Set("Cross-Origin-Embedder-Policy", "require-corp")
+        h.ServeHTTP(w, r)
+    })
+}
+
+// demosPage is the start screen listing the demo scenes.
+var demosPage = template.Must(template.New("demos").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+    <meta charset="UTF-8">
+    <meta name="viewport" content="width=device-width, initial-scale=1.0">
+    <title>WebGL Point Cloud Demos</title>
+    <style>
+        body { font-family: sans-serif; margin: 2em; background: #111; color: #eee; }
+        .demos { display: grid; grid-template-columns: repeat(auto-fill, minmax(240px, 1fr)); gap: 1em; }
+        a.demo { display: block; padding: 1em; border-radius: 8px; background: #222; color: inherit; text-decoration: none; }
+        a.demo:hover { background: #333; }
+        a.demo h2 { margin: 0 0 0.4em; font-size: 1.2em; }
+    </style>
+</head>
+<body>
+    <h1>WebGL Point Cloud Demos</h1>
+    <div class="demos">
+    {{range .}}
+        <a class="demo" href="/wasm/index.html?demo={{.Name}}">
+            <h2>{{.Title}}</h2>
+            <p>{{.Description}}</p>
+        </a>
+    {{end}}
+    </div>
+</body>
+</html>
+`))
+
+// serveDemos serves the start screen, from which each demo scene opens in
+// the viewer.
+func serveDemos(w http.ResponseWriter, r *http.Request) {
+    w.Header().Set("Content-Type", "text/html; charset=utf-8")
+    if err := demosPage.Execute(w, demo.Scenes); err != nil {
+        log.Println("demos:", err)
+    }
+}
+
+// defaultTileURL is the tile server proxied under /tiles/. Set TILE_URL to
+// use another, with {z}, {x} and {y} in place of the tile's coordinates.
+const defaultTileURL = "https://tile.openstreetmap.org/{z}/{x}/{y}.png"
+
+// tilePath matches the /tiles/z/x/y.png paths the viewer requests.
+var tilePath = regexp.MustCompile(`^/tiles/(\d{1,2})/(\d{1,7})/(\d{1,7})\.png$`)
+
+// maxCachedTiles bounds the proxy's in-memory tile cache.
+const maxCachedTiles = 2048
+
+// maxTileBytes bounds the size of a tile the proxy relays; map tiles are
+// tens of kilobytes.
+const maxTileBytes = 4 << 20
+
+// tileProxy serves map tiles from a tile server under the page's own
+// origin, since the cross-origin isolation headers stop the page loading
+// images from servers that don't opt in. Tiles are cached in memory so
+// panning back and forth doesn't refetch them, as tile usage policies ask;
+// once the cache is full the least recently served tile makes way.
+type tileProxy struct {
+    url   string
+    mu    sync.Mutex
+    cache map[string]*list.Element // of *cachedTile, in order
+    order *list.List               // most recently served first
+}
+
+type cachedTile struct {
+    path        string
+    contentType string
+    data        []byte
+}
+
+func newTileProxy() *tileProxy {
+    url := os.Getenv("TILE_URL")
+    if url == "" {
+        url = defaultTileURL
+    }
+    return &tileProxy{url: url, cache: map[string]*list.Element{}, order: list.New()}
+}
+
+// cached returns the tile cached for path, marking it most recently served.
+func (p *tileProxy) cached(path string) (*cachedTile, bool) {
+    p.mu.Lock()
+    defer p.mu.Unlock()
+    e, ok := p.cache[path]
+    if !ok {
+        return nil, false
+    }
+    p.order.MoveToFront(e)
+    return e.Value.(*cachedTile), true
+}
+
+// store caches tile, evicting the least recently served tiles past
+// maxCachedTiles.
+func (p *tileProxy) store(tile *cachedTile) {
+    p.mu.Lock()
+    defer p.mu.Unlock()
+    if e, ok := p.cache[tile.path]; ok {
+        e.Value = tile
+        p.order.MoveToFront(e)
+        return
+    }
+    p.cache[tile.path] = p.order.PushFront(tile)
+    for p.order.Len() > maxCachedTiles {
+        oldest := p.order.Back()
+        p.order.Remove(oldest)
+        delete(p.cache, oldest.Value.(*cachedTile).path)
+    }
+}
+
+func (p *tileProxy) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+    m := tilePath.FindStringSubmatch(r.URL.Path)
+    if m == nil {
+        http.NotFound(w, r)
+        return
+    }
+    tile, ok := p.cached(r.URL.Path)
+    if !ok {
+        url := strings.NewReplacer("{z}", m[1], "{x}", m[2], "{y}", m[3]).Replace(p.url)
+        req, err := http.NewRequestWithContext(r.Context(), http.MethodGet, url, nil)
+        if err != nil {
+            http.Error(w, err.Error(), http.StatusInternalServerError)
+            return
+        }
+        // Tile servers ask clients to identify themselves.
+        req.Header.Set("User-Agent", "webgl-point-cloud tile proxy")
+        resp, err := http.DefaultClient.Do(req)
+        if err != nil {
+            http.Error(w, err.Error(), http.StatusBadGateway)
+            return
+        }
+        defer resp.Body.Close()
+        if resp.StatusCode != http.StatusOK {
+            http.Error(w, "tile server: "+resp.Status, http.StatusBadGateway)
+            return
+        }
+        if resp.ContentLength > maxTileBytes {
+            http.Error(w, "tile server: tile too large", http.StatusBadGateway)
+            return
+        }
+        // Read one byte past the limit to tell a tile of exactly
+        // maxTileBytes from a longer one.
+        data, err := io.ReadAll(io.LimitReader(resp.Body, maxTileBytes+1))
+        if err != nil {
+            http.Error(w, err.Error(), http.StatusBadGateway)
+            return
+        }
+        if len(data) > maxTileBytes {
+            http.Error(w, "tile server: tile too large", http.StatusBadGateway)
+            return
+        }
+        tile = &cachedTile{path: r.URL.Path, contentType: resp.Header.Get("Content-Type"), data: data}
+        p.store(tile)
+    }
+    w.Header().Set("Content-Type", tile.contentType)
+    w.Header().Set("Cache-Control", "public, max-age=86400")
+    w.Write(tile.data)
+}
+
+// startSensorStream relays point batches from a message broker to viewers
+// over a WebSocket at /stream (see package sensorstream and the viewer's
+// connectSensorStream). It is configured by environment variables:
+//
+//    STREAM_URL       broker, e.g. mqtt://localhost:1883 or nats://localhost:4222;
+//                     unset, the relay runs with nothing to relay
+//    STREAM_TOPICS    comma-separated topics or subjects, with wildcards
+//                     (default "#" for MQTT, ">" for NATS)
+//    STREAM_NAMES     dataset naming rules, e.g. "site/+/lidar=lidar-{1}"
+//    STREAM_POINTS    points kept per dataset (default 200000)
+//    STREAM_INTERVAL  milliseconds between updates to viewers (default 100)
+func startSensorStream() http.Handler {
+    hub := sensorstream.NewHub(envInt("STREAM_POINTS", 200000))
+    go hub.Run(context.Background(), time.Duration(envInt("STREAM_INTERVAL", 100))*time.Millisecond)
+    if os.Getenv("LIDAR_PORT") != "" {
+        startLidar(hub)
+    }
+    brokerURL := os.Getenv("STREAM_URL")
+    if brokerURL == "" {
+        return hub.Handler()
+    }
+    namer, err := sensorstream.ParseNamer(os.Getenv("STREAM_NAMES"))
+    if err != nil {
+        log.Fatal(err)
+    }
+    topics := strings.Split(os.Getenv("STREAM_TOPICS"), ",")
+    if topics[0] == "" {
+        topics = []string{"#"}
+        if strings.HasPrefix(brokerURL, "nats:") {
+            topics = []string{">"}
+        }
+    }
+
+    // Log the first bad batch on each topic rather than every one.
+    var bad sync.Map
+    handle := func(topic string, payload []byte) {
+        b, err := sensorstream.DecodeBatch(payload)
+        if err != nil {
+            if _, seen := bad.LoadOrStore(topic, true); !seen {
+                log.Printf("Sensor stream: %s: %v", topic, err)
+            }
+            return
+        }
+        hub.Add(namer.Name(topic), b)
+    }
+    go func() {
+        for {
+            err := sensorstream.Subscribe(context.Background(), brokerURL, topics, handle)
+            log.Printf("Sensor stream: %v; reconnecting in 5s", err)
+            time.Sleep(5 * time.Second)
+        }
+    }()
+    fmt.Printf("Relaying %s from %s at /stream\n", strings.Join(topics, ", "), brokerURL)
+    return hub.Handler()
+}
+
+// startLidar decodes a LiDAR sensor's UDP packets and relays each scan to
+// viewers as one dataset, colored by reflectivity, for live capture
+// without ROS. It is configured by environment variables:
+//
+//    LIDAR_PORT         UDP port the sensor sends to (Velodyne 2368, Ouster 7502)
+//    LIDAR_MODEL        vlp16 (default), velodyne or ouster; see lidar.NewDecoder
+//    LIDAR_CALIBRATION  calibration JSON file: a lidar.Calibration for
+//                       velodyne, the sensor's metadata for ouster
+//    LIDAR_NAME         dataset name (default "lidar")
+func startLidar(hub *sensorstream.Hub) {
+    model := os.Getenv("LIDAR_MODEL")
+    if model == "" {
+        model = "vlp16"
+    }
+    var calibration []byte
+    if path := os.Getenv("LIDAR_CALIBRATION"); path != "" {
+        var err error
+        if calibration, err = os.ReadFile(path); err != nil {
+            log.Fatal(err)
+        }
+    }
+    decoder, err := lidar.NewDecoder(model, calibration)
+    if err != nil {
+        log.Fatal(err)
+    }
+    name := os.Getenv("LIDAR_NAME")
+    if name == "" {
+        name = "lidar"
+    }
+    conn, err := net.ListenPacket("udp", ":"+os.Getenv("LIDAR_PORT"))
+    if err != nil {
+        log.Fatal(err)
+    }
+    go func() {
+        // Ouster packets are the largest, under 33 KB at 128 beams.
+        packet := make([]byte, 65536)
+        var lastErr string
+        for {
+            n, _, err := conn.ReadFrom(packet)
+            if err != nil {
+                log.Printf("LiDAR: %v", err)
+                return
+            }
+            scan, err := decoder.Decode(packet[:n])
+            if err != nil && err.Error() != lastErr {
+                // Log a run of the same error once, as stray packets
+                // would otherwise flood the log.
+                log.Printf("LiDAR: %v", err)
+                lastErr = err.Error()
+            }
+            if scan == nil {
+                continue
+            }
+            colors := make([]uint8, scan.Len()*4)
+            for i, r := range scan.Reflectivity {
+                colors[i*4], colors[i*4+1], colors[i*4+2], colors[i*4+3] = r, r, r, 255
+            }
+            hub.Set(name, &sensorstream.Batch{Coords: scan.Coords, Colors: colors})
+        }
+    }()
+    fmt.Printf("Relaying %s LiDAR scans from UDP port %s as %q at /stream\n", model, os.Getenv("LIDAR_PORT"), name)
+}
+
+// envInt returns the integer in environment variable name, or def if it is
+// unset or not a positive integer.
+func envInt(name string, def int) int {
+    if n, err := strconv.Atoi(os.Getenv(name)); err == nil && n > 0 {
+        return n
+    }
+    return def
+}
@@ -0,0 +1,132 @@
+// shadow/shadow.go
+// Package shadow computes soft contact shadows of point clouds: a top-down
+// map of how densely points crowd the ground beneath them, blurred, for
+// darkening the ground under a cloud so it reads as sitting in space.
+package shadow
+
+import (
+	"math"
+)
+
+// Options controls a shadow map. Zero fields take their defaults.
+type Options struct {
+	// Resolution is the number of cells along the longer side of the
+	// points' footprint. The default is 128.
+	Resolution int
+	// Blur is the standard deviation of the Gaussian blur, in cells. The
+	// default is 3.
+	Blur float64
+	// Falloff is the height above the ground at which points stop casting
+	// shadow, in the points' units; nearer points cast more. The default
+	// is a quarter of the points' height.
+	Falloff float64
+}
+
+// Map is a shadow map over the ground plane y = Ground, from Min to Max in
+// x and z. Values holds Width by Height cells, row by row from Min[1] in z,
+// each from Min[0] in x, with the darkness of the shadow in [0, 1].
+type Map struct {
+	Width, Height int
+	Min, Max      [2]float64
+	Ground        float64
+	Values        []float32
+}
+
+// padding is the margin the map leaves around the points' footprint for the
+// blur to spread into, as a fraction of its longer side.
+const padding = 0.1
+
+// Contact returns the contact shadow of the points in packed xyz coords
+// with y up, on the ground at their lowest point. Each point adds weight
+// to the cell below it, falling linearly from 1 on the ground to 0 at
+// Falloff above it; the weights are blurred and scaled so the darkest cell
+// is 1. Contact returns nil if there are no points.
+func Contact(coords []float32, opts Options) *Map {
+	n := len(coords) / 3
+	if n == 0 {
+		return nil
+	}
+	if opts.Resolution <= 0 {
+		opts.Resolution = 128
+	}
+	if opts.Blur <= 0 {
+		opts.Blur = 3
+	}
+	lo := [3]float64{math.Inf(1), math.Inf(1), math.Inf(1)}
+	hi := [3]float64{math.Inf(-1), math.Inf(-1), math.Inf(-1)}
+	for i := 0; i < n; i++ {
+		for k := 0; k < 3; k++ {
+			v := float64(coords[i*3+k])
+			lo[k], hi[k] = math.Min(lo[k], v), math.Max(hi[k], v)
+		}
+	}
+	falloff := opts.Falloff
+	if falloff <= 0 {
+		falloff = (hi[1] - lo[1]) / 4
+	}
+	side := math.Max(hi[0]-lo[0], hi[2]-lo[2])
+	if side == 0 {
+		side = math.Max(falloff, 1)
+	}
+	cell := side * (1 + 2*padding) / float64(opts.Resolution)
+	m := &Map{Ground: lo[1]}
+	m.Min = [2]float64{lo[0] - side*padding, lo[2] - side*padding}
+	m.Width = int(math.Ceil((hi[0]+side*padding-m.Min[0])/cell)) + 1
+	m.Height = int(math.Ceil((hi[2]+side*padding-m.Min[1])/cell)) + 1
+	m.Max = [2]float64{m.Min[0] + float64(m.Width)*cell, m.Min[1] + float64(m.Height)*cell}
+
+	weights := make([]float64, m.Width*m.Height)
+	for i := 0; i < n; i++ {
+		w := 1.0
+		if falloff > 0 {
+			w = 1 - (float64(coords[i*3+1])-lo[1])/falloff
+		}
+		if w <= 0 {
+			continue
+		}
+		x := int((float64(coords[i*3]) - m.Min[0]) / cell)
+		z := int((float64(coords[i*3+2]) - m.Min[1]) / cell)
+		weights[z*m.Width+x] += w
+	}
+	weights = blur(weights, m.Width, m.Height, opts.Blur)
+	top := 0.0
+	for _, w := range weights {
+		top = math.Max(top, w)
+	}
+	m.Values = make([]float32, len(weights))
+	if top > 0 {
+		for i, w := range weights {
+			m.Values[i] = float32(w / top)
+		}
+	}
+	return m
+}
+
+// blur returns the width by height grid of values convolved with a
+// Gaussian of standard deviation sigma, in two separable passes. Values
+// beyond the edges count as 0.
+func blur(values []float64, width, height int, sigma float64) []float64 {
+	radius := int(math.Ceil(3 * sigma))
+	kernel := make([]float64, 2*radius+1)
+	for i := range kernel {
+		d := float64(i - radius)
+		kernel[i] = math.Exp(-d * d / (2 * sigma * sigma))
+	}
+	pass := func(src []float64, stepAlong, stepAcross, along, across int) []float64 {
+		dst := make([]float64, len(src))
+		for a := 0; a < across; a++ {
+			for b := 0; b < along; b++ {
+				sum := 0.0
+				for k, w := range kernel {
+					if c := b + k - radius; c >= 0 && c < along {
+						sum += w * src[a*stepAcross+c*stepAlong]
+					}
+				}
+				dst[a*stepAcross+b*stepAlong] = sum
+			}
+		}
+		return dst
+	}
+	rows := pass(values, 1, width, width, height)
+	return pass(rows, width, 1, height, width)
+}
@@ -0,0 +1,60 @@
+// shadow/shadow_test.go
+// usage: go test
+
+package shadow
+
+import (
+	"math"
+	"testing"
+)
+
+// at returns the map's value in the cell holding ground position (x, z).
+func at(m *Map, x, z float64) float32 {
+	cell := (m.Max[0] - m.Min[0]) / float64(m.Width)
+	return m.Values[int((z-m.Min[1])/cell)*m.Width+int((x-m.Min[0])/cell)]
+}
+
+func TestContactEmpty(t *testing.T) {
+	if m := Contact(nil, Options{}); m != nil {
+		t.Errorf("expected no map for no points, got %+v", m)
+	}
+}
+
+func TestContactFalloff(t *testing.T) {
+	// A column on the ground at x = 0 and one floating above the falloff
+	// at x = 10, both reaching up to y = 4.
+	var coords []float32
+	for y := 0; y <= 4; y++ {
+		coords = append(coords, 0, float32(y), 0)
+		coords = append(coords, 10, 4, 0)
+	}
+	m := Contact(coords, Options{Resolution: 64, Blur: 1, Falloff: 2})
+	if m.Ground != 0 {
+		t.Errorf("expected the ground at the lowest point, got %g", m.Ground)
+	}
+	if len(m.Values) != m.Width*m.Height {
+		t.Fatalf("expected %d values, got %d", m.Width*m.Height, len(m.Values))
+	}
+	if v := at(m, 0, 0); math.Abs(float64(v)-1) > 1e-6 {
+		t.Errorf("expected the darkest shadow under the grounded column, got %g", v)
+	}
+	if v := at(m, 10, 0); v != 0 {
+		t.Errorf("expected no shadow under the floating column, got %g", v)
+	}
+	if near, far := at(m, 0.5, 0), at(m, 2, 0); !(near > far && far >= 0) {
+		t.Errorf("expected the shadow to fade away from the column, got %g then %g", near, far)
+	}
+	if m.Min[0] >= 0 || m.Max[0] <= 10 || m.Min[1] >= 0 || m.Max[1] <= 0 {
+		t.Errorf("expected the map to cover the footprint with a margin, got %v to %v", m.Min, m.Max)
+	}
+}
+
+func TestContactSinglePoint(t *testing.T) {
+	m := Contact([]float32{1, 2, 3}, Options{})
+	if m == nil || m.Ground != 2 {
+		t.Fatalf("expected a map on the point's ground, got %+v", m)
+	}
+	if v := at(m, 1, 3); math.Abs(float64(v)-1) > 1e-6 {
+		t.Errorf("expected the darkest shadow under the point, got %g", v)
+	}
+}
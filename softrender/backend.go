@@ -0,0 +1,82 @@
+// softrender/backend.go
+package softrender
+
+import (
+	"github.com/sbecker11/webgl-point-cloud/glf32"
+	"github.com/sbecker11/webgl-point-cloud/render"
+)
+
+// Backend carries out render calls with a Renderer, so drawing code written
+// against render.Renderer can be run and checked without a browser. It
+// holds buffers in memory, reads the uMvpMatrix and uPointSize uniforms and
+// the aPosition and aColor attributes, and draws points and lines;
+// triangles are skipped, as the rasterizer has none.
+type Backend struct {
+	*Renderer
+	buffers map[render.Buffer][]float32
+	next    render.Buffer
+	mvp     glf32.Mat4
+}
+
+// NewBackend creates a backend drawing into a new width x height Renderer.
+func NewBackend(width, height int) *Backend {
+	return &Backend{Renderer: New(width, height), buffers: map[render.Buffer][]float32{}, mvp: glf32.Identity()}
+}
+
+// CreateBuffer copies data into a new buffer.
+func (b *Backend) CreateBuffer(data []float32) render.Buffer {
+	b.next++
+	b.buffers[b.next] = append([]float32(nil), data...)
+	return b.next
+}
+
+// UpdateBuffer replaces the data of buf.
+func (b *Backend) UpdateBuffer(buf render.Buffer, data []float32) {
+	b.buffers[buf] = append(b.buffers[buf][:0], data...)
+}
+
+// DeleteBuffer frees buf.
+func (b *Backend) DeleteBuffer(buf render.Buffer) {
+	delete(b.buffers, buf)
+}
+
+// SetUniform sets the matrix points and lines are drawn with or their
+// size. Other uniforms are ignored.
+func (b *Backend) SetUniform(name string, value []float32) {
+	switch {
+	case name == render.UniformMVP && len(value) == 16:
+		b.mvp = append(glf32.Mat4(nil), value...)
+	case name == render.UniformPointSize && len(value) == 1:
+		b.PointSize = value[0]
+	}
+}
+
+// Draw draws count vertices as points or lines, white where no colors are
+// given. Vertices beyond those in the position buffer are not drawn.
+func (b *Backend) Draw(p render.Primitive, attribs []render.VertexAttrib, count int) {
+	var coords, colors []float32
+	for _, a := range attribs {
+		switch {
+		case a.Name == render.AttribPosition && a.Components == 3:
+			coords = b.buffers[a.Buffer]
+		case a.Name == render.AttribColor && a.Components == 4:
+			colors = b.buffers[a.Buffer]
+		}
+	}
+	count = min(count, len(coords)/3)
+	if p == render.Lines {
+		count -= count % 2
+	}
+	if colors == nil || len(colors) < count*4 {
+		colors = make([]float32, count*4)
+		for i := range colors {
+			colors[i] = 1
+		}
+	}
+	switch p {
+	case render.Points:
+		b.DrawPoints(coords[:count*3], colors, b.mvp)
+	case render.Lines:
+		b.DrawLines(coords[:count*3], colors, b.mvp)
+	}
+}
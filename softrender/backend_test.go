@@ -0,0 +1,56 @@
+// softrender/backend_test.go
+// usage: go test
+
+package softrender
+
+import (
+	"image/color"
+	"testing"
+
+	"github.com/sbecker11/webgl-point-cloud/render"
+)
+
+// TestBackendMatchesGoldenScene draws the golden scene through the render
+// interface, as backend-independent drawing code would.
+func TestBackendMatchesGoldenScene(t *testing.T) {
+	b := NewBackend(96, 64)
+	b.Clear(color.RGBA{0, 26, 64, 255})
+	b.Attenuation = 3
+	mvp := sceneMVP(96.0 / 64.0)
+
+	axes := &render.Geometry{Primitive: render.Lines}
+	axes.Set(b, []float32{
+		-1, 0, 0, 1, 0, 0,
+		0, -1, 0, 0, 1, 0,
+		0, 0, -1, 0, 0, 1,
+	}, []float32{
+		1, 0, 0, 1, 1, 0, 0, 1,
+		0, 1, 0, 1, 0, 1, 0, 1,
+		0, 0, 1, 1, 0, 0, 1, 1,
+	})
+	axes.Draw(b, mvp)
+
+	var coords, colors []float32
+	for i := -4; i <= 4; i++ {
+		for j := -4; j <= 4; j++ {
+			coords = append(coords, float32(i)*0.2, 0.5, float32(j)*0.2)
+			colors = append(colors, float32(i+4)/8, float32(j+4)/8, 1, 1)
+		}
+	}
+	points := &render.Geometry{Primitive: render.Points}
+	points.Set(b, coords, colors)
+	b.SetUniform(render.UniformPointSize, []float32{3})
+	points.Draw(b, mvp)
+
+	compareGolden(t, b.Renderer, "scene.png")
+}
+
+func TestBackendSkipsTriangles(t *testing.T) {
+	b := NewBackend(8, 8)
+	tri := &render.Geometry{Primitive: render.Triangles}
+	tri.Set(b, []float32{-1, -1, 0, 1, -1, 0, 0, 1, 0}, make([]float32, 12))
+	tri.Draw(b, b.mvp)
+	if got := b.Image().RGBAAt(4, 4); got != (color.RGBA{0, 0, 0, 255}) {
+		t.Errorf("triangles: expected nothing drawn, got %v at center", got)
+	}
+}
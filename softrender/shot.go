@@ -0,0 +1,151 @@
+// softrender/shot.go
+package softrender
+
+import (
+	"fmt"
+	"image/color"
+	"math"
+	"sort"
+
+	"github.com/sbecker11/webgl-point-cloud/colormap"
+	"github.com/sbecker11/webgl-point-cloud/glf32"
+	"github.com/sbecker11/webgl-point-cloud/pointcloud"
+	"github.com/sbecker11/webgl-point-cloud/render"
+)
+
+// viewAngle is where a named view looks from: the yaw about the up axis
+// from the front, towards the right, and the pitch up from level, in
+// radians, as glf32.Orbit takes them.
+type viewAngle struct{ yaw, pitch float32 }
+
+// views are the named views.
+var views = map[string]viewAngle{
+	"front": {0, 0},
+	"back":  {math.Pi, 0},
+	"right": {math.Pi / 2, 0},
+	"left":  {-math.Pi / 2, 0},
+	"top":   {0, math.Pi / 2},
+	"iso":   {-0.5, 0.3}, // the browser viewer's starting view
+}
+
+// ViewNames returns the names of the views Shoot takes, sorted.
+func ViewNames() []string {
+	names := make([]string, 0, len(views))
+	for name := range views {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// ShotOptions control a screenshot.
+type ShotOptions struct {
+	View          string     // one of views
+	Width, Height int        // image size in pixels
+	PointSize     float32    // point diameter in pixels
+	Background    color.RGBA // color behind the points
+	ZUp           bool       // whether the cloud's z axis is up, as in LAS files, rather than y
+	Color         string     // "rgb", "classification", "height" or "intensity"
+}
+
+// Shoot draws cloud fitted to an image from the named view in opts, as
+// the pcshot and pcconvert commands preview clouds.
+func Shoot(cloud *pointcloud.Cloud, opts ShotOptions) (*Backend, error) {
+	angle, ok := views[opts.View]
+	if !ok {
+		return nil, fmt.Errorf("unknown view %q", opts.View)
+	}
+	if opts.Width <= 0 || opts.Height <= 0 {
+		return nil, fmt.Errorf("size %dx%d is not positive", opts.Width, opts.Height)
+	}
+	colors, err := pointColors(cloud, opts)
+	if err != nil {
+		return nil, err
+	}
+	model := cloud.FitTransform(2)
+	if opts.ZUp {
+		model = glf32.MultiplyMatrices(glf32.RotateX(-math.Pi/2), model)
+	}
+	aspect := float32(opts.Width) / float32(opts.Height)
+	const fov = 0.8
+	// Far enough back that the fitted cloud's bounding sphere fills the
+	// narrower side.
+	distance := float32(boundingRadius(cloud)/math.Sin(fov/2)) * max(1, 1/aspect)
+	view := glf32.Orbit(glf32.Vec3{0, 0, 0}, angle.yaw, angle.pitch, distance)
+	proj := glf32.Perspective(fov, aspect, distance/100, distance*2)
+	mvp := glf32.MultiplyMatrices(glf32.MultiplyMatrices(proj, view), model)
+
+	b := NewBackend(opts.Width, opts.Height)
+	b.Clear(opts.Background)
+	points := &render.Geometry{Primitive: render.Points}
+	points.Set(b, cloud.Coords, colors)
+	b.SetUniform(render.UniformPointSize, []float32{opts.PointSize})
+	points.Draw(b, mvp)
+	return b, nil
+}
+
+// boundingRadius returns the radius of the sphere around cloud's bounding
+// box once it is fitted to a size of 2.
+func boundingRadius(cloud *pointcloud.Cloud) float64 {
+	lo, hi := cloud.Bounds()
+	var sum, extent float64
+	for k := 0; k < 3; k++ {
+		d := float64(hi[k] - lo[k])
+		sum += d * d
+		extent = math.Max(extent, d)
+	}
+	if extent == 0 {
+		return 1
+	}
+	return math.Sqrt(sum) / extent
+}
+
+// pointColors returns the colors of cloud's points in the color mode of
+// opts.
+func pointColors(cloud *pointcloud.Cloud, opts ShotOptions) ([]float32, error) {
+	switch opts.Color {
+	case "", "rgb":
+		return cloud.Colors, nil
+	case "classification":
+		if cloud.Classes == nil {
+			return nil, fmt.Errorf("%s has no classes", cloud.Name)
+		}
+		colors := make([]float32, 0, cloud.Len()*4)
+		for _, c := range cloud.Classes {
+			rgba := pointcloud.ClassColor(c)
+			colors = append(colors, rgba[:]...)
+		}
+		return colors, nil
+	case "height":
+		up := 1
+		if opts.ZUp {
+			up = 2
+		}
+		lo, hi := cloud.Bounds()
+		values := make([]float32, cloud.Len())
+		for i := range values {
+			values[i] = cloud.Coords[i*3+up]
+		}
+		return ramp(colormap.Terrain, values, lo[up], hi[up]), nil
+	case "intensity":
+		_, values, ok := cloud.Attribute("intensity")
+		if !ok {
+			return nil, fmt.Errorf("%s has no intensity", cloud.Name)
+		}
+		return ramp(colormap.Grayscale, values, 0, 1), nil
+	}
+	return nil, fmt.Errorf("unknown color mode %q", opts.Color)
+}
+
+// ramp colors values by where they lie in [lo, hi] on m.
+func ramp(m colormap.Map, values []float32, lo, hi float32) []float32 {
+	colors := make([]float32, 0, len(values)*4)
+	for _, v := range values {
+		t := float32(0)
+		if hi > lo {
+			t = (v - lo) / (hi - lo)
+		}
+		colors = m.AppendRGBA(colors, t)
+	}
+	return colors
+}
@@ -0,0 +1,49 @@
+// softrender/shot_test.go
+// usage: go test
+
+package softrender
+
+import (
+	"image/color"
+	"testing"
+
+	"github.com/sbecker11/webgl-point-cloud/pointcloud"
+)
+
+func TestShootCentersCloud(t *testing.T) {
+	// A red point at each corner of a box far from the origin, and one
+	// green one in the middle.
+	var colors []float32
+	for i := 0; i < 8; i++ {
+		colors = append(colors, 1, 0, 0, 1)
+	}
+	cloud := pointcloud.New("box", []float32{
+		100, 100, 100, 110, 100, 100, 100, 110, 100, 110, 110, 100,
+		100, 100, 110, 110, 100, 110, 100, 110, 110, 110, 110, 110,
+		105, 105, 105,
+	}, append(colors, 0, 1, 0, 1))
+	bg := color.RGBA{0, 0, 0, 255}
+	for _, view := range ViewNames() {
+		shot, err := Shoot(cloud, ShotOptions{View: view, Width: 64, Height: 48, PointSize: 3, Background: bg})
+		if err != nil {
+			t.Fatalf("%s: Shoot failed: %v", view, err)
+		}
+		if got := shot.Image().RGBAAt(32, 24); got.G != 255 {
+			t.Errorf("%s: expected the middle point at the center, got %v", view, got)
+		}
+	}
+}
+
+func TestShootErrors(t *testing.T) {
+	cloud := pointcloud.New("p", []float32{0, 0, 0}, []float32{1, 1, 1, 1})
+	for name, opts := range map[string]ShotOptions{
+		"unknown view":   {View: "under", Width: 8, Height: 8},
+		"empty size":     {View: "top"},
+		"no classes":     {View: "top", Width: 8, Height: 8, Color: "classification"},
+		"unknown colors": {View: "top", Width: 8, Height: 8, Color: "plaid"},
+	} {
+		if _, err := Shoot(cloud, opts); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
@@ -0,0 +1,280 @@
+// softrender/softrender.go
+package softrender
+
+import (
+	"image"
+	"image/color"
+	"image/png"
+	"io"
+	"math"
+	"os"
+
+	"github.com/sbecker11/webgl-point-cloud/glf32"
+)
+
+// Renderer is a CPU rasterizer that mirrors the WebGL point and line pipeline
+// used by the wasm viewer: clip-space culling, perspective divide, viewport
+// mapping, a LESS depth test and SRC_ALPHA/ONE_MINUS_SRC_ALPHA blending.
+// It has no browser dependencies, so renderer math can be exercised in
+// ordinary Go tests and command line tools.
+type Renderer struct {
+	width, height int
+	color         *image.RGBA
+	depth         []float32
+
+	// PointSize is the diameter of a point in pixels, like gl_PointSize.
+	PointSize float32
+
+	// Attenuation enables distance-based point sizes when greater than zero.
+	// The rendered size is PointSize * Attenuation / w, where w is the clip
+	// space w of the point (its distance along the view axis).
+	Attenuation float32
+
+	// MinPointSize and MaxPointSize clamp the final point diameter.
+	MinPointSize float32
+	MaxPointSize float32
+}
+
+// New creates a renderer with a width x height color and depth buffer,
+// cleared to opaque black.
+// Panics if width or height is not positive.
+func New(width, height int) *Renderer {
+	if width <= 0 || height <= 0 {
+		panic("softrender.New: width and height must be positive")
+	}
+	r := &Renderer{
+		width:        width,
+		height:       height,
+		color:        image.NewRGBA(image.Rect(0, 0, width, height)),
+		depth:        make([]float32, width*height),
+		PointSize:    1,
+		MinPointSize: 1,
+		MaxPointSize: 64,
+	}
+	r.Clear(color.RGBA{0, 0, 0, 255})
+	return r
+}
+
+// Width returns the width of the render target in pixels.
+func (r *Renderer) Width() int { return r.width }
+
+// Height returns the height of the render target in pixels.
+func (r *Renderer) Height() int { return r.height }
+
+// Clear fills the color buffer with bg and resets the depth buffer to the far plane.
+func (r *Renderer) Clear(bg color.RGBA) {
+	for i := 0; i < len(r.color.Pix); i += 4 {
+		r.color.Pix[i] = bg.R
+		r.color.Pix[i+1] = bg.G
+		r.color.Pix[i+2] = bg.B
+		r.color.Pix[i+3] = bg.A
+	}
+	for i := range r.depth {
+		r.depth[i] = 1
+	}
+}
+
+// Image returns the color buffer. The image is shared with the renderer and
+// is updated by subsequent draw calls.
+func (r *Renderer) Image() *image.RGBA {
+	return r.color
+}
+
+// Depth returns the window-space depth ([0, 1]) stored at pixel (x, y).
+func (r *Renderer) Depth(x, y int) float32 {
+	return r.depth[y*r.width+x]
+}
+
+// clipVertex is a vertex after the MVP transform, before the perspective divide.
+type clipVertex struct {
+	x, y, z, w float32
+}
+
+func transform(m glf32.Mat4, x, y, z float32) clipVertex {
+	return clipVertex{
+		x: m[0]*x + m[4]*y + m[8]*z + m[12],
+		y: m[1]*x + m[5]*y + m[9]*z + m[13],
+		z: m[2]*x + m[6]*y + m[10]*z + m[14],
+		w: m[3]*x + m[7]*y + m[11]*z + m[15],
+	}
+}
+
+// inside reports whether the vertex lies inside the canonical view volume.
+// Points are clipped by their center, as in WebGL.
+func (v clipVertex) inside() bool {
+	return v.w > 0 &&
+		v.x >= -v.w && v.x <= v.w &&
+		v.y >= -v.w && v.y <= v.w &&
+		v.z >= -v.w && v.z <= v.w
+}
+
+// window maps a clip-space vertex to window coordinates. The origin is the
+// top-left corner of the image, so y is flipped relative to WebGL.
+func (r *Renderer) window(v clipVertex) (sx, sy, depth float32) {
+	nx, ny, nz := v.x/v.w, v.y/v.w, v.z/v.w
+	sx = (nx + 1) * 0.5 * float32(r.width)
+	sy = (1 - ny) * 0.5 * float32(r.height)
+	depth = (nz + 1) * 0.5
+	return sx, sy, depth
+}
+
+// pointSize returns the rasterized diameter of a point with clip-space w.
+func (r *Renderer) pointSize(w float32) float32 {
+	size := r.PointSize
+	if r.Attenuation > 0 {
+		size = size * r.Attenuation / w
+	}
+	if size < r.MinPointSize {
+		size = r.MinPointSize
+	}
+	if r.MaxPointSize > 0 && size > r.MaxPointSize {
+		size = r.MaxPointSize
+	}
+	return size
+}
+
+// DrawPoints rasterizes packed xyz coordinates as square points, like
+// gl.drawArrays(gl.POINTS, ...). Colors are packed RGBA values in [0, 1],
+// one per vertex. Points outside the view volume are culled.
+//
+// Returns the number of points that survived culling.
+// Panics if mvp is not a Mat4, if len(coords) is not a multiple of 3, or if
+// there are fewer colors than points.
+func (r *Renderer) DrawPoints(coords, colors []float32, mvp glf32.Mat4) int {
+	if len(mvp) != 16 {
+		panic("DrawPoints: mvp must be Mat4 (length 16)")
+	}
+	if len(coords)%3 != 0 {
+		panic("DrawPoints: coords slice length must be a multiple of 3")
+	}
+	n := len(coords) / 3
+	if len(colors) < n*4 {
+		panic("DrawPoints: colors slice must have 4 components per point")
+	}
+
+	drawn := 0
+	for i := 0; i < n; i++ {
+		v := transform(mvp, coords[i*3], coords[i*3+1], coords[i*3+2])
+		if !v.inside() {
+			continue
+		}
+		drawn++
+		sx, sy, depth := r.window(v)
+		size := r.pointSize(v.w)
+
+		// Cover the pixels whose centers fall inside the point square.
+		x0 := int(math.Floor(float64(sx - size/2 + 0.5)))
+		y0 := int(math.Floor(float64(sy - size/2 + 0.5)))
+		x1 := x0 + int(math.Max(1, math.Round(float64(size))))
+		y1 := y0 + int(math.Max(1, math.Round(float64(size))))
+		c := colors[i*4 : i*4+4]
+		for y := y0; y < y1; y++ {
+			for x := x0; x < x1; x++ {
+				r.fragment(x, y, depth, c)
+			}
+		}
+	}
+	return drawn
+}
+
+// DrawLines rasterizes packed xyz coordinates as independent line segments,
+// like gl.drawArrays(gl.LINES, ...). Segments with an endpoint behind the
+// camera are culled; the rest are clipped to the image bounds.
+//
+// Returns the number of segments drawn.
+// Panics if mvp is not a Mat4, if len(coords) is not a multiple of 6, or if
+// there are fewer colors than vertices.
+func (r *Renderer) DrawLines(coords, colors []float32, mvp glf32.Mat4) int {
+	if len(mvp) != 16 {
+		panic("DrawLines: mvp must be Mat4 (length 16)")
+	}
+	if len(coords)%6 != 0 {
+		panic("DrawLines: coords slice length must be a multiple of 6")
+	}
+	n := len(coords) / 3
+	if len(colors) < n*4 {
+		panic("DrawLines: colors slice must have 4 components per vertex")
+	}
+
+	drawn := 0
+	for i := 0; i < n; i += 2 {
+		a := transform(mvp, coords[i*3], coords[i*3+1], coords[i*3+2])
+		b := transform(mvp, coords[i*3+3], coords[i*3+4], coords[i*3+5])
+		if a.w <= 0 || b.w <= 0 {
+			continue
+		}
+		drawn++
+		ax, ay, az := r.window(a)
+		bx, by, bz := r.window(b)
+		ca, cb := colors[i*4:i*4+4], colors[i*4+4:i*4+8]
+
+		steps := int(math.Ceil(math.Max(math.Abs(float64(bx-ax)), math.Abs(float64(by-ay)))))
+		if steps == 0 {
+			steps = 1
+		}
+		for s := 0; s <= steps; s++ {
+			t := float32(s) / float32(steps)
+			x := int(math.Floor(float64(ax + (bx-ax)*t)))
+			y := int(math.Floor(float64(ay + (by-ay)*t)))
+			c := []float32{
+				ca[0] + (cb[0]-ca[0])*t,
+				ca[1] + (cb[1]-ca[1])*t,
+				ca[2] + (cb[2]-ca[2])*t,
+				ca[3] + (cb[3]-ca[3])*t,
+			}
+			r.fragment(x, y, az+(bz-az)*t, c)
+		}
+	}
+	return drawn
+}
+
+// fragment depth-tests and blends one RGBA fragment into pixel (x, y).
+// Fragments outside the image or the [0, 1] depth range are discarded.
+func (r *Renderer) fragment(x, y int, depth float32, c []float32) {
+	if x < 0 || y < 0 || x >= r.width || y >= r.height || depth < 0 || depth > 1 {
+		return
+	}
+	di := y*r.width + x
+	if depth >= r.depth[di] {
+		return
+	}
+	r.depth[di] = depth
+
+	alpha := clamp01(c[3])
+	pi := r.color.PixOffset(x, y)
+	for k := 0; k < 3; k++ {
+		dst := float32(r.color.Pix[pi+k]) / 255
+		src := clamp01(c[k])
+		r.color.Pix[pi+k] = uint8(math.Round(float64((src*alpha + dst*(1-alpha)) * 255)))
+	}
+	dstA := float32(r.color.Pix[pi+3]) / 255
+	r.color.Pix[pi+3] = uint8(math.Round(float64((alpha + dstA*(1-alpha)) * 255)))
+}
+
+func clamp01(v float32) float32 {
+	if v < 0 {
+		return 0
+	}
+	if v > 1 {
+		return 1
+	}
+	return v
+}
+
+// EncodePNG writes the current color buffer to w as a PNG image.
+func (r *Renderer) EncodePNG(w io.Writer) error {
+	return png.Encode(w, r.color)
+}
+
+// SavePNG writes the current color buffer to the named file as a PNG image.
+func (r *Renderer) SavePNG(path string) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := r.EncodePNG(f); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
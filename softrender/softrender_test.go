@@ -0,0 +1,158 @@
+// softrender/softrender_test.go
+// usage: go test
+// To regenerate the golden images: go test -update
+
+package softrender
+
+import (
+	"bytes"
+	"flag"
+	"image/color"
+	"image/png"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/sbecker11/webgl-point-cloud/glf32"
+)
+
+var update = flag.Bool("update", false, "rewrite golden images in testdata")
+
+// sceneMVP returns the projection * view matrix used by the golden tests.
+func sceneMVP(aspect float32) glf32.Mat4 {
+	proj := glf32.Perspective(0.8, aspect, 0.1, 100)
+	view := glf32.LookAt(glf32.Vec3{2, 1.5, 3}, glf32.Vec3{0, 0, 0}, glf32.Vec3{0, 1, 0})
+	return glf32.MultiplyMatrices(proj, view)
+}
+
+func TestCullsPointsOutsideFrustum(t *testing.T) {
+	r := New(32, 32)
+	mvp := glf32.MultiplyMatrices(
+		glf32.Perspective(0.8, 1, 0.1, 100),
+		glf32.LookAt(glf32.Vec3{0, 0, 5}, glf32.Vec3{0, 0, 0}, glf32.Vec3{0, 1, 0}),
+	)
+	coords := []float32{
+		0, 0, 0, // visible
+		0, 0, 10, // behind the camera
+		50, 0, 0, // far off to the side
+		0, 0, -200, // beyond the far plane
+	}
+	colors := make([]float32, 16)
+	if drawn := r.DrawPoints(coords, colors, mvp); drawn != 1 {
+		t.Errorf("DrawPoints: expected 1 point to survive culling, got %d", drawn)
+	}
+}
+
+func TestPointSizeAttenuation(t *testing.T) {
+	r := New(8, 8)
+	r.PointSize = 4
+	if got := r.pointSize(10); got != 4 {
+		t.Errorf("pointSize without attenuation: expected 4, got %f", got)
+	}
+	r.Attenuation = 5
+	if got := r.pointSize(10); got != 2 {
+		t.Errorf("pointSize at w=10: expected 2, got %f", got)
+	}
+	if got := r.pointSize(100); got != r.MinPointSize {
+		t.Errorf("pointSize far away: expected min size %f, got %f", r.MinPointSize, got)
+	}
+	if got := r.pointSize(0.01); got != r.MaxPointSize {
+		t.Errorf("pointSize up close: expected max size %f, got %f", r.MaxPointSize, got)
+	}
+}
+
+func TestDepthTest(t *testing.T) {
+	r := New(16, 16)
+	r.PointSize = 3
+	mvp := glf32.MultiplyMatrices(
+		glf32.Perspective(0.8, 1, 0.1, 100),
+		glf32.LookAt(glf32.Vec3{0, 0, 5}, glf32.Vec3{0, 0, 0}, glf32.Vec3{0, 1, 0}),
+	)
+	// The near red point is drawn first; the far green point must not overwrite it.
+	coords := []float32{0, 0, 1, 0, 0, -1}
+	colors := []float32{1, 0, 0, 1, 0, 1, 0, 1}
+	r.DrawPoints(coords, colors, mvp)
+
+	got := r.Image().RGBAAt(8, 8)
+	want := color.RGBA{255, 0, 0, 255}
+	if got != want {
+		t.Errorf("depth test: expected %v at center, got %v", want, got)
+	}
+}
+
+func TestGoldenScene(t *testing.T) {
+	r := New(96, 64)
+	r.Clear(color.RGBA{0, 26, 64, 255})
+	r.PointSize = 3
+	r.Attenuation = 3
+	mvp := sceneMVP(96.0 / 64.0)
+
+	axes := []float32{
+		-1, 0, 0, 1, 0, 0,
+		0, -1, 0, 0, 1, 0,
+		0, 0, -1, 0, 0, 1,
+	}
+	axisColors := []float32{
+		1, 0, 0, 1, 1, 0, 0, 1,
+		0, 1, 0, 1, 0, 1, 0, 1,
+		0, 0, 1, 1, 0, 0, 1, 1,
+	}
+	r.DrawLines(axes, axisColors, mvp)
+
+	var coords, colors []float32
+	for i := -4; i <= 4; i++ {
+		for j := -4; j <= 4; j++ {
+			coords = append(coords, float32(i)*0.2, 0.5, float32(j)*0.2)
+			colors = append(colors, float32(i+4)/8, float32(j+4)/8, 1, 1)
+		}
+	}
+	r.DrawPoints(coords, colors, mvp)
+
+	compareGolden(t, r, "scene.png")
+}
+
+// compareGolden compares the renderer's image with testdata/name, or rewrites
+// the file when the -update flag is set.
+func compareGolden(t *testing.T, r *Renderer, name string) {
+	t.Helper()
+	path := filepath.Join("testdata", name)
+	var buf bytes.Buffer
+	if err := r.EncodePNG(&buf); err != nil {
+		t.Fatalf("EncodePNG failed: %v", err)
+	}
+	if *update {
+		if err := os.MkdirAll("testdata", 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, buf.Bytes(), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatalf("missing golden image (run go test -update): %v", err)
+	}
+	defer f.Close()
+	golden, err := png.Decode(f)
+	if err != nil {
+		t.Fatalf("decoding golden image failed: %v", err)
+	}
+
+	got := r.Image()
+	if !golden.Bounds().Eq(got.Bounds()) {
+		t.Fatalf("golden image size mismatch: expected %v, got %v", golden.Bounds(), got.Bounds())
+	}
+	mismatches := 0
+	for y := 0; y < got.Bounds().Dy(); y++ {
+		for x := 0; x < got.Bounds().Dx(); x++ {
+			if color.RGBAModel.Convert(golden.At(x, y)) != got.RGBAAt(x, y) {
+				mismatches++
+			}
+		}
+	}
+	if mismatches > 0 {
+		t.Errorf("%s: %d pixels differ from the golden image", name, mismatches)
+	}
+}
@@ -0,0 +1,199 @@
+// spatial/grid.go
+// Package spatial indexes points for neighbour queries.
+package spatial
+
+import (
+	"math"
+	"sort"
+)
+
+// Grid is a uniform grid over packed xyz coordinates, answering nearest
+// neighbour and radius queries by visiting the cells around the query
+// point. It suits the evenly sampled surfaces of scans; a cell size near
+// the point spacing keeps queries fast.
+type Grid struct {
+	coords []float32
+	cell   float64
+	cells  map[[3]int32][2]int32 // start and end of each cell's run in order
+	order  []int32               // point indices sorted by cell
+	lo, hi [3]int32              // range of occupied cells
+}
+
+// NewGrid indexes packed xyz coordinates with cubic cells of the given
+// size, or AutoCellSize(coords) when size is not positive. The grid keeps
+// coords and must be rebuilt if they change.
+func NewGrid(coords []float32, size float64) *Grid {
+	if size <= 0 {
+		size = AutoCellSize(coords)
+	}
+	g := &Grid{coords: coords, cell: size, cells: map[[3]int32][2]int32{}}
+	n := len(coords) / 3
+	keys := make([][3]int32, n)
+	counts := map[[3]int32]int32{}
+	for i := 0; i < n; i++ {
+		keys[i] = g.key(float64(coords[i*3]), float64(coords[i*3+1]), float64(coords[i*3+2]))
+		counts[keys[i]]++
+		for a := 0; a < 3; a++ {
+			if i == 0 || keys[i][a] < g.lo[a] {
+				g.lo[a] = keys[i][a]
+			}
+			if i == 0 || keys[i][a] > g.hi[a] {
+				g.hi[a] = keys[i][a]
+			}
+		}
+	}
+	start := int32(0)
+	for k, c := range counts {
+		g.cells[k] = [2]int32{start, start}
+		start += c
+	}
+	g.order = make([]int32, n)
+	for i, k := range keys {
+		run := g.cells[k]
+		g.order[run[1]] = int32(i)
+		run[1]++
+		g.cells[k] = run
+	}
+	return g
+}
+
+// AutoCellSize returns a cell size of about twice the spacing of points
+// spread evenly over the two largest sides of their bounding box, as
+// points sampled from a surface are.
+func AutoCellSize(coords []float32) float64 {
+	n := len(coords) / 3
+	if n == 0 {
+		return 1
+	}
+	var lo, hi [3]float64
+	for i := 0; i < n; i++ {
+		for a := 0; a < 3; a++ {
+			v := float64(coords[i*3+a])
+			if i == 0 || v < lo[a] {
+				lo[a] = v
+			}
+			if i == 0 || v > hi[a] {
+				hi[a] = v
+			}
+		}
+	}
+	e := []float64{hi[0] - lo[0], hi[1] - lo[1], hi[2] - lo[2]}
+	sort.Float64s(e)
+	switch {
+	case e[1] > 0:
+		return 2 * math.Sqrt(e[1]*e[2]/float64(n))
+	case e[2] > 0:
+		return 2 * e[2] / float64(n) // points on a line
+	}
+	return 1
+}
+
+// CellSize returns the grid's cell size.
+func (g *Grid) CellSize() float64 {
+	return g.cell
+}
+
+// Len returns the number of indexed points.
+func (g *Grid) Len() int {
+	return len(g.order)
+}
+
+func (g *Grid) key(x, y, z float64) [3]int32 {
+	return [3]int32{int32(math.Floor(x / g.cell)), int32(math.Floor(y / g.cell)), int32(math.Floor(z / g.cell))}
+}
+
+func (g *Grid) dist2(i int32, p [3]float64) float64 {
+	dx := float64(g.coords[i*3]) - p[0]
+	dy := float64(g.coords[i*3+1]) - p[1]
+	dz := float64(g.coords[i*3+2]) - p[2]
+	return dx*dx + dy*dy + dz*dz
+}
+
+// visitRing calls fn with the points of every occupied cell whose indices
+// differ from c by at most r in each axis and exactly r in at least one:
+// the shell of cells at ring r around c.
+func (g *Grid) visitRing(c [3]int32, r int32, fn func(i int32)) {
+	for dz := -r; dz <= r; dz++ {
+		z := c[2] + dz
+		if z < g.lo[2] || z > g.hi[2] {
+			continue
+		}
+		for dy := -r; dy <= r; dy++ {
+			y := c[1] + dy
+			if y < g.lo[1] || y > g.hi[1] {
+				continue
+			}
+			onShell := dz == -r || dz == r || dy == -r || dy == r
+			step := int32(1)
+			if !onShell && r > 0 {
+				step = 2 * r // only the two end cells of the row
+			}
+			for dx := -r; dx <= r; dx += step {
+				run, ok := g.cells[[3]int32{c[0] + dx, y, z}]
+				if !ok {
+					continue
+				}
+				for _, i := range g.order[run[0]:run[1]] {
+					fn(i)
+				}
+			}
+		}
+	}
+}
+
+// Nearest returns the index of the indexed point nearest to p other than
+// exclude (pass -1 to exclude none) and its distance, or -1 when there is
+// no such point.
+func (g *Grid) Nearest(p [3]float64, exclude int) (int, float64) {
+	if len(g.order) == 0 {
+		return -1, 0
+	}
+	c := g.key(p[0], p[1], p[2])
+	// No ring can hold points beyond this one.
+	var maxRing int32
+	for a := 0; a < 3; a++ {
+		maxRing = max(maxRing, c[a]-g.lo[a], g.hi[a]-c[a])
+	}
+	best, bestD2 := int32(-1), math.Inf(1)
+	for r := int32(0); r <= maxRing; r++ {
+		g.visitRing(c, r, func(i int32) {
+			if int(i) == exclude {
+				return
+			}
+			if d2 := g.dist2(i, p); d2 < bestD2 {
+				best, bestD2 = i, d2
+			}
+		})
+		// Points in rings beyond r are more than r cells away.
+		if reach := float64(r) * g.cell; best >= 0 && bestD2 <= reach*reach {
+			break
+		}
+	}
+	if best < 0 {
+		return -1, 0
+	}
+	return int(best), math.Sqrt(bestD2)
+}
+
+// Within calls fn with the index and squared distance of every indexed
+// point within radius of p.
+func (g *Grid) Within(p [3]float64, radius float64, fn func(i int, d2 float64)) {
+	lo := g.key(p[0]-radius, p[1]-radius, p[2]-radius)
+	hi := g.key(p[0]+radius, p[1]+radius, p[2]+radius)
+	r2 := radius * radius
+	for z := max(lo[2], g.lo[2]); z <= min(hi[2], g.hi[2]); z++ {
+		for y := max(lo[1], g.lo[1]); y <= min(hi[1], g.hi[1]); y++ {
+			for x := max(lo[0], g.lo[0]); x <= min(hi[0], g.hi[0]); x++ {
+				run, ok := g.cells[[3]int32{x, y, z}]
+				if !ok {
+					continue
+				}
+				for _, i := range g.order[run[0]:run[1]] {
+					if d2 := g.dist2(i, p); d2 <= r2 {
+						fn(int(i), d2)
+					}
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,68 @@
+// spatial/grid_test.go
+// usage: go test
+
+package spatial
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+)
+
+func randomCoords(n int, seed int64) []float32 {
+	r := rand.New(rand.NewSource(seed))
+	coords := make([]float32, n*3)
+	for i := range coords {
+		coords[i] = float32(r.Float64() * 10)
+	}
+	return coords
+}
+
+func dist(coords []float32, i int, p [3]float64) float64 {
+	dx := float64(coords[i*3]) - p[0]
+	dy := float64(coords[i*3+1]) - p[1]
+	dz := float64(coords[i*3+2]) - p[2]
+	return math.Sqrt(dx*dx + dy*dy + dz*dz)
+}
+
+func TestNearestMatchesBruteForce(t *testing.T) {
+	coords := randomCoords(2000, 1)
+	g := NewGrid(coords, 0)
+	queries := randomCoords(200, 2)
+	queries = append(queries, -3, 5, 5, 20, 20, 20) // outside the points
+	for q := 0; q < len(queries)/3; q++ {
+		p := [3]float64{float64(queries[q*3]), float64(queries[q*3+1]), float64(queries[q*3+2])}
+		want := math.Inf(1)
+		for i := 0; i < len(coords)/3; i++ {
+			want = math.Min(want, dist(coords, i, p))
+		}
+		i, d := g.Nearest(p, -1)
+		if i < 0 || math.Abs(d-want) > 1e-9 || math.Abs(dist(coords, i, p)-d) > 1e-9 {
+			t.Fatalf("query %v: expected distance %f, got point %d at %f", p, want, i, d)
+		}
+	}
+}
+
+func TestNearestExclude(t *testing.T) {
+	coords := []float32{0, 0, 0, 1, 0, 0, 5, 0, 0}
+	g := NewGrid(coords, 0.5)
+	if i, d := g.Nearest([3]float64{0, 0, 0}, 0); i != 1 || d != 1 {
+		t.Errorf("expected point 1 at 1, got %d at %f", i, d)
+	}
+	if i, _ := NewGrid(nil, 1).Nearest([3]float64{}, -1); i != -1 {
+		t.Errorf("expected no point in an empty grid, got %d", i)
+	}
+}
+
+func TestWithin(t *testing.T) {
+	coords := randomCoords(2000, 3)
+	g := NewGrid(coords, 0)
+	p := [3]float64{5, 5, 5}
+	found := map[int]bool{}
+	g.Within(p, 1.5, func(i int, d2 float64) { found[i] = true })
+	for i := 0; i < len(coords)/3; i++ {
+		if inside := dist(coords, i, p) <= 1.5; inside != found[i] {
+			t.Fatalf("point %d: expected within %v, got %v", i, inside, found[i])
+		}
+	}
+}
@@ -0,0 +1,142 @@
+// spatial/kdtree.go
+package spatial
+
+import (
+	"math"
+	"sort"
+)
+
+// KDTree is a balanced k-d tree over packed xyz coordinates. Unlike Grid
+// it needs no cell size, so it suits clouds whose density varies widely
+// and queries far from the indexed points, such as comparing two scans.
+type KDTree struct {
+	coords []float32
+	order  []int32 // point indices; each subtree is a run, split at its middle
+	axes   []uint8 // split axis of the node at each middle, by position in order
+}
+
+// NewKDTree indexes packed xyz coordinates. The tree keeps coords and must
+// be rebuilt if they change.
+func NewKDTree(coords []float32) *KDTree {
+	n := len(coords) / 3
+	t := &KDTree{coords: coords, order: make([]int32, n), axes: make([]uint8, n)}
+	for i := range t.order {
+		t.order[i] = int32(i)
+	}
+	t.build(0, n)
+	return t
+}
+
+// build splits order[lo:hi] at its middle along the axis of widest extent.
+func (t *KDTree) build(lo, hi int) {
+	if hi-lo <= 1 {
+		return
+	}
+	var min, max [3]float32
+	for k := 0; k < 3; k++ {
+		min[k], max[k] = math.MaxFloat32, -math.MaxFloat32
+	}
+	for _, i := range t.order[lo:hi] {
+		for k := 0; k < 3; k++ {
+			v := t.coords[int(i)*3+k]
+			if v < min[k] {
+				min[k] = v
+			}
+			if v > max[k] {
+				max[k] = v
+			}
+		}
+	}
+	axis := 0
+	for k := 1; k < 3; k++ {
+		if max[k]-min[k] > max[axis]-min[axis] {
+			axis = k
+		}
+	}
+	run := t.order[lo:hi]
+	sort.Slice(run, func(a, b int) bool {
+		return t.coords[int(run[a])*3+axis] < t.coords[int(run[b])*3+axis]
+	})
+	mid := (lo + hi) / 2
+	t.axes[mid] = uint8(axis)
+	t.build(lo, mid)
+	t.build(mid+1, hi)
+}
+
+// Len returns the number of indexed points.
+func (t *KDTree) Len() int {
+	return len(t.order)
+}
+
+// Nearest returns the index of the indexed point nearest to p other than
+// exclude (pass -1 to exclude none) and its distance, or -1 when there is
+// no such point.
+func (t *KDTree) Nearest(p [3]float64, exclude int) (int, float64) {
+	best, bestD2 := -1, math.Inf(1)
+	var search func(lo, hi int)
+	search = func(lo, hi int) {
+		if lo >= hi {
+			return
+		}
+		mid := (lo + hi) / 2
+		i := int(t.order[mid])
+		if i != exclude {
+			dx := float64(t.coords[i*3]) - p[0]
+			dy := float64(t.coords[i*3+1]) - p[1]
+			dz := float64(t.coords[i*3+2]) - p[2]
+			if d2 := dx*dx + dy*dy + dz*dz; d2 < bestD2 {
+				best, bestD2 = i, d2
+			}
+		}
+		if hi-lo == 1 {
+			return
+		}
+		axis := int(t.axes[mid])
+		d := p[axis] - float64(t.coords[i*3+axis])
+		near, far := [2]int{lo, mid}, [2]int{mid + 1, hi}
+		if d > 0 {
+			near, far = far, near
+		}
+		search(near[0], near[1])
+		if d*d < bestD2 {
+			search(far[0], far[1])
+		}
+	}
+	search(0, len(t.order))
+	if best < 0 {
+		return -1, 0
+	}
+	return best, math.Sqrt(bestD2)
+}
+
+// Within calls fn with the index and squared distance of every indexed
+// point within radius of p, as Grid.Within does.
+func (t *KDTree) Within(p [3]float64, radius float64, fn func(i int, d2 float64)) {
+	r2 := radius * radius
+	var search func(lo, hi int)
+	search = func(lo, hi int) {
+		if lo >= hi {
+			return
+		}
+		mid := (lo + hi) / 2
+		i := int(t.order[mid])
+		dx := float64(t.coords[i*3]) - p[0]
+		dy := float64(t.coords[i*3+1]) - p[1]
+		dz := float64(t.coords[i*3+2]) - p[2]
+		if d2 := dx*dx + dy*dy + dz*dz; d2 <= r2 {
+			fn(i, d2)
+		}
+		if hi-lo == 1 {
+			return
+		}
+		axis := int(t.axes[mid])
+		d := p[axis] - float64(t.coords[i*3+axis])
+		if d <= radius {
+			search(lo, mid)
+		}
+		if d >= -radius {
+			search(mid+1, hi)
+		}
+	}
+	search(0, len(t.order))
+}
@@ -0,0 +1,62 @@
+// spatial/kdtree_test.go
+// usage: go test
+
+package spatial
+
+import (
+	"math"
+	"testing"
+)
+
+func TestKDTreeNearestMatchesBruteForce(t *testing.T) {
+	coords := randomCoords(2000, 4)
+	// A dense clump far from the rest, which a uniform grid handles badly.
+	for i := 0; i < 300; i++ {
+		coords = append(coords, 50+float32(i%7)*0.001, 50+float32(i%11)*0.001, 50+float32(i%13)*0.001)
+	}
+	tree := NewKDTree(coords)
+	queries := randomCoords(200, 5)
+	queries = append(queries, -3, 5, 5, 50, 50, 50, 30, 30, 30)
+	for q := 0; q < len(queries)/3; q++ {
+		p := [3]float64{float64(queries[q*3]), float64(queries[q*3+1]), float64(queries[q*3+2])}
+		want := math.Inf(1)
+		for i := 0; i < len(coords)/3; i++ {
+			want = math.Min(want, dist(coords, i, p))
+		}
+		i, d := tree.Nearest(p, -1)
+		if i < 0 || math.Abs(d-want) > 1e-9 || math.Abs(dist(coords, i, p)-d) > 1e-9 {
+			t.Fatalf("query %v: expected distance %f, got point %d at %f", p, want, i, d)
+		}
+	}
+}
+
+func TestKDTreeNearestExclude(t *testing.T) {
+	coords := []float32{0, 0, 0, 1, 0, 0, 5, 0, 0}
+	tree := NewKDTree(coords)
+	if i, d := tree.Nearest([3]float64{0, 0, 0}, 0); i != 1 || d != 1 {
+		t.Errorf("expected point 1 at 1, got %d at %f", i, d)
+	}
+	if i, _ := NewKDTree(nil).Nearest([3]float64{}, -1); i != -1 {
+		t.Errorf("expected no point in an empty tree, got %d", i)
+	}
+}
+
+func TestKDTreeWithinMatchesGrid(t *testing.T) {
+	coords := randomCoords(3000, 6)
+	tree, grid := NewKDTree(coords), NewGrid(coords, 0.3)
+	queries := randomCoords(50, 7)
+	for q := 0; q < len(queries)/3; q++ {
+		p := [3]float64{float64(queries[q*3]), float64(queries[q*3+1]), float64(queries[q*3+2])}
+		want, got := map[int]float64{}, map[int]float64{}
+		grid.Within(p, 1.5, func(i int, d2 float64) { want[i] = d2 })
+		tree.Within(p, 1.5, func(i int, d2 float64) { got[i] = d2 })
+		if len(got) != len(want) {
+			t.Fatalf("query %v: expected %d points, got %d", p, len(want), len(got))
+		}
+		for i, d2 := range want {
+			if got[i] != d2 {
+				t.Fatalf("query %v: point %d at %f missing", p, i, d2)
+			}
+		}
+	}
+}
@@ -0,0 +1,41 @@
+// subsample/subsample.go
+// Package subsample orders a cloud's points so that any fraction of them
+// can be drawn with count-limited draws: the points are split into chunks
+// of consecutive points, each shuffled, and the first part of every chunk
+// is a random sample of it. Drawing the same fraction of each chunk thins
+// the whole cloud evenly, however its points were ordered, without
+// reordering the points themselves.
+package subsample
+
+import "math/rand"
+
+// ChunkSize is the number of points in a chunk; the last chunk may be
+// shorter.
+const ChunkSize = 1 << 16
+
+// Order returns the indices 0 to n-1 with those of each chunk shuffled,
+// the same for the same n and seed.
+func Order(n int, seed int64) []uint32 {
+	order := make([]uint32, n)
+	for i := range order {
+		order[i] = uint32(i)
+	}
+	r := rand.New(rand.NewSource(seed))
+	for start := 0; start < n; start += ChunkSize {
+		chunk := order[start:min(start+ChunkSize, n)]
+		r.Shuffle(len(chunk), func(i, j int) { chunk[i], chunk[j] = chunk[j], chunk[i] })
+	}
+	return order
+}
+
+// Chunks returns the number of chunks of n points.
+func Chunks(n int) int {
+	return (n + ChunkSize - 1) / ChunkSize
+}
+
+// Chunk returns the first index in the order and the length of chunk c of
+// n points.
+func Chunk(n, c int) (first, length int) {
+	first = c * ChunkSize
+	return first, min(ChunkSize, n-first)
+}
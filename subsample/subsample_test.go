@@ -0,0 +1,76 @@
+// subsample/subsample_test.go
+// usage: go test
+
+package subsample
+
+import "testing"
+
+func TestOrderShufflesEachChunk(t *testing.T) {
+	n := 2*ChunkSize + 100
+	order := Order(n, 1)
+	if len(order) != n {
+		t.Fatalf("expected %d indices, got %d", n, len(order))
+	}
+	seen := make([]bool, n)
+	for c := 0; c < Chunks(n); c++ {
+		first, length := Chunk(n, c)
+		moved := 0
+		for i, v := range order[first : first+length] {
+			if int(v) < first || int(v) >= first+length {
+				t.Fatalf("chunk %d: index %d is outside it", c, v)
+			}
+			if seen[v] {
+				t.Fatalf("index %d appears twice", v)
+			}
+			seen[v] = true
+			if int(v) != first+i {
+				moved++
+			}
+		}
+		if moved < length/2 {
+			t.Errorf("chunk %d: expected it shuffled, only %d of %d indices moved", c, moved, length)
+		}
+	}
+}
+
+func TestOrderIsRepeatable(t *testing.T) {
+	a, b := Order(1000, 7), Order(1000, 7)
+	for i := range a {
+		if a[i] != b[i] {
+			t.Fatalf("expected the same order for the same seed, differs at %d", i)
+		}
+	}
+}
+
+func TestPrefixSamplesEvenly(t *testing.T) {
+	// Half of a chunk's first tenth should come from each half of it.
+	order := Order(ChunkSize, 3)
+	low := 0
+	for _, v := range order[:ChunkSize/10] {
+		if v < ChunkSize/2 {
+			low++
+		}
+	}
+	if frac := float64(low) / float64(ChunkSize/10); frac < 0.45 || frac > 0.55 {
+		t.Errorf("expected about half of the sample from the first half, got %.2f", frac)
+	}
+}
+
+func TestChunks(t *testing.T) {
+	for _, tc := range []struct{ n, chunks, last int }{
+		{0, 0, 0},
+		{1, 1, 1},
+		{ChunkSize, 1, ChunkSize},
+		{ChunkSize + 1, 2, 1},
+	} {
+		if got := Chunks(tc.n); got != tc.chunks {
+			t.Errorf("Chunks(%d): expected %d, got %d", tc.n, tc.chunks, got)
+			continue
+		}
+		if tc.chunks > 0 {
+			if _, length := Chunk(tc.n, tc.chunks-1); length != tc.last {
+				t.Errorf("Chunk(%d, last): expected length %d, got %d", tc.n, tc.last, length)
+			}
+		}
+	}
+}
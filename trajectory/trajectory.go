@@ -0,0 +1,86 @@
+// trajectory/trajectory.go
+// Package trajectory records the poses of a moving sensor, such as the
+// output of a SLAM system, and builds line geometry tracing its path with
+// glyphs showing which way it faced.
+package trajectory
+
+import (
+	"math"
+	"slices"
+
+	"github.com/sbecker11/webgl-point-cloud/glf32"
+)
+
+// Trajectory is a sequence of sensor poses, each a column-major matrix
+// taking points from the sensor's frame to the world's. With MaxPoses
+// above 0, the oldest poses beyond it are dropped.
+type Trajectory struct {
+	Poses    []glf32.Mat4
+	MaxPoses int
+}
+
+// Add appends a copy of pose, unless it equals the latest pose, as when a
+// stationary sensor repeats it.
+func (t *Trajectory) Add(pose glf32.Mat4) {
+	if n := len(t.Poses); n > 0 && slices.Equal(t.Poses[n-1], pose[:16]) {
+		return
+	}
+	t.Poses = append(t.Poses, append(glf32.Mat4(nil), pose[:16]...))
+	if excess := len(t.Poses) - t.MaxPoses; t.MaxPoses > 0 && excess > 0 {
+		t.Poses = append(t.Poses[:0], t.Poses[excess:]...)
+	}
+}
+
+// Positions returns where the sensor was at each pose.
+func (t *Trajectory) Positions() []glf32.Vec3 {
+	positions := make([]glf32.Vec3, len(t.Poses))
+	for i, p := range t.Poses {
+		positions[i] = glf32.Vec3{p[12], p[13], p[14]}
+	}
+	return positions
+}
+
+// Length returns the distance travelled along the path.
+func (t *Trajectory) Length() float32 {
+	var length float64
+	positions := t.Positions()
+	for i := 1; i < len(positions); i++ {
+		a, b := positions[i-1], positions[i]
+		dx, dy, dz := float64(b[0]-a[0]), float64(b[1]-a[1]), float64(b[2]-a[2])
+		length += math.Sqrt(dx*dx + dy*dy + dz*dz)
+	}
+	return float32(length)
+}
+
+// Axis colors of the orientation glyphs: the sensor's x, y and z axes are
+// red, green and blue.
+var axisColors = [3][4]float32{{1, 0.2, 0.2, 1}, {0.2, 1, 0.2, 1}, {0.3, 0.5, 1, 1}}
+
+// Lines returns the packed positions and RGBA colors of line segments, to
+// draw as gl.LINES: the path through the poses in pathColor, and at up to
+// maxGlyphs poses spread evenly along it, always including the latest, the
+// sensor's axes glyphSize long.
+func (t *Trajectory) Lines(pathColor [4]float32, glyphSize float32, maxGlyphs int) (positions, colors []float32) {
+	segment := func(a, b glf32.Vec3, color [4]float32) {
+		positions = append(positions, a[0], a[1], a[2], b[0], b[1], b[2])
+		colors = append(colors, color[:]...)
+		colors = append(colors, color[:]...)
+	}
+	points := t.Positions()
+	for i := 1; i < len(points); i++ {
+		segment(points[i-1], points[i], pathColor)
+	}
+	if maxGlyphs <= 0 || len(points) == 0 {
+		return positions, colors
+	}
+	step := max(1, (len(points)+maxGlyphs-1)/maxGlyphs)
+	for i := (len(points) - 1) % step; i < len(points); i += step {
+		p := t.Poses[i]
+		for axis := 0; axis < 3; axis++ {
+			dir := glf32.Normalize(glf32.Vec3{p[axis*4], p[axis*4+1], p[axis*4+2]})
+			end := glf32.Vec3{points[i][0] + dir[0]*glyphSize, points[i][1] + dir[1]*glyphSize, points[i][2] + dir[2]*glyphSize}
+			segment(points[i], end, axisColors[axis])
+		}
+	}
+	return positions, colors
+}
@@ -0,0 +1,51 @@
+// trajectory/trajectory_test.go
+// usage: go test
+
+package trajectory
+
+import (
+	"testing"
+
+	"github.com/sbecker11/webgl-point-cloud/glf32"
+)
+
+func TestTrajectory(t *testing.T) {
+	tr := &Trajectory{MaxPoses: 3}
+	for i := 0; i < 4; i++ {
+		tr.Add(glf32.Translate(float32(i), 0, 0))
+		tr.Add(glf32.Translate(float32(i), 0, 0))
+	}
+	if len(tr.Poses) != 3 || tr.Poses[0][12] != 1 {
+		t.Fatalf("expected the 3 latest distinct poses, got %v", tr.Poses)
+	}
+	if got := tr.Length(); got != 2 {
+		t.Errorf("expected length 2, got %g", got)
+	}
+}
+
+func TestLines(t *testing.T) {
+	tr := &Trajectory{}
+	for i := 0; i < 5; i++ {
+		// Facing along y: the sensor's x axis points to world y.
+		tr.Add(glf32.MultiplyMatrices(glf32.Translate(0, float32(i), 0), glf32.RotateZ(1.5707964)))
+	}
+	positions, colors := tr.Lines([4]float32{1, 1, 1, 1}, 0.5, 2)
+	// 4 path segments, then glyphs at poses 1 and 4, 3 apart.
+	if len(positions) != (4+2*3)*6 || len(colors) != (4+2*3)*8 {
+		t.Fatalf("expected 10 segments, got %d positions and %d colors", len(positions), len(colors))
+	}
+	glyph := positions[4*6:]
+	if glyph[1] != 1 || glyph[3] > 1e-6 || glyph[4] != 1.5 {
+		t.Errorf("expected the first glyph's x axis from (0, 1, 0) to (0, 1.5, 0), got %v", glyph[:6])
+	}
+	last := positions[len(positions)-18:]
+	if last[1] != 4 {
+		t.Errorf("expected the last glyph at the latest pose, got %v", last[:3])
+	}
+	if colors[4*8] != 1 || colors[4*8+1] != 0.2 {
+		t.Errorf("expected a red x axis, got %v", colors[4*8:4*8+4])
+	}
+	if p, _ := (&Trajectory{}).Lines([4]float32{}, 1, 10); len(p) != 0 {
+		t.Errorf("expected no lines for no poses, got %v", p)
+	}
+}
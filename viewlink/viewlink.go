@@ -0,0 +1,95 @@
+// viewlink/viewlink.go
+// Package viewlink encodes a view of the viewer, the dataset shown, the
+// camera and the color mode, as a URL fragment such as
+// #dataset=town&points=5000&seed=42&color=height&camera=1,2,3,0,0,0, so
+// that a link opens the same view of the same data.
+package viewlink
+
+import (
+	"fmt"
+	"math"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// View is the state a link holds. Zero fields are left out of it.
+type View struct {
+	Dataset string // a procgen dataset name
+	Points  int
+	Seed    int64
+	Color   string // a color mode name
+
+	HasCamera bool
+	Position  [3]float32 // the camera's position, in world coordinates
+	Target    [3]float32 // the point it looks at and orbits
+}
+
+// Encode returns the view as a fragment, without the leading '#'.
+func (v View) Encode() string {
+	var parts []string
+	if v.Dataset != "" {
+		parts = append(parts, "dataset="+url.QueryEscape(v.Dataset))
+	}
+	if v.Points > 0 {
+		parts = append(parts, "points="+strconv.Itoa(v.Points))
+	}
+	if v.Seed != 0 {
+		parts = append(parts, "seed="+strconv.FormatInt(v.Seed, 10))
+	}
+	if v.Color != "" {
+		parts = append(parts, "color="+url.QueryEscape(v.Color))
+	}
+	if v.HasCamera {
+		numbers := make([]string, 6)
+		for i, x := range append(v.Position[:], v.Target[:]...) {
+			numbers[i] = strconv.FormatFloat(float64(x), 'g', -1, 32)
+		}
+		parts = append(parts, "camera="+strings.Join(numbers, ","))
+	}
+	return strings.Join(parts, "&")
+}
+
+// Parse reads a view from a fragment, with or without its leading '#'.
+// Unknown keys are ignored, so fragments can carry other state too.
+func Parse(fragment string) (View, error) {
+	var v View
+	values, err := url.ParseQuery(strings.TrimPrefix(fragment, "#"))
+	if err != nil {
+		return v, fmt.Errorf("viewlink: %v", err)
+	}
+	v.Dataset = values.Get("dataset")
+	v.Color = values.Get("color")
+	if s := values.Get("points"); s != "" {
+		if v.Points, err = strconv.Atoi(s); err != nil || v.Points <= 0 {
+			return View{}, fmt.Errorf("viewlink: invalid points %q", s)
+		}
+	}
+	if s := values.Get("seed"); s != "" {
+		if v.Seed, err = strconv.ParseInt(s, 10, 64); err != nil {
+			return View{}, fmt.Errorf("viewlink: invalid seed %q", s)
+		}
+	}
+	if s := values.Get("camera"); s != "" {
+		fields := strings.Split(s, ",")
+		if len(fields) != 6 {
+			return View{}, fmt.Errorf("viewlink: camera needs 6 numbers, got %d", len(fields))
+		}
+		for i, f := range fields {
+			x, err := strconv.ParseFloat(f, 32)
+			if err != nil || math.IsNaN(x) || math.IsInf(x, 0) {
+				return View{}, fmt.Errorf("viewlink: invalid camera number %q", f)
+			}
+			if i < 3 {
+				v.Position[i] = float32(x)
+			} else {
+				v.Target[i-3] = float32(x)
+			}
+		}
+		if v.Position == v.Target {
+			return View{}, fmt.Errorf("viewlink: camera position and target coincide")
+		}
+		v.HasCamera = true
+	}
+	return v, nil
+}
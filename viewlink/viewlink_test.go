@@ -0,0 +1,59 @@
+// viewlink/viewlink_test.go
+// usage: go test
+
+package viewlink
+
+import "testing"
+
+func TestEncodeParseRoundTrip(t *testing.T) {
+	v := View{
+		Dataset: "town", Points: 5000, Seed: -42, Color: "height",
+		HasCamera: true, Position: [3]float32{1.5, 0.1, -3.25}, Target: [3]float32{0, 0.2, 1e-7},
+	}
+	s := v.Encode()
+	if want := "dataset=town&points=5000&seed=-42&color=height&camera=1.5,0.1,-3.25,0,0.2,1e-07"; s != want {
+		t.Errorf("Encode() = %q, want %q", s, want)
+	}
+	got, err := Parse("#" + s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != v {
+		t.Errorf("Parse(Encode()) = %+v, want %+v", got, v)
+	}
+}
+
+func TestEncodeLeavesOutZeroFields(t *testing.T) {
+	if s := (View{Color: "rgb"}).Encode(); s != "color=rgb" {
+		t.Errorf("Encode() = %q, want color=rgb", s)
+	}
+	v, err := Parse("")
+	if err != nil || v != (View{}) {
+		t.Errorf("Parse(\"\") = %+v, %v; want the zero view", v, err)
+	}
+}
+
+func TestParseIgnoresUnknownKeys(t *testing.T) {
+	v, err := Parse("dataset=galaxy&tab=2")
+	if err != nil || v.Dataset != "galaxy" {
+		t.Errorf("Parse = %+v, %v", v, err)
+	}
+}
+
+func TestParseRejectsMalformedValues(t *testing.T) {
+	for _, s := range []string{
+		"points=-1",
+		"points=many",
+		"seed=1.5",
+		"camera=1,2,3",
+		"camera=1,2,3,4,5,x",
+		"camera=1,2,3,1,2,3",
+		"camera=NaN,0,0,1,1,1",
+		"camera=0,0,Inf,1,1,1",
+		"color=%zz",
+	} {
+		if _, err := Parse(s); err == nil {
+			t.Errorf("Parse(%q) succeeded", s)
+		}
+	}
+}
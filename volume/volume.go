@@ -0,0 +1,236 @@
+// volume/volume.go
+// Package volume estimates the volume between a point cloud surface and a
+// base plane, such as a stockpile above its footprint or the cut and fill
+// between a graded site and its design level.
+//
+// The points are binned into a square grid laid out on the base plane. Each
+// occupied cell contributes its area times the mean height of its points
+// above the plane; cells below the plane count as fill. Empty cells inside
+// the footprint contribute nothing, so sparse or occluded scans are
+// underestimated; Result.Cells reports the coverage used.
+package volume
+
+import (
+	"errors"
+	"math"
+)
+
+// Plane is a plane through Point with unit Normal; heights are measured
+// along Normal.
+type Plane struct {
+	Point  [3]float64
+	Normal [3]float64
+}
+
+// Height returns the signed distance of (x, y, z) above the plane.
+func (p Plane) Height(x, y, z float64) float64 {
+	return (x-p.Point[0])*p.Normal[0] + (y-p.Point[1])*p.Normal[1] + (z-p.Point[2])*p.Normal[2]
+}
+
+// frame returns two unit vectors spanning the plane.
+func (p Plane) frame() (u, v [3]float64) {
+	n := p.Normal
+	u = [3]float64{1, 0, 0}
+	if math.Abs(n[0]) > 0.9 {
+		u = [3]float64{0, 1, 0}
+	}
+	d := u[0]*n[0] + u[1]*n[1] + u[2]*n[2]
+	u = [3]float64{u[0] - d*n[0], u[1] - d*n[1], u[2] - d*n[2]}
+	l := math.Sqrt(u[0]*u[0] + u[1]*u[1] + u[2]*u[2])
+	u = [3]float64{u[0] / l, u[1] / l, u[2] / l}
+	v = [3]float64{n[1]*u[2] - n[2]*u[1], n[2]*u[0] - n[0]*u[2], n[0]*u[1] - n[1]*u[0]}
+	return u, v
+}
+
+// NewPlane returns the plane through point with the given normal, which
+// need not be unit length.
+func NewPlane(point, normal [3]float64) (Plane, error) {
+	l := math.Sqrt(normal[0]*normal[0] + normal[1]*normal[1] + normal[2]*normal[2])
+	if l == 0 {
+		return Plane{}, errors.New("volume: zero plane normal")
+	}
+	return Plane{Point: point, Normal: [3]float64{normal[0] / l, normal[1] / l, normal[2] / l}}, nil
+}
+
+// FitPlane fits a plane to packed xyz coordinates by least squares on the
+// heights along up, so the fitted normal points to the same side as up.
+// It fails for fewer than three points or points on a line.
+func FitPlane(coords []float32, up [3]float64) (Plane, error) {
+	ref, err := NewPlane([3]float64{}, up)
+	if err != nil {
+		return Plane{}, err
+	}
+	u, v := ref.frame()
+	n := ref.Normal
+	// Solve h = a*s + b*t + c over the points' (s, t, h) in the up frame,
+	// relative to their mean for conditioning.
+	var mean [3]float64
+	count := len(coords) / 3
+	if count < 3 {
+		return Plane{}, errors.New("volume: need at least three points to fit a plane")
+	}
+	for i := 0; i < count*3; i++ {
+		mean[i%3] += float64(coords[i]) / float64(count)
+	}
+	var sss, sst, stt, ssh, sth float64
+	for i := 0; i < count; i++ {
+		d := [3]float64{float64(coords[i*3]) - mean[0], float64(coords[i*3+1]) - mean[1], float64(coords[i*3+2]) - mean[2]}
+		s := d[0]*u[0] + d[1]*u[1] + d[2]*u[2]
+		t := d[0]*v[0] + d[1]*v[1] + d[2]*v[2]
+		h := d[0]*n[0] + d[1]*n[1] + d[2]*n[2]
+		sss += s * s
+		sst += s * t
+		stt += t * t
+		ssh += s * h
+		sth += t * h
+	}
+	det := sss*stt - sst*sst
+	if det <= 1e-12*sss*stt {
+		return Plane{}, errors.New("volume: points are collinear")
+	}
+	a := (ssh*stt - sth*sst) / det
+	b := (sth*sss - ssh*sst) / det
+	// The plane h = a*s + b*t has normal n - a*u - b*v.
+	normal := [3]float64{n[0] - a*u[0] - b*v[0], n[1] - a*u[1] - b*v[1], n[2] - a*u[2] - b*v[2]}
+	return NewPlane(mean, normal)
+}
+
+// Result is a volume estimate.
+type Result struct {
+	Cut      float64 // volume above the plane
+	Fill     float64 // volume below the plane, positive
+	Area     float64 // area of the occupied cells
+	Cells    int     // occupied cells
+	CellSize float64
+	Base     Plane
+	// Corners are the corners of the rectangle on the base plane covering
+	// the occupied cells, in order around it.
+	Corners [4][3]float64
+}
+
+// Net returns Cut minus Fill.
+func (r Result) Net() float64 {
+	return r.Cut - r.Fill
+}
+
+// grid bins points into cells on the plane.
+type grid struct {
+	size  float64
+	cells map[[2]int]*cell
+}
+
+type cell struct {
+	sum   float64
+	count int
+	low   [3]float64 // lowest point, for rim fitting
+	lowH  float64
+}
+
+func newGrid(coords []float32, base Plane, size float64) *grid {
+	u, v := base.frame()
+	g := &grid{size: size, cells: map[[2]int]*cell{}}
+	for i := 0; i+2 < len(coords); i += 3 {
+		x, y, z := float64(coords[i]), float64(coords[i+1]), float64(coords[i+2])
+		d := [3]float64{x - base.Point[0], y - base.Point[1], z - base.Point[2]}
+		key := [2]int{
+			int(math.Floor((d[0]*u[0] + d[1]*u[1] + d[2]*u[2]) / size)),
+			int(math.Floor((d[0]*v[0] + d[1]*v[1] + d[2]*v[2]) / size)),
+		}
+		h := base.Height(x, y, z)
+		c := g.cells[key]
+		if c == nil {
+			c = &cell{lowH: math.Inf(1)}
+			g.cells[key] = c
+		}
+		c.sum += h
+		c.count++
+		if h < c.lowH {
+			c.low, c.lowH = [3]float64{x, y, z}, h
+		}
+	}
+	return g
+}
+
+// AutoCellSize returns a cell size giving about four points per cell over
+// the points' extent across up.
+func AutoCellSize(coords []float32, up [3]float64) float64 {
+	ref, err := NewPlane([3]float64{}, up)
+	if err != nil || len(coords) < 3 {
+		return 1
+	}
+	u, v := ref.frame()
+	minS, maxS, minT, maxT := math.Inf(1), math.Inf(-1), math.Inf(1), math.Inf(-1)
+	for i := 0; i+2 < len(coords); i += 3 {
+		x, y, z := float64(coords[i]), float64(coords[i+1]), float64(coords[i+2])
+		s, t := x*u[0]+y*u[1]+z*u[2], x*v[0]+y*v[1]+z*v[2]
+		minS, maxS = math.Min(minS, s), math.Max(maxS, s)
+		minT, maxT = math.Min(minT, t), math.Max(maxT, t)
+	}
+	area := (maxS - minS) * (maxT - minT)
+	if area <= 0 {
+		return 1
+	}
+	return math.Sqrt(4 * area / float64(len(coords)/3))
+}
+
+// AbovePlane integrates the heights of packed xyz coordinates above base
+// over a grid of cellSize cells.
+func AbovePlane(coords []float32, base Plane, cellSize float64) (Result, error) {
+	if cellSize <= 0 {
+		return Result{}, errors.New("volume: cell size must be positive")
+	}
+	g := newGrid(coords, base, cellSize)
+	r := Result{CellSize: cellSize, Base: base, Cells: len(g.cells)}
+	area := cellSize * cellSize
+	for _, c := range g.cells {
+		if h := c.sum / float64(c.count); h > 0 {
+			r.Cut += h * area
+		} else {
+			r.Fill -= h * area
+		}
+	}
+	r.Area = float64(r.Cells) * area
+
+	if len(g.cells) > 0 {
+		lo := [2]int{math.MaxInt, math.MaxInt}
+		hi := [2]int{math.MinInt, math.MinInt}
+		for key := range g.cells {
+			for a := 0; a < 2; a++ {
+				lo[a], hi[a] = min(lo[a], key[a]), max(hi[a], key[a]+1)
+			}
+		}
+		u, v := base.frame()
+		for i, c := range [4][2]int{{lo[0], lo[1]}, {hi[0], lo[1]}, {hi[0], hi[1]}, {lo[0], hi[1]}} {
+			s, t := float64(c[0])*cellSize, float64(c[1])*cellSize
+			for k := 0; k < 3; k++ {
+				r.Corners[i][k] = base.Point[k] + s*u[k] + t*v[k]
+			}
+		}
+	}
+	return r, nil
+}
+
+// RimPlane fits a base plane to the rim of the points' footprint: the
+// lowest point of every occupied cell with an empty neighbour, seen along
+// up. For a stockpile scanned with some surrounding ground this is the toe
+// of the pile, so the plane follows a sloping site.
+func RimPlane(coords []float32, up [3]float64, cellSize float64) (Plane, error) {
+	ref, err := NewPlane([3]float64{}, up)
+	if err != nil {
+		return Plane{}, err
+	}
+	if cellSize <= 0 {
+		return Plane{}, errors.New("volume: cell size must be positive")
+	}
+	g := newGrid(coords, ref, cellSize)
+	var rim []float32
+	for key, c := range g.cells {
+		for _, d := range [4][2]int{{1, 0}, {-1, 0}, {0, 1}, {0, -1}} {
+			if g.cells[[2]int{key[0] + d[0], key[1] + d[1]}] == nil {
+				rim = append(rim, float32(c.low[0]), float32(c.low[1]), float32(c.low[2]))
+				break
+			}
+		}
+	}
+	return FitPlane(rim, up)
+}
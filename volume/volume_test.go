@@ -0,0 +1,99 @@
+// volume/volume_test.go
+// usage: go test
+
+package volume
+
+import (
+	"math"
+	"testing"
+)
+
+// coneOnSlope returns a grid of points over a 10 x 10 site sloping up along
+// x, with a cone of radius 2 and height 2 standing on it at the center.
+func coneOnSlope() []float32 {
+	var coords []float32
+	for x := -5.0; x <= 5; x += 0.05 {
+		for z := -5.0; z <= 5; z += 0.05 {
+			y := 0.1*x + 1 + math.Max(0, 2-math.Hypot(x, z))
+			coords = append(coords, float32(x), float32(y), float32(z))
+		}
+	}
+	return coords
+}
+
+func TestFitPlane(t *testing.T) {
+	coords := []float32{0, 1, 0, 1, 1.5, 0, 0, 1, 1, 1, 1.5, 1, 0.5, 1.25, 0.5}
+	p, err := FitPlane(coords, [3]float64{0, 1, 0})
+	if err != nil {
+		t.Fatalf("FitPlane: %v", err)
+	}
+	if p.Normal[1] <= 0 {
+		t.Errorf("expected the normal on the up side, got %v", p.Normal)
+	}
+	for i := 0; i < len(coords); i += 3 {
+		if h := p.Height(float64(coords[i]), float64(coords[i+1]), float64(coords[i+2])); math.Abs(h) > 1e-6 {
+			t.Errorf("point %d: expected on the plane, got height %f", i/3, h)
+		}
+	}
+	if _, err := FitPlane([]float32{0, 0, 0, 1, 0, 0, 2, 0, 0}, [3]float64{0, 1, 0}); err == nil {
+		t.Errorf("expected an error for collinear points")
+	}
+}
+
+func TestConeVolume(t *testing.T) {
+	coords := coneOnSlope()
+	up := [3]float64{0, 1, 0}
+	base, err := RimPlane(coords, up, 0.2)
+	if err != nil {
+		t.Fatalf("RimPlane: %v", err)
+	}
+	if h := base.Height(0, 1, 0); math.Abs(h) > 1e-3 {
+		t.Errorf("expected the rim plane through the site's center, got height %f", h)
+	}
+	r, err := AbovePlane(coords, base, 0.2)
+	if err != nil {
+		t.Fatalf("AbovePlane: %v", err)
+	}
+	want := math.Pi * 4 * 2 / 3
+	if math.Abs(r.Cut-want) > 0.02*want {
+		t.Errorf("expected a cut of %f, got %f", want, r.Cut)
+	}
+	if r.Fill > 1e-3 {
+		t.Errorf("expected no fill, got %f", r.Fill)
+	}
+	if math.Abs(r.Area-100) > 10 { // partly covered edge cells count whole
+		t.Errorf("expected about 100 square units covered, got %f", r.Area)
+	}
+}
+
+func TestCutAndFill(t *testing.T) {
+	// A flat site one unit above a design level on one half and one unit
+	// below it on the other.
+	var coords []float32
+	for x := 0.05; x < 4; x += 0.1 {
+		for z := 0.05; z < 4; z += 0.1 {
+			y := 1.0
+			if x > 2 {
+				y = -1
+			}
+			coords = append(coords, float32(x), float32(y), float32(z))
+		}
+	}
+	base, _ := NewPlane([3]float64{0, 0, 0}, [3]float64{0, 2, 0})
+	r, err := AbovePlane(coords, base, 0.5)
+	if err != nil {
+		t.Fatalf("AbovePlane: %v", err)
+	}
+	if math.Abs(r.Cut-8) > 1e-6 || math.Abs(r.Fill-8) > 1e-6 || math.Abs(r.Net()) > 1e-6 {
+		t.Errorf("expected cut 8 and fill 8, got %f and %f", r.Cut, r.Fill)
+	}
+	for _, c := range r.Corners {
+		if math.Abs(c[1]) > 1e-9 || (math.Abs(c[0]) > 1e-9 && math.Abs(c[0]-4) > 1e-9) || (math.Abs(c[2]) > 1e-9 && math.Abs(c[2]-4) > 1e-9) {
+			t.Errorf("expected the corners of the 4 x 4 site on the plane, got %v", r.Corners)
+			break
+		}
+	}
+	if s := AutoCellSize(coords, [3]float64{0, 1, 0}); s <= 0.1 || s > 0.3 {
+		t.Errorf("expected an automatic cell size of about 0.2, got %f", s)
+	}
+}
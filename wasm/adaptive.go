@@ -0,0 +1,155 @@
+// wasm/adaptive.go
+package main
+
+import (
+	"math"
+	"syscall/js"
+
+	"github.com/sbecker11/webgl-point-cloud/quality"
+)
+
+// qualityLevel is what the adaptive controller trades away at each level:
+// the fraction of each object's points drawn, sampled evenly through
+// them, a point size multiplier that keeps the thinned cloud looking
+// solid, a point budget that caps the view's (0 for none), shared among
+// the parts of the scene by their size on screen as setPointBudget does,
+// the costliest point size mode objects keep: those sized in a costlier
+// one, such as world-sized points that grow huge near the camera, draw at
+// the view's point size instead, and whether eye-dome lighting, when
+// turned on, is drawn.
+type qualityLevel struct {
+	pointFraction float64
+	pointScale    float32
+	pointBudget   int
+	sizeMode      PointSizeMode
+	edl           bool
+}
+
+// qualityLevels runs from best to fastest.
+var qualityLevels = []qualityLevel{
+	{1, 1, 0, PointSizeWorld, true},
+	{0.6, 1.25, 0, PointSizeWorld, true},
+	{0.35, 1.5, 8000000, PointSizeWorld, true},
+	{0.2, 2, 4000000, PointSizePixels, false},
+	{0.1, 2.5, 2000000, PointSizePixels, false},
+}
+
+// defaultTargetFPS sits below the common 60Hz refresh rate, which caps the
+// frame rate the controller can observe.
+const defaultTargetFPS = 50
+
+// adaptiveQuality lowers the quality level when frames take too long and
+// raises it again when there is headroom.
+type adaptiveQuality struct {
+	Enabled    bool
+	controller *quality.Controller
+	lastFrame  float64 // requestAnimationFrame timestamp of the last frame
+}
+
+// newAdaptiveQuality returns a controller at full quality, turned off.
+func newAdaptiveQuality() *adaptiveQuality {
+	return &adaptiveQuality{controller: quality.NewController(defaultTargetFPS, len(qualityLevels))}
+}
+
+// frame records a frame drawn at the requestAnimationFrame timestamp now.
+func (a *adaptiveQuality) frame(now float64) {
+	if a.Enabled && a.lastFrame > 0 {
+		a.controller.Frame(now - a.lastFrame)
+	}
+	a.lastFrame = now
+}
+
+// level returns the settings to draw the next frame with.
+func (a *adaptiveQuality) level() qualityLevel {
+	if !a.Enabled {
+		return qualityLevels[0]
+	}
+	return qualityLevels[a.controller.Level()]
+}
+
+// setEnabled turns the controller on or off, starting again from the best
+// level.
+func (a *adaptiveQuality) setEnabled(enabled bool) {
+	a.Enabled = enabled
+	a.controller.Reset()
+}
+
+// pointBudget returns the number of points to draw each frame, the
+// smaller of the view's point budget and the quality level's, or 0 for
+// all of them.
+func (a *adaptiveQuality) pointBudget(view *ViewSettings) int {
+	budget := a.level().pointBudget
+	if budget == 0 || view.PointBudget > 0 && view.PointBudget < budget {
+		budget = view.PointBudget
+	}
+	return budget
+}
+
+// sizeMode returns the point size mode to draw the style's points in at
+// the quality level.
+func (a *adaptiveQuality) sizeMode(style PointStyle) PointSizeMode {
+	if style.SizeMode > a.level().sizeMode {
+		return PointSizeView
+	}
+	return style.SizeMode
+}
+
+// drawCount returns how many of n points to draw at the given fraction,
+// always at least one of a non-empty cloud.
+func drawCount(n int, fraction float64) int {
+	if fraction >= 1 {
+		return n
+	}
+	count := int(math.Ceil(float64(n) * fraction))
+	if count < 1 && n > 0 {
+		count = 1
+	}
+	return count
+}
+
+// qualityInfo describes the adaptive quality state to JS.
+func (v *Viewer) qualityInfo() map[string]interface{} {
+	level := v.adaptive.level()
+	return map[string]interface{}{
+		"enabled":       v.adaptive.Enabled,
+		"targetFps":     v.adaptive.controller.TargetFPS(),
+		"level":         v.adaptive.controller.Level(),
+		"levels":        len(qualityLevels),
+		"pointFraction": level.pointFraction,
+		"pointScale":    level.pointScale,
+		"pointBudget":   level.pointBudget,
+		"sizeMode":      level.sizeMode.String(),
+		"edl":           level.edl,
+		"frameTimeMs":   v.adaptive.controller.FrameTime(),
+	}
+}
+
+// setAdaptiveQuality(options) turns the adaptive quality controller on or
+// off. options may hold enabled (default true) and targetFps (default 50;
+// keep it below the display's refresh rate). Changing either restarts from
+// the best level.
+//
+// Returns the state as getQuality does, or {error}.
+func (v *Viewer) setAdaptiveQuality(this js.Value, args []js.Value) interface{} {
+	options := js.Undefined()
+	if len(args) > 0 {
+		options = args[0]
+	}
+	if fps := jsValue(options, "targetFps"); !fps.IsUndefined() {
+		if fps.Type() != js.TypeNumber || fps.Float() <= 0 {
+			return jsError("setAdaptiveQuality: targetFps must be a positive number")
+		}
+		v.adaptive.controller = quality.NewController(fps.Float(), len(qualityLevels))
+	}
+	enabled := jsValue(options, "enabled")
+	v.adaptive.setEnabled(enabled.IsUndefined() || enabled.Truthy())
+	return js.ValueOf(v.qualityInfo())
+}
+
+// getQuality() returns {enabled, targetFps, level, levels, pointFraction,
+// pointScale, pointBudget, sizeMode, frameTimeMs}: the controller's state
+// and the fraction of points, point size multiplier, point budget (0 for
+// none) and costliest point size mode it currently draws with.
+func (v *Viewer) getQuality(this js.Value, args []js.Value) interface{} {
+	return js.ValueOf(v.qualityInfo())
+}
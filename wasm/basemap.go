@@ -0,0 +1,273 @@
+// wasm/basemap.go
+package main
+
+import (
+	"fmt"
+	"math"
+	"syscall/js"
+
+	"github.com/sbecker11/webgl-point-cloud/basemap"
+	"github.com/sbecker11/webgl-point-cloud/crs"
+	"github.com/sbecker11/webgl-point-cloud/glf32"
+)
+
+// BasemapPlane is a ground plane under the georeferenced objects textured
+// with map tiles, fetched through the server's /tiles/ proxy. Each tile is
+// a quad whose corners are reprojected from longitude and latitude into
+// the scene frame, so the map lines up with the data in any supported
+// CRS. It lies at the lowest point of the data, or at Height in the scene
+// CRS if HeightSet.
+type BasemapPlane struct {
+	viewer    *Viewer
+	Visible   bool
+	Opacity   float32
+	MaxTiles  int
+	Height    float64
+	HeightSet bool
+
+	program    js.Value
+	mvpLoc     js.Value
+	opacityLoc js.Value
+	texLoc     int
+	buffer     js.Value
+	key        string // scene frame, data and settings the quads were built for
+	tiles      []*mapTile
+	textures   map[basemap.Tile]*mapTile
+}
+
+// mapTile is a tile's texture, loaded asynchronously.
+type mapTile struct {
+	tile    basemap.Tile
+	texture js.Value
+	loaded  bool
+}
+
+// newBasemapPlane returns a basemap plane with no map.
+func newBasemapPlane(v *Viewer) *BasemapPlane {
+	return &BasemapPlane{viewer: v, Opacity: 1, MaxTiles: 16, textures: map[basemap.Tile]*mapTile{}}
+}
+
+const basemapVertexShader = `
+attribute vec4 aPosition;
+attribute vec2 aTexCoord;
+uniform mat4 uMvpMatrix;
+varying vec2 vTexCoord;
+void main() {
+	gl_Position = uMvpMatrix * aPosition;
+	vTexCoord = aTexCoord;
+}`
+
+const basemapFragmentShader = `
+precision mediump float;
+uniform sampler2D uTexture;
+uniform float uOpacity;
+varying vec2 vTexCoord;
+void main() {
+	gl_FragColor = vec4(texture2D(uTexture, vTexCoord).rgb, uOpacity);
+}`
+
+// setup compiles the program and creates the vertex buffer on first use.
+func (b *BasemapPlane) setup(gl js.Value) error {
+	if !b.program.IsUndefined() {
+		return nil
+	}
+	program, err := createShaderProgram(gl, basemapVertexShader, basemapFragmentShader)
+	if err != nil {
+		return err
+	}
+	b.program = program
+	b.mvpLoc = gl.Call("getUniformLocation", program, "uMvpMatrix")
+	b.opacityLoc = gl.Call("getUniformLocation", program, "uOpacity")
+	b.texLoc = gl.Call("getAttribLocation", program, "aTexCoord").Int()
+	b.buffer = gl.Call("createBuffer")
+	return nil
+}
+
+// extent returns the scene-frame bounds of the georeferenced objects, or
+// false if there are none.
+func (b *BasemapPlane) extent() (lo, hi [3]float64, ok bool) {
+	for _, o := range b.viewer.scene.Objects() {
+		if o.Cloud.CRS == "" || o.Cloud.Len() == 0 {
+			continue
+		}
+		olo, ohi := o.Cloud.Bounds()
+		for k := 0; k < 3; k++ {
+			if !ok || float64(olo[k]) < lo[k] {
+				lo[k] = float64(olo[k])
+			}
+			if !ok || float64(ohi[k]) > hi[k] {
+				hi[k] = float64(ohi[k])
+			}
+		}
+		ok = true
+	}
+	return lo, hi, ok
+}
+
+// build covers the georeferenced objects' extent with tiles, uploading a
+// quad per tile and loading the tiles not already loaded.
+func (b *BasemapPlane) build(gl js.Value) error {
+	lo, hi, ok := b.extent()
+	if !ok {
+		b.tiles = nil
+		return fmt.Errorf("no georeferenced objects")
+	}
+	toGeo, err := crs.NewReprojector(b.viewer.sceneFrame, crs.Frame{CRS: crs.WGS84})
+	if err != nil {
+		return err
+	}
+	fromGeo, err := crs.NewReprojector(crs.Frame{CRS: crs.WGS84}, b.viewer.sceneFrame)
+	if err != nil {
+		return err
+	}
+	z := lo[2]
+	if b.HeightSet {
+		z = b.Height - b.viewer.sceneFrame.Origin[2]
+	}
+	west, south, east, north := math.Inf(1), math.Inf(1), math.Inf(-1), math.Inf(-1)
+	for _, corner := range [][2]float64{{lo[0], lo[1]}, {hi[0], lo[1]}, {lo[0], hi[1]}, {hi[0], hi[1]}} {
+		p, err := toGeo.Point([3]float64{corner[0], corner[1], z})
+		if err != nil {
+			return err
+		}
+		west, east = min(west, p[0]), max(east, p[0])
+		south, north = min(south, p[1]), max(north, p[1])
+	}
+
+	tiles := basemap.Cover(west, south, east, north, b.MaxTiles)
+	vertices := make([]float32, 0, len(tiles)*6*5)
+	b.tiles = b.tiles[:0]
+	used := map[basemap.Tile]bool{}
+	for _, t := range tiles {
+		w, s, e, n := t.Bounds()
+		var corners [4][3]float64 // north-west, north-east, south-west, south-east
+		for i, ll := range [4][2]float64{{w, n}, {e, n}, {w, s}, {e, s}} {
+			if corners[i], err = fromGeo.Point([3]float64{ll[0], ll[1], 0}); err != nil {
+				return err
+			}
+		}
+		uv := [4][2]float32{{0, 0}, {1, 0}, {0, 1}, {1, 1}}
+		for _, i := range []int{0, 2, 1, 1, 2, 3} {
+			c := corners[i]
+			vertices = append(vertices, float32(c[0]), float32(c[1]), float32(z), uv[i][0], uv[i][1])
+		}
+		b.tiles = append(b.tiles, b.texture(gl, t))
+		used[t] = true
+	}
+	for t, mt := range b.textures {
+		if !used[t] {
+			gl.Call("deleteTexture", mt.texture)
+			delete(b.textures, t)
+		}
+	}
+	gl.Call("bindBuffer", gl.Get("ARRAY_BUFFER"), b.buffer)
+	gl.Call("bufferData", gl.Get("ARRAY_BUFFER"), glf32.ToFloat32Array(vertices), gl.Get("STATIC_DRAW"))
+	return nil
+}
+
+// texture returns tile t's texture, starting to load it if it is new.
+func (b *BasemapPlane) texture(gl js.Value, t basemap.Tile) *mapTile {
+	if mt, ok := b.textures[t]; ok {
+		return mt
+	}
+	mt := &mapTile{tile: t, texture: gl.Call("createTexture")}
+	b.textures[t] = mt
+	image := js.Global().Get("Image").New()
+	var onLoad, onError js.Func
+	onLoad = js.FuncOf(func(this js.Value, args []js.Value) interface{} {
+		onLoad.Release()
+		onError.Release()
+		gl.Call("bindTexture", gl.Get("TEXTURE_2D"), mt.texture)
+		gl.Call("texImage2D", gl.Get("TEXTURE_2D"), 0, gl.Get("RGBA"), gl.Get("RGBA"), gl.Get("UNSIGNED_BYTE"), image)
+		gl.Call("texParameteri", gl.Get("TEXTURE_2D"), gl.Get("TEXTURE_MIN_FILTER"), gl.Get("LINEAR"))
+		gl.Call("texParameteri", gl.Get("TEXTURE_2D"), gl.Get("TEXTURE_WRAP_S"), gl.Get("CLAMP_TO_EDGE"))
+		gl.Call("texParameteri", gl.Get("TEXTURE_2D"), gl.Get("TEXTURE_WRAP_T"), gl.Get("CLAMP_TO_EDGE"))
+		gl.Call("bindTexture", gl.Get("TEXTURE_2D"), js.Null())
+		mt.loaded = true
+		requestRender()
+		return nil
+	})
+	onError = js.FuncOf(func(this js.Value, args []js.Value) interface{} {
+		onLoad.Release()
+		onError.Release()
+		js.Global().Get("console").Call("warn", "Basemap: could not load tile "+t.Path())
+		return nil
+	})
+	image.Set("onload", onLoad)
+	image.Set("onerror", onError)
+	image.Set("src", "/tiles/"+t.Path()+".png")
+	return mt
+}
+
+// draw draws the loaded tiles, rebuilding the quads first if the scene
+// frame, the data or the settings changed. It is called once a frame,
+// before the points, which draw over the plane where they touch it.
+func (b *BasemapPlane) draw(gl js.Value, viewProj glf32.Mat4) {
+	if !b.Visible || b.viewer.sceneFrame.CRS.IsZero() {
+		return
+	}
+	if err := b.setup(gl); err != nil {
+		js.Global().Get("console").Call("error", "basemap shader setup error: "+err.Error())
+		b.Visible = false
+		return
+	}
+	key := fmt.Sprint(b.viewer.sceneFrame, b.MaxTiles, b.Height, b.HeightSet) + b.viewer.sceneDataKey()
+	if key != b.key {
+		b.key = key
+		if err := b.build(gl); err != nil {
+			js.Global().Get("console").Call("warn", "Basemap: "+err.Error())
+		}
+	}
+	if len(b.tiles) == 0 {
+		return
+	}
+	gl.Call("useProgram", b.program)
+	gl.Call("uniformMatrix4fv", b.mvpLoc, false, glf32.ToFloat32Array(viewProj))
+	gl.Call("uniform1f", b.opacityLoc, b.Opacity)
+	gl.Call("enable", gl.Get("POLYGON_OFFSET_FILL"))
+	gl.Call("polygonOffset", 1, 1)
+	gl.Call("depthMask", b.Opacity >= 1)
+	gl.Call("bindBuffer", gl.Get("ARRAY_BUFFER"), b.buffer)
+	gl.Call("enableVertexAttribArray", b.texLoc)
+	gl.Call("vertexAttribPointer", attribPosition, 3, gl.Get("FLOAT"), false, 20, 0)
+	gl.Call("vertexAttribPointer", b.texLoc, 2, gl.Get("FLOAT"), false, 20, 12)
+	gl.Call("activeTexture", gl.Get("TEXTURE0"))
+	for i, mt := range b.tiles {
+		if !mt.loaded {
+			continue
+		}
+		gl.Call("bindTexture", gl.Get("TEXTURE_2D"), mt.texture)
+		gl.Call("drawArrays", gl.Get("TRIANGLES"), i*6, 6)
+	}
+	gl.Call("bindTexture", gl.Get("TEXTURE_2D"), js.Null())
+	gl.Call("disableVertexAttribArray", b.texLoc)
+	gl.Call("depthMask", true)
+	gl.Call("disable", gl.Get("POLYGON_OFFSET_FILL"))
+}
+
+// showBasemap(visible, params) shows or hides a ground plane of map tiles
+// under the georeferenced objects. params.maxTiles bounds the number of
+// tiles (default 16), which sets the zoom level; params.opacity sets the
+// plane's opacity (default 1); params.height sets its height in the scene
+// CRS (default: the lowest point of the data). Tiles are OpenStreetMap's
+// unless the server's TILE_URL says otherwise.
+//
+// Returns {error} if no georeferenced object has been added.
+func (v *Viewer) showBasemap(this js.Value, args []js.Value) interface{} {
+	visible := len(args) < 1 || args[0].Truthy()
+	if visible && v.sceneFrame.CRS.IsZero() {
+		return jsError("showBasemap: no georeferenced objects; see setObjectCRS")
+	}
+	if len(args) > 1 && args[1].Type() == js.TypeObject {
+		params := args[1]
+		if n := int(jsFloat(params, "maxTiles", float32(v.mapPlane.MaxTiles))); n >= 1 {
+			v.mapPlane.MaxTiles = n
+		}
+		v.mapPlane.Opacity = max(0, min(1, jsFloat(params, "opacity", v.mapPlane.Opacity)))
+		if h := params.Get("height"); h.Type() == js.TypeNumber {
+			v.mapPlane.Height, v.mapPlane.HeightSet = h.Float(), true
+		}
+	}
+	v.mapPlane.Visible = visible
+	return nil
+}
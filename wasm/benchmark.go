@@ -0,0 +1,135 @@
+// wasm/benchmark.go
+package main
+
+import (
+	"fmt"
+	"strings"
+	"syscall/js"
+	"time"
+
+	"github.com/sbecker11/webgl-point-cloud/glf32"
+)
+
+// benchmarkResult is one row of the benchmark report.
+type benchmarkResult struct {
+	name       string
+	iterations int
+	elapsed    time.Duration
+	bytes      int // bytes processed per iteration, 0 if not meaningful
+}
+
+func (r benchmarkResult) perOp() time.Duration {
+	return r.elapsed / time.Duration(r.iterations)
+}
+
+func (r benchmarkResult) row() map[string]interface{} {
+	row := map[string]interface{}{
+		"benchmark":  r.name,
+		"iterations": r.iterations,
+		"totalMs":    float64(r.elapsed.Microseconds()) / 1000,
+		"perOpUs":    float64(r.perOp().Nanoseconds()) / 1000,
+	}
+	if r.bytes > 0 && r.elapsed > 0 {
+		row["MBps"] = float64(r.bytes*r.iterations) / r.elapsed.Seconds() / 1e6
+	}
+	return row
+}
+
+// timeIt runs fn iterations times and returns the elapsed time.
+func timeIt(iterations int, fn func()) time.Duration {
+	start := time.Now()
+	for i := 0; i < iterations; i++ {
+		fn()
+	}
+	return time.Since(start)
+}
+
+// runBenchmarkSuite measures the math, upload and draw paths in the
+// browser: MultiplyMatrices, TransformVertices, copying a float32 slice to
+// a JS typed array, uploading it with bufferData, and the cost of a draw
+// call. gl.finish is called after the GPU work so it is included.
+func (v *Viewer) runBenchmarkSuite(gl js.Value, shader *PointShader) []benchmarkResult {
+	var results []benchmarkResult
+
+	m1 := glf32.MultiplyMatrices(glf32.RotateY(0.3), glf32.Translate(1, 2, 3))
+	m2 := glf32.Perspective(45, 16.0/9.0, 0.1, 100)
+	const matrices = 100000
+	results = append(results, benchmarkResult{"MultiplyMatrices", matrices,
+		timeIt(matrices, func() { glf32.MultiplyMatrices(m1, m2) }), 0})
+
+	const numPoints = 1000000
+	coords := make([]float32, numPoints*3)
+	for i := range coords {
+		coords[i] = float32(i%1000) / 1000
+	}
+	results = append(results, benchmarkResult{"TransformVertices (1M points)", 10,
+		timeIt(10, func() { glf32.TransformVertices(coords, m1) }), len(coords) * 4})
+
+	results = append(results, benchmarkResult{"glf32.ToFloat32Array (1M points)", 10,
+		timeIt(10, func() { glf32.ToFloat32Array(coords) }), len(coords) * 4})
+
+	buffer := gl.Call("createBuffer")
+	gl.Call("bindBuffer", gl.Get("ARRAY_BUFFER"), buffer)
+	results = append(results, benchmarkResult{"bufferData upload (1M points)", 10,
+		timeIt(10, func() {
+			gl.Call("bufferData", gl.Get("ARRAY_BUFFER"), glf32.ToFloat32Array(coords), gl.Get("STATIC_DRAW"))
+			gl.Call("finish")
+		}), len(coords) * 4})
+
+	// Draw calls of a single point measure the per-call overhead of going
+	// through syscall/js and WebGL rather than GPU throughput.
+	gl.Call("useProgram", shader.program)
+	gl.Call("uniformMatrix4fv", shader.mvpLoc, false, glf32.ToFloat32Array(glf32.Identity()))
+	gl.Call("uniformMatrix4fv", shader.modelLoc, false, glf32.ToFloat32Array(glf32.Identity()))
+	if shader.frameBlock {
+		v.frameBlock.setViewProj(gl, glf32.Identity())
+	}
+	for name, loc := range shader.attributes {
+		gl.Call("disableVertexAttribArray", loc)
+		gl.Call("vertexAttrib1f", loc, attributeDefaults[name])
+	}
+	gl.Call("vertexAttribPointer", attribPosition, 3, gl.Get("FLOAT"), false, 0, 0)
+	gl.Call("enableVertexAttribArray", attribPosition)
+	const draws = 1000
+	elapsed := timeIt(1, func() {
+		for i := 0; i < draws; i++ {
+			gl.Call("drawArrays", gl.Get("POINTS"), 0, 1)
+		}
+		gl.Call("finish")
+	})
+	results = append(results, benchmarkResult{"drawArrays call (1 point)", draws, elapsed, 0})
+
+	gl.Call("deleteBuffer", buffer)
+	gl.Call("clear", gl.Get("COLOR_BUFFER_BIT").Int()|gl.Get("DEPTH_BUFFER_BIT").Int())
+	return results
+}
+
+// benchmarkReport runs the suite and prints its results to the console as
+// a plain text table, easy to paste into a review, returning the rows.
+func (v *Viewer) benchmarkReport(gl js.Value, shader *PointShader) []interface{} {
+	var rows []interface{}
+	var report strings.Builder
+	fmt.Fprintf(&report, "%-36s %10s %14s %10s\n", "benchmark", "ops", "time/op", "MB/s")
+	for _, r := range v.runBenchmarkSuite(gl, shader) {
+		row := r.row()
+		rows = append(rows, row)
+		throughput := "-"
+		if mbps, ok := row["MBps"]; ok {
+			throughput = fmt.Sprintf("%.0f", mbps)
+		}
+		fmt.Fprintf(&report, "%-36s %10d %14v %10s\n", r.name, r.iterations, r.perOp(), throughput)
+	}
+	js.Global().Get("console").Call("log", report.String())
+	return rows
+}
+
+// runBenchmarks() runs the benchmark suite (as ?benchmark=1 does at
+// startup), prints the report table to the console and returns its rows as
+// [{benchmark, iterations, totalMs, perOpUs, MBps}]. It blocks the page for
+// a few seconds.
+func (v *Viewer) runBenchmarks(this js.Value, args []js.Value) interface{} {
+	if v.benchmarkShader == nil {
+		return jsError("runBenchmarks: viewer not started")
+	}
+	return js.ValueOf(v.benchmarkReport(v.scene.gl, v.benchmarkShader))
+}
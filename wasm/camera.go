@@ -3,6 +3,7 @@ package main
 
 import (
 	"math"
+	"github.com/sbecker11/webgl-point-cloud/anim"
 	"github.com/sbecker11/webgl-point-cloud/glf32"
 )
 
@@ -11,9 +12,9 @@ type Camera struct {
 	rotationX        float32
 	rotationY        float32
 	zoom             float32
+	target           glf32.Vec3 // point orbited, moved by the minimap
 	velocityX        float32
 	velocityY        float32
-	damping          float32
 	isMouseDown      bool
 	lastMouseX       float64
 	lastMouseY       float64
@@ -21,6 +22,7 @@ type Camera struct {
 	maxRotationX     float32
 	minZoom          float32
 	maxZoom          float32
+	lastView         glf32.Mat4 // last valid view, kept if the pose goes bad
 }
 
 func NewCamera(distance float32) *Camera {
@@ -29,9 +31,9 @@ func NewCamera(distance float32) *Camera {
 		rotationX:    0.3, // Start with a slight tilt
 		rotationY:    -0.5, // Start with a slight rotation
 		zoom:         1.0,
+		target:       glf32.Vec3{0, 0, 0},
 		velocityX:    0,
 		velocityY:    0,
-		damping:      0.90,
 		isMouseDown:  false,
 		minRotationX: -math.Pi / 2 * 0.999, // Clamp just before the poles
 		maxRotationX: math.Pi / 2 * 0.999,
@@ -40,31 +42,63 @@ func NewCamera(distance float32) *Camera {
 	}
 }
 
+// Position returns the camera's position in world coordinates.
+func (c *Camera) Position() glf32.Vec3 {
+	return glf32.OrbitPosition(c.target, c.rotationY, c.rotationX, c.distance/c.zoom)
+}
+
+// SetPose places the camera at position looking at target, which becomes
+// the point it orbits. The zoom is kept and the distance set to match;
+// the tilt is clamped short of the poles. Inertia stops.
+func (c *Camera) SetPose(position, target glf32.Vec3) {
+	dx, dy, dz := float64(position[0]-target[0]), float64(position[1]-target[1]), float64(position[2]-target[2])
+	d := math.Sqrt(dx*dx + dy*dy + dz*dz)
+	if d == 0 {
+		return
+	}
+	c.target = glf32.Vec3{target[0], target[1], target[2]}
+	c.distance = float32(d) * c.zoom
+	c.rotationX = float32(math.Asin(dy / d))
+	c.rotationY = float32(math.Atan2(dx, dz))
+	c.wrapAngles()
+	c.clampRotation()
+	c.velocityX, c.velocityY = 0, 0
+}
+
 func (c *Camera) GetViewMatrix() glf32.Mat4 {
-	// Calculate camera position using spherical coordinates.
-	// This is the standard, stable way for an orbit camera.
-	effectiveDistance := c.distance / c.zoom
-	camX := effectiveDistance * float32(math.Sin(float64(c.rotationY))*math.Cos(float64(c.rotationX)))
-	camY := effectiveDistance * float32(math.Sin(float64(c.rotationX)))
-	camZ := effectiveDistance * float32(math.Cos(float64(c.rotationY))*math.Cos(float64(c.rotationX)))
-	position := glf32.Vec3{camX, camY, camZ}
-
-	// The world's up vector. Clamping rotationX prevents the camera's forward
-	// vector from becoming parallel to 'up', which is what caused all crashes.
-	up := glf32.Vec3{0, 1, 0}
-	target := glf32.Vec3{0, 0, 0}
-
-	// With the corrected LookAt function, this is now stable and reliable.
-	return glf32.LookAt(position, target, up)
+	// Orbit's up follows the tilt, so the view stays stable at the poles.
+	// A pose made non-finite from outside (a host page call, a project
+	// file) keeps the last good view rather than drawing nothing.
+	view := glf32.Orbit(c.target, c.rotationY, c.rotationX, c.distance/c.zoom)
+	if !glf32.IsFinite(view) {
+		if c.lastView == nil {
+			c.lastView = glf32.Orbit(glf32.Vec3{0, 0, 0}, 0, 0, 1)
+		}
+		return c.lastView
+	}
+	c.lastView = view
+	return view
 }
 
-func (c *Camera) ApplyInertia() {
+// minVelocity is the spin, in pixels per reference step, below which
+// inertia stops.
+const minVelocity = 1e-3
+
+// ApplyInertia keeps the camera spinning after a drag for dt seconds. The
+// spin and controls.Damping are per 60 Hz frame, and are integrated over
+// dt, so the spin slows the same way at any frame rate.
+func (c *Camera) ApplyInertia(dt float64) {
 	if !c.isMouseDown && (c.velocityX != 0 || c.velocityY != 0) {
-		c.rotationY += c.velocityX * 0.01
-		c.rotationX += c.velocityY * 0.01
+		travel := float32(anim.Travel(float64(controls.Damping), dt))
+		c.rotationY += c.velocityX * controls.RotateSpeed * travel
+		c.rotationX += c.velocityY * controls.RotateSpeed * travel
 		c.wrapAngles()
-		c.velocityX *= c.damping
-		c.velocityY *= c.damping
+		decay := float32(anim.Decay(float64(controls.Damping), dt))
+		c.velocityX *= decay
+		c.velocityY *= decay
+		if math.Abs(float64(c.velocityX)) < minVelocity && math.Abs(float64(c.velocityY)) < minVelocity {
+			c.velocityX, c.velocityY = 0, 0
+		}
 		c.clampRotation()
 	}
 }
@@ -102,19 +136,25 @@ func (c *Camera) HandleMouseMove(x, y float64) {
 	if !c.isMouseDown {
 		return
 	}
-	dx := x - c.lastMouseX
-	dy := y - c.lastMouseY
+	dx := float32(x - c.lastMouseX)
+	dy := float32(y - c.lastMouseY)
+	if controls.InvertX {
+		dx = -dx
+	}
+	if controls.InvertY {
+		dy = -dy
+	}
 
 	// Invert rotationY for intuitive horizontal rotation
 	// Add to rotationX for intuitive vertical rotation
-	c.rotationY -= float32(dx) * 0.01
-	c.rotationX += float32(dy) * 0.01
+	c.rotationY -= dx * controls.RotateSpeed
+	c.rotationX += dy * controls.RotateSpeed
 	c.wrapAngles()
 	c.clampRotation()
 
 	// Update velocity for inertia, matching the rotation direction
-	c.velocityX = -float32(dx) * 0.5
-	c.velocityY = float32(dy) * 0.5
+	c.velocityX = -dx * controls.Inertia
+	c.velocityY = dy * controls.Inertia
 
 	c.lastMouseX = x
 	c.lastMouseY = y
@@ -122,9 +162,9 @@ func (c *Camera) HandleMouseMove(x, y float64) {
 
 func (c *Camera) HandleMouseWheel(deltaY float64) {
 	if deltaY < 0 {
-		c.zoom *= 1.1
+		c.zoom *= controls.ZoomStep
 	} else {
-		c.zoom /= 1.1
+		c.zoom /= controls.ZoomStep
 	}
 	c.zoom = float32(math.Max(float64(c.minZoom), math.Min(float64(c.zoom), float64(c.maxZoom))))
 } 
\ No newline at end of file
@@ -0,0 +1,143 @@
+// wasm/camlink.go
+package main
+
+import (
+	"syscall/js"
+
+	"github.com/sbecker11/webgl-point-cloud/glf32"
+)
+
+// cameraLink is a group of viewers whose cameras move together, e.g. to
+// compare scans of a site before and after a change: orbiting, zooming or
+// panning in one moves the others the same way.
+type cameraLink struct {
+	viewers []*Viewer
+	target  bool        // whether the point orbited is linked too
+	last    cameraState // the camera last shared, as shared
+}
+
+// cameraLinks holds each linked viewer's link.
+var cameraLinks = map[*Viewer]*cameraLink{}
+
+// shared returns the part of camera state s the link shares.
+func (l *cameraLink) shared(s cameraState) cameraState {
+	if !l.target {
+		s.target = [3]float32{}
+	}
+	return s
+}
+
+// follow turns c to look as from does, from the same distance and zoom,
+// and stops its spin. With target set it orbits the same point too.
+func (c *Camera) follow(from *Camera, target bool) {
+	c.distance, c.rotationX, c.rotationY, c.zoom = from.distance, from.rotationX, from.rotationY, from.zoom
+	if target {
+		c.target = glf32.Vec3{from.target[0], from.target[1], from.target[2]}
+	}
+	c.velocityX, c.velocityY = 0, 0
+}
+
+// share moves the cameras linked to v's to follow it, if it moved since
+// the link's cameras were last shared. It is called every animation frame
+// of v.
+func (l *cameraLink) share(v *Viewer) {
+	from := v.camera
+	state := l.shared(from.state())
+	if state == l.last {
+		return
+	}
+	l.last = state
+	for _, o := range l.viewers {
+		if o != v {
+			o.camera.follow(from, l.target)
+			o.onDemand.dirty = true
+		}
+	}
+}
+
+// unlinkCamera removes v from its link, dropping the link if fewer than
+// two viewers are left in it.
+func unlinkCamera(v *Viewer) {
+	l := cameraLinks[v]
+	if l == nil {
+		return
+	}
+	delete(cameraLinks, v)
+	for i, o := range l.viewers {
+		if o == v {
+			l.viewers = append(l.viewers[:i:i], l.viewers[i+1:]...)
+			break
+		}
+	}
+	if len(l.viewers) < 2 {
+		for _, o := range l.viewers {
+			delete(cameraLinks, o)
+		}
+	}
+}
+
+// linkCameras(canvasIds, params) links the cameras of the viewers in the
+// canvases with the given ids (see createViewer), so that moving any of
+// them moves the rest the same way. They start from the first one's view.
+// A viewer is in one link at a time; linking it again moves it. params
+// may hold target (default true): false links the direction, distance and
+// zoom but not the point each camera orbits, for scans whose coordinates
+// differ.
+//
+// Returns {viewers, target} or {error}.
+func linkCameras(this js.Value, args []js.Value) interface{} {
+	if len(args) < 1 || args[0].Type() != js.TypeObject || args[0].Length() < 2 {
+		return jsError("linkCameras: expected (canvasIds, params) with at least two ids")
+	}
+	l := &cameraLink{target: true}
+	if len(args) > 1 {
+		if v := jsValue(args[1], "target"); v.Type() == js.TypeBoolean {
+			l.target = v.Bool()
+		}
+	}
+	ids := []interface{}{}
+	for i := 0; i < args[0].Length(); i++ {
+		id := args[0].Index(i).String()
+		v, ok := viewers[id]
+		if !ok {
+			return jsError("linkCameras: no viewer in canvas " + id)
+		}
+		for _, o := range l.viewers {
+			if o == v {
+				return jsError("linkCameras: " + id + " is given twice")
+			}
+		}
+		l.viewers = append(l.viewers, v)
+		ids = append(ids, id)
+	}
+	for _, v := range l.viewers {
+		unlinkCamera(v)
+		cameraLinks[v] = l
+	}
+	l.share(l.viewers[0])
+	return js.ValueOf(map[string]interface{}{"viewers": ids, "target": l.target})
+}
+
+// unlinkCameras(canvasIds) unlinks the cameras of the viewers in the
+// canvases with the given ids, or of every viewer if there are none, so
+// that each moves on its own again. Returns {unlinked}, the number of
+// viewers unlinked.
+func unlinkCameras(this js.Value, args []js.Value) interface{} {
+	var unlink []*Viewer
+	if len(args) > 0 && args[0].Type() == js.TypeObject {
+		for i := 0; i < args[0].Length(); i++ {
+			if v, ok := viewers[args[0].Index(i).String()]; ok && cameraLinks[v] != nil {
+				unlink = append(unlink, v)
+			}
+		}
+	} else {
+		for v := range cameraLinks {
+			unlink = append(unlink, v)
+		}
+	}
+	linked := len(cameraLinks)
+	for _, v := range unlink {
+		unlinkCamera(v)
+	}
+	return js.ValueOf(map[string]interface{}{"unlinked": linked - len(cameraLinks)})
+}
@@ -0,0 +1,112 @@
+// wasm/capabilities.go
+package main
+
+import (
+	"syscall/js"
+)
+
+// Capabilities are what the browser's WebGL supports, probed once at
+// startup so features can turn themselves off up front, with a notice,
+// instead of failing mid-frame.
+type Capabilities struct {
+	WebGL2           bool
+	ElementIndexUint bool    // 32-bit vertex indices, for meshes over 65,536 vertices
+	Instancing       bool    // instanced drawing
+	FloatTextures    bool    // drawing into float textures
+	FloatBlend       bool    // blending into float textures
+	VertexTextures   bool    // sampling textures in vertex shaders
+	DepthTextures    bool    // sampling depth buffers
+	MaxPointSize     float32 // largest point size drawn, in pixels
+	MaxTextureSize   int     // largest texture side, in texels
+	MaxVertexAttribs int
+}
+
+// probeCapabilities queries gl's version, extensions and limits.
+func (v *Viewer) probeCapabilities(gl js.Value) Capabilities {
+	c := Capabilities{WebGL2: isWebGL2(gl)}
+	c.ElementIndexUint = c.WebGL2 || !v.glExtension(gl, "OES_element_index_uint").IsNull()
+	c.Instancing = c.WebGL2 || !v.glExtension(gl, "ANGLE_instanced_arrays").IsNull()
+	c.FloatTextures = v.textureFormatError(gl, TextureFloat) == nil
+	c.FloatBlend = c.FloatTextures && (!c.WebGL2 || !v.glExtension(gl, "EXT_float_blend").IsNull())
+	c.DepthTextures = v.textureFormatError(gl, TextureDepth) == nil
+	c.VertexTextures = gl.Call("getParameter", gl.Get("MAX_VERTEX_TEXTURE_IMAGE_UNITS")).Int() > 0
+	if r := gl.Call("getParameter", gl.Get("ALIASED_POINT_SIZE_RANGE")); !r.IsNull() {
+		c.MaxPointSize = float32(r.Index(1).Float())
+	}
+	c.MaxTextureSize = gl.Call("getParameter", gl.Get("MAX_TEXTURE_SIZE")).Int()
+	c.MaxVertexAttribs = gl.Call("getParameter", gl.Get("MAX_VERTEX_ATTRIBS")).Int()
+	return c
+}
+
+// noticed holds the notices already shown, so each shows once.
+var noticed = map[string]bool{}
+
+// noticeBanner is the banner showing the latest notice, built on first
+// use.
+var noticeBanner = struct {
+	root  js.Value
+	timer js.Value
+}{root: js.Undefined(), timer: js.Undefined()}
+
+// notice tells the user, once per message, that a feature is unavailable
+// or limited: in the console, to notice listeners and in a banner at the
+// top of the page for a few seconds.
+func (v *Viewer) notice(message string) {
+	if noticed[message] {
+		return
+	}
+	noticed[message] = true
+	js.Global().Get("console").Call("warn", message)
+	v.emit(eventNotice, map[string]interface{}{"message": message})
+	doc := js.Global().Get("document")
+	b := &noticeBanner
+	if b.root.IsUndefined() {
+		b.root = doc.Call("createElement", "div")
+		b.root.Set("style", "position:fixed;top:12px;left:50%;transform:translateX(-50%);max-width:70%;"+
+			"padding:8px 14px;border-radius:6px;background:rgba(120,80,10,0.92);color:#fff;"+
+			"font:13px sans-serif;z-index:30;display:none")
+		doc.Get("body").Call("appendChild", b.root)
+	}
+	b.root.Set("textContent", message)
+	b.root.Get("style").Set("display", "block")
+	if !b.timer.IsUndefined() {
+		js.Global().Call("clearTimeout", b.timer)
+	}
+	var hide js.Func
+	hide = js.FuncOf(func(this js.Value, args []js.Value) interface{} {
+		hide.Release()
+		b.root.Get("style").Set("display", "none")
+		b.timer = js.Undefined()
+		return nil
+	})
+	b.timer = js.Global().Call("setTimeout", hide, 6000)
+}
+
+// pointSize returns size clamped to the largest point size WebGL draws,
+// noting once when it is clamped.
+func (v *Viewer) pointSize(size float32) float32 {
+	if v.caps.MaxPointSize > 0 && size > v.caps.MaxPointSize {
+		v.notice(msgf("notice.pointSize", v.caps.MaxPointSize))
+		return v.caps.MaxPointSize
+	}
+	return size
+}
+
+// getCapabilities() returns what the browser's WebGL supports, as probed
+// at startup: {webgl2, elementIndexUint, instancing, floatTextures,
+// floatBlend, vertexTextures, depthTextures, maxPointSize, maxTextureSize,
+// maxVertexAttribs}.
+func (v *Viewer) getCapabilities(this js.Value, args []js.Value) interface{} {
+	return js.ValueOf(map[string]interface{}{
+		"webgl2":           v.caps.WebGL2,
+		"elementIndexUint": v.caps.ElementIndexUint,
+		"instancing":       v.caps.Instancing,
+		"floatTextures":    v.caps.FloatTextures,
+		"floatBlend":       v.caps.FloatBlend,
+		"vertexTextures":   v.caps.VertexTextures,
+		"depthTextures":    v.caps.DepthTextures,
+		"maxPointSize":     v.caps.MaxPointSize,
+		"maxTextureSize":   v.caps.MaxTextureSize,
+		"maxVertexAttribs": v.caps.MaxVertexAttribs,
+	})
+}
@@ -0,0 +1,247 @@
+// wasm/classification.go
+package main
+
+import (
+	"fmt"
+	"syscall/js"
+
+	"github.com/sbecker11/webgl-point-cloud/glf32"
+	"github.com/sbecker11/webgl-point-cloud/pointcloud"
+)
+
+// ColorMode selects how the point shader colors points.
+type ColorMode int
+
+const (
+	// ColorModeRGB uses each point's stored color.
+	ColorModeRGB ColorMode = iota
+	// ColorModeClassification colors points by class from the class palette.
+	ColorModeClassification
+	// ColorModeIntensity maps each point's "intensity" attribute through
+	// the scalar colormap; points without one read 0.
+	ColorModeIntensity
+	// ColorModeHeight maps each point's y coordinate, in its object's own
+	// coordinates, through the scalar colormap.
+	ColorModeHeight
+	// ColorModeDistance maps each point's "distance" attribute, set by
+	// compareClouds, through the scalar colormap; points without one read
+	// 0.
+	ColorModeDistance
+	// ColorModeChange colors points by their "change" attribute, set by
+	// detectChanges: added green, removed red and moved yellow, with
+	// unchanged points dimmed.
+	ColorModeChange
+	// ColorModeSelections colors points by the last named selection
+	// holding them, in its color, with the other points dimmed.
+	ColorModeSelections
+	// ColorModeNormal colors points by the direction of their normal, its
+	// x, y and z mapped from [-1, 1] to red, green and blue; points without
+	// one are gray.
+	ColorModeNormal
+	// ColorModeCurvature maps each point's "curvature" attribute, set by
+	// estimateNormals, through the scalar colormap; points without one
+	// read 0.
+	ColorModeCurvature
+)
+
+var colorModeNames = map[string]ColorMode{
+	"rgb":            ColorModeRGB,
+	"classification": ColorModeClassification,
+	"intensity":      ColorModeIntensity,
+	"height":         ColorModeHeight,
+	"distance":       ColorModeDistance,
+	"change":         ColorModeChange,
+	"selections":     ColorModeSelections,
+	"normal":         ColorModeNormal,
+	"curvature":      ColorModeCurvature,
+}
+
+// parseColorMode returns the ColorMode with the given name.
+func parseColorMode(name string) (ColorMode, error) {
+	mode, ok := colorModeNames[name]
+	if !ok {
+		return ColorModeRGB, fmt.Errorf("unknown color mode %q", name)
+	}
+	return mode, nil
+}
+
+// colorModeName returns the name parseColorMode accepts for mode.
+func colorModeName(mode ColorMode) string {
+	for name, m := range colorModeNames {
+		if m == mode {
+			return name
+		}
+	}
+	return ""
+}
+
+// ClassStyle holds the per-class palette, visibility and names shared by
+// all objects, uploaded to the point shader as uniform arrays. An empty
+// name stands for the class's ASPRS name.
+type ClassStyle struct {
+	Mode    ColorMode
+	Colors  [pointcloud.MaxClasses][4]float32
+	Visible [pointcloud.MaxClasses]bool
+	Names   [pointcloud.MaxClasses]string
+	version int // counts palette edits, for views showing the palette
+}
+
+// newClassStyle returns a style with every class visible in the standard
+// ASPRS palette.
+func newClassStyle() *ClassStyle {
+	s := &ClassStyle{}
+	s.resetPalette()
+	return s
+}
+
+// resetPalette restores the standard colors and names and shows every
+// class.
+func (s *ClassStyle) resetPalette() {
+	for i := range s.Colors {
+		s.Colors[i] = pointcloud.ClassColor(uint8(i))
+		s.Visible[i] = true
+		s.Names[i] = ""
+	}
+	s.version++
+}
+
+// name returns the display name of class c.
+func (s *ClassStyle) name(c uint8) string {
+	if int(c) < pointcloud.MaxClasses && s.Names[c] != "" {
+		return s.Names[c]
+	}
+	return pointcloud.ClassName(c)
+}
+
+// apply uploads the style, with the named selections' segment colors, to
+// the point shader, which must be in use.
+func (s *ClassStyle) apply(gl js.Value, shader *PointShader, segments []float32) {
+	colors := make([]float32, 0, pointcloud.MaxClasses*4)
+	visible := make([]float32, pointcloud.MaxClasses)
+	for i := range s.Colors {
+		colors = append(colors, s.Colors[i][:]...)
+		if s.Visible[i] {
+			visible[i] = 1
+		}
+	}
+	gl.Call("uniform1f", shader.colorModeLoc, float32(s.Mode))
+	gl.Call("uniform4fv", shader.classColorsLoc, glf32.ToFloat32Array(colors))
+	gl.Call("uniform1fv", shader.classVisibleLoc, glf32.ToFloat32Array(visible))
+	gl.Call("uniform4fv", shader.segmentsLoc, glf32.ToFloat32Array(segments))
+}
+
+// setColorMode(mode) switches between "rgb", "classification",
+// "intensity", "height", "distance", "change", "selections", "normal" and
+// "curvature" coloring.
+func (v *Viewer) setColorMode(this js.Value, args []js.Value) interface{} {
+	if len(args) < 1 {
+		return jsError("setColorMode: expected (mode)")
+	}
+	mode, err := parseColorMode(args[0].String())
+	if err != nil {
+		return jsError("setColorMode: " + err.Error())
+	}
+	v.classStyle.Mode = mode
+	return nil
+}
+
+// setClassVisible(class, visible) shows or hides every point of a class,
+// e.g. setClassVisible(5, false) hides high vegetation.
+func (v *Viewer) setClassVisible(this js.Value, args []js.Value) interface{} {
+	if len(args) < 2 {
+		return jsError("setClassVisible: expected (class, visible)")
+	}
+	class := args[0].Int()
+	if class < 0 || class >= pointcloud.MaxClasses {
+		return jsError(fmt.Sprintf("setClassVisible: class must be in [0, %d)", pointcloud.MaxClasses))
+	}
+	v.classStyle.Visible[class] = args[1].Truthy()
+	v.classStyle.version++
+	return nil
+}
+
+// getClassCounts() returns {class: {name, count, visible}} for the classes
+// present in the scene's objects.
+func (v *Viewer) getClassCounts(this js.Value, args []js.Value) interface{} {
+	totals := map[uint8]int{}
+	for _, o := range v.scene.Objects() {
+		for class, n := range o.Cloud.ClassCounts() {
+			totals[class] += n
+		}
+	}
+	result := map[string]interface{}{}
+	for class, n := range totals {
+		visible := class >= pointcloud.MaxClasses || v.classStyle.Visible[class]
+		result[fmt.Sprint(class)] = map[string]interface{}{
+			"name":    v.classStyle.name(class),
+			"count":   n,
+			"visible": visible,
+		}
+	}
+	return js.ValueOf(result)
+}
+
+// classInfo returns {class, name, color, visible} for class c.
+func (v *Viewer) classInfo(c int) map[string]interface{} {
+	return map[string]interface{}{
+		"class":   c,
+		"name":    v.classStyle.name(uint8(c)),
+		"color":   colorArray(v.classStyle.Colors[c]),
+		"visible": v.classStyle.Visible[c],
+	}
+}
+
+// setClassStyle(class, style) edits the palette entry of a class: style
+// may hold color ([r, g, b] or [r, g, b, a] in [0, 1]), visible and name,
+// which renames the class in the panel and getClassCounts; an
+// empty name restores the ASPRS name. The palette is saved in projects.
+//
+// Returns {class, name, color, visible} or {error}.
+func (v *Viewer) setClassStyle(this js.Value, args []js.Value) interface{} {
+	if len(args) < 2 {
+		return jsError("setClassStyle: expected (class, style)")
+	}
+	class := args[0].Int()
+	if class < 0 || class >= pointcloud.MaxClasses {
+		return jsError(fmt.Sprintf("setClassStyle: class must be in [0, %d)", pointcloud.MaxClasses))
+	}
+	style := args[1]
+	if value := jsValue(style, "color"); !value.IsUndefined() {
+		c, err := jsColor(value)
+		if err != nil {
+			return jsError("setClassStyle: " + err.Error())
+		}
+		v.classStyle.Colors[class] = c
+	}
+	if value := jsValue(style, "visible"); !value.IsUndefined() {
+		v.classStyle.Visible[class] = value.Truthy()
+	}
+	if value := jsValue(style, "name"); !value.IsUndefined() {
+		v.classStyle.Names[class] = value.String()
+	}
+	v.classStyle.version++
+	if v.histogram != nil {
+		v.histogram.draw()
+	}
+	return js.ValueOf(v.classInfo(class))
+}
+
+// getClassPalette() returns the palette as an array of {class, name,
+// color, visible} for every class the viewer can style.
+func (v *Viewer) getClassPalette(this js.Value, args []js.Value) interface{} {
+	palette := make([]interface{}, pointcloud.MaxClasses)
+	for c := range palette {
+		palette[c] = v.classInfo(c)
+	}
+	return js.ValueOf(palette)
+}
+
+// resetClassPalette() restores the standard ASPRS colors and names and
+// shows every class.
+func (v *Viewer) resetClassPalette(this js.Value, args []js.Value) interface{} {
+	v.classStyle.resetPalette()
+	if v.histogram != nil {
+		v.histogram.draw()
+	}
+	return nil
+}
@@ -0,0 +1,216 @@
+// wasm/cluster.go
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"syscall/js"
+
+	"github.com/sbecker11/webgl-point-cloud/cluster"
+	"github.com/sbecker11/webgl-point-cloud/hull"
+	"github.com/sbecker11/webgl-point-cloud/mesh"
+	"github.com/sbecker11/webgl-point-cloud/pointcloud"
+	"github.com/sbecker11/webgl-point-cloud/spatial"
+)
+
+// clusterAttribute holds each point's cluster label, or cluster.Noise.
+var clusterAttribute = pointcloud.Attribute{Name: "cluster", Components: 1, Type: pointcloud.Float32}
+
+// clusterStats returns the stats of the object's clusters, in its own
+// coordinates, from its "cluster" attribute.
+func clusterStats(o *SceneObject) ([]cluster.Stats, error) {
+	_, values, ok := o.Cloud.Attribute(clusterAttribute.Name)
+	if !ok {
+		return nil, fmt.Errorf("%s has not been clustered", o.Cloud.Name)
+	}
+	labels := make([]int32, len(values))
+	for i, v := range values {
+		labels[i] = int32(v)
+	}
+	return cluster.Summarize(o.Cloud.Coords, o.Cloud.Colors, labels), nil
+}
+
+// clusterBoxes returns the bounding boxes of stats as one mesh, with the
+// wireframe edges outlining each box.
+func clusterBoxes(stats []cluster.Stats) (*mesh.Mesh, []uint32) {
+	m := &mesh.Mesh{}
+	var edges []uint32
+	for _, s := range stats {
+		size := s.Size()
+		box := hull.Box{
+			Axes:     [3][3]float64{{1, 0, 0}, {0, 1, 0}, {0, 0, 1}},
+			HalfSize: [3]float64{size[0] / 2, size[1] / 2, size[2] / 2},
+		}
+		for k := 0; k < 3; k++ {
+			box.Center[k] = (s.Min[k] + s.Max[k]) / 2
+		}
+		b := box.Mesh()
+		base := uint32(m.VertexCount())
+		m.Positions = append(m.Positions, b.Positions...)
+		for _, i := range b.Indices {
+			m.Indices = append(m.Indices, base+i)
+		}
+		for _, i := range hull.BoxEdges {
+			edges = append(edges, base+i)
+		}
+	}
+	return m, edges
+}
+
+// clusterPoints(name, params) splits an object's drawn points into
+// clusters by Euclidean clustering: points within params.radius of each
+// other, directly or through a chain of points, share a cluster. The
+// radius defaults to about twice the point spacing. Clusters of fewer than
+// params.minPoints points (default 10) are noise. Each point gets a
+// "cluster" attribute, its label from 0 in order of decreasing size, or -1
+// for noise and hidden points. Unless params.showBoxes is false, each
+// cluster's bounding box is drawn in a wireframe mesh named name +
+// "-clusters" in params.color, labelled with its number and point count.
+//
+// Returns {clusters, noise, radius, mesh} or {error}.
+func (v *Viewer) clusterPoints(this js.Value, args []js.Value) interface{} {
+	if len(args) < 1 {
+		return jsError("clusterPoints: expected (name, params)")
+	}
+	o := v.scene.Object(args[0].String())
+	if o == nil {
+		return jsError("clusterPoints: no object named " + args[0].String())
+	}
+	params := js.Undefined()
+	if len(args) > 1 {
+		params = args[1]
+	}
+	var coords []float32
+	var indices []int
+	for i, drawn := range v.selectable(o) {
+		if drawn {
+			coords = append(coords, o.Cloud.Coords[i*3:i*3+3]...)
+			indices = append(indices, i)
+		}
+	}
+	radius := float64(jsFloat(params, "radius", 0))
+	if radius <= 0 {
+		radius = spatial.AutoCellSize(coords)
+	}
+	labels, n, err := cluster.Euclidean(coords, radius, int(jsFloat(params, "minPoints", 10)))
+	if err != nil {
+		return jsError("clusterPoints: " + err.Error())
+	}
+	values := make([]float32, o.Cloud.Len())
+	for i := range values {
+		values[i] = cluster.Noise
+	}
+	noise := 0
+	for j, l := range labels {
+		values[indices[j]] = float32(l)
+		if l == cluster.Noise {
+			noise++
+		}
+	}
+	v.scene.SetAttribute(o, clusterAttribute, values)
+
+	meshName := o.Cloud.Name + "-clusters"
+	v.scene.RemoveMesh(meshName)
+	if value := jsValue(params, "showBoxes"); (value.IsUndefined() || value.Truthy()) && n > 0 {
+		stats, _ := clusterStats(o)
+		m, edges := clusterBoxes(stats)
+		sm, err := v.addMeasureMesh(o, meshName, m, params)
+		if err == nil {
+			err = v.scene.SetMeshEdges(sm, edges)
+		}
+		if err != nil {
+			return jsError("clusterPoints: " + err.Error())
+		}
+		positions := make([][3]float32, len(stats))
+		texts := make([]string, len(stats))
+		for i, s := range stats {
+			positions[i] = [3]float32{float32(s.Centroid[0]), float32(s.Max[1]), float32(s.Centroid[2])}
+			texts[i] = fmt.Sprintf("#%d · %d pts", s.Label, s.Points)
+		}
+		v.setMeshLabels(meshName, positions, texts)
+	} else {
+		meshName = ""
+	}
+	return js.ValueOf(map[string]interface{}{
+		"clusters": n,
+		"noise":    noise,
+		"radius":   radius,
+		"mesh":     meshName,
+	})
+}
+
+// clusterTable returns the object's cluster stats as JSON or CSV text.
+func clusterTable(o *SceneObject, format string) (string, error) {
+	stats, err := clusterStats(o)
+	if err != nil {
+		return "", err
+	}
+	switch format {
+	case "", "json":
+		data, err := json.Marshal(stats)
+		return string(data), err
+	case "csv":
+		var b strings.Builder
+		err := cluster.WriteCSV(&b, stats)
+		return b.String(), err
+	}
+	return "", fmt.Errorf("unknown format %q", format)
+}
+
+// getClusterStats(name, format) returns the stats of an object's
+// clusters, from its last clusterPoints, in its own coordinates: with
+// format "json" (default) an array of {label, points, centroid, min, max,
+// meanColor}, with "csv" a CSV table with one row per cluster.
+func (v *Viewer) getClusterStats(this js.Value, args []js.Value) interface{} {
+	if len(args) < 1 {
+		return jsError("getClusterStats: expected (name, format)")
+	}
+	o := v.scene.Object(args[0].String())
+	if o == nil {
+		return jsError("getClusterStats: no object named " + args[0].String())
+	}
+	format := ""
+	if len(args) > 1 && args[1].Type() == js.TypeString {
+		format = args[1].String()
+	}
+	text, err := clusterTable(o, format)
+	if err != nil {
+		return jsError("getClusterStats: " + err.Error())
+	}
+	if format == "csv" {
+		return text
+	}
+	return js.Global().Get("JSON").Call("parse", text)
+}
+
+// downloadClusterStats(name, format, filename) offers the table of
+// getClusterStats as a download, by default named name + "-clusters.json"
+// or ".csv".
+func (v *Viewer) downloadClusterStats(this js.Value, args []js.Value) interface{} {
+	if len(args) < 1 {
+		return jsError("downloadClusterStats: expected (name, format, filename)")
+	}
+	o := v.scene.Object(args[0].String())
+	if o == nil {
+		return jsError("downloadClusterStats: no object named " + args[0].String())
+	}
+	format := "json"
+	if len(args) > 1 && args[1].Type() == js.TypeString {
+		format = args[1].String()
+	}
+	text, err := clusterTable(o, format)
+	if err != nil {
+		return jsError("downloadClusterStats: " + err.Error())
+	}
+	filename := o.Cloud.Name + "-clusters." + format
+	if len(args) > 2 && args[2].Type() == js.TypeString {
+		filename = args[2].String()
+	}
+	mimeType := "application/json"
+	if format == "csv" {
+		mimeType = "text/csv"
+	}
+	downloadText(filename, mimeType, text)
+	return nil
+}
@@ -0,0 +1,168 @@
+// wasm/compare.go
+package main
+
+import (
+	"math"
+	"syscall/js"
+
+	"github.com/sbecker11/webgl-point-cloud/compare"
+	"github.com/sbecker11/webgl-point-cloud/glf32"
+	"github.com/sbecker11/webgl-point-cloud/pointcloud"
+	"github.com/sbecker11/webgl-point-cloud/spatial"
+)
+
+// worldCoords returns a copy of the object's coordinates transformed by
+// its effective model matrix.
+func (v *Viewer) worldCoords(o *SceneObject) []float32 {
+	_, _, model := v.scene.Effective(o)
+	return v.transformed(o.Cloud.Coords, model, false)
+}
+
+// worldNormals returns a copy of the object's normals rotated by its
+// effective model matrix and renormalized, or nil if it has none. Normals
+// are only exact for models without non-uniform scaling.
+func (v *Viewer) worldNormals(o *SceneObject) []float32 {
+	if o.Cloud.Normals == nil {
+		return nil
+	}
+	_, _, model := v.scene.Effective(o)
+	return normalizeVectors(v.transformed(o.Cloud.Normals, model, true))
+}
+
+// normalizeVectors scales each vector of v, in place, to unit length and
+// returns v.
+func normalizeVectors(v []float32) []float32 {
+	for i := 0; i+2 < len(v); i += 3 {
+		n := glf32.Normalize(glf32.Vec3{v[i], v[i+1], v[i+2]})
+		copy(v[i:i+3], n[:])
+	}
+	return v
+}
+
+// compareClouds(name, reference, params) sets a "distance" attribute on
+// every point of the named object: the distance, in world units, to the
+// nearest point of the reference object. With params.signed the distance
+// is negative behind the reference surface, judged by its normals, which it
+// must have. Unless params.colorize is false the viewer switches to the
+// "distance" color mode, with the diverging colormap spanning the
+// distances, symmetric about zero when signed.
+//
+// Returns {name, reference, signed, points, min, max, mean, rms} or
+// {error}. The distances can be filtered on as the "distance" variable.
+func (v *Viewer) compareClouds(this js.Value, args []js.Value) interface{} {
+	if len(args) < 2 {
+		return jsError("compareClouds: expected (name, reference, params)")
+	}
+	o := v.scene.Object(args[0].String())
+	if o == nil {
+		return jsError("compareClouds: no object named " + args[0].String())
+	}
+	ref := v.scene.Object(args[1].String())
+	if ref == nil {
+		return jsError("compareClouds: no object named " + args[1].String())
+	}
+	if ref.Cloud.Len() == 0 {
+		return jsError("compareClouds: the reference has no points")
+	}
+	params := js.Undefined()
+	if len(args) > 2 {
+		params = args[2]
+	}
+	signed := jsValue(params, "signed").Truthy()
+	distances, err := compare.Distances(v.worldCoords(o), v.worldCoords(ref), v.worldNormals(ref), signed)
+	if err != nil {
+		return jsError("compareClouds: " + err.Error())
+	}
+	v.scene.SetAttribute(o, pointcloud.Attribute{Name: "distance", Components: 1, Type: pointcloud.Float32}, distances)
+
+	s := compare.Summarize(distances)
+	if value := jsValue(params, "colorize"); value.IsUndefined() || value.Truthy() {
+		r := v.scalarStyle.modes[ColorModeDistance]
+		r.Auto, r.Min, r.Max = false, 0, float32(s.Max)
+		if signed {
+			extent := float32(math.Max(math.Abs(s.Min), math.Abs(s.Max)))
+			r.Min, r.Max = -extent, extent
+		}
+		v.classStyle.Mode = ColorModeDistance
+	}
+	v.scalarStyle.autoKey = ""
+	if v.histogram != nil {
+		v.histogram.key = ""
+	}
+	return js.ValueOf(map[string]interface{}{
+		"name":      o.Cloud.Name,
+		"reference": ref.Cloud.Name,
+		"signed":    signed,
+		"points":    s.Count,
+		"min":       s.Min,
+		"max":       s.Max,
+		"mean":      s.Mean,
+		"rms":       s.RMS,
+	})
+}
+
+// changeAttribute holds the compare.Change of each point.
+var changeAttribute = pointcloud.Attribute{Name: "change", Components: 1, Type: pointcloud.Uint8}
+
+// detectChanges(before, after, params) compares two frames of a time
+// series, such as two scans of a site or two sensor frames, by the
+// occupancy of octree cells over both. It sets a "change" attribute on the
+// points of each: 0 unchanged, 1 added (after only), 2 removed (before
+// only) or 3 moved, an addition or removal within params.moveCells cells
+// (default 1) of the opposite. params.cellSize sets the cell size, by
+// default about twice the spacing of before's points. Unless
+// params.colorize is false the viewer switches to the "change" color mode.
+//
+// Returns {before, after, cellSize, unchanged, added, removed, moved} or
+// {error}.
+func (v *Viewer) detectChanges(this js.Value, args []js.Value) interface{} {
+	if len(args) < 2 {
+		return jsError("detectChanges: expected (before, after, params)")
+	}
+	before := v.scene.Object(args[0].String())
+	if before == nil {
+		return jsError("detectChanges: no object named " + args[0].String())
+	}
+	after := v.scene.Object(args[1].String())
+	if after == nil {
+		return jsError("detectChanges: no object named " + args[1].String())
+	}
+	params := js.Undefined()
+	if len(args) > 2 {
+		params = args[2]
+	}
+	beforeCoords, afterCoords := v.worldCoords(before), v.worldCoords(after)
+	cellSize := float64(jsFloat(params, "cellSize", 0))
+	if cellSize <= 0 {
+		cellSize = spatial.AutoCellSize(beforeCoords)
+	}
+	moveCells := int(jsFloat(params, "moveCells", 1))
+	beforeChanges, afterChanges, err := compare.Changes(beforeCoords, afterCoords, cellSize, moveCells)
+	if err != nil {
+		return jsError("detectChanges: " + err.Error())
+	}
+	counts := map[compare.Change]int{}
+	for _, frame := range []struct {
+		o       *SceneObject
+		changes []compare.Change
+	}{{before, beforeChanges}, {after, afterChanges}} {
+		values := make([]float32, len(frame.changes))
+		for i, c := range frame.changes {
+			values[i] = float32(c)
+			counts[c]++
+		}
+		v.scene.SetAttribute(frame.o, changeAttribute, values)
+	}
+	if value := jsValue(params, "colorize"); value.IsUndefined() || value.Truthy() {
+		v.classStyle.Mode = ColorModeChange
+	}
+	return js.ValueOf(map[string]interface{}{
+		"before":    before.Cloud.Name,
+		"after":     after.Cloud.Name,
+		"cellSize":  cellSize,
+		"unchanged": counts[compare.Unchanged],
+		"added":     counts[compare.Added],
+		"removed":   counts[compare.Removed],
+		"moved":     counts[compare.Moved],
+	})
+}
@@ -0,0 +1,233 @@
+// wasm/config.go
+package main
+
+import (
+	"strconv"
+	"syscall/js"
+	"time"
+
+	"github.com/sbecker11/webgl-point-cloud/demo"
+	"github.com/sbecker11/webgl-point-cloud/viewlink"
+)
+
+// Config holds viewer settings read from the page URL query string,
+// e.g. wasm/index.html?dataset=torus&seed=42&points=5000.
+type Config struct {
+	// Seed seeds the procedural generators. The same seed always produces
+	// the same scene. When no seed is given, one is derived from the clock.
+	Seed int64
+	// NumPoints is the number of points generated for the dataset
+	// (per cluster for the "clusters" dataset).
+	NumPoints int
+	// Dataset names the procedural dataset to display; see procgen.DatasetNames.
+	Dataset string
+	// ColorMode selects how points are colored ("rgb", "classification",
+	// "intensity" or "height").
+	ColorMode ColorMode
+	// Filter is a filter expression hiding the points that fail it,
+	// e.g. "z < 0.5 && class != 2"; see package filter.
+	Filter string
+	// Panel shows the built-in control panel.
+	Panel bool
+	// Adaptive turns on the adaptive quality controller.
+	Adaptive bool
+	// Coords shows the coordinate HUD for the point under the cursor.
+	Coords bool
+	// Benchmark runs the benchmark suite at startup and prints its report
+	// to the console.
+	Benchmark bool
+	// Demo names the demo scene to show, see package demo. It sets the
+	// dataset, points, seed and color, which other parameters override, and
+	// the camera, view and effects.
+	Demo string
+	// OnDemand turns on the render-on-demand mode.
+	OnDemand bool
+	// Messages is the URL of a JSON message catalog localizing the
+	// viewer's text; see setMessages.
+	Messages string
+	// View is the view in the URL's fragment, a shared link's; its
+	// dataset, points, seed and color override the parameters'.
+	View viewlink.View
+	// Link keeps the URL's fragment in step with the view.
+	Link bool
+	// Origin is the origin of the page the viewer may be embedded in and
+	// take requests from, or "*" for any page's; if empty the viewer
+	// takes no requests from the page embedding it. See setupEmbed.
+	Origin string
+	// Budget is the most points drawn each frame, shared among the parts
+	// of the scene by their size on screen; every point if 0. See
+	// setPointBudget.
+	Budget int
+	// Positions is where objects keep their positions on the GPU; see
+	// setPositionStorage.
+	Positions PositionStorage
+	// WebGL2 draws with WebGL 2 where the browser has it, sharing each
+	// frame's view and light among the point programs in a uniform buffer;
+	// see FrameBlock.
+	WebGL2 bool
+	// Translucency is how translucent objects blend; see setTranslucency.
+	Translucency TranslucencyMode
+	// Reveal is how many milliseconds arriving points take to fade in, or
+	// 0 to show them at once; see setRevealAnimation.
+	Reveal float64
+}
+
+// defaultConfig returns the settings used when the URL does not override them.
+func defaultConfig() Config {
+	return Config{
+		Seed:      time.Now().UnixNano(),
+		NumPoints: 5000,
+		Dataset:   "clusters",
+		Link:      true,
+	}
+}
+
+// loadConfig reads the configuration from window.location.search and the
+// view from its hash. Malformed values are reported to the console and
+// ignored.
+func loadConfig() Config {
+	cfg := defaultConfig()
+	params := js.Global().Get("URLSearchParams").New(js.Global().Get("location").Get("search"))
+
+	if s := queryParam(params, "demo"); s != "" {
+		if d, ok := demo.Lookup(s); ok {
+			cfg.Demo, cfg.Dataset, cfg.NumPoints, cfg.Seed = s, d.Dataset, d.Points, d.Seed
+			if mode, err := parseColorMode(d.Color); err == nil {
+				cfg.ColorMode = mode
+			}
+		} else {
+			js.Global().Get("console").Call("warn", "Ignoring unknown demo: "+s)
+		}
+	}
+	if s := queryParam(params, "seed"); s != "" {
+		if seed, err := strconv.ParseInt(s, 10, 64); err == nil {
+			cfg.Seed = seed
+		} else {
+			js.Global().Get("console").Call("warn", "Ignoring invalid seed: "+s)
+		}
+	}
+	if s := queryParam(params, "points"); s != "" {
+		if n, err := strconv.Atoi(s); err == nil && n > 0 {
+			cfg.NumPoints = n
+		} else {
+			js.Global().Get("console").Call("warn", "Ignoring invalid points: "+s)
+		}
+	}
+	if s := queryParam(params, "dataset"); s != "" {
+		cfg.Dataset = s
+	}
+	if s := queryParam(params, "color"); s != "" {
+		if mode, err := parseColorMode(s); err == nil {
+			cfg.ColorMode = mode
+		} else {
+			js.Global().Get("console").Call("warn", "Ignoring invalid color: "+s)
+		}
+	}
+	if s := queryParam(params, "budget"); s != "" {
+		if n, err := strconv.Atoi(s); err == nil && n >= 0 && n <= maxPointBudget {
+			cfg.Budget = n
+		} else {
+			js.Global().Get("console").Call("warn", "Ignoring invalid budget: "+s)
+		}
+	}
+	if s := queryParam(params, "positions"); s != "" {
+		if storage, err := parsePositionStorage(s); err == nil {
+			cfg.Positions = storage
+		} else {
+			js.Global().Get("console").Call("warn", "Ignoring invalid positions: "+s)
+		}
+	}
+	if s := queryParam(params, "translucency"); s != "" {
+		if mode, err := parseTranslucencyMode(s); err == nil {
+			cfg.Translucency = mode
+		} else {
+			js.Global().Get("console").Call("warn", "Ignoring invalid translucency: "+s)
+		}
+	}
+	if s := queryParam(params, "reveal"); s != "" {
+		if ms, err := strconv.ParseFloat(s, 64); err == nil && ms >= 0 && ms <= maxRevealMs {
+			cfg.Reveal = ms
+		} else {
+			js.Global().Get("console").Call("warn", "Ignoring invalid reveal: "+s)
+		}
+	}
+	if s := queryParam(params, "webgl2"); s != "" {
+		if webgl2, err := strconv.ParseBool(s); err == nil {
+			cfg.WebGL2 = webgl2
+		} else {
+			js.Global().Get("console").Call("warn", "Ignoring invalid webgl2: "+s)
+		}
+	}
+	cfg.Filter = queryParam(params, "filter")
+	cfg.Messages = queryParam(params, "messages")
+	cfg.Origin = queryParam(params, "origin")
+	if s := queryParam(params, "panel"); s != "" {
+		if panel, err := strconv.ParseBool(s); err == nil {
+			cfg.Panel = panel
+		} else {
+			js.Global().Get("console").Call("warn", "Ignoring invalid panel: "+s)
+		}
+	}
+	if s := queryParam(params, "adaptive"); s != "" {
+		if adaptive, err := strconv.ParseBool(s); err == nil {
+			cfg.Adaptive = adaptive
+		} else {
+			js.Global().Get("console").Call("warn", "Ignoring invalid adaptive: "+s)
+		}
+	}
+	if s := queryParam(params, "coords"); s != "" {
+		if coords, err := strconv.ParseBool(s); err == nil {
+			cfg.Coords = coords
+		} else {
+			js.Global().Get("console").Call("warn", "Ignoring invalid coords: "+s)
+		}
+	}
+	if s := queryParam(params, "ondemand"); s != "" {
+		if onDemand, err := strconv.ParseBool(s); err == nil {
+			cfg.OnDemand = onDemand
+		} else {
+			js.Global().Get("console").Call("warn", "Ignoring invalid ondemand: "+s)
+		}
+	}
+	if s := queryParam(params, "benchmark"); s != "" {
+		if benchmark, err := strconv.ParseBool(s); err == nil {
+			cfg.Benchmark = benchmark
+		} else {
+			js.Global().Get("console").Call("warn", "Ignoring invalid benchmark: "+s)
+		}
+	}
+	if s := queryParam(params, "link"); s != "" {
+		if link, err := strconv.ParseBool(s); err == nil {
+			cfg.Link = link
+		} else {
+			js.Global().Get("console").Call("warn", "Ignoring invalid link: "+s)
+		}
+	}
+	if v, err := viewlink.Parse(js.Global().Get("location").Get("hash").String()); err == nil {
+		cfg.View = v
+		if v.Dataset != "" {
+			cfg.Dataset = v.Dataset
+		}
+		if v.Points > 0 {
+			cfg.NumPoints = v.Points
+		}
+		if v.Seed != 0 {
+			cfg.Seed = v.Seed
+		}
+		if mode, err := parseColorMode(v.Color); err == nil {
+			cfg.ColorMode = mode
+		}
+	} else {
+		js.Global().Get("console").Call("warn", "Ignoring invalid view link: "+err.Error())
+	}
+	return cfg
+}
+
+// queryParam returns the named URL parameter, or "" if it is absent.
+func queryParam(params js.Value, name string) string {
+	v := params.Call("get", name)
+	if v.IsNull() {
+		return ""
+	}
+	return v.String()
+}
@@ -0,0 +1,180 @@
+// wasm/contextmenu.go
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"syscall/js"
+
+	"github.com/sbecker11/webgl-point-cloud/cluster"
+	"github.com/sbecker11/webgl-point-cloud/glf32"
+)
+
+// ContextMenu is the menu of actions on the point, or the ground, under
+// the cursor that right-clicking the canvas opens.
+type ContextMenu struct {
+	viewer *Viewer
+	root   js.Value
+	fns    []js.Func // the open menu's click handlers, released when it closes
+}
+
+// menuItem is an entry of the context menu.
+type menuItem struct {
+	label  string
+	action func()
+}
+
+// setupContextMenu closes the menu when the user clicks elsewhere, presses
+// Escape or scrolls the canvas.
+func (v *Viewer) setupContextMenu(canvas js.Value) {
+	doc := js.Global().Get("document")
+	doc.Call("addEventListener", "pointerdown", js.FuncOf(func(this js.Value, args []js.Value) interface{} {
+		if !v.contextMenu.root.IsUndefined() && !v.contextMenu.root.Call("contains", args[0].Get("target")).Bool() {
+			v.contextMenu.close()
+		}
+		return nil
+	}), true)
+	doc.Call("addEventListener", "keydown", js.FuncOf(func(this js.Value, args []js.Value) interface{} {
+		if args[0].Get("key").String() == "Escape" {
+			v.contextMenu.close()
+		}
+		return nil
+	}))
+	canvas.Call("addEventListener", "wheel", js.FuncOf(func(this js.Value, args []js.Value) interface{} {
+		v.contextMenu.close()
+		return nil
+	}))
+}
+
+// contextMenuItems returns the actions that apply at canvas position
+// (x, y) in the last frame: those on the drawn point under it, if any,
+// and those on the world position under it.
+func (v *Viewer) contextMenuItems(x, y float32) []menuItem {
+	if v.lastViewProj == nil {
+		return nil
+	}
+	width, height := v.canvasSize()
+	var items []menuItem
+	o, i := v.snapping.nearest(x, y, width, height)
+	var at [3]float64
+	if o != nil {
+		_, _, model := v.scene.Effective(o)
+		p := glf32.TransformVertices(append([]float32(nil), o.Cloud.Coords[i*3:i*3+3]...), model)
+		at = [3]float64{float64(p[0]), float64(p[1]), float64(p[2])}
+		items = append(items, menuItem{msg("menu.setPivot"), func() {
+			v.camera.SetPose(v.camera.Position(), v.view.exaggerate(at))
+		}})
+	} else if p, ok := v.worldAt(x, y, width, height); ok {
+		at = p
+	} else {
+		return nil
+	}
+	items = append(items, menuItem{msg("menu.measure"), func() {
+		v.setTool(&MeasureTool{viewer: v, down: true, measured: true, a: at, b: at})
+	}})
+	if o != nil {
+		if _, labels, ok := o.Cloud.Attribute(clusterAttribute.Name); ok && labels[i] != cluster.Noise {
+			label := labels[i]
+			items = append(items, menuItem{msgf("menu.hideCluster", label), func() {
+				v.hideCluster(o, label)
+			}})
+		}
+	}
+	copied, what := at, msg("menu.copy")
+	if o != nil {
+		if q, ok := sourcePosition(o, i); ok {
+			copied, what = q, msgf("menu.copyIn", o.SourceCRS.String())
+		}
+	}
+	items = append(items, menuItem{what, func() {
+		copyText(formatPosition(copied))
+	}})
+	return items
+}
+
+// hideCluster hides the points of o's cluster label, as the filter does,
+// until the filter or o's points change.
+func (v *Viewer) hideCluster(o *SceneObject, label float32) {
+	_, labels, ok := o.Cloud.Attribute(clusterAttribute.Name)
+	if !ok {
+		return
+	}
+	mask := make([]bool, o.Cloud.Len())
+	for i := range mask {
+		mask[i] = (o.Mask == nil || o.Mask[i]) && labels[i] != label
+	}
+	v.scene.setMask(o, mask)
+}
+
+// formatPosition returns p as "x, y, z" with the fewest digits that keep
+// its float32 value.
+func formatPosition(p [3]float64) string {
+	s := ""
+	for i, v := range p {
+		if i > 0 {
+			s += ", "
+		}
+		s += strconv.FormatFloat(v, 'f', -1, 32)
+	}
+	return s
+}
+
+// copyText puts text on the clipboard, which browsers allow only in
+// secure contexts.
+func copyText(text string) {
+	clipboard := js.Global().Get("navigator").Get("clipboard")
+	if clipboard.IsUndefined() {
+		js.Global().Get("console").Call("warn", "The clipboard is unavailable; coordinates: "+text)
+		return
+	}
+	clipboard.Call("writeText", text)
+}
+
+// open shows the actions at the canvas position of pointer event e beside
+// the cursor, replacing any menu already open. It reports false if there
+// are none.
+func (m *ContextMenu) open(canvas, e js.Value) bool {
+	m.close()
+	items := m.viewer.contextMenuItems(canvasPoint(canvas, e))
+	if len(items) == 0 {
+		return false
+	}
+	doc := js.Global().Get("document")
+	m.root = doc.Call("createElement", "div")
+	m.root.Set("style", "position:fixed;padding:4px 0;border-radius:6px;min-width:160px;"+
+		"background:rgba(20,20,30,0.95);color:#eee;font:13px sans-serif;box-shadow:0 2px 8px rgba(0,0,0,0.5);z-index:20")
+	for _, item := range items {
+		button := doc.Call("createElement", "div")
+		button.Set("textContent", item.label)
+		button.Set("style", "padding:5px 14px;cursor:pointer;white-space:nowrap")
+		action := item.action
+		listen(button, "click", &m.fns, func(js.Value) {
+			m.close()
+			action()
+		})
+		listen(button, "pointerenter", &m.fns, func(b js.Value) { b.Get("style").Set("background", "rgba(255,255,255,0.12)") })
+		listen(button, "pointerleave", &m.fns, func(b js.Value) { b.Get("style").Set("background", "") })
+		m.root.Call("appendChild", button)
+	}
+	doc.Get("body").Call("appendChild", m.root)
+	// Keep the menu inside the window.
+	left := min(e.Get("clientX").Float(), js.Global().Get("innerWidth").Float()-m.root.Get("offsetWidth").Float())
+	top := min(e.Get("clientY").Float(), js.Global().Get("innerHeight").Float()-m.root.Get("offsetHeight").Float())
+	style := m.root.Get("style")
+	style.Set("left", fmt.Sprintf("%.0fpx", max(0, left)))
+	style.Set("top", fmt.Sprintf("%.0fpx", max(0, top)))
+	return true
+}
+
+// close removes the menu, if it is open.
+func (m *ContextMenu) close() {
+	if m.root.IsUndefined() {
+		return
+	}
+	m.root.Call("remove")
+	m.root = js.Undefined()
+	for _, f := range m.fns {
+		f.Release()
+	}
+	m.fns = nil
+}
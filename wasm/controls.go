@@ -0,0 +1,162 @@
+// wasm/controls.go
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"syscall/js"
+)
+
+// controlsStorageKey is the localStorage key the control settings are
+// saved under.
+const controlsStorageKey = "webgl-point-cloud.controls"
+
+// ControlSettings are the user's preferences for steering the camera.
+type ControlSettings struct {
+	RotateSpeed float32 `json:"rotateSpeed"` // radians turned per pixel dragged
+	Inertia     float32 `json:"inertia"`     // spin kept after a drag, per pixel of its last step
+	ZoomStep    float32 `json:"zoomStep"`    // zoom factor per wheel step, above 1
+	Damping     float32 `json:"damping"`     // fraction of the spin kept each 60th of a second, in [0, 1)
+	InvertX     bool    `json:"invertX"`     // turn the other way when dragging sideways
+	InvertY     bool    `json:"invertY"`     // tilt the other way when dragging up and down
+	SwapButtons bool    `json:"swapButtons"` // orbit with the right button instead of the left
+}
+
+// defaultControlSettings returns the settings used until the user changes
+// them.
+func defaultControlSettings() ControlSettings {
+	return ControlSettings{RotateSpeed: 0.01, Inertia: 0.5, ZoomStep: 1.1, Damping: 0.9}
+}
+
+// controls holds the current settings.
+var controls = defaultControlSettings()
+
+// validate reports settings out of range.
+func (s ControlSettings) validate() error {
+	switch {
+	case s.RotateSpeed <= 0:
+		return fmt.Errorf("rotateSpeed must be positive")
+	case s.Inertia < 0:
+		return fmt.Errorf("inertia must not be negative")
+	case s.ZoomStep <= 1:
+		return fmt.Errorf("zoomStep must be above 1")
+	case s.Damping < 0 || s.Damping >= 1:
+		return fmt.Errorf("damping must be in [0, 1)")
+	}
+	return nil
+}
+
+// orbitButton returns the MouseEvent.button that orbits the camera.
+func (s ControlSettings) orbitButton() int {
+	if s.SwapButtons {
+		return 2
+	}
+	return 0
+}
+
+// localStorage returns window.localStorage, or undefined where storage is
+// unavailable, e.g. disabled by the browser's privacy settings.
+func localStorage() (storage js.Value) {
+	defer func() {
+		if recover() != nil {
+			storage = js.Undefined()
+		}
+	}()
+	return js.Global().Get("localStorage")
+}
+
+// loadControlSettings reads the saved settings, keeping the defaults if
+// there are none or they are malformed.
+func loadControlSettings() {
+	storage := localStorage()
+	if storage.IsUndefined() || storage.IsNull() {
+		return
+	}
+	saved := storage.Call("getItem", controlsStorageKey)
+	if saved.Type() != js.TypeString {
+		return
+	}
+	s := defaultControlSettings()
+	if err := json.Unmarshal([]byte(saved.String()), &s); err != nil || s.validate() != nil {
+		js.Global().Get("console").Call("warn", "Ignoring invalid saved control settings")
+		return
+	}
+	controls = s
+}
+
+// saveControlSettings writes the settings to localStorage, if there is
+// one.
+func saveControlSettings() {
+	storage := localStorage()
+	if storage.IsUndefined() || storage.IsNull() {
+		return
+	}
+	data, _ := json.Marshal(controls)
+	storage.Call("setItem", controlsStorageKey, string(data))
+}
+
+// controlSettingsJS returns the settings as a JS object.
+func controlSettingsJS() js.Value {
+	return js.ValueOf(map[string]interface{}{
+		"rotateSpeed": controls.RotateSpeed,
+		"inertia":     controls.Inertia,
+		"zoomStep":    controls.ZoomStep,
+		"damping":     controls.Damping,
+		"invertX":     controls.InvertX,
+		"invertY":     controls.InvertY,
+		"swapButtons": controls.SwapButtons,
+	})
+}
+
+// setControlSettings(params) changes how the camera responds to the mouse
+// and saves the settings in localStorage for later visits. params may set
+// any of rotateSpeed (radians per pixel dragged, default 0.01), inertia
+// (how much spin a drag leaves, per pixel of its last step, default 0.5; 0
+// for none), zoomStep (zoom factor per wheel step, default 1.1), damping
+// (fraction of the spin kept each 60th of a second, at any frame rate,
+// default 0.9), invertX and invertY
+// (reverse dragging sideways or up and down) and swapButtons (orbit with
+// the right button instead of the left); others keep their values.
+//
+// Returns the settings as getControlSettings does, or {error}.
+func setControlSettings(this js.Value, args []js.Value) interface{} {
+	if len(args) < 1 || args[0].Type() != js.TypeObject {
+		return jsError("setControlSettings: expected ({rotateSpeed, inertia, zoomStep, damping, invertX, invertY, swapButtons})")
+	}
+	params := args[0]
+	s := controls
+	s.RotateSpeed = jsFloat(params, "rotateSpeed", s.RotateSpeed)
+	s.Inertia = jsFloat(params, "inertia", s.Inertia)
+	s.ZoomStep = jsFloat(params, "zoomStep", s.ZoomStep)
+	s.Damping = jsFloat(params, "damping", s.Damping)
+	for _, flag := range []struct {
+		name string
+		v    *bool
+	}{{"invertX", &s.InvertX}, {"invertY", &s.InvertY}, {"swapButtons", &s.SwapButtons}} {
+		if v := jsValue(params, flag.name); v.Type() == js.TypeBoolean {
+			*flag.v = v.Bool()
+		}
+	}
+	if err := s.validate(); err != nil {
+		return jsError("setControlSettings: " + err.Error())
+	}
+	controls = s
+	saveControlSettings()
+	return controlSettingsJS()
+}
+
+// getControlSettings() returns the camera control settings as
+// {rotateSpeed, inertia, zoomStep, damping, invertX, invertY, swapButtons}.
+func getControlSettings(this js.Value, args []js.Value) interface{} {
+	return controlSettingsJS()
+}
+
+// resetControlSettings() restores the default control settings and
+// forgets the saved ones. Returns them as getControlSettings does.
+func resetControlSettings(this js.Value, args []js.Value) interface{} {
+	controls = defaultControlSettings()
+	if storage := localStorage(); !storage.IsUndefined() && !storage.IsNull() {
+		storage.Call("removeItem", controlsStorageKey)
+	}
+	return controlSettingsJS()
+}
@@ -0,0 +1,22 @@
+// wasm/demo.go
+package main
+
+import (
+	"github.com/sbecker11/webgl-point-cloud/demo"
+	"github.com/sbecker11/webgl-point-cloud/glf32"
+)
+
+// applyDemo sets up the camera, view and effects of demo scene s, whose
+// dataset is already loaded.
+func (v *Viewer) applyDemo(s demo.Scene) {
+	p, t := s.Camera.Position, s.Camera.Target
+	v.camera.SetPose(glf32.Vec3{p[0], p[1], p[2]}, glf32.Vec3{t[0], t[1], t[2]})
+	v.view.PointSize = s.PointSize
+	v.view.Background = s.Background
+	if s.Exaggeration > 0 {
+		v.view.Exaggeration = s.Exaggeration
+	}
+	v.view.ShowGrid, v.view.ShowAxes = s.Grid, s.Grid
+	v.ssao.Enabled = s.SSAO
+	v.contactShadow.Visible = s.Shadow
+}
@@ -0,0 +1,127 @@
+// wasm/depthpick.go
+package main
+
+import (
+	"syscall/js"
+
+	"github.com/sbecker11/webgl-point-cloud/glf32"
+	"github.com/sbecker11/webgl-point-cloud/pick"
+)
+
+// usePointShader puts the point shader in use with the view's styles, at
+// pointSize.
+func (v *Viewer) usePointShader(gl js.Value, shader *PointShader, pointSize float32) {
+	gl.Call("useProgram", shader.program)
+	gl.Call("uniform1f", shader.pointSizeLoc, pointSize)
+	v.classStyle.apply(gl, shader, v.namedSelectionColors())
+	v.scalarStyle.apply(gl, shader)
+	v.light.applyPoints(gl, shader, v.camera)
+}
+
+// drawPointDepth draws the first fraction of the points' packed depth into
+// the bound framebuffer at pointSize, seen through viewProj, with shader,
+// a point shader linked with depth.frag.
+func (v *Viewer) drawPointDepth(gl js.Value, shader *PointShader, viewProj glf32.Mat4, pointSize float32, fraction float64) {
+	gl.Call("useProgram", shader.program)
+	gl.Call("uniform1f", shader.pointSizeLoc, pointSize)
+	v.classStyle.apply(gl, shader, v.namedSelectionColors())
+	v.scene.DrawPoints(shader, viewProj, fraction)
+}
+
+// DepthPicker finds world positions under the cursor by drawing the
+// points' depth into an offscreen framebuffer and reading back the pixels
+// around the cursor. It costs one extra draw of the points per query,
+// however many points there are, unlike searching them on the CPU.
+type DepthPicker struct {
+	viewer *Viewer
+	shader *PointShader
+	target *Framebuffer
+}
+
+// setup compiles the depth program on first use and sizes the framebuffer
+// to width by height pixels.
+func (d *DepthPicker) setup(gl js.Value, width, height int) error {
+	if d.shader == nil {
+		shader, err := d.viewer.shaders.point(gl, "depth.frag", d.viewer.baseFeatures())
+		if err != nil {
+			return err
+		}
+		d.shader = shader
+	}
+	if d.target == nil {
+		target, err := d.viewer.newFramebuffer(gl, width, height, TextureRGBA8, DepthRenderbuffer)
+		if err != nil {
+			return err
+		}
+		d.target = target
+	}
+	d.target.resize(gl, width, height)
+	return nil
+}
+
+// worldAt returns the world position of the nearest drawn point within
+// pickRadius of canvas position (x, y), drawn as in the last frame, and its
+// depth in [0, 1]. It reports false when there is none.
+func (d *DepthPicker) worldAt(x, y float32) (pos glf32.Vec3, depth float64, ok bool) {
+	if d.viewer.lastViewProj == nil {
+		return nil, 0, false
+	}
+	inv, ok := glf32.Invert(d.viewer.lastViewProj)
+	if !ok {
+		return nil, 0, false
+	}
+	gl := d.viewer.scene.gl
+	width, height := gl.Get("drawingBufferWidth").Int(), gl.Get("drawingBufferHeight").Int()
+	if err := d.setup(gl, width, height); err != nil {
+		js.Global().Get("console").Call("error", "depth shader setup error: "+err.Error())
+		return nil, 0, false
+	}
+
+	d.target.bind(gl)
+	gl.Call("disable", gl.Get("BLEND"))
+	gl.Call("clearColor", 0, 0, 0, 0)
+	gl.Call("clear", gl.Get("COLOR_BUFFER_BIT").Int()|gl.Get("DEPTH_BUFFER_BIT").Int())
+	d.viewer.drawPointDepth(gl, d.shader, d.viewer.lastViewProj, d.viewer.pointSize(d.viewer.view.PointSize), 1)
+
+	// Read the window of pixels around the cursor, in GL coordinates with
+	// y up.
+	side := 2*pickRadius + 1
+	gx, gy := int(x), height-1-int(y)
+	data := d.target.readPixels(gl, gx-pickRadius, gy-pickRadius, side, side)
+	gl.Call("bindFramebuffer", gl.Get("FRAMEBUFFER"), js.Null())
+	gl.Call("enable", gl.Get("BLEND"))
+
+	dx, dy, depth, ok := pick.NearestDepth(data, side, pickRadius)
+	if !ok {
+		return nil, 0, false
+	}
+	ndc := glf32.Vec3{
+		2*(float32(gx+dx)+0.5)/float32(width) - 1,
+		2*(float32(gy+dy)+0.5)/float32(height) - 1,
+		2*float32(depth) - 1,
+	}
+	pos, ok = glf32.Unproject(inv, ndc)
+	return pos, depth, ok
+}
+
+// worldPositionAt(x, y) returns the world position of the nearest drawn
+// point within a few pixels of (x, y), in CSS pixels from the canvas's
+// top-left corner, as {position, depth}, or null if there is none. It
+// reads back depth rather than searching the points, so it stays fast on
+// large clouds; positions are accurate to the depth buffer's precision.
+func (v *Viewer) worldPositionAt(this js.Value, args []js.Value) interface{} {
+	if len(args) < 2 {
+		return jsError("worldPositionAt: expected (x, y)")
+	}
+	canvas := v.canvas
+	scaleX := canvas.Get("width").Float() / canvas.Get("clientWidth").Float()
+	scaleY := canvas.Get("height").Float() / canvas.Get("clientHeight").Float()
+	pos, depth, ok := v.depthPicker.worldAt(float32(args[0].Float()*scaleX), float32(args[1].Float()*scaleY))
+	if !ok {
+		return js.Null()
+	}
+	return js.ValueOf(map[string]interface{}{
+		"position": []interface{}{pos[0], pos[1], pos[2]},
+		"depth":    depth,
+	})
+}
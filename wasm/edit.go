@@ -0,0 +1,492 @@
+// wasm/edit.go
+package main
+
+import (
+	"fmt"
+	"syscall/js"
+
+	"github.com/sbecker11/webgl-point-cloud/edit"
+	"github.com/sbecker11/webgl-point-cloud/filter"
+	"github.com/sbecker11/webgl-point-cloud/glf32"
+	"github.com/sbecker11/webgl-point-cloud/pointcloud"
+)
+
+// maxUndo bounds the number of edits kept for undo.
+const maxUndo = 20
+
+// inScene reports whether o is still in the scene. Commands skip objects
+// removed or replaced since they ran.
+func (v *Viewer) inScene(o *SceneObject) bool {
+	return v.scene.Object(o.Cloud.Name) == o
+}
+
+// cloudCommand replaces the clouds of one or more objects, e.g. to delete
+// points, keeping the old clouds for undo.
+type cloudCommand struct {
+	viewer  *Viewer
+	desc    string
+	objects []*SceneObject
+	before  []*pointcloud.Cloud
+	after   []*pointcloud.Cloud
+}
+
+// add records a change of o's cloud to cloud.
+func (c *cloudCommand) add(o *SceneObject, cloud *pointcloud.Cloud) {
+	c.objects = append(c.objects, o)
+	c.before = append(c.before, o.Cloud)
+	c.after = append(c.after, cloud)
+}
+
+func (c *cloudCommand) set(clouds []*pointcloud.Cloud) {
+	for i, o := range c.objects {
+		if c.viewer.inScene(o) {
+			c.viewer.scene.SetCloud(o, clouds[i])
+		}
+	}
+}
+
+func (c *cloudCommand) Do()            { c.set(c.after) }
+func (c *cloudCommand) Undo()          { c.set(c.before) }
+func (c *cloudCommand) String() string { return c.desc }
+
+// transformCommand changes an object's model matrix.
+type transformCommand struct {
+	viewer        *Viewer
+	object        *SceneObject
+	before, after glf32.Mat4
+}
+
+func (c *transformCommand) Do() {
+	if c.viewer.inScene(c.object) {
+		c.object.Model = c.after
+	}
+}
+
+func (c *transformCommand) Undo() {
+	if c.viewer.inScene(c.object) {
+		c.object.Model = c.before
+	}
+}
+
+func (c *transformCommand) String() string { return "transform " + c.object.Cloud.Name }
+
+// bakeCommand moves an object's model matrix into its points.
+type bakeCommand struct {
+	cloud     cloudCommand
+	transform transformCommand
+}
+
+func (c *bakeCommand) Do() {
+	c.cloud.Do()
+	c.transform.Do()
+}
+
+func (c *bakeCommand) Undo() {
+	c.cloud.Undo()
+	c.transform.Undo()
+}
+
+func (c *bakeCommand) String() string { return "apply transform " + c.transform.object.Cloud.Name }
+
+// selectable returns which points of o can be selected: those drawn, i.e.
+// passing the filter and in a visible class.
+func (v *Viewer) selectable(o *SceneObject) []bool {
+	visible, _, _ := v.scene.Effective(o)
+	out := make([]bool, o.Cloud.Len())
+	for i := range out {
+		out[i] = visible && (o.Mask == nil || o.Mask[i])
+		if out[i] && o.Cloud.Classes != nil {
+			class := o.Cloud.Classes[i]
+			out[i] = class >= pointcloud.MaxClasses || v.classStyle.Visible[class]
+		}
+	}
+	return out
+}
+
+// editTargets returns the named object, or every object when name is "".
+func (v *Viewer) editTargets(name string) ([]*SceneObject, error) {
+	if name == "" {
+		return v.scene.Objects(), nil
+	}
+	if o := v.scene.Object(name); o != nil {
+		return []*SceneObject{o}, nil
+	}
+	return nil, fmt.Errorf("no object named %s", name)
+}
+
+// applySelection combines sel, restricted to the selectable points, with
+// each target's selection and returns the total number of selected points.
+func (v *Viewer) applySelection(targets []*SceneObject, sels [][]bool, mode edit.Mode) int {
+	total := 0
+	for k, o := range targets {
+		sel := edit.Combine(v.selectable(o), sels[k], edit.Intersect)
+		combined := edit.Combine(o.Selection, sel, mode)
+		if edit.Count(combined) == 0 {
+			combined = nil
+		}
+		v.scene.SetSelection(o, combined)
+		total += edit.Count(combined)
+	}
+	v.emitSelectionChanged()
+	return total
+}
+
+// selectionParams reads the optional {name, mode} selection parameters.
+func (v *Viewer) selectionParams(args []js.Value, i int) (targets []*SceneObject, mode edit.Mode, err error) {
+	params := js.Undefined()
+	if len(args) > i {
+		params = args[i]
+	}
+	if mode, err = edit.ParseMode(jsString(params, "mode", "replace")); err != nil {
+		return nil, mode, err
+	}
+	targets, err = v.editTargets(jsString(params, "name", ""))
+	return targets, mode, err
+}
+
+// selectBox(min, max, params) selects the drawn points inside the world-space
+// box from min to max ([x, y, z] arrays). params is optional and may hold
+// name (restrict to one object) and mode ("replace", "add", "subtract" or
+// "intersect"; default "replace").
+//
+// Returns {selected} or {error}.
+func (v *Viewer) selectBox(this js.Value, args []js.Value) interface{} {
+	if len(args) < 2 {
+		return jsError("selectBox: expected (min, max, params)")
+	}
+	min, err := jsVec3(args[0])
+	if err != nil {
+		return jsError("selectBox: min: " + err.Error())
+	}
+	max, err := jsVec3(args[1])
+	if err != nil {
+		return jsError("selectBox: max: " + err.Error())
+	}
+	targets, mode, err := v.selectionParams(args, 2)
+	if err != nil {
+		return jsError("selectBox: " + err.Error())
+	}
+	sels := make([][]bool, len(targets))
+	for k, o := range targets {
+		_, _, model := v.scene.Effective(o)
+		sels[k] = edit.SelectBox(o.Cloud, model, edit.Box{Min: min, Max: max})
+	}
+	return js.ValueOf(map[string]interface{}{"selected": v.applySelection(targets, sels, mode)})
+}
+
+// selectWhere(expr, params) selects the drawn points matching a filter
+// expression, e.g. selectWhere("class == 7"). params is as for selectBox.
+//
+// Returns {selected} or {error}.
+func (v *Viewer) selectWhere(this js.Value, args []js.Value) interface{} {
+	if len(args) < 1 {
+		return jsError("selectWhere: expected (expr, params)")
+	}
+	e, err := filter.Parse(args[0].String())
+	if err != nil {
+		return jsError("selectWhere: " + err.Error())
+	}
+	targets, mode, err := v.selectionParams(args, 1)
+	if err != nil {
+		return jsError("selectWhere: " + err.Error())
+	}
+	sels := make([][]bool, len(targets))
+	for k, o := range targets {
+		if sels[k], err = e.Mask(filter.CloudSource(o.Cloud)); err != nil {
+			return jsError(fmt.Sprintf("selectWhere: %s: %v", o.Cloud.Name, err))
+		}
+	}
+	return js.ValueOf(map[string]interface{}{"selected": v.applySelection(targets, sels, mode)})
+}
+
+// clearSelection() deselects every point.
+func (v *Viewer) clearSelection(this js.Value, args []js.Value) interface{} {
+	for _, o := range v.scene.Objects() {
+		v.scene.SetSelection(o, nil)
+	}
+	v.emitSelectionChanged()
+	return nil
+}
+
+// invertSelection(params) selects the drawn points that are not selected
+// and deselects the rest. params is optional and may hold name to invert
+// one object's selection only.
+//
+// Returns {selected} or {error}.
+func (v *Viewer) invertSelection(this js.Value, args []js.Value) interface{} {
+	targets, _, err := v.selectionParams(args, 0)
+	if err != nil {
+		return jsError("invertSelection: " + err.Error())
+	}
+	sels := make([][]bool, len(targets))
+	for k, o := range targets {
+		sels[k] = edit.Invert(o.Selection, o.Cloud.Len())
+	}
+	return js.ValueOf(map[string]interface{}{"selected": v.applySelection(targets, sels, edit.Replace)})
+}
+
+// reshapeSelection replaces each target's selection with fn applied to
+// its drawn points in world coordinates and their selection.
+func (v *Viewer) reshapeSelection(targets []*SceneObject, fn func(coords []float32, sel []bool) []bool) int {
+	sels := make([][]bool, len(targets))
+	for k, o := range targets {
+		var coords []float32
+		var sel []bool
+		var indices []int
+		for i, drawn := range v.selectable(o) {
+			if drawn {
+				coords = append(coords, o.Cloud.Coords[i*3:i*3+3]...)
+				sel = append(sel, o.Selection != nil && o.Selection[i])
+				indices = append(indices, i)
+			}
+		}
+		_, _, model := v.scene.Effective(o)
+		sels[k] = make([]bool, o.Cloud.Len())
+		for j, s := range fn(v.transformed(coords, model, false), sel) {
+			sels[k][indices[j]] = s
+		}
+	}
+	return v.applySelection(targets, sels, edit.Replace)
+}
+
+// growSelection(radius, params) adds the drawn points within radius, in
+// world units, of a selected point to the selection, e.g. to take in the
+// rest of a surface partly selected. params is as for invertSelection.
+//
+// Returns {selected} or {error}.
+func (v *Viewer) growSelection(this js.Value, args []js.Value) interface{} {
+	return v.resizeSelection("growSelection", args, edit.Grow)
+}
+
+// shrinkSelection(radius, params) deselects the selected points within
+// radius, in world units, of a drawn point that is not selected, peeling
+// the selection back from its edges. params is as for invertSelection.
+//
+// Returns {selected} or {error}.
+func (v *Viewer) shrinkSelection(this js.Value, args []js.Value) interface{} {
+	return v.resizeSelection("shrinkSelection", args, edit.Shrink)
+}
+
+// resizeSelection implements growSelection and shrinkSelection.
+func (v *Viewer) resizeSelection(name string, args []js.Value, fn func(coords []float32, sel []bool, radius float64) []bool) interface{} {
+	if len(args) < 1 || args[0].Type() != js.TypeNumber || !(args[0].Float() > 0) {
+		return jsError(name + ": expected (radius, params) with a positive radius")
+	}
+	radius := args[0].Float()
+	targets, _, err := v.selectionParams(args, 1)
+	if err != nil {
+		return jsError(name + ": " + err.Error())
+	}
+	selected := v.reshapeSelection(targets, func(coords []float32, sel []bool) []bool {
+		return fn(coords, sel, radius)
+	})
+	return js.ValueOf(map[string]interface{}{"selected": selected})
+}
+
+// deleteSelected() removes the selected points from their objects,
+// compacting and re-uploading the objects' buffers. The deletion can be
+// undone with undo(). Bound to the Delete key.
+//
+// Returns {deleted}.
+func (v *Viewer) deleteSelected(this js.Value, args []js.Value) interface{} {
+	deleted := 0
+	cmd := &cloudCommand{viewer: v}
+	for _, o := range v.scene.Objects() {
+		if n := edit.Count(o.Selection); n > 0 {
+			cmd.add(o, edit.Delete(o.Cloud, o.Selection))
+			deleted += n
+		}
+	}
+	if deleted > 0 {
+		cmd.desc = fmt.Sprintf("delete %d points", deleted)
+		v.history.Execute(cmd)
+		v.emitSelectionChanged()
+	}
+	return js.ValueOf(map[string]interface{}{"deleted": deleted})
+}
+
+// crop(params) permanently discards the points outside a region of
+// interest, as an undoable edit. With params {min, max} ([x, y, z] arrays)
+// the region is that world-space box; otherwise each object is cropped to
+// its current selection, and objects without a selection are left alone.
+// params may also hold name to crop a single object.
+//
+// Returns {removed} or {error}.
+func (v *Viewer) crop(this js.Value, args []js.Value) interface{} {
+	params := js.Undefined()
+	if len(args) > 0 {
+		params = args[0]
+	}
+	targets, err := v.editTargets(jsString(params, "name", ""))
+	if err != nil {
+		return jsError("crop: " + err.Error())
+	}
+	var box *edit.Box
+	if !jsValue(params, "min").IsUndefined() || !jsValue(params, "max").IsUndefined() {
+		min, err := jsVec3(jsValue(params, "min"))
+		if err != nil {
+			return jsError("crop: min: " + err.Error())
+		}
+		max, err := jsVec3(jsValue(params, "max"))
+		if err != nil {
+			return jsError("crop: max: " + err.Error())
+		}
+		box = &edit.Box{Min: min, Max: max}
+	}
+
+	removed := 0
+	cmd := &cloudCommand{viewer: v}
+	for _, o := range targets {
+		var cropped *pointcloud.Cloud
+		switch {
+		case box != nil:
+			_, _, model := v.scene.Effective(o)
+			cropped = edit.Crop(o.Cloud, model, *box)
+		case o.Selection != nil:
+			cropped = edit.Keep(o.Cloud, o.Selection)
+		default:
+			continue
+		}
+		if n := o.Cloud.Len() - cropped.Len(); n > 0 {
+			cmd.add(o, cropped)
+			removed += n
+		}
+	}
+	if removed > 0 {
+		cmd.desc = fmt.Sprintf("crop %d points", removed)
+		v.history.Execute(cmd)
+		v.emitSelectionChanged()
+	}
+	return js.ValueOf(map[string]interface{}{"removed": removed})
+}
+
+// undo() reverts the most recent edit. Bound to Ctrl+Z.
+//
+// Returns {undone} describing the edit, or {error} if there is nothing to
+// undo.
+func (v *Viewer) undo(this js.Value, args []js.Value) interface{} {
+	c, ok := v.history.Undo()
+	if !ok {
+		return jsError("undo: nothing to undo")
+	}
+	v.emitSelectionChanged()
+	return js.ValueOf(map[string]interface{}{"undone": c.String()})
+}
+
+// redo() re-applies the most recently undone edit. Bound to Ctrl+Y and
+// Ctrl+Shift+Z.
+//
+// Returns {redone} describing the edit, or {error} if there is nothing to
+// redo.
+func (v *Viewer) redo(this js.Value, args []js.Value) interface{} {
+	c, ok := v.history.Redo()
+	if !ok {
+		return jsError("redo: nothing to redo")
+	}
+	v.emitSelectionChanged()
+	return js.ValueOf(map[string]interface{}{"redone": c.String()})
+}
+
+// getHistory() returns {undo, redo}, the descriptions of the edits that
+// can be undone and redone, most recent first.
+func (v *Viewer) getHistory(this js.Value, args []js.Value) interface{} {
+	toJS := func(names []string) []interface{} {
+		out := make([]interface{}, len(names))
+		for i, n := range names {
+			out[i] = n
+		}
+		return out
+	}
+	return js.ValueOf(map[string]interface{}{
+		"undo": toJS(v.history.UndoNames()),
+		"redo": toJS(v.history.RedoNames()),
+	})
+}
+
+// setTransform(name, matrix) sets the model matrix of the named object from
+// 16 numbers in column-major order, as an undoable edit.
+//
+// Returns {name} or {error}.
+func (v *Viewer) setTransform(this js.Value, args []js.Value) interface{} {
+	if len(args) < 2 {
+		return jsError("setTransform: expected (name, matrix)")
+	}
+	o := v.scene.Object(args[0].String())
+	if o == nil {
+		return jsError("setTransform: no object named " + args[0].String())
+	}
+	m, err := jsMat4(args[1])
+	if err != nil {
+		return jsError("setTransform: " + err.Error())
+	}
+	v.history.Execute(&transformCommand{viewer: v, object: o, before: o.Model, after: m})
+	return js.ValueOf(map[string]interface{}{"name": o.Cloud.Name})
+}
+
+// transformParts returns a decomposed transform as JS-ready
+// {position, rotationEuler, scale}.
+func transformParts(m glf32.Mat4) map[string]interface{} {
+	position, rotation, scale := glf32.Decompose(m)
+	vec := func(v glf32.Vec3) []interface{} { return []interface{}{v[0], v[1], v[2]} }
+	return map[string]interface{}{
+		"position":      vec(position),
+		"rotationEuler": vec(rotation),
+		"scale":         vec(scale),
+	}
+}
+
+// getObjectTransform(name) returns an object's model matrix split into
+// {position, rotationEuler, scale}, with the rotation in radians about X,
+// then Y, then Z, and the matrix itself as 16 column-major numbers in
+// matrix, or {error}.
+func (v *Viewer) getObjectTransform(this js.Value, args []js.Value) interface{} {
+	if len(args) < 1 {
+		return jsError("getObjectTransform: expected (name)")
+	}
+	o := v.scene.Object(args[0].String())
+	if o == nil {
+		return jsError("getObjectTransform: no object named " + args[0].String())
+	}
+	parts := transformParts(o.Model)
+	matrix := make([]interface{}, len(o.Model))
+	for i, value := range o.Model {
+		matrix[i] = value
+	}
+	parts["matrix"] = matrix
+	return js.ValueOf(parts)
+}
+
+// setObjectTransform(name, {position, rotationEuler, scale}) sets an
+// object's model matrix from its parts, as an undoable edit. Parts left
+// out keep their current values, as getObjectTransform reports them.
+//
+// Returns {name, position, rotationEuler, scale} or {error}.
+func (v *Viewer) setObjectTransform(this js.Value, args []js.Value) interface{} {
+	if len(args) < 2 {
+		return jsError("setObjectTransform: expected (name, {position, rotationEuler, scale})")
+	}
+	o := v.scene.Object(args[0].String())
+	if o == nil {
+		return jsError("setObjectTransform: no object named " + args[0].String())
+	}
+	position, rotation, scale := glf32.Decompose(o.Model)
+	for _, part := range []struct {
+		name string
+		v    *glf32.Vec3
+	}{{"position", &position}, {"rotationEuler", &rotation}, {"scale", &scale}} {
+		value := jsValue(args[1], part.name)
+		if value.IsUndefined() {
+			continue
+		}
+		p, err := jsVec3(value)
+		if err != nil {
+			return jsError("setObjectTransform: " + part.name + ": " + err.Error())
+		}
+		*part.v = p
+	}
+	m := glf32.Compose(position, rotation, scale)
+	v.history.Execute(&transformCommand{viewer: v, object: o, before: o.Model, after: m})
+	result := transformParts(m)
+	result["name"] = o.Cloud.Name
+	return js.ValueOf(result)
+}
@@ -0,0 +1,192 @@
+// wasm/edl.go
+package main
+
+import (
+	"math"
+	"syscall/js"
+
+	"github.com/sbecker11/webgl-point-cloud/glf32"
+	"github.com/sbecker11/webgl-point-cloud/rendergraph"
+)
+
+// edlNeighbours is the number of pixels around each pixel eye-dome
+// lighting compares its depth with.
+const edlNeighbours = 8
+
+// EDLPass shades the drawn points by eye-dome lighting: each pixel
+// darkens by how far its neighbours Radius pixels away lie in front of
+// it, in log depth, which outlines edges and brings out the relief of
+// scans without normals, more cheaply than ambient occlusion. It adds two
+// render passes: one draws the points' depth offscreen, the other blends
+// the shade over the canvas. The adaptive quality controller turns it off
+// at its lowest levels.
+type EDLPass struct {
+	viewer   *Viewer
+	Enabled  bool
+	Radius   float32 // in pixels
+	Strength float32
+
+	depthShader *PointShader
+	program     js.Value
+	locs        map[string]js.Value
+	failed      bool
+}
+
+// newEDLPass returns the pass turned off, with the default settings.
+func newEDLPass(v *Viewer) *EDLPass {
+	return &EDLPass{viewer: v, Radius: 1.4, Strength: 1}
+}
+
+var edlFragmentShader = `
+#ifdef GL_FRAGMENT_PRECISION_HIGH
+precision highp float;
+#else
+precision mediump float;
+#endif
+uniform sampler2D uDepth;
+uniform vec2 uTexel;
+uniform vec2 uNeighbours[8];
+uniform float uRadius;
+uniform float uStrength;
+uniform vec2 uClip;
+varying vec2 vTexCoord;
+// depthAt unpacks the depth drawn at uv, 0 where nothing was drawn.
+float depthAt(vec2 uv) {
+	return dot(texture2D(uDepth, uv), vec4(1.0, 1.0 / 255.0, 1.0 / 65025.0, 1.0 / 16581375.0));
+}
+// logDistanceOf returns the log2 of the distance from the camera of depth d.
+float logDistanceOf(float d) {
+	float z = d * 2.0 - 1.0;
+	return log2(2.0 * uClip.x * uClip.y / (uClip.y + uClip.x - z * (uClip.y - uClip.x)));
+}
+void main() {
+	float d = depthAt(vTexCoord);
+	if (d <= 0.0) {
+		gl_FragColor = vec4(0.0);
+		return;
+	}
+	float center = logDistanceOf(d);
+	float response = 0.0;
+	for (int i = 0; i < 8; i++) {
+		float s = depthAt(vTexCoord + uNeighbours[i] * uRadius * uTexel);
+		if (s > 0.0) {
+			response += max(0.0, center - logDistanceOf(s));
+		}
+	}
+	float shade = exp(-response / 8.0 * 300.0 * uStrength);
+	gl_FragColor = vec4(0.0, 0.0, 0.0, 1.0 - shade);
+}`
+
+// edlKernel returns the directions of the neighbours, evenly around the
+// unit circle.
+func edlKernel() []float32 {
+	kernel := make([]float32, 0, edlNeighbours*2)
+	for i := 0; i < edlNeighbours; i++ {
+		a := 2 * math.Pi * float64(i) / edlNeighbours
+		kernel = append(kernel, float32(math.Cos(a)), float32(math.Sin(a)))
+	}
+	return kernel
+}
+
+// setup compiles the programs on first use. A failure turns the pass off
+// for good.
+func (e *EDLPass) setup(gl js.Value) bool {
+	if e.failed || !e.program.IsUndefined() {
+		return !e.failed
+	}
+	depthShader, err := e.viewer.shaders.point(gl, "depth.frag", e.viewer.baseFeatures())
+	if err == nil {
+		e.depthShader = depthShader
+		e.program, err = createShaderProgram(gl, fullscreenVertexShader, edlFragmentShader)
+	}
+	if err != nil {
+		e.viewer.notice(msgf("notice.edlOff", err))
+		e.failed = true
+		return false
+	}
+	e.locs = map[string]js.Value{}
+	for _, name := range []string{"uDepth", "uTexel", "uNeighbours", "uRadius", "uStrength", "uClip"} {
+		e.locs[name] = gl.Call("getUniformLocation", e.program, name)
+	}
+	return true
+}
+
+// register adds the pass's steps to g.
+func (e *EDLPass) register(g *RenderGraph) {
+	enabled := func() bool { return e.Enabled && !e.failed && e.viewer.adaptive.level().edl }
+	g.add(&RenderPass{
+		Pass:    rendergraph.Pass{Name: "edl-depth", Outputs: []string{"edl-depth"}},
+		Depth:   true,
+		Enabled: enabled,
+		Draw: func(c *PassContext) {
+			if e.setup(c.gl) {
+				e.viewer.drawPointDepth(c.gl, e.depthShader, c.frame.viewProj, c.frame.pointSize, c.frame.fraction)
+			}
+		},
+	})
+	g.add(&RenderPass{
+		Pass:    rendergraph.Pass{Name: "edl", Inputs: []string{"edl-depth"}},
+		Enabled: enabled,
+		Draw:    e.shade,
+	})
+}
+
+// shade blends the eye-dome shade over the canvas.
+func (e *EDLPass) shade(c *PassContext) {
+	if !e.setup(c.gl) {
+		return
+	}
+	gl := c.gl
+	gl.Call("useProgram", e.program)
+	gl.Call("uniform1i", e.locs["uDepth"], c.Unit("edl-depth"))
+	gl.Call("uniform2f", e.locs["uTexel"], 1/float32(c.width), 1/float32(c.height))
+	gl.Call("uniform2fv", e.locs["uNeighbours"], glf32.ToFloat32Array(edlKernel()))
+	gl.Call("uniform1f", e.locs["uRadius"], e.Radius)
+	gl.Call("uniform1f", e.locs["uStrength"], e.Strength)
+	gl.Call("uniform2f", e.locs["uClip"], float32(nearPlane), float32(farPlane))
+	c.DrawQuad()
+}
+
+// edlInfo returns the pass's settings as a JS object.
+func (v *Viewer) edlInfo() js.Value {
+	return js.ValueOf(map[string]interface{}{
+		"enabled":  v.edl.Enabled,
+		"radius":   v.edl.Radius,
+		"strength": v.edl.Strength,
+	})
+}
+
+// setEDL(params) turns eye-dome lighting on or off and tunes it. It
+// darkens points where their neighbours on screen lie in front of them,
+// outlining edges and bringing out the relief of scans without normals,
+// at the cost of drawing the points twice. params may set any of enabled,
+// radius (how far away the neighbours are, in pixels, default 1.4) and
+// strength (default 1); others keep their values. The adaptive quality
+// controller leaves it off at its two lowest levels.
+//
+// Returns the settings as getEDL does, or {error}.
+func (v *Viewer) setEDL(this js.Value, args []js.Value) interface{} {
+	if len(args) < 1 || args[0].Type() != js.TypeObject {
+		return jsError("setEDL: expected ({enabled, radius, strength})")
+	}
+	params := args[0]
+	radius := jsFloat(params, "radius", v.edl.Radius)
+	strength := jsFloat(params, "strength", v.edl.Strength)
+	switch {
+	case !(radius >= 0.5 && radius <= 16):
+		return jsError("setEDL: radius must be in [0.5, 16]")
+	case !(strength >= 0 && strength <= 10):
+		return jsError("setEDL: strength must be in [0, 10]")
+	}
+	v.edl.Radius, v.edl.Strength = radius, strength
+	if value := jsValue(params, "enabled"); value.Type() == js.TypeBoolean {
+		v.edl.Enabled = value.Bool()
+	}
+	return v.edlInfo()
+}
+
+// getEDL() returns the eye-dome lighting settings as {enabled, radius,
+// strength}.
+func (v *Viewer) getEDL(this js.Value, args []js.Value) interface{} {
+	return v.edlInfo()
+}
@@ -0,0 +1,285 @@
+// wasm/embed.go
+package main
+
+import (
+	"fmt"
+	"strings"
+	"syscall/js"
+
+	"github.com/sbecker11/webgl-point-cloud/glf32"
+	"github.com/sbecker11/webgl-point-cloud/procgen"
+)
+
+// embedProtocol tags the messages of the iframe protocol, so the viewer
+// and its host page can tell them from other messages.
+const embedProtocol = "webgl-point-cloud"
+
+// embed is the state of the iframe protocol. It is active when the viewer
+// runs in a frame: requests posted by the parent window are answered, and
+// viewer events are posted to it.
+var embed struct {
+	active bool
+	parent js.Value
+	origin string // the parent's origin messages are accepted from, or "*"
+}
+
+// embedMethods are the protocol's own methods; any function of the JS API
+// can be called too.
+var embedMethods = map[string]func(v *Viewer, params js.Value) (interface{}, error){
+	"load":          (*Viewer).embedLoad,
+	"setCamera":     (*Viewer).embedSetCamera,
+	"getCamera":     (*Viewer).embedGetCamera,
+	"getScreenshot": (*Viewer).embedGetScreenshot,
+}
+
+// setupEmbed starts the iframe protocol for v if the viewer runs in a frame,
+// accepting messages from the parent only if it has the given origin, or
+// from any origin if it is "*", and posts the ready event. Without an
+// origin the protocol stays off, so that no page framing the viewer can
+// drive it unless the viewer's URL allows it.
+//
+// Requests are {protocol: "webgl-point-cloud", id, method, params} for the
+// methods of embedMethods, and {protocol, id, method, args} for the JS API
+// functions. Each is answered with {protocol, id, result} or {protocol, id,
+// error}, once any Promise the method returns settles. Events are posted
+// as {protocol, event, payload}.
+func (v *Viewer) setupEmbed(origin string) {
+	window := js.Global()
+	if window.Get("parent").Equal(window) {
+		return
+	}
+	if origin == "" {
+		js.Global().Get("console").Call("warn", "embed: not answering the parent page without ?origin=")
+		return
+	}
+	embed.active, embed.parent, embed.origin = true, window.Get("parent"), origin
+	window.Call("addEventListener", "message", js.FuncOf(func(this js.Value, args []js.Value) interface{} {
+		e := args[0]
+		data := e.Get("data")
+		if !e.Get("source").Equal(embed.parent) || (origin != "*" && e.Get("origin").String() != origin) ||
+			data.Type() != js.TypeObject || jsString(data, "protocol", "") != embedProtocol {
+			return nil
+		}
+		v.handleEmbedRequest(data)
+		return nil
+	}))
+	methods := []interface{}{}
+	for name := range embedMethods {
+		methods = append(methods, name)
+	}
+	postToParent(map[string]interface{}{"event": "ready", "payload": map[string]interface{}{"methods": methods}})
+}
+
+// postToParent posts a protocol message to the parent window.
+func postToParent(msg map[string]interface{}) {
+	msg["protocol"] = embedProtocol
+	defer func() {
+		if r := recover(); r != nil {
+			js.Global().Get("console").Call("error", fmt.Sprintf("embed: cannot post %v: %v", msg["event"], r))
+		}
+	}()
+	embed.parent.Call("postMessage", js.ValueOf(msg), embed.origin)
+}
+
+// embedEvent posts a viewer event to the parent window, if embedded.
+func embedEvent(event string, payload map[string]interface{}) {
+	if embed.active {
+		postToParent(map[string]interface{}{"event": event, "payload": payload})
+	}
+}
+
+// handleEmbedRequest runs a request and posts its answer. A request that
+// makes the method panic, e.g. with arguments of the wrong types, is
+// answered with the panic as its error.
+func (v *Viewer) handleEmbedRequest(data js.Value) {
+	id := jsValue(data, "id")
+	respond := func(result js.Value, err error) {
+		msg := map[string]interface{}{"id": id}
+		if err == nil && result.Type() == js.TypeObject && result.Get("error").Type() == js.TypeString {
+			err = fmt.Errorf("%s", result.Get("error").String())
+		}
+		if err != nil {
+			msg["error"] = err.Error()
+		} else {
+			msg["result"] = result
+		}
+		postToParent(msg)
+	}
+	method := jsString(data, "method", "")
+	defer func() {
+		if r := recover(); r != nil {
+			respond(js.Undefined(), fmt.Errorf("%s: %v", method, r))
+		}
+	}()
+	var result js.Value
+	if fn, ok := embedMethods[method]; ok {
+		requestRender()
+		value, err := fn(v, jsValue(data, "params"))
+		if err != nil {
+			respond(js.Undefined(), fmt.Errorf("%s: %v", method, err))
+			return
+		}
+		result = js.ValueOf(value)
+	} else if fn, ok := apiFuncs[method]; ok {
+		requestRender()
+		var args []js.Value
+		if a := jsValue(data, "args"); a.Type() == js.TypeObject {
+			for i := 0; i < a.Length(); i++ {
+				args = append(args, a.Index(i))
+			}
+		}
+		result = js.ValueOf(fn(v, js.Undefined(), args))
+	} else {
+		respond(js.Undefined(), fmt.Errorf("unknown method %q", method))
+		return
+	}
+	if !isPromise(result) {
+		respond(result, nil)
+		return
+	}
+	var onResolve, onReject js.Func
+	onResolve = js.FuncOf(func(this js.Value, args []js.Value) interface{} {
+		onResolve.Release()
+		onReject.Release()
+		respond(args[0], nil)
+		return nil
+	})
+	onReject = js.FuncOf(func(this js.Value, args []js.Value) interface{} {
+		onResolve.Release()
+		onReject.Release()
+		respond(js.Undefined(), fmt.Errorf("%s", js.Global().Get("String").Invoke(jsValue(args[0], "message")).String()))
+		return nil
+	})
+	result.Call("then", onResolve, onReject)
+}
+
+// isPromise reports whether v is a Promise.
+func isPromise(v js.Value) bool {
+	return v.Type() == js.TypeObject && v.InstanceOf(js.Global().Get("Promise"))
+}
+
+// embedLoad shows data instead of the scene's: params {dataset, points,
+// seed} generates a procedural dataset, and {url, format, name, points,
+// seed} fetches a file and imports it as importFile does. With replace
+// false it is added to the scene instead. Returns {name, points}, or a
+// Promise of it for a url.
+func (v *Viewer) embedLoad(params js.Value) (interface{}, error) {
+	replace := jsValue(params, "replace").IsUndefined() || jsValue(params, "replace").Truthy()
+	if url := jsString(params, "url", ""); url != "" {
+		importParams := map[string]interface{}{"name": url[strings.LastIndex(url, "/")+1:]}
+		for _, key := range []string{"name", "format", "points", "seed"} {
+			if value := jsValue(params, key); !value.IsUndefined() {
+				importParams[key] = value
+			}
+		}
+		fetched := js.Global().Call("fetch", url).Call("then", js.FuncOf(func(this js.Value, args []js.Value) interface{} {
+			response := args[0]
+			if !response.Get("ok").Bool() {
+				return js.Global().Get("Promise").Call("reject", js.Global().Get("Error").New(url+": "+response.Get("statusText").String()))
+			}
+			return response.Call("arrayBuffer")
+		}))
+		imported := fetched.Call("then", js.FuncOf(func(this js.Value, args []js.Value) interface{} {
+			return v.importFile(js.Undefined(), []js.Value{args[0], js.ValueOf(importParams)})
+		}))
+		return imported.Call("then", js.FuncOf(func(this js.Value, args []js.Value) interface{} {
+			if replace {
+				v.keepOnly(args[0].Get("name").String())
+			}
+			return args[0]
+		})), nil
+	}
+
+	dataset := jsString(params, "dataset", "")
+	if dataset == "" {
+		return nil, fmt.Errorf("expected a dataset or a url")
+	}
+	points := int(jsFloat(params, "points", 5000))
+	seed := int64(jsFloat(params, "seed", 1))
+	cloud, err := procgen.New(seed).Dataset(dataset, points)
+	if err != nil {
+		return nil, err
+	}
+	if replace {
+		v.scene.Clear()
+		v.history.Clear()
+	}
+	o := v.addDataset(cloud, dataset, seed, points)
+	v.emitDatasetLoaded(o)
+	return map[string]interface{}{"name": o.Cloud.Name, "points": o.Cloud.Len()}, nil
+}
+
+// keepOnly removes every object but the named one.
+func (v *Viewer) keepOnly(name string) {
+	for _, o := range append([]*SceneObject(nil), v.scene.Objects()...) {
+		if o.Cloud.Name != name {
+			v.scene.Remove(o.Cloud.Name)
+		}
+	}
+	v.history.Clear()
+}
+
+// embedSetCamera places the camera: params {position, target} as [x, y, z]
+// arrays, or {view} with an axis as setCameraView takes. Returns the
+// camera as getCamera does.
+func (v *Viewer) embedSetCamera(params js.Value) (interface{}, error) {
+	if axis := jsString(params, "view", ""); axis != "" {
+		if !v.snapView(axis) {
+			return nil, fmt.Errorf("unknown axis %s", axis)
+		}
+		return v.embedGetCamera(params)
+	}
+	position, err := jsVec3(jsValue(params, "position"))
+	if err != nil {
+		return nil, fmt.Errorf("position: %v", err)
+	}
+	target := glf32.Vec3{v.camera.target[0], v.camera.target[1], v.camera.target[2]}
+	if value := jsValue(params, "target"); !value.IsUndefined() {
+		if target, err = jsVec3(value); err != nil {
+			return nil, fmt.Errorf("target: %v", err)
+		}
+	}
+	v.camera.SetPose(position, target)
+	return v.embedGetCamera(params)
+}
+
+// embedGetCamera returns the camera as {position, target}.
+func (v *Viewer) embedGetCamera(params js.Value) (interface{}, error) {
+	p, t := v.camera.Position(), v.camera.target
+	return map[string]interface{}{
+		"position": []interface{}{p[0], p[1], p[2]},
+		"target":   []interface{}{t[0], t[1], t[2]},
+	}, nil
+}
+
+// takeScreenshots answers the pending screenshot requests from the frame
+// just drawn on canvas.
+func (v *Viewer) takeScreenshots(canvas js.Value) {
+	for _, take := range v.pendingScreenshots {
+		take(canvas)
+	}
+	v.pendingScreenshots = nil
+}
+
+// embedGetScreenshot captures the next frame drawn. params may hold type
+// (default "image/png") and quality, as canvas.toDataURL takes. Returns a
+// Promise of {dataUrl, width, height}.
+func (v *Viewer) embedGetScreenshot(params js.Value) (interface{}, error) {
+	mime := jsString(params, "type", "image/png")
+	quality := jsValue(params, "quality")
+	var handler js.Func
+	handler = js.FuncOf(func(this js.Value, args []js.Value) interface{} {
+		handler.Release()
+		resolve := args[0]
+		v.pendingScreenshots = append(v.pendingScreenshots, func(canvas js.Value) {
+			resolve.Invoke(map[string]interface{}{
+				"dataUrl": canvas.Call("toDataURL", mime, quality).String(),
+				"width":   canvas.Get("width").Int(),
+				"height":  canvas.Get("height").Int(),
+			})
+		})
+		requestRender()
+		return nil
+	})
+	return js.Global().Get("Promise").New(handler), nil
+}
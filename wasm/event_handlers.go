@@ -5,39 +5,145 @@ import (
 	"syscall/js"
 )
 
-func setupEventHandlers(canvas, gl js.Value, camera *Camera) {
-	canvas.Call("addEventListener", "mousedown", js.FuncOf(func(this js.Value, args []js.Value) interface{} {
-		camera.HandleMouseDown(args[0].Get("clientX").Float(), args[0].Get("clientY").Float())
+// setupEventHandlers turns the pointer's drags over v's canvas and its
+// wheel into camera moves, or hands them to the active tool.
+func (v *Viewer) setupEventHandlers() {
+	canvas, camera := v.canvas, v.camera
+	// Pointer events cover the mouse, pens and touch alike. Capturing the
+	// pointer keeps a drag going when it leaves the canvas, and touch-action
+	// stops touches from scrolling or zooming the page instead.
+	canvas.Get("style").Set("touchAction", "none")
+	canvas.Call("addEventListener", "pointerdown", js.FuncOf(func(this js.Value, args []js.Value) interface{} {
+		e := args[0]
+		if !e.Get("isPrimary").Bool() {
+			return nil
+		}
+		x, y := canvasPoint(canvas, e)
+		if width := float32(canvas.Get("width").Float()); v.gizmo.contains(x, y, width) {
+			if axis := v.gizmo.axisAt(x, y, width); axis != "" {
+				v.snapView(axis)
+			}
+			return nil
+		}
+		canvas.Call("setPointerCapture", e.Get("pointerId"))
+		if v.minimap.contains(x, y) {
+			v.minimap.dragging = true
+			v.minimap.jump(x, y)
+			return nil
+		}
+		if v.activeTool != nil {
+			pointerShift, pointerAlt = e.Get("shiftKey").Bool(), e.Get("altKey").Bool()
+			v.activeTool.Down(x, y)
+			return nil
+		}
+		if e.Get("button").Int() == controls.orbitButton() {
+			camera.HandleMouseDown(e.Get("clientX").Float(), e.Get("clientY").Float())
+		}
+		return nil
+	}))
+
+	// Right-clicking opens the context menu, unless the right button orbits
+	// or a tool is active; the browser's own menu is kept from showing over
+	// either.
+	canvas.Call("addEventListener", "contextmenu", js.FuncOf(func(this js.Value, args []js.Value) interface{} {
+		if controls.SwapButtons || v.activeTool != nil || v.contextMenu.open(canvas, args[0]) {
+			args[0].Call("preventDefault")
+		}
 		return nil
 	}))
+	v.setupContextMenu(canvas)
 
-	canvas.Call("addEventListener", "mousemove", js.FuncOf(func(this js.Value, args []js.Value) interface{} {
+	canvas.Call("addEventListener", "pointermove", js.FuncOf(func(this js.Value, args []js.Value) interface{} {
+		e := args[0]
+		if !e.Get("isPrimary").Bool() {
+			return nil
+		}
+		if v.minimap.dragging {
+			v.minimap.jump(canvasPoint(canvas, e))
+			return nil
+		}
+		if v.activeTool != nil {
+			x, y := canvasPoint(canvas, e)
+			if placesPoints(v.activeTool) {
+				v.snapping.showIndicator(x, y)
+			}
+			v.activeTool.Move(x, y)
+			return nil
+		}
 		if camera.isMouseDown {
-			camera.HandleMouseMove(args[0].Get("clientX").Float(), args[0].Get("clientY").Float())
+			camera.HandleMouseMove(e.Get("clientX").Float(), e.Get("clientY").Float())
 		}
 		return nil
 	}))
 
-	mouseUpOrLeave := js.FuncOf(func(this js.Value, args []js.Value) interface{} {
+	// A cancelled pointer, e.g. a touch taken over by the system, ends the
+	// drag as if it were lifted.
+	pointerUp := js.FuncOf(func(this js.Value, args []js.Value) interface{} {
+		e := args[0]
+		if !e.Get("isPrimary").Bool() {
+			return nil
+		}
+		if canvas.Call("hasPointerCapture", e.Get("pointerId")).Bool() {
+			canvas.Call("releasePointerCapture", e.Get("pointerId"))
+		}
+		if v.minimap.dragging {
+			v.minimap.dragging = false
+			return nil
+		}
+		if v.activeTool != nil {
+			v.activeTool.Up(canvasPoint(canvas, e))
+			return nil
+		}
 		camera.HandleMouseUp()
 		return nil
 	})
-	canvas.Call("addEventListener", "mouseup", mouseUpOrLeave)
-	canvas.Call("addEventListener", "mouseleave", mouseUpOrLeave)
+	canvas.Call("addEventListener", "pointerup", pointerUp)
+	canvas.Call("addEventListener", "pointercancel", pointerUp)
+
+	// Double-clicking a point makes the camera orbit it.
+	canvas.Call("addEventListener", "dblclick", js.FuncOf(func(this js.Value, args []js.Value) interface{} {
+		if v.activeTool == nil {
+			v.pivotAt(canvasPoint(canvas, args[0]))
+		}
+		return nil
+	}))
 
 	canvas.Call("addEventListener", "wheel", js.FuncOf(func(this js.Value, args []js.Value) interface{} {
 		args[0].Call("preventDefault")
 		camera.HandleMouseWheel(args[0].Get("deltaY").Float())
 		return nil
 	}), js.ValueOf(map[string]interface{}{"passive": false}))
+} 
 
-	resizeFunc := js.FuncOf(func(this js.Value, args []js.Value) interface{} {
-		width, height := js.Global().Get("innerWidth").Float(), js.Global().Get("innerHeight").Float()
-		canvas.Set("width", width)
-		canvas.Set("height", height)
-		gl.Call("viewport", 0, 0, width, height)
+// setupKeyboardHandlers binds the editing shortcuts: Ctrl+Z (or Cmd+Z) to
+// undo, Ctrl+Y and Ctrl+Shift+Z to redo, Delete to delete the selected
+// points and Escape to cancel the active tool, and in labeling mode the
+// number keys to label the selected points. Keys typed into form fields
+// are left alone.
+func setupKeyboardHandlers() {
+	js.Global().Get("document").Call("addEventListener", "keydown", js.FuncOf(func(this js.Value, args []js.Value) interface{} {
+		event := args[0]
+		switch event.Get("target").Get("tagName").String() {
+		case "INPUT", "TEXTAREA", "SELECT":
+			return nil
+		}
+		ctrl := event.Get("ctrlKey").Bool() || event.Get("metaKey").Bool()
+		key := event.Get("key").String()
+		switch {
+		case ctrl && (key == "z" || key == "Z") && event.Get("shiftKey").Bool(), ctrl && (key == "y" || key == "Y"):
+			currentViewer.redo(js.Null(), nil)
+		case ctrl && (key == "z" || key == "Z"):
+			currentViewer.undo(js.Null(), nil)
+		case key == "Delete":
+			currentViewer.deleteSelected(js.Null(), nil)
+		case key == "Escape" && currentViewer.activeTool != nil:
+			currentViewer.setTool(nil)
+		case currentViewer.labeling && !ctrl && len(key) == 1 && key[0] >= '0' && key[0] <= '9':
+			currentViewer.labelSelection(js.Null(), []js.Value{js.ValueOf(int(key[0] - '0'))})
+		default:
+			return nil
+		}
+		event.Call("preventDefault")
 		return nil
-	})
-	js.Global().Call("addEventListener", "resize", resizeFunc)
-	resizeFunc.Call("call", js.Null()) // Initial call to set size
-} 
\ No newline at end of file
+	}))
+}
@@ -0,0 +1,273 @@
+// wasm/events.go
+package main
+
+import (
+	"fmt"
+	"syscall/js"
+
+	"github.com/sbecker11/webgl-point-cloud/edit"
+	"github.com/sbecker11/webgl-point-cloud/glf32"
+	"github.com/sbecker11/webgl-point-cloud/pointcloud"
+)
+
+// Events emitted to JS listeners.
+const (
+	eventPointPicked      = "pointPicked"
+	eventSelectionChanged = "selectionChanged"
+	eventDatasetLoaded    = "datasetLoaded"
+	eventCameraChanged    = "cameraChanged"
+	eventFrameStats       = "frameStats"
+	eventJobProgress      = "jobProgress"
+	eventProfile          = "profileExtracted"
+	eventDistance         = "distanceMeasured"
+	eventNotice           = "notice"
+)
+
+// listeners holds the JS callbacks registered for each event.
+var listeners = map[string][]js.Value{
+	eventPointPicked:      nil,
+	eventSelectionChanged: nil,
+	eventDatasetLoaded:    nil,
+	eventCameraChanged:    nil,
+	eventFrameStats:       nil,
+	eventJobProgress:      nil,
+	eventProfile:          nil,
+	eventDistance:         nil,
+	eventNotice:           nil,
+}
+
+// emit calls every listener of event with payload, giving v's canvas id as
+// the viewer it happened in unless v is nil. A listener that throws is
+// reported to the console without affecting the others.
+func (v *Viewer) emit(event string, payload map[string]interface{}) {
+	if v != nil {
+		payload["viewer"] = v.ID
+	}
+	embedEvent(event, payload)
+	fns := listeners[event]
+	if len(fns) == 0 {
+		return
+	}
+	payload["type"] = event
+	arg := js.ValueOf(payload)
+	for _, fn := range fns {
+		func() {
+			defer func() {
+				if r := recover(); r != nil {
+					js.Global().Get("console").Call("error", fmt.Sprintf("%s listener: %v", event, r))
+				}
+			}()
+			fn.Invoke(arg)
+		}()
+	}
+}
+
+// addViewerListener(event, fn) registers fn to be called with a payload
+// object whenever event occurs. Events are pointPicked, selectionChanged,
+// datasetLoaded, cameraChanged, frameStats, jobProgress, profileExtracted
+// and distanceMeasured.
+func addViewerListener(this js.Value, args []js.Value) interface{} {
+	if len(args) < 2 || args[1].Type() != js.TypeFunction {
+		return jsError("addViewerListener: expected (event, fn)")
+	}
+	event := args[0].String()
+	if _, ok := listeners[event]; !ok {
+		return jsError("addViewerListener: unknown event " + event)
+	}
+	listeners[event] = append(listeners[event], args[1])
+	return nil
+}
+
+// removeViewerListener(event, fn) unregisters a function added with
+// addViewerListener.
+func removeViewerListener(this js.Value, args []js.Value) interface{} {
+	if len(args) < 2 {
+		return jsError("removeViewerListener: expected (event, fn)")
+	}
+	event := args[0].String()
+	fns := listeners[event]
+	for i, fn := range fns {
+		if fn.Equal(args[1]) {
+			listeners[event] = append(fns[:i:i], fns[i+1:]...)
+			break
+		}
+	}
+	return nil
+}
+
+// emitDatasetLoaded reports a cloud added to the scene.
+func (v *Viewer) emitDatasetLoaded(o *SceneObject) {
+	v.emit(eventDatasetLoaded, map[string]interface{}{
+		"name":   o.Cloud.Name,
+		"points": o.Cloud.Len(),
+		"source": o.Source.Type,
+	})
+}
+
+// emitSelectionChanged reports the selected point counts after a
+// selection or edit.
+func (v *Viewer) emitSelectionChanged() {
+	total := 0
+	objects := map[string]interface{}{}
+	for _, o := range v.scene.Objects() {
+		if n := edit.Count(o.Selection); n > 0 {
+			objects[o.Cloud.Name] = n
+			total += n
+		}
+	}
+	v.emit(eventSelectionChanged, map[string]interface{}{"selected": total, "objects": objects})
+}
+
+// cameraState is the part of the camera reported by cameraChanged.
+type cameraState struct {
+	distance, rotationX, rotationY, zoom float32
+	target                               [3]float32
+}
+
+// frameMonitor tracks per-frame state for cameraChanged and frameStats.
+type frameMonitor struct {
+	lastCamera cameraState
+	frames     int
+	start      float64 // performance.now() at the start of the interval
+}
+
+// state returns the part of the camera reported by cameraChanged.
+func (c *Camera) state() cameraState {
+	return cameraState{c.distance, c.rotationX, c.rotationY, c.zoom, [3]float32{c.target[0], c.target[1], c.target[2]}}
+}
+
+// frameDone is called after each frame v draws. It emits cameraChanged
+// when the camera moved and frameStats about once a second.
+func (m *frameMonitor) frameDone(v *Viewer) {
+	state := v.camera.state()
+	if state != m.lastCamera {
+		m.lastCamera = state
+		v.emit(eventCameraChanged, map[string]interface{}{
+			"distance":  state.distance,
+			"rotationX": state.rotationX,
+			"rotationY": state.rotationY,
+			"zoom":      state.zoom,
+			"target":    []interface{}{state.target[0], state.target[1], state.target[2]},
+		})
+	}
+
+	now := js.Global().Get("performance").Call("now").Float()
+	if m.start == 0 {
+		m.start = now
+	}
+	m.frames++
+	if elapsed := now - m.start; elapsed >= 1000 {
+		points, objects := 0, 0
+		for _, o := range v.scene.Objects() {
+			if visible, opacity, _ := v.scene.Effective(o); visible && opacity > 0 {
+				points += o.VisibleCount()
+				objects++
+			}
+		}
+		v.emit(eventFrameStats, map[string]interface{}{
+			"fps":          float64(m.frames) * 1000 / elapsed,
+			"frameTimeMs":  elapsed / float64(m.frames),
+			"points":       points,
+			"objects":      objects,
+			"qualityLevel": v.adaptive.controller.Level(),
+		})
+		m.frames, m.start = 0, now
+	}
+}
+
+// pickRadius is the default snap radius: how close, in pixels, a click must
+// be to a point to pick it.
+const pickRadius = 6
+
+// setupPickHandler emits pointPicked when v's canvas is clicked without
+// dragging over a drawn point.
+func (v *Viewer) setupPickHandler() {
+	canvas := v.canvas
+	var downX, downY float64
+	var toolDown bool
+	canvas.Call("addEventListener", "pointerdown", js.FuncOf(func(this js.Value, args []js.Value) interface{} {
+		if !args[0].Get("isPrimary").Bool() {
+			return nil
+		}
+		downX, downY = args[0].Get("clientX").Float(), args[0].Get("clientY").Float()
+		x, y := canvasPoint(canvas, args[0])
+		toolDown = v.activeTool != nil || v.minimap.contains(x, y) || v.gizmo.contains(x, y, float32(canvas.Get("width").Float()))
+		return nil
+	}))
+	canvas.Call("addEventListener", "pointerup", js.FuncOf(func(this js.Value, args []js.Value) interface{} {
+		e := args[0]
+		if !e.Get("isPrimary").Bool() {
+			return nil
+		}
+		dx, dy := e.Get("clientX").Float()-downX, e.Get("clientY").Float()-downY
+		if toolDown || dx*dx+dy*dy > 9 || len(listeners[eventPointPicked]) == 0 || v.lastViewProj == nil {
+			return nil
+		}
+		// Convert from CSS pixels to canvas pixels.
+		scaleX := canvas.Get("width").Float() / canvas.Get("clientWidth").Float()
+		scaleY := canvas.Get("height").Float() / canvas.Get("clientHeight").Float()
+		v.pickAt(float32(e.Get("offsetX").Float()*scaleX), float32(e.Get("offsetY").Float()*scaleY),
+			float32(canvas.Get("width").Float()), float32(canvas.Get("height").Float()))
+		return nil
+	}))
+}
+
+// nearestDrawnPoint returns the drawn point nearest the camera within the
+// snap radius of canvas position (x, y) in the last frame, or nil.
+func (v *Viewer) nearestDrawnPoint(x, y, width, height float32) (*SceneObject, int) {
+	return v.snapping.nearest(x, y, width, height)
+}
+
+// pickAt finds the drawn point nearest the camera under canvas position
+// (x, y) and emits pointPicked with its object, index, world and local
+// positions, color and attributes, and the position in the object's
+// source CRS if it is georeferenced.
+func (v *Viewer) pickAt(x, y, width, height float32) {
+	best, bestIndex := v.nearestDrawnPoint(x, y, width, height)
+	if best == nil {
+		return
+	}
+	_, _, model := v.scene.Effective(best)
+	p := best.Cloud.Point(bestIndex)
+	world := glf32.TransformVertices([]float32{p[0], p[1], p[2]}, model)
+	c := best.Cloud.Colors[bestIndex*4 : bestIndex*4+4]
+	payload := map[string]interface{}{
+		"name":          best.Cloud.Name,
+		"index":         bestIndex,
+		"position":      []interface{}{world[0], world[1], world[2]},
+		"localPosition": []interface{}{p[0], p[1], p[2]},
+		"color":         []interface{}{c[0], c[1], c[2], c[3]},
+		"attributes":    pointAttributes(best, bestIndex),
+	}
+	if q, ok := sourcePosition(best, bestIndex); ok {
+		payload["crs"] = best.SourceCRS.String()
+		payload["sourcePosition"] = []interface{}{q[0], q[1], q[2]}
+	}
+	v.emit(eventPointPicked, payload)
+}
+
+// pointAttributes returns the class and attribute values of point i of o by
+// attribute name, single values as numbers and vectors as lists.
+func pointAttributes(o *SceneObject, i int) map[string]interface{} {
+	attributes := map[string]interface{}{}
+	if o.Cloud.Classes != nil {
+		attributes[pointcloud.AttrClass] = int(o.Cloud.Classes[i])
+	}
+	for _, a := range o.Cloud.Schema() {
+		_, values, ok := o.Cloud.Attribute(a.Name)
+		if !ok {
+			continue
+		}
+		v := values[i*a.Components : (i+1)*a.Components]
+		if a.Components == 1 {
+			attributes[a.Name] = v[0]
+		} else {
+			list := make([]interface{}, len(v))
+			for k := range v {
+				list[k] = v[k]
+			}
+			attributes[a.Name] = list
+		}
+	}
+	return attributes
+}
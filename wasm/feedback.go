@@ -0,0 +1,161 @@
+// wasm/feedback.go
+package main
+
+import (
+	"syscall/js"
+
+	"github.com/sbecker11/webgl-point-cloud/glf32"
+	"github.com/sbecker11/webgl-point-cloud/glsl"
+)
+
+// minFeedbackPoints is the fewest points computed on the GPU; for fewer the
+// round trip through the GPU costs more than the CPU does.
+const minFeedbackPoints = 1 << 15
+
+// feedbackKernel is a program computing values per point with transform
+// feedback: feedback.vert, with its defines.
+type feedbackKernel struct {
+	program    js.Value
+	matrixLoc  js.Value
+	components int // the floats computed per point
+}
+
+// Feedback runs per-point computations over whole clouds on the GPU with
+// WebGL 2 transform feedback, where the CPU would loop over millions of
+// points: transforming points by a matrix and computing their depth for
+// sorting them. Each run uploads the points, draws them with
+// rasterization off, capturing the vertex shader's output in a buffer,
+// and reads that back.
+type Feedback struct {
+	viewer    *Viewer
+	transform *feedbackKernel
+	depth     *feedbackKernel
+	failed    bool
+}
+
+// setup compiles the programs on first use and reports whether they can
+// run: only with WebGL 2, and a failure to compile turns them off for
+// good, with a notice.
+func (f *Feedback) setup(gl js.Value) bool {
+	if !f.viewer.caps.WebGL2 || f.failed {
+		return false
+	}
+	if f.transform != nil {
+		return true
+	}
+	transform, err := newFeedbackKernel(gl, nil, 3)
+	if err == nil {
+		f.depth, err = newFeedbackKernel(gl, []glsl.Define{{Name: "DEPTH_KEY"}}, 1)
+	}
+	if err != nil {
+		f.viewer.notice(msgf("notice.feedbackOff", err))
+		f.failed = true
+		return false
+	}
+	f.transform = transform
+	return true
+}
+
+// newFeedbackKernel links feedback.vert, with the defines, capturing
+// components floats per point.
+func newFeedbackKernel(gl js.Value, defines []glsl.Define, components int) (*feedbackKernel, error) {
+	vert, err := buildShader("feedback.vert", false, defines)
+	if err != nil {
+		return nil, err
+	}
+	frag, err := buildShader("feedback.frag", false, nil)
+	if err != nil {
+		return nil, err
+	}
+	program, err := createShaderProgram(gl, vert.Text, frag.Text, "vResult")
+	if err != nil {
+		return nil, remapShaderError(err, vert, frag)
+	}
+	return &feedbackKernel{
+		program:    program,
+		matrixLoc:  gl.Call("getUniformLocation", program, "uMatrix"),
+		components: components,
+	}, nil
+}
+
+// run returns the kernel's values for the points in coords with matrix m.
+// The vertex arrays and program in use are left as they were.
+func (k *feedbackKernel) run(gl js.Value, coords []float32, m glf32.Mat4) []float32 {
+	n := len(coords) / 3
+	vao := gl.Call("createVertexArray")
+	gl.Call("bindVertexArray", vao)
+	in := createVBO(gl, coords)
+	gl.Call("enableVertexAttribArray", attribPosition)
+	gl.Call("vertexAttribPointer", attribPosition, 3, gl.Get("FLOAT"), false, 0, 0)
+
+	target := gl.Get("TRANSFORM_FEEDBACK_BUFFER")
+	out := gl.Call("createBuffer")
+	gl.Call("bindBuffer", target, out)
+	gl.Call("bufferData", target, 4*k.components*n, gl.Get("STREAM_READ"))
+	tf := gl.Call("createTransformFeedback")
+	gl.Call("bindTransformFeedback", gl.Get("TRANSFORM_FEEDBACK"), tf)
+	gl.Call("bindBufferBase", target, 0, out)
+
+	previous := gl.Call("getParameter", gl.Get("CURRENT_PROGRAM"))
+	gl.Call("useProgram", k.program)
+	gl.Call("uniformMatrix4fv", k.matrixLoc, false, glf32.ToFloat32Array(m))
+	gl.Call("enable", gl.Get("RASTERIZER_DISCARD"))
+	gl.Call("beginTransformFeedback", gl.Get("POINTS"))
+	gl.Call("drawArrays", gl.Get("POINTS"), 0, n)
+	gl.Call("endTransformFeedback")
+	gl.Call("disable", gl.Get("RASTERIZER_DISCARD"))
+	gl.Call("bindBufferBase", target, 0, js.Null())
+	gl.Call("bindTransformFeedback", gl.Get("TRANSFORM_FEEDBACK"), js.Null())
+	gl.Call("useProgram", previous)
+	gl.Call("bindVertexArray", js.Null())
+
+	result := js.Global().Get("Float32Array").New(k.components * n)
+	gl.Call("bindBuffer", gl.Get("COPY_READ_BUFFER"), out)
+	gl.Call("getBufferSubData", gl.Get("COPY_READ_BUFFER"), 0, result)
+	gl.Call("bindBuffer", gl.Get("COPY_READ_BUFFER"), js.Null())
+	gl.Call("deleteTransformFeedback", tf)
+	gl.Call("deleteBuffer", out)
+	gl.Call("deleteBuffer", in)
+	gl.Call("deleteVertexArray", vao)
+	return glf32.FromFloat32Array(result)
+}
+
+// transformed returns a copy of coords transformed by m, as
+// glf32.TransformVertices does, computed on the GPU for large clouds where
+// it can be. With direction set the coordinates are directions, such as
+// normals, which m's translation does not move.
+func (v *Viewer) transformed(coords []float32, m glf32.Mat4, direction bool) []float32 {
+	if direction {
+		m = append(glf32.Mat4(nil), m...)
+		m[12], m[13], m[14] = 0, 0, 0
+	}
+	if len(coords)/3 >= minFeedbackPoints && v.feedback.setup(v.scene.gl) {
+		return v.feedback.transform.run(v.scene.gl, coords, m)
+	}
+	return glf32.TransformVertices(append([]float32(nil), coords...), m)
+}
+
+// pointDepths returns the depth of each point of coords in normalized
+// device coordinates seen through mvp, from -1 at the near plane to 1 at
+// the far one, which orders points by distance from the camera for
+// perspective and orthographic views alike. It is computed on the GPU for
+// large clouds where it can be.
+func (v *Viewer) pointDepths(coords []float32, mvp glf32.Mat4) []float32 {
+	if len(coords)/3 >= minFeedbackPoints && v.feedback.setup(v.scene.gl) {
+		return v.feedback.depth.run(v.scene.gl, coords, mvp)
+	}
+	depths := make([]float32, len(coords)/3)
+	for i := range depths {
+		depths[i] = pointDepth(mvp, coords[3*i], coords[3*i+1], coords[3*i+2])
+	}
+	return depths
+}
+
+// pointDepth returns the depth of the point x, y, z as pointDepths does.
+func pointDepth(mvp glf32.Mat4, x, y, z float32) float32 {
+	w := mvp[3]*x + mvp[7]*y + mvp[11]*z + mvp[15]
+	if w == 0 {
+		return 0
+	}
+	return (mvp[2]*x + mvp[6]*y + mvp[10]*z + mvp[14]) / w
+}
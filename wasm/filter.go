@@ -0,0 +1,56 @@
+// wasm/filter.go
+package main
+
+import (
+	"syscall/js"
+
+	"github.com/sbecker11/webgl-point-cloud/filter"
+)
+
+// setSceneFilter parses expr and applies it to the scene.
+func (v *Viewer) setSceneFilter(expr string) error {
+	e, err := filter.Parse(expr)
+	if err != nil {
+		return err
+	}
+	return v.scene.SetFilter(e)
+}
+
+// filterResult returns {filter, visible, total} summarizing the scene's
+// current filter.
+func (v *Viewer) filterResult() interface{} {
+	visible, total := 0, 0
+	for _, o := range v.scene.Objects() {
+		visible += o.VisibleCount()
+		total += o.Cloud.Len()
+	}
+	expr := ""
+	if f := v.scene.Filter(); f != nil {
+		expr = f.String()
+	}
+	return js.ValueOf(map[string]interface{}{"filter": expr, "visible": visible, "total": total})
+}
+
+// setFilter(expr) hides every point failing a filter expression such as
+// "intensity > 0.4 && z < 10". Expressions may use x, y, z, r, g, b, a,
+// class and, for clouds with normals, nx, ny, nz. The filter stays active
+// for clouds added later.
+//
+// Returns {filter, visible, total} or {error}.
+func (v *Viewer) setFilter(this js.Value, args []js.Value) interface{} {
+	if len(args) < 1 {
+		return jsError("setFilter: expected (expr)")
+	}
+	if err := v.setSceneFilter(args[0].String()); err != nil {
+		return jsError("setFilter: " + err.Error())
+	}
+	return v.filterResult()
+}
+
+// clearFilter() shows every point again.
+//
+// Returns {filter, visible, total}.
+func (v *Viewer) clearFilter(this js.Value, args []js.Value) interface{} {
+	v.scene.SetFilter(nil)
+	return v.filterResult()
+}
@@ -0,0 +1,231 @@
+// wasm/flythrough.go
+package main
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"strings"
+	"syscall/js"
+
+	"github.com/sbecker11/webgl-point-cloud/campath"
+	"github.com/sbecker11/webgl-point-cloud/glf32"
+)
+
+// cameraPathStep is the time between keyframes added without a time, in
+// seconds.
+const cameraPathStep = 2
+
+// Flythrough plays the recorded camera path, moving the camera each frame.
+// While capturing, playback steps by exactly one frame at fps per rendered
+// frame, however long rendering takes, and each frame is added to a zip of
+// PNGs that is downloaded when playback ends.
+type Flythrough struct {
+	viewer  *Viewer
+	path    campath.Path
+	playing bool
+	loop    bool
+	start   float64 // animation frame time of the path's start, in ms
+	time    float64 // path time of the current frame, in seconds
+	resolve js.Value
+
+	// Capture state.
+	fps      float64
+	frames   int
+	archive  *zip.Writer
+	buf      *bytes.Buffer
+	filename string
+}
+
+// update moves the camera to the path's pose at animation frame time now,
+// in ms, before the frame is drawn.
+func (f *Flythrough) update(now float64) {
+	if !f.playing {
+		return
+	}
+	switch {
+	case f.archive != nil:
+		f.time = float64(f.frames) / f.fps
+	case f.start < 0:
+		f.start = now
+		f.time = 0
+	default:
+		f.time = (now - f.start) / 1000
+		if f.loop && f.time > f.path.Duration() && f.path.Duration() > 0 {
+			f.start = now
+			f.time = 0
+		}
+	}
+	position, target := f.path.At(f.time)
+	f.viewer.camera.SetPose(
+		glf32.Vec3{float32(position[0]), float32(position[1]), float32(position[2])},
+		glf32.Vec3{float32(target[0]), float32(target[1]), float32(target[2])})
+}
+
+// frameDone captures the frame just drawn on canvas while capturing, and
+// ends playback after the path's last keyframe. It must run in the same
+// task as the drawing, before the browser clears the canvas.
+func (f *Flythrough) frameDone(canvas js.Value) {
+	if !f.playing {
+		return
+	}
+	if f.archive != nil {
+		url := canvas.Call("toDataURL", "image/png").String()
+		data, err := base64.StdEncoding.DecodeString(url[strings.IndexByte(url, ',')+1:])
+		if err == nil {
+			var w io.Writer
+			if w, err = f.archive.Create(fmt.Sprintf("frame_%05d.png", f.frames)); err == nil {
+				_, err = w.Write(data)
+			}
+		}
+		if err != nil {
+			js.Global().Get("console").Call("error", "flythrough capture: "+err.Error())
+		}
+		f.frames++
+	}
+	if f.time >= f.path.Duration() && !(f.loop && f.archive == nil) {
+		f.stop()
+	}
+}
+
+// stop ends playback, downloading any captured frames, and resolves the
+// promise of playCameraPath.
+func (f *Flythrough) stop() {
+	if !f.playing {
+		return
+	}
+	f.playing = false
+	captured := 0
+	if f.archive != nil {
+		captured = f.frames
+		if err := f.archive.Close(); err == nil && captured > 0 {
+			downloadBytes(f.filename, "application/zip", f.buf.Bytes())
+		}
+		f.archive, f.buf = nil, nil
+	}
+	if f.resolve.Truthy() {
+		f.resolve.Invoke(js.ValueOf(map[string]interface{}{"time": f.time, "frames": captured}))
+		f.resolve = js.Undefined()
+	}
+}
+
+// addCameraKeyframe(time) records the camera's current position and
+// target as a keyframe of the camera path at time seconds, by default 2
+// seconds after the last keyframe, or at 0 for the first. A keyframe
+// already at that time is replaced.
+//
+// Returns {time, keyframes}, the number of keyframes.
+func (v *Viewer) addCameraKeyframe(this js.Value, args []js.Value) interface{} {
+	time := 0.0
+	if len(v.flythrough.path.Keyframes) > 0 {
+		time = v.flythrough.path.Duration() + cameraPathStep
+	}
+	if len(args) > 0 && args[0].Type() == js.TypeNumber {
+		time = args[0].Float()
+	}
+	if time < 0 {
+		return jsError("addCameraKeyframe: time must not be negative")
+	}
+	pos := v.camera.Position()
+	v.flythrough.path.Add(campath.Keyframe{
+		Time:     time,
+		Position: [3]float64{float64(pos[0]), float64(pos[1]), float64(pos[2])},
+		Target:   [3]float64{float64(v.camera.target[0]), float64(v.camera.target[1]), float64(v.camera.target[2])},
+	})
+	return js.ValueOf(map[string]interface{}{"time": time, "keyframes": len(v.flythrough.path.Keyframes)})
+}
+
+// clearCameraPath() removes every keyframe of the camera path, stopping
+// playback.
+func (v *Viewer) clearCameraPath(this js.Value, args []js.Value) interface{} {
+	v.flythrough.stop()
+	v.flythrough.path = campath.Path{}
+	return nil
+}
+
+// getCameraPath() returns the camera path as {keyframes: [{time,
+// position, target}]}, times in seconds.
+func (v *Viewer) getCameraPath(this js.Value, args []js.Value) interface{} {
+	p := v.flythrough.path
+	if p.Keyframes == nil {
+		p.Keyframes = []campath.Keyframe{}
+	}
+	data, err := campath.Marshal(&p)
+	if err != nil {
+		return jsError("getCameraPath: " + err.Error())
+	}
+	return js.Global().Get("JSON").Call("parse", string(data))
+}
+
+// setCameraPath(path) replaces the camera path with one as getCameraPath
+// returns it, or its JSON text, stopping playback. Keyframe times must
+// increase.
+func (v *Viewer) setCameraPath(this js.Value, args []js.Value) interface{} {
+	if len(args) < 1 {
+		return jsError("setCameraPath: expected (path)")
+	}
+	text := args[0]
+	if text.Type() != js.TypeString {
+		text = js.Global().Get("JSON").Call("stringify", text)
+	}
+	p, err := campath.Unmarshal([]byte(text.String()))
+	if err != nil {
+		return jsError("setCameraPath: " + err.Error())
+	}
+	v.flythrough.stop()
+	v.flythrough.path = *p
+	return nil
+}
+
+// playCameraPath(params) flies the camera along the camera path, through
+// every keyframe on a Catmull-Rom spline. params may hold:
+//   - loop: repeat until stopCameraPath (ignored when capturing).
+//   - capture: save every frame as a PNG and download them as a zip for
+//     stitching into a video. Playback then advances one frame at fps per
+//     rendered frame, so the frames are evenly spaced even when rendering
+//     is slow.
+//   - fps: the capture frame rate, default 30.
+//   - filename: the zip's name, default "flythrough.zip".
+//
+// Returns a Promise of {time, frames} when playback ends, which rejects
+// with an Error if the path is empty.
+func (v *Viewer) playCameraPath(this js.Value, args []js.Value) interface{} {
+	promise := js.Global().Get("Promise")
+	if err := v.flythrough.path.Validate(); err != nil {
+		return promise.Call("reject", js.Global().Get("Error").New("playCameraPath: "+err.Error()))
+	}
+	params := js.Undefined()
+	if len(args) > 0 {
+		params = args[0]
+	}
+	fps := float64(jsFloat(params, "fps", 30))
+	if fps <= 0 {
+		return promise.Call("reject", js.Global().Get("Error").New("playCameraPath: fps must be positive"))
+	}
+	v.flythrough.stop()
+
+	var handler js.Func
+	handler = js.FuncOf(func(this js.Value, pargs []js.Value) interface{} {
+		handler.Release()
+		f := v.flythrough
+		f.playing, f.start, f.time, f.resolve = true, -1, 0, pargs[0]
+		f.loop = jsValue(params, "loop").Truthy()
+		f.fps, f.frames = fps, 0
+		if jsValue(params, "capture").Truthy() {
+			f.buf = &bytes.Buffer{}
+			f.archive = zip.NewWriter(f.buf)
+			f.filename = jsString(params, "filename", "flythrough.zip")
+		}
+		return nil
+	})
+	return promise.New(handler)
+}
+
+// stopCameraPath() stops camera path playback where it is, downloading
+// any frames captured so far.
+func (v *Viewer) stopCameraPath(this js.Value, args []js.Value) interface{} {
+	v.flythrough.stop()
+	return nil
+}
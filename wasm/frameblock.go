@@ -0,0 +1,81 @@
+// wasm/frameblock.go
+package main
+
+import (
+	"slices"
+	"syscall/js"
+
+	"github.com/sbecker11/webgl-point-cloud/glf32"
+)
+
+// frameBlockBinding is the uniform buffer binding point of the Frame block.
+const frameBlockBinding = 0
+
+// frameBlockFloats is the size of the Frame block in floats in its std140
+// layout: the view-projection, view and projection matrices, then the
+// camera position, light direction and light color, each padded to four
+// floats but the last, which the ambient term completes.
+const frameBlockFloats = 60
+
+// FrameBlock is the uniform buffer behind the Frame block of frame.glsl,
+// holding the view and the light every point program with
+// FeatureFrameBlock reads. With WebGL 2 it is filled and bound once per
+// frame, so switching between point programs costs no matrix or light
+// uploads; only the model matrix is set per object. Passes drawing
+// through another view, such as the minimap, replace just its
+// view-projection matrix. The viewer draws no fog, so the block holds
+// none.
+type FrameBlock struct {
+	buffer js.Value
+	data   []float32
+}
+
+func newFrameBlock() *FrameBlock {
+	return &FrameBlock{buffer: js.Undefined(), data: make([]float32, frameBlockFloats)}
+}
+
+// bind makes the buffer on first use and binds it to frameBlockBinding.
+func (b *FrameBlock) bind(gl js.Value) {
+	target := gl.Get("UNIFORM_BUFFER")
+	if b.buffer.IsUndefined() {
+		b.buffer = gl.Call("createBuffer")
+		gl.Call("bindBuffer", target, b.buffer)
+		gl.Call("bufferData", target, 4*frameBlockFloats, gl.Get("DYNAMIC_DRAW"))
+	}
+	gl.Call("bindBufferBase", target, frameBlockBinding, b.buffer)
+}
+
+// update fills the block for a frame seen through viewProj, the product
+// of proj, view and the vertical exaggeration, from c and lit by l, and
+// uploads and binds it.
+func (b *FrameBlock) update(gl js.Value, viewProj, view, proj glf32.Mat4, c *Camera, l *Light) {
+	copy(b.data[0:16], viewProj)
+	copy(b.data[16:32], view)
+	copy(b.data[32:48], proj)
+	copy(b.data[48:51], c.Position())
+	copy(b.data[52:55], l.direction(c))
+	copy(b.data[56:59], l.Color)
+	b.data[59] = l.Ambient
+	b.bind(gl)
+	gl.Call("bufferSubData", gl.Get("UNIFORM_BUFFER"), 0, glf32.ToFloat32Array(b.data))
+}
+
+// setViewProj makes the block's view-projection matrix viewProj,
+// uploading it if it differs.
+func (b *FrameBlock) setViewProj(gl js.Value, viewProj glf32.Mat4) {
+	if !b.buffer.IsUndefined() && slices.Equal(b.data[0:16], viewProj) {
+		return
+	}
+	copy(b.data[0:16], viewProj)
+	b.bind(gl)
+	gl.Call("bufferSubData", gl.Get("UNIFORM_BUFFER"), 0, glf32.ToFloat32Array(b.data[0:16]))
+}
+
+// bindFrameBlock points program's Frame block, if it has one, at
+// frameBlockBinding.
+func bindFrameBlock(gl, program js.Value) {
+	index := gl.Call("getUniformBlockIndex", program, "Frame")
+	if !index.Equal(gl.Get("INVALID_INDEX")) {
+		gl.Call("uniformBlockBinding", program, index, frameBlockBinding)
+	}
+}
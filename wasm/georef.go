@@ -0,0 +1,270 @@
+// wasm/georef.go
+package main
+
+import (
+	"fmt"
+	"math"
+	"syscall/js"
+
+	"github.com/sbecker11/webgl-point-cloud/crs"
+	"github.com/sbecker11/webgl-point-cloud/pointcloud"
+)
+
+// georeference returns cloud reprojected into the scene frame, setting the
+// frame first if it is unset, and records the cloud's CRS as o's source
+// CRS unless it already has one. Clouds in local coordinates, and clouds
+// that cannot be reprojected, are returned unchanged.
+func (v *Viewer) georeference(o *SceneObject, cloud *pointcloud.Cloud) *pointcloud.Cloud {
+	if cloud.CRS == "" {
+		return cloud
+	}
+	from, err := cloudFrame(cloud)
+	if err != nil {
+		js.Global().Get("console").Call("warn", fmt.Sprintf("Not reprojecting %s: %v", cloud.Name, err))
+		return cloud
+	}
+	if v.sceneFrame.CRS.IsZero() {
+		frame, err := frameAround(cloud, from.CRS)
+		if err != nil {
+			js.Global().Get("console").Call("warn", fmt.Sprintf("Not reprojecting %s: %v", cloud.Name, err))
+			return cloud
+		}
+		v.sceneFrame = frame
+	}
+	out, err := reprojectCloud(cloud, from, v.sceneFrame)
+	if err != nil {
+		js.Global().Get("console").Call("warn", fmt.Sprintf("Not reprojecting %s: %v", cloud.Name, err))
+		return cloud
+	}
+	if o.SourceCRS.IsZero() {
+		o.SourceCRS = from.CRS
+	}
+	return out
+}
+
+// frameAround returns a frame for showing cloud, which is in CRS c: c
+// itself, or its UTM zone if it is geographic, with the origin at the
+// cloud's centre rounded to whole units.
+func frameAround(cloud *pointcloud.Cloud, c crs.CRS) (crs.Frame, error) {
+	lo, hi := cloud.Bounds()
+	var center [3]float64
+	for k := range center {
+		center[k] = cloud.Offset[k] + (float64(lo[k])+float64(hi[k]))/2
+	}
+	if c == crs.WGS84 {
+		zone := int(math.Floor((center[0]+180)/6)) + 1
+		utm := crs.UTM(max(1, min(60, zone)), center[1] >= 0)
+		t, err := crs.NewTransformer(c, utm)
+		if err != nil {
+			return crs.Frame{}, err
+		}
+		if center, err = t.Transform(center); err != nil {
+			return crs.Frame{}, err
+		}
+		c = utm
+	}
+	return crs.Frame{CRS: c, Origin: [3]float64{math.Round(center[0]), math.Round(center[1]), math.Round(center[2])}}, nil
+}
+
+// reprojectCloud returns a copy of cloud with its coordinates converted
+// from one frame to another, or cloud itself if the frames are the same.
+func reprojectCloud(cloud *pointcloud.Cloud, from, to crs.Frame) (*pointcloud.Cloud, error) {
+	if from == to {
+		return cloud, nil
+	}
+	r, err := crs.NewReprojector(from, to)
+	if err != nil {
+		return nil, err
+	}
+	coords, err := r.Coords(cloud.Coords)
+	if err != nil {
+		return nil, err
+	}
+	out := *cloud
+	out.Coords, out.CRS, out.Offset = coords, to.CRS.String(), to.Origin
+	return &out, nil
+}
+
+// sourcePosition returns the coordinates of point i of o in its source
+// CRS, or false if o is not georeferenced.
+func sourcePosition(o *SceneObject, i int) ([3]float64, bool) {
+	if o.SourceCRS.IsZero() || o.Cloud.CRS == "" {
+		return [3]float64{}, false
+	}
+	from, err := cloudFrame(o.Cloud)
+	if err != nil {
+		return [3]float64{}, false
+	}
+	r, err := crs.NewReprojector(from, crs.Frame{CRS: o.SourceCRS})
+	if err != nil {
+		return [3]float64{}, false
+	}
+	p := o.Cloud.Point(i)
+	q, err := r.Point([3]float64{float64(p[0]), float64(p[1]), float64(p[2])})
+	return q, err == nil
+}
+
+// cloudFrame returns the frame of a georeferenced cloud's coordinates.
+func cloudFrame(cloud *pointcloud.Cloud) (crs.Frame, error) {
+	c, err := crs.Parse(cloud.CRS)
+	return crs.Frame{CRS: c, Origin: cloud.Offset}, err
+}
+
+// parseCRSParam reads a CRS from a JS string, or an EPSG code number.
+func parseCRSParam(v js.Value) (crs.CRS, error) {
+	if v.Type() == js.TypeNumber {
+		return crs.CRS{EPSG: v.Int()}, nil
+	}
+	if v.Type() != js.TypeString {
+		return crs.CRS{}, fmt.Errorf("expected a CRS such as \"EPSG:32633\"")
+	}
+	return crs.Parse(v.String())
+}
+
+// jsPoint3 reads an [x, y, z] array as float64s.
+func jsPoint3(v js.Value) ([3]float64, error) {
+	if v.Type() != js.TypeObject || v.Length() != 3 {
+		return [3]float64{}, fmt.Errorf("expected [x, y, z]")
+	}
+	return [3]float64{v.Index(0).Float(), v.Index(1).Float(), v.Index(2).Float()}, nil
+}
+
+// setObjectCRS(name, {crs, offset}) declares the CRS of the named object's
+// coordinates, e.g. "EPSG:32633" or 32633, for points added without one,
+// and reprojects it into the scene's frame. Coordinates in the CRS are the
+// object's coordinates plus offset (default [0, 0, 0]).
+//
+// Returns the scene's frame as with getSceneCRS, or {error}.
+func (v *Viewer) setObjectCRS(this js.Value, args []js.Value) interface{} {
+	if len(args) < 2 || args[1].Type() != js.TypeObject {
+		return jsError("setObjectCRS: expected (name, {crs, offset})")
+	}
+	o := v.scene.Object(args[0].String())
+	if o == nil {
+		return jsError("setObjectCRS: no object named " + args[0].String())
+	}
+	c, err := parseCRSParam(args[1].Get("crs"))
+	if err != nil {
+		return jsError("setObjectCRS: " + err.Error())
+	}
+	var offset [3]float64
+	if value := args[1].Get("offset"); !value.IsUndefined() {
+		if offset, err = jsPoint3(value); err != nil {
+			return jsError("setObjectCRS: offset: " + err.Error())
+		}
+	}
+	if !v.sceneFrame.CRS.IsZero() {
+		if _, err := crs.NewTransformer(c, v.sceneFrame.CRS); err != nil {
+			return jsError("setObjectCRS: " + err.Error())
+		}
+	}
+	cloud := *o.Cloud
+	cloud.CRS, cloud.Offset = c.String(), offset
+	o.SourceCRS = crs.CRS{}
+	v.scene.SetCloud(o, &cloud)
+	return v.getSceneCRS(js.Undefined(), nil)
+}
+
+// getSceneCRS() returns the frame georeferenced objects are shown in as
+// {crs, origin}: scene coordinates are coordinates in crs minus origin.
+// crs is "" until a georeferenced object is added or setSceneCRS is
+// called.
+func (v *Viewer) getSceneCRS(this js.Value, args []js.Value) interface{} {
+	o := v.sceneFrame.Origin
+	return js.ValueOf(map[string]interface{}{
+		"crs":    v.sceneFrame.CRS.String(),
+		"origin": []interface{}{o[0], o[1], o[2]},
+	})
+}
+
+// setSceneCRS({crs, origin}) sets the frame georeferenced objects are shown
+// in and reprojects them into it. origin defaults to the current origin
+// converted to the new CRS.
+//
+// Returns {error} if an object cannot be reprojected, leaving the frame
+// unchanged.
+func (v *Viewer) setSceneCRS(this js.Value, args []js.Value) interface{} {
+	if len(args) < 1 || args[0].Type() != js.TypeObject {
+		return jsError("setSceneCRS: expected ({crs, origin})")
+	}
+	c, err := parseCRSParam(args[0].Get("crs"))
+	if err == nil && c.IsZero() {
+		err = fmt.Errorf("crs is required")
+	}
+	if err != nil {
+		return jsError("setSceneCRS: " + err.Error())
+	}
+	frame := crs.Frame{CRS: c}
+	if value := args[0].Get("origin"); !value.IsUndefined() {
+		if frame.Origin, err = jsPoint3(value); err != nil {
+			return jsError("setSceneCRS: origin: " + err.Error())
+		}
+	} else if !v.sceneFrame.CRS.IsZero() {
+		t, err := crs.NewTransformer(v.sceneFrame.CRS, c)
+		if err != nil {
+			return jsError("setSceneCRS: " + err.Error())
+		}
+		origin, err := t.Transform(v.sceneFrame.Origin)
+		if err != nil {
+			return jsError("setSceneCRS: " + err.Error())
+		}
+		frame.Origin = [3]float64{math.Round(origin[0]), math.Round(origin[1]), math.Round(origin[2])}
+	}
+
+	// Reproject every object before replacing any, so that a failure
+	// leaves the scene as it was.
+	clouds := map[*SceneObject]*pointcloud.Cloud{}
+	for _, o := range v.scene.Objects() {
+		if o.Cloud.CRS == "" {
+			continue
+		}
+		from, err := cloudFrame(o.Cloud)
+		if err != nil {
+			return jsError("setSceneCRS: " + o.Cloud.Name + ": " + err.Error())
+		}
+		cloud, err := reprojectCloud(o.Cloud, from, frame)
+		if err != nil {
+			return jsError("setSceneCRS: " + o.Cloud.Name + ": " + err.Error())
+		}
+		clouds[o] = cloud
+	}
+	v.sceneFrame = frame
+	for o, cloud := range clouds {
+		if cloud != o.Cloud {
+			v.scene.SetCloud(o, cloud)
+		}
+	}
+	return nil
+}
+
+// reprojectPoint(point, from, to) converts an [x, y, z] point from one CRS
+// to another, e.g. reprojectPoint([12.49, 41.89, 0], "EPSG:4326",
+// "EPSG:32633"). Geographic coordinates are [longitude, latitude, height].
+//
+// Returns the converted point or {error}.
+func reprojectPoint(this js.Value, args []js.Value) interface{} {
+	if len(args) < 3 {
+		return jsError("reprojectPoint: expected (point, from, to)")
+	}
+	p, err := jsPoint3(args[0])
+	if err != nil {
+		return jsError("reprojectPoint: point: " + err.Error())
+	}
+	from, err := parseCRSParam(args[1])
+	if err != nil {
+		return jsError("reprojectPoint: " + err.Error())
+	}
+	to, err := parseCRSParam(args[2])
+	if err != nil {
+		return jsError("reprojectPoint: " + err.Error())
+	}
+	t, err := crs.NewTransformer(from, to)
+	if err != nil {
+		return jsError("reprojectPoint: " + err.Error())
+	}
+	q, err := t.Transform(p)
+	if err != nil {
+		return jsError("reprojectPoint: " + err.Error())
+	}
+	return []interface{}{q[0], q[1], q[2]}
+}
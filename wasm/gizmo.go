@@ -0,0 +1,203 @@
+// wasm/gizmo.go
+package main
+
+import (
+	"math"
+	"syscall/js"
+
+	"github.com/sbecker11/webgl-point-cloud/glf32"
+)
+
+const (
+	// gizmoRight is the gizmo's distance from the right edge of the canvas
+	// in pixels, clear of the control panel.
+	gizmoRight = 260
+	// gizmoHitRadius is how close, in pixels, a click must be to an axis
+	// tip to snap to its view.
+	gizmoHitRadius = 12
+)
+
+// gizmoAxes are the six axis directions the gizmo shows, in the order of
+// their cones, named as setCameraView takes them.
+var gizmoAxes = []struct {
+	name  string
+	dir   [3]float32
+	color [4]float32
+}{
+	{"+x", [3]float32{1, 0, 0}, [4]float32{0.9, 0.25, 0.25, 1}},
+	{"+y", [3]float32{0, 1, 0}, [4]float32{0.3, 0.85, 0.3, 1}},
+	{"+z", [3]float32{0, 0, 1}, [4]float32{0.3, 0.5, 1, 1}},
+	{"-x", [3]float32{-1, 0, 0}, [4]float32{0.45, 0.15, 0.15, 1}},
+	{"-y", [3]float32{0, -1, 0}, [4]float32{0.15, 0.4, 0.15, 1}},
+	{"-z", [3]float32{0, 0, -1}, [4]float32{0.15, 0.25, 0.5, 1}},
+}
+
+// Gizmo is an orientation gizmo drawn in a square viewport in the top-right
+// corner of the canvas: the world axes as seen from the camera's direction,
+// with a cone on each end. Clicking a cone snaps the camera to look along
+// that axis at the point it orbits.
+type Gizmo struct {
+	viewer  *Viewer
+	Visible bool
+	Size    int // side in canvas pixels
+
+	posBuf, colorBuf js.Value
+	shaftCount       int // vertices of the axis shafts, drawn as lines
+	coneCount        int // vertices of the cones, drawn as triangles
+}
+
+// newGizmo returns a visible gizmo of the default size.
+func newGizmo(v *Viewer) *Gizmo {
+	return &Gizmo{viewer: v, Visible: true, Size: 90}
+}
+
+// rect returns the gizmo's left and top edges and side in canvas pixels on
+// a canvas width pixels wide.
+func (g *Gizmo) rect(width float32) (x, y, size float32) {
+	size = float32(g.Size)
+	return width - gizmoRight - size, 10, size
+}
+
+// contains reports whether canvas position (x, y) is on the gizmo.
+func (g *Gizmo) contains(x, y, width float32) bool {
+	left, top, size := g.rect(width)
+	return g.Visible && x >= left && x < left+size && y >= top && y < top+size
+}
+
+// viewProj returns the gizmo's view-projection matrix: the camera's
+// rotation about the origin, without its distance or target.
+func (g *Gizmo) viewProj() glf32.Mat4 {
+	view := glf32.Orbit(glf32.Vec3{0, 0, 0}, g.viewer.camera.rotationY, g.viewer.camera.rotationX, 3)
+	return glf32.MultiplyMatrices(glf32.Orthographic(-1.3, 1.3, -1.3, 1.3, 0.1, 6), view)
+}
+
+// geometry returns the gizmo's vertices and colors: a shaft for each
+// positive axis as lines, then a cone at the end of every axis as
+// triangles.
+func (g *Gizmo) geometry() (positions, colors []float32, shafts int) {
+	for _, a := range gizmoAxes[:3] {
+		positions = append(positions, 0, 0, 0, a.dir[0]*0.75, a.dir[1]*0.75, a.dir[2]*0.75)
+		colors = append(colors, a.color[:]...)
+		colors = append(colors, a.color[:]...)
+	}
+	shafts = len(positions) / 3
+	const segments = 12
+	for _, a := range gizmoAxes {
+		// u and v span the plane of the cone's base.
+		u := [3]float32{a.dir[1], a.dir[2], a.dir[0]}
+		v := [3]float32{a.dir[1]*u[2] - a.dir[2]*u[1], a.dir[2]*u[0] - a.dir[0]*u[2], a.dir[0]*u[1] - a.dir[1]*u[0]}
+		rim := func(i int) [3]float32 {
+			t := 2 * math.Pi * float64(i) / segments
+			c, s := float32(0.12*math.Cos(t)), float32(0.12*math.Sin(t))
+			var p [3]float32
+			for k := range p {
+				p[k] = a.dir[k]*0.7 + u[k]*c + v[k]*s
+			}
+			return p
+		}
+		tip := [3]float32{a.dir[0], a.dir[1], a.dir[2]}
+		base := [3]float32{a.dir[0] * 0.7, a.dir[1] * 0.7, a.dir[2] * 0.7}
+		for i := 0; i < segments; i++ {
+			p, q := rim(i), rim(i+1)
+			for _, tri := range [][3][3]float32{{tip, p, q}, {base, q, p}} {
+				for _, vert := range tri {
+					positions = append(positions, vert[:]...)
+					colors = append(colors, a.color[:]...)
+				}
+			}
+		}
+	}
+	return positions, colors, shafts
+}
+
+// draw renders the gizmo over the frame.
+func (g *Gizmo) draw(gl js.Value, lineProgram, lineMvpLoc js.Value) {
+	if !g.Visible {
+		return
+	}
+	if g.posBuf.IsUndefined() {
+		positions, colors, shafts := g.geometry()
+		g.posBuf, g.colorBuf = createVBO(gl, positions), createVBO(gl, colors)
+		g.shaftCount, g.coneCount = shafts, len(positions)/3-shafts
+	}
+	width, height := gl.Get("drawingBufferWidth").Int(), gl.Get("drawingBufferHeight").Int()
+	left, top, size := g.rect(float32(width))
+	x, y, s := int(left), height-int(top)-int(size), int(size)
+
+	gl.Call("enable", gl.Get("SCISSOR_TEST"))
+	gl.Call("scissor", x, y, s, s)
+	gl.Call("clear", gl.Get("DEPTH_BUFFER_BIT"))
+	gl.Call("viewport", x, y, s, s)
+	gl.Call("useProgram", lineProgram)
+	gl.Call("uniformMatrix4fv", lineMvpLoc, false, glf32.ToFloat32Array(g.viewProj()))
+	// drawObject leaves both buffers bound, so the cones follow directly.
+	drawObject(gl, attribPosition, attribColor, g.posBuf, g.colorBuf, gl.Get("LINES"), g.shaftCount)
+	gl.Call("drawArrays", gl.Get("TRIANGLES"), g.shaftCount, g.coneCount)
+	gl.Call("viewport", 0, 0, width, height)
+	gl.Call("disable", gl.Get("SCISSOR_TEST"))
+}
+
+// axisAt returns the name of the axis whose tip is nearest canvas position
+// (x, y), within gizmoHitRadius, on a canvas width pixels wide. Tips nearer
+// the viewer win ties, as they are drawn on top.
+func (g *Gizmo) axisAt(x, y, width float32) string {
+	left, top, size := g.rect(width)
+	vp := g.viewProj()
+	best, bestDepth := "", float32(math.Inf(1))
+	for _, a := range gizmoAxes {
+		p := a.dir
+		// The projection is orthographic, so w is 1.
+		sx := left + (vp[0]*p[0]+vp[4]*p[1]+vp[8]*p[2]+vp[12]+1)/2*size
+		sy := top + (1-(vp[1]*p[0]+vp[5]*p[1]+vp[9]*p[2]+vp[13]))/2*size
+		depth := vp[2]*p[0] + vp[6]*p[1] + vp[10]*p[2] + vp[14]
+		dx, dy := sx-x, sy-y
+		if dx*dx+dy*dy <= gizmoHitRadius*gizmoHitRadius && depth < bestDepth {
+			best, bestDepth = a.name, depth
+		}
+	}
+	return best
+}
+
+// snapView turns the camera to look at the point it orbits from the side
+// of the named axis, "+x", "-x", "+y", "-y", "+z" or "-z", keeping its
+// distance. It reports false for an unknown name.
+func (v *Viewer) snapView(name string) bool {
+	rx, ry := float32(0), float32(0)
+	switch name {
+	case "+x":
+		ry = math.Pi / 2
+	case "-x":
+		ry = 3 * math.Pi / 2
+	case "+y":
+		rx = v.camera.maxRotationX
+	case "-y":
+		rx = v.camera.minRotationX
+	case "+z":
+	case "-z":
+		ry = math.Pi
+	default:
+		return false
+	}
+	v.camera.rotationX, v.camera.rotationY = rx, ry
+	v.camera.velocityX, v.camera.velocityY = 0, 0
+	return true
+}
+
+// setCameraView(axis) turns the camera to look along an axis at the point
+// it orbits, from the side of axis: "+x", "-x", "+y" (top), "-y"
+// (bottom), "+z" (front) or "-z" (back).
+func (v *Viewer) setCameraView(this js.Value, args []js.Value) interface{} {
+	if len(args) < 1 || args[0].Type() != js.TypeString {
+		return jsError("setCameraView: expected (axis)")
+	}
+	if !v.snapView(args[0].String()) {
+		return jsError("setCameraView: unknown axis " + args[0].String())
+	}
+	return nil
+}
+
+// showGizmo(visible) shows or hides the orientation gizmo.
+func (v *Viewer) showGizmo(this js.Value, args []js.Value) interface{} {
+	v.gizmo.Visible = len(args) < 1 || args[0].Truthy()
+	return nil
+}
@@ -0,0 +1,289 @@
+// wasm/histogram.go
+package main
+
+import (
+	"fmt"
+	"math"
+	"syscall/js"
+
+	"github.com/sbecker11/webgl-point-cloud/analysis"
+	"github.com/sbecker11/webgl-point-cloud/pointcloud"
+)
+
+// Size of the histogram canvas in CSS pixels.
+const (
+	histogramWidth  = 256
+	histogramHeight = 80
+	histogramBins   = 64
+)
+
+// HistogramWidget is a DOM overlay drawing the distribution of the active
+// color mode's values over the visible objects: intensity or height, or
+// the count of each class. Two handles bound a range: dragging them sets
+// the scalar colormap range, and when Filter is ticked, releasing them
+// filters the scene to the range.
+type HistogramWidget struct {
+	viewer   *Viewer
+	root     js.Value
+	title    js.Value
+	canvas   js.Value
+	ctx      js.Value
+	key      string // mode and scene data the bins were computed for
+	mode     ColorMode
+	hist     analysis.Histogram
+	classLo  float64 // class range, in classification mode
+	classHi  float64
+	filter   bool
+	dragging int // 0, or 1 or 2 while dragging the low or high handle
+}
+
+func (v *Viewer) newHistogramWidget() *HistogramWidget {
+	doc := js.Global().Get("document")
+	h := &HistogramWidget{viewer: v}
+	h.root = doc.Call("createElement", "div")
+	h.root.Set("style", "position:fixed;left:10px;bottom:10px;padding:8px;border-radius:6px;"+
+		"background:rgba(20,20,30,0.85);color:#eee;font:12px sans-serif;z-index:10;user-select:none")
+	h.title = doc.Call("createElement", "div")
+	h.title.Set("style", "margin-bottom:4px")
+	h.root.Call("appendChild", h.title)
+
+	ratio := js.Global().Get("devicePixelRatio").Float()
+	h.canvas = doc.Call("createElement", "canvas")
+	h.canvas.Set("width", int(histogramWidth*ratio))
+	h.canvas.Set("height", int(histogramHeight*ratio))
+	h.canvas.Set("style", fmt.Sprintf("display:block;width:%dpx;height:%dpx;cursor:ew-resize;touch-action:none", histogramWidth, histogramHeight))
+	h.ctx = h.canvas.Call("getContext", "2d")
+	h.ctx.Call("scale", ratio, ratio)
+	h.root.Call("appendChild", h.canvas)
+
+	controls := doc.Call("createElement", "div")
+	controls.Set("style", "display:flex;justify-content:space-between;align-items:center;margin-top:4px")
+	filterLabel := doc.Call("createElement", "label")
+	filterBox := doc.Call("createElement", "input")
+	filterBox.Set("type", "checkbox")
+	filterLabel.Call("appendChild", filterBox)
+	filterLabel.Call("appendChild", doc.Call("createTextNode", " "+msg("histogram.filter")))
+	reset := doc.Call("createElement", "button")
+	reset.Set("textContent", msg("button.reset"))
+	controls.Call("appendChild", filterLabel)
+	controls.Call("appendChild", reset)
+	h.root.Call("appendChild", controls)
+
+	listen(filterBox, "change", nil, func(el js.Value) {
+		h.filter = el.Get("checked").Bool()
+		if h.filter {
+			h.applyFilter()
+		} else {
+			v.scene.SetFilter(nil)
+		}
+	})
+	listen(reset, "click", nil, func(js.Value) { h.reset() })
+	h.canvas.Call("addEventListener", "pointerdown", js.FuncOf(func(this js.Value, args []js.Value) interface{} {
+		e := args[0]
+		lo, hi := h.rangeValues()
+		x := e.Get("offsetX").Float()
+		if math.Abs(x-h.x(lo)) <= math.Abs(x-h.x(hi)) {
+			h.dragging = 1
+		} else {
+			h.dragging = 2
+		}
+		h.canvas.Call("setPointerCapture", e.Get("pointerId"))
+		h.drag(x)
+		return nil
+	}))
+	h.canvas.Call("addEventListener", "pointermove", js.FuncOf(func(this js.Value, args []js.Value) interface{} {
+		if h.dragging != 0 {
+			h.drag(args[0].Get("offsetX").Float())
+		}
+		return nil
+	}))
+	h.canvas.Call("addEventListener", "pointerup", js.FuncOf(func(this js.Value, args []js.Value) interface{} {
+		if h.dragging != 0 {
+			h.dragging = 0
+			if h.filter {
+				h.applyFilter()
+			}
+		}
+		return nil
+	}))
+
+	doc.Get("body").Call("appendChild", h.root)
+	h.refresh()
+	return h
+}
+
+// refresh recomputes the bins if the color mode or the scene's data
+// changed, and redraws. It is cheap enough to call every frame.
+func (h *HistogramWidget) refresh() {
+	key := fmt.Sprint(h.viewer.classStyle.Mode) + h.viewer.sceneDataKey()
+	if key == h.key {
+		return
+	}
+	h.key, h.mode = key, h.viewer.classStyle.Mode
+	values := h.viewer.scalarValues(h.mode)
+	switch h.mode {
+	case ColorModeClassification:
+		lo, hi := valueRange(values)
+		n := int(hi) + 1
+		h.hist = analysis.Bin(values, n, 0, float64(n))
+		h.classLo, h.classHi = lo, hi
+	case ColorModeIntensity, ColorModeHeight, ColorModeDistance, ColorModeCurvature:
+		lo, hi := valueRange(values)
+		if r := h.viewer.scalarStyle.current(); !r.Auto {
+			// Keep a manual range that reaches outside the data in view.
+			lo, hi = math.Min(lo, float64(r.Min)), math.Max(hi, float64(r.Max))
+		}
+		h.hist = analysis.Bin(values, histogramBins, lo, hi)
+	default:
+		h.hist = analysis.Histogram{}
+	}
+	h.draw()
+}
+
+// rangeValues returns the handles' values.
+func (h *HistogramWidget) rangeValues() (lo, hi float64) {
+	if h.mode == ColorModeClassification {
+		return h.classLo, h.classHi
+	}
+	if r := h.viewer.scalarStyle.modes[h.mode]; r != nil {
+		return float64(r.Min), float64(r.Max)
+	}
+	return 0, 0
+}
+
+// x returns the canvas x coordinate of value v.
+func (h *HistogramWidget) x(v float64) float64 {
+	if h.mode == ColorModeClassification {
+		// Handles sit at the centers of the class bars.
+		v += 0.5
+	}
+	if h.hist.Max <= h.hist.Min {
+		return 0
+	}
+	return (v - h.hist.Min) / (h.hist.Max - h.hist.Min) * histogramWidth
+}
+
+// drag moves the dragged handle to canvas x coordinate x.
+func (h *HistogramWidget) drag(x float64) {
+	if h.hist.Max <= h.hist.Min {
+		return
+	}
+	v := h.hist.Min + math.Max(0, math.Min(1, x/histogramWidth))*(h.hist.Max-h.hist.Min)
+	if h.mode == ColorModeClassification {
+		v = math.Min(math.Floor(v), h.hist.Max-1)
+		if h.dragging == 1 {
+			h.classLo = math.Min(v, h.classHi)
+		} else {
+			h.classHi = math.Max(v, h.classLo)
+		}
+	} else if r := h.viewer.scalarStyle.modes[h.mode]; r != nil {
+		r.Auto = false
+		if h.dragging == 1 {
+			r.Min = float32(math.Min(v, float64(r.Max)))
+		} else {
+			r.Max = float32(math.Max(v, float64(r.Min)))
+		}
+	}
+	h.draw()
+}
+
+// applyFilter filters the scene to the handles' range.
+func (h *HistogramWidget) applyFilter() {
+	variable, ok := scalarVariable[h.mode]
+	if !ok {
+		return
+	}
+	lo, hi := h.rangeValues()
+	expr := fmt.Sprintf("%s >= %g && %s <= %g", variable, lo, variable, hi)
+	if err := h.viewer.setSceneFilter(expr); err != nil {
+		js.Global().Get("console").Call("warn", "Histogram filter: "+err.Error())
+	}
+}
+
+// reset moves the handles back to the extent of the data.
+func (h *HistogramWidget) reset() {
+	if r := h.viewer.scalarStyle.modes[h.mode]; r != nil {
+		r.Auto, h.viewer.scalarStyle.autoKey = true, ""
+		h.viewer.scalarStyle.current()
+	}
+	h.key = ""
+	h.refresh()
+	if h.filter {
+		h.applyFilter()
+	}
+}
+
+// draw redraws the bars, colored as the points are, with the bars outside
+// the range dimmed, and the handles.
+func (h *HistogramWidget) draw() {
+	ctx := h.ctx
+	ctx.Call("clearRect", 0, 0, histogramWidth, histogramHeight)
+	variable, ok := scalarVariable[h.mode]
+	if !ok || len(h.hist.Counts) == 0 {
+		h.title.Set("textContent", msgf("histogram.noValue", colorModeName(h.mode)))
+		return
+	}
+	lo, hi := h.rangeValues()
+	h.title.Set("textContent", msgf("histogram.range", variable, lo, hi))
+
+	peak := 0
+	for _, n := range h.hist.Counts {
+		peak = max(peak, n)
+	}
+	if peak == 0 {
+		return
+	}
+	barWidth := float64(histogramWidth) / float64(len(h.hist.Counts))
+	r := h.viewer.scalarStyle.modes[h.mode]
+	for i, n := range h.hist.Counts {
+		center := h.hist.Min + (float64(i)+0.5)/float64(len(h.hist.Counts))*(h.hist.Max-h.hist.Min)
+		var c [4]float32
+		inside := center >= lo && center <= hi
+		if h.mode == ColorModeClassification {
+			center = float64(i)
+			inside = center >= lo && center <= hi
+			if i < pointcloud.MaxClasses {
+				c = h.viewer.classStyle.Colors[i]
+			}
+		} else {
+			t := (center - float64(r.Min)) / math.Max(float64(r.Max-r.Min), 1e-12)
+			c[0], c[1], c[2] = r.Ramp.At(float32(t))
+		}
+		alpha := 1.0
+		if !inside {
+			alpha = 0.3
+		}
+		ctx.Set("fillStyle", fmt.Sprintf("rgba(%d,%d,%d,%.2f)", int(c[0]*255), int(c[1]*255), int(c[2]*255), alpha))
+		height := float64(n) / float64(peak) * (histogramHeight - 4)
+		ctx.Call("fillRect", float64(i)*barWidth, histogramHeight-height, math.Max(barWidth-1, 1), height)
+	}
+
+	ctx.Set("fillStyle", "#fff")
+	for _, v := range []float64{lo, hi} {
+		x := h.x(v)
+		ctx.Call("fillRect", x-1, 0, 2, histogramHeight)
+		ctx.Call("beginPath")
+		ctx.Call("moveTo", x-5, 0)
+		ctx.Call("lineTo", x+5, 0)
+		ctx.Call("lineTo", x, 6)
+		ctx.Call("fill")
+	}
+}
+
+// showHistogram(visible) shows or hides the histogram of the active color
+// mode's values, creating it on first use.
+func (v *Viewer) showHistogram(this js.Value, args []js.Value) interface{} {
+	visible := len(args) < 1 || args[0].Truthy()
+	if v.histogram == nil {
+		if !visible {
+			return nil
+		}
+		v.histogram = v.newHistogramWidget()
+	}
+	display := "none"
+	if visible {
+		display = "block"
+	}
+	v.histogram.root.Get("style").Set("display", display)
+	return nil
+}
@@ -0,0 +1,146 @@
+// wasm/hud.go
+package main
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+	"syscall/js"
+
+	"github.com/sbecker11/webgl-point-cloud/glf32"
+	"github.com/sbecker11/webgl-point-cloud/pointcloud"
+)
+
+// CoordinateHUD is a DOM overlay in the top-left corner showing the world
+// coordinates and attribute values of the point under the cursor. The
+// point is looked up at most once every Interval milliseconds rather than
+// every frame, and only while the cursor is over the canvas.
+type CoordinateHUD struct {
+	viewer   *Viewer
+	Visible  bool
+	Interval float64 // ms between lookups
+
+	root    js.Value
+	x, y    float32    // cursor in canvas pixels
+	over    bool       // whether the cursor is over the canvas
+	last    float64    // time of the last lookup, in ms
+	changed bool       // whether the cursor moved since the last lookup
+	seen    glf32.Mat4 // view-projection at the last lookup
+}
+
+// newCoordinateHUD returns a hidden HUD.
+func newCoordinateHUD(v *Viewer) *CoordinateHUD {
+	return &CoordinateHUD{viewer: v, Interval: 100}
+}
+
+// setupHUD tracks the cursor over v's canvas for the HUD.
+func (v *Viewer) setupHUD() {
+	canvas := v.canvas
+	canvas.Call("addEventListener", "pointermove", js.FuncOf(func(this js.Value, args []js.Value) interface{} {
+		v.hud.x, v.hud.y = canvasPoint(canvas, args[0])
+		v.hud.over, v.hud.changed = true, true
+		return nil
+	}))
+	canvas.Call("addEventListener", "pointerleave", js.FuncOf(func(this js.Value, args []js.Value) interface{} {
+		v.hud.over, v.hud.changed = false, true
+		return nil
+	}))
+}
+
+// setVisible shows or hides the HUD, creating it on first use.
+func (h *CoordinateHUD) setVisible(visible bool) {
+	h.Visible = visible
+	if h.root.IsUndefined() {
+		if !visible {
+			return
+		}
+		doc := js.Global().Get("document")
+		h.root = doc.Call("createElement", "div")
+		h.root.Set("style", "position:fixed;left:10px;top:10px;padding:6px 8px;border-radius:6px;min-width:160px;"+
+			"background:rgba(20,20,30,0.85);color:#eee;font:12px monospace;white-space:pre;pointer-events:none;z-index:10")
+		doc.Get("body").Call("appendChild", h.root)
+		h.changed = true
+	}
+	display := "none"
+	if visible {
+		display = "block"
+	}
+	h.root.Get("style").Set("display", display)
+}
+
+// update looks up the point under the cursor and shows it, if Interval has
+// passed since the last lookup and the cursor or the camera has moved. It
+// is called once a frame with the frame's time in ms.
+func (h *CoordinateHUD) update(now float64) {
+	if !h.Visible || now-h.last < h.Interval {
+		return
+	}
+	if !h.changed && slices.Equal(h.seen, h.viewer.lastViewProj) {
+		return
+	}
+	h.last, h.changed = now, false
+	h.seen = append(h.seen[:0], h.viewer.lastViewProj...)
+
+	// Keep clear of the minimap, which is drawn in the same corner.
+	left, top := h.viewer.canvasOffset()
+	if h.viewer.minimap.Visible {
+		canvas := h.viewer.canvas
+		scale := canvas.Get("clientWidth").Float() / canvas.Get("width").Float()
+		top += float64(minimapMargin+h.viewer.minimap.Size)*scale + 20
+	} else {
+		top += 10
+	}
+	h.root.Get("style").Set("left", fmt.Sprintf("%.0fpx", left+10))
+	h.root.Get("style").Set("top", fmt.Sprintf("%.0fpx", top))
+
+	if !h.over || h.viewer.lastViewProj == nil {
+		h.root.Set("textContent", msg("hud.noPoint"))
+		return
+	}
+	width, height := h.viewer.canvasSize()
+	o, i := h.viewer.nearestDrawnPoint(h.x, h.y, width, height)
+	if o == nil {
+		h.root.Set("textContent", msg("hud.noPoint"))
+		return
+	}
+	_, _, model := h.viewer.scene.Effective(o)
+	p := glf32.TransformVertices(append([]float32(nil), o.Cloud.Coords[i*3:i*3+3]...), model)
+	lines := []string{
+		fmt.Sprintf("%s #%d", o.Cloud.Name, i),
+		fmt.Sprintf("x %10.4f", p[0]),
+		fmt.Sprintf("y %10.4f", p[1]),
+		fmt.Sprintf("z %10.4f", p[2]),
+	}
+	if q, ok := sourcePosition(o, i); ok {
+		lines = append(lines, fmt.Sprintf("%s %.3f %.3f %.3f", o.SourceCRS, q[0], q[1], q[2]))
+	}
+	attributes := pointAttributes(o, i)
+	names := make([]string, 0, len(attributes))
+	for name := range attributes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		v := attributes[name]
+		if name == pointcloud.AttrClass {
+			c := v.(int)
+			lines = append(lines, fmt.Sprintf("%s %d (%s)", name, c, h.viewer.classStyle.name(uint8(c))))
+			continue
+		}
+		if f, ok := v.(float32); ok {
+			lines = append(lines, fmt.Sprintf("%s %g", name, f))
+			continue
+		}
+		lines = append(lines, fmt.Sprintf("%s %v", name, v))
+	}
+	h.root.Set("textContent", strings.Join(lines, "\n"))
+}
+
+// showCoordinates(visible) shows or hides the coordinate HUD, which gives
+// the world coordinates and attributes of the point under the cursor
+// (as with ?coords=1). Omitting visible shows it.
+func (v *Viewer) showCoordinates(this js.Value, args []js.Value) interface{} {
+	v.hud.setVisible(len(args) < 1 || args[0].Truthy())
+	return nil
+}
@@ -0,0 +1,130 @@
+// wasm/hull.go
+package main
+
+import (
+	"syscall/js"
+
+	"github.com/sbecker11/webgl-point-cloud/glf32"
+	"github.com/sbecker11/webgl-point-cloud/hull"
+	"github.com/sbecker11/webgl-point-cloud/mesh"
+)
+
+// measuredCoords returns the packed coordinates an analysis of o covers:
+// its selected points when any are selected, otherwise its visible ones.
+func measuredCoords(o *SceneObject) []float32 {
+	var coords []float32
+	for i, sel := range o.Selection {
+		if sel && (o.Mask == nil || o.Mask[i]) {
+			coords = append(coords, o.Cloud.Coords[i*3:i*3+3]...)
+		}
+	}
+	if coords != nil {
+		return coords
+	}
+	return o.VisibleCloud().Coords
+}
+
+// addMeasureMesh shows m as a wireframe named meshName, aligned with o, in
+// the default color or params.color.
+func (v *Viewer) addMeasureMesh(o *SceneObject, meshName string, m *mesh.Mesh, params js.Value) (*SceneMesh, error) {
+	sm, err := v.scene.AddMesh(meshName, m, append(glf32.Mat4(nil), o.Model...))
+	if err != nil {
+		return nil, err
+	}
+	sm.Solid, sm.Wireframe = false, true
+	sm.WireColor = [4]float32{1, 0.85, 0.2, 1}
+	if value := jsValue(params, "color"); !value.IsUndefined() {
+		c, err := jsColor(value)
+		if err != nil {
+			return nil, err
+		}
+		sm.WireColor = c
+	}
+	return sm, nil
+}
+
+// convexHull(name, params) computes the convex hull of an object's selected
+// points, or of its visible points when none are selected, and shows it as
+// a wireframe mesh named name + "-hull" (or params.mesh) in params.color.
+//
+// Returns {mesh, vertices, triangles, area, volume} in the object's own
+// coordinate units, or {error}, which is the case for fewer than four
+// points or points all on one plane.
+func (v *Viewer) convexHull(this js.Value, args []js.Value) interface{} {
+	if len(args) < 1 {
+		return jsError("convexHull: expected (name, params)")
+	}
+	o := v.scene.Object(args[0].String())
+	if o == nil {
+		return jsError("convexHull: no object named " + args[0].String())
+	}
+	params := js.Undefined()
+	if len(args) > 1 {
+		params = args[1]
+	}
+	m, err := hull.Hull(measuredCoords(o))
+	if err != nil {
+		return jsError("convexHull: " + err.Error())
+	}
+	meshName := jsString(params, "mesh", o.Cloud.Name+"-hull")
+	if _, err := v.addMeasureMesh(o, meshName, m, params); err != nil {
+		return jsError("convexHull: " + err.Error())
+	}
+	area := 0.0
+	for t := 0; t < m.TriangleCount(); t++ {
+		area += m.TriangleArea(t)
+	}
+	return js.ValueOf(map[string]interface{}{
+		"mesh":      meshName,
+		"vertices":  m.VertexCount(),
+		"triangles": m.TriangleCount(),
+		"area":      area,
+		"volume":    m.Volume(),
+	})
+}
+
+// orientedBox(name, params) computes a tight oriented bounding box of an
+// object's selected points, or of its visible points when none are
+// selected, and shows its edges as a wireframe mesh named name + "-obb" (or
+// params.mesh) in params.color.
+//
+// Returns {mesh, center, axes, size, volume} or {error}. axes holds three
+// unit vectors, longest side first, and size the box's dimensions along
+// them, all in the object's own coordinate units.
+func (v *Viewer) orientedBox(this js.Value, args []js.Value) interface{} {
+	if len(args) < 1 {
+		return jsError("orientedBox: expected (name, params)")
+	}
+	o := v.scene.Object(args[0].String())
+	if o == nil {
+		return jsError("orientedBox: no object named " + args[0].String())
+	}
+	params := js.Undefined()
+	if len(args) > 1 {
+		params = args[1]
+	}
+	box, err := hull.OrientedBox(measuredCoords(o))
+	if err != nil {
+		return jsError("orientedBox: " + err.Error())
+	}
+	meshName := jsString(params, "mesh", o.Cloud.Name+"-obb")
+	sm, err := v.addMeasureMesh(o, meshName, box.Mesh(), params)
+	if err == nil {
+		err = v.scene.SetMeshEdges(sm, hull.BoxEdges)
+	}
+	if err != nil {
+		return jsError("orientedBox: " + err.Error())
+	}
+	var axes []interface{}
+	for _, a := range box.Axes {
+		axes = append(axes, []interface{}{a[0], a[1], a[2]})
+	}
+	size := box.Size()
+	return js.ValueOf(map[string]interface{}{
+		"mesh":   meshName,
+		"center": []interface{}{box.Center[0], box.Center[1], box.Center[2]},
+		"axes":   axes,
+		"size":   []interface{}{size[0], size[1], size[2]},
+		"volume": box.Volume(),
+	})
+}
@@ -0,0 +1,276 @@
+// wasm/import_worker.go
+package main
+
+import (
+	"errors"
+	"fmt"
+	"syscall/js"
+
+	"github.com/sbecker11/webgl-point-cloud/glf32"
+	"github.com/sbecker11/webgl-point-cloud/importer"
+	"github.com/sbecker11/webgl-point-cloud/job"
+	"github.com/sbecker11/webgl-point-cloud/pointcloud"
+	"github.com/sbecker11/webgl-point-cloud/procgen"
+	"github.com/sbecker11/webgl-point-cloud/project"
+)
+
+// File imports run in Web Workers started from import_worker.js, each of
+// which loads another instance of this module. Without a document, main calls
+// runImportWorker instead of starting the viewer; the worker instance
+// parses files and posts the finished typed arrays back, transferring
+// rather than copying their buffers, so a large file never blocks
+// rendering or input on the page.
+
+// isWorker reports whether the module is running in a worker.
+func isWorker() bool {
+	return js.Global().Get("document").IsUndefined()
+}
+
+// runImportWorker registers parsePointFile for import_worker.js and tells
+// it the module is ready.
+func runImportWorker() {
+	js.Global().Set("parsePointFile", js.FuncOf(parsePointFile))
+	js.Global().Call("onWorkerReady")
+}
+
+// parsePointFile(data, params, progress) parses file contents into a cloud and
+// returns it as typed arrays: {name, positions, colors, normals, classes,
+// attributes, triangles, crs, offset}, where attributes is [{name,
+// components, type, values}] for the extra attributes, crs is the CRS of
+// georeferenced clouds and offset the cloud's Offset. Returns {error} on
+// failure.
+//
+// LAS, PLY and CSV files (see importer.PointFormat) are read as points;
+// other files are read as meshes and sampled. params may hold name, format
+// (inferred from the name, or for meshes the data, when omitted), points
+// (default 100000) and seed for sampling meshes. progress, if given, is
+// called with the fraction of the work done.
+func parsePointFile(this js.Value, args []js.Value) interface{} {
+	if len(args) < 1 {
+		return jsError("parsePointFile: expected (data, params)")
+	}
+	params := js.Undefined()
+	if len(args) > 1 {
+		params = args[1]
+	}
+	report := func(fraction float64) {
+		if len(args) > 2 && args[2].Type() == js.TypeFunction {
+			args[2].Invoke(fraction)
+		}
+	}
+	name := jsString(params, "name", "import")
+	format := jsString(params, "format", "")
+
+	var cloud *pointcloud.Cloud
+	triangles := 0
+	if importer.PointFormat(name, format) != "" {
+		var err error
+		if cloud, err = importer.ReadPoints(name, format, jsBytes(args[0])); err != nil {
+			return jsError("parsePointFile: " + err.Error())
+		}
+	} else {
+		m, err := importer.ReadMesh(name, format, jsBytes(args[0]))
+		if err != nil {
+			return jsError("parsePointFile: " + err.Error())
+		}
+		report(0.5)
+		generator := procgen.New(int64(jsFloat(params, "seed", 0)))
+		if cloud, err = importer.MeshToCloud(name, m, int(jsFloat(params, "points", 100000)), generator); err != nil {
+			return jsError("parsePointFile: " + err.Error())
+		}
+		triangles = m.TriangleCount()
+	}
+	report(0.9)
+
+	result := map[string]interface{}{
+		"name":      name,
+		"positions": glf32.ToFloat32Array(cloud.Coords),
+		"colors":    glf32.ToFloat32Array(cloud.Colors),
+		"triangles": triangles,
+		"offset":    []interface{}{cloud.Offset[0], cloud.Offset[1], cloud.Offset[2]},
+	}
+	if cloud.CRS != "" {
+		result["crs"] = cloud.CRS
+	}
+	if cloud.Normals != nil {
+		result["normals"] = glf32.ToFloat32Array(cloud.Normals)
+	}
+	if cloud.Classes != nil {
+		result["classes"] = glf32.ToUint8Array(cloud.Classes)
+	}
+	var attributes []interface{}
+	for _, a := range cloud.Schema() {
+		_, values, ok := cloud.Attribute(a.Name)
+		if !ok {
+			continue // built in
+		}
+		attributes = append(attributes, map[string]interface{}{
+			"name":       a.Name,
+			"components": a.Components,
+			"type":       int(a.Type),
+			"values":     glf32.ToFloat32Array(values),
+		})
+	}
+	result["attributes"] = attributes
+	return js.ValueOf(result)
+}
+
+// importFile(file, params) parses a File, Blob or ArrayBuffer in an import
+// worker and adds the result to the scene, replacing any object of the same
+// name. LAS, PLY and CSV (or XYZ, TXT and PTS) files are read as points;
+// OBJ and STL meshes are sampled into points as meshToPointCloud does.
+// params is optional and may hold format, points, seed and name (default:
+// the file's name, or "import").
+//
+// Each import is a job with its own worker, shown in the progress overlay;
+// cancelling it terminates the worker.
+//
+// Returns a Promise of {name, points, triangles, job} that rejects with an
+// Error if the file cannot be parsed or the import is cancelled.
+func (v *Viewer) importFile(this js.Value, args []js.Value) interface{} {
+	promise := js.Global().Get("Promise")
+	if len(args) < 1 || args[0].IsUndefined() || args[0].IsNull() {
+		return promise.Call("reject", js.Global().Get("Error").New("importFile: expected (file, params)"))
+	}
+	source := args[0]
+	params := js.Global().Get("Object").New()
+	if len(args) > 1 && args[1].Type() == js.TypeObject {
+		js.Global().Get("Object").Call("assign", params, args[1])
+	}
+	if jsValue(params, "name").IsUndefined() {
+		params.Set("name", jsString(source, "name", "import"))
+	}
+
+	var handler js.Func
+	handler = js.FuncOf(func(this js.Value, pargs []js.Value) interface{} {
+		handler.Release()
+		resolve, reject := pargs[0], pargs[1]
+		name := params.Get("name").String()
+		size := jsSize(source)
+		j := jobs.Start("Import " + name)
+		setLoading(j.ID, msgf("loading.file", name), 0, 0, size)
+		worker := js.Global().Get("Worker").New("import_worker.js")
+		var onMessage, onError js.Func
+		finished := false
+		finish := func(err error) {
+			if finished {
+				return
+			}
+			finished = true
+			worker.Call("terminate")
+			onMessage.Release()
+			onError.Release()
+			delete(cancelHooks, j.ID)
+			j.Finish(err)
+			endLoading(j.ID)
+			if err != nil && !errors.Is(err, job.ErrCancelled) {
+				showError(msgf("error.file", name), err)
+			}
+			if err != nil {
+				reject.Invoke(js.Global().Get("Error").New("importFile: " + err.Error()))
+			}
+		}
+		cancelHooks[j.ID] = func() { finish(job.ErrCancelled) }
+
+		onMessage = js.FuncOf(func(this js.Value, args []js.Value) interface{} {
+			msg := args[0].Get("data")
+			if p := msg.Get("progress"); !p.IsUndefined() {
+				j.Report(p.Float())
+				loaded := -1
+				if size >= 0 {
+					loaded = int(p.Float() * float64(size))
+				}
+				setLoading(j.ID, msgf("loading.file", name), p.Float(), loaded, size)
+				return nil
+			}
+			if e := msg.Get("error"); !e.IsUndefined() {
+				finish(errors.New(e.String()))
+				return nil
+			}
+			result := msg.Get("result")
+			obj, err := v.addImportedCloud(result)
+			if err != nil {
+				finish(err)
+				return nil
+			}
+			finish(nil)
+			resolve.Invoke(js.ValueOf(map[string]interface{}{
+				"name":      obj.Cloud.Name,
+				"points":    obj.Cloud.Len(),
+				"triangles": result.Get("triangles"),
+				"job":       j.ID,
+			}))
+			return nil
+		})
+		onError = js.FuncOf(func(this js.Value, args []js.Value) interface{} {
+			finish(errors.New("worker: " + args[0].Get("message").String()))
+			return nil
+		})
+		worker.Set("onmessage", onMessage)
+		worker.Set("onerror", onError)
+		worker.Call("postMessage", map[string]interface{}{"source": source, "params": params})
+		return nil
+	})
+	return promise.New(handler)
+}
+
+// jsSize returns the size in bytes of a File, Blob or ArrayBuffer, or -1.
+func jsSize(source js.Value) int {
+	for _, name := range []string{"size", "byteLength"} {
+		if v := jsValue(source, name); v.Type() == js.TypeNumber {
+			return v.Int()
+		}
+	}
+	return -1
+}
+
+// addImportedCloud rebuilds a cloud from the typed arrays parsePointFile
+// returned and adds it to the scene.
+func (v *Viewer) addImportedCloud(result js.Value) (*SceneObject, error) {
+	coords, err := jsFloat32s(result.Get("positions"))
+	if err != nil {
+		return nil, fmt.Errorf("positions: %v", err)
+	}
+	colors, err := jsFloat32s(result.Get("colors"))
+	if err != nil {
+		return nil, fmt.Errorf("colors: %v", err)
+	}
+	if len(coords)%3 != 0 || len(colors) != len(coords)/3*4 {
+		return nil, fmt.Errorf("mismatched positions and colors")
+	}
+	cloud := pointcloud.New(result.Get("name").String(), coords, colors)
+	if normals := result.Get("normals"); !normals.IsUndefined() {
+		if cloud.Normals, err = jsFloat32s(normals); err != nil {
+			return nil, fmt.Errorf("normals: %v", err)
+		}
+	}
+	if classes := result.Get("classes"); !classes.IsUndefined() {
+		cloud.Classes = jsBytes(classes)
+	}
+	if crs := result.Get("crs"); crs.Type() == js.TypeString {
+		cloud.CRS = crs.String()
+	}
+	if offset := result.Get("offset"); offset.Truthy() && offset.Length() == 3 {
+		for k := range cloud.Offset {
+			cloud.Offset[k] = offset.Index(k).Float()
+		}
+	}
+	attributes := result.Get("attributes")
+	for i := 0; attributes.Truthy() && i < attributes.Length(); i++ {
+		a := attributes.Index(i)
+		values, err := jsFloat32s(a.Get("values"))
+		if err != nil {
+			return nil, fmt.Errorf("attribute %s: %v", a.Get("name").String(), err)
+		}
+		cloud.SetAttribute(pointcloud.Attribute{
+			Name:       a.Get("name").String(),
+			Components: a.Get("components").Int(),
+			Type:       pointcloud.AttributeType(a.Get("type").Int()),
+		}, values)
+	}
+
+	obj := v.scene.Add(cloud, cloud.FitTransform(2))
+	obj.Source = project.Source{Type: "file"}
+	v.emitDatasetLoaded(obj)
+	return obj, nil
+}
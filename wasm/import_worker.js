@@ -0,0 +1,44 @@
+// wasm/import_worker.js
+// Parses one imported file off the render thread. It loads another instance
+// of main.wasm, which sees no document and registers parsePointFile instead
+// of starting the viewer, then answers importFile's {source, params}
+// message with {progress} updates and finally {result} or {error}. The
+// result's typed arrays are transferred, not copied, back to the page.
+
+importScripts("wasm_exec.js");
+
+const go = new Go();
+const importObject = go.importObject;
+if (importObject.go && !importObject.gojs) {
+	importObject.gojs = importObject.go;
+}
+
+const ready = new Promise((resolve) => {
+	self.onWorkerReady = resolve;
+});
+WebAssembly.instantiateStreaming(fetch("main.wasm"), importObject).then((result) => {
+	go.run(result.instance);
+});
+
+self.onmessage = async (event) => {
+	const { source, params } = event.data;
+	try {
+		const buffer = source instanceof ArrayBuffer ? source : await source.arrayBuffer();
+		self.postMessage({ progress: 0.2 });
+		await ready;
+		const result = self.parsePointFile(new Uint8Array(buffer), params, (fraction) => {
+			self.postMessage({ progress: fraction });
+		});
+		if (result.error) {
+			self.postMessage({ error: result.error });
+			return;
+		}
+		const transfer = [result.positions.buffer, result.colors.buffer];
+		if (result.normals) transfer.push(result.normals.buffer);
+		if (result.classes) transfer.push(result.classes.buffer);
+		for (const a of result.attributes) transfer.push(a.values.buffer);
+		self.postMessage({ result }, transfer);
+	} catch (err) {
+		self.postMessage({ error: String(err) });
+	}
+};
@@ -0,0 +1,139 @@
+// wasm/jobs.go
+package main
+
+import (
+	"fmt"
+	"syscall/js"
+
+	"github.com/sbecker11/webgl-point-cloud/job"
+)
+
+// jobs tracks the viewer's long operations. Each change is reported to
+// jobProgress listeners and shown in the progress overlay.
+var jobs = &job.Manager{}
+
+func init() {
+	jobs.OnChange = jobChanged
+}
+
+// cancelHooks holds, by job id, how to stop operations that cannot poll
+// their job, such as imports running in a worker.
+var cancelHooks = map[int]func(){}
+
+// progressOverlay is the progress bar overlay, built on first use, with a
+// row per running job.
+var progressOverlay struct {
+	root js.Value
+	rows map[int]js.Value
+}
+
+// jobChanged reports a job's progress to listeners, as an event of the
+// current viewer, and the overlay.
+func jobChanged(j *job.Job) {
+	payload := map[string]interface{}{
+		"id":       j.ID,
+		"name":     j.Name,
+		"progress": j.Progress(),
+		"state":    j.State().String(),
+	}
+	if err := j.Err(); err != nil && j.State() == job.Failed {
+		payload["error"] = err.Error()
+	}
+	currentViewer.emit(eventJobProgress, payload)
+	updateProgressOverlay(j)
+}
+
+// updateProgressOverlay adds, updates or removes the job's row and hides
+// the overlay when no jobs are running.
+func updateProgressOverlay(j *job.Job) {
+	doc := js.Global().Get("document")
+	o := &progressOverlay
+	if o.rows == nil {
+		o.rows = map[int]js.Value{}
+		o.root = doc.Call("createElement", "div")
+		o.root.Set("style", "position:fixed;bottom:16px;left:50%;transform:translateX(-50%);min-width:280px;"+
+			"padding:8px;border-radius:6px;background:rgba(20,20,30,0.85);color:#eee;"+
+			"font:12px sans-serif;z-index:20;display:none")
+		doc.Get("body").Call("appendChild", o.root)
+	}
+
+	row, ok := o.rows[j.ID]
+	if j.State() != job.Running {
+		if ok {
+			o.root.Call("removeChild", row)
+			delete(o.rows, j.ID)
+		}
+	} else {
+		if !ok {
+			row = doc.Call("createElement", "div")
+			row.Set("style", "display:flex;align-items:center;gap:6px;margin:2px 0")
+			label := doc.Call("createElement", "span")
+			label.Set("textContent", j.Name)
+			label.Set("style", "flex:1;white-space:nowrap;overflow:hidden;text-overflow:ellipsis")
+			bar := doc.Call("createElement", "progress")
+			bar.Set("max", 1)
+			cancelButton := doc.Call("createElement", "button")
+			cancelButton.Set("textContent", msg("button.cancel"))
+			id := j.ID
+			var onClick js.Func
+			onClick = js.FuncOf(func(this js.Value, args []js.Value) interface{} {
+				onClick.Release()
+				cancelJob(id)
+				return nil
+			})
+			cancelButton.Call("addEventListener", "click", onClick)
+			row.Call("appendChild", label)
+			row.Call("appendChild", bar)
+			row.Call("appendChild", cancelButton)
+			o.root.Call("appendChild", row)
+			o.rows[j.ID] = row
+		}
+		row.Call("querySelector", "progress").Set("value", j.Progress())
+	}
+
+	if len(o.rows) == 0 {
+		o.root.Get("style").Set("display", "none")
+	} else {
+		o.root.Get("style").Set("display", "block")
+	}
+}
+
+// cancelJob cancels the running job with the given id, running its cancel
+// hook if it has one. Returns false if there is no such job.
+func cancelJob(id int) bool {
+	j := jobs.Job(id)
+	if j == nil {
+		return false
+	}
+	j.Cancel()
+	if hook, ok := cancelHooks[id]; ok {
+		hook()
+	}
+	return true
+}
+
+// cancel(id) cancels the running job with the given id, or every running
+// job when id is omitted. Returns the number of jobs cancelled, or {error}
+// if there is no such job.
+func cancel(this js.Value, args []js.Value) interface{} {
+	if len(args) > 0 && args[0].Type() == js.TypeNumber {
+		if !cancelJob(args[0].Int()) {
+			return jsError(fmt.Sprintf("cancel: no running job %d", args[0].Int()))
+		}
+		return 1
+	}
+	running := jobs.Running()
+	for _, j := range running {
+		cancelJob(j.ID)
+	}
+	return len(running)
+}
+
+// getJobs() returns the running jobs as [{id, name, progress}].
+func getJobs(this js.Value, args []js.Value) interface{} {
+	var list []interface{}
+	for _, j := range jobs.Running() {
+		list = append(list, map[string]interface{}{"id": j.ID, "name": j.Name, "progress": j.Progress()})
+	}
+	return js.ValueOf(list)
+}
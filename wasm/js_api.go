@@ -0,0 +1,571 @@
+// wasm/js_api.go
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	_ "image/jpeg"
+	_ "image/png"
+	"syscall/js"
+
+	"github.com/sbecker11/webgl-point-cloud/colormap"
+	"github.com/sbecker11/webgl-point-cloud/glf32"
+	"github.com/sbecker11/webgl-point-cloud/importer"
+	"github.com/sbecker11/webgl-point-cloud/pointcloud"
+	"github.com/sbecker11/webgl-point-cloud/procgen"
+	"github.com/sbecker11/webgl-point-cloud/project"
+)
+
+// registerJSAPI exposes the viewer's functions to host page JavaScript.
+// Calling any of them draws the next frame in render-on-demand mode.
+func registerJSAPI() {
+	expose("addViewerListener", page(addViewerListener))
+	expose("removeViewerListener", page(removeViewerListener))
+	expose("addPoints", (*Viewer).addPoints)
+	expose("importFile", (*Viewer).importFile)
+	expose("cancel", page(cancel))
+	expose("getJobs", page(getJobs))
+	expose("createPointBuffer", page(createPointBuffer))
+	expose("getPointBuffer", page(getPointBuffer))
+	expose("commitPointBuffer", (*Viewer).commitPointBuffer)
+	expose("releasePointBuffer", page(releasePointBuffer))
+	expose("benchmarkTransfer", page(benchmarkTransfer))
+	expose("depthToPointCloud", (*Viewer).depthToPointCloud)
+	expose("heightmapToPointCloud", (*Viewer).heightmapToPointCloud)
+	expose("meshToPointCloud", (*Viewer).meshToPointCloud)
+	expose("setColorMode", (*Viewer).setColorMode)
+	expose("setClassVisible", (*Viewer).setClassVisible)
+	expose("setClassStyle", (*Viewer).setClassStyle)
+	expose("getClassPalette", (*Viewer).getClassPalette)
+	expose("resetClassPalette", (*Viewer).resetClassPalette)
+	expose("getClassCounts", (*Viewer).getClassCounts)
+	expose("setFilter", (*Viewer).setFilter)
+	expose("clearFilter", (*Viewer).clearFilter)
+	expose("getSchema", (*Viewer).getSchema)
+	expose("getObjects", (*Viewer).getObjects)
+	expose("setObjectStyle", (*Viewer).setObjectStyle)
+	expose("setDrawIndices", (*Viewer).setDrawIndices)
+	expose("addMesh", (*Viewer).addMesh)
+	expose("setMeshStyle", (*Viewer).setMeshStyle)
+	expose("removeMesh", (*Viewer).removeMesh)
+	expose("getMeshes", (*Viewer).getMeshes)
+	expose("reconstructSurface", (*Viewer).reconstructSurface)
+	expose("estimateNormals", (*Viewer).estimateNormals)
+	expose("setLight", (*Viewer).setLight)
+	expose("getLight", (*Viewer).getLight)
+	expose("convexHull", (*Viewer).convexHull)
+	expose("orientedBox", (*Viewer).orientedBox)
+	expose("estimateVolume", (*Viewer).estimateVolume)
+	expose("startProfile", (*Viewer).startProfile)
+	expose("extractProfile", (*Viewer).extractProfile)
+	expose("closeProfile", (*Viewer).closeProfile)
+	expose("showMinimap", (*Viewer).showMinimap)
+	expose("showCoordinates", (*Viewer).showCoordinates)
+	expose("showGizmo", (*Viewer).showGizmo)
+	expose("setCameraView", (*Viewer).setCameraView)
+	expose("addCameraKeyframe", (*Viewer).addCameraKeyframe)
+	expose("clearCameraPath", (*Viewer).clearCameraPath)
+	expose("getCameraPath", (*Viewer).getCameraPath)
+	expose("setCameraPath", (*Viewer).setCameraPath)
+	expose("playCameraPath", (*Viewer).playCameraPath)
+	expose("stopCameraPath", (*Viewer).stopCameraPath)
+	expose("startRecording", (*Viewer).startRecording)
+	expose("stopRecording", (*Viewer).stopRecording)
+	expose("isRecording", (*Viewer).isRecording)
+	expose("worldPositionAt", (*Viewer).worldPositionAt)
+	expose("setSnapping", (*Viewer).setSnapping)
+	expose("snapPoint", (*Viewer).snapPoint)
+	expose("setObjectCRS", (*Viewer).setObjectCRS)
+	expose("getSceneCRS", (*Viewer).getSceneCRS)
+	expose("setSceneCRS", (*Viewer).setSceneCRS)
+	expose("reprojectPoint", page(reprojectPoint))
+	expose("showBasemap", (*Viewer).showBasemap)
+	expose("showContactShadow", (*Viewer).showContactShadow)
+	expose("setSSAO", (*Viewer).setSSAO)
+	expose("getSSAO", (*Viewer).getSSAO)
+	expose("setEDL", (*Viewer).setEDL)
+	expose("getEDL", (*Viewer).getEDL)
+	expose("getCapabilities", (*Viewer).getCapabilities)
+	expose("setMessages", page(setMessages))
+	expose("getMessages", page(getMessages))
+	expose("setRenderOnDemand", (*Viewer).setRenderOnDemand)
+	expose("requestRender", page(requestRenderJS))
+	expose("getViewLink", (*Viewer).getViewLink)
+	expose("createViewer", page(createViewer))
+	expose("useViewer", page(useViewer))
+	expose("linkCameras", page(linkCameras))
+	expose("unlinkCameras", page(unlinkCameras))
+	expose("setSwipeCompare", (*Viewer).setSwipeCompare)
+	expose("stopSwipeCompare", (*Viewer).stopSwipeCompare)
+	expose("setDisplayFraction", (*Viewer).setDisplayFraction)
+	expose("setPointBudget", (*Viewer).setPointBudget)
+	expose("setPositionStorage", (*Viewer).setPositionStorage)
+	expose("getShaderVariants", (*Viewer).getShaderVariants)
+	expose("setTranslucency", (*Viewer).setTranslucency)
+	expose("setRevealAnimation", (*Viewer).setRevealAnimation)
+	expose("setVerticalExaggeration", (*Viewer).setVerticalExaggeration)
+	expose("connectROS", (*Viewer).connectROS)
+	expose("disconnectROS", (*Viewer).disconnectROS)
+	expose("getROSConnections", (*Viewer).getROSConnections)
+	expose("connectSensorStream", (*Viewer).connectSensorStream)
+	expose("disconnectSensorStream", (*Viewer).disconnectSensorStream)
+	expose("getSensorStream", (*Viewer).getSensorStream)
+	expose("setStreamRetention", (*Viewer).setStreamRetention)
+	expose("setTrajectoriesVisible", (*Viewer).setTrajectoriesVisible)
+	expose("getTrajectory", (*Viewer).getTrajectory)
+	expose("clearTrajectory", (*Viewer).clearTrajectory)
+	expose("setObjectPose", (*Viewer).setObjectPose)
+	expose("setControlSettings", page(setControlSettings))
+	expose("getControlSettings", page(getControlSettings))
+	expose("resetControlSettings", page(resetControlSettings))
+	expose("startMeasure", (*Viewer).startMeasure)
+	expose("compareClouds", (*Viewer).compareClouds)
+	expose("detectChanges", (*Viewer).detectChanges)
+	expose("clusterPoints", (*Viewer).clusterPoints)
+	expose("getClusterStats", (*Viewer).getClusterStats)
+	expose("downloadClusterStats", (*Viewer).downloadClusterStats)
+	expose("getStats", (*Viewer).getStats)
+	expose("showHistogram", (*Viewer).showHistogram)
+	expose("setScalarStyle", (*Viewer).setScalarStyle)
+	expose("getScalarStyle", (*Viewer).getScalarStyle)
+	expose("createLayer", (*Viewer).createLayer)
+	expose("removeLayer", (*Viewer).removeLayer)
+	expose("setLayer", (*Viewer).setLayer)
+	expose("moveToLayer", (*Viewer).moveToLayer)
+	expose("getLayerTree", (*Viewer).getLayerTree)
+	expose("setAdaptiveQuality", (*Viewer).setAdaptiveQuality)
+	expose("getQuality", (*Viewer).getQuality)
+	expose("runBenchmarks", (*Viewer).runBenchmarks)
+	expose("showPanel", page(showPanel))
+	expose("saveProject", (*Viewer).saveProject)
+	expose("downloadProject", (*Viewer).downloadProject)
+	expose("loadProject", (*Viewer).loadProject)
+	expose("selectBox", (*Viewer).selectBox)
+	expose("selectWhere", (*Viewer).selectWhere)
+	expose("startLasso", (*Viewer).startLasso)
+	expose("invertSelection", (*Viewer).invertSelection)
+	expose("growSelection", (*Viewer).growSelection)
+	expose("shrinkSelection", (*Viewer).shrinkSelection)
+	expose("saveNamedSelection", (*Viewer).saveNamedSelection)
+	expose("applyNamedSelection", (*Viewer).applyNamedSelection)
+	expose("setNamedSelectionColor", (*Viewer).setNamedSelectionColor)
+	expose("removeNamedSelection", (*Viewer).removeNamedSelection)
+	expose("getNamedSelections", (*Viewer).getNamedSelections)
+	expose("exportNamedSelection", (*Viewer).exportNamedSelection)
+	expose("labelSelection", (*Viewer).labelSelection)
+	expose("setLabelingMode", (*Viewer).setLabelingMode)
+	expose("exportLabels", (*Viewer).exportLabels)
+	expose("clearSelection", (*Viewer).clearSelection)
+	expose("deleteSelected", (*Viewer).deleteSelected)
+	expose("crop", (*Viewer).crop)
+	expose("undo", (*Viewer).undo)
+	expose("redo", (*Viewer).redo)
+	expose("getHistory", (*Viewer).getHistory)
+	expose("setTransform", (*Viewer).setTransform)
+	expose("getObjectTransform", (*Viewer).getObjectTransform)
+	expose("setObjectTransform", (*Viewer).setObjectTransform)
+	expose("transformObject", (*Viewer).transformObject)
+	expose("stopTransform", (*Viewer).stopTransform)
+	expose("applyTransform", (*Viewer).applyTransform)
+}
+
+// apiFuncs holds the functions registerJSAPI exposed, by name, each
+// called with the viewer it works on.
+var apiFuncs = map[string]func(v *Viewer, this js.Value, args []js.Value) interface{}{}
+
+// expose records fn in apiFuncs and sets it as the global function name,
+// working on the current viewer.
+func expose(name string, fn func(v *Viewer, this js.Value, args []js.Value) interface{}) {
+	apiFuncs[name] = fn
+	js.Global().Set(name, apiFunc(name, fn, nil))
+}
+
+// page adapts a JS API function that works on no viewer in particular,
+// such as one working on the page's jobs, for expose.
+func page(fn func(this js.Value, args []js.Value) interface{}) func(*Viewer, js.Value, []js.Value) interface{} {
+	return func(_ *Viewer, this js.Value, args []js.Value) interface{} {
+		return fn(this, args)
+	}
+}
+
+// apiFunc wraps the JS API function name so that calling it draws the
+// next frame and works on v, or on the current viewer if v is nil. A call
+// that makes it panic, e.g. with arguments of the wrong types, returns
+// {error} instead of stopping the module.
+func apiFunc(name string, fn func(v *Viewer, this js.Value, args []js.Value) interface{}, v *Viewer) js.Func {
+	return js.FuncOf(func(this js.Value, args []js.Value) (result interface{}) {
+		defer func() {
+			if r := recover(); r != nil {
+				result = jsError(fmt.Sprintf("%s: %v", name, r))
+			}
+		}()
+		requestRender()
+		if v == nil {
+			return fn(currentViewer, this, args)
+		}
+		return fn(v, this, args)
+	})
+}
+
+// jsError logs msg to the console and returns it to the JS caller as
+// {error: msg}.
+func jsError(msg string) interface{} {
+	js.Global().Get("console").Call("error", msg)
+	return js.ValueOf(map[string]interface{}{"error": msg})
+}
+
+// jsFloat returns obj[name] as a float32, or def if it is undefined.
+func jsFloat(obj js.Value, name string, def float32) float32 {
+	v := jsValue(obj, name)
+	if v.IsUndefined() {
+		return def
+	}
+	return float32(v.Float())
+}
+
+// jsString returns obj[name] as a string, or def if it is undefined.
+func jsString(obj js.Value, name string, def string) string {
+	v := jsValue(obj, name)
+	if v.IsUndefined() {
+		return def
+	}
+	return v.String()
+}
+
+// jsValue returns obj[name], or undefined if obj itself is undefined or null.
+func jsValue(obj js.Value, name string) js.Value {
+	if obj.IsUndefined() || obj.IsNull() {
+		return js.Undefined()
+	}
+	v := obj.Get(name)
+	if v.IsNull() {
+		return js.Undefined()
+	}
+	return v
+}
+
+// jsNumbers returns the elements of v if it is an array-like object of n
+// numbers, and false for anything else.
+func jsNumbers(v js.Value, n int) ([]float64, bool) {
+	if v.Type() != js.TypeObject || v.Get("length").Type() != js.TypeNumber || v.Length() != n {
+		return nil, false
+	}
+	values := make([]float64, n)
+	for i := range values {
+		e := v.Index(i)
+		if e.Type() != js.TypeNumber {
+			return nil, false
+		}
+		values[i] = e.Float()
+	}
+	return values, true
+}
+
+// jsVec3 converts a JS array [x, y, z] of finite numbers to a Vec3.
+func jsVec3(v js.Value) (glf32.Vec3, error) {
+	values, ok := jsNumbers(v, 3)
+	if !ok {
+		return nil, fmt.Errorf("expected an [x, y, z] array of numbers")
+	}
+	p := glf32.Vec3{float32(values[0]), float32(values[1]), float32(values[2])}
+	if !glf32.IsFinite(p) {
+		return nil, fmt.Errorf("expected finite numbers, got %v", p)
+	}
+	return p, nil
+}
+
+// jsMat4 converts a JS array of 16 finite numbers in column-major order to
+// a Mat4.
+func jsMat4(v js.Value) (glf32.Mat4, error) {
+	values, ok := jsNumbers(v, 16)
+	if !ok {
+		return nil, fmt.Errorf("matrix must be an array of 16 numbers")
+	}
+	m := make(glf32.Mat4, 16)
+	for i, value := range values {
+		m[i] = float32(value)
+	}
+	if !glf32.IsFinite(m) {
+		return nil, fmt.Errorf("matrix must have finite elements")
+	}
+	return m, nil
+}
+
+// jsColor converts a JS array [r, g, b] or [r, g, b, a] with components in
+// [0, 1] to a color; alpha defaults to 1.
+func jsColor(v js.Value) ([4]float32, error) {
+	c := [4]float32{0, 0, 0, 1}
+	if v.IsUndefined() || v.IsNull() || v.Get("length").IsUndefined() {
+		return c, fmt.Errorf("expected an [r, g, b] or [r, g, b, a] array")
+	}
+	n := v.Length()
+	if n != 3 && n != 4 {
+		return c, fmt.Errorf("expected an [r, g, b] or [r, g, b, a] array")
+	}
+	for i := 0; i < n; i++ {
+		f := float32(v.Index(i).Float())
+		if f < 0 || f > 1 {
+			return c, fmt.Errorf("color components must be in [0, 1]")
+		}
+		c[i] = f
+	}
+	return c, nil
+}
+
+// colorArray converts a color to a JS-ready [r, g, b, a] array.
+func colorArray(c [4]float32) []interface{} {
+	return []interface{}{c[0], c[1], c[2], c[3]}
+}
+
+// jsBytes copies a JS Uint8Array or Uint8ClampedArray into a Go byte slice.
+func jsBytes(v js.Value) []byte {
+	b := make([]byte, v.Get("length").Int())
+	js.CopyBytesToGo(b, v)
+	return b
+}
+
+// jsFloat32s copies a JS Float32Array into a Go slice (see
+// glf32.FromFloat32Array).
+func jsFloat32s(v js.Value) ([]float32, error) {
+	if v.IsUndefined() || v.IsNull() || !v.InstanceOf(js.Global().Get("Float32Array")) {
+		return nil, fmt.Errorf("expected a Float32Array")
+	}
+	return glf32.FromFloat32Array(v), nil
+}
+
+// jsImage converts a JS ImageData ({data, width, height}) or a Uint8Array
+// holding encoded PNG/JPEG bytes into a Go image.
+func jsImage(v js.Value) (image.Image, error) {
+	if v.IsUndefined() || v.IsNull() {
+		return nil, fmt.Errorf("missing image")
+	}
+	if data := v.Get("data"); !data.IsUndefined() {
+		w, h := v.Get("width").Int(), v.Get("height").Int()
+		img := image.NewRGBA(image.Rect(0, 0, w, h))
+		js.CopyBytesToGo(img.Pix, data)
+		return img, nil
+	}
+	img, _, err := image.Decode(bytes.NewReader(jsBytes(v)))
+	return img, err
+}
+
+// getSchema(name) returns the per-point attributes of the named scene
+// object as [{name, components, type}], or {error}.
+func (v *Viewer) getSchema(this js.Value, args []js.Value) interface{} {
+	if len(args) < 1 {
+		return jsError("getSchema: expected (name)")
+	}
+	o := v.scene.Object(args[0].String())
+	if o == nil {
+		return jsError("getSchema: no object named " + args[0].String())
+	}
+	var attributes []interface{}
+	for _, a := range o.Cloud.Schema() {
+		attributes = append(attributes, map[string]interface{}{
+			"name":       a.Name,
+			"components": a.Components,
+			"type":       a.Type.String(),
+		})
+	}
+	return js.ValueOf(attributes)
+}
+
+// addPoints(positions, colors, name) adds points already held in host page
+// memory to the scene, replacing any object of the same name.
+//
+// positions is a Float32Array of packed xyz coordinates. colors is an
+// optional Uint8Array of packed RGB or RGBA values in [0, 255]; points are
+// white when it is omitted. name defaults to "points".
+//
+// Returns {name, points} or {error}.
+func (v *Viewer) addPoints(this js.Value, args []js.Value) interface{} {
+	if len(args) < 1 {
+		return jsError("addPoints: expected (positions, colors, name)")
+	}
+	coords, err := jsFloat32s(args[0])
+	if err != nil {
+		return jsError("addPoints: positions: " + err.Error())
+	}
+	if len(coords)%3 != 0 {
+		return jsError("addPoints: positions length must be a multiple of 3")
+	}
+	n := len(coords) / 3
+	colors := make([]float32, n*4)
+	if len(args) > 1 && !args[1].IsUndefined() && !args[1].IsNull() {
+		if !args[1].InstanceOf(js.Global().Get("Uint8Array")) && !args[1].InstanceOf(js.Global().Get("Uint8ClampedArray")) {
+			return jsError("addPoints: colors: expected a Uint8Array")
+		}
+		rgba := jsBytes(args[1])
+		var stride int
+		switch len(rgba) {
+		case n * 3:
+			stride = 3
+		case n * 4:
+			stride = 4
+		default:
+			return jsError(fmt.Sprintf("addPoints: colors must have 3 or 4 components for each of the %d points", n))
+		}
+		for i := 0; i < n; i++ {
+			for j := 0; j < stride; j++ {
+				colors[i*4+j] = float32(rgba[i*stride+j]) / 255
+			}
+			if stride == 3 {
+				colors[i*4+3] = 1
+			}
+		}
+	} else {
+		for i := range colors {
+			colors[i] = 1
+		}
+	}
+	name := "points"
+	if len(args) > 2 && args[2].Type() == js.TypeString {
+		name = args[2].String()
+	}
+
+	cloud := pointcloud.New(name, coords, colors)
+	obj := v.scene.Add(cloud, cloud.FitTransform(2))
+	obj.Source = project.Source{Type: "host"}
+	v.emitDatasetLoaded(obj)
+	return js.ValueOf(map[string]interface{}{"name": name, "points": n})
+}
+
+// depthToPointCloud(depth, color, params) converts an RGB-D frame into a
+// point cloud and adds it to the scene, replacing any object of the same name.
+//
+// depth is a Uint8Array of PNG bytes (16-bit depth images keep full
+// precision) or an ImageData; color is an optional ImageData or encoded
+// image of the same size. params holds the intrinsics {fx, fy, cx, cy} and
+// optionally depthScale (meters per raw unit, default 0.001), minDepth,
+// maxDepth, stride and name (default "depth").
+//
+// Returns {name, points} or {error}.
+func (v *Viewer) depthToPointCloud(this js.Value, args []js.Value) interface{} {
+	if len(args) < 3 {
+		return jsError("depthToPointCloud: expected (depth, color, params)")
+	}
+	depthImg, err := jsImage(args[0])
+	if err != nil {
+		return jsError("depthToPointCloud: depth image: " + err.Error())
+	}
+	var colorImg image.Image
+	if !args[1].IsUndefined() && !args[1].IsNull() {
+		if colorImg, err = jsImage(args[1]); err != nil {
+			return jsError("depthToPointCloud: color image: " + err.Error())
+		}
+	}
+
+	params := args[2]
+	in := importer.Intrinsics{
+		Fx: jsFloat(params, "fx", 0),
+		Fy: jsFloat(params, "fy", 0),
+		Cx: jsFloat(params, "cx", float32(depthImg.Bounds().Dx())/2),
+		Cy: jsFloat(params, "cy", float32(depthImg.Bounds().Dy())/2),
+	}
+	opts := importer.DepthOptions{
+		MinDepth: jsFloat(params, "minDepth", 0),
+		MaxDepth: jsFloat(params, "maxDepth", 0),
+		Stride:   int(jsFloat(params, "stride", 1)),
+	}
+	name := jsString(params, "name", "depth")
+
+	depth := importer.DecodeDepth(depthImg, jsFloat(params, "depthScale", 0.001))
+	cloud, err := importer.DepthToCloud(name, depth, colorImg, in, opts)
+	if err != nil {
+		return jsError("depthToPointCloud: " + err.Error())
+	}
+	obj := v.scene.Add(cloud, cloud.FitTransform(2))
+	obj.Source = project.Source{Type: "depth"}
+	v.emitDatasetLoaded(obj)
+	return js.ValueOf(map[string]interface{}{"name": name, "points": cloud.Len()})
+}
+
+// heightmapToPointCloud(image, params) converts a grayscale heightmap into a
+// terrain point cloud and adds it to the scene, replacing any object of the
+// same name.
+//
+// image is an ImageData or a Uint8Array of PNG/JPEG bytes (16-bit PNGs keep
+// full precision). params is optional and may hold cellSize, heightScale,
+// stride, texture (an ImageData or encoded image used for colors), ramp (a
+// colormap name, default "terrain") and name (default "heightmap").
+//
+// Returns {name, points} or {error}.
+func (v *Viewer) heightmapToPointCloud(this js.Value, args []js.Value) interface{} {
+	if len(args) < 1 {
+		return jsError("heightmapToPointCloud: expected (image, params)")
+	}
+	img, err := jsImage(args[0])
+	if err != nil {
+		return jsError("heightmapToPointCloud: " + err.Error())
+	}
+	params := js.Undefined()
+	if len(args) > 1 {
+		params = args[1]
+	}
+
+	opts := importer.HeightmapOptions{
+		CellSize:    jsFloat(params, "cellSize", 1),
+		HeightScale: jsFloat(params, "heightScale", 0),
+		Stride:      int(jsFloat(params, "stride", 1)),
+	}
+	if opts.HeightScale == 0 {
+		// Default to a relief of a fifth of the grid width.
+		opts.HeightScale = opts.CellSize * float32(img.Bounds().Dx()) / 5
+	}
+	if tex := jsValue(params, "texture"); !tex.IsUndefined() {
+		if opts.Texture, err = jsImage(tex); err != nil {
+			return jsError("heightmapToPointCloud: texture: " + err.Error())
+		}
+	}
+	ramp, err := colormap.Lookup(jsString(params, "ramp", "terrain"))
+	if err != nil {
+		return jsError("heightmapToPointCloud: " + err.Error())
+	}
+	opts.Ramp = &ramp
+	name := jsString(params, "name", "heightmap")
+
+	cloud, err := importer.HeightmapToCloud(name, img, opts)
+	if err != nil {
+		return jsError("heightmapToPointCloud: " + err.Error())
+	}
+	obj := v.scene.Add(cloud, cloud.FitTransform(2))
+	obj.Source = project.Source{Type: "heightmap"}
+	v.emitDatasetLoaded(obj)
+	return js.ValueOf(map[string]interface{}{"name": name, "points": cloud.Len()})
+}
+
+// meshToPointCloud(data, params) parses an OBJ or STL mesh and samples its
+// surface into a point cloud with interpolated normals, adding it to the
+// scene and replacing any object of the same name.
+//
+// data is a Uint8Array of the file contents. params is optional and may hold
+// format ("obj" or "stl"; inferred from name or the data when omitted),
+// points (default 100000), seed (default 0) and name (default "mesh").
+//
+// Returns {name, points, triangles} or {error}.
+func (v *Viewer) meshToPointCloud(this js.Value, args []js.Value) interface{} {
+	if len(args) < 1 || args[0].IsUndefined() || args[0].IsNull() {
+		return jsError("meshToPointCloud: expected (data, params)")
+	}
+	params := js.Undefined()
+	if len(args) > 1 {
+		params = args[1]
+	}
+	name := jsString(params, "name", "mesh")
+
+	m, err := importer.ReadMesh(name, jsString(params, "format", ""), jsBytes(args[0]))
+	if err != nil {
+		return jsError("meshToPointCloud: " + err.Error())
+	}
+	numPoints := int(jsFloat(params, "points", 100000))
+	generator := procgen.New(int64(jsFloat(params, "seed", 0)))
+	cloud, err := importer.MeshToCloud(name, m, numPoints, generator)
+	if err != nil {
+		return jsError("meshToPointCloud: " + err.Error())
+	}
+	obj := v.scene.Add(cloud, cloud.FitTransform(2))
+	obj.Source = project.Source{Type: "mesh"}
+	v.emitDatasetLoaded(obj)
+	return js.ValueOf(map[string]interface{}{"name": name, "points": cloud.Len(), "triangles": m.TriangleCount()})
+}
@@ -0,0 +1,202 @@
+// wasm/labeling.go
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"syscall/js"
+
+	"github.com/sbecker11/webgl-point-cloud/annotate"
+	"github.com/sbecker11/webgl-point-cloud/edit"
+	"github.com/sbecker11/webgl-point-cloud/glf32"
+	"github.com/sbecker11/webgl-point-cloud/pointcloud"
+)
+
+// classCommand changes the classes of one or more objects' points.
+type classCommand struct {
+	viewer  *Viewer
+	desc    string
+	objects []*SceneObject
+	before  [][]uint8
+	after   [][]uint8
+}
+
+func (c *classCommand) set(classes [][]uint8) {
+	for i, o := range c.objects {
+		if c.viewer.inScene(o) && (classes[i] == nil || len(classes[i]) == o.Cloud.Len()) {
+			c.viewer.scene.SetClasses(o, classes[i])
+		}
+	}
+}
+
+func (c *classCommand) Do()            { c.set(c.after) }
+func (c *classCommand) Undo()          { c.set(c.before) }
+func (c *classCommand) String() string { return c.desc }
+
+// labelPoints sets the class of the points sel marks in each object, as
+// an undoable edit, and returns the number labelled. Objects without
+// classes start with every point unclassified.
+func (v *Viewer) labelPoints(objects []*SceneObject, sels [][]bool, class uint8) int {
+	cmd := &classCommand{viewer: v, desc: fmt.Sprintf("label class %d", class)}
+	labelled := 0
+	for k, o := range objects {
+		n := edit.Count(sels[k])
+		if n == 0 {
+			continue
+		}
+		classes := make([]uint8, o.Cloud.Len())
+		if o.Cloud.Classes != nil {
+			copy(classes, o.Cloud.Classes)
+		} else {
+			for i := range classes {
+				classes[i] = pointcloud.ClassUnclassified
+			}
+		}
+		for i, s := range sels[k] {
+			if s {
+				classes[i] = class
+			}
+		}
+		cmd.objects = append(cmd.objects, o)
+		cmd.before = append(cmd.before, o.Cloud.Classes)
+		cmd.after = append(cmd.after, classes)
+		labelled += n
+	}
+	if labelled > 0 {
+		v.history.Execute(cmd)
+	}
+	return labelled
+}
+
+// labelSelection(class, params) sets the class of the selected points, as
+// an undoable edit, for annotating training data. params is optional and
+// may hold selection, the name of a named selection to label instead of
+// the current one, and colorize: unless it is false the viewer switches to
+// the "classification" color mode so the labels show. Classes can be
+// named with setClassStyle.
+//
+// Returns {class, labelled} or {error}.
+func (v *Viewer) labelSelection(this js.Value, args []js.Value) interface{} {
+	if len(args) < 1 || args[0].Type() != js.TypeNumber {
+		return jsError("labelSelection: expected (class, params)")
+	}
+	class := args[0].Int()
+	if class < 0 || class >= pointcloud.MaxClasses {
+		return jsError(fmt.Sprintf("labelSelection: class must be in [0, %d)", pointcloud.MaxClasses))
+	}
+	params := js.Undefined()
+	if len(args) > 1 {
+		params = args[1]
+	}
+	objects := v.scene.Objects()
+	sels := make([][]bool, len(objects))
+	if name := jsString(params, "selection", ""); name != "" {
+		k := v.namedSelection(name)
+		if k < 0 {
+			return jsError("labelSelection: no selection named " + name)
+		}
+		for j, o := range objects {
+			sels[j] = make([]bool, o.Cloud.Len())
+			for _, i := range v.namedSelections[k].Points[o.Cloud.Name] {
+				if int(i) < len(sels[j]) {
+					sels[j][i] = true
+				}
+			}
+		}
+	} else {
+		for j, o := range objects {
+			sels[j] = o.Selection
+		}
+	}
+	labelled := v.labelPoints(objects, sels, uint8(class))
+	if value := jsValue(params, "colorize"); value.IsUndefined() || value.Truthy() {
+		v.classStyle.Mode = ColorModeClassification
+	}
+	return js.ValueOf(map[string]interface{}{"class": class, "labelled": labelled})
+}
+
+// setLabelingMode(enabled) turns the labeling mode on or off. While it is
+// on, pressing a number key 0 to 9 labels the selected points with that
+// class, as labelSelection does.
+func (v *Viewer) setLabelingMode(this js.Value, args []js.Value) interface{} {
+	if len(args) < 1 || args[0].Type() != js.TypeBoolean {
+		return jsError("setLabelingMode: expected (enabled)")
+	}
+	v.labeling = args[0].Bool()
+	if v.labeling {
+		v.classStyle.Mode = ColorModeClassification
+	}
+	return nil
+}
+
+// labelDataset returns the drawn points of the visible objects, or of the
+// named one, with their classes, in world coordinates unless local.
+func (v *Viewer) labelDataset(name string, local bool) (*annotate.Dataset, error) {
+	targets, err := v.editTargets(name)
+	if err != nil {
+		return nil, err
+	}
+	d := &annotate.Dataset{}
+	for _, o := range targets {
+		visible, _, model := v.scene.Effective(o)
+		if !visible {
+			continue
+		}
+		var coords []float32
+		var labels []uint8
+		for i, drawn := range v.selectable(o) {
+			if !drawn {
+				continue
+			}
+			coords = append(coords, o.Cloud.Coords[i*3:i*3+3]...)
+			label := pointcloud.ClassUnclassified
+			if o.Cloud.Classes != nil {
+				label = o.Cloud.Classes[i]
+			}
+			labels = append(labels, label)
+		}
+		if !local {
+			coords = glf32.TransformVertices(coords, model)
+		}
+		d.Add(coords, labels)
+	}
+	return d, nil
+}
+
+// exportLabels(params) offers the drawn points of the visible objects as
+// a labelled dataset for training segmentation models: each point's x, y,
+// z and class. params is optional and may hold format, "csv" (default) for
+// a CSV table with an x,y,z,label header or "bin" for little-endian
+// float32 x, y, z, label records; name, to export one object; local, to
+// keep each object's own coordinates instead of world ones; and filename
+// (default "labels.csv" or "labels.bin"). Points without a class are
+// labelled 1, unclassified.
+//
+// Returns {points, counts} with the number of points of each class, or
+// {error}.
+func (v *Viewer) exportLabels(this js.Value, args []js.Value) interface{} {
+	params := js.Undefined()
+	if len(args) > 0 {
+		params = args[0]
+	}
+	format := jsString(params, "format", "csv")
+	d, err := v.labelDataset(jsString(params, "name", ""), jsValue(params, "local").Truthy())
+	if err != nil {
+		return jsError("exportLabels: " + err.Error())
+	}
+	var b bytes.Buffer
+	if err := d.Write(&b, format); err != nil {
+		return jsError("exportLabels: " + err.Error())
+	}
+	filename := jsString(params, "filename", "labels."+format)
+	if format == "csv" {
+		downloadText(filename, "text/csv", b.String())
+	} else {
+		downloadBytes(filename, "application/octet-stream", b.Bytes())
+	}
+	counts := map[string]interface{}{}
+	for class, n := range d.Counts() {
+		counts[fmt.Sprint(class)] = n
+	}
+	return js.ValueOf(map[string]interface{}{"points": d.Len(), "counts": counts})
+}
@@ -0,0 +1,75 @@
+// wasm/labels.go
+package main
+
+import (
+	"fmt"
+	"syscall/js"
+
+	"github.com/sbecker11/webgl-point-cloud/glf32"
+)
+
+// meshLabel is a DOM text label pinned to a position in a mesh's own
+// coordinates.
+type meshLabel struct {
+	el  js.Value
+	pos [3]float32
+}
+
+// setMeshLabels replaces the labels of the named mesh with one per text,
+// at the matching position in the mesh's coordinates.
+func (v *Viewer) setMeshLabels(mesh string, positions [][3]float32, texts []string) {
+	v.removeMeshLabels(mesh)
+	doc := js.Global().Get("document")
+	labels := make([]meshLabel, len(texts))
+	for i, text := range texts {
+		el := doc.Call("createElement", "div")
+		el.Set("textContent", text)
+		el.Set("style", "position:fixed;transform:translate(-50%,-100%);padding:1px 4px;border-radius:3px;"+
+			"background:rgba(20,20,30,0.75);color:#fff;font:11px sans-serif;white-space:nowrap;pointer-events:none;z-index:6")
+		doc.Get("body").Call("appendChild", el)
+		labels[i] = meshLabel{el: el, pos: positions[i]}
+	}
+	v.meshLabels[mesh] = labels
+}
+
+// removeMeshLabels removes the labels of the named mesh.
+func (v *Viewer) removeMeshLabels(mesh string) {
+	for _, l := range v.meshLabels[mesh] {
+		l.el.Call("remove")
+	}
+	delete(v.meshLabels, mesh)
+}
+
+// updateLabels moves every label to its position in the last frame,
+// hiding labels behind the camera or of hidden meshes, and drops the
+// labels of removed meshes. It is called once a frame.
+func (v *Viewer) updateLabels() {
+	if len(v.meshLabels) == 0 || v.lastViewProj == nil {
+		return
+	}
+	canvas := v.canvas
+	width, height := canvas.Get("clientWidth").Float(), canvas.Get("clientHeight").Float()
+	left, top := v.canvasOffset()
+	for name, labels := range v.meshLabels {
+		sm := v.scene.Mesh(name)
+		if sm == nil {
+			v.removeMeshLabels(name)
+			continue
+		}
+		mvp := glf32.MultiplyMatrices(v.lastViewProj, sm.Model)
+		for _, l := range labels {
+			p := l.pos
+			w := mvp[3]*p[0] + mvp[7]*p[1] + mvp[11]*p[2] + mvp[15]
+			style := l.el.Get("style")
+			if !sm.Visible || w <= 0 {
+				style.Set("display", "none")
+				continue
+			}
+			x := (mvp[0]*p[0] + mvp[4]*p[1] + mvp[8]*p[2] + mvp[12]) / w
+			y := (mvp[1]*p[0] + mvp[5]*p[1] + mvp[9]*p[2] + mvp[13]) / w
+			style.Set("display", "block")
+			style.Set("left", fmt.Sprintf("%.1fpx", left+(float64(x)+1)/2*width))
+			style.Set("top", fmt.Sprintf("%.1fpx", top+(1-float64(y))/2*height))
+		}
+	}
+}
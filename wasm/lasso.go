@@ -0,0 +1,117 @@
+// wasm/lasso.go
+package main
+
+import (
+	"math"
+	"syscall/js"
+
+	"github.com/sbecker11/webgl-point-cloud/edit"
+	"github.com/sbecker11/webgl-point-cloud/glf32"
+)
+
+// lassoStep is how far, in canvas pixels, the pointer must move before the
+// lasso gets another vertex.
+const lassoStep = 3
+
+// LassoTool selects the drawn points whose projections fall inside a
+// freehand outline dragged over the canvas, closed back to its start on
+// release. Holding Shift as a drag starts adds to the selection and
+// holding Alt subtracts from it. It stays active for further lassos until
+// Escape.
+type LassoTool struct {
+	viewer   *Viewer
+	targets  []*SceneObject
+	mode     edit.Mode
+	down     bool
+	outline  []float32 // packed x, y vertices in canvas pixels
+	dragMode edit.Mode // mode of the drag, after its modifiers
+}
+
+func (t *LassoTool) Down(x, y float32) {
+	t.down, t.outline, t.dragMode = true, []float32{x, y}, t.mode
+	if pointerShift {
+		t.dragMode = edit.Add
+	} else if pointerAlt {
+		t.dragMode = edit.Subtract
+	}
+}
+
+func (t *LassoTool) Move(x, y float32) {
+	if !t.down {
+		return
+	}
+	n := len(t.outline)
+	if math.Hypot(float64(x-t.outline[n-2]), float64(y-t.outline[n-1])) < lassoStep {
+		return
+	}
+	t.outline = append(t.outline, x, y)
+	t.draw()
+}
+
+func (t *LassoTool) Up(x, y float32) {
+	if !t.down {
+		return
+	}
+	t.down = false
+	t.outline = append(t.outline, x, y)
+	t.viewer.clearOverlay()
+	if t.viewer.lastViewProj == nil {
+		return
+	}
+	width, height := t.viewer.canvasSize()
+	var targets []*SceneObject
+	var sels [][]bool
+	for _, o := range t.targets {
+		if !t.viewer.inScene(o) {
+			continue
+		}
+		_, _, model := t.viewer.scene.Effective(o)
+		drawn := t.viewer.selectable(o)
+		targets = append(targets, o)
+		sels = append(sels, t.viewer.snapping.tree(o).WithinPolygon(glf32.MultiplyMatrices(t.viewer.lastViewProj, model), width, height, t.outline,
+			func(i int) bool { return drawn[i] }))
+	}
+	t.viewer.applySelection(targets, sels, t.dragMode)
+}
+
+func (t *LassoTool) Cancel() {
+	t.down = false
+	t.viewer.clearOverlay()
+}
+
+// draw outlines the lasso so far on the overlay, dashing the edge that
+// will close it.
+func (t *LassoTool) draw() {
+	ctx := t.viewer.overlayContext()
+	ratio := js.Global().Get("devicePixelRatio").Float()
+	ctx.Set("strokeStyle", "#ffd24d")
+	ctx.Set("lineWidth", 2*ratio)
+	ctx.Call("beginPath")
+	ctx.Call("moveTo", t.outline[0], t.outline[1])
+	for i := 2; i+1 < len(t.outline); i += 2 {
+		ctx.Call("lineTo", t.outline[i], t.outline[i+1])
+	}
+	ctx.Call("stroke")
+	n := len(t.outline)
+	ctx.Call("setLineDash", []interface{}{4 * ratio, 4 * ratio})
+	ctx.Call("beginPath")
+	ctx.Call("moveTo", t.outline[n-2], t.outline[n-1])
+	ctx.Call("lineTo", t.outline[0], t.outline[1])
+	ctx.Call("stroke")
+	ctx.Call("setLineDash", []interface{}{})
+}
+
+// startLasso(params) lets the user select points by dragging a freehand
+// outline over the canvas: on release, the drawn points projecting inside
+// it, closed back to its start, are selected. params is as for selectBox.
+// The tool stays active for further lassos until Escape.
+//
+// Returns nothing, or {error}.
+func (v *Viewer) startLasso(this js.Value, args []js.Value) interface{} {
+	targets, mode, err := v.selectionParams(args, 0)
+	if err != nil {
+		return jsError("startLasso: " + err.Error())
+	}
+	v.setTool(&LassoTool{viewer: v, targets: targets, mode: mode})
+	return nil
+}
@@ -0,0 +1,117 @@
+// wasm/layers.go
+package main
+
+import (
+	"syscall/js"
+
+	"github.com/sbecker11/webgl-point-cloud/layer"
+)
+
+// layerInfo describes a layer and everything below it to JS.
+func layerInfo(l *layer.Layer) map[string]interface{} {
+	objects := make([]interface{}, len(l.Objects))
+	for i, o := range l.Objects {
+		objects[i] = o
+	}
+	children := make([]interface{}, len(l.Children))
+	for i, c := range l.Children {
+		children[i] = layerInfo(c)
+	}
+	transform := make([]interface{}, len(l.Transform))
+	for i, v := range l.Transform {
+		transform[i] = v
+	}
+	return map[string]interface{}{
+		"name":      l.Name,
+		"path":      l.Path(),
+		"visible":   l.Visible,
+		"opacity":   l.Opacity,
+		"transform": transform,
+		"objects":   objects,
+		"children":  children,
+	}
+}
+
+// getLayerTree() returns the root layer as {name, path, visible, opacity,
+// transform, objects, children}, with children nested the same way, for
+// building a layers panel.
+func (v *Viewer) getLayerTree(this js.Value, args []js.Value) interface{} {
+	return js.ValueOf(layerInfo(v.scene.Layers()))
+}
+
+// createLayer(path) creates the layer at a slash-separated path such as
+// "scans/2024", along with any missing parent layers.
+//
+// Returns the layer's info or {error}.
+func (v *Viewer) createLayer(this js.Value, args []js.Value) interface{} {
+	if len(args) < 1 {
+		return jsError("createLayer: expected (path)")
+	}
+	l, err := v.scene.Layers().Create(args[0].String())
+	if err != nil {
+		return jsError("createLayer: " + err.Error())
+	}
+	return js.ValueOf(layerInfo(l))
+}
+
+// removeLayer(path) deletes a layer; its objects and child layers move up
+// to its parent.
+func (v *Viewer) removeLayer(this js.Value, args []js.Value) interface{} {
+	if len(args) < 1 {
+		return jsError("removeLayer: expected (path)")
+	}
+	if err := v.scene.Layers().Remove(args[0].String()); err != nil {
+		return jsError("removeLayer: " + err.Error())
+	}
+	return nil
+}
+
+// setLayer(path, settings) changes a layer's visible, opacity (0 to 1) or
+// transform (16 column-major numbers); omitted fields are left unchanged.
+// They apply to every object and layer below it.
+//
+// Returns the layer's info or {error}.
+func (v *Viewer) setLayer(this js.Value, args []js.Value) interface{} {
+	if len(args) < 2 {
+		return jsError("setLayer: expected (path, settings)")
+	}
+	l := v.scene.Layers().Find(args[0].String())
+	if l == nil {
+		return jsError("setLayer: no layer " + args[0].String())
+	}
+	settings := args[1]
+	opacity := jsFloat(settings, "opacity", l.Opacity)
+	if opacity < 0 || opacity > 1 {
+		return jsError("setLayer: opacity must be in [0, 1]")
+	}
+	if value := jsValue(settings, "transform"); !value.IsUndefined() {
+		m, err := jsMat4(value)
+		if err != nil {
+			return jsError("setLayer: " + err.Error())
+		}
+		l.Transform = m
+	}
+	l.Opacity = opacity
+	if value := jsValue(settings, "visible"); !value.IsUndefined() {
+		l.Visible = value.Truthy()
+	}
+	return js.ValueOf(layerInfo(l))
+}
+
+// moveToLayer(name, path) moves a scene object into the layer at path,
+// creating the layer if needed. An empty path moves it to the root.
+func (v *Viewer) moveToLayer(this js.Value, args []js.Value) interface{} {
+	if len(args) < 2 {
+		return jsError("moveToLayer: expected (name, path)")
+	}
+	name := args[0].String()
+	if v.scene.Object(name) == nil {
+		return jsError("moveToLayer: no object named " + name)
+	}
+	l, err := v.scene.Layers().Create(args[1].String())
+	if err != nil {
+		return jsError("moveToLayer: " + err.Error())
+	}
+	v.scene.Layers().Move(name, l)
+	return nil
+}
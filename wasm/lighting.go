@@ -1,10 +1,145 @@
 // wasm/lighting.go
 package main
 
-// This file is a placeholder for future lighting models and shaders.
-// Currently, the application uses unlit materials with vertex colors.
+import (
+	"syscall/js"
 
-// A basic light struct for future use.
+	"github.com/sbecker11/webgl-point-cloud/glf32"
+)
+
+// Light is a directional light with an ambient term, shared by the lit
+// materials: meshes always, so their shape reads clearly under a point
+// cloud, and points with normals when Points is set, shading them like
+// small surface splats. Other points, lines and glyphs draw unlit with
+// their own colors.
 type Light struct {
-    // Position, color, intensity etc. would go here.
-} 
\ No newline at end of file
+	// Direction is the world-space direction the light travels in.
+	Direction glf32.Vec3
+	// Color scales the light a surface reflects toward the viewer.
+	Color glf32.Vec3
+	// Ambient is the fraction of a surface's color shown where the light
+	// does not reach.
+	Ambient float32
+	// Headlight makes the light travel along the view direction, following
+	// the camera, instead of Direction.
+	Headlight bool
+	// Points shades points that have normals.
+	Points bool
+}
+
+// defaultLight returns the light the viewer starts with.
+func defaultLight() Light {
+	return Light{
+		Direction: glf32.Normalize(glf32.Vec3{-0.4, -1, -0.6}),
+		Color:     glf32.Vec3{1, 1, 1},
+		Ambient:   0.3,
+	}
+}
+
+// lightLocations are a program's light uniforms: uLightDir, uLightColor
+// and uAmbient.
+type lightLocations struct {
+	dir, color, ambient js.Value
+}
+
+// lightUniforms looks up the light uniforms of program.
+func lightUniforms(gl, program js.Value) lightLocations {
+	return lightLocations{
+		dir:     gl.Call("getUniformLocation", program, "uLightDir"),
+		color:   gl.Call("getUniformLocation", program, "uLightColor"),
+		ambient: gl.Call("getUniformLocation", program, "uAmbient"),
+	}
+}
+
+// direction returns the world-space direction the light travels in this
+// frame, seen through c.
+func (l *Light) direction(c *Camera) glf32.Vec3 {
+	if l.Headlight {
+		if d := glf32.Normalize(glf32.Subtract(c.target, c.Position())); d[0] != 0 || d[1] != 0 || d[2] != 0 {
+			return d
+		}
+	}
+	return l.Direction
+}
+
+// apply uploads the light, seen through c, to the program using locs,
+// which must be in use.
+func (l *Light) apply(gl js.Value, locs lightLocations, c *Camera) {
+	d := l.direction(c)
+	gl.Call("uniform3f", locs.dir, d[0], d[1], d[2])
+	gl.Call("uniform3f", locs.color, l.Color[0], l.Color[1], l.Color[2])
+	gl.Call("uniform1f", locs.ambient, l.Ambient)
+}
+
+// applyPoints uploads the light to the point shader, which must be in use.
+// Points are shaded by programs with FeatureLitPoints; see pointFeatures.
+// Programs reading the frame block have the light from there.
+func (l *Light) applyPoints(gl js.Value, shader *PointShader, c *Camera) {
+	if !shader.frameBlock {
+		l.apply(gl, shader.light, c)
+	}
+}
+
+// lightInfo returns the light as a JS object.
+func (v *Viewer) lightInfo() js.Value {
+	return js.ValueOf(map[string]interface{}{
+		"direction": []interface{}{v.light.Direction[0], v.light.Direction[1], v.light.Direction[2]},
+		"color":     []interface{}{v.light.Color[0], v.light.Color[1], v.light.Color[2]},
+		"ambient":   v.light.Ambient,
+		"headlight": v.light.Headlight,
+		"points":    v.light.Points,
+	})
+}
+
+// setLight(params) changes the light shading meshes and, optionally,
+// points. params may set any of direction ([x, y, z], the world-space
+// direction the light travels in, normalized), color ([r, g, b] in [0, 1],
+// default white), ambient (the fraction of a surface's color shown in
+// shadow, in [0, 1], default 0.3), headlight (true to light along the view
+// direction, following the camera, instead of direction) and points (true
+// to shade points that have normals, see estimateNormals; default false);
+// others keep their values.
+//
+// Returns the light as getLight does, or {error}.
+func (v *Viewer) setLight(this js.Value, args []js.Value) interface{} {
+	if len(args) < 1 || args[0].Type() != js.TypeObject {
+		return jsError("setLight: expected ({direction, color, ambient, headlight, points})")
+	}
+	params := args[0]
+	l := v.light
+	if value := jsValue(params, "direction"); !value.IsUndefined() {
+		d, err := jsVec3(value)
+		if err != nil {
+			return jsError("setLight: direction: " + err.Error())
+		}
+		if l.Direction = glf32.Normalize(d); l.Direction[0] == 0 && l.Direction[1] == 0 && l.Direction[2] == 0 {
+			return jsError("setLight: direction must not be zero")
+		}
+	}
+	if value := jsValue(params, "color"); !value.IsUndefined() {
+		c, err := jsColor(value)
+		if err != nil {
+			return jsError("setLight: color: " + err.Error())
+		}
+		l.Color = glf32.Vec3{c[0], c[1], c[2]}
+	}
+	if l.Ambient = jsFloat(params, "ambient", l.Ambient); l.Ambient < 0 || l.Ambient > 1 {
+		return jsError("setLight: ambient must be in [0, 1]")
+	}
+	for _, flag := range []struct {
+		name string
+		v    *bool
+	}{{"headlight", &l.Headlight}, {"points", &l.Points}} {
+		if value := jsValue(params, flag.name); value.Type() == js.TypeBoolean {
+			*flag.v = value.Bool()
+		}
+	}
+	v.light = l
+	return v.lightInfo()
+}
+
+// getLight() returns the light as {direction, color, ambient, headlight,
+// points}.
+func (v *Viewer) getLight(this js.Value, args []js.Value) interface{} {
+	return v.lightInfo()
+}
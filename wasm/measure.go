@@ -0,0 +1,118 @@
+// wasm/measure.go
+package main
+
+import (
+	"fmt"
+	"math"
+	"syscall/js"
+)
+
+// MeasureTool measures the distance between two world positions by
+// dragging from one to the other. The ends snap to points, falling back
+// to the ground plane y = 0. The last measurement stays drawn, following
+// the camera, until the tool is cancelled. A tool made already down, with
+// a set, measures from a to wherever the next click lands.
+type MeasureTool struct {
+	viewer   *Viewer
+	down     bool
+	measured bool
+	a, b     [3]float64
+}
+
+func (t *MeasureTool) Down(x, y float32) {
+	if t.down {
+		return
+	}
+	width, height := t.viewer.canvasSize()
+	a, ok := t.viewer.worldAt(x, y, width, height)
+	if !ok {
+		return
+	}
+	t.down, t.measured, t.a, t.b = true, true, a, a
+}
+
+func (t *MeasureTool) Move(x, y float32) {
+	if !t.down {
+		return
+	}
+	width, height := t.viewer.canvasSize()
+	if b, ok := t.viewer.worldAt(x, y, width, height); ok {
+		t.b = b
+	}
+}
+
+func (t *MeasureTool) Up(x, y float32) {
+	if !t.down {
+		return
+	}
+	t.Move(x, y)
+	t.down = false
+	d := [3]float64{t.b[0] - t.a[0], t.b[1] - t.a[1], t.b[2] - t.a[2]}
+	t.viewer.emit(eventDistance, map[string]interface{}{
+		"from":     []interface{}{t.a[0], t.a[1], t.a[2]},
+		"to":       []interface{}{t.b[0], t.b[1], t.b[2]},
+		"delta":    []interface{}{d[0], d[1], d[2]},
+		"distance": math.Sqrt(d[0]*d[0] + d[1]*d[1] + d[2]*d[2]),
+	})
+}
+
+func (t *MeasureTool) Cancel() {
+	t.down, t.measured = false, false
+	t.viewer.clearOverlay()
+}
+
+// draw draws the measurement's line and length on the overlay as seen in
+// the last frame. It is called once a frame while the tool is active.
+func (t *MeasureTool) draw() {
+	if !t.measured || t.viewer.lastViewProj == nil {
+		return
+	}
+	ctx := t.viewer.overlayContext()
+	width, height := t.viewer.canvasSize()
+	ax, ay, okA := t.screen(t.a, width, height)
+	bx, by, okB := t.screen(t.b, width, height)
+	if !okA || !okB {
+		return
+	}
+	ratio := js.Global().Get("devicePixelRatio").Float()
+	ctx.Set("strokeStyle", "#ffd24d")
+	ctx.Set("fillStyle", "#ffd24d")
+	ctx.Set("lineWidth", 2*ratio)
+	ctx.Call("beginPath")
+	ctx.Call("moveTo", ax, ay)
+	ctx.Call("lineTo", bx, by)
+	ctx.Call("stroke")
+	for _, p := range [][2]float64{{ax, ay}, {bx, by}} {
+		ctx.Call("beginPath")
+		ctx.Call("arc", p[0], p[1], 3*ratio, 0, 2*math.Pi)
+		ctx.Call("fill")
+	}
+	d := [3]float64{t.b[0] - t.a[0], t.b[1] - t.a[1], t.b[2] - t.a[2]}
+	ctx.Set("font", fmt.Sprintf("%.0fpx sans-serif", 12*ratio))
+	ctx.Set("textAlign", "center")
+	ctx.Call("fillText", fmt.Sprintf("%.3f", math.Sqrt(d[0]*d[0]+d[1]*d[1]+d[2]*d[2])), (ax+bx)/2, (ay+by)/2-6*ratio)
+}
+
+// screen returns world position p's position on the canvas in the last
+// frame, in canvas pixels, or false if it is behind the camera.
+func (t *MeasureTool) screen(p [3]float64, width, height float32) (x, y float64, ok bool) {
+	m := t.viewer.lastViewProj
+	px, py, pz := float32(p[0]), float32(p[1]), float32(p[2])
+	w := m[3]*px + m[7]*py + m[11]*pz + m[15]
+	if w <= 0 {
+		return 0, 0, false
+	}
+	nx := (m[0]*px + m[4]*py + m[8]*pz + m[12]) / w
+	ny := (m[1]*px + m[5]*py + m[9]*pz + m[13]) / w
+	return float64((nx + 1) / 2 * width), float64((1 - ny) / 2 * height), true
+}
+
+// startMeasure() lets the user measure distances by dragging between two
+// positions on the canvas; the ends snap to points, or fall on the ground
+// plane y = 0. Each measurement emits distanceMeasured with {from, to,
+// delta, distance} in world units. The tool stays active for further
+// measurements until Escape.
+func (v *Viewer) startMeasure(this js.Value, args []js.Value) interface{} {
+	v.setTool(&MeasureTool{viewer: v})
+	return nil
+}
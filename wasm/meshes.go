@@ -0,0 +1,343 @@
+// wasm/meshes.go
+package main
+
+import (
+	"fmt"
+	"syscall/js"
+
+	"github.com/sbecker11/webgl-point-cloud/glf32"
+	"github.com/sbecker11/webgl-point-cloud/importer"
+	"github.com/sbecker11/webgl-point-cloud/mesh"
+	"github.com/sbecker11/webgl-point-cloud/pointcloud"
+)
+
+// SceneMesh is a triangle mesh drawn with the points, such as a building
+// model or CAD part for reference. It draws as a lit solid, a wireframe of
+// its edges, or both; the wireframe shares the solid's vertex buffer
+// through a second index buffer.
+type SceneMesh struct {
+	Name      string
+	Mesh      *mesh.Mesh
+	Model     glf32.Mat4
+	Visible   bool
+	Solid     bool
+	Wireframe bool
+	Color     [4]float32
+	WireColor [4]float32
+	positions js.Value
+	normals   js.Value
+	triangles *IndexBuffer
+	edges     *IndexBuffer
+}
+
+// MeshShader is the lit mesh program and its locations.
+type MeshShader struct {
+	program   js.Value
+	normalLoc int
+	mvpLoc    js.Value
+	modelLoc  js.Value
+	colorLoc  js.Value
+	light     lightLocations
+}
+
+func setupMeshShader(gl js.Value) (*MeshShader, error) {
+	vertShader := `
+attribute vec4 aPosition;
+attribute vec3 aNormal;
+uniform mat4 uMvpMatrix;
+uniform mat4 uModelMatrix;
+varying vec3 vNormal;
+void main() {
+	gl_Position = uMvpMatrix * aPosition;
+	vNormal = (uModelMatrix * vec4(aNormal, 0.0)).xyz;
+}`
+	fragShader := `
+precision mediump float;
+uniform vec4 uColor;
+uniform vec3 uLightDir;
+uniform vec3 uLightColor;
+uniform float uAmbient;
+varying vec3 vNormal;
+void main() {
+	// Lit from both sides, as imported meshes often have inconsistent
+	// winding.
+	float diffuse = abs(dot(normalize(vNormal), -uLightDir));
+	gl_FragColor = vec4(uColor.rgb * (uAmbient + (1.0 - uAmbient) * diffuse * uLightColor), uColor.a);
+}`
+	program, err := createShaderProgram(gl, vertShader, fragShader)
+	if err != nil {
+		return nil, err
+	}
+	return &MeshShader{
+		program:   program,
+		normalLoc: gl.Call("getAttribLocation", program, "aNormal").Int(),
+		mvpLoc:    gl.Call("getUniformLocation", program, "uMvpMatrix"),
+		modelLoc:  gl.Call("getUniformLocation", program, "uModelMatrix"),
+		colorLoc:  gl.Call("getUniformLocation", program, "uColor"),
+		light:     lightUniforms(gl, program),
+	}, nil
+}
+
+// AddMesh uploads m and adds it to the scene with the given model matrix,
+// drawn solid in light gray. Normals are computed if m has none. An
+// existing mesh with the same name is replaced, keeping its style.
+func (s *Scene) AddMesh(name string, m *mesh.Mesh, model glf32.Mat4) (*SceneMesh, error) {
+	if len(m.Normals) != len(m.Positions) {
+		m.ComputeVertexNormals()
+	}
+	triangles, err := s.viewer.createIndexBuffer(s.gl, m.Indices)
+	if err != nil {
+		return nil, err
+	}
+	edges, err := s.viewer.createIndexBuffer(s.gl, m.Edges())
+	if err != nil {
+		triangles.delete(s.gl)
+		return nil, err
+	}
+	sm := s.Mesh(name)
+	if sm != nil {
+		s.deleteMeshBuffers(sm)
+	} else {
+		sm = &SceneMesh{
+			Name:      name,
+			Visible:   true,
+			Solid:     true,
+			Color:     [4]float32{0.75, 0.75, 0.75, 1},
+			WireColor: [4]float32{0.1, 0.1, 0.1, 1},
+		}
+		s.meshes = append(s.meshes, sm)
+	}
+	sm.Mesh, sm.Model = m, model
+	sm.positions = createVBO(s.gl, m.Positions)
+	sm.normals = createVBO(s.gl, m.Normals)
+	sm.triangles, sm.edges = triangles, edges
+	return sm, nil
+}
+
+// SetMeshEdges replaces the pairs of vertex indices m's wireframe draws,
+// which default to every triangle edge, for meshes whose flat faces are
+// better outlined without their diagonals.
+func (s *Scene) SetMeshEdges(m *SceneMesh, edges []uint32) error {
+	buf, err := s.viewer.createIndexBuffer(s.gl, edges)
+	if err != nil {
+		return err
+	}
+	m.edges.delete(s.gl)
+	m.edges = buf
+	return nil
+}
+
+// Mesh returns the named mesh, or nil.
+func (s *Scene) Mesh(name string) *SceneMesh {
+	for _, m := range s.meshes {
+		if m.Name == name {
+			return m
+		}
+	}
+	return nil
+}
+
+// Meshes returns the scene's meshes in draw order.
+func (s *Scene) Meshes() []*SceneMesh {
+	return s.meshes
+}
+
+// RemoveMesh deletes the named mesh and its GPU buffers.
+// Returns false if there is no such mesh.
+func (s *Scene) RemoveMesh(name string) bool {
+	for i, m := range s.meshes {
+		if m.Name == name {
+			s.deleteMeshBuffers(m)
+			s.meshes = append(s.meshes[:i], s.meshes[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+func (s *Scene) deleteMeshBuffers(m *SceneMesh) {
+	s.gl.Call("deleteBuffer", m.positions)
+	s.gl.Call("deleteBuffer", m.normals)
+	m.triangles.delete(s.gl)
+	m.edges.delete(s.gl)
+}
+
+// DrawMeshes draws the visible meshes: solids with the mesh shader, then
+// wireframes with the line program, which must take aPosition and aColor
+// and a uMvpMatrix at lineMvpLoc. Solids are pushed back slightly in depth
+// so their own wireframe draws on top without z-fighting. Translucent
+// solids do not write depth. The line program is left in use.
+func (s *Scene) DrawMeshes(shader *MeshShader, lineProgram, lineMvpLoc js.Value, viewProj glf32.Mat4) {
+	gl := s.gl
+	if len(s.meshes) == 0 {
+		return
+	}
+	gl.Call("useProgram", shader.program)
+	s.viewer.light.apply(gl, shader.light, s.viewer.camera)
+	gl.Call("enable", gl.Get("POLYGON_OFFSET_FILL"))
+	gl.Call("polygonOffset", 1, 1)
+	gl.Call("enableVertexAttribArray", attribPosition)
+	gl.Call("enableVertexAttribArray", shader.normalLoc)
+	for _, m := range s.meshes {
+		if !m.Visible || !m.Solid {
+			continue
+		}
+		mvp := glf32.MultiplyMatrices(viewProj, m.Model)
+		gl.Call("uniformMatrix4fv", shader.mvpLoc, false, glf32.ToFloat32Array(mvp))
+		gl.Call("uniformMatrix4fv", shader.modelLoc, false, glf32.ToFloat32Array(m.Model))
+		gl.Call("uniform4f", shader.colorLoc, m.Color[0], m.Color[1], m.Color[2], m.Color[3])
+		gl.Call("bindBuffer", gl.Get("ARRAY_BUFFER"), m.positions)
+		gl.Call("vertexAttribPointer", attribPosition, 3, gl.Get("FLOAT"), false, 0, 0)
+		gl.Call("bindBuffer", gl.Get("ARRAY_BUFFER"), m.normals)
+		gl.Call("vertexAttribPointer", shader.normalLoc, 3, gl.Get("FLOAT"), false, 0, 0)
+		gl.Call("depthMask", m.Color[3] >= 1)
+		m.triangles.draw(gl, gl.Get("TRIANGLES"), m.triangles.count)
+	}
+	gl.Call("depthMask", true)
+	gl.Call("disable", gl.Get("POLYGON_OFFSET_FILL"))
+	gl.Call("disableVertexAttribArray", shader.normalLoc)
+
+	gl.Call("useProgram", lineProgram)
+	gl.Call("disableVertexAttribArray", attribColor)
+	for _, m := range s.meshes {
+		if !m.Visible || !m.Wireframe {
+			continue
+		}
+		mvp := glf32.MultiplyMatrices(viewProj, m.Model)
+		gl.Call("uniformMatrix4fv", lineMvpLoc, false, glf32.ToFloat32Array(mvp))
+		gl.Call("vertexAttrib4f", attribColor, m.WireColor[0], m.WireColor[1], m.WireColor[2], m.WireColor[3])
+		gl.Call("bindBuffer", gl.Get("ARRAY_BUFFER"), m.positions)
+		gl.Call("vertexAttribPointer", attribPosition, 3, gl.Get("FLOAT"), false, 0, 0)
+		m.edges.draw(gl, gl.Get("LINES"), m.edges.count)
+	}
+	gl.Call("enableVertexAttribArray", attribColor)
+}
+
+// meshInfo describes a mesh to JS.
+func meshInfo(m *SceneMesh) map[string]interface{} {
+	return map[string]interface{}{
+		"name":      m.Name,
+		"vertices":  m.Mesh.VertexCount(),
+		"triangles": m.Mesh.TriangleCount(),
+		"visible":   m.Visible,
+		"solid":     m.Solid,
+		"wireframe": m.Wireframe,
+		"color":     colorArray(m.Color),
+		"wireColor": colorArray(m.WireColor),
+	}
+}
+
+// applyMeshStyle sets the fields present in style: visible, solid,
+// wireframe, color and wireColor.
+func applyMeshStyle(m *SceneMesh, style js.Value) error {
+	for _, field := range []struct {
+		name string
+		dst  *bool
+	}{{"visible", &m.Visible}, {"solid", &m.Solid}, {"wireframe", &m.Wireframe}} {
+		if v := jsValue(style, field.name); !v.IsUndefined() {
+			*field.dst = v.Truthy()
+		}
+	}
+	for _, field := range []struct {
+		name string
+		dst  *[4]float32
+	}{{"color", &m.Color}, {"wireColor", &m.WireColor}} {
+		if v := jsValue(style, field.name); !v.IsUndefined() {
+			c, err := jsColor(v)
+			if err != nil {
+				return fmt.Errorf("%s: %v", field.name, err)
+			}
+			*field.dst = c
+		}
+	}
+	return nil
+}
+
+// addMesh(data, params) parses an OBJ or STL mesh and displays it as
+// reference geometry with the points, replacing any mesh of the same name.
+//
+// data is a Uint8Array of the file contents. params is optional and may
+// hold format ("obj" or "stl"; inferred from name or the data when
+// omitted), name (default "mesh"), alignTo (the name of a point cloud
+// object whose model matrix to share, so a model in the same coordinates
+// as a scan lines up with it), model (16 numbers, column-major) and the
+// style fields of setMeshStyle. Without alignTo or model the mesh is
+// fitted to the view like a point cloud.
+//
+// Returns the mesh's {name, vertices, triangles, visible, solid,
+// wireframe, color, wireColor} or {error}.
+func (v *Viewer) addMesh(this js.Value, args []js.Value) interface{} {
+	if len(args) < 1 || args[0].IsUndefined() || args[0].IsNull() {
+		return jsError("addMesh: expected (data, params)")
+	}
+	params := js.Undefined()
+	if len(args) > 1 {
+		params = args[1]
+	}
+	name := jsString(params, "name", "mesh")
+	m, err := importer.ReadMesh(name, jsString(params, "format", ""), jsBytes(args[0]))
+	if err != nil {
+		return jsError("addMesh: " + err.Error())
+	}
+
+	var model glf32.Mat4
+	if target := jsValue(params, "alignTo"); !target.IsUndefined() {
+		o := v.scene.Object(target.String())
+		if o == nil {
+			return jsError("addMesh: no object named " + target.String())
+		}
+		model = append(glf32.Mat4(nil), o.Model...)
+	} else if value := jsValue(params, "model"); !value.IsUndefined() {
+		if model, err = jsMat4(value); err != nil {
+			return jsError("addMesh: model: " + err.Error())
+		}
+	} else {
+		model = (&pointcloud.Cloud{Coords: m.Positions}).FitTransform(2)
+	}
+
+	sm, err := v.scene.AddMesh(name, m, model)
+	if err != nil {
+		return jsError("addMesh: " + err.Error())
+	}
+	if err := applyMeshStyle(sm, params); err != nil {
+		return jsError("addMesh: " + err.Error())
+	}
+	return js.ValueOf(meshInfo(sm))
+}
+
+// setMeshStyle(name, style) changes how a mesh draws. style may hold
+// visible, solid (lit surface), wireframe (edges drawn as lines), color
+// and wireColor ([r, g, b] or [r, g, b, a] in [0, 1]; a solid color with
+// alpha below 1 is see-through). Omitted fields are left unchanged.
+//
+// Returns the mesh's info as addMesh does, or {error}.
+func (v *Viewer) setMeshStyle(this js.Value, args []js.Value) interface{} {
+	if len(args) < 2 {
+		return jsError("setMeshStyle: expected (name, style)")
+	}
+	m := v.scene.Mesh(args[0].String())
+	if m == nil {
+		return jsError("setMeshStyle: no mesh named " + args[0].String())
+	}
+	if err := applyMeshStyle(m, args[1]); err != nil {
+		return jsError("setMeshStyle: " + err.Error())
+	}
+	return js.ValueOf(meshInfo(m))
+}
+
+// removeMesh(name) removes a mesh. Returns true if it existed.
+func (v *Viewer) removeMesh(this js.Value, args []js.Value) interface{} {
+	if len(args) < 1 {
+		return jsError("removeMesh: expected (name)")
+	}
+	return v.scene.RemoveMesh(args[0].String())
+}
+
+// getMeshes() returns the info of every mesh in draw order.
+func (v *Viewer) getMeshes(this js.Value, args []js.Value) interface{} {
+	var meshes []interface{}
+	for _, m := range v.scene.Meshes() {
+		meshes = append(meshes, meshInfo(m))
+	}
+	return js.ValueOf(meshes)
+}
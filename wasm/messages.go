@@ -0,0 +1,118 @@
+// wasm/messages.go
+package main
+
+import (
+	"errors"
+	"strings"
+	"syscall/js"
+
+	"github.com/sbecker11/webgl-point-cloud/i18n"
+)
+
+// messages is the catalog the viewer's overlays, panels and menus take
+// their text from.
+var messages = i18n.English
+
+// msg returns the message for key.
+func msg(key string) string {
+	return messages.Text(key)
+}
+
+// msgf returns the message for key formatted with args.
+func msgf(key string, args ...interface{}) string {
+	return messages.Format(key, args...)
+}
+
+// useMessages makes c the catalog, warning of keys it has that the viewer
+// does not use, and rebuilds the control panel in its language. Other text
+// changes as it is next drawn.
+func useMessages(c *i18n.Catalog) {
+	messages = c
+	if unknown := c.Unknown(); len(unknown) > 0 {
+		js.Global().Get("console").Call("warn", "Unknown message keys: "+strings.Join(unknown, ", "))
+	}
+	if controlPanel != nil {
+		controlPanel.remove()
+		controlPanel = newPanel()
+	}
+}
+
+// await blocks until promise settles and returns its value, or an error
+// if it rejects. It must not be called from a JS callback.
+func await(promise js.Value) (js.Value, error) {
+	type result struct {
+		v   js.Value
+		err error
+	}
+	done := make(chan result, 1)
+	onResolve := js.FuncOf(func(this js.Value, args []js.Value) interface{} {
+		done <- result{v: args[0]}
+		return nil
+	})
+	onReject := js.FuncOf(func(this js.Value, args []js.Value) interface{} {
+		done <- result{err: errors.New(js.Global().Get("String").Invoke(args[0]).String())}
+		return nil
+	})
+	defer onResolve.Release()
+	defer onReject.Release()
+	promise.Call("then", onResolve, onReject)
+	r := <-done
+	return r.v, r.err
+}
+
+// fetchMessages loads a JSON catalog from url, for ?messages=.
+func fetchMessages(url, lang string) (*i18n.Catalog, error) {
+	response, err := await(js.Global().Call("fetch", url))
+	if err != nil {
+		return nil, err
+	}
+	if !response.Get("ok").Bool() {
+		return nil, errors.New(url + ": " + response.Get("statusText").String())
+	}
+	text, err := await(response.Call("text"))
+	if err != nil {
+		return nil, err
+	}
+	return i18n.Parse(lang, []byte(text.String()), i18n.English)
+}
+
+// setMessages(catalog, lang) localizes the viewer's built-in text:
+// catalog is an object mapping message keys to messages, which may be fmt
+// formats (see getMessages for the keys and English messages). Keys it
+// lacks keep their English text. lang is optional and names its language.
+// null restores English.
+//
+// Returns {lang, keys} with the number of keys it has, or {error}.
+func setMessages(this js.Value, args []js.Value) interface{} {
+	if len(args) < 1 {
+		return jsError("setMessages: expected (catalog, lang)")
+	}
+	if args[0].IsNull() {
+		useMessages(i18n.English)
+		return js.ValueOf(map[string]interface{}{"lang": "en", "keys": len(i18n.English.Keys())})
+	}
+	if args[0].Type() != js.TypeObject {
+		return jsError("setMessages: catalog must be an object")
+	}
+	lang := ""
+	if len(args) > 1 && args[1].Type() == js.TypeString {
+		lang = args[1].String()
+	}
+	data := js.Global().Get("JSON").Call("stringify", args[0]).String()
+	c, err := i18n.Parse(lang, []byte(data), i18n.English)
+	if err != nil {
+		return jsError("setMessages: " + err.Error())
+	}
+	useMessages(c)
+	return js.ValueOf(map[string]interface{}{"lang": lang, "keys": len(c.Keys())})
+}
+
+// getMessages() returns every message key with its current text, for
+// starting a translation.
+func getMessages(this js.Value, args []js.Value) interface{} {
+	all := map[string]interface{}{}
+	for _, k := range i18n.English.Keys() {
+		all[k] = messages.Text(k)
+	}
+	return js.ValueOf(all)
+}
@@ -0,0 +1,203 @@
+// wasm/minimap.go
+package main
+
+import (
+	"fmt"
+	"math"
+	"syscall/js"
+
+	"github.com/sbecker11/webgl-point-cloud/glf32"
+	"github.com/sbecker11/webgl-point-cloud/render"
+)
+
+// minimapMargin is the minimap's distance from the canvas corner in
+// pixels.
+const minimapMargin = 10
+
+// Minimap is a top-down overview of the visible objects drawn in a square
+// viewport in the top-left corner of the canvas, with the ground footprint
+// of the main camera's view outlined. Clicking or dragging in it moves the
+// point the camera orbits there.
+type Minimap struct {
+	viewer   *Viewer
+	Visible  bool
+	Size     int // side in canvas pixels
+	dragging bool
+
+	boundsKey string // scene data and models the bounds were computed for
+	lo, hi    [3]float64
+	outline   render.Geometry // the footprint of the main view
+}
+
+// newMinimap returns a hidden minimap of the default size.
+func newMinimap(v *Viewer) *Minimap {
+	return &Minimap{viewer: v, Size: 200, outline: render.Geometry{Primitive: render.Lines}}
+}
+
+// rect returns the minimap's left and top edges and side in canvas pixels.
+func (m *Minimap) rect() (x, y, size float32) {
+	return minimapMargin, minimapMargin, float32(m.Size)
+}
+
+// contains reports whether canvas position (x, y) is on the minimap.
+func (m *Minimap) contains(x, y float32) bool {
+	left, top, size := m.rect()
+	return m.Visible && x >= left && x < left+size && y >= top && y < top+size
+}
+
+// bounds returns the world bounds of the visible objects.
+func (m *Minimap) bounds() (lo, hi [3]float64) {
+	key := m.viewer.sceneDataKey()
+	for _, o := range m.viewer.scene.Objects() {
+		_, _, model := m.viewer.scene.Effective(o)
+		key += fmt.Sprint(model)
+	}
+	if key == m.boundsKey {
+		return m.lo, m.hi
+	}
+	m.boundsKey = key
+	m.lo = [3]float64{math.Inf(1), math.Inf(1), math.Inf(1)}
+	m.hi = [3]float64{math.Inf(-1), math.Inf(-1), math.Inf(-1)}
+	for _, o := range m.viewer.scene.Objects() {
+		visible, _, model := m.viewer.scene.Effective(o)
+		if !visible || o.Cloud.Len() == 0 {
+			continue
+		}
+		min, max := o.Cloud.Bounds()
+		var corners []float32
+		for i := 0; i < 8; i++ {
+			corners = append(corners, pick3(i&1, min[0], max[0]), pick3(i&2, min[1], max[1]), pick3(i&4, min[2], max[2]))
+		}
+		corners = glf32.TransformVertices(corners, model)
+		for i := 0; i < len(corners); i += 3 {
+			for k := 0; k < 3; k++ {
+				m.lo[k] = math.Min(m.lo[k], float64(corners[i+k]))
+				m.hi[k] = math.Max(m.hi[k], float64(corners[i+k]))
+			}
+		}
+	}
+	if m.lo[0] > m.hi[0] {
+		m.lo, m.hi = [3]float64{-1, -1, -1}, [3]float64{1, 1, 1}
+	}
+	return m.lo, m.hi
+}
+
+// pick3 returns hi if bit is set, otherwise lo.
+func pick3(bit int, lo, hi float32) float32 {
+	if bit != 0 {
+		return hi
+	}
+	return lo
+}
+
+// frame returns the center and half extent of the square area shown.
+func (m *Minimap) frame() (cx, cz, half float64) {
+	lo, hi := m.bounds()
+	half = math.Max(math.Max(hi[0]-lo[0], hi[2]-lo[2])/2*1.1, 1e-3)
+	return (lo[0] + hi[0]) / 2, (lo[2] + hi[2]) / 2, half
+}
+
+// viewProj returns the minimap's view-projection matrix: an orthographic
+// view straight down the y axis with -z up the screen.
+func (m *Minimap) viewProj() glf32.Mat4 {
+	lo, hi := m.bounds()
+	cx, cz, half := m.frame()
+	top := float32(hi[1] + 1)
+	view := glf32.LookAt(glf32.Vec3{float32(cx), top, float32(cz)}, glf32.Vec3{float32(cx), float32(lo[1]), float32(cz)}, glf32.Vec3{0, 0, -1})
+	proj := glf32.Orthographic(-float32(half), float32(half), -float32(half), float32(half), 0.01, float32(hi[1]-lo[1])+2)
+	return glf32.MultiplyMatrices(proj, view)
+}
+
+// footprint returns line segments, as packed xyz pairs, outlining where
+// the main camera's view meets the plane through the orbit point, and from
+// the camera to that point, at height y.
+func (m *Minimap) footprint(viewProj glf32.Mat4, y float32) []float32 {
+	inv, ok := glf32.Invert(viewProj)
+	if !ok {
+		return nil
+	}
+	ground := float64(m.viewer.camera.target[1])
+	var corners [][3]float64
+	for _, c := range [][2]float64{{-1, -1}, {1, -1}, {1, 1}, {-1, 1}} {
+		near, far := unproject(inv, c[0], c[1], -1), unproject(inv, c[0], c[1], 1)
+		p := far
+		if dy := far[1] - near[1]; dy != 0 {
+			if t := (ground - near[1]) / dy; t >= 0 && t <= 1 {
+				p = [3]float64{near[0] + t*(far[0]-near[0]), ground, near[2] + t*(far[2]-near[2])}
+			}
+		}
+		corners = append(corners, p)
+	}
+	var lines []float32
+	for i := range corners {
+		a, b := corners[i], corners[(i+1)%4]
+		lines = append(lines, float32(a[0]), y, float32(a[2]), float32(b[0]), y, float32(b[2]))
+	}
+	eye := unproject(inv, 0, 0, -1)
+	return append(lines, float32(eye[0]), y, float32(eye[2]), m.viewer.camera.target[0], y, m.viewer.camera.target[2])
+}
+
+// draw renders the minimap over the frame just drawn with viewProj. The
+// point shader's style uniforms must already be set for the frame.
+func (m *Minimap) draw(gl js.Value, shader *PointShader, lineProgram js.Value, viewProj glf32.Mat4, fraction float64) {
+	if !m.Visible {
+		return
+	}
+	width, height := gl.Get("drawingBufferWidth").Int(), gl.Get("drawingBufferHeight").Int()
+	left, top, size := m.rect()
+	x, y, s := int(left), height-int(top)-int(size), int(size)
+
+	gl.Call("enable", gl.Get("SCISSOR_TEST"))
+	// A border, then the map's background.
+	gl.Call("scissor", x-1, y-1, s+2, s+2)
+	gl.Call("clearColor", 0.6, 0.6, 0.6, 1)
+	gl.Call("clear", gl.Get("COLOR_BUFFER_BIT"))
+	gl.Call("scissor", x, y, s, s)
+	gl.Call("clearColor", 0.05, 0.05, 0.1, 1)
+	gl.Call("clear", gl.Get("COLOR_BUFFER_BIT").Int()|gl.Get("DEPTH_BUFFER_BIT").Int())
+	gl.Call("viewport", x, y, s, s)
+
+	mapViewProj := m.viewProj()
+	gl.Call("useProgram", shader.program)
+	gl.Call("uniform1f", shader.pointSizeLoc, 1)
+	m.viewer.scene.DrawPoints(shader, mapViewProj, fraction)
+
+	_, hi := m.bounds()
+	lines := m.footprint(viewProj, float32(hi[1]))
+	if lines != nil {
+		colors := make([]float32, 0, len(lines)/3*4)
+		for i := 0; i < len(lines)/3; i++ {
+			colors = append(colors, 1, 0.85, 0.2, 1)
+		}
+		m.outline.Set(m.viewer.renderer, lines, colors)
+		m.viewer.renderer.use(lineProgram)
+		m.outline.Draw(m.viewer.renderer, mapViewProj)
+	}
+
+	gl.Call("viewport", 0, 0, width, height)
+	gl.Call("disable", gl.Get("SCISSOR_TEST"))
+}
+
+// jump moves the camera's orbit point to the spot under canvas position
+// (x, y) on the minimap, keeping its height.
+func (m *Minimap) jump(x, y float32) {
+	left, top, size := m.rect()
+	ndcX := 2*float64(x-left)/float64(size) - 1
+	ndcY := 1 - 2*float64(y-top)/float64(size)
+	cx, cz, half := m.frame()
+	m.viewer.camera.target = glf32.Vec3{float32(cx + ndcX*half), m.viewer.camera.target[1], float32(cz - ndcY*half)}
+	m.viewer.camera.velocityX, m.viewer.camera.velocityY = 0, 0
+}
+
+// showMinimap(visible, params) shows or hides the overview map in the
+// top-left corner of the canvas. params.size sets its side in pixels
+// (default 200).
+func (v *Viewer) showMinimap(this js.Value, args []js.Value) interface{} {
+	v.minimap.Visible = len(args) < 1 || args[0].Truthy()
+	if len(args) > 1 {
+		if size := int(jsFloat(args[1], "size", float32(v.minimap.Size))); size >= 50 {
+			v.minimap.Size = size
+		}
+	}
+	return nil
+}
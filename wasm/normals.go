@@ -0,0 +1,103 @@
+// wasm/normals.go
+package main
+
+import (
+	"errors"
+	"syscall/js"
+
+	"github.com/sbecker11/webgl-point-cloud/normals"
+	"github.com/sbecker11/webgl-point-cloud/pointcloud"
+)
+
+// curvatureAttribute holds each point's estimated curvature, for the
+// "curvature" color mode.
+var curvatureAttribute = pointcloud.Attribute{Name: "curvature", Components: 1, Type: pointcloud.Float32}
+
+// estimateNormals(name, params) estimates a normal and a curvature for every
+// point of the named object from its neighbours within a radius, replacing
+// any normals it had and setting a "curvature" attribute: 0 on flat
+// surfaces and up to 1/3 where points scatter evenly, so edges, corners
+// and surface defects stand out. The "normal" color mode then shows the
+// normals' directions and the "curvature" mode the curvature through a
+// colormap.
+//
+// params is optional and may hold radius (default about three point
+// spacings), viewpoint ([x, y, z] in the object's coordinates, such as the
+// scanner's position, that normals are turned toward; default straight up)
+// and colorize, a color mode to switch to when done ("normal" or
+// "curvature"; default "normal", false for none). The work runs as a job,
+// shown in the progress overlay and cancellable.
+//
+// Returns a Promise of {name, points, job}; it rejects with an Error if
+// there is no such object or the job is cancelled.
+func (v *Viewer) estimateNormals(this js.Value, args []js.Value) interface{} {
+	promise := js.Global().Get("Promise")
+	fail := func(msg string) interface{} {
+		return promise.Call("reject", js.Global().Get("Error").New("estimateNormals: "+msg))
+	}
+	if len(args) < 1 || args[0].Type() != js.TypeString {
+		return fail("expected (name, params)")
+	}
+	name := args[0].String()
+	o := v.scene.Object(name)
+	if o == nil {
+		return fail("no object named " + name)
+	}
+	params := js.Undefined()
+	if len(args) > 1 {
+		params = args[1]
+	}
+	opts := normals.Options{Radius: float64(jsFloat(params, "radius", 0))}
+	if value := jsValue(params, "viewpoint"); !value.IsUndefined() {
+		p, err := jsPoint3(value)
+		if err != nil {
+			return fail("viewpoint: " + err.Error())
+		}
+		opts.Viewpoint = &p
+	}
+	colorize := ColorModeNormal
+	if value := jsValue(params, "colorize"); value.Type() == js.TypeString {
+		mode, err := parseColorMode(value.String())
+		if err != nil {
+			return fail(err.Error())
+		}
+		colorize = mode
+	} else if !value.IsUndefined() && !value.Truthy() {
+		colorize = -1
+	}
+	cloud := o.Cloud
+
+	var handler js.Func
+	handler = js.FuncOf(func(this js.Value, pargs []js.Value) interface{} {
+		handler.Release()
+		resolve, reject := pargs[0], pargs[1]
+		j := jobs.Start("Estimate normals of " + name)
+		yield := yielder()
+		go func() {
+			opts.Progress = func(fraction float64) error {
+				if err := j.Report(fraction); err != nil {
+					return err
+				}
+				yield()
+				return nil
+			}
+			n, curvature, err := normals.Estimate(cloud.Coords, opts)
+			if err == nil && (!v.inScene(o) || o.Cloud.Len() != len(curvature)) {
+				err = errors.New("the object changed while its normals were estimated")
+			}
+			j.Finish(err)
+			if err != nil {
+				reject.Invoke(js.Global().Get("Error").New("estimateNormals: " + err.Error()))
+				return
+			}
+			v.scene.SetNormals(o, n)
+			v.scene.SetAttribute(o, curvatureAttribute, curvature)
+			if colorize >= 0 {
+				v.classStyle.Mode = colorize
+			}
+			resolve.Invoke(js.ValueOf(map[string]interface{}{"name": name, "points": cloud.Len(), "job": j.ID}))
+		}()
+		return nil
+	})
+	return promise.New(handler)
+}
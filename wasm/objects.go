@@ -0,0 +1,160 @@
+// wasm/objects.go
+package main
+
+import (
+	"syscall/js"
+
+	"github.com/sbecker11/webgl-point-cloud/glf32"
+)
+
+// objectInfo describes a scene object to JS.
+func objectInfo(o *SceneObject) map[string]interface{} {
+	info := map[string]interface{}{
+		"name":       o.Cloud.Name,
+		"points":     o.Cloud.Len(),
+		"visible":    o.Visible,
+		"opacity":    o.Opacity,
+		"depthWrite": o.DepthWrite,
+		"tint":       nil,
+		"color":      nil,
+		"sizeMode":   o.PointStyle.SizeMode.String(),
+		"pointSize":  o.PointStyle.Size,
+		"round":      o.PointStyle.Round,
+		"shading":    o.PointStyle.Shading.String(),
+	}
+	if o.Tint != nil {
+		info["tint"] = []interface{}{o.Tint[0], o.Tint[1], o.Tint[2]}
+	}
+	if o.Color != nil {
+		info["color"] = []interface{}{o.Color[0], o.Color[1], o.Color[2], o.Color[3]}
+	}
+	return info
+}
+
+// getObjects() returns [{name, points, visible, opacity, depthWrite, tint,
+// color, sizeMode, pointSize, round, shading}] for the scene's objects in
+// draw order.
+func (v *Viewer) getObjects(this js.Value, args []js.Value) interface{} {
+	var objects []interface{}
+	for _, o := range v.scene.Objects() {
+		objects = append(objects, objectInfo(o))
+	}
+	return js.ValueOf(objects)
+}
+
+// setObjectStyle(name, style) changes how an object draws. style may hold
+// visible, opacity (0 to 1, multiplied into every point's alpha),
+// depthWrite, tint (an [r, g, b] multiplied into every point's color) and
+// color (an [r, g, b] replacing every point's color, or [r, g, b, a]
+// mixing it in by a), sizeMode ("view" for the view's point size, "pixels"
+// or "world" for pointSize pixels or world units across), pointSize, round
+// (true to draw points as discs) and shading ("view" to light points with
+// normals when the light does, "flat" or "lit"); omitted fields are left
+// unchanged and a null tint or color removes it. Fading an object with
+// depthWrite false lets another scan behind it show through; drawing two
+// scans in contrasting colors shows how well they are registered.
+// Reference markers can draw as big round sprites while a dense scan stays
+// at the view's size.
+//
+// Returns the object's info as getObjects does, or {error}.
+func (v *Viewer) setObjectStyle(this js.Value, args []js.Value) interface{} {
+	if len(args) < 2 {
+		return jsError("setObjectStyle: expected (name, style)")
+	}
+	o := v.scene.Object(args[0].String())
+	if o == nil {
+		return jsError("setObjectStyle: no object named " + args[0].String())
+	}
+	style := args[1]
+	opacity := jsFloat(style, "opacity", o.Opacity)
+	if opacity < 0 || opacity > 1 {
+		return jsError("setObjectStyle: opacity must be in [0, 1]")
+	}
+	tint, color := o.Tint, o.Color
+	if style.Type() == js.TypeObject && style.Get("tint").IsNull() {
+		tint = nil
+	} else if value := jsValue(style, "tint"); !value.IsUndefined() {
+		c, err := jsColor(value)
+		if err != nil || value.Length() != 3 {
+			return jsError("setObjectStyle: tint must be an [r, g, b] array with components in [0, 1]")
+		}
+		tint = glf32.Vec3{c[0], c[1], c[2]}
+	}
+	if style.Type() == js.TypeObject && style.Get("color").IsNull() {
+		color = nil
+	} else if value := jsValue(style, "color"); !value.IsUndefined() {
+		c, err := jsColor(value)
+		if err != nil {
+			return jsError("setObjectStyle: color: " + err.Error())
+		}
+		color = c[:]
+	}
+	points := o.PointStyle
+	if value := jsValue(style, "sizeMode"); !value.IsUndefined() {
+		mode, err := parseName("size mode", pointSizeModeNames, value.String())
+		if err != nil {
+			return jsError("setObjectStyle: " + err.Error())
+		}
+		points.SizeMode = PointSizeMode(mode)
+	}
+	points.Size = jsFloat(style, "pointSize", points.Size)
+	if points.SizeMode != PointSizeView && !(points.Size > 0) {
+		return jsError("setObjectStyle: pointSize must be positive")
+	}
+	if value := jsValue(style, "round"); !value.IsUndefined() {
+		points.Round = value.Truthy()
+	}
+	if value := jsValue(style, "shading"); !value.IsUndefined() {
+		shading, err := parseName("shading", shadingNames, value.String())
+		if err != nil {
+			return jsError("setObjectStyle: " + err.Error())
+		}
+		points.Shading = Shading(shading)
+	}
+	o.Opacity, o.Tint, o.Color, o.PointStyle = opacity, tint, color, points
+	if value := jsValue(style, "visible"); !value.IsUndefined() {
+		o.Visible = value.Truthy()
+	}
+	if value := jsValue(style, "depthWrite"); !value.IsUndefined() {
+		o.DepthWrite = value.Truthy()
+	}
+	return js.ValueOf(objectInfo(o))
+}
+
+// setDrawIndices(name, indices) draws only the object's points at the given
+// indices, reusing its vertex buffers, e.g. for a decimated view of a large
+// cloud. indices is a Uint32Array or an array of point indices; null
+// restores drawing every point. Indices are dropped when the object's points
+// are replaced by an edit or a reload.
+//
+// Returns {name, points} with the number of points drawn, or {error}.
+func (v *Viewer) setDrawIndices(this js.Value, args []js.Value) interface{} {
+	if len(args) < 2 {
+		return jsError("setDrawIndices: expected (name, indices)")
+	}
+	o := v.scene.Object(args[0].String())
+	if o == nil {
+		return jsError("setDrawIndices: no object named " + args[0].String())
+	}
+	var indices []uint32
+	switch value := args[1]; {
+	case value.IsUndefined() || value.IsNull():
+	case value.InstanceOf(js.Global().Get("Uint32Array")):
+		indices = glf32.FromUint32Array(value)
+	case js.Global().Get("Array").Call("isArray", value).Bool():
+		indices = make([]uint32, value.Length())
+		for i := range indices {
+			indices[i] = uint32(value.Index(i).Int())
+		}
+	default:
+		return jsError("setDrawIndices: indices must be a Uint32Array, an array or null")
+	}
+	if err := v.scene.SetDrawIndices(o, indices); err != nil {
+		return jsError("setDrawIndices: " + err.Error())
+	}
+	points := o.Cloud.Len()
+	if indices != nil {
+		points = len(indices)
+	}
+	return js.ValueOf(map[string]interface{}{"name": o.Cloud.Name, "points": points})
+}
@@ -0,0 +1,145 @@
+// wasm/oit.go
+package main
+
+import (
+	"errors"
+	"syscall/js"
+
+	"github.com/sbecker11/webgl-point-cloud/rendergraph"
+)
+
+// OITPass draws translucent points with weighted blended order-independent
+// transparency, McGuire and Bavoil's approximation of blending them back to
+// front that needs no sorting, when the view's translucency mode is
+// TranslucencyWeighted. The scene draws only its opaque points; the pass's
+// two offscreen passes draw the opaque points' depth and then the
+// translucent points, adding their weighted colors into a float texture
+// and multiplying together how much of what is behind them shows through
+// into another, and its composite blends their weighted average color over
+// the canvas. Translucent points are hidden behind opaque points but not
+// behind meshes or lines.
+type OITPass struct {
+	viewer  *Viewer
+	program js.Value
+	locs    map[string]js.Value
+	failed  bool
+}
+
+func newOITPass(v *Viewer) *OITPass {
+	return &OITPass{viewer: v, program: js.Undefined()}
+}
+
+// setup compiles the composite program on first use. Browsers that
+// cannot blend into float textures, and a failure to compile, turn the
+// pass off for good, with a notice; translucent points then blend in the
+// order they are stored.
+func (p *OITPass) setup(gl js.Value) bool {
+	if p.failed || !p.program.IsUndefined() {
+		return !p.failed
+	}
+	var program js.Value
+	err := errors.New("this browser cannot blend into float textures")
+	if p.viewer.caps.FloatBlend {
+		program, err = newCompositeProgram(gl, "oit-composite.frag")
+	}
+	if err != nil {
+		p.viewer.notice(msgf("notice.oitOff", err))
+		p.failed = true
+		return false
+	}
+	p.program = program
+	p.locs = map[string]js.Value{}
+	for _, name := range []string{"uAccum", "uReveal"} {
+		p.locs[name] = gl.Call("getUniformLocation", p.program, name)
+	}
+	return true
+}
+
+// newCompositeProgram links fullscreenVertexShader with the fragment
+// shader file.
+func newCompositeProgram(gl js.Value, fragment string) (js.Value, error) {
+	frag, err := buildShader(fragment, false, nil)
+	if err != nil {
+		return js.Null(), err
+	}
+	program, err := createShaderProgram(gl, fullscreenVertexShader, frag.Text)
+	if err != nil {
+		return js.Null(), remapShaderError(err, nil, frag)
+	}
+	return program, nil
+}
+
+// active reports whether the pass draws the translucent points this
+// frame: in the weighted mode, where the browser supports it, with a
+// translucent object in view and no swipe comparison, which draws all
+// points itself.
+func (p *OITPass) active() bool {
+	if p.viewer.view.Translucency != TranslucencyWeighted || p.viewer.swipe != nil || !p.setup(p.viewer.scene.gl) {
+		return false
+	}
+	for _, o := range p.viewer.scene.objects {
+		if visible, opacity, _ := p.viewer.scene.Effective(o); visible && opacity > 0 && opacity < 1 {
+			return true
+		}
+	}
+	return false
+}
+
+// register adds the pass's steps to g.
+func (p *OITPass) register(g *RenderGraph) {
+	g.add(&RenderPass{
+		Pass:    rendergraph.Pass{Name: "oit-accum", Outputs: []string{"oit-accum"}},
+		Format:  TextureFloat,
+		Depth:   true,
+		Enabled: p.active,
+		Draw: func(c *PassContext) {
+			p.drawTranslucent(c, "oit-accum.frag", "ONE", "ONE")
+		},
+	})
+	g.add(&RenderPass{
+		Pass:    rendergraph.Pass{Name: "oit-reveal", Outputs: []string{"oit-reveal"}},
+		Depth:   true,
+		Enabled: p.active,
+		Draw: func(c *PassContext) {
+			p.drawTranslucent(c, "oit-reveal.frag", "ZERO", "ONE_MINUS_SRC_COLOR")
+		},
+	})
+	g.add(&RenderPass{
+		Pass:    rendergraph.Pass{Name: "oit", Inputs: []string{"oit-accum", "oit-reveal"}},
+		Enabled: p.active,
+		Draw:    p.composite,
+	})
+}
+
+// drawTranslucent draws the opaque points' depth, then the translucent
+// points with the point program of the fragment shader file, blended by
+// the factors named. The revealage pass's target starts at 1, all of what
+// is behind showing.
+func (p *OITPass) drawTranslucent(c *PassContext, fragment, src, dst string) {
+	gl := c.gl
+	shader, err := p.viewer.shaders.point(gl, fragment, p.viewer.pointFeatures())
+	if err != nil {
+		p.viewer.notice(msgf("notice.oitOff", err))
+		return
+	}
+	p.viewer.usePointShader(gl, shader, c.frame.pointSize)
+	gl.Call("colorMask", false, false, false, false)
+	p.viewer.scene.drawPoints(shader, c.frame.viewProj, c.frame.fraction, opaquePoints)
+	gl.Call("colorMask", true, true, true, true)
+	if fragment == "oit-reveal.frag" {
+		gl.Call("clearColor", 1, 1, 1, 1)
+		gl.Call("clear", gl.Get("COLOR_BUFFER_BIT"))
+	}
+	gl.Call("enable", gl.Get("BLEND"))
+	gl.Call("blendFunc", gl.Get(src), gl.Get(dst))
+	p.viewer.scene.drawPoints(shader, c.frame.viewProj, c.frame.fraction, translucentPoints)
+}
+
+// composite blends the translucent points over the canvas.
+func (p *OITPass) composite(c *PassContext) {
+	gl := c.gl
+	gl.Call("useProgram", p.program)
+	gl.Call("uniform1i", p.locs["uAccum"], c.Unit("oit-accum"))
+	gl.Call("uniform1i", p.locs["uReveal"], c.Unit("oit-reveal"))
+	c.DrawQuad()
+}
@@ -0,0 +1,96 @@
+// wasm/ondemand.go
+package main
+
+import (
+	"syscall/js"
+)
+
+// onDemandMode is the render-on-demand mode: frames are drawn only when
+// something may have changed, instead of at the display's rate, so large
+// static clouds leave the GPU idle. Changes are noticed from input, calls
+// to the JS API, buffer uploads, camera movement and animations, and a
+// heartbeat draws a frame now and then in case one was missed.
+type onDemandMode struct {
+	viewer    *Viewer
+	Enabled   bool
+	Heartbeat float64 // ms between frames drawn regardless; 0 for none
+
+	dirty    bool
+	lastDraw float64     // animation frame time of the last frame drawn, in ms
+	camera   cameraState // the camera when it was drawn
+}
+
+// newOnDemandMode returns the mode turned off, with the first frame to draw.
+func newOnDemandMode(v *Viewer) *onDemandMode {
+	return &onDemandMode{viewer: v, Heartbeat: 1000, dirty: true}
+}
+
+// requestRender has every viewer's next frame drawn in render-on-demand
+// mode.
+func requestRender() {
+	for _, v := range viewers {
+		v.onDemand.dirty = true
+	}
+}
+
+// animating reports whether the scene moves on its own, so that every
+// frame must be drawn.
+func (v *Viewer) animating() bool {
+	return v.flythrough.playing || v.recording != nil || len(v.poseTracks) > 0 || v.revealing()
+}
+
+// shouldDraw reports whether to draw the frame at animation frame time
+// now, and if so records it as drawn.
+func (d *onDemandMode) shouldDraw(now float64) bool {
+	state := d.viewer.camera.state()
+	if d.Enabled && !d.dirty && !d.viewer.animating() && state == d.camera &&
+		(d.Heartbeat <= 0 || now-d.lastDraw < d.Heartbeat) {
+		return false
+	}
+	d.dirty, d.lastDraw, d.camera = false, now, state
+	return true
+}
+
+// setupRenderOnDemand draws the next frame after any input to the page,
+// including the control panel's, and after the window resizes.
+func setupRenderOnDemand() {
+	mark := js.FuncOf(func(this js.Value, args []js.Value) interface{} {
+		requestRender()
+		return nil
+	})
+	doc := js.Global().Get("document")
+	for _, event := range []string{"pointerdown", "pointermove", "pointerup", "pointerleave", "wheel", "keydown", "keyup", "input", "change", "click"} {
+		doc.Call("addEventListener", event, mark, map[string]interface{}{"capture": true, "passive": true})
+	}
+	js.Global().Call("addEventListener", "resize", mark)
+}
+
+// setRenderOnDemand(params) turns the render-on-demand mode on or off.
+// While it is on, frames are drawn only when the camera, the scene or the
+// view may have changed: after input, JS API calls, uploads and while
+// animations or recordings play. params may hold enabled and heartbeat,
+// the ms between frames drawn regardless (default 1000; 0 for none). Page
+// code changing what is drawn without the API should call requestRender.
+//
+// Returns {enabled, heartbeat} or {error}.
+func (v *Viewer) setRenderOnDemand(this js.Value, args []js.Value) interface{} {
+	if len(args) < 1 || args[0].Type() != js.TypeObject {
+		return jsError("setRenderOnDemand: expected ({enabled, heartbeat})")
+	}
+	heartbeat := float64(jsFloat(args[0], "heartbeat", float32(v.onDemand.Heartbeat)))
+	if heartbeat < 0 {
+		return jsError("setRenderOnDemand: heartbeat must not be negative")
+	}
+	if value := jsValue(args[0], "enabled"); value.Type() == js.TypeBoolean {
+		v.onDemand.Enabled = value.Bool()
+	}
+	v.onDemand.Heartbeat = heartbeat
+	return js.ValueOf(map[string]interface{}{"enabled": v.onDemand.Enabled, "heartbeat": v.onDemand.Heartbeat})
+}
+
+// requestRenderJS() draws the next frame in render-on-demand mode, for
+// page code that changes what is drawn without the viewer's API.
+func requestRenderJS(this js.Value, args []js.Value) interface{} {
+	requestRender()
+	return nil
+}
@@ -0,0 +1,152 @@
+// wasm/overlay.go
+package main
+
+import (
+	"fmt"
+	"syscall/js"
+)
+
+// startupLoad is the loading overlay's key for the viewer's startup;
+// imports use their job's id, which counts from 1.
+const startupLoad = 0
+
+// loadStatus is what the loading overlay shows for one load.
+type loadStatus struct {
+	status string
+	detail string
+}
+
+// loading holds the loads in progress by key, and their keys in the order
+// they began. The overlay shows the latest.
+var loading struct {
+	loads map[int]loadStatus
+	keys  []int
+}
+
+// overlayElement returns the element with the given id of the loading
+// overlay or the error panel. index.html has both from the start, so the
+// download of the viewer itself shows progress; pages without them get
+// plain ones built on first use.
+func overlayElement(id string) js.Value {
+	doc := js.Global().Get("document")
+	if el := doc.Call("getElementById", id); el.Truthy() {
+		return el
+	}
+	buildOverlays()
+	return doc.Call("getElementById", id)
+}
+
+// buildOverlays adds whichever of the loading overlay and the error panel
+// the page lacks.
+func buildOverlays() {
+	doc := js.Global().Get("document")
+	body := doc.Get("body")
+	add := func(parent js.Value, tag, id, style string) js.Value {
+		el := doc.Call("createElement", tag)
+		el.Set("id", id)
+		el.Set("style", style)
+		parent.Call("appendChild", el)
+		return el
+	}
+	if !doc.Call("getElementById", "loading").Truthy() {
+		root := add(body, "div", "loading", "position:fixed;top:50%;left:50%;transform:translate(-50%,-50%);"+
+			"min-width:220px;padding:16px 20px;border-radius:8px;background:rgba(20,20,30,0.9);color:#eee;"+
+			"font:13px sans-serif;text-align:center;z-index:30;display:none")
+		add(root, "div", "loading-status", "font-weight:bold")
+		add(root, "div", "loading-detail", "margin-top:4px;opacity:0.8")
+	}
+	if !doc.Call("getElementById", "error").Truthy() {
+		root := add(body, "div", "error", "position:fixed;top:50%;left:50%;transform:translate(-50%,-50%);"+
+			"max-width:min(560px,90vw);padding:16px 20px;border-radius:8px;background:rgba(60,10,10,0.95);"+
+			"color:#fdd;font:13px sans-serif;z-index:31;display:none")
+		add(root, "div", "error-title", "font-weight:bold;font-size:15px")
+		add(root, "pre", "error-message", "white-space:pre-wrap;max-height:40vh;overflow:auto")
+		closeButton := add(root, "button", "error-close", "")
+		closeButton.Set("textContent", msg("button.close"))
+		listen(closeButton, "click", nil, func(js.Value) { root.Get("style").Set("display", "none") })
+	}
+}
+
+// setLoading shows status in the loading overlay for the load with the
+// given key, with its progress in [0, 1] and the bytes loaded of total
+// where they are known, and negative where they are not.
+func setLoading(key int, status string, fraction float64, loaded, total int) {
+	if loading.loads == nil {
+		loading.loads = map[int]loadStatus{}
+	}
+	if _, ok := loading.loads[key]; !ok {
+		loading.keys = append(loading.keys, key)
+	}
+	detail := ""
+	if fraction >= 0 {
+		detail = fmt.Sprintf("%.0f%%", 100*min(fraction, 1))
+	}
+	if loaded >= 0 {
+		if detail != "" {
+			detail += " · "
+		}
+		detail += formatBytes(loaded)
+		if total > 0 {
+			detail += " of " + formatBytes(total)
+		}
+	}
+	loading.loads[key] = loadStatus{status, detail}
+	showLoading()
+}
+
+// endLoading removes the load with the given key from the overlay, which
+// hides when none remain.
+func endLoading(key int) {
+	if _, ok := loading.loads[key]; !ok {
+		return
+	}
+	delete(loading.loads, key)
+	for i, k := range loading.keys {
+		if k == key {
+			loading.keys = append(loading.keys[:i], loading.keys[i+1:]...)
+			break
+		}
+	}
+	showLoading()
+}
+
+// showLoading shows the latest load, or hides the overlay if there is
+// none.
+func showLoading() {
+	root := overlayElement("loading")
+	if len(loading.keys) == 0 {
+		root.Get("style").Set("display", "none")
+		return
+	}
+	l := loading.loads[loading.keys[len(loading.keys)-1]]
+	overlayElement("loading-status").Set("textContent", l.status)
+	overlayElement("loading-detail").Set("textContent", l.detail)
+	root.Get("style").Set("display", "block")
+}
+
+// formatBytes returns n as a size in B, KB or MB.
+func formatBytes(n int) string {
+	switch {
+	case n >= 1e6:
+		return fmt.Sprintf("%.1f MB", float64(n)/1e6)
+	case n >= 1e3:
+		return fmt.Sprintf("%.0f KB", float64(n)/1e3)
+	}
+	return fmt.Sprintf("%d B", n)
+}
+
+// showError reports a failure the user would otherwise only see as a
+// frozen canvas: in the console and in the error panel, replacing any
+// error it shows.
+func showError(title string, err error) {
+	js.Global().Get("console").Call("error", title+": "+err.Error())
+	overlayElement("error-title").Set("textContent", title)
+	overlayElement("error-message").Set("textContent", err.Error())
+	overlayElement("error").Get("style").Set("display", "block")
+}
+
+// startupFailed ends the startup's loading and shows why it failed.
+func startupFailed(title string, err error) {
+	endLoading(startupLoad)
+	showError(title, err)
+}
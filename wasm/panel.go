@@ -0,0 +1,337 @@
+// wasm/panel.go
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"syscall/js"
+
+	"github.com/sbecker11/webgl-point-cloud/pointcloud"
+)
+
+// Panel is the optional built-in control panel, a DOM overlay generated
+// from Go so the viewer is usable without host page JavaScript. It holds
+// view settings, a class palette editor and a section of per-object
+// toggles; the last two are rebuilt when the scene's objects change.
+type Panel struct {
+	root       js.Value
+	body       js.Value
+	objects    js.Value
+	objectsKey string
+	built      bool
+	objectFns  []js.Func // released when the objects section is rebuilt
+	classes    js.Value
+	classesKey string
+	classFns   []js.Func // released when the classes section is rebuilt
+}
+
+// controlPanel is the panel, once shown.
+var controlPanel *Panel
+
+// newPanel builds the panel and adds it to the page.
+func newPanel() *Panel {
+	doc := js.Global().Get("document")
+	p := &Panel{}
+	p.root = doc.Call("createElement", "div")
+	p.root.Set("style", "position:fixed;top:10px;right:10px;width:240px;max-height:90vh;overflow:auto;"+
+		"padding:8px;border-radius:6px;background:rgba(20,20,30,0.85);color:#eee;"+
+		"font:12px sans-serif;z-index:10;user-select:none")
+
+	header := doc.Call("createElement", "div")
+	header.Set("textContent", msg("panel.title"))
+	header.Set("style", "font-weight:bold;cursor:pointer;margin-bottom:4px")
+	p.root.Call("appendChild", header)
+	p.body = doc.Call("createElement", "div")
+	p.root.Call("appendChild", p.body)
+	header.Call("addEventListener", "click", js.FuncOf(func(this js.Value, args []js.Value) interface{} {
+		style := p.body.Get("style")
+		if style.Get("display").String() == "none" {
+			style.Set("display", "block")
+		} else {
+			style.Set("display", "none")
+		}
+		return nil
+	}))
+
+	p.addSlider(p.body, msg("panel.pointSize"), 1, 10, 0.5, float64(currentViewer.view.PointSize), nil, func(value float64) {
+		currentViewer.view.PointSize = float32(value)
+	})
+	p.addSlider(p.body, msg("panel.displayed"), 100*minDisplayFraction, 100, 1, 100*currentViewer.view.DisplayFraction, nil, func(value float64) {
+		currentViewer.view.DisplayFraction = value / 100
+	})
+	p.addInput(p.body, msg("panel.budget"), "number", strconv.Itoa(currentViewer.view.PointBudget), nil, func(value string) {
+		if n, err := strconv.Atoi(value); err == nil && n >= 0 && n <= maxPointBudget {
+			currentViewer.view.PointBudget = n
+		}
+	})
+	p.addSlider(p.body, msg("panel.exaggeration"), 1, 20, 0.5, float64(currentViewer.view.Exaggeration), nil, func(value float64) {
+		currentViewer.view.Exaggeration = float32(value)
+	})
+	p.addInput(p.body, msg("panel.background"), "color", currentViewer.view.backgroundHex(), nil, func(value string) {
+		currentViewer.view.setBackgroundHex(value)
+	})
+	p.addSelect(p.body, msg("panel.shading"), []string{"rgb", "classification", "intensity", "height", "distance", "change", "selections", "normal", "curvature"}, colorModeName(currentViewer.classStyle.Mode), func(value string) {
+		if mode, err := parseColorMode(value); err == nil {
+			currentViewer.classStyle.Mode = mode
+		}
+	})
+	p.addCheckbox(p.body, msg("panel.axes"), currentViewer.view.ShowAxes, nil, func(on bool) { currentViewer.view.ShowAxes = on })
+	p.addCheckbox(p.body, msg("panel.grid"), currentViewer.view.ShowGrid, nil, func(on bool) { currentViewer.view.ShowGrid = on })
+	p.addCheckbox(p.body, msg("panel.adaptive"), currentViewer.adaptive.Enabled, nil, func(on bool) { currentViewer.adaptive.setEnabled(on) })
+	p.addCheckbox(p.body, msg("panel.onDemand"), currentViewer.onDemand.Enabled, nil, func(on bool) { currentViewer.onDemand.Enabled = on })
+	p.addCheckbox(p.body, msg("panel.histogram"), currentViewer.histogram != nil && currentViewer.histogram.root.Get("style").Get("display").String() != "none", nil, func(on bool) {
+		currentViewer.showHistogram(js.Undefined(), []js.Value{js.ValueOf(on)})
+	})
+	p.addCheckbox(p.body, msg("panel.minimap"), currentViewer.minimap.Visible, nil, func(on bool) { currentViewer.minimap.Visible = on })
+	p.addCheckbox(p.body, msg("panel.coordinates"), currentViewer.hud.Visible, nil, func(on bool) { currentViewer.hud.setVisible(on) })
+	p.addCheckbox(p.body, msg("panel.basemap"), currentViewer.mapPlane.Visible, nil, func(on bool) { currentViewer.showBasemap(js.Undefined(), []js.Value{js.ValueOf(on)}) })
+	p.addCheckbox(p.body, msg("panel.ssao"), currentViewer.ssao.Enabled, nil, func(on bool) { currentViewer.ssao.Enabled = on })
+	p.addCheckbox(p.body, msg("panel.edl"), currentViewer.edl.Enabled, nil, func(on bool) { currentViewer.edl.Enabled = on })
+	p.addCheckbox(p.body, msg("panel.shadow"), currentViewer.contactShadow.Visible, nil, func(on bool) { currentViewer.contactShadow.Visible = on })
+	p.addCheckbox(p.body, msg("panel.gizmo"), currentViewer.gizmo.Visible, nil, func(on bool) { currentViewer.gizmo.Visible = on })
+	p.addCheckbox(p.body, msg("panel.trajectories"), currentViewer.showTrajectories, nil, func(on bool) { currentViewer.showTrajectories = on })
+	p.addButton(p.body, msg("panel.profile"), msg("button.draw"), nil, func(js.Value) { currentViewer.startProfile(js.Undefined(), nil) })
+	p.addButton(p.body, msg("panel.measure"), msg("button.draw"), nil, func(js.Value) { currentViewer.startMeasure(js.Undefined(), nil) })
+	p.addButton(p.body, msg("panel.lasso"), msg("button.select"), nil, func(js.Value) { currentViewer.startLasso(js.Undefined(), nil) })
+	p.addButton(p.body, msg("panel.selection"), msg("button.invert"), nil, func(js.Value) { currentViewer.invertSelection(js.Undefined(), nil) })
+	p.addCheckbox(p.body, msg("panel.labeling"), currentViewer.labeling, nil, func(on bool) { currentViewer.setLabelingMode(js.Undefined(), []js.Value{js.ValueOf(on)}) })
+	p.addCheckbox(p.body, msg("panel.snap"), currentViewer.snapping.Enabled, nil, func(on bool) {
+		currentViewer.setSnapping(js.Undefined(), []js.Value{js.ValueOf(map[string]interface{}{"enabled": on})})
+	})
+	p.addButton(p.body, msg("panel.cameraPath"), msg("button.addKeyframe"), nil, func(js.Value) { currentViewer.addCameraKeyframe(js.Undefined(), nil) })
+	p.addButton(p.body, msg("panel.flythrough"), msg("button.play"), nil, func(js.Value) { currentViewer.playCameraPath(js.Undefined(), nil) })
+	p.addCheckbox(p.body, msg("panel.record"), currentViewer.recording != nil, nil, func(on bool) {
+		if on {
+			currentViewer.startRecording(js.Undefined(), nil)
+		} else {
+			currentViewer.stopRecording(js.Undefined(), nil)
+		}
+	})
+	filterText := ""
+	if f := currentViewer.scene.Filter(); f != nil {
+		filterText = f.String()
+	}
+	p.addInput(p.body, msg("panel.filter"), "text", filterText, nil, func(value string) {
+		if value == "" {
+			currentViewer.scene.SetFilter(nil)
+		} else if err := currentViewer.setSceneFilter(value); err != nil {
+			js.Global().Get("console").Call("warn", "Filter: "+err.Error())
+		}
+	})
+
+	title := doc.Call("createElement", "div")
+	title.Set("textContent", msg("panel.classes"))
+	title.Set("style", "font-weight:bold;margin:8px 0 4px")
+	p.body.Call("appendChild", title)
+	p.classes = doc.Call("createElement", "div")
+	p.body.Call("appendChild", p.classes)
+	p.addButton(p.body, msg("panel.palette"), msg("button.reset"), nil, func(js.Value) { currentViewer.resetClassPalette(js.Undefined(), nil) })
+
+	title = doc.Call("createElement", "div")
+	title.Set("textContent", msg("panel.objects"))
+	title.Set("style", "font-weight:bold;margin:8px 0 4px")
+	p.body.Call("appendChild", title)
+	p.objects = doc.Call("createElement", "div")
+	p.body.Call("appendChild", p.objects)
+	p.refresh()
+
+	doc.Get("body").Call("appendChild", p.root)
+	return p
+}
+
+// remove takes the panel off the page.
+func (p *Panel) remove() {
+	for _, fn := range append(p.objectFns, p.classFns...) {
+		fn.Release()
+	}
+	p.objectFns, p.classFns = nil, nil
+	p.root.Call("remove")
+}
+
+// refresh rebuilds the classes and objects sections if the scene's objects
+// or the palette changed. It is cheap enough to call every frame.
+func (p *Panel) refresh() {
+	p.refreshClasses()
+	var names []string
+	for _, o := range currentViewer.scene.Objects() {
+		names = append(names, o.Cloud.Name)
+	}
+	key := strings.Join(names, "\x00")
+	if p.built && key == p.objectsKey {
+		return
+	}
+	p.objectsKey, p.built = key, true
+	for _, fn := range p.objectFns {
+		fn.Release()
+	}
+	p.objectFns = nil
+	p.objects.Set("innerHTML", "")
+	for _, o := range currentViewer.scene.Objects() {
+		p.addCheckbox(p.objects, o.Cloud.Name, o.Visible, &p.objectFns, func(v bool) { o.Visible = v })
+		p.addSlider(p.objects, msg("panel.opacity"), 0, 1, 0.05, float64(o.Opacity), &p.objectFns, func(v float64) {
+			o.Opacity = float32(v)
+		})
+		stats := js.Global().Get("document").Call("createElement", "pre")
+		stats.Set("style", "display:none;margin:2px 0 6px;font:11px monospace;white-space:pre-wrap")
+		p.addButton(p.objects, msg("panel.statistics"), msg("button.show"), &p.objectFns, func(button js.Value) {
+			if stats.Get("style").Get("display").String() == "none" {
+				stats.Set("textContent", statsText(objectStats(o, js.Undefined())))
+				stats.Get("style").Set("display", "block")
+				button.Set("textContent", msg("button.hide"))
+			} else {
+				stats.Get("style").Set("display", "none")
+				button.Set("textContent", msg("button.show"))
+			}
+		})
+		p.objects.Call("appendChild", stats)
+	}
+}
+
+// refreshClasses rebuilds the classes section, a color and a visibility
+// toggle for each class present in the scene, if the scene's objects or
+// the palette changed.
+func (p *Panel) refreshClasses() {
+	key := fmt.Sprint(currentViewer.sceneDataKey(), currentViewer.classStyle.version)
+	if key == p.classesKey {
+		return
+	}
+	p.classesKey = key
+	for _, fn := range p.classFns {
+		fn.Release()
+	}
+	p.classFns = nil
+	p.classes.Set("innerHTML", "")
+	present := map[uint8]bool{}
+	for _, o := range currentViewer.scene.Objects() {
+		if o.Cloud.Classes == nil {
+			continue
+		}
+		for class := range o.Cloud.ClassCounts() {
+			present[class] = true
+		}
+	}
+	for c := 0; c < pointcloud.MaxClasses; c++ {
+		if !present[uint8(c)] {
+			continue
+		}
+		p.addInput(p.classes, currentViewer.classStyle.name(uint8(c)), "color", colorHex(currentViewer.classStyle.Colors[c]), &p.classFns, func(v string) {
+			if color, err := parseColorHex(v); err == nil {
+				currentViewer.classStyle.Colors[c] = color
+				currentViewer.classStyle.version++
+			}
+		})
+		row := p.classes.Get("lastChild")
+		visible := js.Global().Get("document").Call("createElement", "input")
+		visible.Set("type", "checkbox")
+		visible.Set("checked", currentViewer.classStyle.Visible[c])
+		row.Call("appendChild", visible)
+		listen(visible, "change", &p.classFns, func(el js.Value) {
+			currentViewer.classStyle.Visible[c] = el.Get("checked").Bool()
+			currentViewer.classStyle.version++
+		})
+	}
+	if len(present) == 0 {
+		p.classes.Set("textContent", msg("panel.noClasses"))
+	}
+}
+
+// row appends a labelled row to parent and returns it.
+func (p *Panel) row(parent js.Value, label string) js.Value {
+	doc := js.Global().Get("document")
+	row := doc.Call("createElement", "label")
+	row.Set("style", "display:flex;align-items:center;justify-content:space-between;margin:3px 0;gap:6px")
+	text := doc.Call("createElement", "span")
+	text.Set("textContent", label)
+	row.Call("appendChild", text)
+	parent.Call("appendChild", row)
+	return row
+}
+
+// listen adds an event listener, recording the callback in fns if non-nil
+// so it can be released later.
+func listen(el js.Value, event string, fns *[]js.Func, fn func(js.Value)) {
+	f := js.FuncOf(func(this js.Value, args []js.Value) interface{} {
+		fn(el)
+		return nil
+	})
+	if fns != nil {
+		*fns = append(*fns, f)
+	}
+	el.Call("addEventListener", event, f)
+}
+
+// addSlider adds a range input calling onChange as it moves.
+func (p *Panel) addSlider(parent js.Value, label string, min, max, step, value float64, fns *[]js.Func, onChange func(float64)) {
+	input := js.Global().Get("document").Call("createElement", "input")
+	input.Set("type", "range")
+	input.Set("min", min)
+	input.Set("max", max)
+	input.Set("step", step)
+	input.Set("value", value)
+	input.Set("style", "width:110px")
+	p.row(parent, label).Call("appendChild", input)
+	listen(input, "input", fns, func(el js.Value) { onChange(el.Get("valueAsNumber").Float()) })
+}
+
+// addCheckbox adds a checkbox calling onChange when toggled.
+func (p *Panel) addCheckbox(parent js.Value, label string, checked bool, fns *[]js.Func, onChange func(bool)) {
+	input := js.Global().Get("document").Call("createElement", "input")
+	input.Set("type", "checkbox")
+	input.Set("checked", checked)
+	p.row(parent, label).Call("appendChild", input)
+	listen(input, "change", fns, func(el js.Value) { onChange(el.Get("checked").Bool()) })
+}
+
+// addInput adds an input of the given type (e.g. "color" or "text") calling
+// onChange when its value is committed.
+func (p *Panel) addInput(parent js.Value, label, kind, value string, fns *[]js.Func, onChange func(string)) {
+	input := js.Global().Get("document").Call("createElement", "input")
+	input.Set("type", kind)
+	input.Set("value", value)
+	input.Set("style", "width:110px")
+	p.row(parent, label).Call("appendChild", input)
+	listen(input, "change", fns, func(el js.Value) { onChange(el.Get("value").String()) })
+}
+
+// addButton adds a button calling onClick with the button when clicked.
+func (p *Panel) addButton(parent js.Value, label, text string, fns *[]js.Func, onClick func(js.Value)) {
+	button := js.Global().Get("document").Call("createElement", "button")
+	button.Set("textContent", text)
+	p.row(parent, label).Call("appendChild", button)
+	listen(button, "click", fns, onClick)
+}
+
+// addSelect adds a dropdown of options calling onChange on selection.
+func (p *Panel) addSelect(parent js.Value, label string, options []string, value string, onChange func(string)) {
+	doc := js.Global().Get("document")
+	sel := doc.Call("createElement", "select")
+	for _, o := range options {
+		opt := doc.Call("createElement", "option")
+		opt.Set("value", o)
+		opt.Set("textContent", o)
+		sel.Call("appendChild", opt)
+	}
+	sel.Set("value", value)
+	sel.Set("style", "width:114px")
+	p.row(parent, label).Call("appendChild", sel)
+	listen(sel, "change", nil, func(el js.Value) { onChange(el.Get("value").String()) })
+}
+
+// showPanel(visible) shows or hides the built-in control panel, creating it
+// on first use.
+func showPanel(this js.Value, args []js.Value) interface{} {
+	visible := len(args) < 1 || args[0].Truthy()
+	if controlPanel == nil {
+		if !visible {
+			return nil
+		}
+		controlPanel = newPanel()
+	}
+	display := "none"
+	if visible {
+		display = "block"
+	}
+	controlPanel.root.Get("style").Set("display", display)
+	return nil
+}
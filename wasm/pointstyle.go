@@ -0,0 +1,99 @@
+// wasm/pointstyle.go
+package main
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/sbecker11/webgl-point-cloud/glf32"
+)
+
+// PointSizeMode is how an object's points are sized.
+type PointSizeMode int
+
+const (
+	// PointSizeView draws the points at the view's point size.
+	PointSizeView PointSizeMode = iota
+	// PointSizePixels draws them the object's own size across, in pixels,
+	// however near or far they are.
+	PointSizePixels
+	// PointSizeWorld draws them the object's own size across in world
+	// units, shrinking with distance like the surfaces they sample.
+	PointSizeWorld
+)
+
+// pointSizeModeNames are the modes' names, as setObjectStyle takes them.
+var pointSizeModeNames = []string{"view", "pixels", "world"}
+
+func (m PointSizeMode) String() string {
+	return pointSizeModeNames[m]
+}
+
+// Shading is whether an object's points with normals are lit.
+type Shading int
+
+const (
+	// ShadingView lights them when the view's light does; see Light.Points.
+	ShadingView Shading = iota
+	// ShadingFlat never lights them.
+	ShadingFlat
+	// ShadingLit always lights them.
+	ShadingLit
+)
+
+// shadingNames are the shadings' names, as setObjectStyle takes them.
+var shadingNames = []string{"view", "flat", "lit"}
+
+func (s Shading) String() string {
+	return shadingNames[s]
+}
+
+// parseName returns the index of name in names, those of a kind of
+// setting.
+func parseName(kind string, names []string, name string) (int, error) {
+	for i, n := range names {
+		if n == name {
+			return i, nil
+		}
+	}
+	return 0, fmt.Errorf("unknown %s %q", kind, name)
+}
+
+// PointStyle is how an object's points are sized and shaded, where it
+// differs from the view's: reference markers can draw as big round
+// sprites while the dense scan around them stays at a pixel.
+type PointStyle struct {
+	SizeMode PointSizeMode
+	Size     float32 // in pixels or world units, as SizeMode says
+	Round    bool    // draws the points as discs rather than squares
+	Shading  Shading
+}
+
+// lit reports whether the style lights points with normals under l.
+func (p PointStyle) lit(l *Light) bool {
+	return p.Shading == ShadingLit || p.Shading == ShadingView && l.Points
+}
+
+// sizeUniform returns point.vert's uObjectSize for the style, seen through
+// viewProj in a viewport height pixels high by v: the size in pixels, or 0
+// for the view's size, and 1 where it is divided by the clip w, pixels per
+// world unit at unit distance being half the height times the length of
+// viewProj's y row.
+func (p PointStyle) sizeUniform(v *Viewer, viewProj glf32.Mat4, height float64) (size, perspective float32) {
+	switch p.SizeMode {
+	case PointSizePixels:
+		return v.pointSize(p.Size), 0
+	case PointSizeWorld:
+		row := math.Sqrt(float64(viewProj[1]*viewProj[1] + viewProj[5]*viewProj[5] + viewProj[9]*viewProj[9]))
+		return p.Size * float32(height/2*row), 1
+	}
+	return 0, 0
+}
+
+// boolFloat returns 1 for true and 0 for false, as shaders take flags.
+func boolFloat(b bool) float32 {
+	if b {
+		return 1
+	}
+	return 0
+}
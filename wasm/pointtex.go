@@ -0,0 +1,230 @@
+// wasm/pointtex.go
+package main
+
+import (
+	"fmt"
+	"syscall/js"
+
+	"github.com/sbecker11/webgl-point-cloud/glf32"
+	"github.com/sbecker11/webgl-point-cloud/pointcloud"
+)
+
+// PositionStorage is where objects' positions are kept on the GPU.
+//
+// WebGL 1 devices, older mobile GPUs above all, may fail to allocate the
+// vertex buffer of a large cloud's positions. There the positions can go in
+// a float texture instead, one point per texel, which the point vertex
+// shader samples by point index; the index buffer is shared by every
+// object. The texture is filled a band of rows at a time, one band each
+// time the object is drawn, so a huge cloud appears progressively without
+// stalling a frame.
+type PositionStorage int
+
+const (
+	// PositionsAuto keeps positions in a vertex buffer, falling back to a
+	// texture for clouds whose buffer cannot be allocated.
+	PositionsAuto PositionStorage = iota
+	// PositionsBuffer always keeps positions in a vertex buffer.
+	PositionsBuffer
+	// PositionsTexture always keeps positions in a texture.
+	PositionsTexture
+)
+
+// positionStorageNames are the storages' names, as setPositionStorage
+// takes them.
+var positionStorageNames = []string{"auto", "buffer", "texture"}
+
+func (p PositionStorage) String() string {
+	return positionStorageNames[p]
+}
+
+// parsePositionStorage returns the storage with the given name.
+func parsePositionStorage(name string) (PositionStorage, error) {
+	for i, n := range positionStorageNames {
+		if n == name {
+			return PositionStorage(i), nil
+		}
+	}
+	return 0, fmt.Errorf("unknown position storage %q", name)
+}
+
+// positionStorage is where objects uploaded from now on keep their
+// positions.
+var positionStorage = PositionsAuto
+
+// minAutoTexturePoints is the fewest points for which PositionsAuto checks
+// whether the positions' buffer was allocated, as the check waits for the
+// GPU.
+const minAutoTexturePoints = 1 << 20
+
+// maxTexturePoints is the most points a position texture holds: point
+// indices are floats in the shader, exact up to 2^24.
+const maxTexturePoints = 1 << 24
+
+// positionsTextureUnit is the texture unit position textures are bound to
+// while drawing, clear of the units offscreen passes use.
+const positionsTextureUnit = 7
+
+// positionUploadTexels is about how many points are uploaded to a position
+// texture each time its object is drawn.
+const positionUploadTexels = 1 << 18
+
+// positionTexturesSupported reports whether the browser can sample float
+// textures in vertex shaders.
+func (v *Viewer) positionTexturesSupported() bool {
+	return v.caps.VertexTextures && v.caps.FloatTextures
+}
+
+// positionTexture is an object's positions in a float texture, xyz and 1
+// in each texel, filled row by row.
+type positionTexture struct {
+	tex    *Texture
+	coords []float32 // the packed positions, until all are uploaded
+	points int
+	rows   int // the rows uploaded
+}
+
+// newPositionTexture makes a texture for the positions in coords, to be
+// filled by upload.
+func (v *Viewer) newPositionTexture(gl js.Value, coords []float32) (*positionTexture, error) {
+	n := len(coords) / 3
+	width := min(v.caps.MaxTextureSize, 4096)
+	height := (n + width - 1) / width
+	if n > maxTexturePoints || height > v.caps.MaxTextureSize {
+		return nil, fmt.Errorf("%d points do not fit in a texture", n)
+	}
+	tex, err := v.newTexture(gl, TextureFloat, width, max(height, 1), false)
+	if err != nil {
+		return nil, err
+	}
+	return &positionTexture{tex: tex, coords: coords, points: n}, nil
+}
+
+// loaded returns how many of the points have their positions uploaded.
+func (t *positionTexture) loaded() int {
+	return min(t.rows*t.tex.width, t.points)
+}
+
+// upload uploads the next band of rows of positions, if any are left,
+// and asks for another frame to upload the rest. It returns the first of
+// the points uploaded and their count.
+func (t *positionTexture) upload(gl js.Value) (first, count int) {
+	if t.coords == nil {
+		return 0, 0
+	}
+	width := t.tex.width
+	rows := min(max(positionUploadTexels/width, 1), t.tex.height-t.rows)
+	texels := make([]float32, 4*width*rows)
+	first = t.rows * width
+	for i := 0; i < width*rows && first+i < t.points; i++ {
+		copy(texels[4*i:4*i+3], t.coords[3*(first+i):3*(first+i)+3])
+		texels[4*i+3] = 1
+	}
+	gl.Call("bindTexture", gl.Get("TEXTURE_2D"), t.tex.texture)
+	gl.Call("texSubImage2D", gl.Get("TEXTURE_2D"), 0, 0, t.rows, width, rows, gl.Get("RGBA"), gl.Get("FLOAT"), glf32.ToFloat32Array(texels))
+	gl.Call("bindTexture", gl.Get("TEXTURE_2D"), js.Null())
+	t.rows += rows
+	if t.rows >= t.tex.height {
+		t.coords = nil
+	} else {
+		requestRender()
+	}
+	return first, min(width*rows, t.points-first)
+}
+
+// storePositions uploads the object's positions, attribute a, where
+// positionStorage says.
+func (s *Scene) storePositions(o *SceneObject, a pointcloud.Attribute) {
+	gl, coords := s.gl, o.Cloud.Coords
+	if positionStorage != PositionsTexture || !s.viewer.positionTexturesSupported() {
+		buf := createAttributeVBO(gl, a, coords)
+		if positionStorage != PositionsAuto || !s.viewer.positionTexturesSupported() || o.Cloud.Len() < minAutoTexturePoints ||
+			!gl.Call("getError").Equal(gl.Get("OUT_OF_MEMORY")) {
+			o.buffers[a.Name] = buf
+			return
+		}
+		gl.Call("deleteBuffer", buf)
+	}
+	t, err := s.viewer.newPositionTexture(gl, coords)
+	if err != nil {
+		s.viewer.notice(msgf("notice.positionTexture", o.Cloud.Name, err))
+		o.buffers[a.Name] = createAttributeVBO(gl, a, coords)
+		return
+	}
+	o.positions = t
+}
+
+// bindPositions points shader at the object's position texture, if it has
+// one, uploading the next band of it, which starts being revealed, and
+// feeds the shader the point indices to sample it by.
+func (s *Scene) bindPositions(shader *PointShader, o *SceneObject) {
+	gl, t := s.gl, o.positions
+	loc, ok := shader.attributes["aIndex"]
+	if t == nil || !ok {
+		gl.Call("uniform4f", shader.positionsLoc, 0, 0, 0, 0)
+		return
+	}
+	first, count := t.upload(gl)
+	s.viewer.revealChunk(o, first, count)
+	gl.Call("enableVertexAttribArray", loc)
+	gl.Call("bindBuffer", gl.Get("ARRAY_BUFFER"), s.pointIndices(t.points))
+	gl.Call("vertexAttribPointer", loc, 1, gl.Get("FLOAT"), false, 0, 0)
+	gl.Call("activeTexture", gl.Get("TEXTURE0").Int()+positionsTextureUnit)
+	gl.Call("bindTexture", gl.Get("TEXTURE_2D"), t.tex.texture)
+	gl.Call("activeTexture", gl.Get("TEXTURE0"))
+	gl.Call("uniform1i", shader.positionsTexLoc, positionsTextureUnit)
+	gl.Call("uniform4f", shader.positionsLoc, t.tex.width, t.tex.height, t.loaded(), 1)
+}
+
+// pointIndices returns the scene's buffer of point indices 0, 1, 2, ... as
+// floats, grown to at least n of them.
+func (s *Scene) pointIndices(n int) js.Value {
+	if s.indexCount < n {
+		if s.indexVBO.Truthy() {
+			s.gl.Call("deleteBuffer", s.indexVBO)
+		}
+		indices := make([]float32, n)
+		for i := range indices {
+			indices[i] = float32(i)
+		}
+		s.indexVBO, s.indexCount = createVBO(s.gl, indices), n
+	}
+	return s.indexVBO
+}
+
+// setPositionStorage(storage) sets where objects keep their positions on
+// the GPU: "auto" (the default) in a vertex buffer, or in a float texture
+// the vertex shader samples for clouds whose buffer cannot be allocated,
+// as happens on older mobile GPUs; "buffer" always in a buffer; "texture"
+// always in a texture. Textured positions are uploaded progressively, the
+// cloud filling in over a few frames. Objects in the scene are uploaded
+// again. Returns {storage} or {error}, also where the browser cannot
+// sample float textures in vertex shaders.
+func (v *Viewer) setPositionStorage(this js.Value, args []js.Value) interface{} {
+	if len(args) < 1 || args[0].Type() != js.TypeString {
+		return jsError("setPositionStorage: expected \"auto\", \"buffer\" or \"texture\"")
+	}
+	storage, err := parsePositionStorage(args[0].String())
+	if err != nil {
+		return jsError("setPositionStorage: " + err.Error())
+	}
+	if storage == PositionsTexture && !v.positionTexturesSupported() {
+		return jsError("setPositionStorage: this browser cannot sample float textures in vertex shaders")
+	}
+	positionStorage = storage
+	for _, o := range v.scene.Objects() {
+		if o.Cloud.Len() == 0 {
+			continue
+		}
+		if buf, ok := o.buffers[pointcloud.AttrPosition]; ok {
+			v.scene.gl.Call("deleteBuffer", buf)
+			delete(o.buffers, pointcloud.AttrPosition)
+		}
+		if o.positions != nil {
+			o.positions.tex.delete(v.scene.gl)
+			o.positions = nil
+		}
+		v.scene.storePositions(o, pointcloud.Attribute{Name: pointcloud.AttrPosition, Components: 3, Type: pointcloud.Float32})
+	}
+	return js.ValueOf(map[string]interface{}{"storage": positionStorage.String()})
+}
@@ -0,0 +1,119 @@
+// wasm/pose.go
+package main
+
+import (
+	"math"
+	"syscall/js"
+
+	"github.com/sbecker11/webgl-point-cloud/glf32"
+)
+
+// maxPoseGlideMs bounds how long an object glides to a new pose, so that a
+// pause in updates does not turn the next one into a slow drift.
+const maxPoseGlideMs = 250
+
+// poseTrack moves an object driven by setObjectPose from where it was
+// shown when a pose arrived to that pose, over about the time between
+// updates, so it moves smoothly one update behind its source.
+type poseTrack struct {
+	from, to glf32.Mat4
+	start    float64 // performance.now() when the latest pose arrived
+	duration float64
+	interval float64 // smoothed time between updates
+	settled  bool
+}
+
+// at returns the pose to show at now.
+func (t *poseTrack) at(now float64) glf32.Mat4 {
+	if t.duration <= 0 || now >= t.start+t.duration {
+		return t.to
+	}
+	return glf32.Interpolate(t.from, t.to, float32((now-t.start)/t.duration))
+}
+
+// updatePoses moves the objects driven by setObjectPose. It is called once
+// a frame with the frame's timestamp.
+func (v *Viewer) updatePoses(now float64) {
+	for name, t := range v.poseTracks {
+		o := v.scene.Object(name)
+		if o == nil {
+			delete(v.poseTracks, name)
+			continue
+		}
+		if t.settled {
+			continue
+		}
+		o.Model = t.at(now)
+		t.settled = now >= t.start+t.duration
+	}
+}
+
+// setObjectPose(name, matrix, params) sets the model matrix of the named
+// object from 16 numbers in column-major order, for external tracking
+// systems (IMUs, odometry, motion capture) driving objects through the
+// host page, e.g. from WebSocket telemetry. It is cheap enough to call on
+// every update, at hundreds of hertz. Unlike setTransform it is not an
+// undoable edit. The object glides from where it is shown to the new pose,
+// rotating along the shortest arc, over the smoothed time between updates
+// (at most 250 ms), so motion stays smooth whatever the update rate, one
+// update behind. params.duration sets the glide in milliseconds instead;
+// params.interpolate false jumps straight to the pose.
+//
+// name must be a string, matrix an array or typed array of 16 finite
+// numbers, and params, if given, an object whose duration is a number and
+// interpolate a boolean.
+//
+// Returns nothing, or {error} for arguments of other shapes or an unknown
+// object.
+func (v *Viewer) setObjectPose(this js.Value, args []js.Value) interface{} {
+	if len(args) < 2 || args[0].Type() != js.TypeString {
+		return jsError("setObjectPose: expected (name, matrix, params)")
+	}
+	m, err := jsMat4(args[1])
+	if err != nil {
+		return jsError("setObjectPose: " + err.Error())
+	}
+	var params js.Value
+	if len(args) > 2 && !args[2].IsUndefined() && !args[2].IsNull() {
+		if args[2].Type() != js.TypeObject {
+			return jsError("setObjectPose: params must be an object")
+		}
+		params = args[2]
+	}
+	if d := jsValue(params, "duration"); !d.IsUndefined() && d.Type() != js.TypeNumber {
+		return jsError("setObjectPose: duration must be a number")
+	}
+	if i := jsValue(params, "interpolate"); !i.IsUndefined() && i.Type() != js.TypeBoolean {
+		return jsError("setObjectPose: interpolate must be a boolean")
+	}
+	name := args[0].String()
+	o := v.scene.Object(name)
+	if o == nil {
+		return jsError("setObjectPose: no object named " + name)
+	}
+	now := js.Global().Get("performance").Call("now").Float()
+	t := v.poseTracks[name]
+	if t == nil {
+		t = &poseTrack{start: now, settled: true}
+		v.poseTracks[name] = t
+	} else {
+		since := math.Min(now-t.start, maxPoseGlideMs)
+		if t.interval == 0 {
+			t.interval = since
+		}
+		t.interval += (since - t.interval) * 0.2
+	}
+	from := o.Model
+	if !t.settled {
+		from = t.at(now)
+	}
+	t.from, t.to, t.start, t.settled = from, m, now, false
+	t.duration = float64(jsFloat(params, "duration", float32(t.interval)))
+	if value := jsValue(params, "interpolate"); value.Type() == js.TypeBoolean && !value.Bool() {
+		t.duration = 0
+	}
+	if t.duration <= 0 {
+		o.Model, t.settled = m, true
+	}
+	return nil
+}
@@ -0,0 +1,370 @@
+// wasm/profile.go
+package main
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"syscall/js"
+
+	"github.com/sbecker11/webgl-point-cloud/glf32"
+	"github.com/sbecker11/webgl-point-cloud/hull"
+	"github.com/sbecker11/webgl-point-cloud/profile"
+)
+
+// Size of the profile canvas in CSS pixels, and the name of the corridor
+// mesh.
+const (
+	profileWidth  = 480
+	profileHeight = 200
+	profileMesh   = "profile"
+)
+
+// profileParams are the options of startProfile and extractProfile.
+type profileParams struct {
+	halfWidth float64
+	up        [3]float64
+}
+
+func parseProfileParams(params js.Value) (profileParams, error) {
+	up, err := vec3Param(params, "up", [3]float64{0, 1, 0})
+	if err != nil {
+		return profileParams{}, err
+	}
+	width := float64(jsFloat(params, "width", 0.05))
+	if width <= 0 {
+		return profileParams{}, fmt.Errorf("width must be positive")
+	}
+	return profileParams{halfWidth: width / 2, up: up}, nil
+}
+
+// profileSample is a section point with the object it came from and its
+// color.
+type profileSample struct {
+	profile.Sample
+	object string
+	color  [4]float32
+}
+
+// ProfileData is an extracted section through the drawn points of the
+// visible objects, in world coordinates.
+type ProfileData struct {
+	line      profile.Line
+	halfWidth float64
+	length    float64
+	samples   []profileSample // ordered by distance
+}
+
+// extractSection returns the drawn points of the visible objects within
+// p.halfWidth of the vertical plane through a and b.
+func (v *Viewer) extractSection(a, b [3]float64, p profileParams) (*ProfileData, error) {
+	line := profile.Line{A: a, B: b, Up: p.up}
+	_, _, _, length, err := line.Frame()
+	if err != nil {
+		return nil, err
+	}
+	data := &ProfileData{line: line, halfWidth: p.halfWidth, length: length}
+	for _, o := range v.scene.Objects() {
+		visible, opacity, model := v.scene.Effective(o)
+		if !visible || opacity <= 0 {
+			continue
+		}
+		var coords []float32
+		var indices []int
+		for i, drawn := range v.selectable(o) {
+			if drawn {
+				coords = append(coords, o.Cloud.Coords[i*3:i*3+3]...)
+				indices = append(indices, i)
+			}
+		}
+		if coords == nil {
+			continue
+		}
+		samples, err := profile.Extract(glf32.TransformVertices(coords, model), line, p.halfWidth)
+		if err != nil {
+			return nil, err
+		}
+		for _, s := range samples {
+			s.Index = indices[s.Index]
+			ps := profileSample{Sample: s, object: o.Cloud.Name, color: [4]float32{1, 1, 1, 1}}
+			if len(o.Cloud.Colors) >= s.Index*4+4 {
+				copy(ps.color[:], o.Cloud.Colors[s.Index*4:s.Index*4+4])
+			}
+			data.samples = append(data.samples, ps)
+		}
+	}
+	sort.SliceStable(data.samples, func(i, j int) bool { return data.samples[i].Distance < data.samples[j].Distance })
+	return data, nil
+}
+
+// elevationRange returns the lowest and highest elevations in the
+// section, or the elevation of the line's start when it is empty.
+func (d *ProfileData) elevationRange() (lo, hi float64) {
+	if len(d.samples) == 0 {
+		up, _, _, _, _ := d.line.Frame()
+		e := d.line.A[0]*up[0] + d.line.A[1]*up[1] + d.line.A[2]*up[2]
+		return e, e
+	}
+	lo, hi = math.Inf(1), math.Inf(-1)
+	for _, s := range d.samples {
+		lo, hi = math.Min(lo, s.Elevation), math.Max(hi, s.Elevation)
+	}
+	return lo, hi
+}
+
+// corridor returns the box spanning the section's corridor and elevations.
+func (d *ProfileData) corridor() hull.Box {
+	up, along, across, _, _ := d.line.Frame()
+	lo, hi := d.elevationRange()
+	a := d.line.A
+	base := a[0]*up[0] + a[1]*up[1] + a[2]*up[2]
+	mid := (lo+hi)/2 - base
+	var center [3]float64
+	for i := range center {
+		center[i] = a[i] + along[i]*d.length/2 + up[i]*mid
+	}
+	return hull.Box{
+		Center:   center,
+		Axes:     [3][3]float64{along, up, across},
+		HalfSize: [3]float64{d.length / 2, math.Max((hi-lo)/2, d.halfWidth), d.halfWidth},
+	}
+}
+
+// showCorridor draws the section's corridor as a wireframe box mesh in s.
+func (d *ProfileData) showCorridor(s *Scene) error {
+	sm, err := s.AddMesh(profileMesh, d.corridor().Mesh(), glf32.Identity())
+	if err != nil {
+		return err
+	}
+	sm.Solid, sm.Wireframe = false, true
+	sm.WireColor = [4]float32{0.3, 0.9, 1, 1}
+	return s.SetMeshEdges(sm, hull.BoxEdges)
+}
+
+// ProfileTool draws a section line by dragging across the canvas, then
+// extracts and shows the section under it.
+type ProfileTool struct {
+	viewer *Viewer
+	params profileParams
+	down   bool
+	x0, y0 float32
+}
+
+func (t *ProfileTool) Down(x, y float32) {
+	t.down, t.x0, t.y0 = true, x, y
+}
+
+func (t *ProfileTool) Move(x, y float32) {
+	if !t.down {
+		return
+	}
+	ctx := t.viewer.overlayContext()
+	ctx.Set("strokeStyle", "#4de6ff")
+	ctx.Set("lineWidth", 2*js.Global().Get("devicePixelRatio").Float())
+	ctx.Call("beginPath")
+	ctx.Call("moveTo", t.x0, t.y0)
+	ctx.Call("lineTo", x, y)
+	ctx.Call("stroke")
+}
+
+func (t *ProfileTool) Up(x, y float32) {
+	if !t.down {
+		return
+	}
+	t.down = false
+	t.viewer.setTool(nil)
+	canvas := t.viewer.canvas
+	width, height := float32(canvas.Get("width").Float()), float32(canvas.Get("height").Float())
+	a, okA := t.viewer.worldAt(t.x0, t.y0, width, height)
+	b, okB := t.viewer.worldAt(x, y, width, height)
+	if !okA || !okB {
+		js.Global().Get("console").Call("warn", "Profile: the line's ends must be over points or the ground")
+		return
+	}
+	data, err := t.viewer.extractSection(a, b, t.params)
+	if err != nil {
+		js.Global().Get("console").Call("warn", "Profile: "+err.Error())
+		return
+	}
+	t.viewer.showProfile(data)
+}
+
+func (t *ProfileTool) Cancel() {
+	t.down = false
+	t.viewer.clearOverlay()
+}
+
+// ProfileView is a DOM overlay plotting a section as distance along the
+// line against elevation. Each axis is fitted to the section separately,
+// so the plot is usually vertically exaggerated; the title gives by how
+// much.
+type ProfileView struct {
+	root   js.Value
+	title  js.Value
+	canvas js.Value
+	ctx    js.Value
+	data   *ProfileData
+}
+
+func (v *Viewer) newProfileView() *ProfileView {
+	doc := js.Global().Get("document")
+	p := &ProfileView{}
+	p.root = doc.Call("createElement", "div")
+	p.root.Set("style", "position:fixed;right:10px;bottom:10px;padding:8px;border-radius:6px;"+
+		"background:rgba(20,20,30,0.85);color:#eee;font:12px sans-serif;z-index:10;user-select:none")
+	header := doc.Call("createElement", "div")
+	header.Set("style", "display:flex;justify-content:space-between;align-items:center;margin-bottom:4px")
+	p.title = doc.Call("createElement", "span")
+	closeButton := doc.Call("createElement", "button")
+	closeButton.Set("textContent", msg("button.close"))
+	header.Call("appendChild", p.title)
+	header.Call("appendChild", closeButton)
+	p.root.Call("appendChild", header)
+
+	ratio := js.Global().Get("devicePixelRatio").Float()
+	p.canvas = doc.Call("createElement", "canvas")
+	p.canvas.Set("width", int(profileWidth*ratio))
+	p.canvas.Set("height", int(profileHeight*ratio))
+	p.canvas.Set("style", fmt.Sprintf("display:block;width:%dpx;height:%dpx;background:#111", profileWidth, profileHeight))
+	p.ctx = p.canvas.Call("getContext", "2d")
+	p.ctx.Call("scale", ratio, ratio)
+	p.root.Call("appendChild", p.canvas)
+
+	listen(closeButton, "click", nil, func(js.Value) { v.closeProfile(js.Undefined(), nil) })
+	doc.Get("body").Call("appendChild", p.root)
+	return p
+}
+
+// draw plots the section's points in their colors, with the elevation and
+// distance ranges labelled.
+func (v *ProfileView) draw() {
+	const margin = 24
+	ctx, d := v.ctx, v.data
+	ctx.Call("clearRect", 0, 0, profileWidth, profileHeight)
+	lo, hi := d.elevationRange()
+	span := math.Max(hi-lo, 1e-9)
+	plotW, plotH := float64(profileWidth-2*margin), float64(profileHeight-2*margin)
+	exaggeration := (plotH / span) / (plotW / math.Max(d.length, 1e-9))
+	v.title.Set("textContent", msgf("profile.title", d.length, len(d.samples), exaggeration))
+
+	for _, s := range d.samples {
+		x := margin + s.Distance/math.Max(d.length, 1e-9)*plotW
+		y := margin + (hi-s.Elevation)/span*plotH
+		c := s.color
+		ctx.Set("fillStyle", fmt.Sprintf("rgb(%d,%d,%d)", int(c[0]*255), int(c[1]*255), int(c[2]*255)))
+		ctx.Call("fillRect", x-1, y-1, 2, 2)
+	}
+
+	ctx.Set("strokeStyle", "#666")
+	ctx.Call("strokeRect", margin, margin, plotW, plotH)
+	ctx.Set("fillStyle", "#ccc")
+	ctx.Set("font", "10px sans-serif")
+	ctx.Call("fillText", fmt.Sprintf("%.4g", hi), 2, margin-4)
+	ctx.Call("fillText", fmt.Sprintf("%.4g", lo), 2, profileHeight-margin+12)
+	ctx.Call("fillText", "0", margin, profileHeight-4)
+	ctx.Call("fillText", fmt.Sprintf("%.4g", d.length), profileWidth-margin-24, profileHeight-4)
+}
+
+// showProfile shows data in the profile view and its corridor in the
+// scene, and emits profileExtracted.
+func (v *Viewer) showProfile(data *ProfileData) {
+	if v.profileView == nil {
+		v.profileView = v.newProfileView()
+	}
+	v.profileView.data = data
+	v.profileView.root.Get("style").Set("display", "block")
+	v.profileView.draw()
+	if err := data.showCorridor(v.scene); err != nil {
+		js.Global().Get("console").Call("warn", "Profile: "+err.Error())
+	}
+	v.emit(eventProfile, map[string]interface{}{
+		"length": data.length,
+		"width":  2 * data.halfWidth,
+		"count":  len(data.samples),
+	})
+}
+
+// startProfile(params) lets the user drag a section line across the
+// canvas; on release the points within params.width (default 0.05) of the
+// vertical plane through the line are shown in the profile view. The
+// line's ends are placed on the points under them, or on the ground plane
+// y = 0. params.up sets the vertical, default [0, 1, 0]. Escape cancels.
+func (v *Viewer) startProfile(this js.Value, args []js.Value) interface{} {
+	params := js.Undefined()
+	if len(args) > 0 {
+		params = args[0]
+	}
+	p, err := parseProfileParams(params)
+	if err != nil {
+		return jsError("startProfile: " + err.Error())
+	}
+	v.setTool(&ProfileTool{viewer: v, params: p})
+	return nil
+}
+
+// extractProfile(a, b, params) extracts the section along the line from
+// world position a to b with the options of startProfile, and shows it
+// unless params.show is false.
+//
+// Returns {length, width, count, distance, elevation, offset, objects,
+// indices}, where the last five are per point, ordered by distance:
+// Float32Arrays of the distance along the line, the elevation and the
+// signed distance from the section plane, and the name of each point's
+// object and its index in it. Returns {error} for a vertical or empty
+// line.
+func (v *Viewer) extractProfile(this js.Value, args []js.Value) interface{} {
+	if len(args) < 2 {
+		return jsError("extractProfile: expected (a, b, params)")
+	}
+	a, errA := jsVec3(args[0])
+	b, errB := jsVec3(args[1])
+	if errA != nil || errB != nil {
+		return jsError("extractProfile: a and b must be [x, y, z]")
+	}
+	params := js.Undefined()
+	if len(args) > 2 {
+		params = args[2]
+	}
+	p, err := parseProfileParams(params)
+	if err != nil {
+		return jsError("extractProfile: " + err.Error())
+	}
+	data, err := v.extractSection([3]float64{float64(a[0]), float64(a[1]), float64(a[2])},
+		[3]float64{float64(b[0]), float64(b[1]), float64(b[2])}, p)
+	if err != nil {
+		return jsError("extractProfile: " + err.Error())
+	}
+	if value := jsValue(params, "show"); value.IsUndefined() || value.Truthy() {
+		v.showProfile(data)
+	}
+	n := len(data.samples)
+	distance, elevation, offset := make([]float32, n), make([]float32, n), make([]float32, n)
+	objects, indices := make([]interface{}, n), make([]interface{}, n)
+	for i, s := range data.samples {
+		distance[i], elevation[i], offset[i] = float32(s.Distance), float32(s.Elevation), float32(s.Offset)
+		objects[i], indices[i] = s.object, s.Index
+	}
+	return js.ValueOf(map[string]interface{}{
+		"length":    data.length,
+		"width":     2 * data.halfWidth,
+		"count":     n,
+		"distance":  glf32.ToFloat32Array(distance),
+		"elevation": glf32.ToFloat32Array(elevation),
+		"offset":    glf32.ToFloat32Array(offset),
+		"objects":   objects,
+		"indices":   indices,
+	})
+}
+
+// closeProfile() hides the profile view, removes the corridor mesh and
+// cancels drawing a section line.
+func (v *Viewer) closeProfile(this js.Value, args []js.Value) interface{} {
+	if _, ok := v.activeTool.(*ProfileTool); ok {
+		v.setTool(nil)
+	}
+	if v.profileView != nil {
+		v.profileView.root.Get("style").Set("display", "none")
+	}
+	v.scene.RemoveMesh(profileMesh)
+	return nil
+}
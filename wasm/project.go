@@ -0,0 +1,255 @@
+// wasm/project.go
+package main
+
+import (
+	"fmt"
+	"syscall/js"
+
+	"github.com/sbecker11/webgl-point-cloud/glf32"
+	"github.com/sbecker11/webgl-point-cloud/layer"
+	"github.com/sbecker11/webgl-point-cloud/pointcloud"
+	"github.com/sbecker11/webgl-point-cloud/procgen"
+	"github.com/sbecker11/webgl-point-cloud/project"
+)
+
+// applyPendingObject applies and forgets any pending project settings for o.
+func (v *Viewer) applyPendingObject(o *SceneObject) {
+	p, ok := v.pendingObjects[o.Cloud.Name]
+	if !ok {
+		return
+	}
+	delete(v.pendingObjects, o.Cloud.Name)
+	v.applyObjectSettings(o, p)
+	v.paintObjectSegments(o)
+}
+
+// applyObjectSettings applies saved settings to an object.
+func (v *Viewer) applyObjectSettings(o *SceneObject, p project.Object) {
+	o.Model = glf32.Mat4(append([]float32(nil), p.Model...))
+	o.Visible = p.Visible
+	o.Opacity = p.Opacity
+	o.DepthWrite = p.DepthWrite
+	o.Tint, o.Color = p.Tint, p.Color
+	o.PointStyle = PointStyle{Size: p.PointSize, Round: p.Round}
+	if mode, err := parseName("size mode", pointSizeModeNames, p.SizeMode); err == nil {
+		o.PointStyle.SizeMode = PointSizeMode(mode)
+	}
+	if shading, err := parseName("shading", shadingNames, p.Shading); err == nil {
+		o.PointStyle.Shading = Shading(shading)
+	}
+	if l, err := v.scene.Layers().Create(p.Layer); err == nil {
+		v.scene.Layers().Move(o.Cloud.Name, l)
+	}
+}
+
+// currentProject captures the scene, view settings and camera.
+func (v *Viewer) currentProject() *project.Project {
+	p := &project.Project{
+		Camera: &project.Camera{
+			Distance:  v.camera.distance,
+			RotationX: v.camera.rotationX,
+			RotationY: v.camera.rotationY,
+			Zoom:      v.camera.zoom,
+			Target:    [3]float32{v.camera.target[0], v.camera.target[1], v.camera.target[2]},
+		},
+	}
+	p.View.ColorMode = colorModeName(v.classStyle.Mode)
+	if f := v.scene.Filter(); f != nil {
+		p.View.Filter = f.String()
+	}
+	for class, visible := range v.classStyle.Visible {
+		if !visible {
+			p.View.HiddenClasses = append(p.View.HiddenClasses, class)
+		}
+	}
+	for class, color := range v.classStyle.Colors {
+		entry := project.ClassStyle{Class: class, Name: v.classStyle.Names[class]}
+		if color != pointcloud.ClassColor(uint8(class)) {
+			entry.Color = color[:]
+		}
+		if entry.Color != nil || entry.Name != "" {
+			p.View.Palette = append(p.View.Palette, entry)
+		}
+	}
+	v.scene.Layers().Walk(func(l *layer.Layer) {
+		p.Layers = append(p.Layers, project.Layer{
+			Path:      l.Path(),
+			Visible:   l.Visible,
+			Opacity:   l.Opacity,
+			Transform: append([]float32(nil), l.Transform...),
+		})
+	})
+	for _, o := range v.scene.Objects() {
+		obj := project.Object{
+			Name:       o.Cloud.Name,
+			Source:     o.Source,
+			Model:      append([]float32(nil), o.Model...),
+			Visible:    o.Visible,
+			Opacity:    o.Opacity,
+			DepthWrite: o.DepthWrite,
+			Tint:       o.Tint,
+			Color:      o.Color,
+			SizeMode:   o.PointStyle.SizeMode.String(),
+			PointSize:  o.PointStyle.Size,
+			Round:      o.PointStyle.Round,
+			Shading:    o.PointStyle.Shading.String(),
+		}
+		if l := v.scene.Layers().LayerOf(o.Cloud.Name); l != nil {
+			obj.Layer = l.Path()
+		}
+		p.Objects = append(p.Objects, obj)
+	}
+	p.Selections = append(p.Selections, v.namedSelections...)
+	return p
+}
+
+// saveProject() returns the scene as a JSON project string: object sources,
+// transforms, styles and layers, named selections, the view settings and
+// the camera. Point
+// data is not included; procedural datasets are regenerated on load.
+func (v *Viewer) saveProject(this js.Value, args []js.Value) interface{} {
+	data, err := project.Marshal(v.currentProject())
+	if err != nil {
+		return jsError("saveProject: " + err.Error())
+	}
+	return string(data)
+}
+
+// downloadProject(filename) saves the project and offers it as a download
+// (default "scene.json").
+func (v *Viewer) downloadProject(this js.Value, args []js.Value) interface{} {
+	filename := "scene.json"
+	if len(args) > 0 && args[0].Type() == js.TypeString {
+		filename = args[0].String()
+	}
+	data, err := project.Marshal(v.currentProject())
+	if err != nil {
+		return jsError("downloadProject: " + err.Error())
+	}
+	downloadText(filename, "application/json", string(data))
+	return nil
+}
+
+// downloadText offers text as a download named filename.
+func downloadText(filename, mimeType, text string) {
+	downloadBlob(filename, js.Global().Get("Blob").New([]interface{}{text}, map[string]interface{}{"type": mimeType}))
+}
+
+// downloadBytes offers data as a download named filename.
+func downloadBytes(filename, mimeType string, data []byte) {
+	downloadBlob(filename, js.Global().Get("Blob").New([]interface{}{glf32.ToUint8Array(data)}, map[string]interface{}{"type": mimeType}))
+}
+
+// downloadBlob offers a JS Blob as a download named filename.
+func downloadBlob(filename string, blob js.Value) {
+	url := js.Global().Get("URL").Call("createObjectURL", blob)
+	a := js.Global().Get("document").Call("createElement", "a")
+	a.Set("href", url)
+	a.Set("download", filename)
+	a.Call("click")
+	js.Global().Get("URL").Call("revokeObjectURL", url)
+}
+
+// loadProject(json) replaces the scene with a saved project. Procedural
+// datasets are regenerated; other objects are listed as missing and get
+// their saved settings when a cloud of the same name is imported again.
+// Point edits made before saving are not restored. Clears the edit
+// history.
+//
+// Returns {objects, missing} or {error}.
+func (v *Viewer) loadProject(this js.Value, args []js.Value) interface{} {
+	if len(args) < 1 {
+		return jsError("loadProject: expected (json)")
+	}
+	p, err := project.Unmarshal([]byte(args[0].String()))
+	if err != nil {
+		return jsError("loadProject: " + err.Error())
+	}
+	mode := ColorModeRGB
+	if p.View.ColorMode != "" {
+		if mode, err = parseColorMode(p.View.ColorMode); err != nil {
+			return jsError("loadProject: " + err.Error())
+		}
+	}
+	// Regenerate datasets before touching the scene, so a bad project
+	// leaves it unchanged.
+	clouds := map[string]*pointcloud.Cloud{}
+	for _, o := range p.Objects {
+		if !o.Source.Reproducible() {
+			continue
+		}
+		cloud, err := procgen.New(o.Source.Seed).Dataset(o.Source.Dataset, o.Source.Points)
+		if err != nil {
+			return jsError(fmt.Sprintf("loadProject: %s: %v", o.Name, err))
+		}
+		cloud.Name = o.Name
+		clouds[o.Name] = cloud
+	}
+
+	v.scene.Clear()
+	v.scene.SetFilter(nil)
+	v.history.Clear()
+	v.pendingObjects = map[string]project.Object{}
+	v.namedSelections = nil
+	for _, sel := range p.Selections {
+		if len(v.namedSelections) == maxNamedSelections {
+			js.Global().Get("console").Call("warn", fmt.Sprintf("loadProject: ignoring selections beyond the first %d", maxNamedSelections))
+			break
+		}
+		if sel.Color == nil {
+			sel.Color = selectionPalette[len(v.namedSelections)][:]
+		}
+		v.namedSelections = append(v.namedSelections, sel)
+	}
+	for _, l := range p.Layers {
+		created, err := v.scene.Layers().Create(l.Path)
+		if err != nil {
+			continue
+		}
+		created.Visible, created.Opacity = l.Visible, l.Opacity
+		created.Transform = glf32.Mat4(append([]float32(nil), l.Transform...))
+	}
+	loaded, missing := []interface{}{}, []interface{}{}
+	for _, o := range p.Objects {
+		cloud, ok := clouds[o.Name]
+		if !ok {
+			v.pendingObjects[o.Name] = o
+			missing = append(missing, o.Name)
+			continue
+		}
+		obj := v.scene.Add(cloud, glf32.Identity())
+		obj.Source = o.Source
+		v.applyObjectSettings(obj, o)
+		v.paintObjectSegments(obj)
+		v.emitDatasetLoaded(obj)
+		loaded = append(loaded, o.Name)
+	}
+
+	v.classStyle.Mode = mode
+	v.classStyle.resetPalette()
+	for _, c := range p.View.Palette {
+		if c.Class < 0 || c.Class >= pointcloud.MaxClasses {
+			continue
+		}
+		if c.Color != nil {
+			copy(v.classStyle.Colors[c.Class][:], c.Color)
+		}
+		v.classStyle.Names[c.Class] = c.Name
+	}
+	for _, class := range p.View.HiddenClasses {
+		if class >= 0 && class < pointcloud.MaxClasses {
+			v.classStyle.Visible[class] = false
+		}
+	}
+	if p.View.Filter != "" {
+		if err := v.setSceneFilter(p.View.Filter); err != nil {
+			js.Global().Get("console").Call("warn", "loadProject: ignoring filter: "+err.Error())
+		}
+	}
+	if c := p.Camera; c != nil {
+		v.camera.distance, v.camera.rotationX, v.camera.rotationY, v.camera.zoom = c.Distance, c.RotationX, c.RotationY, c.Zoom
+		v.camera.target = glf32.Vec3{c.Target[0], c.Target[1], c.Target[2]}
+		v.camera.velocityX, v.camera.velocityY = 0, 0
+	}
+	return js.ValueOf(map[string]interface{}{"objects": loaded, "missing": missing})
+}
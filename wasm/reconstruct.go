@@ -0,0 +1,102 @@
+// wasm/reconstruct.go
+package main
+
+import (
+	"syscall/js"
+	"time"
+
+	"github.com/sbecker11/webgl-point-cloud/glf32"
+	"github.com/sbecker11/webgl-point-cloud/reconstruct"
+)
+
+// yieldEvery is how long a job running on the page's thread computes
+// before sleeping briefly, which hands control back to the browser so it
+// can draw frames, update the progress bar and deliver Cancel clicks.
+const yieldEvery = 30 * time.Millisecond
+
+// yielder returns a function that sleeps a millisecond whenever yieldEvery
+// has passed since it last did. It must be called from a goroutine other
+// than a JS callback's.
+func yielder() func() {
+	last := time.Now()
+	return func() {
+		if time.Since(last) >= yieldEvery {
+			time.Sleep(time.Millisecond)
+			last = time.Now()
+		}
+	}
+}
+
+// reconstructSurface(name, params) builds a preview surface mesh over an
+// object's visible points and adds it as a mesh named name + "-surface"
+// (or params.mesh), sharing the object's model matrix so it lines up with
+// the points. The cloud is downsampled to a grid and the boundary of a
+// ball around each sample is extracted, giving a closed shell that shows
+// where the scan's surface is continuous and where it has holes.
+//
+// params is optional and may hold resolution (grid cells along the longest
+// side, default 64), radius (ball radius in cells, default 1.2), mesh and
+// the style fields of setMeshStyle. The work runs as a job, shown in the
+// progress overlay and cancellable.
+//
+// Returns a Promise of the mesh's info, as addMesh returns it, plus job;
+// it rejects with an Error if there is no such object or the job is
+// cancelled.
+func (v *Viewer) reconstructSurface(this js.Value, args []js.Value) interface{} {
+	promise := js.Global().Get("Promise")
+	if len(args) < 1 || args[0].Type() != js.TypeString {
+		return promise.Call("reject", js.Global().Get("Error").New("reconstructSurface: expected (name, params)"))
+	}
+	name := args[0].String()
+	o := v.scene.Object(name)
+	if o == nil {
+		return promise.Call("reject", js.Global().Get("Error").New("reconstructSurface: no object named "+name))
+	}
+	params := js.Undefined()
+	if len(args) > 1 {
+		params = args[1]
+	}
+	meshName := jsString(params, "mesh", name+"-surface")
+	coords := o.VisibleCloud().Coords
+	model := append(glf32.Mat4(nil), o.Model...)
+
+	var handler js.Func
+	handler = js.FuncOf(func(this js.Value, pargs []js.Value) interface{} {
+		handler.Release()
+		resolve, reject := pargs[0], pargs[1]
+		j := jobs.Start("Reconstruct " + name)
+		yield := yielder()
+		go func() {
+			m, err := reconstruct.Surface(coords, reconstruct.Options{
+				Resolution: int(jsFloat(params, "resolution", 0)),
+				Radius:     float64(jsFloat(params, "radius", 0)),
+				Progress: func(fraction float64) error {
+					if err := j.Report(fraction); err != nil {
+						return err
+					}
+					yield()
+					return nil
+				},
+			})
+			if err != nil {
+				j.Finish(err)
+				reject.Invoke(js.Global().Get("Error").New("reconstructSurface: " + err.Error()))
+				return
+			}
+			sm, err := v.scene.AddMesh(meshName, m, model)
+			if err == nil {
+				err = applyMeshStyle(sm, params)
+			}
+			j.Finish(err)
+			if err != nil {
+				reject.Invoke(js.Global().Get("Error").New("reconstructSurface: " + err.Error()))
+				return
+			}
+			info := meshInfo(sm)
+			info["job"] = j.ID
+			resolve.Invoke(js.ValueOf(info))
+		}()
+		return nil
+	})
+	return promise.New(handler)
+}
@@ -0,0 +1,127 @@
+// wasm/recording.go
+package main
+
+import (
+	"syscall/js"
+)
+
+// recordingTypes are the video formats tried for recordings, best first.
+var recordingTypes = []string{"video/webm;codecs=vp9", "video/webm;codecs=vp8", "video/webm"}
+
+// Recorder records the canvas to a WebM video with a MediaRecorder on the
+// canvas's capture stream.
+type Recorder struct {
+	recorder js.Value
+	chunks   js.Value // JS array of the Blobs recorded so far
+	mimeType string
+	started  float64 // performance.now() at the start, in ms
+	funcs    []js.Func
+}
+
+// release frees the recorder's callbacks.
+func (r *Recorder) release() {
+	for _, fn := range r.funcs {
+		fn.Release()
+	}
+	r.funcs = nil
+}
+
+// startRecording(fps) starts recording the canvas as a WebM video at fps
+// frames per second (default 30), for recording interaction or a
+// flythrough, e.g. startRecording(); playCameraPath().then(() =>
+// stopRecording()).
+//
+// Returns {mimeType} or {error} if recording is unsupported or already
+// running.
+func (v *Viewer) startRecording(this js.Value, args []js.Value) interface{} {
+	if v.recording != nil {
+		return jsError("startRecording: already recording")
+	}
+	fps := 30.0
+	if len(args) > 0 && args[0].Type() == js.TypeNumber {
+		fps = args[0].Float()
+	}
+	if fps <= 0 {
+		return jsError("startRecording: fps must be positive")
+	}
+	mediaRecorder := js.Global().Get("MediaRecorder")
+	canvas := v.canvas
+	if mediaRecorder.IsUndefined() || canvas.Get("captureStream").IsUndefined() {
+		return jsError("startRecording: this browser cannot record the canvas")
+	}
+	mimeType := ""
+	for _, t := range recordingTypes {
+		if mediaRecorder.Call("isTypeSupported", t).Bool() {
+			mimeType = t
+			break
+		}
+	}
+	if mimeType == "" {
+		return jsError("startRecording: this browser cannot record WebM video")
+	}
+	stream := canvas.Call("captureStream", fps)
+	r := &Recorder{
+		recorder: mediaRecorder.New(stream, map[string]interface{}{"mimeType": mimeType}),
+		chunks:   js.Global().Get("Array").New(),
+		mimeType: mimeType,
+		started:  js.Global().Get("performance").Call("now").Float(),
+	}
+	onData := js.FuncOf(func(this js.Value, args []js.Value) interface{} {
+		if data := args[0].Get("data"); data.Get("size").Int() > 0 {
+			r.chunks.Call("push", data)
+		}
+		return nil
+	})
+	r.funcs = append(r.funcs, onData)
+	r.recorder.Set("ondataavailable", onData)
+	r.recorder.Call("start")
+	v.recording = r
+	return js.ValueOf(map[string]interface{}{"mimeType": mimeType})
+}
+
+// stopRecording(filename) stops the recording and downloads it, by default
+// as "recording.webm".
+//
+// Returns a Promise of {bytes, seconds} once the video is downloaded,
+// which rejects with an Error if nothing is being recorded.
+func (v *Viewer) stopRecording(this js.Value, args []js.Value) interface{} {
+	promise := js.Global().Get("Promise")
+	r := v.recording
+	if r == nil {
+		return promise.Call("reject", js.Global().Get("Error").New("stopRecording: not recording"))
+	}
+	v.recording = nil
+	filename := "recording.webm"
+	if len(args) > 0 && args[0].Type() == js.TypeString {
+		filename = args[0].String()
+	}
+	seconds := (js.Global().Get("performance").Call("now").Float() - r.started) / 1000
+
+	var handler js.Func
+	handler = js.FuncOf(func(this js.Value, pargs []js.Value) interface{} {
+		handler.Release()
+		resolve := pargs[0]
+		onStop := js.FuncOf(func(this js.Value, args []js.Value) interface{} {
+			blob := js.Global().Get("Blob").New(r.chunks, map[string]interface{}{"type": r.mimeType})
+			downloadBlob(filename, blob)
+			r.release()
+			resolve.Invoke(js.ValueOf(map[string]interface{}{"bytes": blob.Get("size"), "seconds": seconds}))
+			return nil
+		})
+		r.funcs = append(r.funcs, onStop)
+		r.recorder.Set("onstop", onStop)
+		r.recorder.Call("stop")
+		// Stop the capture stream's track so the canvas is no longer captured.
+		tracks := r.recorder.Get("stream").Call("getTracks")
+		for i := 0; i < tracks.Length(); i++ {
+			tracks.Index(i).Call("stop")
+		}
+		return nil
+	})
+	return promise.New(handler)
+}
+
+// isRecording() reports whether the canvas is being recorded.
+func (v *Viewer) isRecording(this js.Value, args []js.Value) interface{} {
+	return v.recording != nil
+}
@@ -0,0 +1,220 @@
+// wasm/rendergraph.go
+package main
+
+import (
+	"fmt"
+	"strings"
+	"syscall/js"
+
+	"github.com/sbecker11/webgl-point-cloud/glf32"
+	"github.com/sbecker11/webgl-point-cloud/rendergraph"
+)
+
+// frameState is what render passes draw with: this frame's view and level
+// of detail.
+type frameState struct {
+	viewProj  glf32.Mat4
+	pointSize float32
+	fraction  float64
+}
+
+// RenderPass is an effect's step of the frame, run by a RenderGraph after
+// the scene is drawn. A pass with an output draws offscreen into a texture
+// of the given Format the size of the drawing buffer, cleared first, with
+// blending off, and with a depth buffer if Depth; WebGL 1 gives it one
+// output. A pass without one draws onto the canvas over the scene, with
+// depth testing off, as full-screen composites do.
+type RenderPass struct {
+	rendergraph.Pass
+	Format  TextureFormat
+	Depth   bool
+	Enabled func() bool
+	Draw    func(c *PassContext)
+}
+
+// PassContext is what a pass's Draw gets: the GL context, the frame and
+// its inputs, bound to texture units in the order the pass lists them.
+type PassContext struct {
+	gl            js.Value
+	frame         *frameState
+	width, height int
+	graph         *RenderGraph
+	units         map[string]int
+}
+
+// Unit returns the texture unit input name is bound to.
+func (c *PassContext) Unit(name string) int {
+	return c.units[name]
+}
+
+// DrawQuad draws a quad covering the target, for the fragment shader of a
+// program linked with fullscreenVertexShader, which must be in use.
+func (c *PassContext) DrawQuad() {
+	gl := c.gl
+	gl.Call("bindBuffer", gl.Get("ARRAY_BUFFER"), c.graph.quad)
+	gl.Call("vertexAttribPointer", attribPosition, 2, gl.Get("FLOAT"), false, 0, 0)
+	gl.Call("drawArrays", gl.Get("TRIANGLES"), 0, 6)
+}
+
+// fullscreenVertexShader positions the quad PassContext.DrawQuad draws and
+// hands its fragment shader vTexCoord, running from 0 to 1 across the
+// target.
+const fullscreenVertexShader = `
+attribute vec2 aPosition;
+varying vec2 vTexCoord;
+void main() {
+	gl_Position = vec4(aPosition, 0.0, 1.0);
+	vTexCoord = aPosition * 0.5 + 0.5;
+}`
+
+// RenderGraph runs the enabled render passes each frame in an order that
+// draws every texture before it is read, creating the textures and
+// framebuffers they need, resizing them with the canvas and deleting them
+// when no enabled pass uses them any more.
+type RenderGraph struct {
+	viewer        *Viewer
+	passes        []*RenderPass
+	targets       map[string]*Framebuffer // by output texture
+	width, height int
+	quad          js.Value
+	key           string // names of the enabled passes the order is for
+	order         []*RenderPass
+	err           error
+}
+
+// newRenderGraph returns a graph with no passes.
+func newRenderGraph(v *Viewer) *RenderGraph {
+	return &RenderGraph{viewer: v, targets: map[string]*Framebuffer{}, quad: js.Undefined()}
+}
+
+// add appends a pass. Passes drawing onto the canvas composite in the
+// order they were added.
+func (g *RenderGraph) add(p *RenderPass) {
+	g.passes = append(g.passes, p)
+}
+
+// plan orders the enabled passes and frees the resources of the others.
+// It reports an error, once, if they cannot be ordered.
+func (g *RenderGraph) plan(gl js.Value) {
+	var enabled []*RenderPass
+	var names []string
+	for _, p := range g.passes {
+		if p.Enabled() && g.supported(gl, p) {
+			enabled = append(enabled, p)
+			names = append(names, p.Name)
+		}
+	}
+	key := strings.Join(names, "\x00")
+	if key == g.key {
+		return
+	}
+	g.key = key
+	byName := map[string]*RenderPass{}
+	passes := make([]rendergraph.Pass, len(enabled))
+	for i, p := range enabled {
+		byName[p.Name], passes[i] = p, p.Pass
+	}
+	order, err := rendergraph.Order(passes)
+	for _, p := range passes {
+		if len(p.Outputs) > 1 && err == nil {
+			err = fmt.Errorf("pass %q draws %d textures; WebGL 1 draws one", p.Name, len(p.Outputs))
+		}
+	}
+	if err != nil {
+		js.Global().Get("console").Call("error", "Render graph: "+err.Error())
+	}
+	g.order, g.err = nil, err
+	for _, p := range order {
+		g.order = append(g.order, byName[p.Name])
+	}
+	used := map[string]bool{}
+	for _, t := range rendergraph.Textures(order) {
+		used[t] = true
+	}
+	for name, f := range g.targets {
+		if !used[name] {
+			f.delete(gl)
+			delete(g.targets, name)
+		}
+	}
+}
+
+// supported reports whether the browser can draw pass p's output, telling
+// the user once when it cannot, so the pass is left out instead of
+// failing each frame.
+func (g *RenderGraph) supported(gl js.Value, p *RenderPass) bool {
+	if len(p.Outputs) == 0 {
+		return true
+	}
+	if err := g.viewer.textureFormatError(gl, p.Format); err != nil {
+		g.viewer.notice(msgf("notice.effectOff", p.Name, err))
+		return false
+	}
+	return true
+}
+
+// target returns the framebuffer of offscreen pass p, creating it on first
+// use and resizing it with the drawing buffer.
+func (g *RenderGraph) target(gl js.Value, p *RenderPass) (*Framebuffer, error) {
+	output := p.Outputs[0]
+	if f := g.targets[output]; f != nil {
+		f.resize(gl, g.width, g.height)
+		return f, nil
+	}
+	depth := NoDepth
+	if p.Depth {
+		depth = DepthRenderbuffer
+	}
+	f, err := g.viewer.newFramebuffer(gl, g.width, g.height, p.Format, depth)
+	if err != nil {
+		return nil, fmt.Errorf("pass %q: %v", p.Name, err)
+	}
+	g.targets[output] = f
+	return f, nil
+}
+
+// run runs the enabled passes for frame f, after the scene is drawn, and
+// leaves the canvas bound with blending and depth testing on.
+func (g *RenderGraph) run(gl js.Value, f *frameState) {
+	g.plan(gl)
+	if g.err != nil || len(g.order) == 0 {
+		return
+	}
+	g.width, g.height = gl.Get("drawingBufferWidth").Int(), gl.Get("drawingBufferHeight").Int()
+	if g.quad.IsUndefined() {
+		g.quad = gl.Call("createBuffer")
+		gl.Call("bindBuffer", gl.Get("ARRAY_BUFFER"), g.quad)
+		gl.Call("bufferData", gl.Get("ARRAY_BUFFER"), glf32.ToFloat32Array([]float32{-1, -1, 1, -1, -1, 1, -1, 1, 1, -1, 1, 1}), gl.Get("STATIC_DRAW"))
+	}
+	for _, p := range g.order {
+		c := &PassContext{gl: gl, frame: f, width: g.width, height: g.height, graph: g, units: map[string]int{}}
+		if len(p.Outputs) > 0 {
+			target, err := g.target(gl, p)
+			if err != nil {
+				g.viewer.notice(msgf("notice.postOff", err))
+				g.err = err
+				return
+			}
+			target.bind(gl)
+			gl.Call("disable", gl.Get("BLEND"))
+			gl.Call("clearColor", 0, 0, 0, 0)
+			gl.Call("clear", gl.Get("COLOR_BUFFER_BIT").Int()|gl.Get("DEPTH_BUFFER_BIT").Int())
+		} else {
+			gl.Call("disable", gl.Get("DEPTH_TEST"))
+		}
+		for i, name := range p.Inputs {
+			c.units[name] = i
+			gl.Call("activeTexture", gl.Get("TEXTURE0").Int()+i)
+			gl.Call("bindTexture", gl.Get("TEXTURE_2D"), g.targets[name].Color.texture)
+		}
+		p.Draw(c)
+		gl.Call("bindFramebuffer", gl.Get("FRAMEBUFFER"), js.Null())
+		gl.Call("enable", gl.Get("BLEND"))
+		gl.Call("enable", gl.Get("DEPTH_TEST"))
+		for i := range p.Inputs {
+			gl.Call("activeTexture", gl.Get("TEXTURE0").Int()+i)
+			gl.Call("bindTexture", gl.Get("TEXTURE_2D"), js.Null())
+		}
+	}
+	gl.Call("activeTexture", gl.Get("TEXTURE0"))
+}
@@ -0,0 +1,207 @@
+// wasm/retention.go
+package main
+
+import (
+	"fmt"
+	"syscall/js"
+
+	"github.com/sbecker11/webgl-point-cloud/pointcloud"
+)
+
+// Retention modes of streamed objects.
+const (
+	retainReplace    = "replace"
+	retainAccumulate = "accumulate"
+	retainWindow     = "window"
+	retainDecay      = "decay"
+)
+
+// Retention says which frames of a stream its object shows: only the
+// latest (replace, the default), all of them (accumulate), those among the
+// last Frames frames and the last Seconds seconds (window), or those of
+// the last Seconds seconds, fading out as they age (decay). MaxPoints
+// bounds the points shown by dropping the oldest frames beyond it.
+type Retention struct {
+	Mode      string
+	Frames    int
+	Seconds   float64
+	MaxPoints int
+}
+
+// defaultStreamPoints is the default MaxPoints.
+const defaultStreamPoints = 5000000
+
+// retentionRefreshMs is how often objects whose frames expire with time
+// are updated while no new frames arrive.
+const retentionRefreshMs = 100
+
+// streamFrame is a frame of a stream and the time it arrived, as
+// performance.now().
+type streamFrame struct {
+	cloud *pointcloud.Cloud
+	time  float64
+}
+
+// streamHistory holds the frames an object shows under its retention.
+type streamHistory struct {
+	Retention
+	frames    []streamFrame
+	colors    []float32 // the shown frames' colors before fading, when decaying
+	refreshed float64
+}
+
+// add records a frame arriving at now and returns the cloud to show.
+func (h *streamHistory) add(cloud *pointcloud.Cloud, now float64) *pointcloud.Cloud {
+	h.frames = append(h.frames, streamFrame{cloud, now})
+	h.prune(now)
+	return h.cloud(cloud.Name, now)
+}
+
+// prune drops the frames the retention no longer keeps, always keeping
+// the latest, and reports whether it dropped any.
+func (h *streamHistory) prune(now float64) bool {
+	keep := 0
+	switch h.Mode {
+	case retainReplace:
+		keep = len(h.frames) - 1
+	case retainWindow, retainDecay:
+		if h.Frames > 0 {
+			keep = max(keep, len(h.frames)-h.Frames)
+		}
+		if h.Seconds > 0 {
+			for keep < len(h.frames)-1 && now-h.frames[keep].time >= h.Seconds*1000 {
+				keep++
+			}
+		}
+	}
+	points := 0
+	for _, f := range h.frames[keep:] {
+		points += f.cloud.Len()
+	}
+	for keep < len(h.frames)-1 && points > h.MaxPoints {
+		points -= h.frames[keep].cloud.Len()
+		keep++
+	}
+	if keep <= 0 {
+		return false
+	}
+	h.frames = append(h.frames[:0], h.frames[keep:]...)
+	return true
+}
+
+// cloud returns the frames' points as one cloud named name, faded by age
+// when decaying.
+func (h *streamHistory) cloud(name string, now float64) *pointcloud.Cloud {
+	if len(h.frames) == 1 && h.Mode != retainDecay {
+		return h.frames[0].cloud
+	}
+	clouds := make([]*pointcloud.Cloud, len(h.frames))
+	for i, f := range h.frames {
+		clouds[i] = f.cloud
+	}
+	c := pointcloud.Concat(name, clouds...)
+	if h.Mode == retainDecay {
+		h.colors = c.Colors
+		c.Colors = h.faded(now)
+	}
+	return c
+}
+
+// faded returns the shown frames' colors with their alpha scaled down
+// linearly with age, to 0 at Seconds.
+func (h *streamHistory) faded(now float64) []float32 {
+	colors := make([]float32, len(h.colors))
+	copy(colors, h.colors)
+	i := 0
+	for _, f := range h.frames {
+		fade := float32(max(0, 1-(now-f.time)/(h.Seconds*1000)))
+		for end := i + f.cloud.Len()*4; i < end; i += 4 {
+			colors[i+3] *= fade
+		}
+	}
+	return colors
+}
+
+// updateRetention expires and fades the frames of objects whose retention
+// depends on time, at most every retentionRefreshMs. It is called once a
+// frame with the frame's timestamp.
+func (v *Viewer) updateRetention(now float64) {
+	for name, h := range v.streamHistories {
+		if h.Seconds <= 0 || (h.Mode != retainWindow && h.Mode != retainDecay) || now-h.refreshed < retentionRefreshMs || len(h.frames) == 0 {
+			continue
+		}
+		h.refreshed = now
+		o := v.scene.Object(name)
+		if o == nil {
+			continue
+		}
+		if h.prune(now) {
+			v.scene.SetCloud(o, h.cloud(name, now))
+			o.chunks = h.revealChunks(v.view.Reveal)
+		} else if h.Mode == retainDecay && len(o.Cloud.Colors) == len(h.colors) {
+			v.scene.SetColors(o, h.faded(now))
+		}
+	}
+}
+
+// setStreamRetention(name, params) sets which frames the named streamed
+// object shows (see commitPointBuffer, connectROS and connectSensorStream).
+// params.mode is "replace" (only the latest, the default), "accumulate"
+// (every frame), "window" (the last params.frames frames and/or
+// params.seconds seconds; default 10 frames) or "decay" (the last
+// params.seconds seconds, default 5, fading out with age in the RGB color
+// mode). params.maxPoints bounds the points shown, dropping the oldest
+// frames beyond it (default 5,000,000). The object need not exist yet; if
+// it does, its current points count as its first frame.
+//
+// Returns {name, mode, frames, seconds, maxPoints} or {error}.
+func (v *Viewer) setStreamRetention(this js.Value, args []js.Value) interface{} {
+	if len(args) < 2 || args[0].Type() != js.TypeString || args[1].Type() != js.TypeObject {
+		return jsError("setStreamRetention: expected (name, {mode, frames, seconds, maxPoints})")
+	}
+	name, params := args[0].String(), args[1]
+	r := Retention{
+		Mode:      jsString(params, "mode", retainReplace),
+		Frames:    int(jsFloat(params, "frames", 0)),
+		Seconds:   float64(jsFloat(params, "seconds", 0)),
+		MaxPoints: int(jsFloat(params, "maxPoints", defaultStreamPoints)),
+	}
+	switch r.Mode {
+	case retainReplace, retainAccumulate:
+	case retainWindow:
+		if r.Frames <= 0 && r.Seconds <= 0 {
+			r.Frames = 10
+		}
+	case retainDecay:
+		if r.Seconds <= 0 {
+			r.Seconds = 5
+		}
+	default:
+		return jsError(fmt.Sprintf("setStreamRetention: unknown mode %q; expected replace, accumulate, window or decay", r.Mode))
+	}
+	if r.Frames < 0 || r.Seconds < 0 || r.MaxPoints < 1 {
+		return jsError("setStreamRetention: frames, seconds and maxPoints must be positive")
+	}
+
+	h := v.streamHistories[name]
+	if h == nil {
+		h = &streamHistory{}
+		v.streamHistories[name] = h
+	}
+	h.Retention = r
+	now := js.Global().Get("performance").Call("now").Float()
+	if o := v.scene.Object(name); o != nil {
+		if len(h.frames) == 0 {
+			h.frames = []streamFrame{{o.Cloud, now}}
+		}
+		h.prune(now)
+		v.scene.SetCloud(o, h.cloud(name, now))
+	}
+	return js.ValueOf(map[string]interface{}{
+		"name":      name,
+		"mode":      r.Mode,
+		"frames":    r.Frames,
+		"seconds":   r.Seconds,
+		"maxPoints": r.MaxPoints,
+	})
+}
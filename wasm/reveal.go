@@ -0,0 +1,120 @@
+// wasm/reveal.go
+package main
+
+import (
+	"syscall/js"
+
+	"github.com/sbecker11/webgl-point-cloud/glf32"
+	"github.com/sbecker11/webgl-point-cloud/reveal"
+)
+
+// maxRevealMs is the longest reveal animation.
+const maxRevealMs = 10000
+
+// maxRevealChunks is how many chunks of an object the point shader times
+// apart; more are merged (see reveal.Merge). It sizes reveal.glsl's
+// uRevealChunks.
+const maxRevealChunks = 16
+
+// noRevealChunks fills uRevealChunks for objects not being revealed.
+var noRevealChunks = make([]float32, maxRevealChunks*3)
+
+// revealNow returns the time reveal chunks are timed by, as
+// performance.now(), the clock streamed frames are stamped with.
+func revealNow() float64 {
+	return js.Global().Get("performance").Call("now").Float()
+}
+
+// revealChunk starts revealing count of the object's points from the
+// first'th, which just arrived, if the view animates arriving points.
+func (v *Viewer) revealChunk(o *SceneObject, first, count int) {
+	if v.view.Reveal > 0 && count > 0 {
+		o.chunks = append(o.chunks, reveal.Chunk{First: first, Count: count, Time: revealNow()})
+	}
+}
+
+// bindReveal sets shader's uRevealChunks to the object's chunks still
+// being revealed at now, feeding it the point indices to find each
+// point's chunk by, if it was built with FeatureReveal. Whichever way the
+// object's points are drawn, through the point budget, draw indices or a
+// subsample, each point fades in with its own chunk.
+func (s *Scene) bindReveal(shader *PointShader, o *SceneObject, now float64) {
+	gl := s.gl
+	if shader.revealLoc.IsNull() {
+		return
+	}
+	o.chunks = reveal.Prune(o.chunks, now, s.viewer.view.Reveal)
+	loc, ok := shader.attributes["aIndex"]
+	if o.chunks == nil || !ok {
+		gl.Call("uniform3fv", shader.revealLoc, glf32.ToFloat32Array(noRevealChunks))
+		return
+	}
+	o.chunks = reveal.Merge(o.chunks, maxRevealChunks)
+	packed := make([]float32, 0, maxRevealChunks*3)
+	for _, c := range o.chunks {
+		packed = append(packed, float32(c.First), float32(c.First+c.Count), float32(reveal.Progress(c.Time, now, s.viewer.view.Reveal)))
+	}
+	packed = append(packed, noRevealChunks[len(packed):]...)
+	gl.Call("uniform3fv", shader.revealLoc, glf32.ToFloat32Array(packed))
+	if o.positions == nil {
+		// Objects with position textures have their indices bound already.
+		gl.Call("enableVertexAttribArray", loc)
+		gl.Call("bindBuffer", gl.Get("ARRAY_BUFFER"), s.pointIndices(o.Cloud.Len()))
+		gl.Call("vertexAttribPointer", loc, 1, gl.Get("FLOAT"), false, 0, 0)
+	}
+}
+
+// revealing reports whether any object's points are being revealed, which
+// needs a frame drawn for every step of the animation and point programs
+// with FeatureReveal. Chunks revealed in full are dropped.
+func (v *Viewer) revealing() bool {
+	now, active := 0.0, false
+	for _, o := range v.scene.objects {
+		if o.chunks == nil {
+			continue
+		}
+		if now == 0 {
+			now = revealNow()
+		}
+		o.chunks = reveal.Prune(o.chunks, now, v.view.Reveal)
+		active = active || o.chunks != nil
+	}
+	return active
+}
+
+// revealChunks returns the history's frames as reveal chunks, in the order
+// its cloud holds their points, for a reveal over duration milliseconds.
+func (h *streamHistory) revealChunks(duration float64) []reveal.Chunk {
+	if duration <= 0 {
+		return nil
+	}
+	var chunks []reveal.Chunk
+	first := 0
+	for _, f := range h.frames {
+		chunks = append(chunks, reveal.Chunk{First: first, Count: f.cloud.Len(), Time: f.time})
+		first += f.cloud.Len()
+	}
+	return reveal.Prune(chunks, revealNow(), duration)
+}
+
+// setRevealAnimation(ms) fades in and grows points as they arrive over ms
+// milliseconds, up to 10,000, or turns the animation off with 0, the
+// default: the points of objects added, of each frame a stream
+// accumulates (see setStreamRetention) and of each band of a position
+// texture uploaded (see setPositionStorage), so progressive loads fill in
+// smoothly instead of popping in, however the points are drawn. Streams
+// that replace their points with each frame show each frame at once, as
+// fading the whole cloud in every frame would make it flicker. Returns
+// {duration} or {error}.
+func (v *Viewer) setRevealAnimation(this js.Value, args []js.Value) interface{} {
+	if len(args) < 1 || args[0].Type() != js.TypeNumber || !(args[0].Float() >= 0 && args[0].Float() <= maxRevealMs) {
+		return jsError("setRevealAnimation: expected a duration from 0 to 10000 ms")
+	}
+	v.view.Reveal = args[0].Float()
+	if v.view.Reveal == 0 {
+		for _, o := range v.scene.objects {
+			o.chunks = nil
+		}
+	}
+	return js.ValueOf(map[string]interface{}{"duration": v.view.Reveal})
+}
@@ -0,0 +1,247 @@
+// wasm/ros.go
+package main
+
+import (
+	"fmt"
+	"sort"
+	"syscall/js"
+
+	"github.com/sbecker11/webgl-point-cloud/glf32"
+	"github.com/sbecker11/webgl-point-cloud/project"
+	"github.com/sbecker11/webgl-point-cloud/ros"
+)
+
+// ROSSubscription streams a sensor_msgs/PointCloud2 topic from a rosbridge
+// WebSocket server into the scene object Name, replacing its points with
+// each frame. With a PoseTopic, frames are placed in the world by the
+// sensor's latest pose, and the poses trace its trajectory. It reconnects
+// when the connection drops until it is closed.
+type ROSSubscription struct {
+	viewer     *Viewer
+	Name       string
+	URL        string
+	Topic      string
+	PoseTopic  string
+	ThrottleMs int
+
+	socket    js.Value
+	connected bool
+	closed    bool
+	frames    int
+	pose      glf32.Mat4
+	lastError string
+	funcs     []js.Func
+}
+
+// connect opens the WebSocket and subscribes to the topic once it is open.
+func (s *ROSSubscription) connect() {
+	s.release()
+	socket := js.Global().Get("WebSocket").New(s.URL)
+	s.socket = socket
+	on := func(event string, handler func(js.Value)) {
+		fn := js.FuncOf(func(this js.Value, args []js.Value) interface{} {
+			handler(args[0])
+			return nil
+		})
+		s.funcs = append(s.funcs, fn)
+		socket.Set(event, fn)
+	}
+	on("onopen", func(js.Value) {
+		s.connected, s.lastError = true, ""
+		socket.Call("send", string(ros.Subscribe(s.Name, s.Topic, "sensor_msgs/PointCloud2", s.ThrottleMs)))
+		if s.PoseTopic != "" {
+			socket.Call("send", string(ros.Subscribe(s.Name, s.PoseTopic, "", 0)))
+		}
+	})
+	on("onmessage", func(e js.Value) {
+		s.receive(e.Get("data"))
+	})
+	on("onerror", func(js.Value) {
+		s.lastError = "cannot reach " + s.URL
+	})
+	on("onclose", func(js.Value) {
+		s.connected = false
+		if s.closed {
+			s.release()
+			return
+		}
+		var retry js.Func
+		retry = js.FuncOf(func(this js.Value, args []js.Value) interface{} {
+			retry.Release()
+			if !s.closed {
+				s.connect()
+			}
+			return nil
+		})
+		js.Global().Call("setTimeout", retry, reconnectMs)
+	})
+}
+
+// receive shows a frame from the bridge.
+func (s *ROSSubscription) receive(data js.Value) {
+	if data.Type() != js.TypeString {
+		s.fail("binary messages are not supported; use the bridge's default JSON encoding")
+		return
+	}
+	m, err := ros.ParseMessage([]byte(data.String()))
+	if err != nil {
+		s.fail(err.Error())
+		return
+	}
+	if m.Op != "publish" {
+		return
+	}
+	if s.PoseTopic != "" && m.Topic == s.PoseTopic {
+		_, pose, err := ros.ParsePose(m.Msg)
+		if err != nil {
+			s.fail(err.Error())
+			return
+		}
+		s.pose = pose.Matrix()
+		s.viewer.trackPose(s.Name, s.pose)
+		return
+	}
+	if m.Topic != s.Topic {
+		return
+	}
+	if s.PoseTopic != "" && s.pose == nil {
+		// Frames cannot be placed before the first pose.
+		return
+	}
+	msg, err := ros.ParsePointCloud2(m.Msg)
+	if err != nil {
+		s.fail(err.Error())
+		return
+	}
+	cloud, err := msg.Cloud(s.Name)
+	if err != nil {
+		s.fail(err.Error())
+		return
+	}
+	if s.pose != nil {
+		cloud = s.viewer.placeFrame(cloud, s.pose)
+	}
+	s.viewer.showFrame(cloud, project.Source{Type: "ros"})
+	s.frames++
+}
+
+// fail records an error, logging it the first time it occurs.
+func (s *ROSSubscription) fail(msg string) {
+	if msg != s.lastError {
+		js.Global().Get("console").Call("warn", fmt.Sprintf("ROS %s: %s", s.Topic, msg))
+	}
+	s.lastError = msg
+}
+
+// close unsubscribes and closes the connection for good.
+func (s *ROSSubscription) close() {
+	s.closed = true
+	if s.connected {
+		s.socket.Call("send", string(ros.Unsubscribe(s.Name, s.Topic)))
+		if s.PoseTopic != "" {
+			s.socket.Call("send", string(ros.Unsubscribe(s.Name, s.PoseTopic)))
+		}
+	}
+	s.socket.Call("close")
+}
+
+// release frees the callbacks of the current connection.
+func (s *ROSSubscription) release() {
+	if !s.socket.IsUndefined() {
+		for _, event := range []string{"onopen", "onmessage", "onerror", "onclose"} {
+			s.socket.Set(event, js.Null())
+		}
+	}
+	for _, fn := range s.funcs {
+		fn.Release()
+	}
+	s.funcs = nil
+}
+
+// status returns the subscription as a JS object.
+func (s *ROSSubscription) status() map[string]interface{} {
+	return map[string]interface{}{
+		"name":      s.Name,
+		"url":       s.URL,
+		"topic":     s.Topic,
+		"poseTopic": s.PoseTopic,
+		"connected": s.connected,
+		"frames":    s.frames,
+		"error":     s.lastError,
+	}
+}
+
+// connectROS(params) subscribes to a sensor_msgs/PointCloud2 topic through
+// a rosbridge WebSocket server (rosbridge_suite) and shows its frames live
+// as a scene object. params.url is the bridge (default
+// "ws://localhost:9090"), params.topic the topic (default "/points"),
+// params.name the object (default: the topic) and params.throttle the
+// least milliseconds between frames (default 100; 0 for every frame).
+// params.poseTopic, optionally, is a nav_msgs/Odometry,
+// geometry_msgs/PoseStamped or PoseWithCovarianceStamped topic giving the
+// sensor's pose in the world, as SLAM and odometry nodes publish: each
+// frame is moved into the world by the latest pose, frames before the
+// first are skipped, and the poses trace the sensor's trajectory (see
+// getTrajectory). Frames are taken to be in the posed frame; fixed offsets
+// between it and the cloud's frame_id are not applied. Connecting another
+// topic to the same name replaces its subscription.
+//
+// Returns the subscription as with getROSConnections, or {error}.
+func (v *Viewer) connectROS(this js.Value, args []js.Value) interface{} {
+	var params js.Value
+	if len(args) > 0 {
+		params = args[0]
+	}
+	s := &ROSSubscription{
+		viewer:     v,
+		URL:        jsString(params, "url", "ws://localhost:9090"),
+		Topic:      jsString(params, "topic", "/points"),
+		PoseTopic:  jsString(params, "poseTopic", ""),
+		ThrottleMs: int(jsFloat(params, "throttle", 100)),
+		socket:     js.Undefined(),
+	}
+	s.Name = jsString(params, "name", s.Topic)
+	if s.Topic == "" || s.Name == "" {
+		return jsError("connectROS: topic and name must not be empty")
+	}
+	if js.Global().Get("WebSocket").IsUndefined() {
+		return jsError("connectROS: this browser has no WebSocket")
+	}
+	if old, ok := v.rosSubscriptions[s.Name]; ok {
+		old.close()
+	}
+	v.rosSubscriptions[s.Name] = s
+	s.connect()
+	return js.ValueOf(s.status())
+}
+
+// disconnectROS(name) ends the subscription showing the named object,
+// leaving its last frame in the scene. Returns {error} if there is none.
+func (v *Viewer) disconnectROS(this js.Value, args []js.Value) interface{} {
+	if len(args) < 1 {
+		return jsError("disconnectROS: expected (name)")
+	}
+	s, ok := v.rosSubscriptions[args[0].String()]
+	if !ok {
+		return jsError("disconnectROS: no ROS subscription for " + args[0].String())
+	}
+	s.close()
+	delete(v.rosSubscriptions, s.Name)
+	return nil
+}
+
+// getROSConnections() returns the ROS subscriptions as [{name, url, topic,
+// poseTopic, connected, frames, error}], where frames counts the frames shown and
+// error is the latest problem, or "".
+func (v *Viewer) getROSConnections(this js.Value, args []js.Value) interface{} {
+	names := make([]string, 0, len(v.rosSubscriptions))
+	for name := range v.rosSubscriptions {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	list := make([]interface{}, len(names))
+	for i, name := range names {
+		list[i] = v.rosSubscriptions[name].status()
+	}
+	return js.ValueOf(list)
+}
@@ -0,0 +1,236 @@
+// wasm/scalar.go
+package main
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"syscall/js"
+
+	"github.com/sbecker11/webgl-point-cloud/colormap"
+	"github.com/sbecker11/webgl-point-cloud/glf32"
+	"github.com/sbecker11/webgl-point-cloud/pointcloud"
+)
+
+// maxRampStops is the number of colormap stops the point shader takes;
+// colormaps with more are resampled.
+const maxRampStops = 8
+
+// scalarRange is how a scalar color mode maps values to colors: values from
+// Min to Max run along Ramp. An Auto range follows the extent of the
+// values in the scene.
+type scalarRange struct {
+	Ramp     colormap.Map
+	Min, Max float32
+	Auto     bool
+}
+
+// ScalarStyle holds the ranges of the scalar color modes, intensity,
+// height, distance and curvature, and uploads the active one to the point shader.
+type ScalarStyle struct {
+	viewer  *Viewer
+	modes   map[ColorMode]*scalarRange
+	autoKey string // scene data the auto range was computed for
+}
+
+// newScalarStyle returns the default colormap and range of each mode of
+// v's scene.
+func newScalarStyle(v *Viewer) *ScalarStyle {
+	return &ScalarStyle{viewer: v, modes: map[ColorMode]*scalarRange{
+		// Raw intensities in [0, 1] as gray, as the mode has always drawn.
+		ColorModeIntensity: {Ramp: colormap.Grayscale, Min: 0, Max: 1},
+		ColorModeHeight:    {Ramp: colormap.Rainbow, Auto: true},
+		ColorModeDistance:  {Ramp: colormap.CoolWarm, Auto: true},
+		ColorModeCurvature: {Ramp: colormap.Rainbow, Auto: true},
+	}}
+}
+
+// scalarVariable is the filter expression variable of each mode's values.
+var scalarVariable = map[ColorMode]string{
+	ColorModeIntensity:      "intensity",
+	ColorModeHeight:         "y",
+	ColorModeClassification: "class",
+	ColorModeDistance:       "distance",
+	ColorModeCurvature:      "curvature",
+}
+
+// scalarValues returns the values a color mode shows for the points of
+// every visible object, whether or not the filter hides them.
+func (v *Viewer) scalarValues(mode ColorMode) []float64 {
+	var values []float64
+	for _, o := range v.scene.Objects() {
+		if !o.Visible {
+			continue
+		}
+		c := o.Cloud
+		switch mode {
+		case ColorModeHeight:
+			for i := 1; i < len(c.Coords); i += 3 {
+				values = append(values, float64(c.Coords[i]))
+			}
+		case ColorModeIntensity, ColorModeDistance, ColorModeCurvature:
+			for _, value := range attributeValues(c, scalarVariable[mode]) {
+				values = append(values, float64(value))
+			}
+		case ColorModeClassification:
+			for i := 0; i < c.Len(); i++ {
+				class := pointcloud.ClassUnclassified
+				if c.Classes != nil {
+					class = c.Classes[i]
+				}
+				values = append(values, float64(class))
+			}
+		}
+	}
+	return values
+}
+
+// valueRange returns the extent of values, or 0 to 1 when there are none.
+func valueRange(values []float64) (lo, hi float64) {
+	lo, hi = math.Inf(1), math.Inf(-1)
+	for _, v := range values {
+		lo, hi = math.Min(lo, v), math.Max(hi, v)
+	}
+	if lo > hi {
+		return 0, 1
+	}
+	return lo, hi
+}
+
+// sceneDataKey changes when the visible objects or their point counts do,
+// for caches of values computed over the scene.
+func (v *Viewer) sceneDataKey() string {
+	var b strings.Builder
+	for _, o := range v.scene.Objects() {
+		fmt.Fprintf(&b, "%s\x00%d\x00%t\x00", o.Cloud.Name, o.Cloud.Len(), o.Visible)
+	}
+	return b.String()
+}
+
+// current returns the range of the active color mode, or nil if it is not
+// a scalar mode. Auto ranges are brought up to date.
+func (s *ScalarStyle) current() *scalarRange {
+	r := s.modes[s.viewer.classStyle.Mode]
+	if r != nil && r.Auto {
+		key := fmt.Sprint(s.viewer.classStyle.Mode) + s.viewer.sceneDataKey()
+		if key != s.autoKey {
+			s.autoKey = key
+			lo, hi := valueRange(s.viewer.scalarValues(s.viewer.classStyle.Mode))
+			r.Min, r.Max = float32(lo), float32(hi)
+		}
+	}
+	return r
+}
+
+// apply uploads the active scalar range and colormap to the point shader,
+// which must be in use.
+func (s *ScalarStyle) apply(gl js.Value, shader *PointShader) {
+	r := s.current()
+	if r == nil {
+		return
+	}
+	stops := r.Ramp.Stops
+	if len(stops) > maxRampStops {
+		stops = make([]colormap.Stop, maxRampStops)
+		for i := range stops {
+			t := float32(i) / (maxRampStops - 1)
+			stops[i].T = t
+			stops[i].R, stops[i].G, stops[i].B = r.Ramp.At(t)
+		}
+	}
+	ramp := make([]float32, 0, maxRampStops*4)
+	for _, st := range stops {
+		ramp = append(ramp, st.R, st.G, st.B, st.T)
+	}
+	for len(ramp) < maxRampStops*4 {
+		ramp = append(ramp, 0, 0, 0, 1)
+	}
+	gl.Call("uniform2f", shader.scalarRangeLoc, r.Min, r.Max)
+	gl.Call("uniform4fv", shader.rampLoc, glf32.ToFloat32Array(ramp))
+	gl.Call("uniform1f", shader.rampStopsLoc, float32(len(stops)))
+}
+
+// scalarInfo describes a scalar mode's range to JS.
+func scalarInfo(mode ColorMode, r *scalarRange) map[string]interface{} {
+	return map[string]interface{}{
+		"mode":     colorModeName(mode),
+		"variable": scalarVariable[mode],
+		"min":      r.Min,
+		"max":      r.Max,
+		"ramp":     r.Ramp.Name,
+		"auto":     r.Auto,
+	}
+}
+
+// scalarMode reads the mode parameter of the scalar style functions,
+// defaulting to the active color mode.
+func (v *Viewer) scalarMode(params js.Value) (ColorMode, *scalarRange, error) {
+	mode := v.classStyle.Mode
+	if value := jsValue(params, "mode"); !value.IsUndefined() {
+		var err error
+		if mode, err = parseColorMode(value.String()); err != nil {
+			return mode, nil, err
+		}
+	}
+	r := v.scalarStyle.modes[mode]
+	if r == nil {
+		return mode, nil, fmt.Errorf("%q is not a scalar color mode", colorModeName(mode))
+	}
+	return mode, r, nil
+}
+
+// setScalarStyle(style) sets how the "intensity", "height", "distance" or
+// "curvature" color mode (style.mode, default the active mode) maps values
+// to colors: min and max bound the range the colormap spans, ramp names the
+// colormap (see colormap names) and auto: true makes the range follow the
+// extent of the values in the scene again. Setting min or max turns auto
+// off. The histogram's handles drag the same range.
+//
+// Returns {mode, variable, min, max, ramp, auto} or {error}.
+func (v *Viewer) setScalarStyle(this js.Value, args []js.Value) interface{} {
+	params := js.Undefined()
+	if len(args) > 0 {
+		params = args[0]
+	}
+	mode, r, err := v.scalarMode(params)
+	if err != nil {
+		return jsError("setScalarStyle: " + err.Error())
+	}
+	if value := jsValue(params, "ramp"); !value.IsUndefined() {
+		ramp, err := colormap.Lookup(value.String())
+		if err != nil {
+			return jsError("setScalarStyle: " + err.Error())
+		}
+		r.Ramp = ramp
+	}
+	if value := jsValue(params, "min"); !value.IsUndefined() {
+		r.Min, r.Auto = float32(value.Float()), false
+	}
+	if value := jsValue(params, "max"); !value.IsUndefined() {
+		r.Max, r.Auto = float32(value.Float()), false
+	}
+	if value := jsValue(params, "auto"); value.Truthy() {
+		r.Auto, v.scalarStyle.autoKey = true, ""
+	}
+	if v.histogram != nil {
+		v.histogram.draw()
+	}
+	return js.ValueOf(scalarInfo(mode, r))
+}
+
+// getScalarStyle(mode) returns the range of a scalar color mode, default
+// the active one, as setScalarStyle does.
+func (v *Viewer) getScalarStyle(this js.Value, args []js.Value) interface{} {
+	params := js.Undefined()
+	if len(args) > 0 && args[0].Type() == js.TypeString {
+		params = js.ValueOf(map[string]interface{}{"mode": args[0].String()})
+	}
+	mode, r, err := v.scalarMode(params)
+	if err != nil {
+		return jsError("getScalarStyle: " + err.Error())
+	}
+	if mode == v.classStyle.Mode {
+		r = v.scalarStyle.current()
+	}
+	return js.ValueOf(scalarInfo(mode, r))
+}
@@ -0,0 +1,696 @@
+// wasm/scene.go
+package main
+
+import (
+	"fmt"
+	"sort"
+	"syscall/js"
+
+	"github.com/sbecker11/webgl-point-cloud/budget"
+	"github.com/sbecker11/webgl-point-cloud/crs"
+	"github.com/sbecker11/webgl-point-cloud/filter"
+	"github.com/sbecker11/webgl-point-cloud/glf32"
+	"github.com/sbecker11/webgl-point-cloud/layer"
+	"github.com/sbecker11/webgl-point-cloud/pointcloud"
+	"github.com/sbecker11/webgl-point-cloud/project"
+	"github.com/sbecker11/webgl-point-cloud/reveal"
+	"github.com/sbecker11/webgl-point-cloud/subsample"
+)
+
+// SceneObject is a point cloud together with the WebGL buffers holding it
+// and the model matrix placing it in the world. Every attribute in the
+// cloud's schema is uploaded to its own buffer when the object is added;
+// call Scene.Add again after changing the cloud to re-upload it. Mask,
+// when non-nil, holds the result of the scene's filter: points with a
+// false entry are neither drawn nor exported. Selection, when non-nil,
+// marks the points selected for editing, which draw highlighted.
+//
+// Opacity multiplies the alpha of every point. Objects with an opacity
+// below 1 draw after the opaque ones; turning DepthWrite off as well lets
+// points behind a faded object show through it, so overlapping scans can
+// be compared. Source records where the points came from, for saving the
+// scene as a project. When draw indices are set, only the listed points are
+// drawn, reusing the object's vertex buffers; see SetDrawIndices. While
+// fewer than all points are shown (see setDisplayFraction), a sample of
+// each chunk of them is drawn through a shuffled index buffer. While the
+// scene holds more points than the view's point budget (see
+// setPointBudget), objects are drawn through the leaves of a budget.Tree
+// instead, each leaf to the share of the budget its size on screen earns.
+// Translucent objects may be drawn back to front through a depthOrder; see
+// TranslucencyMode. SourceCRS is the coordinate reference system a
+// georeferenced cloud was in before it was reprojected into the scene's;
+// see sceneFrame. Tint, when non-nil, multiplies the color of every point
+// and Color, when non-nil, replaces it, mixed in by its alpha, whatever
+// the color mode, so two scans drawn in contrasting colors show how well
+// they are registered. PointStyle sizes and shades the points where the
+// object differs from the view. Points that just arrived are revealed
+// over the view's reveal duration, chunk by chunk; see revealChunk.
+type SceneObject struct {
+	Cloud      *pointcloud.Cloud
+	Source     project.Source
+	SourceCRS  crs.CRS
+	Model      glf32.Mat4
+	Visible    bool
+	Opacity    float32
+	DepthWrite bool
+	Tint       glf32.Vec3
+	Color      glf32.Vec4
+	PointStyle PointStyle
+	chunks     []reveal.Chunk
+	Mask       []bool
+	Selection  []bool
+	schema     []pointcloud.Attribute
+	buffers    map[string]js.Value
+	maskVBO    js.Value
+	selVBO     js.Value
+	indices    *IndexBuffer
+	subsample  *IndexBuffer
+	budget     *budget.Tree
+	budgetVBO  *IndexBuffer
+	positions  *positionTexture
+	depth      *depthOrder
+}
+
+// Scene is the ordered list of point cloud objects drawn each frame.
+// Object names are unique. Objects are also organized in a layer tree
+// whose visibility, opacity and transforms combine with each object's own.
+type Scene struct {
+	viewer  *Viewer
+	gl      js.Value
+	objects []*SceneObject
+	meshes  []*SceneMesh
+	filter  *filter.Expr
+	layers  *layer.Layer
+	// indexVBO holds the point indices objects with position textures
+	// sample them by; see pointIndices.
+	indexVBO   js.Value
+	indexCount int
+}
+
+// NewScene returns v's scene, empty.
+func NewScene(v *Viewer) *Scene {
+	return &Scene{viewer: v, gl: v.gl, layers: layer.New("")}
+}
+
+// Add uploads cloud to the GPU and adds it to the scene with the given model
+// matrix. An existing object with the same name is replaced and keeps its
+// layer; new objects go in the root layer. Settings loaded from a project
+// for an object of that name are applied.
+func (s *Scene) Add(cloud *pointcloud.Cloud, model glf32.Mat4) *SceneObject {
+	obj := &SceneObject{
+		Model:      model,
+		Visible:    true,
+		Opacity:    1,
+		DepthWrite: true,
+	}
+	s.upload(obj, cloud)
+	if obj.positions == nil {
+		// Position textures reveal their bands as they upload.
+		s.viewer.revealChunk(obj, 0, obj.Cloud.Len())
+	}
+	defer s.viewer.applyPendingObject(obj)
+	for i, o := range s.objects {
+		if o.Cloud.Name == cloud.Name {
+			s.deleteBuffers(o)
+			s.objects[i] = obj
+			return obj
+		}
+	}
+	s.objects = append(s.objects, obj)
+	s.layers.Objects = append(s.layers.Objects, cloud.Name)
+	return obj
+}
+
+// Clear removes every object, mesh and layer.
+func (s *Scene) Clear() {
+	for _, o := range s.objects {
+		s.deleteBuffers(o)
+	}
+	for _, m := range s.meshes {
+		s.deleteMeshBuffers(m)
+	}
+	s.objects, s.meshes = nil, nil
+	s.layers = layer.New("")
+	s.viewer.sceneFrame = crs.Frame{}
+}
+
+// SetCloud replaces the points of an object, keeping its name, model matrix
+// and visibility, and re-uploads its buffers. The active filter is
+// re-evaluated and the selection cleared.
+func (s *Scene) SetCloud(o *SceneObject, cloud *pointcloud.Cloud) {
+	s.deleteBuffers(o)
+	o.Mask, o.Selection = nil, nil
+	o.maskVBO, o.selVBO = js.Undefined(), js.Undefined()
+	o.indices, o.subsample = nil, nil
+	o.budget, o.budgetVBO, o.positions, o.depth, o.chunks = nil, nil, nil, nil, nil
+	s.upload(o, cloud)
+}
+
+// upload sets the object's cloud, reprojected into the scene's frame if it
+// is georeferenced, creates a buffer for each attribute in its schema and
+// applies the active filter.
+func (s *Scene) upload(o *SceneObject, cloud *pointcloud.Cloud) {
+	cloud = s.viewer.georeference(o, cloud)
+	o.Cloud = cloud
+	o.schema = cloud.Schema()
+	o.buffers = map[string]js.Value{}
+	if cloud.Len() > 0 {
+		for _, a := range o.schema {
+			if a.Name == pointcloud.AttrPosition {
+				s.storePositions(o, a)
+				continue
+			}
+			o.buffers[a.Name] = createAttributeVBO(s.gl, a, attributeValues(cloud, a.Name))
+		}
+	}
+	if s.filter != nil {
+		if mask, err := s.filter.Mask(filter.CloudSource(cloud)); err == nil {
+			s.setMask(o, mask)
+		} else {
+			js.Global().Get("console").Call("warn", fmt.Sprintf("Filter %q not applied to %s: %v", s.filter, cloud.Name, err))
+		}
+	}
+}
+
+// Remove deletes the named object and its GPU buffers.
+// Returns false if there is no such object.
+func (s *Scene) Remove(name string) bool {
+	for i, o := range s.objects {
+		if o.Cloud.Name == name {
+			s.deleteBuffers(o)
+			s.objects = append(s.objects[:i], s.objects[i+1:]...)
+			s.layers.RemoveObject(name)
+			return true
+		}
+	}
+	return false
+}
+
+// Object returns the named object, or nil if there is none.
+func (s *Scene) Object(name string) *SceneObject {
+	for _, o := range s.objects {
+		if o.Cloud.Name == name {
+			return o
+		}
+	}
+	return nil
+}
+
+// Objects returns the scene's objects in draw order.
+func (s *Scene) Objects() []*SceneObject {
+	return s.objects
+}
+
+func (s *Scene) deleteBuffers(o *SceneObject) {
+	for _, buf := range o.buffers {
+		s.gl.Call("deleteBuffer", buf)
+	}
+	if o.maskVBO.Truthy() {
+		s.gl.Call("deleteBuffer", o.maskVBO)
+	}
+	if o.selVBO.Truthy() {
+		s.gl.Call("deleteBuffer", o.selVBO)
+	}
+	if o.indices != nil {
+		o.indices.delete(s.gl)
+	}
+	if o.subsample != nil {
+		o.subsample.delete(s.gl)
+	}
+	if o.budgetVBO != nil {
+		o.budgetVBO.delete(s.gl)
+	}
+	if o.positions != nil {
+		o.positions.tex.delete(s.gl)
+	}
+	if o.depth != nil && o.depth.indices != nil {
+		o.depth.indices.delete(s.gl)
+	}
+}
+
+// SetDrawIndices limits the object to drawing the points at the given
+// indices, in that order, or restores drawing every point when indices is
+// nil. The indices are dropped when the object's cloud is replaced.
+func (s *Scene) SetDrawIndices(o *SceneObject, indices []uint32) error {
+	var b *IndexBuffer
+	if indices != nil {
+		for _, i := range indices {
+			if int(i) >= o.Cloud.Len() {
+				return fmt.Errorf("index %d out of range for %d points", i, o.Cloud.Len())
+			}
+		}
+		var err error
+		if b, err = s.viewer.createIndexBuffer(s.gl, indices); err != nil {
+			return err
+		}
+	}
+	if o.indices != nil {
+		o.indices.delete(s.gl)
+	}
+	o.indices = b
+	return nil
+}
+
+// SetAttribute sets an extra per-point attribute of the object's cloud
+// (see pointcloud.Cloud.SetAttribute) and uploads it, keeping the object's
+// mask, selection and draw indices.
+func (s *Scene) SetAttribute(o *SceneObject, a pointcloud.Attribute, values []float32) {
+	o.Cloud.SetAttribute(a, values)
+	o.schema = o.Cloud.Schema()
+	if buf, ok := o.buffers[a.Name]; ok {
+		s.gl.Call("deleteBuffer", buf)
+		delete(o.buffers, a.Name)
+	}
+	if o.Cloud.Len() > 0 {
+		o.buffers[a.Name] = createAttributeVBO(s.gl, a, values)
+	}
+}
+
+// SetColors replaces the colors of the object's cloud and uploads them,
+// keeping its other buffers, mask, selection and draw indices.
+func (s *Scene) SetColors(o *SceneObject, colors []float32) {
+	o.Cloud.Colors = colors
+	if buf, ok := o.buffers[pointcloud.AttrColor]; ok {
+		s.gl.Call("deleteBuffer", buf)
+		delete(o.buffers, pointcloud.AttrColor)
+	}
+	if o.Cloud.Len() > 0 {
+		o.buffers[pointcloud.AttrColor] = createAttributeVBO(s.gl, pointcloud.Attribute{Name: pointcloud.AttrColor, Components: 4, Type: pointcloud.Float32}, colors)
+	}
+}
+
+// SetNormals replaces the normals of the object's cloud and uploads them,
+// keeping its other buffers, mask, selection and draw indices.
+func (s *Scene) SetNormals(o *SceneObject, normals []float32) {
+	o.Cloud.Normals = normals
+	o.schema = o.Cloud.Schema()
+	if buf, ok := o.buffers[pointcloud.AttrNormal]; ok {
+		s.gl.Call("deleteBuffer", buf)
+		delete(o.buffers, pointcloud.AttrNormal)
+	}
+	if o.Cloud.Len() > 0 && normals != nil {
+		o.buffers[pointcloud.AttrNormal] = createAttributeVBO(s.gl, pointcloud.Attribute{Name: pointcloud.AttrNormal, Components: 3, Type: pointcloud.Float32}, normals)
+	}
+}
+
+// SetClasses replaces the classes of the object's cloud and uploads them,
+// keeping its other buffers, selection and draw indices. The active
+// filter is re-evaluated, since it may test the class.
+func (s *Scene) SetClasses(o *SceneObject, classes []uint8) {
+	o.Cloud.Classes = classes
+	o.schema = o.Cloud.Schema()
+	if buf, ok := o.buffers[pointcloud.AttrClass]; ok {
+		s.gl.Call("deleteBuffer", buf)
+		delete(o.buffers, pointcloud.AttrClass)
+	}
+	if o.Cloud.Len() > 0 && classes != nil {
+		o.buffers[pointcloud.AttrClass] = createAttributeVBO(s.gl, pointcloud.Attribute{Name: pointcloud.AttrClass, Components: 1, Type: pointcloud.Uint8}, attributeValues(o.Cloud, pointcloud.AttrClass))
+	}
+	if s.filter != nil {
+		if mask, err := s.filter.Mask(filter.CloudSource(o.Cloud)); err == nil {
+			s.setMask(o, mask)
+		}
+	}
+}
+
+// Layers returns the root of the scene's layer tree.
+func (s *Scene) Layers() *layer.Layer {
+	return s.layers
+}
+
+// Effective returns whether the object is drawn, its opacity and its
+// world matrix, combining the object's own settings with its layer's.
+func (s *Scene) Effective(o *SceneObject) (visible bool, opacity float32, model glf32.Mat4) {
+	visible, opacity, model = o.Visible, o.Opacity, o.Model
+	if l := s.layers.LayerOf(o.Cloud.Name); l != nil {
+		lv, lo, lm := l.Effective()
+		visible = visible && lv
+		opacity *= lo
+		model = glf32.MultiplyMatrices(lm, model)
+	}
+	return visible, opacity, model
+}
+
+// Filter returns the active filter expression, or nil if there is none.
+func (s *Scene) Filter() *filter.Expr {
+	return s.filter
+}
+
+// SetFilter evaluates expr against every object and hides the points that
+// fail it; a nil expr clears the filter. The filter also applies to objects
+// added later. If expr refers to an attribute some object lacks, the scene
+// is left unchanged and the error is returned.
+func (s *Scene) SetFilter(expr *filter.Expr) error {
+	masks := make([][]bool, len(s.objects))
+	if expr != nil {
+		for i, o := range s.objects {
+			mask, err := expr.Mask(filter.CloudSource(o.Cloud))
+			if err != nil {
+				return fmt.Errorf("%s: %v", o.Cloud.Name, err)
+			}
+			masks[i] = mask
+		}
+	}
+	s.filter = expr
+	for i, o := range s.objects {
+		s.setMask(o, masks[i])
+	}
+	return nil
+}
+
+// setMask replaces the object's mask and re-uploads it as the per-point
+// aVisible attribute.
+func (s *Scene) setMask(o *SceneObject, mask []bool) {
+	o.Mask = mask
+	o.maskVBO = s.uploadFlags(o.maskVBO, mask)
+}
+
+// SetSelection replaces the object's selection and re-uploads it as the
+// per-point aSelected attribute. A nil sel clears the selection.
+func (s *Scene) SetSelection(o *SceneObject, sel []bool) {
+	o.Selection = sel
+	o.selVBO = s.uploadFlags(o.selVBO, sel)
+}
+
+// uploadFlags deletes buf, if any, and returns a new buffer holding 1 for
+// each true flag and 0 for each false one, or undefined if flags is empty.
+func (s *Scene) uploadFlags(buf js.Value, flags []bool) js.Value {
+	if buf.Truthy() {
+		s.gl.Call("deleteBuffer", buf)
+	}
+	if len(flags) == 0 {
+		return js.Undefined()
+	}
+	values := make([]float32, len(flags))
+	for i, ok := range flags {
+		if ok {
+			values[i] = 1
+		}
+	}
+	return createVBO(s.gl, values)
+}
+
+// VisibleCount returns the number of points passing the object's mask.
+func (o *SceneObject) VisibleCount() int {
+	if o.Mask == nil {
+		return o.Cloud.Len()
+	}
+	n := 0
+	for _, ok := range o.Mask {
+		if ok {
+			n++
+		}
+	}
+	return n
+}
+
+// VisibleCloud returns the points passing the object's mask, for exports.
+// Without a mask it returns the object's cloud itself.
+func (o *SceneObject) VisibleCloud() *pointcloud.Cloud {
+	if o.Mask == nil {
+		return o.Cloud
+	}
+	var indices []int
+	for i, ok := range o.Mask {
+		if ok {
+			indices = append(indices, i)
+		}
+	}
+	return o.Cloud.Subset(indices)
+}
+
+// DrawPoints draws every visible object with the point shader, which must
+// already be in use, combining viewProj with each object's world matrix.
+// Each of the object's attributes feeds the shader attribute named after it
+// (see shaderAttributeName); shader attributes the object does not have
+// read their default value, so objects without classifications draw as
+// ClassUnclassified, objects without a mask draw every point and objects
+// without a selection draw unhighlighted. Translucent objects draw last.
+// Only fraction of each object's points, times the view's displayed
+// fraction, is drawn, sampled evenly through them (see subsampleOrder), or
+// that much of the start of its draw indices. Over the point budget,
+// objects without draw indices draw only their share of it; see
+// pointBudget.
+func (s *Scene) DrawPoints(shader *PointShader, viewProj glf32.Mat4, fraction float64) {
+	s.drawPoints(shader, viewProj, fraction, allPoints)
+}
+
+// pointLayer picks the objects drawPoints draws by their opacity.
+type pointLayer int
+
+const (
+	allPoints pointLayer = iota
+	opaquePoints
+	// translucentPoints draw without writing depth, blended as the caller
+	// set up; see OITPass.
+	translucentPoints
+)
+
+// drawPoints draws the objects of the layer as DrawPoints does. The point
+// budget is shared among all visible objects whichever layer is drawn.
+func (s *Scene) drawPoints(shader *PointShader, viewProj glf32.Mat4, fraction float64, layer pointLayer) {
+	gl := s.gl
+	type drawItem struct {
+		o       *SceneObject
+		opacity float32
+		model   glf32.Mat4
+	}
+	var opaque, translucent []drawItem
+	for _, o := range s.objects {
+		visible, opacity, model := s.Effective(o)
+		if !visible || opacity <= 0 || o.Cloud.Len() == 0 {
+			continue
+		}
+		if opacity < 1 {
+			translucent = append(translucent, drawItem{o, opacity, model})
+		} else {
+			opaque = append(opaque, drawItem{o, opacity, model})
+		}
+	}
+	if s.viewer.view.Translucency == TranslucencySorted {
+		depths := map[*SceneObject]float32{}
+		for _, item := range translucent {
+			depths[item.o] = objectDepth(item.o, glf32.MultiplyMatrices(viewProj, item.model))
+		}
+		sort.SliceStable(translucent, func(i, j int) bool { return depths[translucent[i].o] > depths[translucent[j].o] })
+	}
+	items := append(opaque, translucent...)
+	objects, models := make([]*SceneObject, len(items)), make([]glf32.Mat4, len(items))
+	for i, item := range items {
+		objects[i], models[i] = item.o, item.model
+	}
+	budgeted := s.pointBudget(objects, models, viewProj, fraction*s.viewer.view.DisplayFraction)
+	if shader.frameBlock {
+		s.viewer.frameBlock.setViewProj(gl, viewProj)
+	}
+	stack := glf32.NewMatrixStack(viewProj)
+	viewportHeight, now := 0.0, 0.0
+	if s.viewer.view.Reveal > 0 {
+		now = revealNow()
+	}
+	for _, item := range items {
+		if layer == opaquePoints && item.opacity < 1 || layer == translucentPoints && item.opacity >= 1 {
+			continue
+		}
+		o := item.o
+		bound := map[int]bool{}
+		for _, a := range o.schema {
+			loc, ok := shader.attributes[shaderAttributeName(a.Name)]
+			buf, stored := o.buffers[a.Name]
+			if !ok || !stored {
+				continue
+			}
+			gl.Call("enableVertexAttribArray", loc)
+			gl.Call("bindBuffer", gl.Get("ARRAY_BUFFER"), buf)
+			gl.Call("vertexAttribPointer", loc, a.Components, glAttributeType(gl, a.Type), false, 0, 0)
+			bound[loc] = true
+		}
+		for name, loc := range shader.attributes {
+			if !bound[loc] && loc != attribVisible && loc != attribSelected {
+				gl.Call("disableVertexAttribArray", loc)
+				gl.Call("vertexAttrib1f", loc, attributeDefaults[name])
+			}
+		}
+		bindFlags(gl, attribVisible, o.maskVBO, 1)
+		bindFlags(gl, attribSelected, o.selVBO, 0)
+		s.bindPositions(shader, o)
+		s.bindReveal(shader, o, now)
+		if !shader.frameBlock {
+			stack.Push()
+			stack.MultMatrix(item.model)
+			gl.Call("uniformMatrix4fv", shader.mvpLoc, false, glf32.ToFloat32Array(stack.Top()))
+			stack.Pop()
+		}
+		gl.Call("uniformMatrix4fv", shader.modelLoc, false, glf32.ToFloat32Array(item.model))
+		gl.Call("uniform1f", shader.opacityLoc, item.opacity)
+		tint, color := o.Tint, o.Color
+		if tint == nil {
+			tint = glf32.Vec3{1, 1, 1}
+		}
+		if color == nil {
+			color = glf32.Vec4{0, 0, 0, 0}
+		}
+		gl.Call("uniform3f", shader.tintLoc, tint[0], tint[1], tint[2])
+		gl.Call("uniform4f", shader.colorLoc, color[0], color[1], color[2], color[3])
+		style := o.PointStyle
+		style.SizeMode = s.viewer.adaptive.sizeMode(style)
+		if style.SizeMode == PointSizeWorld && viewportHeight == 0 {
+			viewportHeight = gl.Call("getParameter", gl.Get("VIEWPORT")).Index(3).Float()
+		}
+		size, perspective := style.sizeUniform(s.viewer, viewProj, viewportHeight)
+		gl.Call("uniform2f", shader.sizeLoc, size, perspective)
+		gl.Call("uniform1f", shader.roundLoc, boolFloat(style.Round))
+		gl.Call("uniform1f", shader.litLoc, boolFloat(style.lit(&s.viewer.light)))
+		ranges, inBudget := budgeted[o]
+		var sorted *IndexBuffer
+		switch {
+		case layer == translucentPoints:
+			gl.Call("depthMask", false)
+		case item.opacity < 1 && s.viewer.view.Translucency == TranslucencyAdditive:
+			gl.Call("blendFunc", gl.Get("SRC_ALPHA"), gl.Get("ONE"))
+			gl.Call("depthMask", false)
+		case item.opacity < 1 && s.viewer.view.Translucency == TranslucencySorted && o.indices == nil && !inBudget:
+			sorted = s.depthOrdered(o, glf32.MultiplyMatrices(viewProj, item.model), fraction*s.viewer.view.DisplayFraction)
+			fallthrough
+		default:
+			gl.Call("depthMask", o.DepthWrite)
+		}
+		if inBudget {
+			for _, r := range ranges {
+				o.budgetVBO.drawRange(gl, gl.Get("POINTS"), r.First, r.Count)
+			}
+		} else if sorted != nil {
+			sorted.draw(gl, gl.Get("POINTS"), sorted.count)
+		} else if o.indices != nil {
+			o.indices.draw(gl, gl.Get("POINTS"), drawCount(o.indices.count, fraction*s.viewer.view.DisplayFraction))
+		} else if order := s.subsampleOrder(o, fraction*s.viewer.view.DisplayFraction); order != nil {
+			n := o.Cloud.Len()
+			for c := 0; c < subsample.Chunks(n); c++ {
+				first, length := subsample.Chunk(n, c)
+				order.drawRange(gl, gl.Get("POINTS"), first, drawCount(length, fraction*s.viewer.view.DisplayFraction))
+			}
+		} else {
+			gl.Call("drawArrays", gl.Get("POINTS"), 0, drawCount(o.Cloud.Len(), fraction*s.viewer.view.DisplayFraction))
+		}
+	}
+	gl.Call("depthMask", true)
+	gl.Call("blendFunc", gl.Get("SRC_ALPHA"), gl.Get("ONE_MINUS_SRC_ALPHA"))
+	// Leave only position and color enabled, as the line program expects.
+	for _, loc := range shader.attributes {
+		if loc != attribPosition && loc != attribColor {
+			gl.Call("disableVertexAttribArray", loc)
+		}
+	}
+}
+
+// subsampleOrder returns the object's shuffled index buffer, made on
+// first use, for drawing fraction of its points, or nil to draw them all.
+// Clouds needing 32-bit indices have none where the browser lacks them,
+// and draw their first points instead.
+func (s *Scene) subsampleOrder(o *SceneObject, fraction float64) *IndexBuffer {
+	if fraction >= 1 {
+		return nil
+	}
+	if o.subsample == nil && (o.Cloud.Len() <= 0x10000 || s.viewer.caps.ElementIndexUint) {
+		o.subsample, _ = s.viewer.createIndexBuffer(s.gl, subsample.Order(o.Cloud.Len(), 1))
+	}
+	return o.subsample
+}
+
+// pointBudget shares the point budget, the view's or the adaptive quality
+// level's (see adaptiveQuality.pointBudget), among the objects drawn with
+// the given model matrices, when they hold more points than it: each leaf
+// of each object's budget.Tree in view gets a share in proportion to the
+// area it covers on screen, thinned further by fraction. It returns the
+// runs of each object's budget order to draw, or nil within the budget.
+// Objects with draw indices, and objects needing 32-bit indices where the
+// browser lacks them, are drawn as usual and do not count.
+func (s *Scene) pointBudget(objects []*SceneObject, models []glf32.Mat4, viewProj glf32.Mat4, fraction float64) map[*SceneObject][]budget.Range {
+	limit := s.viewer.adaptive.pointBudget(s.viewer.view)
+	if limit <= 0 {
+		return nil
+	}
+	var budgeted []*SceneObject
+	var mvps []glf32.Mat4
+	total := 0
+	for i, o := range objects {
+		if o.indices == nil && (o.Cloud.Len() <= 0x10000 || s.viewer.caps.ElementIndexUint) {
+			budgeted = append(budgeted, o)
+			mvps = append(mvps, glf32.MultiplyMatrices(viewProj, models[i]))
+			total += o.Cloud.Len()
+		}
+	}
+	if total <= limit {
+		return nil
+	}
+	width, height := s.viewer.canvasSize()
+	var counts []int
+	var weights []float64
+	for i, o := range budgeted {
+		if o.budget == nil {
+			o.budget = budget.Build(o.Cloud.Coords, budget.LeafSize, 1)
+			o.budgetVBO, _ = s.viewer.createIndexBuffer(s.gl, o.budget.Order)
+			o.budget.Order = nil // uploaded; only the leaves are needed now
+		}
+		for _, nd := range o.budget.Nodes {
+			counts = append(counts, nd.Count)
+		}
+		weights = budget.Weigh(weights, o.budget.Nodes, mvps[i], width, height)
+	}
+	alloc := budget.Allocate(counts, weights, limit)
+	ranges := map[*SceneObject][]budget.Range{}
+	for _, o := range budgeted {
+		n := len(o.budget.Nodes)
+		if o.budgetVBO != nil {
+			for i, a := range alloc[:n] {
+				if a > 0 {
+					alloc[i] = drawCount(a, fraction)
+				}
+			}
+			ranges[o] = o.budget.Ranges(alloc[:n])
+		}
+		alloc = alloc[n:]
+	}
+	return ranges
+}
+
+// bindFlags feeds a buffer made by uploadFlags to the attribute at loc, or
+// the constant def if there is no buffer.
+func bindFlags(gl js.Value, loc int, buf js.Value, def float32) {
+	if buf.Truthy() {
+		gl.Call("enableVertexAttribArray", loc)
+		gl.Call("bindBuffer", gl.Get("ARRAY_BUFFER"), buf)
+		gl.Call("vertexAttribPointer", loc, 1, gl.Get("FLOAT"), false, 0, 0)
+	} else {
+		gl.Call("disableVertexAttribArray", loc)
+		gl.Call("vertexAttrib1f", loc, def)
+	}
+}
+
+// attributeDefaults holds the values shader attributes read when an object
+// has no data for them, by GLSL name. Unlisted attributes read 0.
+var attributeDefaults = map[string]float32{
+	"aClass":   float32(pointcloud.ClassUnclassified),
+	"aVisible": 1,
+}
+
+// attributeValues returns the packed values of any attribute in the cloud's
+// schema as float32s.
+func attributeValues(c *pointcloud.Cloud, name string) []float32 {
+	switch name {
+	case pointcloud.AttrPosition:
+		return c.Coords
+	case pointcloud.AttrColor:
+		return c.Colors
+	case pointcloud.AttrNormal:
+		return c.Normals
+	case pointcloud.AttrClass:
+		classes := make([]float32, len(c.Classes))
+		for i, class := range c.Classes {
+			classes[i] = float32(class)
+		}
+		return classes
+	}
+	_, values, _ := c.Attribute(name)
+	return values
+}
@@ -0,0 +1,238 @@
+// wasm/selections.go
+package main
+
+import (
+	"fmt"
+	"syscall/js"
+
+	"github.com/sbecker11/webgl-point-cloud/edit"
+	"github.com/sbecker11/webgl-point-cloud/pointcloud"
+	"github.com/sbecker11/webgl-point-cloud/project"
+)
+
+// maxNamedSelections bounds the named selections, whose colors the point
+// shader holds in a uniform array.
+const maxNamedSelections = 16
+
+// segmentAttribute holds, for each point, 1 plus the index of the last
+// named selection holding it, or 0, for the "selections" color mode.
+var segmentAttribute = pointcloud.Attribute{Name: "segment", Components: 1, Type: pointcloud.Uint8}
+
+// selectionPalette holds the colors given to new named selections in turn.
+var selectionPalette = [maxNamedSelections][4]float32{
+	{0.90, 0.10, 0.29, 1}, {0.24, 0.71, 0.29, 1}, {1.00, 0.88, 0.10, 1}, {0.26, 0.39, 0.85, 1},
+	{0.96, 0.51, 0.19, 1}, {0.57, 0.12, 0.71, 1}, {0.27, 0.94, 0.94, 1}, {0.94, 0.20, 0.90, 1},
+	{0.74, 0.96, 0.05, 1}, {0.98, 0.75, 0.83, 1}, {0.00, 0.50, 0.50, 1}, {0.86, 0.75, 1.00, 1},
+	{0.60, 0.39, 0.14, 1}, {1.00, 0.98, 0.78, 1}, {0.50, 0.00, 0.00, 1}, {0.67, 1.00, 0.76, 1},
+}
+
+// namedSelection returns the index of the named selection called name, or
+// -1.
+func (v *Viewer) namedSelection(name string) int {
+	for i, s := range v.namedSelections {
+		if s.Name == name {
+			return i
+		}
+	}
+	return -1
+}
+
+// namedSelectionColors returns the named selections' colors, packed for
+// the point shader's uSegmentColors.
+func (v *Viewer) namedSelectionColors() []float32 {
+	colors := make([]float32, maxNamedSelections*4)
+	for i, s := range v.namedSelections {
+		copy(colors[i*4:], s.Color)
+	}
+	return colors
+}
+
+// paintSegments sets the segment attribute of every object.
+func (v *Viewer) paintSegments() {
+	for _, o := range v.scene.Objects() {
+		v.paintObjectSegments(o)
+	}
+}
+
+// paintObjectSegments sets o's segment attribute from the named
+// selections. Indices beyond its points, left by deleting points after
+// saving a selection, are skipped. Objects in no selection get no
+// attribute unless they already had one.
+func (v *Viewer) paintObjectSegments(o *SceneObject) {
+	values := make([]float32, o.Cloud.Len())
+	painted := false
+	for k, s := range v.namedSelections {
+		for _, i := range s.Points[o.Cloud.Name] {
+			if int(i) < len(values) {
+				values[i], painted = float32(k+1), true
+			}
+		}
+	}
+	if _, _, ok := o.Cloud.Attribute(segmentAttribute.Name); ok || painted {
+		v.scene.SetAttribute(o, segmentAttribute, values)
+	}
+}
+
+// namedSelectionInfo returns a named selection as {name, color, points},
+// with the number of points it holds.
+func namedSelectionInfo(s project.Selection) map[string]interface{} {
+	points := 0
+	for _, indices := range s.Points {
+		points += len(indices)
+	}
+	return map[string]interface{}{
+		"name":   s.Name,
+		"color":  []interface{}{s.Color[0], s.Color[1], s.Color[2], s.Color[3]},
+		"points": points,
+	}
+}
+
+// saveNamedSelection(name, params) saves the current selection under name,
+// replacing any selection of that name, for re-applying later, coloring
+// in the "selections" color mode and exporting: a lightweight way to label
+// segments by hand. params is optional and may hold color ([r, g, b] or
+// [r, g, b, a] in [0, 1]); a new selection otherwise gets the next color
+// of a palette of 16, and a replaced one keeps its own. Up to 16
+// selections can be named. They are saved in projects, as point indices:
+// deleting points shifts the indices of those after them.
+//
+// Returns {name, color, points} or {error}.
+func (v *Viewer) saveNamedSelection(this js.Value, args []js.Value) interface{} {
+	if len(args) < 1 || args[0].Type() != js.TypeString || args[0].String() == "" {
+		return jsError("saveNamedSelection: expected (name, params)")
+	}
+	s := project.Selection{Name: args[0].String(), Points: map[string][]uint32{}}
+	for _, o := range v.scene.Objects() {
+		for _, i := range edit.Indices(o.Selection, true) {
+			s.Points[o.Cloud.Name] = append(s.Points[o.Cloud.Name], uint32(i))
+		}
+	}
+	if len(s.Points) == 0 {
+		return jsError("saveNamedSelection: nothing is selected")
+	}
+	k := v.namedSelection(s.Name)
+	if k < 0 && len(v.namedSelections) == maxNamedSelections {
+		return jsError(fmt.Sprintf("saveNamedSelection: at most %d selections can be named", maxNamedSelections))
+	}
+	color := selectionPalette[len(v.namedSelections)]
+	if k >= 0 {
+		copy(color[:], v.namedSelections[k].Color)
+	}
+	if len(args) > 1 {
+		if value := jsValue(args[1], "color"); !value.IsUndefined() {
+			c, err := jsColor(value)
+			if err != nil {
+				return jsError("saveNamedSelection: " + err.Error())
+			}
+			color = c
+		}
+	}
+	s.Color = color[:]
+	if k >= 0 {
+		v.namedSelections[k] = s
+	} else {
+		v.namedSelections = append(v.namedSelections, s)
+	}
+	v.paintSegments()
+	return js.ValueOf(namedSelectionInfo(s))
+}
+
+// applyNamedSelection(name, params) selects the drawn points of a named
+// selection. params is optional and may hold mode ("replace", "add",
+// "subtract" or "intersect"; default "replace").
+//
+// Returns {selected} or {error}.
+func (v *Viewer) applyNamedSelection(this js.Value, args []js.Value) interface{} {
+	if len(args) < 1 {
+		return jsError("applyNamedSelection: expected (name, params)")
+	}
+	k := v.namedSelection(args[0].String())
+	if k < 0 {
+		return jsError("applyNamedSelection: no selection named " + args[0].String())
+	}
+	params := js.Undefined()
+	if len(args) > 1 {
+		params = args[1]
+	}
+	mode, err := edit.ParseMode(jsString(params, "mode", "replace"))
+	if err != nil {
+		return jsError("applyNamedSelection: " + err.Error())
+	}
+	targets := v.scene.Objects()
+	sels := make([][]bool, len(targets))
+	for j, o := range targets {
+		sels[j] = make([]bool, o.Cloud.Len())
+		for _, i := range v.namedSelections[k].Points[o.Cloud.Name] {
+			if int(i) < len(sels[j]) {
+				sels[j][i] = true
+			}
+		}
+	}
+	return js.ValueOf(map[string]interface{}{"selected": v.applySelection(targets, sels, mode)})
+}
+
+// setNamedSelectionColor(name, color) recolors a named selection, with
+// color [r, g, b] or [r, g, b, a] in [0, 1].
+//
+// Returns {name, color, points} or {error}.
+func (v *Viewer) setNamedSelectionColor(this js.Value, args []js.Value) interface{} {
+	if len(args) < 2 {
+		return jsError("setNamedSelectionColor: expected (name, color)")
+	}
+	k := v.namedSelection(args[0].String())
+	if k < 0 {
+		return jsError("setNamedSelectionColor: no selection named " + args[0].String())
+	}
+	c, err := jsColor(args[1])
+	if err != nil {
+		return jsError("setNamedSelectionColor: " + err.Error())
+	}
+	v.namedSelections[k].Color = c[:]
+	return js.ValueOf(namedSelectionInfo(v.namedSelections[k]))
+}
+
+// removeNamedSelection(name) forgets a named selection; its points stay
+// as they are.
+func (v *Viewer) removeNamedSelection(this js.Value, args []js.Value) interface{} {
+	if len(args) < 1 {
+		return jsError("removeNamedSelection: expected (name)")
+	}
+	if k := v.namedSelection(args[0].String()); k >= 0 {
+		v.namedSelections = append(v.namedSelections[:k], v.namedSelections[k+1:]...)
+		v.paintSegments()
+	}
+	return nil
+}
+
+// getNamedSelections() returns the named selections as an array of
+// {name, color, points}.
+func (v *Viewer) getNamedSelections(this js.Value, args []js.Value) interface{} {
+	list := make([]interface{}, len(v.namedSelections))
+	for i, s := range v.namedSelections {
+		list[i] = namedSelectionInfo(s)
+	}
+	return js.ValueOf(list)
+}
+
+// exportNamedSelection(name) returns the point indices of a named
+// selection as {objectName: [index, ...]}, in ascending order.
+//
+// Returns the index lists or {error}.
+func (v *Viewer) exportNamedSelection(this js.Value, args []js.Value) interface{} {
+	if len(args) < 1 {
+		return jsError("exportNamedSelection: expected (name)")
+	}
+	k := v.namedSelection(args[0].String())
+	if k < 0 {
+		return jsError("exportNamedSelection: no selection named " + args[0].String())
+	}
+	lists := map[string]interface{}{}
+	for name, indices := range v.namedSelections[k].Points {
+		list := make([]interface{}, len(indices))
+		for i, index := range indices {
+			list[i] = index
+		}
+		lists[name] = list
+	}
+	return js.ValueOf(lists)
+}
@@ -0,0 +1,182 @@
+// wasm/sensorstream.go
+package main
+
+import (
+	"sort"
+	"syscall/js"
+
+	"github.com/sbecker11/webgl-point-cloud/pointcloud"
+	"github.com/sbecker11/webgl-point-cloud/project"
+	"github.com/sbecker11/webgl-point-cloud/sensorstream"
+)
+
+// SensorStream receives the datasets the server relays from a message
+// broker at /stream, showing each as a scene object whose points are
+// replaced with every update. It reconnects when the connection drops
+// until it is closed.
+type SensorStream struct {
+	viewer *Viewer
+	URL    string
+
+	socket    js.Value
+	connected bool
+	closed    bool
+	frames    int
+	datasets  map[string]bool
+	lastError string
+	funcs     []js.Func
+}
+
+// connect opens the WebSocket.
+func (s *SensorStream) connect() {
+	s.release()
+	socket := js.Global().Get("WebSocket").New(s.URL)
+	socket.Set("binaryType", "arraybuffer")
+	s.socket = socket
+	on := func(event string, handler func(js.Value)) {
+		fn := js.FuncOf(func(this js.Value, args []js.Value) interface{} {
+			handler(args[0])
+			return nil
+		})
+		s.funcs = append(s.funcs, fn)
+		socket.Set(event, fn)
+	}
+	on("onopen", func(js.Value) {
+		s.connected, s.lastError = true, ""
+	})
+	on("onmessage", func(e js.Value) {
+		s.receive(e.Get("data"))
+	})
+	on("onerror", func(js.Value) {
+		s.lastError = "cannot reach " + s.URL
+	})
+	on("onclose", func(js.Value) {
+		s.connected = false
+		if s.closed {
+			s.release()
+			return
+		}
+		var retry js.Func
+		retry = js.FuncOf(func(this js.Value, args []js.Value) interface{} {
+			retry.Release()
+			if !s.closed {
+				s.connect()
+			}
+			return nil
+		})
+		js.Global().Call("setTimeout", retry, reconnectMs)
+	})
+}
+
+// receive shows a dataset's points from the server.
+func (s *SensorStream) receive(data js.Value) {
+	bytes := js.Global().Get("Uint8Array").New(data)
+	buf := make([]byte, bytes.Length())
+	js.CopyBytesToGo(buf, bytes)
+	name, b, err := sensorstream.DecodeFrame(buf)
+	if err != nil {
+		if err.Error() != s.lastError {
+			js.Global().Get("console").Call("warn", "Sensor stream: "+err.Error())
+		}
+		s.lastError = err.Error()
+		return
+	}
+	colors := make([]float32, b.Len()*4)
+	for i := range colors {
+		colors[i] = 1
+		if b.Colors != nil {
+			colors[i] = float32(b.Colors[i]) / 255
+		}
+	}
+	if b.Pose != nil {
+		// The server has moved the points into the world already.
+		s.viewer.trackPose(name, b.Pose)
+	}
+	s.viewer.showFrame(pointcloud.New(name, b.Coords, colors), project.Source{Type: "stream"})
+	s.frames++
+	s.datasets[name] = true
+}
+
+// close closes the connection for good.
+func (s *SensorStream) close() {
+	s.closed = true
+	s.socket.Call("close")
+}
+
+// release frees the callbacks of the current connection.
+func (s *SensorStream) release() {
+	if !s.socket.IsUndefined() {
+		for _, event := range []string{"onopen", "onmessage", "onerror", "onclose"} {
+			s.socket.Set(event, js.Null())
+		}
+	}
+	for _, fn := range s.funcs {
+		fn.Release()
+	}
+	s.funcs = nil
+}
+
+// connectSensorStream(url) shows the point datasets the server relays from
+// edge devices through a message broker (see server.go's STREAM_URL), one
+// scene object per dataset, updated live. Datasets whose devices publish
+// posed batches arrive in the world's frame and trace their sensor's
+// trajectory. url defaults to the page's own server at /stream.
+//
+// Returns the stream's status as with getSensorStream, or {error}.
+func (v *Viewer) connectSensorStream(this js.Value, args []js.Value) interface{} {
+	if js.Global().Get("WebSocket").IsUndefined() {
+		return jsError("connectSensorStream: this browser has no WebSocket")
+	}
+	location := js.Global().Get("location")
+	scheme := "ws://"
+	if location.Get("protocol").String() == "https:" {
+		scheme = "wss://"
+	}
+	url := scheme + location.Get("host").String() + "/stream"
+	if len(args) > 0 && args[0].Type() == js.TypeString {
+		url = args[0].String()
+	}
+	if v.sensorStream != nil {
+		v.sensorStream.close()
+	}
+	v.sensorStream = &SensorStream{viewer: v, URL: url, socket: js.Undefined(), datasets: map[string]bool{}}
+	v.sensorStream.connect()
+	return v.getSensorStream(js.Undefined(), nil)
+}
+
+// disconnectSensorStream() closes the sensor stream, leaving its datasets'
+// last points in the scene.
+func (v *Viewer) disconnectSensorStream(this js.Value, args []js.Value) interface{} {
+	if v.sensorStream != nil {
+		v.sensorStream.close()
+		v.sensorStream = nil
+	}
+	return nil
+}
+
+// getSensorStream() returns {url, connected, frames, datasets, error} for
+// the sensor stream, where frames counts the updates shown, datasets lists
+// the objects it has shown and error is the latest problem, or "". Returns
+// null if no stream is open.
+func (v *Viewer) getSensorStream(this js.Value, args []js.Value) interface{} {
+	s := v.sensorStream
+	if s == nil {
+		return nil
+	}
+	names := make([]string, 0, len(s.datasets))
+	for name := range s.datasets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	datasets := make([]interface{}, len(names))
+	for i, name := range names {
+		datasets[i] = name
+	}
+	return js.ValueOf(map[string]interface{}{
+		"url":       s.URL,
+		"connected": s.connected,
+		"frames":    s.frames,
+		"datasets":  datasets,
+		"error":     s.lastError,
+	})
+}
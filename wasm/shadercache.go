@@ -0,0 +1,144 @@
+// wasm/shadercache.go
+package main
+
+import (
+	"syscall/js"
+
+	"github.com/sbecker11/webgl-point-cloud/glsl"
+)
+
+// ShaderFeatures is a set of optional parts of the point vertex shader.
+// Each is compiled in by defining its macro ahead of the source, so a
+// program only carries the code and uniforms the view needs; the variant
+// for every combination in use is compiled once, when first drawn with,
+// and kept in the viewer's shaderCache.
+type ShaderFeatures uint32
+
+const (
+	// FeatureTexturePositions reads positions from position textures; see
+	// PositionStorage.
+	FeatureTexturePositions ShaderFeatures = 1 << iota
+	// FeatureLitPoints shades points with normals by the light.
+	FeatureLitPoints
+	// FeatureColorRamp maps scalars through the colormap, for the color
+	// modes that do.
+	FeatureColorRamp
+	// FeatureFrameBlock reads the view and the light from the frame block,
+	// compiling the program as GLSL ES 3.00; see FrameBlock.
+	FeatureFrameBlock
+	// FeatureReveal fades in the points of objects being revealed; see
+	// setRevealAnimation.
+	FeatureReveal
+)
+
+// shaderFeatureMacros are the features' macros, in bit order.
+var shaderFeatureMacros = []string{"TEXTURE_POSITIONS", "LIT_POINTS", "COLOR_RAMP", "FRAME_BLOCK", "REVEAL"}
+
+// defines returns the features' macros, to define in shaders.
+func (f ShaderFeatures) defines() []glsl.Define {
+	var defines []glsl.Define
+	for i, macro := range shaderFeatureMacros {
+		if f&(1<<i) != 0 {
+			defines = append(defines, glsl.Define{Name: macro})
+		}
+	}
+	return defines
+}
+
+// names returns the features' macros.
+func (f ShaderFeatures) names() []interface{} {
+	names := []interface{}{}
+	for i, macro := range shaderFeatureMacros {
+		if f&(1<<i) != 0 {
+			names = append(names, macro)
+		}
+	}
+	return names
+}
+
+// baseFeatures returns the features every point program of the active
+// viewer has: those that depend on the browser rather than on the view.
+func (v *Viewer) baseFeatures() ShaderFeatures {
+	var f ShaderFeatures
+	if v.positionTexturesSupported() {
+		f |= FeatureTexturePositions
+	}
+	if v.caps.WebGL2 {
+		f |= FeatureFrameBlock
+	}
+	return f
+}
+
+// pointFeatures returns the features the view's point colors need,
+// lighting where the light or an object's style lights points, and the
+// reveal while points are being revealed.
+func (v *Viewer) pointFeatures() ShaderFeatures {
+	f := v.baseFeatures()
+	lit := v.light.Points
+	for _, o := range v.scene.objects {
+		lit = lit || o.PointStyle.Shading == ShadingLit
+	}
+	if lit {
+		f |= FeatureLitPoints
+	}
+	switch v.classStyle.Mode {
+	case ColorModeIntensity, ColorModeHeight, ColorModeDistance, ColorModeCurvature:
+		f |= FeatureColorRamp
+	}
+	if v.revealing() {
+		f |= FeatureReveal
+	}
+	return f
+}
+
+// shaderVariant identifies a point program: the file of its fragment
+// shader and its vertex shader features.
+type shaderVariant struct {
+	fragment string
+	features ShaderFeatures
+}
+
+// cachedShader is a compiled variant, or why it failed to compile.
+type cachedShader struct {
+	shader *PointShader
+	err    error
+}
+
+// shaderCache holds a viewer's point programs by variant.
+type shaderCache struct {
+	variants map[shaderVariant]cachedShader
+	order    []shaderVariant // the variants in the order compiled
+}
+
+func newShaderCache() *shaderCache {
+	return &shaderCache{variants: map[shaderVariant]cachedShader{}}
+}
+
+// point returns the point program linking point.vert, with the
+// features, and the fragment shader file, compiling it on first use. A variant that
+// fails to compile is not tried again.
+func (c *shaderCache) point(gl js.Value, fragment string, features ShaderFeatures) (*PointShader, error) {
+	key := shaderVariant{fragment, features}
+	cached, ok := c.variants[key]
+	if !ok {
+		cached.shader, cached.err = newPointShader(gl, fragment, features)
+		c.variants[key] = cached
+		c.order = append(c.order, key)
+	}
+	return cached.shader, cached.err
+}
+
+// getShaderVariants() returns the point programs the viewer has compiled,
+// as [{features, error}] in the order compiled, features being the macros
+// defined for the variant and error set if it failed to compile.
+func (v *Viewer) getShaderVariants(this js.Value, args []js.Value) interface{} {
+	variants := []interface{}{}
+	for _, key := range v.shaders.order {
+		variant := map[string]interface{}{"features": key.features.names()}
+		if err := v.shaders.variants[key].err; err != nil {
+			variant["error"] = err.Error()
+		}
+		variants = append(variants, variant)
+	}
+	return js.ValueOf(variants)
+}
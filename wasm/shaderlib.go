@@ -0,0 +1,74 @@
+// wasm/shaderlib.go
+package main
+
+import (
+	stdembed "embed" // the package has a variable named embed
+	"errors"
+	"fmt"
+	"path"
+
+	"github.com/sbecker11/webgl-point-cloud/glsl"
+	"github.com/sbecker11/webgl-point-cloud/pointcloud"
+)
+
+// shaderFiles are the shader sources in wasm/shaders: whole shaders, e.g.
+// point.vert, and .glsl snippets they #include.
+//
+//go:embed shaders/*
+var shaderFiles stdembed.FS
+
+// shaderLibrary holds shaderFiles by file name.
+var shaderLibrary = loadShaderLibrary()
+
+func loadShaderLibrary() *glsl.Library {
+	l := glsl.NewLibrary()
+	if err := l.AddFS(shaderFiles, "shaders/*"); err != nil {
+		panic(err)
+	}
+	return l
+}
+
+// shaderConstants are the Go constants shaders size their arrays by,
+// defined in every shader built.
+func shaderConstants() []glsl.Define {
+	return []glsl.Define{
+		{Name: "MAX_CLASSES", Value: fmt.Sprint(pointcloud.MaxClasses)},
+		{Name: "MAX_RAMP_STOPS", Value: fmt.Sprint(maxRampStops)},
+		{Name: "MAX_SEGMENTS", Value: fmt.Sprint(maxNamedSelections)},
+		{Name: "MAX_REVEAL_CHUNKS", Value: fmt.Sprint(maxRevealChunks)},
+	}
+}
+
+// es3Preludes are the snippets that let a shader written in GLSL ES 1.00
+// compile as GLSL ES 3.00, by the shader's file extension.
+var es3Preludes = map[string]string{".vert": "es3-vert.glsl", ".frag": "es3-frag.glsl"}
+
+// buildShader assembles the named shader of shaderLibrary with the
+// constants and the given defines; as GLSL ES 3.00 if es3 is set, for
+// WebGL 2 features such as uniform blocks, by way of a wrapper snippet
+// putting the #version line and the prelude ahead of it.
+func buildShader(name string, es3 bool, defines []glsl.Define) (*glsl.Source, error) {
+	if es3 {
+		wrapper := "es3/" + name
+		shaderLibrary.Add(wrapper, fmt.Sprintf("#version 300 es\n#include %q\n#include %q\n", es3Preludes[path.Ext(name)], name))
+		name = wrapper
+	}
+	return shaderLibrary.Build(name, append(shaderConstants(), defines...))
+}
+
+// remapShaderError maps the line numbers in the compiler log of err, a
+// failure of createShaderProgram, to the files and lines of the shaders
+// built as vert and frag, either of which may be nil.
+func remapShaderError(err error, vert, frag *glsl.Source) error {
+	var se *shaderError
+	if !errors.As(err, &se) {
+		return err
+	}
+	switch {
+	case se.stage == "vertex" && vert != nil:
+		se.log = vert.Remap(se.log)
+	case se.stage == "fragment" && frag != nil:
+		se.log = frag.Remap(se.log)
+	}
+	return se
+}
@@ -0,0 +1,15 @@
+// depth.frag writes each fragment's depth packed into RGBA as
+// pick.PackDepth does, as WebGL 1 cannot read the depth buffer back.
+#ifdef GL_FRAGMENT_PRECISION_HIGH
+precision highp float;
+#else
+precision mediump float;
+#endif
+varying vec4 vColor;
+#include "round.glsl"
+void main() {
+	clipRound();
+	vec4 enc = fract(gl_FragCoord.z * vec4(1.0, 255.0, 65025.0, 16581375.0));
+	enc -= enc.yzww * vec4(1.0 / 255.0, 1.0 / 255.0, 1.0 / 255.0, 0.0);
+	gl_FragColor = enc;
+}
@@ -0,0 +1,6 @@
+// es3-frag.glsl lets a fragment shader written in GLSL ES 1.00 compile as
+// GLSL ES 3.00; see buildShader.
+#define varying in
+layout(location = 0) out highp vec4 pc_fragColor;
+#define gl_FragColor pc_fragColor
+#define texture2D texture
@@ -0,0 +1,5 @@
+// es3-vert.glsl lets a vertex shader written in GLSL ES 1.00 compile as
+// GLSL ES 3.00; see buildShader.
+#define attribute in
+#define varying out
+#define texture2D texture
@@ -0,0 +1,8 @@
+#version 300 es
+// feedback.frag is the fragment shader feedback.vert links with, never run
+// as nothing is rasterized.
+precision mediump float;
+out vec4 fragColor;
+void main() {
+	fragColor = vec4(0.0);
+}
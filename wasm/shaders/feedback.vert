@@ -0,0 +1,22 @@
+#version 300 es
+// feedback.vert computes a value per point for transform feedback,
+// rasterizing nothing: the point transformed by uMatrix, divided by w as
+// glf32.TransformVertices does, or with DEPTH_KEY its depth in normalized
+// device coordinates, uMatrix being a model-view-projection matrix.
+in vec3 aPosition;
+uniform mat4 uMatrix;
+#ifdef DEPTH_KEY
+out float vResult;
+#else
+out vec3 vResult;
+#endif
+void main() {
+	vec4 p = uMatrix * vec4(aPosition, 1.0);
+#ifdef DEPTH_KEY
+	vResult = p.w != 0.0 ? p.z / p.w : 0.0;
+#else
+	vResult = p.w != 0.0 ? p.xyz / p.w : vec3(0.0);
+#endif
+	gl_Position = vec4(0.0, 0.0, 0.0, 1.0);
+	gl_PointSize = 1.0;
+}
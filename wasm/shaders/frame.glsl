@@ -0,0 +1,13 @@
+// frame.glsl declares the Frame uniform block: what every point program
+// shares in a frame, filled once per frame by FrameBlock, whose layout
+// this must match.
+layout(std140) uniform Frame {
+	// The view-projection matrix, with the vertical exaggeration.
+	mat4 uViewProjMatrix;
+	mat4 uViewMatrix;
+	mat4 uProjectionMatrix;
+	vec3 uCameraPosition;
+	vec3 uLightDir;
+	vec3 uLightColor;
+	float uAmbient;
+};
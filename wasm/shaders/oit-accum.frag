@@ -0,0 +1,18 @@
+// oit-accum.frag adds each translucent fragment's color, premultiplied by
+// its alpha and weighted by oitWeight, to the accumulation texture of
+// weighted blended order-independent transparency, with the weighted
+// alphas summed in a; blended ONE, ONE.
+#ifdef GL_FRAGMENT_PRECISION_HIGH
+precision highp float;
+#else
+precision mediump float;
+#endif
+uniform float uOpacity;
+varying vec4 vColor;
+#include "oit.glsl"
+#include "round.glsl"
+void main() {
+	clipRound();
+	float a = vColor.a * uOpacity;
+	gl_FragColor = vec4(vColor.rgb * a, a) * oitWeight(a);
+}
@@ -0,0 +1,14 @@
+// oit-composite.frag blends the translucent points' weighted average color
+// over the canvas, covering it as much as the revealage says they do.
+precision mediump float;
+uniform sampler2D uAccum;
+uniform sampler2D uReveal;
+varying vec2 vTexCoord;
+void main() {
+	float reveal = texture2D(uReveal, vTexCoord).r;
+	if (reveal >= 1.0) {
+		discard;
+	}
+	vec4 accum = texture2D(uAccum, vTexCoord);
+	gl_FragColor = vec4(accum.rgb / max(accum.a, 1e-5), 1.0 - reveal);
+}
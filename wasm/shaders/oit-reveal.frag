@@ -0,0 +1,12 @@
+// oit-reveal.frag multiplies the revealage texture of weighted blended
+// order-independent transparency, cleared to 1, by one minus each
+// translucent fragment's alpha, leaving how much of what is behind shows
+// through; blended ZERO, ONE_MINUS_SRC_COLOR.
+precision mediump float;
+uniform float uOpacity;
+varying vec4 vColor;
+#include "round.glsl"
+void main() {
+	clipRound();
+	gl_FragColor = vec4(vColor.a * uOpacity);
+}
@@ -0,0 +1,7 @@
+// oit.glsl weighs translucent fragments for weighted blended
+// order-independent transparency: near, opaque fragments count for more,
+// as in McGuire and Bavoil's depth weight for a 0 to 1 depth buffer.
+float oitWeight(float a) {
+	float z = 1.0 - gl_FragCoord.z;
+	return a * clamp(3e3 * z * z * z, 1e-2, 3e3);
+}
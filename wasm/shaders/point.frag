@@ -0,0 +1,10 @@
+// point.frag colors points as the vertex shader says, faded by their
+// object's opacity.
+precision mediump float;
+uniform float uOpacity;
+varying vec4 vColor;
+#include "round.glsl"
+void main() {
+	clipRound();
+	gl_FragColor = vec4(vColor.rgb, vColor.a * uOpacity);
+}
@@ -0,0 +1,130 @@
+// point.vert positions, sizes and colors points for every point program,
+// hiding filtered points and points of hidden classes. Its optional parts
+// are compiled in by the macros of ShaderFeatures: with TEXTURE_POSITIONS
+// it reads the positions of objects that keep them in a texture from there
+// (see PositionStorage), with LIT_POINTS it shades points by the light and
+// with COLOR_RAMP it maps scalars through the colormap; without, scalars
+// show in gray. With FRAME_BLOCK, for GLSL ES 3.00, the view and the light
+// come from the Frame block rather than uniforms of the program's own.
+// Every color mode's colors are multiplied by the object's uTint, then
+// mixed towards the rgb of its uObjectColor by its alpha. Objects sized
+// their own way draw uObjectSize.x pixels across, divided by the clip w
+// where uObjectSize.y is 1, and uLit turns lighting off per object.
+// With REVEAL, points being revealed fade in and grow; see reveal.glsl.
+// MAX_CLASSES, MAX_RAMP_STOPS, MAX_SEGMENTS and MAX_REVEAL_CHUNKS are
+// defined by shaderConstants.
+attribute vec4 aPosition;
+attribute vec4 aColor;
+attribute float aClass;
+attribute float aVisible;
+attribute float aIntensity;
+attribute float aDistance;
+attribute float aChange;
+attribute float aSelected;
+attribute float aSegment;
+attribute vec3 aNormal;
+attribute float aCurvature;
+uniform float uPointSize;
+uniform float uColorMode;
+uniform vec4 uClassColors[MAX_CLASSES];
+uniform float uClassVisible[MAX_CLASSES];
+uniform vec4 uSegmentColors[MAX_SEGMENTS];
+uniform vec3 uTint;
+uniform vec4 uObjectColor;
+uniform vec2 uObjectSize;
+#ifdef FRAME_BLOCK
+#include "frame.glsl"
+uniform mat4 uModelMatrix;
+#else
+uniform mat4 uMvpMatrix;
+#ifdef LIT_POINTS
+uniform mat4 uModelMatrix;
+uniform vec3 uLightDir;
+uniform vec3 uLightColor;
+uniform float uAmbient;
+#endif
+#endif
+#ifdef LIT_POINTS
+uniform float uLit;
+#endif
+varying vec4 vColor;
+#include "ramp.glsl"
+#include "positions.glsl"
+#include "reveal.glsl"
+void main() {
+	int cls = int(clamp(aClass, 0.0, float(MAX_CLASSES - 1)) + 0.5);
+	bool hidden = uClassVisible[cls] < 0.5 || aVisible < 0.5;
+#ifdef TEXTURE_POSITIONS
+	// Points whose positions are not uploaded yet are hidden too.
+	hidden = hidden || (uPositionsSize.w > 0.5 && aIndex >= uPositionsSize.z);
+#endif
+	if (hidden) {
+		// Hidden class or filtered out: move the point outside the clip volume.
+		gl_Position = vec4(2.0, 2.0, 2.0, 1.0);
+		gl_PointSize = 0.0;
+		vColor = vec4(0.0);
+		return;
+	}
+	vec4 position = pointPosition();
+#ifdef FRAME_BLOCK
+	gl_Position = uViewProjMatrix * (uModelMatrix * position);
+#else
+	gl_Position = uMvpMatrix * position;
+#endif
+	gl_PointSize = uObjectSize.x > 0.0 ? uObjectSize.x / mix(1.0, gl_Position.w, uObjectSize.y) : uPointSize;
+	if (uColorMode > 7.5) {
+		vColor = vec4(ramp(scalarT(aCurvature)), 1.0);
+	} else if (uColorMode > 6.5) {
+		vColor = vec4(aNormal * 0.5 + 0.5, 1.0);
+	} else if (uColorMode > 5.5) {
+		int segment = int(aSegment + 0.5);
+		if (segment > 0) {
+			for (int i = 0; i < MAX_SEGMENTS; i++) {
+				if (i == segment - 1) {
+					vColor = uSegmentColors[i];
+				}
+			}
+		} else {
+			vColor = vec4(mix(aColor.rgb, vec3(0.5), 0.7), aColor.a);
+		}
+	} else if (uColorMode > 4.5) {
+		if (aChange > 2.5) {
+			vColor = vec4(1.0, 0.85, 0.1, 1.0);
+		} else if (aChange > 1.5) {
+			vColor = vec4(0.9, 0.15, 0.1, 1.0);
+		} else if (aChange > 0.5) {
+			vColor = vec4(0.1, 0.85, 0.2, 1.0);
+		} else {
+			vColor = vec4(mix(aColor.rgb, vec3(0.5), 0.7), aColor.a);
+		}
+	} else if (uColorMode > 3.5) {
+		vColor = vec4(ramp(scalarT(aDistance)), 1.0);
+	} else if (uColorMode > 2.5) {
+		vColor = vec4(ramp(scalarT(position.y)), 1.0);
+	} else if (uColorMode > 1.5) {
+		vColor = vec4(ramp(scalarT(aIntensity)), 1.0);
+	} else if (uColorMode > 0.5) {
+		vColor = uClassColors[cls];
+	} else {
+		vColor = aColor;
+	}
+	vColor.rgb = mix(vColor.rgb * uTint, uObjectColor.rgb, uObjectColor.a);
+#ifdef LIT_POINTS
+	if (uLit > 0.5 && dot(aNormal, aNormal) > 0.25) {
+		// Shaded like a small surface patch, from both sides as normals
+		// estimated without a viewpoint may face either way.
+		float diffuse = abs(dot(normalize((uModelMatrix * vec4(aNormal, 0.0)).xyz), -uLightDir));
+		vColor.rgb *= uAmbient + (1.0 - uAmbient) * diffuse * uLightColor;
+	}
+#endif
+	if (aSelected > 0.5) {
+		// Selected points draw larger and tinted yellow.
+		gl_PointSize += 2.0;
+		vColor = vec4(mix(vColor.rgb, vec3(1.0, 0.9, 0.1), 0.7), 1.0);
+	}
+#ifdef REVEAL
+	float reveal = revealProgress();
+	gl_PointSize *= reveal;
+	vColor.a *= reveal;
+#endif
+}
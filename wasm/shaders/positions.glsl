@@ -0,0 +1,22 @@
+// positions.glsl reads point positions from the vertex buffer, or with
+// TEXTURE_POSITIONS from the texture of objects that keep them there. It
+// declares aIndex, each point's index, for them and for REVEAL.
+#if defined(TEXTURE_POSITIONS) || defined(REVEAL)
+attribute float aIndex;
+#endif
+#ifdef TEXTURE_POSITIONS
+uniform highp sampler2D uPositions;
+// The texture's width and height, the points uploaded to it and whether
+// it is used.
+uniform vec4 uPositionsSize;
+#endif
+vec4 pointPosition() {
+#ifdef TEXTURE_POSITIONS
+	if (uPositionsSize.w > 0.5) {
+		float row = floor((aIndex + 0.5) / uPositionsSize.x);
+		vec2 texel = vec2(aIndex - row * uPositionsSize.x, row);
+		return texture2D(uPositions, (texel + 0.5) / uPositionsSize.xy);
+	}
+#endif
+	return aPosition;
+}
@@ -0,0 +1,28 @@
+// ramp.glsl maps scalars in uScalarRange through the colormap.
+uniform vec2 uScalarRange;
+#ifdef COLOR_RAMP
+uniform vec4 uRamp[MAX_RAMP_STOPS];
+uniform float uRampStops;
+// ramp maps t in [0, 1] through the colormap stops in uRamp, each holding
+// a color in rgb and its position in a.
+vec3 ramp(float t) {
+	vec3 color = uRamp[0].rgb;
+	for (int i = 1; i < MAX_RAMP_STOPS; i++) {
+		if (float(i) >= uRampStops) {
+			break;
+		}
+		if (t > uRamp[i - 1].a) {
+			float span = max(uRamp[i].a - uRamp[i - 1].a, 1e-6);
+			color = mix(uRamp[i - 1].rgb, uRamp[i].rgb, clamp((t - uRamp[i - 1].a) / span, 0.0, 1.0));
+		}
+	}
+	return color;
+}
+#else
+vec3 ramp(float t) {
+	return vec3(t);
+}
+#endif
+float scalarT(float v) {
+	return clamp((v - uScalarRange.x) / max(uScalarRange.y - uScalarRange.x, 1e-6), 0.0, 1.0);
+}
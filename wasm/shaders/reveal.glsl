@@ -0,0 +1,21 @@
+// reveal.glsl times the reveal of points by their index, aIndex:
+// uRevealChunks holds the object's chunks of points being revealed, each
+// as its first point, the point after its last and its progress from 0
+// to 1, in up to MAX_REVEAL_CHUNKS slots; unused slots are empty. Float
+// indices are exact up to 2^24 points, past which chunk edges blur by a
+// few points.
+#ifdef REVEAL
+uniform vec3 uRevealChunks[MAX_REVEAL_CHUNKS];
+// revealProgress returns how far the point is revealed, eased, and 1 for
+// points of no chunk.
+float revealProgress() {
+	float progress = 1.0;
+	for (int i = 0; i < MAX_REVEAL_CHUNKS; i++) {
+		vec3 chunk = uRevealChunks[i];
+		if (aIndex >= chunk.x && aIndex < chunk.y) {
+			progress = min(progress, chunk.z);
+		}
+	}
+	return smoothstep(0.0, 1.0, progress);
+}
+#endif
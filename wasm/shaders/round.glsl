@@ -0,0 +1,8 @@
+// round.glsl clips the points of objects drawn round to discs; see
+// PointStyle.
+uniform float uRound;
+void clipRound() {
+	if (uRound > 0.5 && length(gl_PointCoord - vec2(0.5)) > 0.5) {
+		discard;
+	}
+}
@@ -0,0 +1,190 @@
+// wasm/shadow.go
+package main
+
+import (
+	"fmt"
+	"syscall/js"
+
+	"github.com/sbecker11/webgl-point-cloud/glf32"
+	"github.com/sbecker11/webgl-point-cloud/shadow"
+)
+
+// ContactShadow is a soft shadow of the drawn points on the ground under
+// them, at their lowest world y: a blurred top-down density map, rebuilt
+// when the points, their placement or the settings change, that darkens
+// whatever draws behind it.
+type ContactShadow struct {
+	viewer   *Viewer
+	Visible  bool
+	Strength float32
+	Options  shadow.Options
+
+	program     js.Value
+	mvpLoc      js.Value
+	texLoc      int
+	strengthLoc js.Value
+	buffer      js.Value
+	texture     js.Value
+	key         string // scene data and settings the map was built for
+	built       bool
+}
+
+// newContactShadow returns a hidden shadow of the default strength.
+func newContactShadow(v *Viewer) *ContactShadow {
+	return &ContactShadow{viewer: v, Strength: 0.6}
+}
+
+const shadowVertexShader = `
+attribute vec4 aPosition;
+attribute vec2 aTexCoord;
+uniform mat4 uMvpMatrix;
+varying vec2 vTexCoord;
+void main() {
+	gl_Position = uMvpMatrix * aPosition;
+	vTexCoord = aTexCoord;
+}`
+
+const shadowFragmentShader = `
+precision mediump float;
+uniform sampler2D uTexture;
+uniform float uStrength;
+varying vec2 vTexCoord;
+void main() {
+	gl_FragColor = vec4(0.0, 0.0, 0.0, texture2D(uTexture, vTexCoord).a * uStrength);
+}`
+
+// setup compiles the program and creates the buffer and texture on first
+// use.
+func (s *ContactShadow) setup(gl js.Value) error {
+	if !s.program.IsUndefined() {
+		return nil
+	}
+	program, err := createShaderProgram(gl, shadowVertexShader, shadowFragmentShader)
+	if err != nil {
+		return err
+	}
+	s.program = program
+	s.mvpLoc = gl.Call("getUniformLocation", program, "uMvpMatrix")
+	s.strengthLoc = gl.Call("getUniformLocation", program, "uStrength")
+	s.texLoc = gl.Call("getAttribLocation", program, "aTexCoord").Int()
+	s.buffer = gl.Call("createBuffer")
+	s.texture = gl.Call("createTexture")
+	return nil
+}
+
+// dataKey describes what the map depends on: the settings and the drawn
+// points and their placement.
+func (s *ContactShadow) dataKey() string {
+	key := fmt.Sprint(s.Options, s.viewer.classStyle.Visible) + s.viewer.sceneDataKey()
+	for _, o := range s.viewer.scene.Objects() {
+		_, _, model := s.viewer.scene.Effective(o)
+		key += fmt.Sprintf("%v%p", model, o.Mask)
+	}
+	return key
+}
+
+// build computes the map from the drawn points of the visible objects, in
+// world coordinates, and uploads it with the quad it covers.
+func (s *ContactShadow) build(gl js.Value) {
+	var coords []float32
+	for _, o := range s.viewer.scene.Objects() {
+		visible, _, model := s.viewer.scene.Effective(o)
+		if !visible {
+			continue
+		}
+		var local []float32
+		for i, drawn := range s.viewer.selectable(o) {
+			if drawn {
+				local = append(local, o.Cloud.Coords[i*3:i*3+3]...)
+			}
+		}
+		coords = append(coords, glf32.TransformVertices(local, model)...)
+	}
+	m := shadow.Contact(coords, s.Options)
+	s.built = m != nil
+	if m == nil {
+		return
+	}
+	alpha := make([]byte, len(m.Values))
+	for i, v := range m.Values {
+		alpha[i] = byte(v*255 + 0.5)
+	}
+	pixels := js.Global().Get("Uint8Array").New(len(alpha))
+	js.CopyBytesToJS(pixels, alpha)
+	gl.Call("bindTexture", gl.Get("TEXTURE_2D"), s.texture)
+	gl.Call("pixelStorei", gl.Get("UNPACK_ALIGNMENT"), 1)
+	gl.Call("texImage2D", gl.Get("TEXTURE_2D"), 0, gl.Get("ALPHA"), m.Width, m.Height, 0, gl.Get("ALPHA"), gl.Get("UNSIGNED_BYTE"), pixels)
+	gl.Call("pixelStorei", gl.Get("UNPACK_ALIGNMENT"), 4)
+	gl.Call("texParameteri", gl.Get("TEXTURE_2D"), gl.Get("TEXTURE_MIN_FILTER"), gl.Get("LINEAR"))
+	gl.Call("texParameteri", gl.Get("TEXTURE_2D"), gl.Get("TEXTURE_MAG_FILTER"), gl.Get("LINEAR"))
+	gl.Call("texParameteri", gl.Get("TEXTURE_2D"), gl.Get("TEXTURE_WRAP_S"), gl.Get("CLAMP_TO_EDGE"))
+	gl.Call("texParameteri", gl.Get("TEXTURE_2D"), gl.Get("TEXTURE_WRAP_T"), gl.Get("CLAMP_TO_EDGE"))
+	gl.Call("bindTexture", gl.Get("TEXTURE_2D"), js.Null())
+
+	x0, z0, x1, z1, y := float32(m.Min[0]), float32(m.Min[1]), float32(m.Max[0]), float32(m.Max[1]), float32(m.Ground)
+	vertices := []float32{
+		x0, y, z0, 0, 0, x0, y, z1, 0, 1, x1, y, z0, 1, 0,
+		x1, y, z0, 1, 0, x0, y, z1, 0, 1, x1, y, z1, 1, 1,
+	}
+	gl.Call("bindBuffer", gl.Get("ARRAY_BUFFER"), s.buffer)
+	gl.Call("bufferData", gl.Get("ARRAY_BUFFER"), glf32.ToFloat32Array(vertices), gl.Get("STATIC_DRAW"))
+}
+
+// draw draws the shadow, rebuilding it first if what it depends on
+// changed. It is called once a frame, after the ground grid and before
+// the meshes and points, which draw over it.
+func (s *ContactShadow) draw(gl js.Value, viewProj glf32.Mat4) {
+	if !s.Visible {
+		return
+	}
+	if err := s.setup(gl); err != nil {
+		s.viewer.notice(msgf("notice.shadowOff", err))
+		s.Visible = false
+		return
+	}
+	if key := s.dataKey(); key != s.key {
+		s.key = key
+		s.build(gl)
+	}
+	if !s.built {
+		return
+	}
+	gl.Call("useProgram", s.program)
+	gl.Call("uniformMatrix4fv", s.mvpLoc, false, glf32.ToFloat32Array(viewProj))
+	gl.Call("uniform1f", s.strengthLoc, s.Strength)
+	gl.Call("depthMask", false)
+	gl.Call("bindBuffer", gl.Get("ARRAY_BUFFER"), s.buffer)
+	gl.Call("enableVertexAttribArray", s.texLoc)
+	gl.Call("vertexAttribPointer", attribPosition, 3, gl.Get("FLOAT"), false, 20, 0)
+	gl.Call("vertexAttribPointer", s.texLoc, 2, gl.Get("FLOAT"), false, 20, 12)
+	gl.Call("activeTexture", gl.Get("TEXTURE0"))
+	gl.Call("bindTexture", gl.Get("TEXTURE_2D"), s.texture)
+	gl.Call("drawArrays", gl.Get("TRIANGLES"), 0, 6)
+	gl.Call("bindTexture", gl.Get("TEXTURE_2D"), js.Null())
+	gl.Call("disableVertexAttribArray", s.texLoc)
+	gl.Call("depthMask", true)
+}
+
+// showContactShadow(visible, params) shows or hides a soft shadow of the
+// drawn points on the ground at their lowest point, which helps them read
+// as sitting in space. params may hold strength (the darkness of the
+// shadow's core, in [0, 1], default 0.6), resolution (cells along the
+// longer side of the points' footprint, default 128, at most 1024 and the
+// largest texture size), blur (the softness, in cells, default 3) and
+// falloff (the height above the ground at which points stop casting
+// shadow, default a quarter of the points' height).
+// The shadow follows changes to the points, filter and transforms.
+func (v *Viewer) showContactShadow(this js.Value, args []js.Value) interface{} {
+	visible := len(args) < 1 || args[0].Truthy()
+	if len(args) > 1 && args[1].Type() == js.TypeObject {
+		params := args[1]
+		v.contactShadow.Strength = max(0, min(1, jsFloat(params, "strength", v.contactShadow.Strength)))
+		if n := int(jsFloat(params, "resolution", float32(v.contactShadow.Options.Resolution))); n >= 0 {
+			v.contactShadow.Options.Resolution = min(n, 1024, v.caps.MaxTextureSize)
+		}
+		v.contactShadow.Options.Blur = float64(max(0, jsFloat(params, "blur", float32(v.contactShadow.Options.Blur))))
+		v.contactShadow.Options.Falloff = float64(max(0, jsFloat(params, "falloff", float32(v.contactShadow.Options.Falloff))))
+	}
+	v.contactShadow.Visible = visible
+	return nil
+}
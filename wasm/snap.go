@@ -0,0 +1,200 @@
+// wasm/snap.go
+package main
+
+import (
+	"fmt"
+	"syscall/js"
+
+	"github.com/sbecker11/webgl-point-cloud/glf32"
+	"github.com/sbecker11/webgl-point-cloud/pick"
+)
+
+// Snapper snaps canvas positions to the drawn point nearest the camera
+// within Radius pixels, so that picks and the tools' clicks land on data
+// rather than between points. Each object's points are indexed in an
+// octree, rebuilt when the object's coordinates are replaced.
+type Snapper struct {
+	viewer *Viewer
+	// Enabled makes tools snap to points; when false they use the ground
+	// plane. Picking points always snaps.
+	Enabled bool
+	// Radius is how close, in canvas pixels, a position must be to a point
+	// to snap to it.
+	Radius    float32
+	trees     map[*SceneObject]snapTree
+	indicator js.Value
+}
+
+// snapTree is an object's octree and the coordinates it indexes.
+type snapTree struct {
+	coords []float32
+	tree   *pick.Octree
+}
+
+// newSnapper returns a snapper turned on, with the default radius.
+func newSnapper(v *Viewer) *Snapper {
+	return &Snapper{viewer: v, Enabled: true, Radius: pickRadius, trees: map[*SceneObject]snapTree{}}
+}
+
+// tree returns o's octree, indexing its coordinates if they changed since
+// it was built.
+func (s *Snapper) tree(o *SceneObject) *pick.Octree {
+	coords := o.Cloud.Coords
+	if t, ok := s.trees[o]; ok && len(t.coords) == len(coords) && (len(coords) == 0 || &t.coords[0] == &coords[0]) {
+		return t.tree
+	}
+	t := snapTree{coords: coords, tree: pick.NewOctree(coords)}
+	s.trees[o] = t
+	return t.tree
+}
+
+// nearest returns the drawn point nearest the camera within Radius of
+// canvas position (x, y) in the last frame, or a nil object if there is
+// none. It drops the octrees of objects no longer in the scene.
+func (s *Snapper) nearest(x, y, width, height float32) (*SceneObject, int) {
+	var best *SceneObject
+	var bestIndex int
+	var bestDepth float32 = 2
+	objects := s.viewer.scene.Objects()
+	current := make(map[*SceneObject]bool, len(objects))
+	for _, o := range objects {
+		current[o] = true
+		visible, opacity, model := s.viewer.scene.Effective(o)
+		if !visible || opacity <= 0 {
+			continue
+		}
+		mvp := glf32.MultiplyMatrices(s.viewer.lastViewProj, model)
+		drawn := s.viewer.selectable(o)
+		i, depth, ok := s.tree(o).Nearest(mvp, width, height, x, y, s.Radius, func(i int) bool { return drawn[i] })
+		if ok && depth < bestDepth {
+			best, bestIndex, bestDepth = o, i, depth
+		}
+	}
+	for o := range s.trees {
+		if !current[o] {
+			delete(s.trees, o)
+		}
+	}
+	return best, bestIndex
+}
+
+// snapAt returns the world position of the point canvas position (x, y)
+// snaps to in the last frame, or false if snapping is off or there is no
+// point within Radius.
+func (s *Snapper) snapAt(x, y, width, height float32) (*SceneObject, int, [3]float64, bool) {
+	if !s.Enabled || s.viewer.lastViewProj == nil {
+		return nil, 0, [3]float64{}, false
+	}
+	o, i := s.nearest(x, y, width, height)
+	if o == nil {
+		return nil, 0, [3]float64{}, false
+	}
+	_, _, model := s.viewer.scene.Effective(o)
+	p := glf32.TransformVertices(append([]float32(nil), o.Cloud.Coords[i*3:i*3+3]...), model)
+	return o, i, [3]float64{float64(p[0]), float64(p[1]), float64(p[2])}, true
+}
+
+// showIndicator rings the point canvas position (x, y) snaps to, or hides
+// the ring if it snaps to none.
+func (s *Snapper) showIndicator(x, y float32) {
+	width, height := s.viewer.canvasSize()
+	_, _, p, ok := s.snapAt(x, y, width, height)
+	if !ok {
+		s.hideIndicator()
+		return
+	}
+	if s.indicator.IsUndefined() {
+		doc := js.Global().Get("document")
+		s.indicator = doc.Call("createElement", "div")
+		s.indicator.Set("style", "position:fixed;width:12px;height:12px;margin:-8px 0 0 -8px;border:2px solid #ffd24d;"+
+			"border-radius:50%;pointer-events:none;z-index:6;display:none")
+		doc.Get("body").Call("appendChild", s.indicator)
+	}
+	// Ring the point itself rather than the cursor, in CSS pixels.
+	m := s.viewer.lastViewProj
+	w := m[3]*float32(p[0]) + m[7]*float32(p[1]) + m[11]*float32(p[2]) + m[15]
+	sx := (m[0]*float32(p[0]) + m[4]*float32(p[1]) + m[8]*float32(p[2]) + m[12]) / w
+	sy := (m[1]*float32(p[0]) + m[5]*float32(p[1]) + m[9]*float32(p[2]) + m[13]) / w
+	canvas := s.viewer.canvas
+	style := s.indicator.Get("style")
+	style.Set("left", fmt.Sprintf("%.1fpx", (float64(sx)+1)/2*canvas.Get("clientWidth").Float()))
+	style.Set("top", fmt.Sprintf("%.1fpx", (1-float64(sy))/2*canvas.Get("clientHeight").Float()))
+	style.Set("display", "block")
+}
+
+// placesPoints reports whether tool t places positions with worldAt, so
+// the snap ring is shown while it is active.
+func placesPoints(t Tool) bool {
+	switch t.(type) {
+	case *MeasureTool, *ProfileTool:
+		return true
+	}
+	return false
+}
+
+// hideIndicator hides the snap ring.
+func (s *Snapper) hideIndicator() {
+	if !s.indicator.IsUndefined() {
+		s.indicator.Get("style").Set("display", "none")
+	}
+}
+
+// pivotAt makes the camera orbit the point canvas position (x, y) snaps
+// to, keeping the camera where it is. It reports false if there is none.
+func (v *Viewer) pivotAt(x, y float32) bool {
+	width, height := v.canvasSize()
+	_, _, p, ok := v.snapping.snapAt(x, y, width, height)
+	if !ok {
+		return false
+	}
+	v.camera.SetPose(v.camera.Position(), v.view.exaggerate(p))
+	return true
+}
+
+// setSnapping({enabled, radius}) turns snapping the tools' clicks to points
+// on or off and sets how close, in CSS pixels, a click must be to a point
+// to snap to it (default 6). Omitted fields are unchanged.
+func (v *Viewer) setSnapping(this js.Value, args []js.Value) interface{} {
+	if len(args) < 1 || args[0].Type() != js.TypeObject {
+		return jsError("setSnapping: expected ({enabled, radius})")
+	}
+	params := args[0]
+	radius := v.snapping.Radius
+	if r := params.Get("radius"); !r.IsUndefined() {
+		canvas := v.canvas
+		radius = float32(r.Float() * canvas.Get("width").Float() / canvas.Get("clientWidth").Float())
+		if !(radius > 0) {
+			return jsError("setSnapping: radius must be positive")
+		}
+	}
+	v.snapping.Radius = radius
+	if e := params.Get("enabled"); !e.IsUndefined() {
+		v.snapping.Enabled = e.Truthy()
+	}
+	if !v.snapping.Enabled {
+		v.snapping.hideIndicator()
+	}
+	return nil
+}
+
+// snapPoint(x, y) returns the point that (x, y), in CSS pixels from the
+// canvas's top-left corner, snaps to as {name, index, position} with
+// the position in world coordinates, or null if there is none.
+func (v *Viewer) snapPoint(this js.Value, args []js.Value) interface{} {
+	if len(args) < 2 {
+		return jsError("snapPoint: expected (x, y)")
+	}
+	canvas := v.canvas
+	scaleX := canvas.Get("width").Float() / canvas.Get("clientWidth").Float()
+	scaleY := canvas.Get("height").Float() / canvas.Get("clientHeight").Float()
+	width, height := v.canvasSize()
+	o, i, p, ok := v.snapping.snapAt(float32(args[0].Float()*scaleX), float32(args[1].Float()*scaleY), width, height)
+	if !ok {
+		return js.Null()
+	}
+	return js.ValueOf(map[string]interface{}{
+		"name":     o.Cloud.Name,
+		"index":    i,
+		"position": []interface{}{p[0], p[1], p[2]},
+	})
+}
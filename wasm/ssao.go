@@ -0,0 +1,203 @@
+// wasm/ssao.go
+package main
+
+import (
+	"fmt"
+	"math"
+	"syscall/js"
+
+	"github.com/sbecker11/webgl-point-cloud/glf32"
+	"github.com/sbecker11/webgl-point-cloud/rendergraph"
+)
+
+// ssaoSamples is the number of depth samples taken around each pixel.
+const ssaoSamples = 16
+
+// SSAOPass darkens the drawn points where nearer points crowd around them,
+// screen-space ambient occlusion, so the shape of dense surfaces reads
+// without lighting or normals. It adds two render passes: one draws the
+// points' depth offscreen, the other blends a shade over the canvas from
+// depth samples spread over Radius pixels around each pixel. Samples nearer
+// the camera occlude, unless nearer by more than Range, as a fraction of
+// the pixel's own depth, so foreground objects do not shade those behind
+// them.
+type SSAOPass struct {
+	viewer   *Viewer
+	Enabled  bool
+	Radius   float32 // in pixels
+	Strength float32 // darkness of full occlusion, in [0, 1]
+	Range    float32
+
+	depthShader *PointShader
+	program     js.Value
+	locs        map[string]js.Value
+	failed      bool
+}
+
+// newSSAOPass returns the pass turned off, with the default settings.
+func newSSAOPass(v *Viewer) *SSAOPass {
+	return &SSAOPass{viewer: v, Radius: 12, Strength: 0.8, Range: 0.05}
+}
+
+var ssaoFragmentShader = `
+#ifdef GL_FRAGMENT_PRECISION_HIGH
+precision highp float;
+#else
+precision mediump float;
+#endif
+uniform sampler2D uDepth;
+uniform vec2 uTexel;
+uniform vec2 uKernel[` + fmt.Sprint(ssaoSamples) + `];
+uniform float uRadius;
+uniform float uStrength;
+uniform float uRange;
+uniform vec2 uClip;
+varying vec2 vTexCoord;
+// depthAt unpacks the depth drawn at uv, 0 where nothing was drawn.
+float depthAt(vec2 uv) {
+	return dot(texture2D(uDepth, uv), vec4(1.0, 1.0 / 255.0, 1.0 / 65025.0, 1.0 / 16581375.0));
+}
+// distanceOf returns the distance from the camera of depth d.
+float distanceOf(float d) {
+	float z = d * 2.0 - 1.0;
+	return 2.0 * uClip.x * uClip.y / (uClip.y + uClip.x - z * (uClip.y - uClip.x));
+}
+void main() {
+	float d = depthAt(vTexCoord);
+	if (d <= 0.0) {
+		gl_FragColor = vec4(0.0);
+		return;
+	}
+	float center = distanceOf(d);
+	float occlusion = 0.0;
+	for (int i = 0; i < ` + fmt.Sprint(ssaoSamples) + `; i++) {
+		float s = depthAt(vTexCoord + uKernel[i] * uRadius * uTexel);
+		if (s > 0.0) {
+			float nearer = (center - distanceOf(s)) / center;
+			occlusion += step(0.002, nearer) * (1.0 - smoothstep(0.5 * uRange, uRange, nearer));
+		}
+	}
+	gl_FragColor = vec4(0.0, 0.0, 0.0, uStrength * occlusion / ` + fmt.Sprintf("%.1f", float64(ssaoSamples)) + `);
+}`
+
+// ssaoKernel returns the sample offsets, in units of the radius, spread
+// evenly over the unit disk along a sunflower spiral.
+func ssaoKernel() []float32 {
+	kernel := make([]float32, 0, ssaoSamples*2)
+	golden := math.Pi * (3 - math.Sqrt(5))
+	for i := 0; i < ssaoSamples; i++ {
+		r := math.Sqrt((float64(i) + 0.5) / ssaoSamples)
+		a := float64(i) * golden
+		kernel = append(kernel, float32(r*math.Cos(a)), float32(r*math.Sin(a)))
+	}
+	return kernel
+}
+
+// setup compiles the programs on first use. A failure turns the pass off
+// for good.
+func (s *SSAOPass) setup(gl js.Value) bool {
+	if s.failed || !s.program.IsUndefined() {
+		return !s.failed
+	}
+	depthShader, err := s.viewer.shaders.point(gl, "depth.frag", s.viewer.baseFeatures())
+	if err == nil {
+		s.depthShader = depthShader
+		s.program, err = createShaderProgram(gl, fullscreenVertexShader, ssaoFragmentShader)
+	}
+	if err != nil {
+		s.viewer.notice(msgf("notice.ssaoOff", err))
+		s.failed = true
+		return false
+	}
+	s.locs = map[string]js.Value{}
+	for _, name := range []string{"uDepth", "uTexel", "uKernel", "uRadius", "uStrength", "uRange", "uClip"} {
+		s.locs[name] = gl.Call("getUniformLocation", s.program, name)
+	}
+	return true
+}
+
+// register adds the pass's steps to g.
+func (s *SSAOPass) register(g *RenderGraph) {
+	enabled := func() bool { return s.Enabled && !s.failed }
+	g.add(&RenderPass{
+		Pass:    rendergraph.Pass{Name: "ssao-depth", Outputs: []string{"ssao-depth"}},
+		Depth:   true,
+		Enabled: enabled,
+		Draw: func(c *PassContext) {
+			if s.setup(c.gl) {
+				s.viewer.drawPointDepth(c.gl, s.depthShader, c.frame.viewProj, c.frame.pointSize, c.frame.fraction)
+			}
+		},
+	})
+	g.add(&RenderPass{
+		Pass:    rendergraph.Pass{Name: "ssao", Inputs: []string{"ssao-depth"}},
+		Enabled: enabled,
+		Draw:    s.shade,
+	})
+}
+
+// shade blends the occlusion over the canvas.
+func (s *SSAOPass) shade(c *PassContext) {
+	if !s.setup(c.gl) {
+		return
+	}
+	gl := c.gl
+	gl.Call("useProgram", s.program)
+	gl.Call("uniform1i", s.locs["uDepth"], c.Unit("ssao-depth"))
+	gl.Call("uniform2f", s.locs["uTexel"], 1/float32(c.width), 1/float32(c.height))
+	gl.Call("uniform2fv", s.locs["uKernel"], glf32.ToFloat32Array(ssaoKernel()))
+	gl.Call("uniform1f", s.locs["uRadius"], s.Radius)
+	gl.Call("uniform1f", s.locs["uStrength"], s.Strength)
+	gl.Call("uniform1f", s.locs["uRange"], s.Range)
+	gl.Call("uniform2f", s.locs["uClip"], float32(nearPlane), float32(farPlane))
+	c.DrawQuad()
+}
+
+// ssaoInfo returns the pass's settings as a JS object.
+func (v *Viewer) ssaoInfo() js.Value {
+	return js.ValueOf(map[string]interface{}{
+		"enabled":  v.ssao.Enabled,
+		"radius":   v.ssao.Radius,
+		"strength": v.ssao.Strength,
+		"range":    v.ssao.Range,
+	})
+}
+
+// setSSAO(params) turns screen-space ambient occlusion on or off and tunes
+// it. It darkens points where nearer points crowd around them, bringing out
+// the shape of dense scans without normals, at the cost of drawing the
+// points twice. params may set any of enabled, radius (how far around each
+// pixel to look, in pixels, default 12), strength (the darkness of full
+// occlusion, in [0, 1], default 0.8) and range (how much nearer, as a
+// fraction of a pixel's distance, a neighbour may be and still occlude,
+// default 0.05); others keep their values.
+//
+// Returns the settings as getSSAO does, or {error}.
+func (v *Viewer) setSSAO(this js.Value, args []js.Value) interface{} {
+	if len(args) < 1 || args[0].Type() != js.TypeObject {
+		return jsError("setSSAO: expected ({enabled, radius, strength, range})")
+	}
+	params := args[0]
+	radius := jsFloat(params, "radius", v.ssao.Radius)
+	strength := jsFloat(params, "strength", v.ssao.Strength)
+	rng := jsFloat(params, "range", v.ssao.Range)
+	switch {
+	case !(radius >= 1 && radius <= 64):
+		return jsError("setSSAO: radius must be in [1, 64]")
+	case !(strength >= 0 && strength <= 1):
+		return jsError("setSSAO: strength must be in [0, 1]")
+	case !(rng > 0):
+		return jsError("setSSAO: range must be positive")
+	}
+	v.ssao.Radius, v.ssao.Strength, v.ssao.Range = radius, strength, rng
+	if value := jsValue(params, "enabled"); value.Type() == js.TypeBoolean {
+		v.ssao.Enabled = value.Bool()
+	}
+	return v.ssaoInfo()
+}
+
+// getSSAO() returns the ambient occlusion settings as {enabled, radius,
+// strength, range}.
+func (v *Viewer) getSSAO(this js.Value, args []js.Value) interface{} {
+	return v.ssaoInfo()
+}
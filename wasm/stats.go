@@ -0,0 +1,109 @@
+// wasm/stats.go
+package main
+
+import (
+	"fmt"
+	"strings"
+	"syscall/js"
+
+	"github.com/sbecker11/webgl-point-cloud/analysis"
+)
+
+// objectStats computes the statistics of an object's visible points.
+func objectStats(o *SceneObject, params js.Value) analysis.Stats {
+	return analysis.Compute(o.VisibleCloud(), analysis.Options{
+		Samples: int(jsFloat(params, "samples", 0)),
+		Bins:    int(jsFloat(params, "bins", 0)),
+	})
+}
+
+// statsInfo describes statistics to JS.
+func statsInfo(s analysis.Stats) map[string]interface{} {
+	var counts []interface{}
+	for _, n := range s.Density.Counts {
+		counts = append(counts, n)
+	}
+	var attributes []interface{}
+	for _, a := range s.Attributes {
+		attributes = append(attributes, map[string]interface{}{
+			"name":      a.Name,
+			"component": a.Component,
+			"min":       a.Min,
+			"max":       a.Max,
+			"mean":      a.Mean,
+		})
+	}
+	return map[string]interface{}{
+		"points":        s.Points,
+		"min":           []interface{}{s.Min[0], s.Min[1], s.Min[2]},
+		"max":           []interface{}{s.Max[0], s.Max[1], s.Max[2]},
+		"meanSpacing":   s.MeanSpacing,
+		"minSpacing":    s.MinSpacing,
+		"maxSpacing":    s.MaxSpacing,
+		"densityRadius": s.DensityRadius,
+		"meanDensity":   s.MeanDensity,
+		"density": map[string]interface{}{
+			"min":    s.Density.Min,
+			"max":    s.Density.Max,
+			"counts": counts,
+		},
+		"attributes": attributes,
+	}
+}
+
+// statsText formats statistics for the panel.
+func statsText(s analysis.Stats) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "points   %d\n", s.Points)
+	fmt.Fprintf(&b, "min      %.3g %.3g %.3g\n", s.Min[0], s.Min[1], s.Min[2])
+	fmt.Fprintf(&b, "max      %.3g %.3g %.3g\n", s.Max[0], s.Max[1], s.Max[2])
+	fmt.Fprintf(&b, "spacing  %.3g (%.3g-%.3g)\n", s.MeanSpacing, s.MinSpacing, s.MaxSpacing)
+	fmt.Fprintf(&b, "density  %.3g /unit²\n", s.MeanDensity)
+	// A text sparkline of the density histogram.
+	if peak := maxCount(s.Density.Counts); peak > 0 {
+		bars := []rune("▁▂▃▄▅▆▇█")
+		b.WriteString("         ")
+		for _, n := range s.Density.Counts {
+			b.WriteRune(bars[n*(len(bars)-1)/peak])
+		}
+		fmt.Fprintf(&b, "\n         %.3g-%.3g\n", s.Density.Min, s.Density.Max)
+	}
+	for _, a := range s.Attributes {
+		fmt.Fprintf(&b, "%s[%d] %.3g-%.3g, mean %.3g\n", a.Name, a.Component, a.Min, a.Max, a.Mean)
+	}
+	return b.String()
+}
+
+func maxCount(counts []int) int {
+	peak := 0
+	for _, n := range counts {
+		peak = max(peak, n)
+	}
+	return peak
+}
+
+// getStats(name, params) returns statistics of an object's visible points:
+// {points, min, max, meanSpacing, minSpacing, maxSpacing, densityRadius,
+// meanDensity, density: {min, max, counts}, attributes: [{name, component,
+// min, max, mean}]}, or {error}.
+//
+// Spacing is the distance from a point to its nearest neighbour. Density is
+// the number of neighbours within densityRadius (four times the mean
+// spacing) per unit area, as suits scanned surfaces, binned into a
+// histogram. Both are measured at params.samples points (default 10000)
+// spread through the cloud; params.bins sets the number of histogram bins
+// (default 20).
+func (v *Viewer) getStats(this js.Value, args []js.Value) interface{} {
+	if len(args) < 1 {
+		return jsError("getStats: expected (name, params)")
+	}
+	o := v.scene.Object(args[0].String())
+	if o == nil {
+		return jsError("getStats: no object named " + args[0].String())
+	}
+	params := js.Undefined()
+	if len(args) > 1 {
+		params = args[1]
+	}
+	return js.ValueOf(statsInfo(objectStats(o, params)))
+}
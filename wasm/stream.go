@@ -0,0 +1,231 @@
+// wasm/stream.go
+package main
+
+import (
+	"fmt"
+	"syscall/js"
+	"unsafe"
+
+	"github.com/sbecker11/webgl-point-cloud/glf32"
+	"github.com/sbecker11/webgl-point-cloud/pointcloud"
+	"github.com/sbecker11/webgl-point-cloud/project"
+)
+
+// pointBuffer is a staging area in WASM linear memory that the host page
+// writes point data into directly. Go's garbage collector does not move
+// objects, so the slices stay at the same address for the buffer's life.
+type pointBuffer struct {
+	coords []float32
+	colors []uint8
+}
+
+// reconnectMs is how long live streams (ROS topics, the sensor stream)
+// wait before reconnecting after losing their server.
+const reconnectMs = 2000
+
+// pointBuffers holds the staging buffers by id. Ids are never reused.
+var (
+	pointBuffers    = map[int]*pointBuffer{}
+	nextPointBuffer = 1
+)
+
+// wasmMemory returns the ArrayBuffer backing WASM linear memory. Growing
+// the memory replaces it, detaching any views made over the old one.
+func wasmMemory() js.Value {
+	return js.Global().Get("go").Get("_inst").Get("exports").Get("mem").Get("buffer")
+}
+
+// views returns Float32Array and Uint8Array views of the buffer's slices
+// over the current WASM memory.
+func (b *pointBuffer) views() map[string]interface{} {
+	mem := wasmMemory()
+	return map[string]interface{}{
+		"positions": js.Global().Get("Float32Array").New(mem, int(uintptr(unsafe.Pointer(&b.coords[0]))), len(b.coords)),
+		"colors":    js.Global().Get("Uint8Array").New(mem, int(uintptr(unsafe.Pointer(&b.colors[0]))), len(b.colors)),
+	}
+}
+
+// createPointBuffer(capacity) allocates a staging buffer for up to capacity
+// points in WASM memory and returns {id, capacity, positions, colors}.
+// positions is a Float32Array of capacity*3 packed coordinates and colors a
+// Uint8Array of capacity*4 packed RGBA bytes, both viewing Go memory, so
+// anything the page writes into them is visible to the viewer without a
+// copy. The views are detached when WASM memory grows, which can happen
+// during any viewer call; fetch fresh ones with getPointBuffer(id) before
+// writing.
+func createPointBuffer(this js.Value, args []js.Value) interface{} {
+	if len(args) < 1 || args[0].Type() != js.TypeNumber || args[0].Int() < 1 {
+		return jsError("createPointBuffer: expected (capacity) of at least 1")
+	}
+	n := args[0].Int()
+	b := &pointBuffer{coords: make([]float32, n*3), colors: make([]uint8, n*4)}
+	id := nextPointBuffer
+	nextPointBuffer++
+	pointBuffers[id] = b
+	info := b.views()
+	info["id"] = id
+	info["capacity"] = n
+	return js.ValueOf(info)
+}
+
+// getPointBuffer(id) returns fresh {positions, colors} views of a staging
+// buffer, or {error}.
+func getPointBuffer(this js.Value, args []js.Value) interface{} {
+	if len(args) < 1 {
+		return jsError("getPointBuffer: expected (id)")
+	}
+	b, ok := pointBuffers[args[0].Int()]
+	if !ok {
+		return jsError(fmt.Sprintf("getPointBuffer: no buffer %d", args[0].Int()))
+	}
+	return js.ValueOf(b.views())
+}
+
+// commitPointBuffer(id, count, name, pose) shows the first count points of
+// a staging buffer as the named scene object. The first commit adds the
+// object, fitted to the view; later commits replace its points in place,
+// keeping its transform and style, which suits streaming sensor frames.
+// The object's coordinates share the buffer's memory, so they change with
+// the next frame written. name defaults to "stream". pose, optionally, is
+// the sensor's pose as 16 numbers in column-major order, taking the points
+// from the sensor's frame to the world's: the points are moved into the
+// world and the pose is added to the object's trajectory (see
+// getTrajectory).
+//
+// Returns {name, points} or {error}.
+func (v *Viewer) commitPointBuffer(this js.Value, args []js.Value) interface{} {
+	if len(args) < 2 {
+		return jsError("commitPointBuffer: expected (id, count, name, pose)")
+	}
+	b, ok := pointBuffers[args[0].Int()]
+	if !ok {
+		return jsError(fmt.Sprintf("commitPointBuffer: no buffer %d", args[0].Int()))
+	}
+	n := args[1].Int()
+	if n < 0 || n*3 > len(b.coords) {
+		return jsError(fmt.Sprintf("commitPointBuffer: count must be between 0 and %d", len(b.coords)/3))
+	}
+	name := "stream"
+	if len(args) > 2 && args[2].Type() == js.TypeString {
+		name = args[2].String()
+	}
+
+	colors := make([]float32, n*4)
+	for i, c := range b.colors[:n*4] {
+		colors[i] = float32(c) / 255
+	}
+	var pose glf32.Mat4
+	if len(args) > 3 && !args[3].IsUndefined() && !args[3].IsNull() {
+		var err error
+		if pose, err = jsMat4(args[3]); err != nil {
+			return jsError("commitPointBuffer: pose " + err.Error())
+		}
+	}
+	coords := b.coords[: n*3 : n*3]
+	if h := v.streamHistories[name]; h != nil && h.Mode != retainReplace && pose == nil {
+		// Frames kept for the retention must not change with the buffer.
+		coords = append([]float32(nil), coords...)
+	}
+	cloud := pointcloud.New(name, coords, colors)
+	if pose != nil {
+		v.trackPose(name, pose)
+		cloud = v.placeFrame(cloud, pose)
+	}
+	v.showFrame(cloud, project.Source{Type: "host"})
+	return js.ValueOf(map[string]interface{}{"name": name, "points": n})
+}
+
+// showFrame shows a streamed frame as the scene object named after the
+// cloud: the first frame adds the object, fitted to the view, and later
+// ones update its points under its retention (see setStreamRetention),
+// keeping its transform and style. While the page is hidden only the
+// latest frame is kept, and shown when the page shows again.
+func (v *Viewer) showFrame(cloud *pointcloud.Cloud, source project.Source) {
+	_, pending := v.pendingFrames[cloud.Name]
+	if h := v.streamHistories[cloud.Name]; h != nil {
+		if (v.scene.Object(cloud.Name) == nil && !pending) || h.Mode == retainReplace {
+			h.frames = nil
+		}
+		if h.Mode != retainReplace {
+			cloud = h.add(cloud, js.Global().Get("performance").Call("now").Float())
+		}
+	}
+	if pageHidden {
+		v.pendingFrames[cloud.Name] = pendingFrame{cloud, source}
+		return
+	}
+	v.presentFrame(cloud, source)
+}
+
+// presentFrame replaces the points of the object named after the cloud,
+// or adds it, fitted to the view. The frames an object accumulates are
+// revealed as they arrive.
+func (v *Viewer) presentFrame(cloud *pointcloud.Cloud, source project.Source) {
+	if o := v.scene.Object(cloud.Name); o != nil {
+		v.scene.SetCloud(o, cloud)
+		if h := v.streamHistories[cloud.Name]; h != nil && h.Mode != retainReplace {
+			o.chunks = h.revealChunks(v.view.Reveal)
+		}
+		return
+	}
+	o := v.scene.Add(cloud, cloud.FitTransform(2))
+	o.Source = source
+	v.emitDatasetLoaded(o)
+}
+
+// releasePointBuffer(id) frees a staging buffer. Objects committed from it
+// keep their points.
+func releasePointBuffer(this js.Value, args []js.Value) interface{} {
+	if len(args) < 1 {
+		return jsError("releasePointBuffer: expected (id)")
+	}
+	delete(pointBuffers, args[0].Int())
+	return nil
+}
+
+// benchmarkTransfer(points, iterations) times moving points*3 coordinates
+// into Go memory each way: copying a Float32Array with CopyBytesToGo as
+// addPoints does, copying one that lives in a SharedArrayBuffer the same
+// way (when the page is cross-origin isolated), and writing straight into
+// a staging buffer with TypedArray.set. iterations defaults to 20.
+//
+// Returns {points, iterations, crossOriginIsolated, copyMs, sharedCopyMs,
+// stagedMs} with the mean milliseconds per transfer; sharedCopyMs is null
+// without cross-origin isolation.
+func benchmarkTransfer(this js.Value, args []js.Value) interface{} {
+	if len(args) < 1 || args[0].Type() != js.TypeNumber || args[0].Int() < 1 {
+		return jsError("benchmarkTransfer: expected (points, iterations)")
+	}
+	n := args[0].Int()
+	iterations := 20
+	if len(args) > 1 && args[1].Type() == js.TypeNumber && args[1].Int() > 0 {
+		iterations = args[1].Int()
+	}
+	perf := js.Global().Get("performance")
+	mean := func(transfer func()) float64 {
+		start := perf.Call("now").Float()
+		for i := 0; i < iterations; i++ {
+			transfer()
+		}
+		return (perf.Call("now").Float() - start) / float64(iterations)
+	}
+
+	float32Array := js.Global().Get("Float32Array")
+	src := float32Array.New(n * 3)
+	src.Call("fill", 0.5)
+	result := map[string]interface{}{
+		"points":              n,
+		"iterations":          iterations,
+		"crossOriginIsolated": js.Global().Get("crossOriginIsolated").Truthy(),
+		"sharedCopyMs":        nil,
+	}
+	result["copyMs"] = mean(func() { jsFloat32s(src) })
+	if result["crossOriginIsolated"].(bool) {
+		shared := float32Array.New(js.Global().Get("SharedArrayBuffer").New(n * 12))
+		shared.Call("set", src)
+		result["sharedCopyMs"] = mean(func() { jsFloat32s(shared) })
+	}
+	b := &pointBuffer{coords: make([]float32, n*3), colors: make([]uint8, n*4)}
+	result["stagedMs"] = mean(func() { b.views()["positions"].(js.Value).Call("set", src) })
+	return js.ValueOf(result)
+}
@@ -0,0 +1,137 @@
+// wasm/swipe.go
+package main
+
+import (
+	"fmt"
+	"syscall/js"
+
+	"github.com/sbecker11/webgl-point-cloud/glf32"
+)
+
+// SwipeCompare splits the view between two objects at a divider the user
+// drags across the canvas: the left object is drawn only left of it and
+// the right object only right of it, so differences between two scans of
+// the same place show as the divider sweeps over them. Other objects are
+// drawn on both sides.
+type SwipeCompare struct {
+	viewer      *Viewer
+	Left, Right string  // the objects' names
+	Split       float64 // the divider's position, as a fraction of the canvas's width
+
+	divider js.Value
+}
+
+// drawPoints draws the scene's points with s's objects each on its side
+// of the divider, using scissor rectangles, and moves the divider over the
+// canvas. If either object is gone, the comparison ends.
+func (s *SwipeCompare) drawPoints(gl js.Value, shader *PointShader, viewProj glf32.Mat4, fraction float64) {
+	left, right := s.viewer.scene.Object(s.Left), s.viewer.scene.Object(s.Right)
+	if left == nil || right == nil {
+		s.remove()
+		s.viewer.swipe = nil
+		s.viewer.scene.DrawPoints(shader, viewProj, fraction)
+		return
+	}
+	width, height := s.viewer.canvasSize()
+	x := int(s.Split*float64(width) + 0.5)
+	leftVisible, rightVisible := left.Visible, right.Visible
+	gl.Call("enable", gl.Get("SCISSOR_TEST"))
+	right.Visible = false
+	gl.Call("scissor", 0, 0, x, int(height))
+	s.viewer.scene.DrawPoints(shader, viewProj, fraction)
+	right.Visible, left.Visible = rightVisible, false
+	gl.Call("scissor", x, 0, int(width)-x, int(height))
+	s.viewer.scene.DrawPoints(shader, viewProj, fraction)
+	left.Visible = leftVisible
+	gl.Call("disable", gl.Get("SCISSOR_TEST"))
+	s.place()
+}
+
+// place shows the divider over the canvas at the split, creating it on
+// first use. Dragging it moves the split.
+func (s *SwipeCompare) place() {
+	canvas := s.viewer.canvas
+	if s.divider.IsUndefined() {
+		doc := js.Global().Get("document")
+		s.divider = doc.Call("createElement", "div")
+		s.divider.Set("title", msg("swipe.divider"))
+		s.divider.Set("style", "position:fixed;width:4px;margin-left:-2px;background:rgba(255,255,255,0.85);"+
+			"box-shadow:0 0 4px rgba(0,0,0,0.6);cursor:ew-resize;touch-action:none;z-index:6")
+		s.divider.Call("addEventListener", "pointerdown", js.FuncOf(func(this js.Value, args []js.Value) interface{} {
+			s.divider.Call("setPointerCapture", args[0].Get("pointerId"))
+			return nil
+		}))
+		s.divider.Call("addEventListener", "pointermove", js.FuncOf(func(this js.Value, args []js.Value) interface{} {
+			if !s.divider.Call("hasPointerCapture", args[0].Get("pointerId")).Bool() {
+				return nil
+			}
+			rect := canvas.Call("getBoundingClientRect")
+			split := (args[0].Get("clientX").Float() - rect.Get("left").Float()) / rect.Get("width").Float()
+			s.Split = min(max(split, 0), 1)
+			requestRender()
+			return nil
+		}))
+		doc.Get("body").Call("appendChild", s.divider)
+	}
+	left, top := s.viewer.canvasOffset()
+	style := s.divider.Get("style")
+	style.Set("left", fmt.Sprintf("%.0fpx", left+s.Split*canvas.Get("clientWidth").Float()))
+	style.Set("top", fmt.Sprintf("%.0fpx", top))
+	style.Set("height", fmt.Sprintf("%.0fpx", canvas.Get("clientHeight").Float()))
+}
+
+// remove takes the divider off the page.
+func (s *SwipeCompare) remove() {
+	if !s.divider.IsUndefined() {
+		s.divider.Call("remove")
+		s.divider = js.Undefined()
+	}
+}
+
+// setSwipeCompare(left, right, params) splits the view between the objects
+// named left and right at a divider the user drags across the canvas: left
+// is drawn only left of it and right only right of it, for spotting
+// changes between two scans without toggling their visibility. params may
+// hold split, the divider's position as a fraction of the canvas's width
+// (default 0.5, or where it was). Calling it again changes the objects or
+// the split.
+//
+// Returns {left, right, split} or {error}.
+func (v *Viewer) setSwipeCompare(this js.Value, args []js.Value) interface{} {
+	if len(args) < 2 || args[0].Type() != js.TypeString || args[1].Type() != js.TypeString {
+		return jsError("setSwipeCompare: expected (left, right, params)")
+	}
+	for _, name := range []string{args[0].String(), args[1].String()} {
+		if v.scene.Object(name) == nil {
+			return jsError("setSwipeCompare: unknown object " + name)
+		}
+	}
+	if args[0].String() == args[1].String() {
+		return jsError("setSwipeCompare: left and right must differ")
+	}
+	split := 0.5
+	if v.swipe != nil {
+		split = v.swipe.Split
+	}
+	if len(args) > 2 {
+		split = float64(jsFloat(args[2], "split", float32(split)))
+	}
+	if split < 0 || split > 1 {
+		return jsError("setSwipeCompare: split must be in [0, 1]")
+	}
+	if v.swipe == nil {
+		v.swipe = &SwipeCompare{viewer: v, divider: js.Undefined()}
+	}
+	v.swipe.Left, v.swipe.Right, v.swipe.Split = args[0].String(), args[1].String(), split
+	return js.ValueOf(map[string]interface{}{"left": v.swipe.Left, "right": v.swipe.Right, "split": v.swipe.Split})
+}
+
+// stopSwipeCompare() ends the swipe comparison, drawing both objects
+// everywhere again.
+func (v *Viewer) stopSwipeCompare(this js.Value, args []js.Value) interface{} {
+	if v.swipe != nil {
+		v.swipe.remove()
+		v.swipe = nil
+	}
+	return nil
+}
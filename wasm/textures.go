@@ -0,0 +1,244 @@
+// wasm/textures.go
+package main
+
+import (
+	"fmt"
+	"syscall/js"
+)
+
+// TextureFormat is the texel format of a texture drawn into by an
+// offscreen pass.
+type TextureFormat int
+
+const (
+	// TextureRGBA8 holds four bytes per texel, available everywhere.
+	TextureRGBA8 TextureFormat = iota
+	// TextureFloat holds four 32-bit floats per texel. WebGL 1 needs the
+	// OES_texture_float extension, WebGL 2 EXT_color_buffer_float to draw
+	// into it, and both OES_texture_float_linear to filter it.
+	TextureFloat
+	// TextureDepth holds depth, for sampling the depth buffer in later
+	// passes. WebGL 1 needs the WEBGL_depth_texture extension; WebGL 2
+	// has depth textures natively. It is never filtered.
+	TextureDepth
+)
+
+// String returns the format's name, for error messages.
+func (f TextureFormat) String() string {
+	switch f {
+	case TextureFloat:
+		return "float"
+	case TextureDepth:
+		return "depth"
+	}
+	return "RGBA8"
+}
+
+// glExtension returns the named extension's object, null if the browser
+// does not support it.
+func (v *Viewer) glExtension(gl js.Value, name string) js.Value {
+	ext, ok := v.glExtensions[name]
+	if !ok {
+		ext = gl.Call("getExtension", name)
+		v.glExtensions[name] = ext
+	}
+	return ext
+}
+
+// isWebGL2 reports whether gl is a WebGL 2 context.
+func isWebGL2(gl js.Value) bool {
+	ctor := js.Global().Get("WebGL2RenderingContext")
+	return !ctor.IsUndefined() && gl.InstanceOf(ctor)
+}
+
+// textureFormatError returns why format cannot be drawn into on gl, or nil
+// if it can.
+func (v *Viewer) textureFormatError(gl js.Value, format TextureFormat) error {
+	var need string
+	switch {
+	case format == TextureFloat && isWebGL2(gl):
+		need = "EXT_color_buffer_float"
+	case format == TextureFloat:
+		need = "OES_texture_float"
+	case format == TextureDepth && !isWebGL2(gl):
+		need = "WEBGL_depth_texture"
+	default:
+		return nil
+	}
+	if v.glExtension(gl, need).IsNull() {
+		return fmt.Errorf("%s textures need %s, which this browser does not support", format, need)
+	}
+	return nil
+}
+
+// Texture is a 2D texture sized to match what draws into it.
+type Texture struct {
+	texture       js.Value
+	format        TextureFormat
+	linear        bool
+	width, height int
+}
+
+// newTexture creates a width by height texture of the given format,
+// sampled with linear filtering if linear and unfiltered otherwise, and
+// clamped at the edges, which WebGL 1 needs to sample textures of any
+// size.
+func (v *Viewer) newTexture(gl js.Value, format TextureFormat, width, height int, linear bool) (*Texture, error) {
+	if err := v.textureFormatError(gl, format); err != nil {
+		return nil, err
+	}
+	if format == TextureFloat && linear && v.glExtension(gl, "OES_texture_float_linear").IsNull() {
+		linear = false
+	}
+	t := &Texture{texture: gl.Call("createTexture"), format: format, linear: linear && format != TextureDepth}
+	filter := gl.Get("NEAREST")
+	if t.linear {
+		filter = gl.Get("LINEAR")
+	}
+	gl.Call("bindTexture", gl.Get("TEXTURE_2D"), t.texture)
+	gl.Call("texParameteri", gl.Get("TEXTURE_2D"), gl.Get("TEXTURE_MIN_FILTER"), filter)
+	gl.Call("texParameteri", gl.Get("TEXTURE_2D"), gl.Get("TEXTURE_MAG_FILTER"), filter)
+	gl.Call("texParameteri", gl.Get("TEXTURE_2D"), gl.Get("TEXTURE_WRAP_S"), gl.Get("CLAMP_TO_EDGE"))
+	gl.Call("texParameteri", gl.Get("TEXTURE_2D"), gl.Get("TEXTURE_WRAP_T"), gl.Get("CLAMP_TO_EDGE"))
+	gl.Call("bindTexture", gl.Get("TEXTURE_2D"), js.Null())
+	t.resize(gl, width, height)
+	return t, nil
+}
+
+// resize reallocates the texture at width by height texels, discarding
+// its contents. It does nothing if the size is unchanged.
+func (t *Texture) resize(gl js.Value, width, height int) {
+	if width == t.width && height == t.height {
+		return
+	}
+	t.width, t.height = width, height
+	internal, format, typ := gl.Get("RGBA"), gl.Get("RGBA"), gl.Get("UNSIGNED_BYTE")
+	switch t.format {
+	case TextureFloat:
+		typ = gl.Get("FLOAT")
+		if isWebGL2(gl) {
+			internal = gl.Get("RGBA32F")
+		}
+	case TextureDepth:
+		internal, format, typ = gl.Get("DEPTH_COMPONENT"), gl.Get("DEPTH_COMPONENT"), gl.Get("UNSIGNED_INT")
+		if isWebGL2(gl) {
+			internal = gl.Get("DEPTH_COMPONENT24")
+		}
+	}
+	gl.Call("bindTexture", gl.Get("TEXTURE_2D"), t.texture)
+	gl.Call("texImage2D", gl.Get("TEXTURE_2D"), 0, internal, width, height, 0, format, typ, js.Null())
+	gl.Call("bindTexture", gl.Get("TEXTURE_2D"), js.Null())
+}
+
+// delete frees the texture.
+func (t *Texture) delete(gl js.Value) {
+	gl.Call("deleteTexture", t.texture)
+}
+
+// DepthAttachment is how a framebuffer keeps depth while drawing.
+type DepthAttachment int
+
+const (
+	// NoDepth draws without depth testing against earlier draws.
+	NoDepth DepthAttachment = iota
+	// DepthRenderbuffer keeps depth in a renderbuffer, which cannot be
+	// sampled but needs no extension.
+	DepthRenderbuffer
+	// DepthTexture keeps depth in a TextureDepth texture, for sampling in
+	// later passes.
+	DepthTexture
+)
+
+// Framebuffer is an offscreen target: a color texture and, optionally, a
+// depth buffer, all the same size.
+type Framebuffer struct {
+	framebuffer   js.Value
+	Color         *Texture
+	Depth         *Texture // the depth texture, with DepthTexture
+	renderbuffer  js.Value // the depth renderbuffer, with DepthRenderbuffer
+	width, height int
+}
+
+// newFramebuffer creates a width by height framebuffer drawing into a
+// color texture of the given format, keeping depth as depth says. It
+// reports an error if the browser cannot draw into that combination.
+func (v *Viewer) newFramebuffer(gl js.Value, width, height int, color TextureFormat, depth DepthAttachment) (*Framebuffer, error) {
+	tex, err := v.newTexture(gl, color, width, height, false)
+	if err != nil {
+		return nil, err
+	}
+	f := &Framebuffer{framebuffer: gl.Call("createFramebuffer"), Color: tex, renderbuffer: js.Undefined(), width: width, height: height}
+	gl.Call("bindFramebuffer", gl.Get("FRAMEBUFFER"), f.framebuffer)
+	gl.Call("framebufferTexture2D", gl.Get("FRAMEBUFFER"), gl.Get("COLOR_ATTACHMENT0"), gl.Get("TEXTURE_2D"), tex.texture, 0)
+	switch depth {
+	case DepthRenderbuffer:
+		f.renderbuffer = gl.Call("createRenderbuffer")
+		gl.Call("bindRenderbuffer", gl.Get("RENDERBUFFER"), f.renderbuffer)
+		gl.Call("renderbufferStorage", gl.Get("RENDERBUFFER"), gl.Get("DEPTH_COMPONENT16"), width, height)
+		gl.Call("framebufferRenderbuffer", gl.Get("FRAMEBUFFER"), gl.Get("DEPTH_ATTACHMENT"), gl.Get("RENDERBUFFER"), f.renderbuffer)
+	case DepthTexture:
+		if f.Depth, err = v.newTexture(gl, TextureDepth, width, height, false); err == nil {
+			gl.Call("framebufferTexture2D", gl.Get("FRAMEBUFFER"), gl.Get("DEPTH_ATTACHMENT"), gl.Get("TEXTURE_2D"), f.Depth.texture, 0)
+		}
+	}
+	if err == nil {
+		if status := gl.Call("checkFramebufferStatus", gl.Get("FRAMEBUFFER")); !status.Equal(gl.Get("FRAMEBUFFER_COMPLETE")) {
+			err = fmt.Errorf("cannot draw into %s textures here (framebuffer status %d)", color, status.Int())
+		}
+	}
+	gl.Call("bindFramebuffer", gl.Get("FRAMEBUFFER"), js.Null())
+	if err != nil {
+		f.delete(gl)
+		return nil, err
+	}
+	return f, nil
+}
+
+// resize resizes the framebuffer's texture and depth buffer to width by
+// height, discarding their contents. It does nothing if the size is
+// unchanged.
+func (f *Framebuffer) resize(gl js.Value, width, height int) {
+	if width == f.width && height == f.height {
+		return
+	}
+	f.width, f.height = width, height
+	f.Color.resize(gl, width, height)
+	if f.Depth != nil {
+		f.Depth.resize(gl, width, height)
+	}
+	if !f.renderbuffer.IsUndefined() {
+		gl.Call("bindRenderbuffer", gl.Get("RENDERBUFFER"), f.renderbuffer)
+		gl.Call("renderbufferStorage", gl.Get("RENDERBUFFER"), gl.Get("DEPTH_COMPONENT16"), width, height)
+		gl.Call("bindRenderbuffer", gl.Get("RENDERBUFFER"), js.Null())
+	}
+}
+
+// bind makes the framebuffer the target of draws, covering all of it.
+func (f *Framebuffer) bind(gl js.Value) {
+	gl.Call("bindFramebuffer", gl.Get("FRAMEBUFFER"), f.framebuffer)
+	gl.Call("viewport", 0, 0, f.width, f.height)
+}
+
+// readPixels returns the RGBA bytes of the width by height rectangle with
+// its lower left corner at (x, y) of an RGBA8 framebuffer, rows bottom to
+// top. The framebuffer is left bound.
+func (f *Framebuffer) readPixels(gl js.Value, x, y, width, height int) []byte {
+	gl.Call("bindFramebuffer", gl.Get("FRAMEBUFFER"), f.framebuffer)
+	pixels := js.Global().Get("Uint8Array").New(width * height * 4)
+	gl.Call("readPixels", x, y, width, height, gl.Get("RGBA"), gl.Get("UNSIGNED_BYTE"), pixels)
+	data := make([]byte, width*height*4)
+	js.CopyBytesToGo(data, pixels)
+	return data
+}
+
+// delete frees the framebuffer, its textures and its renderbuffer.
+func (f *Framebuffer) delete(gl js.Value) {
+	gl.Call("deleteFramebuffer", f.framebuffer)
+	f.Color.delete(gl)
+	if f.Depth != nil {
+		f.Depth.delete(gl)
+	}
+	if !f.renderbuffer.IsUndefined() {
+		gl.Call("deleteRenderbuffer", f.renderbuffer)
+	}
+}
@@ -0,0 +1,118 @@
+// wasm/tools.go
+package main
+
+import (
+	"fmt"
+	"math"
+	"syscall/js"
+
+	"github.com/sbecker11/webgl-point-cloud/glf32"
+)
+
+// Tool is an interactive canvas tool. While one is active, mouse drags on
+// the canvas go to it instead of orbiting the camera. Positions are in
+// canvas pixels.
+type Tool interface {
+	Down(x, y float32)
+	Move(x, y float32)
+	Up(x, y float32)
+	// Cancel abandons the tool, clearing anything it drew.
+	Cancel()
+}
+
+// pointerShift and pointerAlt hold whether Shift and Alt were down when the
+// pointer last went down on the canvas, for tools whose drags they modify.
+var pointerShift, pointerAlt bool
+
+// setTool makes t the active tool, cancelling the previous one. A nil t
+// gives mouse drags back to the camera.
+func (v *Viewer) setTool(t Tool) {
+	if v.activeTool != nil && v.activeTool != t {
+		v.activeTool.Cancel()
+	}
+	v.activeTool = t
+	if t == nil || !placesPoints(t) {
+		v.snapping.hideIndicator()
+	}
+	cursor := "crosshair"
+	if t == nil {
+		cursor = ""
+	}
+	v.canvas.Get("style").Set("cursor", cursor)
+}
+
+// canvasPoint converts the position of mouse event e on canvas from CSS
+// pixels to canvas pixels.
+func canvasPoint(canvas, e js.Value) (x, y float32) {
+	scaleX := canvas.Get("width").Float() / canvas.Get("clientWidth").Float()
+	scaleY := canvas.Get("height").Float() / canvas.Get("clientHeight").Float()
+	return float32(e.Get("offsetX").Float() * scaleX), float32(e.Get("offsetY").Float() * scaleY)
+}
+
+// overlayContext returns the overlay's 2D context, sized to the WebGL
+// canvas in canvas pixels and cleared, creating the overlay on first use.
+func (v *Viewer) overlayContext() js.Value {
+	doc := js.Global().Get("document")
+	canvas := v.canvas
+	if v.overlay.IsUndefined() {
+		v.overlay = doc.Call("createElement", "canvas")
+		v.overlay.Set("style", "position:fixed;pointer-events:none;z-index:5")
+		doc.Get("body").Call("appendChild", v.overlay)
+	}
+	left, top := v.canvasOffset()
+	style := v.overlay.Get("style")
+	style.Set("left", fmt.Sprintf("%.0fpx", left))
+	style.Set("top", fmt.Sprintf("%.0fpx", top))
+	style.Set("width", fmt.Sprintf("%.0fpx", canvas.Get("clientWidth").Float()))
+	style.Set("height", fmt.Sprintf("%.0fpx", canvas.Get("clientHeight").Float()))
+	v.overlay.Set("width", canvas.Get("width"))
+	v.overlay.Set("height", canvas.Get("height"))
+	return v.overlay.Call("getContext", "2d")
+}
+
+// clearOverlay erases whatever tools drew on the overlay.
+func (v *Viewer) clearOverlay() {
+	if !v.overlay.IsUndefined() {
+		v.overlay.Call("getContext", "2d").Call("clearRect", 0, 0, v.overlay.Get("width"), v.overlay.Get("height"))
+	}
+}
+
+// worldAt returns the world position under canvas position (x, y) in the
+// last frame: the point it snaps to if there is one, otherwise where the
+// view ray meets the ground plane y = 0. It reports false when the ray
+// misses the ground.
+func (v *Viewer) worldAt(x, y, width, height float32) ([3]float64, bool) {
+	if v.lastViewProj == nil {
+		return [3]float64{}, false
+	}
+	if _, _, p, ok := v.snapping.snapAt(x, y, width, height); ok {
+		return p, true
+	}
+	inv, ok := glf32.Invert(v.lastViewProj)
+	if !ok {
+		return [3]float64{}, false
+	}
+	// Unproject the pixel at the near and far clip planes.
+	ndcX, ndcY := 2*float64(x)/float64(width)-1, 1-2*float64(y)/float64(height)
+	near, far := unproject(inv, ndcX, ndcY, -1), unproject(inv, ndcX, ndcY, 1)
+	dy := far[1] - near[1]
+	if dy == 0 {
+		return [3]float64{}, false
+	}
+	t := -near[1] / dy
+	if t < 0 {
+		return [3]float64{}, false
+	}
+	return [3]float64{near[0] + t*(far[0]-near[0]), 0, near[2] + t*(far[2]-near[2])}, true
+}
+
+// unproject returns the world position of normalized device coordinates
+// (x, y, z) under inv, the inverse of a view-projection matrix, or NaNs if
+// it is at infinity.
+func unproject(inv glf32.Mat4, x, y, z float64) [3]float64 {
+	p, ok := glf32.Unproject(inv, glf32.Vec3{float32(x), float32(y), float32(z)})
+	if !ok {
+		return [3]float64{math.NaN(), math.NaN(), math.NaN()}
+	}
+	return [3]float64{float64(p[0]), float64(p[1]), float64(p[2])}
+}
@@ -0,0 +1,152 @@
+// wasm/trajectory.go
+package main
+
+import (
+	"math"
+	"syscall/js"
+
+	"github.com/sbecker11/webgl-point-cloud/glf32"
+	"github.com/sbecker11/webgl-point-cloud/pointcloud"
+	"github.com/sbecker11/webgl-point-cloud/render"
+	"github.com/sbecker11/webgl-point-cloud/trajectory"
+)
+
+// Bounds of a drawn trajectory: the poses kept and the orientation glyphs
+// drawn along it.
+const (
+	maxTrajectoryPoses  = 100000
+	maxTrajectoryGlyphs = 200
+)
+
+// trajectoryColor is the color of a trajectory's path.
+var trajectoryColor = [4]float32{1, 0.85, 0.2, 1}
+
+// sensorPath is the trajectory of the sensor feeding a streamed object and
+// the buffers drawing it.
+type sensorPath struct {
+	trajectory.Trajectory
+	shown bool // whether its object has existed since it started
+	dirty bool
+	lines render.Geometry
+}
+
+// placeFrame returns a streamed frame moved from its sensor's frame into
+// the world's by the sensor's pose. The frame itself is left as it was.
+func (v *Viewer) placeFrame(cloud *pointcloud.Cloud, pose glf32.Mat4) *pointcloud.Cloud {
+	placed := *cloud
+	placed.Coords = v.transformed(cloud.Coords, pose, false)
+	if cloud.Normals != nil {
+		placed.Normals = v.transformed(cloud.Normals, pose, true)
+	}
+	return &placed
+}
+
+// trackPose adds a sensor pose to the named object's trajectory. A
+// trajectory whose object was removed starts over.
+func (v *Viewer) trackPose(name string, pose glf32.Mat4) {
+	p := v.sensorPaths[name]
+	if p == nil {
+		p = &sensorPath{Trajectory: trajectory.Trajectory{MaxPoses: maxTrajectoryPoses}, lines: render.Geometry{Primitive: render.Lines}}
+		v.sensorPaths[name] = p
+	}
+	exists := v.scene.Object(name) != nil
+	if p.shown && !exists {
+		p.Poses, p.shown = nil, false
+	}
+	p.shown = p.shown || exists
+	p.Add(pose)
+	p.dirty = true
+}
+
+// drawTrajectories draws the trajectory of each visible object that has
+// one, in the object's frame, with glyphs scaled to its bounds.
+func (v *Viewer) drawTrajectories(lineProgram js.Value, viewProj glf32.Mat4) {
+	if !v.showTrajectories {
+		return
+	}
+	stack := glf32.NewMatrixStack(viewProj)
+	for name, p := range v.sensorPaths {
+		o := v.scene.Object(name)
+		if o == nil || len(p.Poses) == 0 {
+			continue
+		}
+		visible, _, model := v.scene.Effective(o)
+		if !visible {
+			continue
+		}
+		if p.dirty {
+			p.upload(v.renderer, o.Cloud)
+		}
+		v.renderer.use(lineProgram)
+		stack.Push()
+		stack.MultMatrix(model)
+		p.lines.Draw(v.renderer, stack.Top())
+		stack.Pop()
+	}
+}
+
+// upload rebuilds the path's lines for r, with glyphs 2% of the size of
+// cloud.
+func (p *sensorPath) upload(r render.Renderer, cloud *pointcloud.Cloud) {
+	p.dirty = false
+	glyph := float32(1)
+	if cloud.Len() > 0 {
+		lo, hi := cloud.Bounds()
+		dx, dy, dz := float64(hi[0]-lo[0]), float64(hi[1]-lo[1]), float64(hi[2]-lo[2])
+		if d := math.Sqrt(dx*dx + dy*dy + dz*dz); d > 0 {
+			glyph = float32(d * 0.02)
+		}
+	}
+	positions, colors := p.Lines(trajectoryColor, glyph, maxTrajectoryGlyphs)
+	p.lines.Set(r, positions, colors)
+}
+
+// setTrajectoriesVisible(visible) shows or hides the trajectories of
+// pose-tagged streams (see commitPointBuffer, connectROS and
+// connectSensorStream): a yellow path through the sensor's positions with
+// its x, y and z axes drawn red, green and blue at up to 200 poses along
+// it. They are shown by default.
+func (v *Viewer) setTrajectoriesVisible(this js.Value, args []js.Value) interface{} {
+	if len(args) < 1 || args[0].Type() != js.TypeBoolean {
+		return jsError("setTrajectoriesVisible: expected (visible)")
+	}
+	v.showTrajectories = args[0].Bool()
+	return nil
+}
+
+// getTrajectory(name) returns the trajectory of the sensor feeding the
+// named object as {name, poses, length, pose}: the number of poses kept
+// (up to 100,000), the distance travelled along them and the latest pose
+// as 16 numbers in column-major order. Returns {error} if it has none.
+func (v *Viewer) getTrajectory(this js.Value, args []js.Value) interface{} {
+	if len(args) < 1 {
+		return jsError("getTrajectory: expected (name)")
+	}
+	p := v.sensorPaths[args[0].String()]
+	if p == nil || len(p.Poses) == 0 {
+		return jsError("getTrajectory: no trajectory for " + args[0].String())
+	}
+	latest := p.Poses[len(p.Poses)-1]
+	pose := make([]interface{}, 16)
+	for i, value := range latest {
+		pose[i] = value
+	}
+	return js.ValueOf(map[string]interface{}{
+		"name":   args[0].String(),
+		"poses":  len(p.Poses),
+		"length": p.Length(),
+		"pose":   pose,
+	})
+}
+
+// clearTrajectory(name) forgets the poses of the named object's sensor; its
+// trajectory starts again with the next one.
+func (v *Viewer) clearTrajectory(this js.Value, args []js.Value) interface{} {
+	if len(args) < 1 {
+		return jsError("clearTrajectory: expected (name)")
+	}
+	if p := v.sensorPaths[args[0].String()]; p != nil {
+		p.Poses, p.dirty = nil, true
+	}
+	return nil
+}
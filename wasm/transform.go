@@ -0,0 +1,256 @@
+// wasm/transform.go
+package main
+
+import (
+	"math"
+	"syscall/js"
+
+	"github.com/sbecker11/webgl-point-cloud/glf32"
+	"github.com/sbecker11/webgl-point-cloud/manipulator"
+	"github.com/sbecker11/webgl-point-cloud/render"
+)
+
+// transformGizmoScale is the length of the gizmo's handles as a fraction of
+// its distance from the camera, keeping it the same size on screen.
+const transformGizmoScale = 0.15
+
+// TransformTool is the tool that moves, turns and scales one object by
+// dragging the handles of a gizmo drawn over it. Drags that miss the
+// handles orbit the camera as usual. Each handle drag is one undoable
+// transform.
+type TransformTool struct {
+	viewer *Viewer
+	object *SceneObject
+	mode   manipulator.Mode
+	hover  int // axis under the mouse, or -1
+	axis   int // axis being dragged, or -1
+	orbit  bool
+	start  manipulator.Manipulator // the gizmo as the drag started
+	from   manipulator.Ray
+	before glf32.Mat4
+	lines  render.Geometry
+}
+
+// viewRay returns the view ray through canvas position (x, y) in the last
+// frame.
+func (v *Viewer) viewRay(x, y, width, height float32) (manipulator.Ray, bool) {
+	if v.lastViewProj == nil {
+		return manipulator.Ray{}, false
+	}
+	inv, ok := glf32.Invert(v.lastViewProj)
+	if !ok {
+		return manipulator.Ray{}, false
+	}
+	ndcX, ndcY := 2*float64(x)/float64(width)-1, 1-2*float64(y)/float64(height)
+	near, far := unproject(inv, ndcX, ndcY, -1), unproject(inv, ndcX, ndcY, 1)
+	return manipulator.Ray{Origin: near, Dir: [3]float64{far[0] - near[0], far[1] - near[1], far[2] - near[2]}}, true
+}
+
+// handles returns the gizmo for the object as last drawn: at the
+// center of its bounds, sized for the camera, with world axes for moving
+// and turning and the object's own axes for scaling.
+func (t *TransformTool) handles() (manipulator.Manipulator, bool) {
+	if t.viewer.lastViewProj == nil || !t.viewer.inScene(t.object) {
+		return manipulator.Manipulator{}, false
+	}
+	_, _, model := t.viewer.scene.Effective(t.object)
+	min, max := t.object.Cloud.Bounds()
+	c := glf32.TransformVertices([]float32{(min[0] + max[0]) / 2, (min[1] + max[1]) / 2, (min[2] + max[2]) / 2}, model)
+	m := manipulator.Manipulator{
+		Mode:   t.mode,
+		Center: [3]float64{float64(c[0]), float64(c[1]), float64(c[2])},
+		Axes:   [3][3]float64{{1, 0, 0}, {0, 1, 0}, {0, 0, 1}},
+	}
+	if t.mode == manipulator.Scale {
+		for i := range m.Axes {
+			col := [3]float64{float64(model[i*4]), float64(model[i*4+1]), float64(model[i*4+2])}
+			if n := math.Sqrt(col[0]*col[0] + col[1]*col[1] + col[2]*col[2]); n > 0 {
+				m.Axes[i] = [3]float64{col[0] / n, col[1] / n, col[2] / n}
+			}
+		}
+	}
+	inv, ok := glf32.Invert(t.viewer.lastViewProj)
+	if !ok {
+		return manipulator.Manipulator{}, false
+	}
+	eye := unproject(inv, 0, 0, -1)
+	d := [3]float64{m.Center[0] - eye[0], m.Center[1] - eye[1], m.Center[2] - eye[2]}
+	m.Size = transformGizmoScale * math.Sqrt(d[0]*d[0]+d[1]*d[1]+d[2]*d[2])
+	return m, m.Size > 0
+}
+
+// canvasSize returns the canvas size in canvas pixels.
+func (v *Viewer) canvasSize() (width, height float32) {
+	canvas := v.canvas
+	return float32(canvas.Get("width").Float()), float32(canvas.Get("height").Float())
+}
+
+func (t *TransformTool) Down(x, y float32) {
+	t.axis = -1
+	width, height := t.viewer.canvasSize()
+	if m, ok := t.handles(); ok {
+		if r, ok := t.viewer.viewRay(x, y, width, height); ok {
+			if axis := m.Pick(r); axis >= 0 {
+				t.axis, t.start, t.from, t.before = axis, m, r, t.object.Model
+				return
+			}
+		}
+	}
+	t.orbit = true
+	t.viewer.camera.HandleMouseDown(float64(x), float64(y))
+}
+
+func (t *TransformTool) Move(x, y float32) {
+	width, height := t.viewer.canvasSize()
+	r, ok := t.viewer.viewRay(x, y, width, height)
+	switch {
+	case t.orbit:
+		t.viewer.camera.HandleMouseMove(float64(x), float64(y))
+	case t.axis >= 0 && ok && t.viewer.inScene(t.object):
+		if delta, ok := t.start.Drag(t.axis, t.from, r); ok {
+			t.object.Model = t.worldTransform(delta)
+		}
+	case ok:
+		t.hover = -1
+		if m, ok := t.handles(); ok {
+			t.hover = m.Pick(r)
+		}
+	}
+}
+
+// worldTransform returns the object's model matrix at the start of the
+// drag with world transform delta applied after its layer's transform.
+func (t *TransformTool) worldTransform(delta glf32.Mat4) glf32.Mat4 {
+	layerModel := glf32.Identity()
+	if l := t.viewer.scene.Layers().LayerOf(t.object.Cloud.Name); l != nil {
+		_, _, layerModel = l.Effective()
+	}
+	inv, ok := glf32.Invert(layerModel)
+	if !ok {
+		return t.before
+	}
+	return glf32.MultiplyMatrices(inv, glf32.MultiplyMatrices(delta, glf32.MultiplyMatrices(layerModel, t.before)))
+}
+
+func (t *TransformTool) Up(x, y float32) {
+	if t.orbit {
+		t.orbit = false
+		t.viewer.camera.HandleMouseUp()
+		return
+	}
+	if t.axis < 0 {
+		return
+	}
+	t.axis = -1
+	if t.viewer.inScene(t.object) {
+		after := t.object.Model
+		t.object.Model = t.before
+		t.viewer.history.Execute(&transformCommand{viewer: t.viewer, object: t.object, before: t.before, after: after})
+	}
+}
+
+// Cancel abandons a drag in progress, restoring the object, and removes
+// the gizmo.
+func (t *TransformTool) Cancel() {
+	if t.axis >= 0 && t.viewer.inScene(t.object) {
+		t.object.Model = t.before
+	}
+	if t.orbit {
+		t.viewer.camera.HandleMouseUp()
+	}
+	if t.viewer.transformTool == t {
+		t.viewer.transformTool = nil
+	}
+}
+
+// draw renders the gizmo over the frame, in front of everything.
+func (t *TransformTool) draw(gl, lineProgram js.Value, viewProj glf32.Mat4) {
+	m, ok := t.handles()
+	if !ok {
+		return
+	}
+	highlight := t.hover
+	if t.axis >= 0 {
+		m, highlight = t.start, t.axis
+	}
+	positions, colors := m.Lines(highlight)
+	t.lines.Set(t.viewer.renderer, positions, colors)
+	gl.Call("disable", gl.Get("DEPTH_TEST"))
+	t.viewer.renderer.use(lineProgram)
+	t.lines.Draw(t.viewer.renderer, viewProj)
+	gl.Call("enable", gl.Get("DEPTH_TEST"))
+}
+
+// drawTransformGizmo draws the active transform tool's gizmo, if any.
+func (v *Viewer) drawTransformGizmo(gl, lineProgram js.Value, viewProj glf32.Mat4) {
+	if v.transformTool != nil && v.activeTool == v.transformTool {
+		v.transformTool.draw(gl, lineProgram, viewProj)
+	}
+}
+
+// transformObject(name, mode) shows a gizmo over an object for moving,
+// turning or scaling it by dragging, with mode "translate" (default),
+// "rotate" or "scale". Translate and rotate handles follow the world axes,
+// scale handles the object's own axes, all through the center of its
+// bounds. Dragging off the handles orbits the camera; Escape or
+// stopTransform removes the gizmo. Each drag can be undone.
+func (v *Viewer) transformObject(this js.Value, args []js.Value) interface{} {
+	if len(args) < 1 {
+		return jsError("transformObject: expected (name, mode)")
+	}
+	o := v.scene.Object(args[0].String())
+	if o == nil {
+		return jsError("transformObject: no object named " + args[0].String())
+	}
+	mode := manipulator.Translate
+	if len(args) > 1 && args[1].Type() == js.TypeString {
+		var err error
+		if mode, err = manipulator.ParseMode(args[1].String()); err != nil {
+			return jsError("transformObject: " + err.Error())
+		}
+	}
+	if v.transformTool != nil && v.activeTool == v.transformTool && v.transformTool.object == o {
+		v.transformTool.mode = mode
+		return nil
+	}
+	t := &TransformTool{viewer: v, object: o, mode: mode, hover: -1, axis: -1, lines: render.Geometry{Primitive: render.Lines}}
+	v.setTool(t)
+	v.transformTool = t
+	// Handles are grabbed, not drawn with, so keep the default cursor.
+	v.canvas.Get("style").Set("cursor", "")
+	return nil
+}
+
+// applyTransform(name) moves the named object's model matrix into its
+// points, leaving the model the identity, e.g. to make a registration
+// permanent before exporting the cloud. Normals turn with the points.
+// Large clouds are transformed on the GPU with WebGL 2. Undoable.
+//
+// Returns {name, points} or {error}.
+func (v *Viewer) applyTransform(this js.Value, args []js.Value) interface{} {
+	if len(args) < 1 || args[0].Type() != js.TypeString {
+		return jsError("applyTransform: expected (name)")
+	}
+	o := v.scene.Object(args[0].String())
+	if o == nil {
+		return jsError("applyTransform: no object named " + args[0].String())
+	}
+	baked := *o.Cloud
+	baked.Coords = v.transformed(o.Cloud.Coords, o.Model, false)
+	if o.Cloud.Normals != nil {
+		baked.Normals = normalizeVectors(v.transformed(o.Cloud.Normals, o.Model, true))
+	}
+	cmd := &bakeCommand{transform: transformCommand{viewer: v, object: o, before: o.Model, after: glf32.Identity()}}
+	cmd.cloud.add(o, &baked)
+	v.history.Execute(cmd)
+	v.emitSelectionChanged()
+	return js.ValueOf(map[string]interface{}{"name": o.Cloud.Name, "points": o.Cloud.Len()})
+}
+
+// stopTransform() removes the transform gizmo.
+func (v *Viewer) stopTransform(this js.Value, args []js.Value) interface{} {
+	if v.transformTool != nil && v.activeTool == v.transformTool {
+		v.setTool(nil)
+	}
+	return nil
+}
@@ -0,0 +1,145 @@
+// wasm/translucency.go
+package main
+
+import (
+	"fmt"
+	"slices"
+	"syscall/js"
+
+	"github.com/sbecker11/webgl-point-cloud/depthsort"
+	"github.com/sbecker11/webgl-point-cloud/glf32"
+	"github.com/sbecker11/webgl-point-cloud/subsample"
+)
+
+// TranslucencyMode is how the points of translucent objects, those with
+// an opacity below 1, blend with what is behind them.
+type TranslucencyMode int
+
+const (
+	// TranslucencyBlend blends points in the order they are stored, which
+	// costs nothing but shows points behind others drawn after them on top.
+	TranslucencyBlend TranslucencyMode = iota
+	// TranslucencySorted draws translucent objects back to front, and the
+	// points of each back to front, sorting them again whenever the view
+	// changes; see depthOrder.
+	TranslucencySorted
+	// TranslucencyAdditive adds up the points' colors, weighted by their
+	// opacity, which needs no order but brightens where points overlap.
+	TranslucencyAdditive
+	// TranslucencyWeighted blends the points with weighted blended
+	// order-independent transparency, which needs no order either; see
+	// OITPass.
+	TranslucencyWeighted
+)
+
+// translucencyNames are the modes' names, as setTranslucency takes them.
+var translucencyNames = []string{"blend", "sorted", "additive", "weighted"}
+
+func (m TranslucencyMode) String() string {
+	return translucencyNames[m]
+}
+
+// parseTranslucencyMode returns the mode with the given name.
+func parseTranslucencyMode(name string) (TranslucencyMode, error) {
+	for i, n := range translucencyNames {
+		if n == name {
+			return TranslucencyMode(i), nil
+		}
+	}
+	return 0, fmt.Errorf("unknown translucency mode %q", name)
+}
+
+// depthOrder is an object's points ordered back to front for the view they
+// were last drawn in, as an index buffer. Only the points drawn are
+// sorted: while fewer than all are, the sample of each chunk that
+// subsampleOrder would draw.
+type depthOrder struct {
+	indices  *IndexBuffer
+	mvp      glf32.Mat4 // the view sorted for
+	fraction float64    // the fraction of the points sorted
+	center   glf32.Vec3 // the center of the object's bounds
+	sample   []uint32   // subsample.Order of the points, once needed
+}
+
+// depthOrderOf returns the object's depthOrder, made on first use.
+func depthOrderOf(o *SceneObject) *depthOrder {
+	if o.depth == nil {
+		min, max := o.Cloud.Bounds()
+		o.depth = &depthOrder{center: glf32.Vec3{(min[0] + max[0]) / 2, (min[1] + max[1]) / 2, (min[2] + max[2]) / 2}}
+	}
+	return o.depth
+}
+
+// objectDepth returns the depth of the center of the object's bounds seen
+// through mvp, by which translucent objects are drawn back to front.
+func objectDepth(o *SceneObject, mvp glf32.Mat4) float32 {
+	c := depthOrderOf(o).center
+	return pointDepth(mvp, c[0], c[1], c[2])
+}
+
+// depthOrdered returns the index buffer drawing the fraction of the
+// object's points back to front seen through mvp, sorting them again if
+// the view or the fraction changed since the last time. Large clouds'
+// depths are computed on the GPU where they can be; see pointDepths.
+// Returns nil for clouds needing 32-bit indices where the browser lacks
+// them.
+func (s *Scene) depthOrdered(o *SceneObject, mvp glf32.Mat4, fraction float64) *IndexBuffer {
+	n := o.Cloud.Len()
+	if n > 0x10000 && !s.viewer.caps.ElementIndexUint {
+		return nil
+	}
+	d := depthOrderOf(o)
+	if d.indices != nil && d.fraction == fraction && slices.Equal(d.mvp, mvp) {
+		return d.indices
+	}
+	var drawn []uint32
+	coords := o.Cloud.Coords
+	if fraction >= 1 {
+		drawn = make([]uint32, n)
+		for i := range drawn {
+			drawn[i] = uint32(i)
+		}
+	} else {
+		if d.sample == nil {
+			d.sample = subsample.Order(n, 1)
+		}
+		for c := 0; c < subsample.Chunks(n); c++ {
+			first, length := subsample.Chunk(n, c)
+			drawn = append(drawn, d.sample[first:first+drawCount(length, fraction)]...)
+		}
+		coords = make([]float32, 3*len(drawn))
+		for i, p := range drawn {
+			copy(coords[3*i:3*i+3], o.Cloud.Coords[3*p:3*p+3])
+		}
+	}
+	if d.indices != nil {
+		d.indices.delete(s.gl)
+	}
+	d.indices, _ = s.viewer.createIndexBuffer(s.gl, depthsort.BackToFront(drawn, s.viewer.pointDepths(coords, mvp)))
+	d.mvp, d.fraction = append(d.mvp[:0], mvp...), fraction
+	return d.indices
+}
+
+// setTranslucency(mode) sets how the points of translucent objects, those
+// faded with setObjectStyle, blend: "blend" (the default) in the order they
+// are stored, cheap but showing points behind others on top where they
+// happen to be drawn later; "sorted" back to front, objects by the centers
+// of their bounds and the points of each by depth, sorted again whenever
+// the view changes, on the GPU with WebGL 2; "additive" adding up their
+// colors, which needs no order but brightens where points overlap;
+// "weighted" averaging their colors weighted by opacity and nearness,
+// which needs no order and keeps overlaps' brightness, close to sorting
+// without its cost, where the browser can blend into float textures and
+// else as "blend". Objects drawn through the point budget or limited to
+// draw indices are not sorted. Returns {mode} or {error}.
+func (v *Viewer) setTranslucency(this js.Value, args []js.Value) interface{} {
+	if len(args) < 1 || args[0].Type() != js.TypeString {
+		return jsError("setTranslucency: expected \"blend\", \"sorted\", \"additive\" or \"weighted\"")
+	}
+	mode, err := parseTranslucencyMode(args[0].String())
+	if err != nil {
+		return jsError("setTranslucency: " + err.Error())
+	}
+	v.view.Translucency = mode
+	return js.ValueOf(map[string]interface{}{"mode": mode.String()})
+}
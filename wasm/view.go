@@ -0,0 +1,160 @@
+// wasm/view.go
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"syscall/js"
+
+	"github.com/sbecker11/webgl-point-cloud/glf32"
+)
+
+// ViewSettings holds scene-wide display settings adjustable at runtime.
+//
+// Exaggeration stretches the scene along the vertical axis ExaggerationAxis
+// (1 for y, 2 for z) about 0, to make the relief of terrain visible. It is
+// part of the view-projection rather than of objects' coordinates, so
+// picks, snaps and measurements still give true positions; the camera
+// works in the stretched space.
+//
+// DisplayFraction is the fraction of each object's points drawn, sampled
+// evenly through it, as a density control apart from adaptive quality.
+// PointBudget, when positive, is the most points drawn each frame, shared
+// among the parts of the scene by their size on screen; see pointBudget.
+// Translucency is how translucent objects blend. Reveal, when positive, is
+// how many milliseconds arriving points take to fade in; see
+// setRevealAnimation.
+type ViewSettings struct {
+	PointSize        float32
+	DisplayFraction  float64
+	PointBudget      int
+	Translucency     TranslucencyMode
+	Reveal           float64
+	Background       [4]float32
+	ShowAxes         bool
+	ShowGrid         bool
+	Exaggeration     float32
+	ExaggerationAxis int
+}
+
+func defaultViewSettings() *ViewSettings {
+	return &ViewSettings{
+		PointSize:        2,
+		DisplayFraction:  1,
+		Background:       [4]float32{0.0, 0.1, 0.25, 1.0},
+		ShowAxes:         true,
+		ShowGrid:         true,
+		Exaggeration:     1,
+		ExaggerationAxis: 1,
+	}
+}
+
+// exaggeration returns the matrix stretching world positions by the
+// vertical exaggeration.
+func (v *ViewSettings) exaggeration() glf32.Mat4 {
+	scale := glf32.Vec3{1, 1, 1}
+	scale[v.ExaggerationAxis] = v.Exaggeration
+	return glf32.Scale(scale[0], scale[1], scale[2])
+}
+
+// exaggerate returns world position p stretched by the vertical
+// exaggeration, as the camera sees it.
+func (v *ViewSettings) exaggerate(p [3]float64) glf32.Vec3 {
+	q := glf32.Vec3{float32(p[0]), float32(p[1]), float32(p[2])}
+	q[v.ExaggerationAxis] *= v.Exaggeration
+	return q
+}
+
+// backgroundHex returns the background color as "#rrggbb".
+func (v *ViewSettings) backgroundHex() string {
+	return colorHex(v.Background)
+}
+
+// setBackgroundHex sets the background color from "#rrggbb".
+func (v *ViewSettings) setBackgroundHex(hex string) error {
+	c, err := parseColorHex(hex)
+	if err != nil {
+		return err
+	}
+	v.Background = c
+	return nil
+}
+
+// colorHex returns the RGB of c as "#rrggbb", the value of color inputs.
+func colorHex(c [4]float32) string {
+	return fmt.Sprintf("#%02x%02x%02x", int(c[0]*255+0.5), int(c[1]*255+0.5), int(c[2]*255+0.5))
+}
+
+// parseColorHex returns the opaque color "#rrggbb".
+func parseColorHex(hex string) ([4]float32, error) {
+	if len(hex) != 7 || hex[0] != '#' {
+		return [4]float32{}, fmt.Errorf("invalid color %q", hex)
+	}
+	rgb, err := strconv.ParseUint(hex[1:], 16, 32)
+	if err != nil {
+		return [4]float32{}, fmt.Errorf("invalid color %q", hex)
+	}
+	return [4]float32{float32(rgb>>16) / 255, float32(rgb>>8&0xff) / 255, float32(rgb&0xff) / 255, 1}, nil
+}
+
+// setVerticalExaggeration(factor, axis) stretches the scene along the
+// vertical axis, "y" (default) or "z" for georeferenced data, by factor
+// (1 for true scale), so that the relief of flat terrain shows. Picked,
+// snapped and measured positions stay true to the data.
+//
+// Returns {factor, axis} or {error}.
+func (v *Viewer) setVerticalExaggeration(this js.Value, args []js.Value) interface{} {
+	if len(args) < 1 || args[0].Type() != js.TypeNumber || !(args[0].Float() > 0) {
+		return jsError("setVerticalExaggeration: expected a positive factor")
+	}
+	axis := v.view.ExaggerationAxis
+	if len(args) > 1 && args[1].Type() == js.TypeString {
+		switch args[1].String() {
+		case "y":
+			axis = 1
+		case "z":
+			axis = 2
+		default:
+			return jsError("setVerticalExaggeration: axis must be \"y\" or \"z\"")
+		}
+	}
+	v.view.Exaggeration, v.view.ExaggerationAxis = float32(args[0].Float()), axis
+	return js.ValueOf(map[string]interface{}{"factor": v.view.Exaggeration, "axis": string(rune('x' + axis))})
+}
+
+// minDisplayFraction is the smallest fraction of the points that can be
+// shown.
+const minDisplayFraction = 0.01
+
+// setDisplayFraction(fraction) shows only fraction of each object's
+// points, from 0.01 to 1 (all), for thinning dense clouds at once. The
+// points shown are a random sample spread evenly through each object,
+// the same every frame, and the adaptive quality controller thins them
+// further when frames are slow. Picking, selection and export still see
+// every point. Returns {fraction} or {error}.
+func (v *Viewer) setDisplayFraction(this js.Value, args []js.Value) interface{} {
+	if len(args) < 1 || args[0].Type() != js.TypeNumber || !(args[0].Float() >= minDisplayFraction && args[0].Float() <= 1) {
+		return jsError(fmt.Sprintf("setDisplayFraction: expected a fraction from %g to 1", minDisplayFraction))
+	}
+	v.view.DisplayFraction = args[0].Float()
+	return js.ValueOf(map[string]interface{}{"fraction": v.view.DisplayFraction})
+}
+
+// maxPointBudget is the largest point budget, far more points than any
+// GPU draws in a frame.
+const maxPointBudget = 1 << 30
+
+// setPointBudget(points) draws at most points points each frame, or every
+// point if 0, to keep huge scenes interactive: each part of every object in
+// view gets a share in proportion to the area it covers on screen, so
+// near parts are drawn densely, far parts sparsely and parts out of view
+// not at all. The parts are found on first use, which takes a moment for
+// large clouds. Objects limited to draw indices are drawn as before.
+// points may be at most 2^30. Returns {points} or {error}.
+func (v *Viewer) setPointBudget(this js.Value, args []js.Value) interface{} {
+	if len(args) < 1 || args[0].Type() != js.TypeNumber || !(args[0].Float() >= 0 && args[0].Float() <= maxPointBudget) {
+		return jsError(fmt.Sprintf("setPointBudget: expected a number of points up to %d, or 0 for all", maxPointBudget))
+	}
+	v.view.PointBudget = int(args[0].Float())
+	return js.ValueOf(map[string]interface{}{"points": v.view.PointBudget})
+}
@@ -0,0 +1,454 @@
+// wasm/viewer.go
+package main
+
+import (
+	"errors"
+	"fmt"
+	"syscall/js"
+
+	"github.com/sbecker11/webgl-point-cloud/anim"
+	"github.com/sbecker11/webgl-point-cloud/crs"
+	"github.com/sbecker11/webgl-point-cloud/edit"
+	"github.com/sbecker11/webgl-point-cloud/glf32"
+	"github.com/sbecker11/webgl-point-cloud/pointcloud"
+	"github.com/sbecker11/webgl-point-cloud/procgen"
+	"github.com/sbecker11/webgl-point-cloud/project"
+	"github.com/sbecker11/webgl-point-cloud/render"
+)
+
+// Viewer draws a scene into one canvas, with its own WebGL context,
+// camera, scene and view settings, so that a page can show several, e.g.
+// two datasets side by side. The code drawing, handling the canvas's
+// events and serving the JS API works on the viewer it is given; user
+// preferences, the control panel and the JS API's listeners are shared.
+type Viewer struct {
+	ID     string // the id of its canvas
+	canvas js.Value
+	gl     js.Value
+
+	pointShader *PointShader
+	meshShader  *MeshShader
+	lineProgram js.Value
+	lineMvpLoc  js.Value
+	axes, grid  *render.Geometry
+	renderFrame js.Func
+	fillWindow  bool     // whether the canvas fills the window
+	stopped     bool     // whether its render loop stopped for the hidden page
+	api         js.Value // the object createViewer returns, once made
+
+	camera       *Camera
+	scene        *Scene
+	renderer     *WebGLRenderer
+	caps         Capabilities        // the probed capabilities
+	glExtensions map[string]js.Value // getExtension's results by name; see glExtension
+	shaders      *shaderCache
+	frameBlock   *FrameBlock
+	feedback     *Feedback // the transform feedback programs
+	// benchmarkShader is the point shader the benchmarks draw with.
+	benchmarkShader *PointShader
+
+	view         *ViewSettings
+	light        Light
+	classStyle   *ClassStyle
+	scalarStyle  *ScalarStyle
+	lastViewProj glf32.Mat4 // the view-projection of the last frame, to pick with
+	monitor      frameMonitor
+	frameClock   anim.Clock // the time between animation frames
+	onDemand     *onDemandMode
+	adaptive     *adaptiveQuality
+
+	renderGraph   *RenderGraph
+	oit           *OITPass
+	ssao          *SSAOPass
+	edl           *EDLPass
+	contactShadow *ContactShadow
+	mapPlane      *BasemapPlane
+	minimap       *Minimap
+	gizmo         *Gizmo
+	hud           *CoordinateHUD
+	depthPicker   *DepthPicker
+	swipe         *SwipeCompare // the swipe comparison, or nil
+	histogram     *HistogramWidget
+	profileView   *ProfileView
+	contextMenu   *ContextMenu
+
+	history         *edit.History // the edits, for undo and redo
+	namedSelections []project.Selection
+	labeling        bool // whether the number keys label the selected points
+	// sceneFrame is the frame the scene's georeferenced objects are placed
+	// in; see georeference.
+	sceneFrame crs.Frame
+
+	activeTool    Tool     // the tool mouse drags go to, or nil for the camera
+	overlay       js.Value // a 2D canvas over the canvas that tools draw on
+	transformTool *TransformTool
+	snapping      *Snapper
+	flythrough    *Flythrough
+	recording     *Recorder // the recording in progress, or nil
+
+	// pendingObjects holds the settings of objects in a loaded project
+	// whose points could not be regenerated, by name; see loadProject.
+	pendingObjects map[string]project.Object
+	// pendingScreenshots are called with the canvas after the next frame
+	// is drawn, in the same task, before the browser clears it.
+	pendingScreenshots []func(canvas js.Value)
+	meshLabels         map[string][]meshLabel // the labels of each mesh by name
+
+	rosSubscriptions map[string]*ROSSubscription // by object name
+	sensorStream     *SensorStream               // the open stream, or nil
+	pendingFrames    map[string]pendingFrame     // by object name, shown when the page shows
+	poseTracks       map[string]*poseTrack       // by object name
+	streamHistories  map[string]*streamHistory   // by object name
+	sensorPaths      map[string]*sensorPath      // the trajectories by object name
+	showTrajectories bool                        // whether the trajectories are drawn
+}
+
+// viewers holds the viewers by canvas id.
+var viewers = map[string]*Viewer{}
+
+// mainViewer is the viewer of the page's "canvas", made at startup. The
+// URL's parameters and fragment are its.
+var mainViewer *Viewer
+
+// currentViewer is the viewer the JS API's global functions, the keyboard
+// and the control panel address: the one the pointer was last over, or the
+// one chosen with useViewer.
+var currentViewer *Viewer
+
+// makeCurrent makes v the viewer the JS API's global functions address.
+func (v *Viewer) makeCurrent() {
+	currentViewer = v
+}
+
+// viewerError is an error setting up a viewer, with the key of the
+// message to show it under.
+type viewerError struct {
+	key string
+	err error
+}
+
+func (e *viewerError) Error() string { return e.err.Error() }
+
+// newViewer sets up a viewer drawing into canvas, with an empty scene and
+// every setting at its default. If fillWindow is set the canvas is sized to the window,
+// otherwise its drawing buffer follows the size the page gives it. If
+// webgl2 is set it draws with WebGL 2 where the browser has it. Its render
+// loop starts with start.
+func newViewer(canvas js.Value, fillWindow, webgl2 bool) (*Viewer, error) {
+	gl := js.Null()
+	if webgl2 {
+		gl = canvas.Call("getContext", "webgl2")
+	}
+	if gl.IsUndefined() || gl.IsNull() {
+		gl = canvas.Call("getContext", "webgl")
+	}
+	if gl.IsUndefined() || gl.IsNull() {
+		return nil, &viewerError{"error.webgl", errors.New("this browser or device could not create a WebGL context")}
+	}
+	v := &Viewer{
+		ID:               canvas.Get("id").String(),
+		canvas:           canvas,
+		gl:               gl,
+		fillWindow:       fillWindow,
+		glExtensions:     map[string]js.Value{},
+		shaders:          newShaderCache(),
+		frameBlock:       newFrameBlock(),
+		view:             defaultViewSettings(),
+		light:            defaultLight(),
+		classStyle:       newClassStyle(),
+		adaptive:         newAdaptiveQuality(),
+		history:          edit.NewHistory(maxUndo),
+		overlay:          js.Undefined(),
+		pendingObjects:   map[string]project.Object{},
+		meshLabels:       map[string][]meshLabel{},
+		rosSubscriptions: map[string]*ROSSubscription{},
+		pendingFrames:    map[string]pendingFrame{},
+		poseTracks:       map[string]*poseTrack{},
+		streamHistories:  map[string]*streamHistory{},
+		sensorPaths:      map[string]*sensorPath{},
+		showTrajectories: true,
+	}
+	v.scalarStyle = newScalarStyle(v)
+	v.feedback = &Feedback{viewer: v}
+	v.onDemand = newOnDemandMode(v)
+	v.renderGraph = newRenderGraph(v)
+	v.oit = newOITPass(v)
+	v.ssao = newSSAOPass(v)
+	v.edl = newEDLPass(v)
+	v.contactShadow = newContactShadow(v)
+	v.mapPlane = newBasemapPlane(v)
+	v.minimap = newMinimap(v)
+	v.gizmo = newGizmo(v)
+	v.hud = newCoordinateHUD(v)
+	v.depthPicker = &DepthPicker{viewer: v}
+	v.contextMenu = &ContextMenu{viewer: v, root: js.Undefined()}
+	v.snapping = newSnapper(v)
+	v.flythrough = &Flythrough{viewer: v}
+
+	v.caps = v.probeCapabilities(gl)
+	v.renderer = newWebGLRenderer(gl)
+	v.camera = NewCamera(3.0)
+	v.scene = NewScene(v)
+	v.oit.register(v.renderGraph)
+	v.ssao.register(v.renderGraph)
+	v.edl.register(v.renderGraph)
+
+	gl.Call("enable", gl.Get("DEPTH_TEST"))
+	gl.Call("enable", gl.Get("BLEND"))
+	gl.Call("blendFunc", gl.Get("SRC_ALPHA"), gl.Get("ONE_MINUS_SRC_ALPHA"))
+
+	var err error
+	if v.pointShader, err = v.setupPointShaders(gl); err != nil {
+		return nil, &viewerError{"error.pointShader", err}
+	}
+	if v.meshShader, err = setupMeshShader(gl); err != nil {
+		return nil, &viewerError{"error.meshShader", err}
+	}
+	if v.lineProgram, v.lineMvpLoc, err = setupLineShaders(gl); err != nil {
+		return nil, &viewerError{"error.lineShader", err}
+	}
+	v.benchmarkShader = v.pointShader
+
+	axisCoords, axisColors := render.Axes(1.5)
+	gridCoords, gridColors := render.Grid(1.5, 10)
+	v.axes, v.grid = &render.Geometry{Primitive: render.Lines}, &render.Geometry{Primitive: render.Lines}
+	v.axes.Set(v.renderer, axisCoords, axisColors)
+	v.grid.Set(v.renderer, gridCoords, gridColors)
+
+	// Pointing at the viewer makes it current.
+	for _, event := range []string{"pointerenter", "pointerdown", "pointermove", "wheel", "contextmenu", "dblclick"} {
+		canvas.Call("addEventListener", event, js.FuncOf(func(this js.Value, args []js.Value) interface{} {
+			v.makeCurrent()
+			return nil
+		}))
+	}
+	v.setupEventHandlers()
+	v.setupPickHandler()
+	v.setupHUD()
+	js.Global().Call("addEventListener", "resize", js.FuncOf(func(this js.Value, args []js.Value) interface{} {
+		v.resize()
+		return nil
+	}))
+	v.resize()
+
+	v.renderFrame = js.FuncOf(func(this js.Value, args []js.Value) interface{} {
+		v.frame(args[0].Float())
+		return nil
+	})
+	viewers[v.ID] = v
+	return v, nil
+}
+
+// resize sizes the canvas's drawing buffer and the viewport to the window
+// or to the canvas's size on the page.
+func (v *Viewer) resize() {
+	width, height := js.Global().Get("innerWidth").Float(), js.Global().Get("innerHeight").Float()
+	if !v.fillWindow {
+		width, height = v.canvas.Get("clientWidth").Float(), v.canvas.Get("clientHeight").Float()
+	}
+	v.canvas.Set("width", width)
+	v.canvas.Set("height", height)
+	v.gl.Call("viewport", 0, 0, width, height)
+}
+
+// start starts v's render loop.
+func (v *Viewer) start() {
+	js.Global().Call("requestAnimationFrame", v.renderFrame)
+}
+
+// addDataset adds a procedural dataset's cloud to v's scene, recording where it came from.
+func (v *Viewer) addDataset(cloud *pointcloud.Cloud, dataset string, seed int64, points int) *SceneObject {
+	o := v.scene.Add(cloud, glf32.Identity())
+	o.Source = project.Source{Type: "dataset", Dataset: dataset, Seed: seed, Points: points}
+	return o
+}
+
+// frame updates v's animations and draws its frame at animation frame
+// time now, then asks for the next.
+func (v *Viewer) frame(now float64) {
+	if pageHidden {
+		v.stopped = true
+		return
+	}
+	gl, canvas := v.gl, v.canvas
+	v.camera.ApplyInertia(v.frameClock.Tick(now))
+	if l := cameraLinks[v]; l != nil {
+		l.share(v)
+	}
+	v.flythrough.update(now)
+	v.updateRetention(now)
+	v.updatePoses(now)
+	if v == mainViewer {
+		v.updateViewLink(now)
+	}
+	if !v.onDemand.shouldDraw(now) {
+		// Idle time is not frame time.
+		v.adaptive.lastFrame = 0
+		v.start()
+		return
+	}
+	v.adaptive.frame(now)
+	level := v.adaptive.level()
+	aspect := float32(canvas.Get("width").Float() / canvas.Get("height").Float())
+	projMatrix, err := glf32.PerspectiveChecked(45.0, aspect, nearPlane, farPlane)
+	if err != nil {
+		// A collapsed canvas has no aspect ratio; wait for it to open.
+		v.start()
+		return
+	}
+	viewMatrix := v.camera.GetViewMatrix()
+	mvpMatrix := glf32.MultiplyMatrices(glf32.MultiplyMatrices(projMatrix, viewMatrix), v.view.exaggeration())
+	if v.caps.WebGL2 {
+		v.frameBlock.update(gl, mvpMatrix, viewMatrix, projMatrix, v.camera, &v.light)
+	}
+
+	bg := v.view.Background
+	gl.Call("clearColor", bg[0], bg[1], bg[2], bg[3])
+	gl.Call("clear", gl.Get("COLOR_BUFFER_BIT").Int()|gl.Get("DEPTH_BUFFER_BIT").Int())
+
+	v.renderer.use(v.lineProgram)
+	gl.Call("enableVertexAttribArray", attribPosition)
+	gl.Call("enableVertexAttribArray", attribColor)
+	if v.view.ShowGrid {
+		v.grid.Draw(v.renderer, mvpMatrix)
+	}
+	if v.view.ShowAxes {
+		v.axes.Draw(v.renderer, mvpMatrix)
+	}
+
+	v.mapPlane.draw(gl, mvpMatrix)
+	v.contactShadow.draw(gl, mvpMatrix)
+	v.scene.DrawMeshes(v.meshShader, v.lineProgram, v.lineMvpLoc, mvpMatrix)
+
+	pointShader, err := v.shaders.point(gl, "point.frag", v.pointFeatures())
+	if err != nil {
+		v.notice(msgf("notice.shaderVariant", err))
+		pointShader = v.pointShader
+	}
+	size := v.pointSize(v.view.PointSize * level.pointScale)
+	v.usePointShader(gl, pointShader, size)
+	switch {
+	case v.swipe != nil:
+		v.swipe.drawPoints(gl, pointShader, mvpMatrix, level.pointFraction)
+	case v.oit.active():
+		// The OIT pass draws the translucent points.
+		v.scene.drawPoints(pointShader, mvpMatrix, level.pointFraction, opaquePoints)
+	default:
+		v.scene.DrawPoints(pointShader, mvpMatrix, level.pointFraction)
+	}
+	v.renderGraph.run(gl, &frameState{viewProj: mvpMatrix, pointSize: size, fraction: level.pointFraction})
+	v.lastViewProj = mvpMatrix
+	v.updateLabels()
+	if t, ok := v.activeTool.(*MeasureTool); ok {
+		t.draw()
+	}
+	v.drawTrajectories(v.lineProgram, mvpMatrix)
+	v.drawTransformGizmo(gl, v.lineProgram, mvpMatrix)
+	v.minimap.draw(gl, pointShader, v.lineProgram, mvpMatrix, level.pointFraction)
+	v.gizmo.draw(gl, v.lineProgram, v.lineMvpLoc)
+	if v == currentViewer {
+		if controlPanel != nil {
+			controlPanel.refresh()
+		}
+		if v.histogram != nil {
+			v.histogram.refresh()
+		}
+	}
+	v.hud.update(now)
+	v.monitor.frameDone(v)
+	v.flythrough.frameDone(canvas)
+	v.takeScreenshots(canvas)
+
+	v.start()
+}
+
+// canvasOffset returns the top-left corner of v's canvas in the page's
+// viewport, where the overlays drawn over it start.
+func (v *Viewer) canvasOffset() (left, top float64) {
+	rect := v.canvas.Call("getBoundingClientRect")
+	return rect.Get("left").Float(), rect.Get("top").Float()
+}
+
+// handle returns the object createViewer returns for v: its id and every
+// function of the JS API, working on v.
+func (v *Viewer) handle() js.Value {
+	if !v.api.IsUndefined() {
+		return v.api
+	}
+	v.api = js.Global().Get("Object").New()
+	v.api.Set("id", v.ID)
+	for name, fn := range apiFuncs {
+		if name == "createViewer" || name == "useViewer" {
+			continue
+		}
+		v.api.Set(name, apiFunc(name, fn, v))
+	}
+	return v.api
+}
+
+// createViewer(canvasId, params) starts another viewer in the canvas with
+// id canvasId, with its own camera, scene and settings, e.g. to compare
+// two datasets side by side. params may hold dataset (default
+// "clusters"), points (default 5000), seed (default 1), color, the color
+// mode, and webgl2 (true to draw with WebGL 2 where the browser has it, as
+// ?webgl2=1 does for the page's viewer). The viewer is drawn until the page closes; calling
+// createViewer again for its canvas returns it as it is.
+//
+// Returns an object with the viewer's id and every function of the JS API
+// working on that viewer, or {error}. Work a function finishes later, such
+// as an import, goes to the viewer it was called on.
+func createViewer(this js.Value, args []js.Value) interface{} {
+	if len(args) < 1 || args[0].Type() != js.TypeString {
+		return jsError("createViewer: expected (canvasId, params)")
+	}
+	id := args[0].String()
+	if v, ok := viewers[id]; ok {
+		return v.handle()
+	}
+	canvas := js.Global().Get("document").Call("getElementById", id)
+	if canvas.IsNull() || canvas.Get("tagName").String() != "CANVAS" {
+		return jsError("createViewer: no canvas with id " + id)
+	}
+	params := js.Undefined()
+	if len(args) > 1 {
+		params = args[1]
+	}
+	mode := currentViewer.classStyle.Mode
+	if s := jsString(params, "color", ""); s != "" {
+		var err error
+		if mode, err = parseColorMode(s); err != nil {
+			return jsError("createViewer: " + err.Error())
+		}
+	}
+	dataset := jsString(params, "dataset", "clusters")
+	points := int(jsFloat(params, "points", 5000))
+	seed := int64(jsFloat(params, "seed", 1))
+	cloud, err := procgen.New(seed).Dataset(dataset, points)
+	if err != nil {
+		return jsError("createViewer: " + err.Error())
+	}
+
+	v, err := newViewer(canvas, false, jsValue(params, "webgl2").Equal(js.ValueOf(true)))
+	if err != nil {
+		return jsError(fmt.Sprintf("createViewer: %v", err))
+	}
+	v.classStyle.Mode = mode
+	v.emitDatasetLoaded(v.addDataset(cloud, dataset, seed, points))
+	v.start()
+	return v.handle()
+}
+
+// useViewer(canvasId) makes the viewer in that canvas the one the JS API's
+// functions, the keyboard and the control panel address, as pointing at
+// it does. Returns {id} or {error}.
+func useViewer(this js.Value, args []js.Value) interface{} {
+	if len(args) < 1 || args[0].Type() != js.TypeString {
+		return jsError("useViewer: expected (canvasId)")
+	}
+	v, ok := viewers[args[0].String()]
+	if !ok {
+		return jsError("useViewer: no viewer in canvas " + args[0].String())
+	}
+	v.makeCurrent()
+	return js.ValueOf(map[string]interface{}{"id": v.ID})
+}
@@ -0,0 +1,95 @@
+// wasm/viewlink.go
+package main
+
+import (
+	"syscall/js"
+
+	"github.com/sbecker11/webgl-point-cloud/glf32"
+	"github.com/sbecker11/webgl-point-cloud/viewlink"
+)
+
+// viewLinkIntervalMs is the least time between rewrites of the URL's
+// fragment; browsers limit how often history entries may be replaced.
+const viewLinkIntervalMs = 500
+
+// viewLink is the state of the URL's fragment, kept in step with the view
+// so the address bar always holds a link to it.
+var viewLink struct {
+	enabled   bool
+	written   string  // the fragment last written, without '#'
+	lastWrite float64 // when it was written, in ms
+}
+
+// currentView returns the view as a link holds it: the procedural dataset
+// first shown, if it is still in the scene, the color mode and the camera.
+func (v *Viewer) currentView() viewlink.View {
+	link := viewlink.View{Color: colorModeName(v.classStyle.Mode), HasCamera: true}
+	for _, o := range v.scene.Objects() {
+		if o.Source.Type == "dataset" {
+			link.Dataset, link.Points, link.Seed = o.Source.Dataset, o.Source.Points, o.Source.Seed
+			break
+		}
+	}
+	position := v.camera.Position()
+	copy(link.Position[:], position)
+	copy(link.Target[:], v.camera.target)
+	return link
+}
+
+// applyView moves the camera to a link's view and sets its color mode.
+// Its data is chosen at startup.
+func (v *Viewer) applyView(link viewlink.View) {
+	if mode, err := parseColorMode(link.Color); err == nil {
+		v.classStyle.Mode = mode
+	}
+	if link.HasCamera {
+		v.camera.SetPose(
+			glf32.Vec3{link.Position[0], link.Position[1], link.Position[2]},
+			glf32.Vec3{link.Target[0], link.Target[1], link.Target[2]})
+	}
+}
+
+// setupViewLink keeps the fragment in step with the view if enabled, and
+// follows edits of it: a link to other data reloads the page, and one to
+// the same data moves the camera.
+func (v *Viewer) setupViewLink(enabled bool) {
+	viewLink.enabled = enabled
+	js.Global().Call("addEventListener", "hashchange", js.FuncOf(func(this js.Value, args []js.Value) interface{} {
+		link, err := viewlink.Parse(js.Global().Get("location").Get("hash").String())
+		if err != nil {
+			js.Global().Get("console").Call("warn", "Ignoring invalid view link: "+err.Error())
+			return nil
+		}
+		current := v.currentView()
+		if link.Dataset != "" && (link.Dataset != current.Dataset || link.Points != current.Points || link.Seed != current.Seed) {
+			js.Global().Get("location").Call("reload")
+			return nil
+		}
+		v.applyView(link)
+		requestRender()
+		return nil
+	}))
+}
+
+// updateViewLink rewrites the fragment if the view changed, replacing the
+// page's history entry rather than adding one. It is called every
+// animation frame.
+func (v *Viewer) updateViewLink(now float64) {
+	if !viewLink.enabled || now-viewLink.lastWrite < viewLinkIntervalMs {
+		return
+	}
+	s := v.currentView().Encode()
+	if s == viewLink.written {
+		return
+	}
+	viewLink.written, viewLink.lastWrite = s, now
+	js.Global().Get("history").Call("replaceState", js.Null(), "", "#"+s)
+}
+
+// getViewLink() returns a URL that opens the viewer on the current view:
+// the same procedural dataset, camera and color mode.
+func (v *Viewer) getViewLink(this js.Value, args []js.Value) interface{} {
+	location := js.Global().Get("location")
+	return location.Get("origin").String() + location.Get("pathname").String() +
+		location.Get("search").String() + "#" + v.currentView().Encode()
+}
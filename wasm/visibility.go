@@ -0,0 +1,69 @@
+// wasm/visibility.go
+package main
+
+import (
+	"syscall/js"
+
+	"github.com/sbecker11/webgl-point-cloud/pointcloud"
+	"github.com/sbecker11/webgl-point-cloud/project"
+)
+
+// pageHidden is whether the page is hidden, e.g. in a background tab or a
+// minimized window. While it is, the render loop stops and streamed frames
+// wait to be uploaded, so the viewer uses no GPU and little battery.
+var pageHidden bool
+
+// hiddenAt is when the page was last hidden, in ms of performance.now().
+var hiddenAt float64
+
+// pendingFrame is the latest frame streamed to an object while the page
+// was hidden.
+type pendingFrame struct {
+	cloud  *pointcloud.Cloud
+	source project.Source
+}
+
+// setupVisibility pauses the viewers while the page is hidden and, when it
+// shows again, shows the latest streamed frames and restarts the render
+// loops that stopped.
+func setupVisibility() {
+	doc := js.Global().Get("document")
+	pageHidden = doc.Get("hidden").Bool()
+	doc.Call("addEventListener", "visibilitychange", js.FuncOf(func(this js.Value, args []js.Value) interface{} {
+		now := js.Global().Get("performance").Call("now").Float()
+		if doc.Get("hidden").Bool() {
+			if !pageHidden {
+				pageHidden, hiddenAt = true, now
+			}
+			return nil
+		}
+		if !pageHidden {
+			return nil
+		}
+		pageHidden = false
+		requestRender()
+		for _, v := range viewers {
+			v.resumeClocks(now - hiddenAt)
+			for name, f := range v.pendingFrames {
+				v.presentFrame(f.cloud, f.source)
+				delete(v.pendingFrames, name)
+			}
+			if v.stopped {
+				v.stopped = false
+				v.start()
+			}
+		}
+		return nil
+	}))
+}
+
+// resumeClocks keeps the time the page was hidden, in ms, out of the
+// frame timings and the flythrough's playback of v.
+func (v *Viewer) resumeClocks(hidden float64) {
+	v.adaptive.lastFrame = 0
+	v.frameClock.Reset()
+	v.monitor.start, v.monitor.frames = 0, 0
+	if v.flythrough.playing && v.flythrough.start >= 0 {
+		v.flythrough.start += hidden
+	}
+}
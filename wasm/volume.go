@@ -0,0 +1,121 @@
+// wasm/volume.go
+package main
+
+import (
+	"syscall/js"
+
+	"github.com/sbecker11/webgl-point-cloud/glf32"
+	"github.com/sbecker11/webgl-point-cloud/mesh"
+	"github.com/sbecker11/webgl-point-cloud/volume"
+)
+
+// vec3Param reads an [x, y, z] parameter as float64s, or def if absent.
+func vec3Param(params js.Value, name string, def [3]float64) ([3]float64, error) {
+	v := jsValue(params, name)
+	if v.IsUndefined() {
+		return def, nil
+	}
+	p, err := jsVec3(v)
+	if err != nil {
+		return def, err
+	}
+	return [3]float64{float64(p[0]), float64(p[1]), float64(p[2])}, nil
+}
+
+// estimateVolume(name, params) estimates the volume between an object's
+// selected points, or its visible points when none are selected, and a
+// base plane, and shows the plane as a translucent mesh named name +
+// "-base" unless params.showBase is false.
+//
+// params may hold:
+//   - base: "rim" (default) fits the plane to the lowest points around the
+//     edge of the points' footprint, the toe of a stockpile; "fit" fits it
+//     to all the points; {point, normal} gives it, such as a design level.
+//   - up: the direction heights are measured along, default [0, 1, 0].
+//   - cellSize: the grid cell size, by default about four points per cell.
+//   - units: the name of the coordinate unit, such as "m", which labels
+//     the result.
+//
+// Returns {cut, fill, net, area, cells, cellSize, base: {point, normal},
+// units, areaUnits, volumeUnits} in the object's own coordinates, or
+// {error}. Cut is the volume above the plane and fill the volume below it.
+func (v *Viewer) estimateVolume(this js.Value, args []js.Value) interface{} {
+	if len(args) < 1 {
+		return jsError("estimateVolume: expected (name, params)")
+	}
+	o := v.scene.Object(args[0].String())
+	if o == nil {
+		return jsError("estimateVolume: no object named " + args[0].String())
+	}
+	params := js.Undefined()
+	if len(args) > 1 {
+		params = args[1]
+	}
+	up, err := vec3Param(params, "up", [3]float64{0, 1, 0})
+	if err != nil {
+		return jsError("estimateVolume: up: " + err.Error())
+	}
+	coords := measuredCoords(o)
+	cellSize := float64(jsFloat(params, "cellSize", 0))
+	if cellSize <= 0 {
+		cellSize = volume.AutoCellSize(coords, up)
+	}
+
+	var base volume.Plane
+	switch b := jsValue(params, "base"); {
+	case b.IsUndefined() || b.String() == "rim":
+		base, err = volume.RimPlane(coords, up, cellSize)
+	case b.String() == "fit":
+		base, err = volume.FitPlane(coords, up)
+	case b.Type() == js.TypeObject:
+		var point, normal [3]float64
+		if point, err = vec3Param(b, "point", [3]float64{}); err == nil {
+			if normal, err = vec3Param(b, "normal", up); err == nil {
+				base, err = volume.NewPlane(point, normal)
+			}
+		}
+	default:
+		return jsError(`estimateVolume: base must be "rim", "fit" or {point, normal}`)
+	}
+	if err != nil {
+		return jsError("estimateVolume: base: " + err.Error())
+	}
+	r, err := volume.AbovePlane(coords, base, cellSize)
+	if err != nil {
+		return jsError("estimateVolume: " + err.Error())
+	}
+
+	if jsValue(params, "showBase").IsUndefined() || jsValue(params, "showBase").Truthy() {
+		quad := &mesh.Mesh{Indices: []uint32{0, 1, 2, 0, 2, 3}}
+		for _, c := range r.Corners {
+			quad.Positions = append(quad.Positions, float32(c[0]), float32(c[1]), float32(c[2]))
+		}
+		sm, err := v.scene.AddMesh(o.Cloud.Name+"-base", quad, append(glf32.Mat4(nil), o.Model...))
+		if err != nil {
+			return jsError("estimateVolume: " + err.Error())
+		}
+		sm.Color = [4]float32{0.2, 0.6, 1, 0.35}
+		sm.Wireframe = true
+		sm.WireColor = [4]float32{0.2, 0.6, 1, 1}
+	}
+
+	units := jsString(params, "units", "")
+	result := map[string]interface{}{
+		"cut":      r.Cut,
+		"fill":     r.Fill,
+		"net":      r.Net(),
+		"area":     r.Area,
+		"cells":    r.Cells,
+		"cellSize": r.CellSize,
+		"base": map[string]interface{}{
+			"point":  []interface{}{base.Point[0], base.Point[1], base.Point[2]},
+			"normal": []interface{}{base.Normal[0], base.Normal[1], base.Normal[2]},
+		},
+		"units": units,
+	}
+	if units != "" {
+		result["areaUnits"] = units + "²"
+		result["volumeUnits"] = units + "³"
+	}
+	return js.ValueOf(result)
+}
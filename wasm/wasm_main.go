@@ -2,17 +2,25 @@
 package main
 
 import (
+	"errors"
 	"fmt"
-	"math/rand"
 	"syscall/js"
-	"time"
 
-	"github.com/sbecker11/webgl-point-cloud/glf32"
+	"github.com/sbecker11/webgl-point-cloud/demo"
+	"github.com/sbecker11/webgl-point-cloud/procgen"
 )
 
-var camera *Camera
+// The near and far clip distances of the camera's projection.
+const (
+	nearPlane = 0.1
+	farPlane  = 100.0
+)
 
 func main() {
+	if isWorker() {
+		runImportWorker()
+		<-make(chan bool)
+	}
 	js.Global().Call("setTimeout", js.FuncOf(func(this js.Value, args []js.Value) interface{} {
 		go mainLogic()
 		return nil
@@ -21,95 +29,159 @@ func main() {
 }
 
 func mainLogic() {
-	rand.Seed(time.Now().UnixNano())
-	js.Global().Get("console").Call("log", "WASM module started")
-
-	canvas := js.Global().Get("document").Call("getElementById", "canvas")
-	gl := canvas.Call("getContext", "webgl")
-	if gl.IsUndefined() {
-		js.Global().Call("alert", "WebGL not supported")
-		return
+	defer func() {
+		if r := recover(); r != nil {
+			startupFailed(msg("error.start"), fmt.Errorf("%v", r))
+		}
+	}()
+	config := loadConfig()
+	if config.Messages != "" {
+		if c, err := fetchMessages(config.Messages, ""); err == nil {
+			useMessages(c)
+		} else {
+			currentViewer.notice(msgf("notice.messagesBad", err))
+		}
 	}
+	js.Global().Get("console").Call("log", fmt.Sprintf("WASM module started (seed=%d)", config.Seed))
+	setLoading(startupLoad, msg("loading.starting"), -1, -1, -1)
 
-	gl.Call("enable", gl.Get("DEPTH_TEST"))
-	gl.Call("enable", gl.Get("BLEND"))
-	gl.Call("blendFunc", gl.Get("SRC_ALPHA"), gl.Get("ONE_MINUS_SRC_ALPHA"))
-	gl.Call("clearColor", 0.0, 0.1, 0.25, 1.0)
-
-	camera = NewCamera(3.0)
-	setupEventHandlers(canvas, gl, camera)
-
-	pointProgram, pointMvpLoc, posLoc, colorLoc, err := setupPointShaders(gl)
+	loadControlSettings()
+	v, err := newViewer(js.Global().Get("document").Call("getElementById", "canvas"), true, config.WebGL2)
 	if err != nil {
-		js.Global().Get("console").Call("error", "Point shader setup error: "+err.Error())
+		var ve *viewerError
+		if errors.As(err, &ve) {
+			startupFailed(msg(ve.key), ve.err)
+		} else {
+			startupFailed(msg("error.start"), err)
+		}
 		return
 	}
-	lineProgram, lineMvpLoc, err := setupLineShaders(gl)
+	mainViewer = v
+	v.makeCurrent()
+	positionStorage = config.Positions
+
+	setLoading(startupLoad, msgf("loading.dataset", config.Dataset), -1, -1, -1)
+	generator := procgen.New(config.Seed)
+	cloud, err := generator.Dataset(config.Dataset, config.NumPoints)
 	if err != nil {
-		js.Global().Get("console").Call("error", "Line shader setup error: "+err.Error())
+		startupFailed(msg("error.dataset"), err)
 		return
 	}
+	obj := v.addDataset(cloud, config.Dataset, config.Seed, config.NumPoints)
+	if config.Filter != "" {
+		if err := v.setSceneFilter(config.Filter); err != nil {
+			js.Global().Get("console").Call("warn", "Ignoring invalid filter: "+err.Error())
+		}
+	}
+	v.classStyle.Mode = config.ColorMode
+	if d, ok := demo.Lookup(config.Demo); ok {
+		v.applyDemo(d)
+	}
+	v.applyView(config.View)
+	v.view.PointBudget = config.Budget
+	v.view.Translucency = config.Translucency
+	v.view.Reveal = config.Reveal
+	v.adaptive.setEnabled(config.Adaptive)
+	registerJSAPI()
+	setupKeyboardHandlers()
+	v.hud.setVisible(config.Coords)
+	v.emitDatasetLoaded(obj)
+	if config.Panel {
+		controlPanel = newPanel()
+	}
+	if config.Benchmark {
+		v.benchmarkReport(v.gl, v.pointShader)
+	}
 
-	numPoints := 5000
-	redCoords, redColors := generateNormalCluster(numPoints, glf32.Vec3{0.5, 0.5, 0.5}, 0.2, glf32.Vec3{1, 0, 0})
-	greenCoords, greenColors := generateNormalCluster(numPoints, glf32.Vec3{-0.5, -0.5, 0.5}, 0.2, glf32.Vec3{0, 1, 0})
-	blueCoords, blueColors := generateNormalCluster(numPoints, glf32.Vec3{0.0, 0.5, -0.5}, 0.2, glf32.Vec3{0, 0, 1})
-	redPosVBO, redColorVBO := createVBO(gl, redCoords), createVBO(gl, redColors)
-	greenPosVBO, greenColorVBO := createVBO(gl, greenCoords), createVBO(gl, greenColors)
-	bluePosVBO, blueColorVBO := createVBO(gl, blueCoords), createVBO(gl, blueColors)
-
-	axisCoords, axisColors := generateAxes(1.5)
-	gridCoords, gridColors := generateGrid(1.5, 10)
-	axisPosVBO, axisColorVBO := createVBO(gl, axisCoords), createVBO(gl, axisColors)
-	gridPosVBO, gridColorVBO := createVBO(gl, gridCoords), createVBO(gl, gridColors)
-	numAxisVertices := len(axisCoords) / 3
-	numGridVertices := len(gridCoords) / 3
-
-	var renderFrame js.Func
-	renderFrame = js.FuncOf(func(this js.Value, args []js.Value) interface{} {
-		camera.ApplyInertia()
-		aspect := float32(canvas.Get("width").Float() / canvas.Get("height").Float())
-		projMatrix := glf32.Perspective(45.0, aspect, 0.1, 100.0)
-		viewMatrix := camera.GetViewMatrix()
-		mvpMatrix := glf32.MultiplyMatrices(projMatrix, viewMatrix)
-
-		gl.Call("clear", gl.Get("COLOR_BUFFER_BIT").Int()|gl.Get("DEPTH_BUFFER_BIT").Int())
-
-		gl.Call("useProgram", lineProgram)
-		gl.Call("uniformMatrix4fv", lineMvpLoc, false, sliceToJsFloat32Array(mvpMatrix[:]))
-		gl.Call("enableVertexAttribArray", posLoc)
-		gl.Call("enableVertexAttribArray", colorLoc)
-		drawObject(gl, posLoc, colorLoc, gridPosVBO, gridColorVBO, gl.Get("LINES"), numGridVertices)
-		drawObject(gl, posLoc, colorLoc, axisPosVBO, axisColorVBO, gl.Get("LINES"), numAxisVertices)
-
-		gl.Call("useProgram", pointProgram)
-		gl.Call("uniformMatrix4fv", pointMvpLoc, false, sliceToJsFloat32Array(mvpMatrix[:]))
-		gl.Call("enableVertexAttribArray", posLoc)
-		gl.Call("enableVertexAttribArray", colorLoc)
-		drawObject(gl, posLoc, colorLoc, redPosVBO, redColorVBO, gl.Get("POINTS"), numPoints)
-		drawObject(gl, posLoc, colorLoc, greenPosVBO, greenColorVBO, gl.Get("POINTS"), numPoints)
-		drawObject(gl, posLoc, colorLoc, bluePosVBO, blueColorVBO, gl.Get("POINTS"), numPoints)
+	setupRenderOnDemand()
+	v.setupViewLink(config.Link)
+	v.setupEmbed(config.Origin)
+	v.onDemand.Enabled = config.OnDemand
+	setupVisibility()
+	endLoading(startupLoad)
+	v.start()
+}
 
-		js.Global().Call("requestAnimationFrame", renderFrame)
-		return nil
-	})
-	js.Global().Call("requestAnimationFrame", renderFrame)
+// PointShader is the point program and the locations of its uniforms and
+// active attributes. Point cloud attributes are matched to the attributes
+// by name when drawing; see Scene.DrawPoints.
+type PointShader struct {
+	program         js.Value
+	attributes      map[string]int
+	mvpLoc          js.Value
+	pointSizeLoc    js.Value
+	opacityLoc      js.Value
+	tintLoc         js.Value
+	colorLoc        js.Value
+	sizeLoc         js.Value
+	roundLoc        js.Value
+	litLoc          js.Value
+	revealLoc       js.Value
+	colorModeLoc    js.Value
+	classColorsLoc  js.Value
+	classVisibleLoc js.Value
+	scalarRangeLoc  js.Value
+	rampLoc         js.Value
+	rampStopsLoc    js.Value
+	segmentsLoc     js.Value
+	modelLoc        js.Value
+	light           lightLocations
+	positionsLoc    js.Value
+	positionsTexLoc js.Value
+	frameBlock      bool // reads the view and the light from the frame block
 }
 
-func setupPointShaders(gl js.Value) (program, mvpLoc, posLoc, colorLoc js.Value, err error) {
-	pointSize := 2.0
-	vertShader := `attribute vec4 aPosition; attribute vec4 aColor; uniform mat4 uMvpMatrix; varying vec4 vColor; void main() { gl_Position = uMvpMatrix * aPosition; gl_PointSize = ` + fmt.Sprintf("%.1f", pointSize) + `; vColor = aColor; }`
-	fragShader := `precision mediump float; varying vec4 vColor; void main() { gl_FragColor = vColor; }`
+// setupPointShaders compiles the point program with the base features,
+// the one drawn with when a variant fails.
+func (v *Viewer) setupPointShaders(gl js.Value) (*PointShader, error) {
+	return v.shaders.point(gl, "point.frag", v.baseFeatures())
+}
 
-	program, err = createShaderProgram(gl, vertShader, fragShader)
+// newPointShader links point.vert, with the features, and the fragment
+// shader file fragment. Use shaderCache.point to reuse programs.
+func newPointShader(gl js.Value, fragment string, features ShaderFeatures) (*PointShader, error) {
+	es3 := features&FeatureFrameBlock != 0
+	vertShader, err := buildShader("point.vert", es3, features.defines())
 	if err != nil {
-		return js.Null(), js.Null(), js.Null(), js.Null(), err
+		return nil, err
+	}
+	fragShader, err := buildShader(fragment, es3, nil)
+	if err != nil {
+		return nil, err
+	}
+	program, err := createShaderProgram(gl, vertShader.Text, fragShader.Text)
+	if err != nil {
+		return nil, remapShaderError(err, vertShader, fragShader)
+	}
+	if es3 {
+		bindFrameBlock(gl, program)
 	}
 
-	posLoc = gl.Call("getAttribLocation", program, "aPosition")
-	colorLoc = gl.Call("getAttribLocation", program, "aColor")
-	mvpLoc = gl.Call("getUniformLocation", program, "uMvpMatrix")
-	return
+	return &PointShader{
+		program:         program,
+		attributes:      activeAttributes(gl, program),
+		mvpLoc:          gl.Call("getUniformLocation", program, "uMvpMatrix"),
+		pointSizeLoc:    gl.Call("getUniformLocation", program, "uPointSize"),
+		opacityLoc:      gl.Call("getUniformLocation", program, "uOpacity"),
+		tintLoc:         gl.Call("getUniformLocation", program, "uTint"),
+		colorLoc:        gl.Call("getUniformLocation", program, "uObjectColor"),
+		sizeLoc:         gl.Call("getUniformLocation", program, "uObjectSize"),
+		roundLoc:        gl.Call("getUniformLocation", program, "uRound"),
+		litLoc:          gl.Call("getUniformLocation", program, "uLit"),
+		revealLoc:       gl.Call("getUniformLocation", program, "uRevealChunks"),
+		colorModeLoc:    gl.Call("getUniformLocation", program, "uColorMode"),
+		classColorsLoc:  gl.Call("getUniformLocation", program, "uClassColors"),
+		classVisibleLoc: gl.Call("getUniformLocation", program, "uClassVisible"),
+		scalarRangeLoc:  gl.Call("getUniformLocation", program, "uScalarRange"),
+		rampLoc:         gl.Call("getUniformLocation", program, "uRamp"),
+		rampStopsLoc:    gl.Call("getUniformLocation", program, "uRampStops"),
+		segmentsLoc:     gl.Call("getUniformLocation", program, "uSegmentColors"),
+		modelLoc:        gl.Call("getUniformLocation", program, "uModelMatrix"),
+		light:           lightUniforms(gl, program),
+		positionsLoc:    gl.Call("getUniformLocation", program, "uPositionsSize"),
+		positionsTexLoc: gl.Call("getUniformLocation", program, "uPositions"),
+		frameBlock:      es3,
+	}, nil
 }
 
 func setupLineShaders(gl js.Value) (program, mvpLoc js.Value, err error) {
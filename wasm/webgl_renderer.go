@@ -0,0 +1,112 @@
+// wasm/webgl_renderer.go
+package main
+
+import (
+	"syscall/js"
+
+	"github.com/sbecker11/webgl-point-cloud/glf32"
+	"github.com/sbecker11/webgl-point-cloud/render"
+)
+
+// WebGLRenderer is the WebGL backend of render.Renderer. It draws with the
+// program chosen by use, looking up and caching its uniform and attribute
+// locations by name.
+type WebGLRenderer struct {
+	gl       js.Value
+	program  *programLocations
+	programs []*programLocations
+	buffers  map[render.Buffer]js.Value
+	next     render.Buffer
+}
+
+// programLocations caches the locations of a program's uniforms and
+// attributes.
+type programLocations struct {
+	program  js.Value
+	uniforms map[string]js.Value
+	attribs  map[string]int
+}
+
+func newWebGLRenderer(gl js.Value) *WebGLRenderer {
+	return &WebGLRenderer{gl: gl, buffers: map[render.Buffer]js.Value{}}
+}
+
+// use makes program the one later calls set uniforms of and draw with.
+func (r *WebGLRenderer) use(program js.Value) {
+	r.gl.Call("useProgram", program)
+	for _, p := range r.programs {
+		if p.program.Equal(program) {
+			r.program = p
+			return
+		}
+	}
+	r.program = &programLocations{program: program, uniforms: map[string]js.Value{}, attribs: map[string]int{}}
+	r.programs = append(r.programs, r.program)
+}
+
+func (r *WebGLRenderer) CreateBuffer(data []float32) render.Buffer {
+	r.next++
+	r.buffers[r.next] = r.gl.Call("createBuffer")
+	r.UpdateBuffer(r.next, data)
+	return r.next
+}
+
+func (r *WebGLRenderer) UpdateBuffer(b render.Buffer, data []float32) {
+	gl := r.gl
+	gl.Call("bindBuffer", gl.Get("ARRAY_BUFFER"), r.buffers[b])
+	gl.Call("bufferData", gl.Get("ARRAY_BUFFER"), glf32.ToFloat32Array(data), gl.Get("DYNAMIC_DRAW"))
+}
+
+func (r *WebGLRenderer) DeleteBuffer(b render.Buffer) {
+	if buf, ok := r.buffers[b]; ok {
+		r.gl.Call("deleteBuffer", buf)
+		delete(r.buffers, b)
+	}
+}
+
+func (r *WebGLRenderer) SetUniform(name string, value []float32) {
+	gl, p := r.gl, r.program
+	loc, ok := p.uniforms[name]
+	if !ok {
+		loc = gl.Call("getUniformLocation", p.program, name)
+		p.uniforms[name] = loc
+	}
+	if loc.IsNull() {
+		return
+	}
+	switch len(value) {
+	case 1:
+		gl.Call("uniform1f", loc, value[0])
+	case 2:
+		gl.Call("uniform2fv", loc, glf32.ToFloat32Array(value))
+	case 3:
+		gl.Call("uniform3fv", loc, glf32.ToFloat32Array(value))
+	case 4:
+		gl.Call("uniform4fv", loc, glf32.ToFloat32Array(value))
+	case 16:
+		gl.Call("uniformMatrix4fv", loc, false, glf32.ToFloat32Array(value))
+	default:
+		gl.Call("uniform1fv", loc, glf32.ToFloat32Array(value))
+	}
+}
+
+// Draw enables and feeds the attributes the program reads, leaving them
+// enabled, and draws.
+func (r *WebGLRenderer) Draw(prim render.Primitive, attribs []render.VertexAttrib, count int) {
+	gl, p := r.gl, r.program
+	for _, a := range attribs {
+		loc, ok := p.attribs[a.Name]
+		if !ok {
+			loc = gl.Call("getAttribLocation", p.program, a.Name).Int()
+			p.attribs[a.Name] = loc
+		}
+		if loc < 0 {
+			continue
+		}
+		gl.Call("enableVertexAttribArray", loc)
+		gl.Call("bindBuffer", gl.Get("ARRAY_BUFFER"), r.buffers[a.Buffer])
+		gl.Call("vertexAttribPointer", loc, a.Components, gl.Get("FLOAT"), false, 0, 0)
+	}
+	mode := map[render.Primitive]string{render.Points: "POINTS", render.Lines: "LINES", render.Triangles: "TRIANGLES"}[prim]
+	gl.Call("drawArrays", gl.Get(mode), 0, count)
+}
@@ -3,37 +3,16 @@ package main
 
 import (
 	"fmt"
-	"reflect"
+	"strings"
 	"syscall/js"
-	"unsafe"
-)
-
-// sliceToJsFloat32Array converts a Go slice to a JavaScript Float32Array by
-// copying the data. This is a safer approach than creating a view, as it
-// prevents "detached ArrayBuffer" errors if the Go WASM memory is resized.
-func sliceToJsFloat32Array(slice []float32) js.Value {
-	// Create a new JavaScript ArrayBuffer of the required size.
-	jsArray := js.Global().Get("Uint8Array").New(len(slice) * 4)
-
-	// Create a Go byte slice that views the same memory as the float32 slice
-	header := (*reflect.SliceHeader)(unsafe.Pointer(&slice))
-	header.Len *= 4
-	header.Cap *= 4
-	byteSlice := *(*[]byte)(unsafe.Pointer(header))
-
-	// Copy the data from Go to JavaScript.
-	js.CopyBytesToJS(jsArray, byteSlice)
+	"unicode"
 
-	// Restore the slice header to its original state to avoid memory corruption.
-	header.Len /= 4
-	header.Cap /= 4
-
-	// Create a Float32Array view on the new buffer.
-	return js.Global().Get("Float32Array").New(jsArray.Get("buffer"))
-}
+	"github.com/sbecker11/webgl-point-cloud/glf32"
+	"github.com/sbecker11/webgl-point-cloud/pointcloud"
+)
 
 // drawObject is a helper function that encapsulates the WebGL calls needed to draw a single object.
-func drawObject(gl, positionLoc, colorLoc, posBuf, colorBuf, drawMode js.Value, vertexCount int) {
+func drawObject(gl js.Value, positionLoc, colorLoc int, posBuf, colorBuf, drawMode js.Value, vertexCount int) {
 	// Bind position buffer
 	gl.Call("bindBuffer", gl.Get("ARRAY_BUFFER"), posBuf)
 	gl.Call("vertexAttribPointer", positionLoc, 3, gl.Get("FLOAT"), false, 0, 0)
@@ -48,21 +27,190 @@ func drawObject(gl, positionLoc, colorLoc, posBuf, colorBuf, drawMode js.Value,
 
 // createVBO is a helper function to create a Vertex Buffer Object
 func createVBO(gl js.Value, data []float32) js.Value {
+	requestRender()
+	buffer := gl.Call("createBuffer")
+	gl.Call("bindBuffer", gl.Get("ARRAY_BUFFER"), buffer)
+	gl.Call("bufferData", gl.Get("ARRAY_BUFFER"), glf32.ToFloat32Array(data), gl.Get("STATIC_DRAW"))
+	return buffer
+}
+
+// IndexBuffer is an element array buffer of vertex indices, letting draws
+// reuse vertex buffers instead of duplicating vertex data.
+type IndexBuffer struct {
+	buffer js.Value
+	count  int
+	typ    js.Value // UNSIGNED_SHORT or UNSIGNED_INT
+}
+
+// createIndexBuffer uploads indices as 16-bit values when they all fit and
+// as 32-bit ones otherwise, which WebGL1 supports only with the
+// OES_element_index_uint extension (see Capabilities). An empty list makes a buffer that
+// draws nothing.
+func (v *Viewer) createIndexBuffer(gl js.Value, indices []uint32) (*IndexBuffer, error) {
+	requestRender()
+	b := &IndexBuffer{count: len(indices), typ: gl.Get("UNSIGNED_SHORT")}
+	if len(indices) == 0 {
+		return b, nil
+	}
+	var max uint32
+	for _, i := range indices {
+		if i > max {
+			max = i
+		}
+	}
+	if max <= 0xffff {
+		short := make([]uint16, len(indices))
+		for i, value := range indices {
+			short[i] = uint16(value)
+		}
+		b.buffer = glf32.UploadSliceToGL(gl, short, "ELEMENT_ARRAY_BUFFER", gl.Get("STATIC_DRAW"))
+		return b, nil
+	}
+	if !v.caps.ElementIndexUint {
+		return nil, fmt.Errorf("index %d needs 32-bit indices, which this browser does not support", max)
+	}
+	b.typ = gl.Get("UNSIGNED_INT")
+	b.buffer = glf32.UploadSliceToGL(gl, indices, "ELEMENT_ARRAY_BUFFER", gl.Get("STATIC_DRAW"))
+	return b, nil
+}
+
+// draw draws the first count indexed vertices as primitives of the given
+// mode from the vertex buffers currently bound to the attributes.
+func (b *IndexBuffer) draw(gl js.Value, mode js.Value, count int) {
+	b.drawRange(gl, mode, 0, count)
+}
+
+// drawRange draws count indexed vertices from the first'th index on, as
+// draw does.
+func (b *IndexBuffer) drawRange(gl js.Value, mode js.Value, first, count int) {
+	if count > b.count-first {
+		count = b.count - first
+	}
+	if count <= 0 {
+		return
+	}
+	size := 2
+	if b.typ.Equal(gl.Get("UNSIGNED_INT")) {
+		size = 4
+	}
+	gl.Call("bindBuffer", gl.Get("ELEMENT_ARRAY_BUFFER"), b.buffer)
+	gl.Call("drawElements", mode, count, b.typ, first*size)
+}
+
+// delete frees the buffer.
+func (b *IndexBuffer) delete(gl js.Value) {
+	if b.buffer.Truthy() {
+		gl.Call("deleteBuffer", b.buffer)
+	}
+}
+
+// createAttributeVBO creates a Vertex Buffer Object holding values in the
+// attribute's storage type.
+func createAttributeVBO(gl js.Value, a pointcloud.Attribute, values []float32) js.Value {
+	if a.Type == pointcloud.Float32 {
+		return createVBO(gl, values)
+	}
+	var jsArray js.Value
+	if a.Type == pointcloud.Uint8 {
+		data := make([]uint8, len(values))
+		for i, value := range values {
+			data[i] = uint8(value)
+		}
+		jsArray = glf32.ToUint8Array(data)
+	} else {
+		data := make([]uint16, len(values))
+		for i, value := range values {
+			data[i] = uint16(value)
+		}
+		jsArray = glf32.ToUint16Array(data)
+	}
+	requestRender()
 	buffer := gl.Call("createBuffer")
 	gl.Call("bindBuffer", gl.Get("ARRAY_BUFFER"), buffer)
-	jsArray := sliceToJsFloat32Array(data)
 	gl.Call("bufferData", gl.Get("ARRAY_BUFFER"), jsArray, gl.Get("STATIC_DRAW"))
 	return buffer
 }
 
+// glAttributeType returns the WebGL component type for an attribute type.
+func glAttributeType(gl js.Value, t pointcloud.AttributeType) js.Value {
+	switch t {
+	case pointcloud.Uint8:
+		return gl.Get("UNSIGNED_BYTE")
+	case pointcloud.Uint16:
+		return gl.Get("UNSIGNED_SHORT")
+	}
+	return gl.Get("FLOAT")
+}
+
+// shaderAttributeName returns the GLSL attribute a point cloud attribute
+// feeds: "a" followed by the name in camel case, so "position" feeds
+// aPosition and "return_number" feeds aReturnNumber.
+func shaderAttributeName(name string) string {
+	var b strings.Builder
+	b.WriteString("a")
+	upper := true
+	for _, r := range name {
+		if r == '_' {
+			upper = true
+			continue
+		}
+		if upper {
+			r = unicode.ToUpper(r)
+			upper = false
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// activeAttributes returns the locations of a linked program's active
+// attributes, keyed by GLSL name.
+func activeAttributes(gl js.Value, program js.Value) map[string]int {
+	attributes := map[string]int{}
+	n := gl.Call("getProgramParameter", program, gl.Get("ACTIVE_ATTRIBUTES")).Int()
+	for i := 0; i < n; i++ {
+		name := gl.Call("getActiveAttrib", program, i).Get("name").String()
+		attributes[name] = gl.Call("getAttribLocation", program, name).Int()
+	}
+	return attributes
+}
+
+// Fixed attribute locations shared by every program, so that vertex buffers
+// can be bound the same way whichever program is in use.
+const (
+	attribPosition = 0
+	attribColor    = 1
+	attribClass    = 2
+	attribVisible  = 3
+	attribSelected = 4
+)
+
+// shaderError is a failure to compile or link a program, with the
+// compiler's log.
+type shaderError struct {
+	stage string // "vertex", "fragment" or "link"
+	log   string
+}
+
+func (e *shaderError) Error() string {
+	if e.stage == "link" {
+		return "shader link error: " + e.log
+	}
+	return e.stage + " shader compile error: " + e.log
+}
+
 // createShaderProgram compiles and links the vertex and fragment shaders.
-func createShaderProgram(gl js.Value, vertSrc, fragSrc string) (js.Value, error) {
+// The aPosition, aColor, aClass, aVisible and aSelected attributes, where
+// declared, are bound to the fixed attrib* locations. The vertex shader
+// outputs named by varyings, if any, are captured by transform feedback,
+// each in a buffer of its own. Failures are *shaderError.
+func createShaderProgram(gl js.Value, vertSrc, fragSrc string, varyings ...string) (js.Value, error) {
 	vertShader := gl.Call("createShader", gl.Get("VERTEX_SHADER"))
 	gl.Call("shaderSource", vertShader, vertSrc)
 	gl.Call("compileShader", vertShader)
 	if !gl.Call("getShaderParameter", vertShader, gl.Get("COMPILE_STATUS")).Bool() {
 		log := gl.Call("getShaderInfoLog", vertShader).String()
-		return js.Null(), fmt.Errorf("vertex shader compile error: %s", log)
+		return js.Null(), &shaderError{"vertex", log}
 	}
 
 	fragShader := gl.Call("createShader", gl.Get("FRAGMENT_SHADER"))
@@ -70,16 +218,28 @@ func createShaderProgram(gl js.Value, vertSrc, fragSrc string) (js.Value, error)
 	gl.Call("compileShader", fragShader)
 	if !gl.Call("getShaderParameter", fragShader, gl.Get("COMPILE_STATUS")).Bool() {
 		log := gl.Call("getShaderInfoLog", fragShader).String()
-		return js.Null(), fmt.Errorf("fragment shader compile error: %s", log)
+		return js.Null(), &shaderError{"fragment", log}
 	}
 
 	p := gl.Call("createProgram")
 	gl.Call("attachShader", p, vertShader)
 	gl.Call("attachShader", p, fragShader)
+	gl.Call("bindAttribLocation", p, attribPosition, "aPosition")
+	gl.Call("bindAttribLocation", p, attribColor, "aColor")
+	gl.Call("bindAttribLocation", p, attribClass, "aClass")
+	gl.Call("bindAttribLocation", p, attribVisible, "aVisible")
+	gl.Call("bindAttribLocation", p, attribSelected, "aSelected")
+	if len(varyings) > 0 {
+		names := make([]interface{}, len(varyings))
+		for i, v := range varyings {
+			names[i] = v
+		}
+		gl.Call("transformFeedbackVaryings", p, names, gl.Get("SEPARATE_ATTRIBS"))
+	}
 	gl.Call("linkProgram", p)
 	if !gl.Call("getProgramParameter", p, gl.Get("LINK_STATUS")).Bool() {
 		log := gl.Call("getProgramInfoLog", p).String()
-		return js.Null(), fmt.Errorf("shader link error: %s", log)
+		return js.Null(), &shaderError{"link", log}
 	}
 	return p, nil
 } 
\ No newline at end of file